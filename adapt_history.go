@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/codewandler/llm/msg"
+)
+
+// maxPortableIDLen is the longest tool call ID every supported API accepts (OpenAI Chat Completions limits tool call IDs to 40 bytes).
+const maxPortableIDLen = 40
+
+// AdaptHistory rewrites provider-specific artifacts in msgs so a conversation
+// recorded on provider from can continue on provider to. Provider names are
+// the ProviderName* constants.
+//
+// When the providers belong to different API families:
+//   - thinking parts are dropped, because reasoning signatures only verify
+//     with the provider that issued them; assistant messages left empty are
+//     removed
+//   - tool call IDs outside [a-zA-Z0-9_-] or longer than 40 bytes are
+//     rewritten, consistently across calls and results
+//
+// Tool names are left alone: they must keep matching the tool definitions
+// of the request.
+//
+// msgs is not modified. Within the same family the messages are returned
+// unchanged.
+func AdaptHistory(msgs Messages, from, to string) Messages {
+	if providerFamily(from) == providerFamily(to) {
+		return msgs
+	}
+	ids := make(map[string]string)
+	out := make(Messages, 0, len(msgs))
+	for _, m := range msgs {
+		parts := make(msg.Parts, 0, len(m.Parts))
+		for _, p := range m.Parts {
+			switch {
+			case p.Type == msg.PartTypeThinking:
+				continue
+			case p.ToolCall != nil:
+				tc := *p.ToolCall
+				tc.ID = portableID(ids, tc.ID)
+				p.ToolCall = &tc
+			case p.ToolResult != nil:
+				tr := *p.ToolResult
+				tr.ToolCallID = portableID(ids, tr.ToolCallID)
+				p.ToolResult = &tr
+			}
+			parts = append(parts, p)
+		}
+		if m.IsAssistant() && len(parts) == 0 {
+			continue
+		}
+		m.Parts = parts
+		out = append(out, m)
+	}
+	return out
+}
+
+// providerFamily groups providers that share reasoning signatures and ID
+// formats.
+func providerFamily(provider string) string {
+	switch provider {
+	case ProviderNameAnthropic, ProviderNameClaude:
+		return ProviderNameAnthropic
+	case ProviderNameOpenAI, ProviderNameCodex:
+		return ProviderNameOpenAI
+	default:
+		return provider
+	}
+}
+
+// portableID returns id if every API accepts it, otherwise a sanitized ID
+// with a hash suffix. seen keeps the mapping stable across a conversation.
+func portableID(seen map[string]string, id string) string {
+	if out, ok := seen[id]; ok {
+		return out
+	}
+	out := id
+	if !isPortable(id) {
+		sum := sha256.Sum256([]byte(id))
+		suffix := "_" + hex.EncodeToString(sum[:])[:8]
+		out = sanitize(id, maxPortableIDLen-len(suffix)) + suffix
+	}
+	seen[id] = out
+	return out
+}
+
+func isPortable(s string) bool {
+	if s == "" || len(s) > maxPortableIDLen {
+		return false
+	}
+	for _, r := range s {
+		if !isPortableRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPortableRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// sanitize replaces characters outside [a-zA-Z0-9_-] with '_' and truncates
+// the result to max bytes.
+func sanitize(s string, max int) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if sb.Len() >= max {
+			break
+		}
+		if isPortableRune(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package llm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+)
+
+func TestAdaptHistory_DropsThinkingAcrossFamilies(t *testing.T) {
+	in := llm.Messages{
+		llm.User("hi"),
+		msg.Assistant(msg.Thinking("let me think", "sig-abc")).Build(),
+		msg.Assistant(msg.Thinking("more", "sig-def"), msg.Text("hello")).Build(),
+	}
+
+	out := llm.AdaptHistory(in, llm.ProviderNameAnthropic, llm.ProviderNameOpenAI)
+
+	require.Len(t, out, 2)
+	assert.Equal(t, "hi", out[0].Text())
+	require.Len(t, out[1].Parts, 1)
+	assert.Equal(t, msg.PartTypeText, out[1].Parts[0].Type)
+	assert.Equal(t, "hello", out[1].Text())
+	// input is untouched
+	assert.Len(t, in[2].Parts, 2)
+}
+
+func TestAdaptHistory_SameFamilyUnchanged(t *testing.T) {
+	in := llm.Messages{
+		msg.Assistant(msg.Thinking("thought", "sig")).Build(),
+		msg.Assistant(msg.ToolCall(msg.NewToolCall("toolu_01", "search", nil))).Build(),
+	}
+
+	assert.Equal(t, in, llm.AdaptHistory(in, llm.ProviderNameClaude, llm.ProviderNameAnthropic))
+	assert.Equal(t, in, llm.AdaptHistory(in, llm.ProviderNameCodex, llm.ProviderNameOpenAI))
+}
+
+func TestAdaptHistory_RewritesToolIDsConsistently(t *testing.T) {
+	longID := "fc_" + strings.Repeat("a", 60)
+	in := llm.Messages{
+		msg.Assistant(
+			msg.ToolCall(msg.NewToolCall("call|1", "search", msg.ToolArgs{"q": "go"})),
+			msg.ToolCall(msg.NewToolCall(longID, "read.file", nil)),
+			msg.ToolCall(msg.NewToolCall("call_ok", "search", nil)),
+		).Build(),
+		msg.Tool().Results(msg.ToolResults{
+			{ToolCallID: "call|1", ToolOutput: "r1"},
+			{ToolCallID: longID, ToolOutput: "r2"},
+			{ToolCallID: "call_ok", ToolOutput: "r3"},
+		}).Build(),
+	}
+
+	out := llm.AdaptHistory(in, llm.ProviderNameOpenRouter, llm.ProviderNameBedrock)
+
+	calls := out[0].ToolCalls()
+	results := out[1].ToolResults()
+	require.Len(t, calls, 3)
+	require.Len(t, results, 3)
+	for i := range calls {
+		assert.Equal(t, calls[i].ID, results[i].ToolCallID)
+		assert.Regexp(t, `^[a-zA-Z0-9_-]{1,40}$`, calls[i].ID)
+	}
+	assert.True(t, strings.HasPrefix(calls[0].ID, "call_1_"))
+	assert.Equal(t, "read.file", calls[1].Name)
+	assert.Equal(t, "call_ok", calls[2].ID)
+	assert.Equal(t, "go", calls[0].Args["q"])
+
+	// input is untouched
+	assert.Equal(t, "call|1", in[0].ToolCalls()[0].ID)
+}
+
+func TestAdaptHistory_KeepsLongToolNames(t *testing.T) {
+	name := strings.Repeat("n", 50)
+	in := llm.Messages{
+		msg.Assistant(msg.ToolCall(msg.NewToolCall("call_1", name, nil))).Build(),
+	}
+
+	out := llm.AdaptHistory(in, llm.ProviderNameAnthropic, llm.ProviderNameOpenAI)
+
+	require.Len(t, out[0].ToolCalls(), 1)
+	assert.Equal(t, name, out[0].ToolCalls()[0].Name, "names must match the request's tool definitions")
+}
@@ -0,0 +1,232 @@
+// Package analytics flattens Run results into a tabular, analytics-friendly
+// schema and writes it as CSV, JSON Lines or Parquet, so conversations can be
+// loaded into a warehouse or dataframe without custom ETL.
+//
+// Each conversation becomes one row per message, plus one row per tool call
+// and per tool result. Usage, cost and timings are attached to the assistant
+// message row of the turn that produced it. The columns, in CSV order, are:
+//
+//	conversation_id     caller-supplied conversation identifier
+//	seq                 row position within the conversation, from 0
+//	message_index       index of the message in RunResult.Messages
+//	turn                1-based turn that produced the message; 0 for input
+//	kind                "message", "tool_call" or "tool_result"
+//	role                message role: system, user, assistant, tool, ...
+//	text                message text, or the tool result output
+//	tool_call_id        tool call ID (tool_call and tool_result rows)
+//	tool_name           tool name (tool_call and tool_result rows)
+//	tool_args           tool arguments as JSON (tool_call rows)
+//	tool_error          whether the tool result is an error (tool_result rows)
+//	model               model reported for the turn
+//	provider            provider reported for the turn
+//	stop_reason         stop reason of the turn
+//	input_tokens        non-cached input tokens of the turn
+//	output_tokens       output tokens of the turn, excluding reasoning
+//	reasoning_tokens    reasoning tokens of the turn
+//	cache_read_tokens   input tokens read from the prompt cache
+//	cache_write_tokens  input tokens written to the prompt cache
+//	cost_usd            cost of the turn in USD
+//	started_at          turn start time, RFC 3339 with nanoseconds
+//	ttft_ms             time to first token of the turn in milliseconds
+//	duration_ms         turn duration in milliseconds
+//
+// Turn columns are empty or zero on rows that are not the assistant message
+// of a turn.
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/usage"
+)
+
+// Row kinds.
+const (
+	KindMessage    = "message"
+	KindToolCall   = "tool_call"
+	KindToolResult = "tool_result"
+)
+
+// Columns lists the CSV header in order. See the package documentation for
+// their meaning.
+var Columns = []string{
+	"conversation_id", "seq", "message_index", "turn", "kind", "role", "text",
+	"tool_call_id", "tool_name", "tool_args", "tool_error",
+	"model", "provider", "stop_reason",
+	"input_tokens", "output_tokens", "reasoning_tokens", "cache_read_tokens", "cache_write_tokens",
+	"cost_usd", "started_at", "ttft_ms", "duration_ms",
+}
+
+// Row is one record of the export schema.
+type Row struct {
+	ConversationID string `json:"conversation_id"`
+	Seq            int    `json:"seq"`
+	MessageIndex   int    `json:"message_index"`
+	Turn           int    `json:"turn"`
+	Kind           string `json:"kind"`
+	Role           string `json:"role"`
+	Text           string `json:"text,omitempty"`
+
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+	ToolArgs   string `json:"tool_args,omitempty"`
+	ToolError  bool   `json:"tool_error,omitempty"`
+
+	Model      string `json:"model,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+
+	InputTokens      int     `json:"input_tokens,omitempty"`
+	OutputTokens     int     `json:"output_tokens,omitempty"`
+	ReasoningTokens  int     `json:"reasoning_tokens,omitempty"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	CostUSD          float64 `json:"cost_usd,omitempty"`
+
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	TTFTMs     int64     `json:"ttft_ms,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+}
+
+// FromRun flattens the result of llm.Run into rows. Tool names on result
+// rows are looked up from the matching tool call.
+func FromRun(conversationID string, res *llm.RunResult) []Row {
+	if res == nil {
+		return nil
+	}
+	turns := make(map[int]int, len(res.TurnDetails)) // message index -> turn
+	for i, t := range res.TurnDetails {
+		turns[t.Message] = i + 1
+	}
+	toolNames := map[string]string{}
+
+	var rows []Row
+	add := func(r Row) {
+		r.ConversationID = conversationID
+		r.Seq = len(rows)
+		rows = append(rows, r)
+	}
+	for i, m := range res.Messages {
+		row := Row{MessageIndex: i, Kind: KindMessage, Role: string(m.Role), Text: m.Text()}
+		if n, ok := turns[i]; ok {
+			row.Turn = n
+			applyTurn(&row, res.TurnDetails[n-1])
+		}
+		if m.IsTool() {
+			// The tool result rows carry the content.
+			row.Text = ""
+		}
+		add(row)
+
+		for _, tc := range m.ToolCalls() {
+			toolNames[tc.ID] = tc.Name
+			add(Row{MessageIndex: i, Turn: row.Turn, Kind: KindToolCall, Role: string(m.Role), ToolCallID: tc.ID, ToolName: tc.Name, ToolArgs: toolArgs(tc)})
+		}
+		for _, tr := range m.ToolResults() {
+			add(Row{MessageIndex: i, Kind: KindToolResult, Role: string(m.Role), Text: tr.ToolOutput, ToolCallID: tr.ToolCallID, ToolName: toolNames[tr.ToolCallID], ToolError: tr.IsError})
+		}
+	}
+	return rows
+}
+
+func applyTurn(r *Row, t llm.RunTurn) {
+	r.Model = t.Model
+	r.Provider = t.Provider
+	r.StopReason = string(t.StopReason)
+	r.StartedAt = t.StartedAt
+	r.TTFTMs = t.TimeToFirstToken.Milliseconds()
+	r.DurationMs = t.Duration.Milliseconds()
+	for _, rec := range t.Usage {
+		if rec.IsEstimate {
+			continue
+		}
+		if r.Model == "" {
+			r.Model = rec.Dims.Model
+		}
+		if r.Provider == "" {
+			r.Provider = rec.Dims.Provider
+		}
+		r.InputTokens += rec.Tokens.Count(usage.KindInput)
+		r.OutputTokens += rec.Tokens.Count(usage.KindOutput)
+		r.ReasoningTokens += rec.Tokens.Count(usage.KindReasoning)
+		r.CacheReadTokens += rec.Tokens.Count(usage.KindCacheRead)
+		r.CacheWriteTokens += rec.Tokens.Count(usage.KindCacheWrite)
+		r.CostUSD += rec.Cost.Total
+	}
+}
+
+func toolArgs(tc msg.ToolCall) string {
+	if len(tc.Args) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(tc.Args)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// WriteCSV writes rows with a Columns header.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.record()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSONL writes rows as one JSON object per line.
+func WriteJSONL(w io.Writer, rows []Row) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record returns r's CSV fields in Columns order.
+func (r Row) record() []string {
+	startedAt := ""
+	if !r.StartedAt.IsZero() {
+		startedAt = r.StartedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return []string{
+		r.ConversationID,
+		strconv.Itoa(r.Seq),
+		strconv.Itoa(r.MessageIndex),
+		strconv.Itoa(r.Turn),
+		r.Kind,
+		r.Role,
+		r.Text,
+		r.ToolCallID,
+		r.ToolName,
+		r.ToolArgs,
+		strconv.FormatBool(r.ToolError),
+		r.Model,
+		r.Provider,
+		r.StopReason,
+		strconv.Itoa(r.InputTokens),
+		strconv.Itoa(r.OutputTokens),
+		strconv.Itoa(r.ReasoningTokens),
+		strconv.Itoa(r.CacheReadTokens),
+		strconv.Itoa(r.CacheWriteTokens),
+		strconv.FormatFloat(r.CostUSD, 'f', -1, 64),
+		startedAt,
+		strconv.FormatInt(r.TTFTMs, 10),
+		strconv.FormatInt(r.DurationMs, 10),
+	}
+}
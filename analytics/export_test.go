@@ -0,0 +1,122 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/usage"
+)
+
+func testRun() *llm.RunResult {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &llm.RunResult{
+		Messages: llm.Messages{
+			llm.System("be brief"),
+			llm.User("weather in Berlin?"),
+			msg.Assistant(msg.Text("checking"), msg.NewToolCall("call_1", "weather", msg.ToolArgs{"city": "Berlin"})).Build(),
+			msg.Tool().Results(msg.ToolResult{ToolCallID: "call_1", ToolOutput: "sunny"}).Build(),
+			llm.Assistant("It is sunny."),
+		},
+		Turns: 2,
+		TurnDetails: []llm.RunTurn{
+			{
+				Message: 2, Model: "m-1", Provider: "fake", StopReason: llm.StopReasonToolUse,
+				Usage: []usage.Record{{
+					Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 100}, {Kind: usage.KindOutput, Count: 10}, {Kind: usage.KindCacheRead, Count: 50}},
+					Cost:   usage.Cost{Total: 0.01},
+				}},
+				StartedAt: started, TimeToFirstToken: 150 * time.Millisecond, Duration: time.Second,
+			},
+			{Message: 4, Model: "m-1", Provider: "fake", StopReason: llm.StopReasonEndTurn, StartedAt: started.Add(2 * time.Second), Duration: 500 * time.Millisecond},
+		},
+	}
+}
+
+func TestFromRun(t *testing.T) {
+	rows := FromRun("conv-1", testRun())
+	require.Len(t, rows, 7)
+
+	kinds := make([]string, len(rows))
+	for i, r := range rows {
+		assert.Equal(t, "conv-1", r.ConversationID)
+		assert.Equal(t, i, r.Seq)
+		kinds[i] = r.Kind
+	}
+	assert.Equal(t, []string{KindMessage, KindMessage, KindMessage, KindToolCall, KindMessage, KindToolResult, KindMessage}, kinds)
+
+	assert.Zero(t, rows[0].Turn)
+	assert.Zero(t, rows[1].InputTokens)
+
+	first := rows[2]
+	assert.Equal(t, 1, first.Turn)
+	assert.Equal(t, "checking", first.Text)
+	assert.Equal(t, "m-1", first.Model)
+	assert.Equal(t, string(llm.StopReasonToolUse), first.StopReason)
+	assert.Equal(t, 100, first.InputTokens)
+	assert.Equal(t, 10, first.OutputTokens)
+	assert.Equal(t, 50, first.CacheReadTokens)
+	assert.InDelta(t, 0.01, first.CostUSD, 1e-9)
+	assert.Equal(t, int64(150), first.TTFTMs)
+	assert.Equal(t, int64(1000), first.DurationMs)
+
+	call := rows[3]
+	assert.Equal(t, 1, call.Turn)
+	assert.Equal(t, "weather", call.ToolName)
+	assert.JSONEq(t, `{"city":"Berlin"}`, call.ToolArgs)
+
+	result := rows[5]
+	assert.Equal(t, "call_1", result.ToolCallID)
+	assert.Equal(t, "weather", result.ToolName)
+	assert.Equal(t, "sunny", result.Text)
+	assert.Empty(t, rows[4].Text)
+
+	assert.Equal(t, 2, rows[6].Turn)
+	assert.Equal(t, "It is sunny.", rows[6].Text)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, FromRun("conv-1", testRun())))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 8)
+	assert.Equal(t, Columns, records[0])
+
+	col := func(name string) int {
+		for i, c := range Columns {
+			if c == name {
+				return i
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return -1
+	}
+	row := records[3]
+	assert.Equal(t, "1", row[col("turn")])
+	assert.Equal(t, "100", row[col("input_tokens")])
+	assert.Equal(t, "0.01", row[col("cost_usd")])
+	assert.Equal(t, "2026-01-02T03:04:05Z", row[col("started_at")])
+	assert.Empty(t, records[1][col("started_at")])
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONL(&buf, FromRun("conv-1", testRun())))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 7)
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "system", first["role"])
+	assert.NotContains(t, first, "started_at")
+}
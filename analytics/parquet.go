@@ -0,0 +1,324 @@
+package analytics
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// WriteParquet writes rows as a Parquet file with a single row group, so
+// exports load directly into DuckDB, pandas or Spark. Columns follow
+// Columns: text columns are UTF-8 strings, counts INT64, cost_usd DOUBLE
+// and tool_error BOOLEAN. started_at is a TIMESTAMP_MICROS column that is
+// null on rows without a turn; every other column is required. Values are
+// PLAIN encoded and uncompressed, which keeps the writer free of
+// dependencies.
+func WriteParquet(w io.Writer, rows []Row) error {
+	cw := &countingWriter{w: w}
+	cw.write([]byte(parquetMagic))
+	chunks := make([]parquetChunk, 0, len(parquetColumns))
+	for _, c := range parquetColumns {
+		data := c.encode(rows)
+		var hdr thriftWriter
+		hdr.begin()
+		hdr.i32(1, 0) // type: DATA_PAGE
+		hdr.i32(2, int32(len(data)))
+		hdr.i32(3, int32(len(data)))
+		hdr.structField(5, func() { // data_page_header
+			hdr.i32(1, int32(len(rows)))
+			hdr.i32(2, encodingPlain)
+			hdr.i32(3, encodingRLE)
+			hdr.i32(4, encodingRLE)
+		})
+		hdr.end()
+
+		offset := cw.n
+		cw.write(hdr.buf)
+		cw.write(data)
+		chunks = append(chunks, parquetChunk{column: c, offset: offset, size: cw.n - offset})
+	}
+
+	footer := fileMetaData(int64(len(rows)), chunks)
+	cw.write(footer)
+	cw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	cw.write([]byte(parquetMagic))
+	return cw.err
+}
+
+const parquetMagic = "PAR1"
+
+// Parquet enum values, see parquet.thrift.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	convertedNone            = -1
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+)
+
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+	optional  bool
+	encode    func(rows []Row) []byte
+}
+
+type parquetChunk struct {
+	column parquetColumn
+	offset int64
+	size   int64
+}
+
+// parquetColumns lists the columns in Columns order.
+var parquetColumns = []parquetColumn{
+	stringColumn("conversation_id", func(r Row) string { return r.ConversationID }),
+	int64Column("seq", func(r Row) int64 { return int64(r.Seq) }),
+	int64Column("message_index", func(r Row) int64 { return int64(r.MessageIndex) }),
+	int64Column("turn", func(r Row) int64 { return int64(r.Turn) }),
+	stringColumn("kind", func(r Row) string { return r.Kind }),
+	stringColumn("role", func(r Row) string { return r.Role }),
+	stringColumn("text", func(r Row) string { return r.Text }),
+	stringColumn("tool_call_id", func(r Row) string { return r.ToolCallID }),
+	stringColumn("tool_name", func(r Row) string { return r.ToolName }),
+	stringColumn("tool_args", func(r Row) string { return r.ToolArgs }),
+	{name: "tool_error", typ: typeBoolean, converted: convertedNone, encode: func(rows []Row) []byte {
+		out := make([]byte, (len(rows)+7)/8)
+		for i, r := range rows {
+			if r.ToolError {
+				out[i/8] |= 1 << (i % 8)
+			}
+		}
+		return out
+	}},
+	stringColumn("model", func(r Row) string { return r.Model }),
+	stringColumn("provider", func(r Row) string { return r.Provider }),
+	stringColumn("stop_reason", func(r Row) string { return r.StopReason }),
+	int64Column("input_tokens", func(r Row) int64 { return int64(r.InputTokens) }),
+	int64Column("output_tokens", func(r Row) int64 { return int64(r.OutputTokens) }),
+	int64Column("reasoning_tokens", func(r Row) int64 { return int64(r.ReasoningTokens) }),
+	int64Column("cache_read_tokens", func(r Row) int64 { return int64(r.CacheReadTokens) }),
+	int64Column("cache_write_tokens", func(r Row) int64 { return int64(r.CacheWriteTokens) }),
+	{name: "cost_usd", typ: typeDouble, converted: convertedNone, encode: func(rows []Row) []byte {
+		out := make([]byte, 0, 8*len(rows))
+		for _, r := range rows {
+			out = binary.LittleEndian.AppendUint64(out, math.Float64bits(r.CostUSD))
+		}
+		return out
+	}},
+	{name: "started_at", typ: typeInt64, converted: convertedTimestampMicros, optional: true, encode: func(rows []Row) []byte {
+		present := make([]bool, len(rows))
+		var values []byte
+		for i, r := range rows {
+			if r.StartedAt.IsZero() {
+				continue
+			}
+			present[i] = true
+			values = binary.LittleEndian.AppendUint64(values, uint64(r.StartedAt.UnixMicro()))
+		}
+		return append(definitionLevels(present), values...)
+	}},
+	int64Column("ttft_ms", func(r Row) int64 { return r.TTFTMs }),
+	int64Column("duration_ms", func(r Row) int64 { return r.DurationMs }),
+}
+
+func stringColumn(name string, get func(Row) string) parquetColumn {
+	return parquetColumn{name: name, typ: typeByteArray, converted: convertedUTF8, encode: func(rows []Row) []byte {
+		var out []byte
+		for _, r := range rows {
+			s := get(r)
+			out = binary.LittleEndian.AppendUint32(out, uint32(len(s)))
+			out = append(out, s...)
+		}
+		return out
+	}}
+}
+
+func int64Column(name string, get func(Row) int64) parquetColumn {
+	return parquetColumn{name: name, typ: typeInt64, converted: convertedNone, encode: func(rows []Row) []byte {
+		out := make([]byte, 0, 8*len(rows))
+		for _, r := range rows {
+			out = binary.LittleEndian.AppendUint64(out, uint64(get(r)))
+		}
+		return out
+	}}
+}
+
+// definitionLevels encodes the definition levels of an optional column
+// (bit width 1) as length-prefixed RLE runs.
+func definitionLevels(present []bool) []byte {
+	var runs []byte
+	for i := 0; i < len(present); {
+		j := i
+		for j < len(present) && present[j] == present[i] {
+			j++
+		}
+		runs = binary.AppendUvarint(runs, uint64(j-i)<<1)
+		if present[i] {
+			runs = append(runs, 1)
+		} else {
+			runs = append(runs, 0)
+		}
+		i = j
+	}
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(runs))), runs...)
+}
+
+// fileMetaData encodes the Parquet footer.
+func fileMetaData(numRows int64, chunks []parquetChunk) []byte {
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1) // version
+	t.list(2, ctStruct, len(chunks)+1, func(i int) {
+		t.begin()
+		if i == 0 {
+			t.str(4, "schema")
+			t.i32(5, int32(len(chunks)))
+		} else {
+			c := chunks[i-1].column
+			t.i32(1, c.typ)
+			repetition := int32(0) // REQUIRED
+			if c.optional {
+				repetition = 1 // OPTIONAL
+			}
+			t.i32(3, repetition)
+			t.str(4, c.name)
+			if c.converted != convertedNone {
+				t.i32(6, c.converted)
+			}
+		}
+		t.end()
+	})
+	t.i64(3, numRows)
+	t.list(4, ctStruct, 1, func(int) {
+		t.begin()
+		var total int64
+		t.list(1, ctStruct, len(chunks), func(i int) {
+			c := chunks[i]
+			total += c.size
+			t.begin()
+			t.i64(2, c.offset) // file_offset
+			t.structField(3, func() {
+				t.i32(1, c.column.typ)
+				encodings := []int32{encodingPlain}
+				if c.column.optional {
+					encodings = append(encodings, encodingRLE)
+				}
+				t.list(2, ctI32, len(encodings), func(i int) { t.varint(zigzag(int64(encodings[i]))) })
+				t.list(3, ctBinary, 1, func(int) { t.binary(c.column.name) })
+				t.i32(4, 0) // codec: UNCOMPRESSED
+				t.i64(5, numRows)
+				t.i64(6, c.size)
+				t.i64(7, c.size)
+				t.i64(9, c.offset) // data_page_offset
+			})
+			t.end()
+		})
+		t.i64(2, total)
+		t.i64(3, numRows)
+		t.end()
+	})
+	t.str(6, "github.com/codewandler/llm/analytics")
+	t.end()
+	return t.buf
+}
+
+// Thrift compact protocol type codes.
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// thriftWriter encodes the subset of the Thrift compact protocol Parquet
+// metadata needs.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // last field ID of each open struct
+}
+
+func (t *thriftWriter) begin() { t.last = append(t.last, 0) }
+
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0) // stop
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	top := len(t.last) - 1
+	if delta := id - t.last[top]; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.last[top] = id
+}
+
+func (t *thriftWriter) varint(v uint64) { t.buf = binary.AppendUvarint(t.buf, v) }
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, ctI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, ctI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, ctBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) structField(id int16, fn func()) {
+	t.field(id, ctStruct)
+	t.begin()
+	fn()
+	t.end()
+}
+
+// list writes a list field of n elements; elem writes element i.
+func (t *thriftWriter) list(id int16, elemType byte, n int, elem func(i int)) {
+	t.field(id, ctList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xf0|elemType)
+		t.varint(uint64(n))
+	}
+	for i := 0; i < n; i++ {
+		elem(i)
+	}
+}
+
+func zigzag(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) write(p []byte) {
+	if c.err != nil {
+		return
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteParquet(t *testing.T) {
+	rows := FromRun("conv-1", testRun())
+	var buf bytes.Buffer
+	require.NoError(t, WriteParquet(&buf, rows))
+	file := buf.Bytes()
+
+	require.Equal(t, parquetMagic, string(file[:4]))
+	require.Equal(t, parquetMagic, string(file[len(file)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	meta := readThriftStruct(t, bytes.NewReader(file[len(file)-8-footerLen:len(file)-8]))
+
+	assert.Equal(t, int64(len(rows)), meta[3])
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(Columns)+1)
+	for i, name := range Columns {
+		assert.Equal(t, name, schema[i+1].(map[int16]any)[4])
+	}
+
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	require.Len(t, chunks, len(Columns))
+	column := func(name string) []byte {
+		for i, c := range Columns {
+			if c != name {
+				continue
+			}
+			md := chunks[i].(map[int16]any)[3].(map[int16]any)
+			assert.Equal(t, int64(len(rows)), md[5])
+			r := bytes.NewReader(file[md[9].(int64):])
+			page := readThriftStruct(t, r)
+			data := make([]byte, page[3].(int64))
+			_, err := r.Read(data)
+			require.NoError(t, err)
+			return data
+		}
+		t.Fatalf("no column %s", name)
+		return nil
+	}
+
+	// strings: length-prefixed
+	kinds := column("kind")
+	var got []string
+	for len(kinds) > 0 {
+		n := binary.LittleEndian.Uint32(kinds)
+		got = append(got, string(kinds[4:4+n]))
+		kinds = kinds[4+n:]
+	}
+	assert.Equal(t, []string{KindMessage, KindMessage, KindMessage, KindToolCall, KindMessage, KindToolResult, KindMessage}, got)
+
+	assert.Equal(t, int64(100), int64(binary.LittleEndian.Uint64(column("input_tokens")[2*8:])))
+	assert.Equal(t, 0.01, math.Float64frombits(binary.LittleEndian.Uint64(column("cost_usd")[2*8:])))
+
+	// started_at is optional: RLE definition levels, then the present values
+	startedAt := column("started_at")
+	levels := int(binary.LittleEndian.Uint32(startedAt))
+	assert.Equal(t, []byte{2 << 1, 0, 1 << 1, 1, 3 << 1, 0, 1 << 1, 1}, startedAt[4:4+levels])
+	values := startedAt[4+levels:]
+	require.Len(t, values, 16)
+	assert.Equal(t, rows[2].StartedAt.UnixMicro(), int64(binary.LittleEndian.Uint64(values)))
+}
+
+func TestWriteParquet_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteParquet(&buf, nil))
+	assert.Equal(t, parquetMagic, buf.String()[:4])
+}
+
+// readThriftStruct decodes a Thrift compact struct into field ID -> value,
+// with i32/i64 as int64, binaries as string, lists as []any and structs as
+// map[int16]any.
+func readThriftStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	t.Helper()
+	out := map[int16]any{}
+	var last int16
+	for {
+		b, err := r.ReadByte()
+		require.NoError(t, err)
+		if b == 0 {
+			return out
+		}
+		typ := b & 0x0f
+		if delta := int16(b >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(readZigzag(t, r))
+		}
+		out[last] = readThriftValue(t, r, typ)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	switch typ {
+	case ctI32, ctI64:
+		return readZigzag(t, r)
+	case ctBinary:
+		n, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		b := make([]byte, n)
+		_, _ = r.Read(b)
+		return string(b)
+	case ctList:
+		h, err := r.ReadByte()
+		require.NoError(t, err)
+		n := int(h >> 4)
+		if n == 15 {
+			u, err := binary.ReadUvarint(r)
+			require.NoError(t, err)
+			n = int(u)
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = readThriftValue(t, r, h&0x0f)
+		}
+		return list
+	case ctStruct:
+		return readThriftStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func readZigzag(t *testing.T, r *bytes.Reader) int64 {
+	u, err := binary.ReadUvarint(r)
+	require.NoError(t, err)
+	return int64(u>>1) ^ -int64(u&1)
+}
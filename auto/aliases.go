@@ -9,8 +9,10 @@ import (
 	"github.com/codewandler/llm/provider/anthropic"
 	"github.com/codewandler/llm/provider/bedrock"
 	"github.com/codewandler/llm/provider/codex"
+	"github.com/codewandler/llm/provider/groq"
 	"github.com/codewandler/llm/provider/minimax"
 	"github.com/codewandler/llm/provider/openai"
+	"github.com/codewandler/llm/provider/vertex"
 )
 
 // builtinAliasModels defines which model to use for each built-in top-level alias per provider.
@@ -48,6 +50,16 @@ var builtinAliasFallbacks = map[string]builtinAliasModels{
 		normal:   minimax.ModelM27,
 		powerful: minimax.ModelM27,
 	},
+	ProviderGroq: {
+		fast:     groq.ModelLlama31_8B,
+		normal:   groq.ModelLlama33_70B,
+		powerful: groq.ModelGPTOSS120B,
+	},
+	ProviderVertex: {
+		fast:     vertex.ModelGemini25FlashLite,
+		normal:   vertex.ModelGemini25Flash,
+		powerful: vertex.ModelGemini25Pro,
+	},
 	ProviderCodex: func() builtinAliasModels {
 		fast, normal, powerful := codex.BuiltinAliasModels()
 		return builtinAliasModels{fast: fast, normal: normal, powerful: powerful}
@@ -110,6 +122,10 @@ func fallbackModelAliasesForProvider(providerType string) map[string]string {
 		return bedrock.ModelAliases
 	case ProviderMiniMax:
 		return minimax.ModelAliases
+	case ProviderGroq:
+		return groq.ModelAliases
+	case ProviderVertex:
+		return vertex.ModelAliases
 	default:
 		return nil
 	}
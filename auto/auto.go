@@ -24,6 +24,7 @@ func New(ctx context.Context, opts ...Option) (*llm.Service, error) {
 	reg := providerregistry.New()
 	serviceOpts := []llm.ServiceOption{
 		llm.WithRetryPolicy(llm.DefaultRetryPolicy()),
+		llm.WithMiddleware(cfg.middleware...),
 		func(sc *llm.ServiceConfig) {
 			sc.Registry = reg
 			sc.HTTPClient = cfg.httpClient
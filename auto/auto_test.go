@@ -2,6 +2,10 @@ package auto
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -107,3 +111,32 @@ func TestWithGlobalAlias_BuildsIntentAlias(t *testing.T) {
 		}
 	}
 }
+
+func TestNew_WithConfig(t *testing.T) {
+	var gotModel, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		gotModel, gotAuth = body.Model, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	cfg := &llm.Config{
+		Providers: []llm.ProviderConfig{{Type: "openai", BaseURL: server.URL, APIKey: "sk-config"}},
+		Aliases:   map[string]string{"fast": "openai/gpt-4o-mini"},
+	}
+	svc, err := New(context.Background(), WithoutAutoDetect(), WithConfig(cfg))
+	require.NoError(t, err)
+
+	stream, err := svc.CreateStream(context.Background(), llm.Request{Model: "fast", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	for range stream {
+	}
+	assert.Equal(t, "gpt-4o-mini", gotModel)
+	assert.Equal(t, "Bearer sk-config", gotAuth)
+}
@@ -10,6 +10,8 @@ const (
 	ProviderOpenRouter = "openrouter"
 	ProviderAnthropic  = "anthropic"
 	ProviderMiniMax    = "minimax"
+	ProviderGroq       = "groq"
+	ProviderVertex     = "vertex"
 	ProviderOllama     = "ollama"
 	ProviderDockerMR   = "dockermr"
 )
@@ -21,6 +23,7 @@ const (
 	EnvOpenRouterKey = "OPENROUTER_API_KEY"
 	EnvAnthropicKey  = "ANTHROPIC_API_KEY"
 	EnvMiniMaxKey    = "MINIMAX_API_KEY"
+	EnvGroqKey       = "GROQ_API_KEY"
 )
 
 // Built-in top-level aliases provided by provider/auto.
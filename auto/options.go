@@ -29,6 +29,7 @@ type config struct {
 	globalAliases     map[string][]string
 	httpClient        *http.Client
 	llmOpts           []llm.Option
+	middleware        []llm.Middleware
 }
 
 type Option func(*config)
@@ -37,6 +38,13 @@ func WithHTTPClient(client *http.Client) Option { return func(c *config) { c.htt
 func WithLLMOptions(opts ...llm.Option) Option {
 	return func(c *config) { c.llmOpts = append(c.llmOpts, opts...) }
 }
+
+// WithMiddleware wraps every provider of the service with mw, see
+// llm.WithMiddleware.
+func WithMiddleware(mw ...llm.Middleware) Option {
+	return func(c *config) { c.middleware = append(c.middleware, mw...) }
+}
+
 func WithName(name string) Option   { return func(c *config) { c.name = name } }
 func WithoutAutoDetect() Option     { return func(c *config) { c.autoDetect = false } }
 func WithoutBuiltinAliases() Option { return func(c *config) { c.builtinAliases = false } }
@@ -104,6 +112,21 @@ func WithDockerModelRunner(opts ...llm.Option) Option {
 	}
 }
 
+// WithConfig adds the providers and aliases declared by cfg, see
+// llm.LoadConfig. Combine it with WithoutAutoDetect to use only the
+// configured providers.
+func WithConfig(cfg *llm.Config) Option {
+	return func(c *config) {
+		c.detectedProviders = append(c.detectedProviders, cfg.DetectedProviders()...)
+		if c.globalAliases == nil {
+			c.globalAliases = make(map[string][]string)
+		}
+		for alias, sel := range cfg.IntentAliases() {
+			c.globalAliases[alias] = []string{sel.Model}
+		}
+	}
+}
+
 func WithGlobalAlias(alias string, targets ...string) Option {
 	return func(c *config) {
 		if c.globalAliases == nil {
@@ -0,0 +1,304 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BalanceStrategy chooses which backend of a Router serves a request.
+type BalanceStrategy string
+
+const (
+	// BalanceRoundRobin cycles through the backends in order.
+	BalanceRoundRobin BalanceStrategy = "round_robin"
+	// BalanceLeastInflight picks the backend with the fewest open streams.
+	BalanceLeastInflight BalanceStrategy = "least_inflight"
+	// BalanceWeighted spreads requests in proportion to Backend.Weight
+	// (smooth weighted round-robin).
+	BalanceWeighted BalanceStrategy = "weighted"
+)
+
+// DefaultCooldown is how long a Router skips a failing backend when no
+// Retry-After is known.
+const DefaultCooldown = 30 * time.Second
+
+// Backend is one provider behind a Router.
+type Backend struct {
+	Provider Provider
+	// Weight is the share of requests under BalanceWeighted. Zero or
+	// negative counts as 1.
+	Weight int
+}
+
+// BackendsForKeys returns one Backend per API key, each built by newProvider,
+// so a single account's traffic can be spread over several keys:
+//
+//	backends := llm.BackendsForKeys(keys, func(key string) llm.Provider {
+//		return openai.New(llm.WithAPIKey(key))
+//	})
+func BackendsForKeys(keys []string, newProvider func(key string) Provider) []Backend {
+	out := make([]Backend, len(keys))
+	for i, key := range keys {
+		out[i] = Backend{Provider: newProvider(key)}
+	}
+	return out
+}
+
+// BackendHealth is a point-in-time view of a Router backend.
+type BackendHealth struct {
+	Name     string
+	Inflight int
+	// Failures counts consecutive retryable failures.
+	Failures int
+	// CoolingUntil is set while the backend is skipped.
+	CoolingUntil time.Time
+}
+
+// Healthy reports whether the backend is not cooling down at now.
+func (h BackendHealth) Healthy(now time.Time) bool { return !now.Before(h.CoolingUntil) }
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithBalanceStrategy sets the strategy. The default is BalanceRoundRobin.
+func WithBalanceStrategy(s BalanceStrategy) RouterOption {
+	return func(r *Router) { r.strategy = s }
+}
+
+// WithCooldown sets how long a backend is skipped after failing. A
+// Retry-After reported by the backend takes precedence when longer.
+func WithCooldown(d time.Duration) RouterOption {
+	return func(r *Router) { r.cooldown = d }
+}
+
+// WithFailureThreshold sets how many consecutive retryable failures put a
+// backend into cooldown. The default is 1.
+func WithFailureThreshold(n int) RouterOption {
+	return func(r *Router) { r.threshold = n }
+}
+
+// WithRouterRetryable replaces IsFallbackError as the test for whether an
+// error counts against a backend's health and moves the request on.
+func WithRouterRetryable(fn func(error) bool) RouterOption {
+	return func(r *Router) { r.retryable = fn }
+}
+
+// Router is a Provider that spreads requests across backends serving the
+// same models, e.g. several API keys or regions, to scale past the limits of
+// a single one. Backends failing with a retryable error are cooled down and
+// the request moves on to the next backend; a backend is tried again once its
+// cooldown has passed.
+type Router struct {
+	strategy  BalanceStrategy
+	cooldown  time.Duration
+	threshold int
+	retryable func(error) bool
+	now       func() time.Time
+
+	mu       sync.Mutex
+	backends []*routerBackend
+	next     int
+}
+
+type routerBackend struct {
+	Backend
+	current      int // smooth weighted round-robin state
+	inflight     int
+	failures     int
+	coolingUntil time.Time
+}
+
+// NewRouter returns a Router over backends. It panics when backends is
+// empty.
+func NewRouter(backends []Backend, opts ...RouterOption) *Router {
+	if len(backends) == 0 {
+		panic("llm: NewRouter needs at least one backend")
+	}
+	r := &Router{
+		strategy:  BalanceRoundRobin,
+		cooldown:  DefaultCooldown,
+		threshold: 1,
+		retryable: IsFallbackError,
+		now:       time.Now,
+	}
+	for _, b := range backends {
+		if b.Weight <= 0 {
+			b.Weight = 1
+		}
+		r.backends = append(r.backends, &routerBackend{Backend: b})
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Name returns the name of the first backend.
+func (r *Router) Name() string { return r.backends[0].Provider.Name() }
+
+// Models returns the models of all backends. A model ID offered by several
+// backends is listed once.
+func (r *Router) Models() Models {
+	providers := make([]Provider, len(r.backends))
+	for i, b := range r.backends {
+		providers[i] = b.Provider
+	}
+	return unionModels(providers)
+}
+
+// Health returns the state of every backend, in registration order.
+func (r *Router) Health() []BackendHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]BackendHealth, len(r.backends))
+	for i, b := range r.backends {
+		out[i] = BackendHealth{
+			Name:         b.Provider.Name(),
+			Inflight:     b.inflight,
+			Failures:     b.failures,
+			CoolingUntil: b.coolingUntil,
+		}
+	}
+	return out
+}
+
+// CreateStream sends src to the backend chosen by the strategy. When it
+// fails with a retryable error, the remaining healthy backends are tried in
+// order and the stream reports the failover like Fallback does. When every
+// backend fails, the error wraps all of their errors.
+func (r *Router) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		errs   []error
+		failed []string
+	)
+	for _, b := range r.order() {
+		r.acquire(b)
+		stream, err := b.Provider.CreateStream(ctx, req)
+		if err == nil {
+			stream = r.track(ctx, b, stream)
+			if len(failed) == 0 {
+				return stream, nil
+			}
+			return withFailoverEvents(ctx, stream, failed, errs, b.Provider.Name()), nil
+		}
+		r.release(b)
+		if ctx.Err() != nil || !r.retryable(err) {
+			return nil, err
+		}
+		r.fail(b, err)
+		errs = append(errs, err)
+		failed = append(failed, b.Provider.Name())
+	}
+	return nil, NewErrAllProvidersFailed(r.Name(), errs)
+}
+
+// order returns the backends to try: the strategy's pick first, then the
+// other healthy backends in registration order after it. When every backend
+// is cooling down, all of them are tried.
+func (r *Router) order() []*routerBackend {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var healthy []*routerBackend
+	for _, b := range r.backends {
+		if !now.Before(b.coolingUntil) {
+			healthy = append(healthy, b)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = r.backends
+	}
+
+	pick := 0
+	switch r.strategy {
+	case BalanceLeastInflight:
+		start := r.next % len(healthy)
+		pick = start
+		for i := range healthy {
+			j := (start + i) % len(healthy)
+			if healthy[j].inflight < healthy[pick].inflight {
+				pick = j
+			}
+		}
+		r.next++
+	case BalanceWeighted:
+		total := 0
+		for i, b := range healthy {
+			b.current += b.Weight
+			total += b.Weight
+			if b.current > healthy[pick].current {
+				pick = i
+			}
+		}
+		healthy[pick].current -= total
+	default:
+		pick = r.next % len(healthy)
+		r.next++
+	}
+
+	out := make([]*routerBackend, 0, len(healthy))
+	for i := range healthy {
+		out = append(out, healthy[(pick+i)%len(healthy)])
+	}
+	return out
+}
+
+// track releases b when stream ends and updates its health from the
+// stream's outcome. A stream ended by the caller's own ctx says nothing
+// about b's health and leaves it unchanged.
+func (r *Router) track(ctx context.Context, b *routerBackend, stream Stream) Stream {
+	var streamErr error
+	return ObserveStream(ctx, stream, func(env Envelope) {
+		if ev, ok := env.Data.(*ErrorEvent); ok && streamErr == nil {
+			streamErr = ev.Error
+		}
+	}, func() {
+		r.release(b)
+		if ctx.Err() != nil {
+			return
+		}
+		if streamErr != nil && r.retryable(streamErr) {
+			r.fail(b, streamErr)
+			return
+		}
+		r.mu.Lock()
+		b.failures = 0
+		r.mu.Unlock()
+	})
+}
+
+func (r *Router) acquire(b *routerBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.inflight++
+}
+
+func (r *Router) release(b *routerBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.inflight--
+}
+
+// fail counts a retryable failure against b and starts its cooldown once
+// the threshold is reached.
+func (r *Router) fail(b *routerBackend, err error) {
+	cooldown := r.cooldown
+	var pe *ProviderError
+	if errors.As(err, &pe) && pe.RetryAfter > cooldown {
+		cooldown = pe.RetryAfter
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.failures++
+	if b.failures >= r.threshold {
+		b.coolingUntil = r.now().Add(cooldown)
+	}
+}
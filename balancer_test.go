@@ -0,0 +1,173 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+func routerRequest() llm.Request {
+	return llm.Request{Model: "claude-sonnet-4-6", Messages: llm.Messages{llm.User("hi")}}
+}
+
+func drainRouter(t *testing.T, r *llm.Router) {
+	t.Helper()
+	stream, err := r.CreateStream(context.Background(), routerRequest())
+	require.NoError(t, err)
+	require.NoError(t, llm.ProcessEvents(context.Background(), stream).Error())
+}
+
+func TestRouter_RoundRobin(t *testing.T) {
+	a, b := &recordingProvider{name: "a"}, &recordingProvider{name: "b"}
+	r := llm.NewRouter([]llm.Backend{{Provider: a}, {Provider: b}})
+
+	for range 4 {
+		drainRouter(t, r)
+	}
+	assert.Len(t, a.reqs, 2)
+	assert.Len(t, b.reqs, 2)
+}
+
+func TestRouter_Weighted(t *testing.T) {
+	a, b := &recordingProvider{name: "a"}, &recordingProvider{name: "b"}
+	r := llm.NewRouter([]llm.Backend{{Provider: a, Weight: 3}, {Provider: b, Weight: 1}},
+		llm.WithBalanceStrategy(llm.BalanceWeighted))
+
+	for range 8 {
+		drainRouter(t, r)
+	}
+	assert.Len(t, a.reqs, 6)
+	assert.Len(t, b.reqs, 2)
+}
+
+func TestRouter_BackendsForKeys(t *testing.T) {
+	var built []string
+	backends := llm.BackendsForKeys([]string{"k1", "k2"}, func(key string) llm.Provider {
+		built = append(built, key)
+		return &recordingProvider{name: key}
+	})
+	require.Len(t, backends, 2)
+	assert.Equal(t, []string{"k1", "k2"}, built)
+	assert.Equal(t, "k1", llm.NewRouter(backends).Name())
+}
+
+func TestRouter_CoolsDownFailingBackend(t *testing.T) {
+	bad := &recordingProvider{name: "bad", err: llm.NewErrAPIError("bad", 429, "rate limit")}
+	good := &recordingProvider{name: "good"}
+	r := llm.NewRouter([]llm.Backend{{Provider: bad}, {Provider: good}}, llm.WithCooldown(time.Hour))
+
+	stream, err := r.CreateStream(context.Background(), routerRequest())
+	require.NoError(t, err)
+	var failovers int
+	res := llm.NewEventProcessor(context.Background(), stream).
+		OnEvent(llm.TypedEventHandler[*llm.ProviderFailoverEvent](func(*llm.ProviderFailoverEvent) { failovers++ })).
+		Result()
+	require.NoError(t, res.Error())
+	assert.Equal(t, 1, failovers)
+
+	health := r.Health()
+	assert.Equal(t, 1, health[0].Failures)
+	assert.False(t, health[0].Healthy(time.Now()))
+	assert.True(t, health[1].Healthy(time.Now()))
+
+	// The cooling backend is skipped.
+	for range 3 {
+		drainRouter(t, r)
+	}
+	assert.Len(t, bad.reqs, 1)
+	assert.Len(t, good.reqs, 4)
+}
+
+func TestRouter_NonRetryableErrorReturned(t *testing.T) {
+	bad := &recordingProvider{name: "bad", err: llm.NewErrAPIError("bad", 400, "bad request")}
+	good := &recordingProvider{name: "good"}
+	r := llm.NewRouter([]llm.Backend{{Provider: bad}, {Provider: good}})
+
+	_, err := r.CreateStream(context.Background(), routerRequest())
+	require.Error(t, err)
+	assert.Empty(t, good.reqs)
+	assert.Zero(t, r.Health()[0].Failures)
+}
+
+// openStreamProvider returns streams that stay open until release is closed.
+type openStreamProvider struct {
+	recordingProvider
+	release chan struct{}
+}
+
+func (p *openStreamProvider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	if _, err := p.recordingProvider.CreateStream(ctx, src); err != nil {
+		return nil, err
+	}
+	ch := make(chan llm.Envelope)
+	go func() {
+		<-p.release
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func TestRouter_LeastInflight(t *testing.T) {
+	release := make(chan struct{})
+	busy := &openStreamProvider{recordingProvider: recordingProvider{name: "busy"}, release: release}
+	idle := &recordingProvider{name: "idle"}
+	r := llm.NewRouter([]llm.Backend{{Provider: busy}, {Provider: idle}},
+		llm.WithBalanceStrategy(llm.BalanceLeastInflight))
+
+	open, err := r.CreateStream(context.Background(), routerRequest())
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Health()[0].Inflight)
+
+	for range 3 {
+		drainRouter(t, r)
+	}
+	assert.Len(t, busy.reqs, 1)
+	assert.Len(t, idle.reqs, 3)
+
+	close(release)
+	for range open {
+	}
+	assert.Zero(t, r.Health()[0].Inflight)
+}
+
+// cancelledStreamProvider returns streams that fail once the caller's ctx is
+// done, like a transport whose read is aborted by the cancellation.
+type cancelledStreamProvider struct {
+	recordingProvider
+}
+
+func (p *cancelledStreamProvider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	if _, err := p.recordingProvider.CreateStream(ctx, src); err != nil {
+		return nil, err
+	}
+	ch := make(chan llm.Envelope, 1)
+	go func() {
+		defer close(ch)
+		<-ctx.Done()
+		ev := &llm.ErrorEvent{Error: llm.NewErrRequestFailed(p.name, ctx.Err())}
+		ch <- llm.Envelope{Type: ev.Type(), Data: ev}
+	}()
+	return ch, nil
+}
+
+func TestRouter_CallerCancelKeepsBackendHealthy(t *testing.T) {
+	p := &cancelledStreamProvider{recordingProvider: recordingProvider{name: "a"}}
+	r := llm.NewRouter([]llm.Backend{{Provider: p}}, llm.WithCooldown(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := r.CreateStream(ctx, routerRequest())
+	require.NoError(t, err)
+	cancel()
+	for range stream {
+	}
+
+	health := r.Health()
+	assert.Zero(t, health[0].Inflight)
+	assert.Zero(t, health[0].Failures)
+	assert.True(t, health[0].Healthy(time.Now()))
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/codewandler/llm/catalog"
+)
+
+// ModelCapabilities describes what a model supports according to the model
+// catalog (see RefreshModelCatalog).
+type ModelCapabilities struct {
+	Tools            bool `json:"tools"`
+	Vision           bool `json:"vision"`
+	Reasoning        bool `json:"reasoning"`
+	StructuredOutput bool `json:"structured_output"`
+
+	// ContextWindow and MaxOutput are in tokens; zero when unknown.
+	ContextWindow int `json:"context_window,omitempty"`
+	MaxOutput     int `json:"max_output,omitempty"`
+}
+
+// Capabilities returns the capabilities of model on provider. It returns
+// false when the catalog does not know the model.
+func Capabilities(provider, model string) (ModelCapabilities, bool) {
+	m, ok := catalog.Lookup(provider, model)
+	if !ok {
+		return ModelCapabilities{}, false
+	}
+	return ModelCapabilities{
+		Tools:            m.ToolCall,
+		Vision:           m.Vision,
+		Reasoning:        m.Reasoning,
+		StructuredOutput: m.StructuredOutput,
+		ContextWindow:    m.ContextWindow,
+		MaxOutput:        m.MaxOutput,
+	}, true
+}
+
+// Check returns an ErrUnsupportedFeature error when req uses a feature the
+// model lacks: tools, thinking, JSON output or more output tokens than the
+// model can generate.
+func (c ModelCapabilities) Check(provider string, req Request) error {
+	if err := c.checkRequired(provider, req); err != nil {
+		return err
+	}
+	switch {
+	case (req.OutputFormat == OutputFormatJSON || req.OutputSchema != nil) && !c.StructuredOutput:
+		return NewErrUnsupportedFeature(provider, req.Model, "structured output")
+	case c.MaxOutput > 0 && req.MaxTokens > c.MaxOutput:
+		return NewErrUnsupportedFeature(provider, req.Model, fmt.Sprintf("max_tokens above %d", c.MaxOutput))
+	}
+	return nil
+}
+
+// checkRequired checks the features that fail on every provider when the
+// model lacks them. Structured output and output limits are left out: the
+// catalog under-reports them, and some providers emulate JSON output.
+func (c ModelCapabilities) checkRequired(provider string, req Request) error {
+	switch {
+	case len(req.Tools) > 0 && !c.Tools:
+		return NewErrUnsupportedFeature(provider, req.Model, "tool calling")
+	case (req.Thinking.IsOn() || req.ThinkingBudget > 0) && !req.Thinking.IsOff() && !c.Reasoning:
+		return NewErrUnsupportedFeature(provider, req.Model, "thinking")
+	}
+	return nil
+}
+
+// CheckCapabilities checks req against the capabilities of req.Model on
+// provider, so unsupported features fail with a typed error before the
+// request is sent. Models unknown to the catalog pass.
+func CheckCapabilities(provider string, req Request) error {
+	c, ok := Capabilities(provider, req.Model)
+	if !ok {
+		return nil
+	}
+	return c.Check(provider, req)
+}
+
+// WithCapabilityCheck makes the Service check every candidate with
+// CheckCapabilities before sending. Candidates that cannot serve the request
+// are skipped; when none can, the last check error is returned.
+func WithCapabilityCheck() ServiceOption {
+	return func(c *ServiceConfig) { c.CapabilityCheck = true }
+}
+
+// checkCandidate runs CheckCapabilities for candidate when enabled.
+func (s *Service) checkCandidate(candidate RegisteredProvider, req Request) error {
+	if !s.capabilityCheck {
+		return nil
+	}
+	provider := candidate.ServiceID
+	if provider == "" {
+		provider = candidate.Provider.Name()
+	}
+	return CheckCapabilities(provider, req)
+}
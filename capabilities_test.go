@@ -0,0 +1,73 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+)
+
+func TestCapabilities(t *testing.T) {
+	c, ok := llm.Capabilities("claude", "claude-sonnet-4-6")
+	require.True(t, ok)
+	assert.True(t, c.Tools && c.Reasoning)
+	assert.Positive(t, c.ContextWindow)
+	assert.Positive(t, c.MaxOutput)
+
+	_, ok = llm.Capabilities("anthropic", "no-such-model")
+	assert.False(t, ok)
+}
+
+func TestModelCapabilities_Check(t *testing.T) {
+	tools := []tool.Definition{{Name: "lookup"}}
+	for name, tc := range map[string]struct {
+		caps llm.ModelCapabilities
+		req  llm.Request
+		ok   bool
+	}{
+		"plain request":           {llm.ModelCapabilities{}, llm.Request{MaxTokens: 100}, true},
+		"tools unsupported":       {llm.ModelCapabilities{}, llm.Request{Tools: tools}, false},
+		"tools supported":         {llm.ModelCapabilities{Tools: true}, llm.Request{Tools: tools}, true},
+		"thinking unsupported":    {llm.ModelCapabilities{}, llm.Request{Thinking: llm.ThinkingOn}, false},
+		"thinking budget off":     {llm.ModelCapabilities{}, llm.Request{Thinking: llm.ThinkingOff, ThinkingBudget: 1024}, true},
+		"json unsupported":        {llm.ModelCapabilities{}, llm.Request{OutputFormat: llm.OutputFormatJSON}, false},
+		"max tokens above output": {llm.ModelCapabilities{MaxOutput: 4096}, llm.Request{MaxTokens: 8192}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.caps.Check("test", tc.req)
+			if tc.ok {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, llm.ErrUnsupportedFeature)
+		})
+	}
+}
+
+func TestService_CapabilityCheck(t *testing.T) {
+	p := &recordingProvider{name: "anthropic"}
+	svc, err := llm.New(
+		llm.WithRegisteredProvider(llm.RegisteredProvider{ServiceID: "anthropic", Provider: p}),
+		llm.WithCapabilityCheck(),
+	)
+	require.NoError(t, err)
+
+	_, err = svc.CreateStream(context.Background(), llm.Request{
+		Model:     "claude-sonnet-4-6",
+		Messages:  llm.Messages{llm.User("hi")},
+		MaxTokens: 10_000_000,
+	})
+	require.ErrorIs(t, err, llm.ErrUnsupportedFeature)
+	assert.Empty(t, p.reqs, "the request is not sent")
+
+	_, err = svc.CreateStream(context.Background(), llm.Request{
+		Model:    "claude-sonnet-4-6",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	assert.Len(t, p.reqs, 1)
+}
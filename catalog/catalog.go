@@ -0,0 +1,233 @@
+// Package catalog queries the model catalog the library prices, limits and
+// routes with: the snapshot embedded at build time, or the models.dev data
+// applied by llm.RefreshModelCatalog.
+//
+// Example:
+//
+//	models := catalog.Find(
+//	    catalog.ToolCall(true),
+//	    catalog.MinContext(200_000),
+//	    catalog.MaxInputPrice(1.0),
+//	).SortByPrice()
+package catalog
+
+import (
+	"path"
+	"sort"
+
+	modelcatalog "github.com/codewandler/llm/internal/modelcatalog"
+	"github.com/codewandler/llm/usage"
+	modeldb "github.com/codewandler/modeldb"
+)
+
+// Model is a model as offered by one provider.
+type Model struct {
+	// Provider is the catalog service ID, e.g. "openai" or "bedrock".
+	Provider string `json:"provider"`
+	// ID is the wire model ID sent to the provider.
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+
+	ContextWindow int `json:"context_window,omitempty"`
+	MaxOutput     int `json:"max_output,omitempty"`
+
+	ToolCall         bool `json:"tool_call,omitempty"`
+	Vision           bool `json:"vision,omitempty"`
+	Reasoning        bool `json:"reasoning,omitempty"`
+	StructuredOutput bool `json:"structured_output,omitempty"`
+	Deprecated       bool `json:"deprecated,omitempty"`
+
+	// Pricing is in USD per 1M tokens; nil when unknown.
+	Pricing *usage.Pricing `json:"pricing,omitempty"`
+}
+
+// Models is a list of catalog models.
+type Models []Model
+
+// Filter selects models; Find keeps the models all filters accept.
+type Filter func(Model) bool
+
+// All returns every model in the catalog, deprecated ones included, sorted
+// by provider and ID.
+func All() Models {
+	cat, err := modelcatalog.Load()
+	if err != nil {
+		return nil
+	}
+	out := make(Models, 0, len(cat.Offerings))
+	for _, offering := range cat.Offerings {
+		rec, ok := cat.ModelByKey(offering.ModelKey)
+		if !ok {
+			continue
+		}
+		out = append(out, FromOffering(offering, rec))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Find returns the non-deprecated models accepted by every filter, sorted
+// by provider and ID.
+func Find(filters ...Filter) Models {
+	return All().Filter(append([]Filter{func(m Model) bool { return !m.Deprecated }}, filters...)...)
+}
+
+// Lookup returns model as offered by provider. Provider names are
+// canonicalised; model is the wire model ID.
+func Lookup(provider, model string) (Model, bool) {
+	cat, err := modelcatalog.Load()
+	if err != nil {
+		return Model{}, false
+	}
+	for _, serviceID := range modelcatalog.LookupServices(provider) {
+		offering, ok := cat.Offerings[modeldb.OfferingRef{ServiceID: serviceID, WireModelID: model}]
+		if !ok {
+			continue
+		}
+		if rec, ok := cat.ModelByKey(offering.ModelKey); ok {
+			return FromOffering(offering, rec), true
+		}
+	}
+	return Model{}, false
+}
+
+// Filter returns the models accepted by every filter, in order.
+func (ms Models) Filter(filters ...Filter) Models {
+	var out Models
+next:
+	for _, m := range ms {
+		for _, f := range filters {
+			if !f(m) {
+				continue next
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// SortByPrice sorts by input plus output price, cheapest first, and returns
+// ms. Models without pricing sort last.
+func (ms Models) SortByPrice() Models {
+	sort.SliceStable(ms, func(i, j int) bool {
+		a, b := ms[i].Pricing, ms[j].Pricing
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		return a != nil && a.Input+a.Output < b.Input+b.Output
+	})
+	return ms
+}
+
+// SortByContext sorts by context window, largest first, and returns ms.
+func (ms Models) SortByContext() Models {
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].ContextWindow > ms[j].ContextWindow })
+	return ms
+}
+
+// FromOffering converts a catalog offering and its model record. Offering
+// limits and pricing take precedence over the model's reference values.
+func FromOffering(offering modeldb.Offering, rec modeldb.ModelRecord) Model {
+	m := Model{
+		Provider:         offering.ServiceID,
+		ID:               offering.WireModelID,
+		Name:             rec.Name,
+		ContextWindow:    rec.Limits.ContextWindow,
+		MaxOutput:        rec.Limits.MaxOutput,
+		ToolCall:         rec.Capabilities.ToolUse,
+		Vision:           rec.Capabilities.Vision || containsString(rec.InputModalities, "image"),
+		Reasoning:        rec.Capabilities.Reasoning != nil && rec.Capabilities.Reasoning.Available,
+		StructuredOutput: rec.Capabilities.StructuredOutput || rec.Capabilities.StructuredOutputs,
+		Deprecated:       rec.Deprecated,
+	}
+	if o := offering.LimitsOverride; o != nil {
+		if o.ContextWindow > 0 {
+			m.ContextWindow = o.ContextWindow
+		}
+		if o.MaxOutput > 0 {
+			m.MaxOutput = o.MaxOutput
+		}
+	}
+	pricing := offering.Pricing
+	if pricing == nil {
+		pricing = rec.ReferencePricing
+	}
+	if pricing != nil {
+		m.Pricing = &usage.Pricing{
+			Input:       pricing.Input,
+			Output:      pricing.Output,
+			Reasoning:   pricing.Reasoning,
+			CachedInput: pricing.CachedInput,
+			CacheWrite:  pricing.CacheWrite,
+		}
+	}
+	return m
+}
+
+// Provider accepts models offered by one of the given providers. Provider
+// names are canonicalised, so "claude" matches "anthropic".
+func Provider(ids ...string) Filter {
+	return func(m Model) bool {
+		for _, id := range ids {
+			if modelcatalog.CanonicalProvider(id) == m.Provider {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match accepts models whose ID matches a path.Match pattern such as
+// "claude-sonnet-*".
+func Match(pattern string) Filter {
+	return func(m Model) bool {
+		ok, _ := path.Match(pattern, m.ID)
+		return ok
+	}
+}
+
+// ToolCall accepts models whose tool calling support equals v.
+func ToolCall(v bool) Filter { return func(m Model) bool { return m.ToolCall == v } }
+
+// Vision accepts models whose image input support equals v.
+func Vision(v bool) Filter { return func(m Model) bool { return m.Vision == v } }
+
+// Reasoning accepts models whose reasoning support equals v.
+func Reasoning(v bool) Filter { return func(m Model) bool { return m.Reasoning == v } }
+
+// StructuredOutput accepts models whose structured output support equals v.
+func StructuredOutput(v bool) Filter {
+	return func(m Model) bool { return m.StructuredOutput == v }
+}
+
+// MinContext accepts models with a context window of at least n tokens.
+func MinContext(n int) Filter { return func(m Model) bool { return m.ContextWindow >= n } }
+
+// MinOutput accepts models that can generate at least n tokens.
+func MinOutput(n int) Filter { return func(m Model) bool { return m.MaxOutput >= n } }
+
+// MaxInputPrice accepts models with known input pricing of at most usd per
+// 1M tokens.
+func MaxInputPrice(usd float64) Filter {
+	return func(m Model) bool { return m.Pricing != nil && m.Pricing.Input <= usd }
+}
+
+// MaxOutputPrice accepts models with known output pricing of at most usd per
+// 1M tokens.
+func MaxOutputPrice(usd float64) Filter {
+	return func(m Model) bool { return m.Pricing != nil && m.Pricing.Output <= usd }
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"sort"
+	"testing"
+
+	modeldb "github.com/codewandler/modeldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/usage"
+)
+
+func TestFind(t *testing.T) {
+	all := All()
+	require.NotEmpty(t, all)
+	assert.True(t, sort.SliceIsSorted(all, func(i, j int) bool {
+		if all[i].Provider != all[j].Provider {
+			return all[i].Provider < all[j].Provider
+		}
+		return all[i].ID < all[j].ID
+	}))
+
+	found := Find(Provider("claude"), ToolCall(true), Reasoning(true), MinContext(200_000), MaxInputPrice(3))
+	require.NotEmpty(t, found)
+	for _, m := range found {
+		assert.Equal(t, "anthropic", m.Provider)
+		assert.True(t, m.ToolCall && m.Reasoning)
+		assert.False(t, m.Deprecated)
+		assert.GreaterOrEqual(t, m.ContextWindow, 200_000)
+		assert.LessOrEqual(t, m.Pricing.Input, 3.0)
+	}
+
+	sonnet := Find(Provider("anthropic"), Match("claude-sonnet-4-6"))
+	require.Len(t, sonnet, 1)
+	assert.Equal(t, 3.0, sonnet[0].Pricing.Input)
+
+	assert.Empty(t, Find(Provider("anthropic"), MaxOutputPrice(0.001)))
+}
+
+func TestLookup(t *testing.T) {
+	m, ok := Lookup("claude", "claude-sonnet-4-6")
+	require.True(t, ok)
+	assert.Equal(t, "anthropic", m.Provider)
+	assert.True(t, m.ToolCall)
+
+	_, ok = Lookup("anthropic", "no-such-model")
+	assert.False(t, ok)
+}
+
+func TestModels_Sort(t *testing.T) {
+	ms := Models{
+		{ID: "unpriced", ContextWindow: 1_000_000},
+		{ID: "pricey", Pricing: &usage.Pricing{Input: 10, Output: 30}, ContextWindow: 200_000},
+		{ID: "cheap", Pricing: &usage.Pricing{Input: 1, Output: 2}, ContextWindow: 128_000},
+	}
+	assert.Equal(t, []string{"cheap", "pricey", "unpriced"}, ids(ms.SortByPrice()))
+	assert.Equal(t, []string{"unpriced", "pricey", "cheap"}, ids(ms.SortByContext()))
+}
+
+func TestFromOffering_OfferingOverridesModel(t *testing.T) {
+	m := FromOffering(
+		modeldb.Offering{
+			ServiceID:      "bedrock",
+			WireModelID:    "m",
+			LimitsOverride: &modeldb.Limits{ContextWindow: 1_000_000},
+			Pricing:        &modeldb.Pricing{Input: 2},
+		},
+		modeldb.ModelRecord{
+			Limits:           modeldb.Limits{ContextWindow: 200_000, MaxOutput: 64_000},
+			ReferencePricing: &modeldb.Pricing{Input: 1},
+			InputModalities:  []string{"text", "image"},
+		},
+	)
+	assert.Equal(t, 1_000_000, m.ContextWindow)
+	assert.Equal(t, 64_000, m.MaxOutput)
+	assert.Equal(t, 2.0, m.Pricing.Input)
+	assert.True(t, m.Vision)
+}
+
+func ids(ms Models) []string {
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.ID
+	}
+	return out
+}
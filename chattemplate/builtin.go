@@ -0,0 +1,94 @@
+package chattemplate
+
+import "strings"
+
+// Built-in templates. Each starts with the model's BOS token, so the
+// completion server must not add one of its own.
+var (
+	// Llama3 is the Llama 3.x instruct format. Tool results use the
+	// "ipython" role.
+	Llama3 = MustParse("llama3",
+		"<|begin_of_text|>"+
+			"{{range .Messages}}"+
+			"<|start_header_id|>{{if eq .Role \"tool\"}}ipython{{else}}{{.Role}}{{end}}<|end_header_id|>\n\n"+
+			"{{.Content}}{{range .ToolCalls}}{\"name\": \"{{.Name}}\", \"parameters\": {{.Arguments}}}{{end}}<|eot_id|>"+
+			"{{end}}"+
+			"{{if .AddGenerationPrompt}}<|start_header_id|>assistant<|end_header_id|>\n\n{{end}}",
+		"<|eot_id|>", "<|end_of_text|>")
+
+	// ChatML is the <|im_start|>/<|im_end|> format used by Qwen and many
+	// fine-tunes. Tool calls and results use Qwen's tags.
+	ChatML = MustParse("chatml",
+		"{{range .Messages}}"+
+			"{{if eq .Role \"tool\"}}<|im_start|>user\n<tool_response>\n{{.Content}}\n</tool_response><|im_end|>\n"+
+			"{{else}}<|im_start|>{{.Role}}\n{{.Content}}"+
+			"{{range .ToolCalls}}\n<tool_call>\n{\"name\": \"{{.Name}}\", \"arguments\": {{.Arguments}}}\n</tool_call>{{end}}"+
+			"<|im_end|>\n{{end}}"+
+			"{{end}}"+
+			"{{if .AddGenerationPrompt}}<|im_start|>assistant\n{{end}}",
+		"<|im_end|>", "<|endoftext|>")
+
+	// Mistral is the [INST] format of Mistral instruct models. System
+	// prompts are merged into the first user turn.
+	Mistral = func() *Template {
+		t := MustParse("mistral",
+			"<s>"+
+				"{{range .Messages}}"+
+				"{{if eq .Role \"user\"}}[INST] {{.Content}}[/INST]"+
+				"{{else if eq .Role \"tool\"}}[TOOL_RESULTS] {{.Content}}[/TOOL_RESULTS]"+
+				"{{else if eq .Role \"assistant\"}}{{.Content}}"+
+				"{{if .ToolCalls}}[TOOL_CALLS] [{{range $i, $c := .ToolCalls}}{{if $i}}, {{end}}{\"name\": \"{{$c.Name}}\", \"arguments\": {{$c.Arguments}}}{{end}}]{{end}}</s>"+
+				"{{end}}"+
+				"{{end}}",
+			"</s>")
+		t.MergeSystem = true
+		return t
+	}()
+
+	// Gemma is the <start_of_turn> format of Gemma instruct models. System
+	// prompts are merged into the first user turn.
+	Gemma = func() *Template {
+		t := MustParse("gemma",
+			"<bos>"+
+				"{{range .Messages}}"+
+				"<start_of_turn>{{if eq .Role \"assistant\"}}model{{else}}user{{end}}\n{{.Content}}<end_of_turn>\n"+
+				"{{end}}"+
+				"{{if .AddGenerationPrompt}}<start_of_turn>model\n{{end}}",
+			"<end_of_turn>")
+		t.MergeSystem = true
+		return t
+	}()
+)
+
+var builtins = map[string]*Template{
+	"llama3":  Llama3,
+	"chatml":  ChatML,
+	"qwen":    ChatML,
+	"mistral": Mistral,
+	"gemma":   Gemma,
+}
+
+// Lookup returns the built-in template registered under name. Accepted
+// names are "llama3", "chatml", "qwen", "mistral" and "gemma".
+func Lookup(name string) (*Template, bool) {
+	t, ok := builtins[strings.ToLower(name)]
+	return t, ok
+}
+
+// ForModel guesses the built-in template for a model ID from its family
+// name, e.g. "llama3.2:1b" → Llama3, "qwen2.5" → ChatML.
+func ForModel(model string) (*Template, bool) {
+	id := strings.ToLower(model)
+	switch {
+	case strings.Contains(id, "llama-3"), strings.Contains(id, "llama3"):
+		return Llama3, true
+	case strings.Contains(id, "qwen"):
+		return ChatML, true
+	case strings.Contains(id, "mistral"), strings.Contains(id, "ministral"), strings.Contains(id, "devstral"):
+		return Mistral, true
+	case strings.Contains(id, "gemma"):
+		return Gemma, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,146 @@
+// Package chattemplate renders conversations into the raw prompt strings
+// expected by base and completion-only models.
+//
+// Templates use Go text/template syntax, which covers the loops and
+// conditionals of the Jinja chat templates shipped with Hugging Face models.
+// Built-in templates are provided for the common Llama 3, ChatML (Qwen),
+// Mistral and Gemma formats.
+package chattemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/codewandler/llm/msg"
+)
+
+// Turn is one message as seen by a template.
+type Turn struct {
+	// Role is "system", "user", "assistant" or "tool". Developer messages
+	// are rendered as system turns.
+	Role string
+
+	// Content is the text of the message. For tool turns it is the tool
+	// output.
+	Content string
+
+	// ToolCalls holds the tool calls of an assistant turn.
+	ToolCalls []ToolCall
+
+	// ToolCallID is the call a tool turn answers.
+	ToolCallID string
+}
+
+// ToolCall is an assistant tool call as seen by a template.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON-encoded arguments
+}
+
+// Data is the value a template is executed with.
+type Data struct {
+	Messages []Turn
+
+	// AddGenerationPrompt is true when the rendered prompt should end with
+	// the header of an assistant turn so the model continues as assistant.
+	AddGenerationPrompt bool
+}
+
+// Template renders messages into a prompt string.
+type Template struct {
+	// Name identifies the template, e.g. "llama3".
+	Name string
+
+	// Stop lists the sequences that end an assistant turn. Completion
+	// requests should pass them as stop sequences.
+	Stop []string
+
+	// MergeSystem folds system turns into the first user turn, for formats
+	// without a system role.
+	MergeSystem bool
+
+	tmpl *template.Template
+}
+
+// Parse parses src as a text/template chat template executed with Data.
+func Parse(name, src string, stop ...string) (*Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse chat template %s: %w", name, err)
+	}
+	return &Template{Name: name, Stop: stop, tmpl: t}, nil
+}
+
+// MustParse is like Parse but panics on error.
+func MustParse(name, src string, stop ...string) *Template {
+	t, err := Parse(name, src, stop...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render converts msgs into turns and executes the template. When
+// addGenerationPrompt is true the prompt ends with an open assistant turn.
+func (t *Template) Render(msgs msg.Messages, addGenerationPrompt bool) (string, error) {
+	data := Data{Messages: Turns(msgs), AddGenerationPrompt: addGenerationPrompt}
+	if t.MergeSystem {
+		data.Messages = mergeSystem(data.Messages)
+	}
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render chat template %s: %w", t.Name, err)
+	}
+	return sb.String(), nil
+}
+
+// Turns flattens msgs into template turns. Each tool result becomes its own
+// tool turn; thinking parts are dropped.
+func Turns(msgs msg.Messages) []Turn {
+	out := make([]Turn, 0, len(msgs))
+	for _, m := range msgs {
+		switch m.Role {
+		case msg.RoleTool:
+			for _, tr := range m.ToolResults() {
+				out = append(out, Turn{Role: string(msg.RoleTool), Content: tr.ToolOutput, ToolCallID: tr.ToolCallID})
+			}
+		case msg.RoleDeveloper:
+			out = append(out, Turn{Role: string(msg.RoleSystem), Content: m.Text()})
+		default:
+			turn := Turn{Role: string(m.Role), Content: m.Text()}
+			for _, tc := range m.ToolCalls() {
+				args, _ := json.Marshal(tc.Args)
+				turn.ToolCalls = append(turn.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Name, Arguments: string(args)})
+			}
+			out = append(out, turn)
+		}
+	}
+	return out
+}
+
+// mergeSystem prepends the system turns to the first user turn.
+func mergeSystem(turns []Turn) []Turn {
+	var system []string
+	out := make([]Turn, 0, len(turns))
+	for _, t := range turns {
+		if t.Role == string(msg.RoleSystem) {
+			system = append(system, t.Content)
+			continue
+		}
+		out = append(out, t)
+	}
+	if len(system) == 0 {
+		return out
+	}
+	prefix := strings.Join(system, "\n\n")
+	for i := range out {
+		if out[i].Role == string(msg.RoleUser) {
+			out[i].Content = prefix + "\n\n" + out[i].Content
+			return out
+		}
+	}
+	return append([]Turn{{Role: string(msg.RoleUser), Content: prefix}}, out...)
+}
@@ -0,0 +1,128 @@
+package chattemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/msg"
+)
+
+func conversation() msg.Messages {
+	return msg.Messages{
+		msg.System("be brief").Build(),
+		msg.User("hi").Build(),
+		msg.Assistant(msg.Text("hello")).Build(),
+		msg.User("bye").Build(),
+	}
+}
+
+func TestBuiltins_Render(t *testing.T) {
+	tests := []struct {
+		tmpl *Template
+		want string
+	}{
+		{
+			tmpl: Llama3,
+			want: "<|begin_of_text|>" +
+				"<|start_header_id|>system<|end_header_id|>\n\nbe brief<|eot_id|>" +
+				"<|start_header_id|>user<|end_header_id|>\n\nhi<|eot_id|>" +
+				"<|start_header_id|>assistant<|end_header_id|>\n\nhello<|eot_id|>" +
+				"<|start_header_id|>user<|end_header_id|>\n\nbye<|eot_id|>" +
+				"<|start_header_id|>assistant<|end_header_id|>\n\n",
+		},
+		{
+			tmpl: ChatML,
+			want: "<|im_start|>system\nbe brief<|im_end|>\n" +
+				"<|im_start|>user\nhi<|im_end|>\n" +
+				"<|im_start|>assistant\nhello<|im_end|>\n" +
+				"<|im_start|>user\nbye<|im_end|>\n" +
+				"<|im_start|>assistant\n",
+		},
+		{
+			tmpl: Mistral,
+			want: "<s>[INST] be brief\n\nhi[/INST]hello</s>[INST] bye[/INST]",
+		},
+		{
+			tmpl: Gemma,
+			want: "<bos>" +
+				"<start_of_turn>user\nbe brief\n\nhi<end_of_turn>\n" +
+				"<start_of_turn>model\nhello<end_of_turn>\n" +
+				"<start_of_turn>user\nbye<end_of_turn>\n" +
+				"<start_of_turn>model\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl.Name, func(t *testing.T) {
+			got, err := tt.tmpl.Render(conversation(), true)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChatML_RendersToolCallsAndResults(t *testing.T) {
+	got, err := ChatML.Render(msg.Messages{
+		msg.User("weather?").Build(),
+		msg.Assistant(msg.ToolCall(msg.NewToolCall("call_1", "get_weather", msg.ToolArgs{"city": "Paris"}))).Build(),
+		msg.Tool().Results(msg.ToolResult{ToolCallID: "call_1", ToolOutput: "22C"}).Build(),
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"<|im_start|>user\nweather?<|im_end|>\n"+
+			"<|im_start|>assistant\n\n<tool_call>\n{\"name\": \"get_weather\", \"arguments\": {\"city\":\"Paris\"}}\n</tool_call><|im_end|>\n"+
+			"<|im_start|>user\n<tool_response>\n22C\n</tool_response><|im_end|>\n",
+		got)
+}
+
+func TestParse_CustomTemplate(t *testing.T) {
+	tmpl, err := Parse("plain", "{{range .Messages}}{{.Role}}: {{.Content}}\n{{end}}{{if .AddGenerationPrompt}}assistant:{{end}}", "\nuser:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\nuser:"}, tmpl.Stop)
+
+	got, err := tmpl.Render(msg.Messages{
+		msg.Developer("rules").Build(),
+		msg.User("hi").Build(),
+	}, true)
+	require.NoError(t, err)
+	assert.Equal(t, "system: rules\nuser: hi\nassistant:", got)
+}
+
+func TestParse_InvalidTemplate(t *testing.T) {
+	_, err := Parse("bad", "{{range .Messages}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse chat template bad")
+}
+
+func TestMergeSystem_WithoutUserTurn(t *testing.T) {
+	got, err := Gemma.Render(msg.Messages{msg.System("sys").Build()}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "<bos><start_of_turn>user\nsys<end_of_turn>\n", got)
+}
+
+func TestForModel(t *testing.T) {
+	tests := map[string]*Template{
+		"llama3.2:1b":             Llama3,
+		"meta-llama/Llama-3.1-8B": Llama3,
+		"qwen2.5:0.5b":            ChatML,
+		"mistral-7b-v0.3":         Mistral,
+		"devstral-small-2":        Mistral,
+		"google/gemma-3-4b":       Gemma,
+	}
+	for model, want := range tests {
+		got, ok := ForModel(model)
+		require.True(t, ok, model)
+		assert.Same(t, want, got, model)
+	}
+	_, ok := ForModel("gpt2")
+	assert.False(t, ok)
+}
+
+func TestLookup(t *testing.T) {
+	got, ok := Lookup("Qwen")
+	require.True(t, ok)
+	assert.Same(t, ChatML, got)
+	_, ok = Lookup("unknown")
+	assert.False(t, ok)
+}
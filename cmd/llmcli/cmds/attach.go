@@ -0,0 +1,136 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tokencount"
+)
+
+// attachment is file content sent along with the prompt.
+type attachment struct {
+	Name        string
+	ContentType string
+	Text        string
+}
+
+// textContentTypes are non-text/* content types attached as text.
+var textContentTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/toml":       true,
+	"application/javascript": true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// newAttachment detects the content type of data and accepts text only:
+// images and other binary files are rejected until requests can carry them.
+// The content decides whether data is text; the file extension, when it
+// names a text type, refines the reported type.
+func newAttachment(name string, data []byte) (attachment, error) {
+	sniffed := baseContentType(http.DetectContentType(data))
+	byExt := baseContentType(mime.TypeByExtension(filepath.Ext(name)))
+	switch {
+	case strings.HasPrefix(sniffed, "image/"), strings.HasPrefix(byExt, "image/"):
+		return attachment{}, fmt.Errorf("%s: image attachments are not supported yet", name)
+	case !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0:
+		return attachment{}, fmt.Errorf("%s: cannot attach %s content", name, sniffed)
+	case !strings.HasPrefix(sniffed, "text/") && sniffed != "application/octet-stream":
+		return attachment{}, fmt.Errorf("%s: cannot attach %s content", name, sniffed)
+	}
+	contentType := "text/plain"
+	if isTextContentType(byExt) {
+		contentType = byExt
+	} else if strings.HasPrefix(sniffed, "text/") {
+		contentType = sniffed
+	}
+	return attachment{Name: name, ContentType: contentType, Text: string(data)}, nil
+}
+
+func baseContentType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	return strings.TrimSpace(contentType)
+}
+
+func isTextContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") || textContentTypes[contentType]
+}
+
+// readAttachments reads the files at paths.
+func readAttachments(paths []string) ([]attachment, error) {
+	atts := make([]attachment, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		att, err := newAttachment(filepath.Base(path), data)
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, att)
+	}
+	return atts, nil
+}
+
+// readStdin returns what is piped into the command, or nil when stdin is a
+// terminal.
+func readStdin(in *os.File) ([]byte, error) {
+	if isTerminal(in) {
+		return nil, nil
+	}
+	return io.ReadAll(in)
+}
+
+// buildPrompt places the attachments, each in a <file> element, before the
+// message.
+func buildPrompt(message string, atts []attachment) string {
+	var b strings.Builder
+	for _, att := range atts {
+		fmt.Fprintf(&b, "<file name=%q type=%q>\n%s", att.Name, att.ContentType, att.Text)
+		if !strings.HasSuffix(att.Text, "\n") {
+			b.WriteByte('\n')
+		}
+		b.WriteString("</file>\n\n")
+	}
+	b.WriteString(message)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// checkTokenBudget fails when prompt and maxTokens together exceed the
+// context window of the model the service would use first. Models without
+// a known context window are not checked.
+func checkTokenBudget(service *llm.Service, model, prompt string, maxTokens int) error {
+	resolved, candidates, err := service.ExplainModel(model)
+	if err != nil || len(candidates) == 0 {
+		return nil
+	}
+	provider := candidates[0].ServiceID
+	if provider == "" {
+		provider = candidates[0].Provider.Name()
+	}
+	window, ok := llm.ContextWindow(provider, resolved.RequestedModel)
+	if !ok {
+		return nil
+	}
+	tokens, err := tokencount.CountText(resolved.RequestedModel, prompt)
+	if err != nil {
+		return nil
+	}
+	if tokens+maxTokens > window {
+		return fmt.Errorf("prompt is about %d tokens; with --max-tokens %d it exceeds the %d token context window of %s",
+			tokens, maxTokens, window, resolved.RequestedModel)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAttachment(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	for name, tc := range map[string]struct {
+		file        string
+		data        []byte
+		contentType string
+		err         string
+	}{
+		"plain text":    {"notes", []byte("hello"), "text/plain", ""},
+		"json by ext":   {"data.json", []byte(`{"a":1}`), "application/json", ""},
+		"source by ext": {"main.ts", []byte("const a = 1"), "", ""},
+		"image":         {"shot.png", png, "", "image attachments are not supported yet"},
+		"image by ext":  {"photo.jpg", []byte("not really"), "", "image attachments are not supported yet"},
+		"binary":        {"blob", []byte{0, 1, 2, 3}, "", "cannot attach application/octet-stream content"},
+		"pdf":           {"doc.pdf", []byte("%PDF-1.7\n"), "", "cannot attach application/pdf content"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			att, err := newAttachment(tc.file, tc.data)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.contentType != "" {
+				assert.Equal(t, tc.contentType, att.ContentType)
+			}
+			assert.Equal(t, string(tc.data), att.Text)
+		})
+	}
+}
+
+func TestInferOpts_Prompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+
+	prompt, err := inferOpts{UserMsg: "summarize", Stdin: []byte("piped"), Files: []string{path}}.prompt()
+	require.NoError(t, err)
+	assert.Equal(t, "<file name=\"stdin\" type=\"text/plain\">\npiped\n</file>\n\n"+
+		"<file name=\"report.json\" type=\"application/json\">\n{}\n</file>\n\nsummarize", prompt)
+
+	prompt, err = inferOpts{Stdin: []byte("only input\n")}.prompt()
+	require.NoError(t, err)
+	assert.Equal(t, "<file name=\"stdin\" type=\"text/plain\">\nonly input\n</file>", prompt)
+
+	_, err = inferOpts{}.prompt()
+	assert.Error(t, err)
+}
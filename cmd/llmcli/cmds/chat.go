@@ -0,0 +1,271 @@
+package cmds
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codewandler/llm"
+	"github.com/spf13/cobra"
+)
+
+const ansiCyan = "\033[36m"
+
+// NewChatCmd returns the chat command.
+func NewChatCmd(root *RootFlags) *cobra.Command {
+	var opts chatOpts
+
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Chat with an LLM in an interactive session",
+		Long: `Start an interactive chat. Every line you enter is sent with the
+conversation so far and the reply is streamed back. Replies are rendered
+as Markdown when stdout is a terminal.
+
+Commands:
+  /model <name>   Switch the model for the following turns
+  /reset          Forget the conversation
+  /exit           Leave the chat (also Ctrl-D)
+
+Examples:
+  llmcli chat
+  llmcli chat -m powerful -s "You are terse."`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChat(cmd.Context(), opts, root)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.Model, "model", "m", "default", "Model alias or full path")
+	f.StringVarP(&opts.System, "system", "s", "", "System prompt")
+	f.IntVar(&opts.MaxTokens, "max-tokens", 8_000, "Max tokens to generate per reply")
+	f.TextVar(&opts.Thinking, "thinking", llm.ThinkingMode(""), "Thinking mode: auto, on, off")
+	f.TextVar(&opts.Effort, "effort", llm.Effort(""), "Effort: low, medium, high, max")
+	f.BoolVar(&opts.Plain, "plain", false, "Print replies without Markdown rendering")
+
+	return cmd
+}
+
+type chatOpts struct {
+	Model     string
+	System    string
+	MaxTokens int
+	Thinking  llm.ThinkingMode // f.TextVar
+	Effort    llm.Effort       // f.TextVar
+	Plain     bool
+}
+
+func runChat(ctx context.Context, opts chatOpts, root *RootFlags) error {
+	httpClient, logHandler := root.BuildHTTPClient()
+	service, closeUsageLog, err := createProvider(ctx, root, httpClient, root.BuildLLMOptions(logHandler)...)
+	if err != nil {
+		return err
+	}
+	defer closeUsageLog()
+	s := newChatSession(service, opts, os.Stdout, !opts.Plain && isTerminal(os.Stdout))
+	return s.run(ctx, os.Stdin)
+}
+
+// chatSession is the state of an interactive chat.
+type chatSession struct {
+	streamer llm.Streamer
+	opts     chatOpts
+	conv     *llm.Conversation
+	out      io.Writer
+	markdown bool
+}
+
+func newChatSession(s llm.Streamer, opts chatOpts, out io.Writer, markdown bool) *chatSession {
+	c := &chatSession{streamer: s, opts: opts, out: out, markdown: markdown}
+	c.reset()
+	return c
+}
+
+func (c *chatSession) reset() {
+	if c.opts.System != "" {
+		c.conv = llm.NewConversation(llm.System(c.opts.System))
+		return
+	}
+	c.conv = llm.NewConversation()
+}
+
+// run reads lines from in until EOF, /exit or cancellation of ctx. Failed
+// turns are reported and the session continues.
+func (c *chatSession) run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for {
+		fmt.Fprint(c.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			if c.command(line) {
+				return nil
+			}
+			continue
+		}
+		if err := c.turn(ctx, line); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(c.out, "error: %v\n", err)
+		}
+	}
+}
+
+// command runs a slash command and reports whether the session ends.
+func (c *chatSession) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/exit", "/quit":
+		return true
+	case "/reset":
+		c.reset()
+		fmt.Fprintln(c.out, "conversation reset")
+	case "/model":
+		if arg == "" {
+			fmt.Fprintf(c.out, "model: %s\n", c.opts.Model)
+			break
+		}
+		c.opts.Model = arg
+		fmt.Fprintf(c.out, "model set to %s\n", arg)
+	default:
+		fmt.Fprintf(c.out, "unknown command %s; use /model, /reset or /exit\n", name)
+	}
+	return false
+}
+
+// turn sends text and streams the reply.
+func (c *chatSession) turn(ctx context.Context, text string) error {
+	stream, err := c.conv.Send(ctx, c.streamer, llm.Request{
+		Model:     c.opts.Model,
+		Messages:  llm.Messages{llm.User(text)},
+		MaxTokens: c.opts.MaxTokens,
+		Thinking:  c.opts.Thinking,
+		Effort:    c.opts.Effort,
+	})
+	if err != nil {
+		return err
+	}
+
+	reply := &markdownWriter{w: c.out, plain: !c.markdown}
+	var inReasoning bool
+	result := llm.NewEventProcessor(ctx, stream).
+		OnReasoningDelta(func(chunk string) {
+			if !inReasoning {
+				fmt.Fprint(c.out, ansiDim)
+				inReasoning = true
+			}
+			fmt.Fprint(c.out, chunk)
+		}).
+		OnTextDelta(func(chunk string) {
+			if inReasoning {
+				fmt.Fprint(c.out, ansiReset+"\n")
+				inReasoning = false
+			}
+			reply.WriteString(chunk)
+		}).
+		Result()
+	if inReasoning {
+		fmt.Fprint(c.out, ansiReset)
+	}
+	reply.Flush()
+	fmt.Fprintln(c.out)
+	return result.Error()
+}
+
+// markdownWriter styles streamed Markdown line by line: headings are bold,
+// code fences dim and code blocks cyan. The start of a line is held back
+// until its style is known; the rest streams through.
+type markdownWriter struct {
+	w     io.Writer
+	plain bool
+
+	pending string // start of the current line, not yet written
+	started bool   // the current line's style has been written
+	style   string
+	inCode  bool
+}
+
+func (m *markdownWriter) WriteString(s string) {
+	if m.plain {
+		_, _ = io.WriteString(m.w, s)
+		return
+	}
+	for s != "" {
+		line, rest, newline := strings.Cut(s, "\n")
+		m.write(line)
+		if !newline {
+			return
+		}
+		m.endLine()
+		s = rest
+	}
+}
+
+// Flush writes a held back line start and resets the style.
+func (m *markdownWriter) Flush() {
+	if m.plain {
+		return
+	}
+	if m.pending != "" {
+		m.start()
+	}
+	if m.style != "" {
+		_, _ = io.WriteString(m.w, ansiReset)
+		m.style = ""
+	}
+	m.started = false
+}
+
+func (m *markdownWriter) write(s string) {
+	if m.started {
+		_, _ = io.WriteString(m.w, s)
+		return
+	}
+	m.pending += s
+	if len(strings.TrimLeft(m.pending, " \t")) >= len("```") {
+		m.start()
+	}
+}
+
+func (m *markdownWriter) start() {
+	trimmed := strings.TrimLeft(m.pending, " \t")
+	switch {
+	case strings.HasPrefix(trimmed, "```"):
+		m.inCode = !m.inCode
+		m.style = ansiDim
+	case m.inCode:
+		m.style = ansiCyan
+	case strings.HasPrefix(trimmed, "#"):
+		m.style = ansiBold
+	default:
+		m.style = ""
+	}
+	_, _ = io.WriteString(m.w, m.style+m.pending)
+	m.pending = ""
+	m.started = true
+}
+
+func (m *markdownWriter) endLine() {
+	if !m.started {
+		m.start()
+	}
+	if m.style != "" {
+		_, _ = io.WriteString(m.w, ansiReset)
+	}
+	_, _ = io.WriteString(m.w, "\n")
+	m.style = ""
+	m.started = false
+}
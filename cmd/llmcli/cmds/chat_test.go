@@ -0,0 +1,54 @@
+package cmds
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func TestChatSession_Run(t *testing.T) {
+	var reqs []llm.Request
+	streamer := llm.StreamFunc(func(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		require.NoError(t, err)
+		reqs = append(reqs, req)
+		return llmtest.SendEvents(llmtest.TextEvent("hi there"), llmtest.CompletedEvent(llm.StopReasonEndTurn)), nil
+	})
+	var out strings.Builder
+	s := newChatSession(streamer, chatOpts{Model: "fast", System: "be brief"}, &out, false)
+
+	in := "hello\n/model powerful\nagain\n/reset\nfresh\n/exit\nignored\n"
+	require.NoError(t, s.run(context.Background(), strings.NewReader(in)))
+
+	require.Len(t, reqs, 3)
+	assert.Equal(t, "fast", reqs[0].Model)
+	assert.Len(t, reqs[0].Messages, 2)
+	assert.Equal(t, "powerful", reqs[1].Model)
+	assert.Len(t, reqs[1].Messages, 4, "the second turn carries the history")
+	assert.Len(t, reqs[2].Messages, 2, "/reset keeps only the system prompt")
+	assert.Contains(t, out.String(), "hi there")
+	assert.Contains(t, out.String(), "model set to powerful")
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	var out strings.Builder
+	w := &markdownWriter{w: &out}
+	for _, chunk := range []string{"# Ti", "tle\nplain ", "text\n``", "`go\nx := 1\n```\ntail"} {
+		w.WriteString(chunk)
+	}
+	w.Flush()
+
+	want := ansiBold + "# Title" + ansiReset + "\n" +
+		"plain text\n" +
+		ansiDim + "```go" + ansiReset + "\n" +
+		ansiCyan + "x := 1" + ansiReset + "\n" +
+		ansiDim + "```" + ansiReset + "\n" +
+		"tail"
+	assert.Equal(t, want, out.String())
+}
@@ -0,0 +1,259 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/spf13/cobra"
+)
+
+// NewDebugCmd returns the debug command group.
+func NewDebugCmd(root *RootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Tools for debugging providers",
+	}
+	cmd.AddCommand(newDebugStreamCmd(root))
+	cmd.AddCommand(newDebugFixtureCmd())
+	return cmd
+}
+
+type debugStreamOpts struct {
+	UserMsg string
+
+	Model     string
+	System    string
+	MaxTokens int
+	Thinking  llm.ThinkingMode
+	Effort    llm.Effort
+	ApiType   llm.ApiType
+	DemoTools bool
+	Raw       bool
+	Events    bool
+	Refresh   time.Duration
+	Plain     bool
+}
+
+func newDebugStreamCmd(root *RootFlags) *cobra.Command {
+	var opts debugStreamOpts
+
+	cmd := &cobra.Command{
+		Use:   "stream <message>",
+		Short: "Render a provider stream live for debugging",
+		Long: `Send a message and render the stream in a live terminal view: status,
+text and reasoning deltas, tool-call accumulation, usage, warnings, the
+event log and the raw HTTP frames read from the provider.
+
+Hotkeys (when attached to a terminal):
+  r  toggle raw frames
+  e  toggle event log
+  q  cancel the stream
+
+When stdout is not a terminal, or with --plain, every event is printed as
+one line instead.
+
+Examples:
+  llmcli debug stream "Hello"
+  llmcli debug stream -m openai/gpt-5 --raw "Hello"
+  llmcli debug stream --demo-tools --events "Remember that Go is fun"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.UserMsg = args[0]
+			return runDebugStream(cmd.Context(), opts, root)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.Model, "model", "m", "fast", "Model alias or full path")
+	f.StringVarP(&opts.System, "system", "s", "", "System prompt")
+	f.IntVar(&opts.MaxTokens, "max-tokens", 8_000, "Max tokens to generate")
+	f.TextVar(&opts.Thinking, "thinking", llm.ThinkingMode(""), "Thinking mode: auto, on, off")
+	f.TextVar(&opts.Effort, "effort", llm.Effort(""), "Effort: low, medium, high, max")
+	f.TextVar(&opts.ApiType, "api", llm.ApiType(""), "API backend hint: auto, openai-chat, openai-responses, anthropic-messages")
+	f.BoolVar(&opts.DemoTools, "demo-tools", false, "Offer the demo tools (add_fact + complete_turn)")
+	f.BoolVar(&opts.Raw, "raw", false, "Show raw HTTP frames")
+	f.BoolVar(&opts.Events, "events", false, "Show the event log")
+	f.DurationVar(&opts.Refresh, "refresh", 50*time.Millisecond, "Redraw interval")
+	f.BoolVar(&opts.Plain, "plain", false, "Print one line per event instead of the live view")
+
+	return cmd
+}
+
+func runDebugStream(ctx context.Context, opts debugStreamOpts, root *RootFlags) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	view := newStreamView(time.Now())
+	live := !opts.Plain && isTerminal(os.Stdout)
+
+	onFrame := view.AddFrame
+	if !live {
+		onFrame = func(line string) { fmt.Fprintf(os.Stderr, "%s[raw]%s %s\n", ansiDim, ansiReset, line) }
+		if !opts.Raw {
+			onFrame = func(string) {}
+		}
+	}
+	baseClient, logHandler := root.BuildHTTPClient()
+	service, closeUsageLog, err := createProvider(ctx, root, frameTapClient(baseClient, onFrame), root.BuildLLMOptions(logHandler)...)
+	if err != nil {
+		return err
+	}
+	defer closeUsageLog()
+
+	b := llm.NewRequestBuilder().
+		Model(opts.Model).
+		Effort(opts.Effort).
+		Thinking(opts.Thinking).
+		ApiTypeHint(opts.ApiType).
+		MaxTokens(opts.MaxTokens)
+	if opts.System != "" {
+		b = b.System(opts.System)
+	}
+	b = b.User(opts.UserMsg)
+	if opts.DemoTools {
+		defs, _ := buildDemoTools()
+		b = b.Tools(defs...)
+	}
+	req, err := b.Build()
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	if !live {
+		return debugStreamPlain(ctx, service, req, view)
+	}
+	return debugStreamLive(ctx, cancel, service, req, view, viewOpts{Raw: opts.Raw, Events: opts.Events}, opts.Refresh)
+}
+
+// debugStreamPlain prints one line per event, then the final view.
+func debugStreamPlain(ctx context.Context, s llm.Streamer, req llm.Request, view *streamView) error {
+	stream, err := s.CreateStream(ctx, req)
+	if err != nil {
+		return fmt.Errorf("create stream: %w", err)
+	}
+	for env := range stream {
+		view.Apply(env)
+		fmt.Println(view.describe(env))
+	}
+	fmt.Println()
+	fmt.Print(view.Render(viewOpts{}))
+	return view.err
+}
+
+func debugStreamLive(ctx context.Context, cancel context.CancelFunc, s llm.Streamer, req llm.Request, view *streamView, vo viewOpts, refresh time.Duration) error {
+	var mu sync.Mutex
+	toggle := func(fn func(*viewOpts)) {
+		mu.Lock()
+		fn(&vo)
+		mu.Unlock()
+	}
+	restore := readHotkeys(func(key byte) {
+		switch key {
+		case 'r':
+			toggle(func(o *viewOpts) { o.Raw = !o.Raw })
+		case 'e':
+			toggle(func(o *viewOpts) { o.Events = !o.Events })
+		case 'q':
+			cancel()
+		}
+	})
+	defer restore()
+
+	width, height := terminalSize()
+	draw := func() {
+		mu.Lock()
+		o := vo
+		mu.Unlock()
+		o.Width, o.Height = width, height
+		fmt.Print("\033[H\033[2J" + view.Render(o))
+		fmt.Printf("%s[r] raw frames  [e] events  [q] cancel%s\n", ansiDim, ansiReset)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream, err := s.CreateStream(ctx, req)
+		if err != nil {
+			view.Fail(fmt.Errorf("create stream: %w", err))
+			return
+		}
+		for env := range stream {
+			view.Apply(env)
+		}
+	}()
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			draw()
+		case <-done:
+			draw()
+			return view.err
+		}
+	}
+}
+
+// readHotkeys switches the terminal on stdin to unbuffered input without echo
+// and calls fn for each key read. The returned function restores the
+// terminal. Without a terminal (or stty) it does nothing.
+func readHotkeys(fn func(byte)) (restore func()) {
+	if !isTerminal(os.Stdin) {
+		return func() {}
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return func() {}
+	}
+	if _, err := stty("cbreak", "-echo"); err != nil {
+		return func() {}
+	}
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				return
+			}
+			fn(buf[0])
+		}
+	}()
+	return func() { _, _ = stty(strings.TrimSpace(saved)) }
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// terminalSize returns the size of the terminal on stdin, falling back to
+// $COLUMNS and $LINES.
+func terminalSize() (width, height int) {
+	if out, err := stty("size"); err == nil {
+		if f := strings.Fields(out); len(f) == 2 {
+			height, _ = strconv.Atoi(f[0])
+			width, _ = strconv.Atoi(f[1])
+		}
+	}
+	if width == 0 {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if height == 0 {
+		height, _ = strconv.Atoi(os.Getenv("LINES"))
+	}
+	return width, height
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
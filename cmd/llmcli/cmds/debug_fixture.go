@@ -0,0 +1,270 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/internal/sse"
+	"github.com/codewandler/llm/provider/anthropic"
+	"github.com/codewandler/llm/provider/dockermr"
+	"github.com/codewandler/llm/provider/groq"
+	"github.com/codewandler/llm/provider/openai"
+	"github.com/codewandler/llm/provider/openrouter"
+)
+
+// fixtureProvider describes a provider whose streams can be replayed from an
+// SSE fixture.
+type fixtureProvider struct {
+	model string
+	new   func(opts ...llm.Option) llm.Provider
+}
+
+var fixtureProviders = map[string]fixtureProvider{
+	"anthropic":  {model: "claude-sonnet-4-6", new: func(opts ...llm.Option) llm.Provider { return anthropic.New(opts...) }},
+	"dockermr":   {model: "ai/smollm2", new: func(opts ...llm.Option) llm.Provider { return dockermr.New(opts...) }},
+	"groq":       {model: "llama-3.3-70b-versatile", new: func(opts ...llm.Option) llm.Provider { return groq.New(opts...) }},
+	"openai":     {model: "gpt-5.4", new: func(opts ...llm.Option) llm.Provider { return openai.New(opts...) }},
+	"openrouter": {model: "openai/gpt-4o", new: func(opts ...llm.Option) llm.Provider { return openrouter.New(opts...) }},
+}
+
+type debugFixtureOpts struct {
+	Capture  string
+	Provider string
+	Name     string
+	Model    string
+	Dir      string
+	MaxRun   int
+}
+
+func newDebugFixtureCmd() *cobra.Command {
+	var opts debugFixtureOpts
+
+	cmd := &cobra.Command{
+		Use:   "fixture <capture>",
+		Short: "Turn a captured provider response into an SSE test fixture",
+		Long: `Minimize a captured raw provider response (an SSE body, optionally with
+the HTTP head written by curl -i) into <dir>/testdata/<name>.sse, replay it
+through the provider, and add a table-driven test case asserting the
+replayed text, tool calls and stop reason.
+
+The test goes to <dir>/stream_fixtures_test.go. When that file already
+exists, the case to add to its table is printed instead. Edit the expected
+values when the capture reproduces a parsing bug.
+
+Examples:
+  curl -sN -i https://api.openai.com/v1/responses ... > capture.txt
+  llmcli debug fixture -p openai -n tool_call capture.txt
+  llmcli debug fixture -p anthropic -n thinking --max-run 3 capture.txt`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Capture = args[0]
+			return runDebugFixture(cmd.Context(), opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.Provider, "provider", "p", "", "Provider package: "+strings.Join(fixtureProviderNames(), ", "))
+	f.StringVarP(&opts.Name, "name", "n", "", "Fixture name (file and test case)")
+	f.StringVarP(&opts.Model, "model", "m", "", "Model used for the replay (default: a model of the provider)")
+	f.StringVar(&opts.Dir, "dir", "", "Provider package directory (default: provider/<provider>)")
+	f.IntVar(&opts.MaxRun, "max-run", 3, "Keep at most this many consecutive events of the same kind (0 keeps all)")
+	_ = cmd.MarkFlagRequired("provider")
+	_ = cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func fixtureProviderNames() []string {
+	names := make([]string, 0, len(fixtureProviders))
+	for name := range fixtureProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var fixtureNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func runDebugFixture(ctx context.Context, opts debugFixtureOpts) error {
+	fp, ok := fixtureProviders[opts.Provider]
+	if !ok {
+		return fmt.Errorf("unsupported provider %q; supported: %s", opts.Provider, strings.Join(fixtureProviderNames(), ", "))
+	}
+	if !fixtureNamePattern.MatchString(opts.Name) {
+		return fmt.Errorf("invalid fixture name %q: use lowercase letters, digits and underscores", opts.Name)
+	}
+	if opts.Model == "" {
+		opts.Model = fp.model
+	}
+	if opts.Dir == "" {
+		opts.Dir = filepath.Join("provider", opts.Provider)
+	}
+
+	f, err := os.Open(opts.Capture)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fixture, err := sse.Minimize(f, sse.MinimizeOptions{MaxRun: opts.MaxRun})
+	if err != nil {
+		return fmt.Errorf("minimize %s: %w", opts.Capture, err)
+	}
+
+	c, err := replayFixture(ctx, fp, opts.Model, fixture)
+	if err != nil {
+		return err
+	}
+	tc := fixtureCase{File: opts.Name + ".sse", Text: c.Text, StopReason: c.StopReason}
+	for _, call := range c.ToolCalls {
+		tc.ToolCalls = append(tc.ToolCalls, call.ToolName())
+	}
+
+	fixturePath := filepath.Join(opts.Dir, "testdata", tc.File)
+	if err := os.MkdirAll(filepath.Dir(fixturePath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fixturePath, fixture, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s (%d bytes)\n", fixturePath, len(fixture))
+
+	testPath := filepath.Join(opts.Dir, "stream_fixtures_test.go")
+	if _, err := os.Stat(testPath); err == nil {
+		fmt.Fprintf(os.Stderr, "%s exists; add this case to its table:\n", testPath)
+		fmt.Println(tc.GoLiteral())
+		return nil
+	}
+	src, err := renderFixtureTest(filepath.Base(opts.Dir), opts.Model, []fixtureCase{tc})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(testPath, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", testPath)
+	return nil
+}
+
+// replayFixture streams fixture through a fresh provider pointed at a local
+// server and collects the result.
+func replayFixture(ctx context.Context, fp fixtureProvider, model string, fixture []byte) (*llm.Completion, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	}))
+	defer server.Close()
+
+	p := fp.new(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+	stream, err := p.CreateStream(ctx, llm.Request{Model: model, Messages: llm.Messages{llm.User("hi")}})
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	c, err := llm.Collect(stream)
+	if err != nil {
+		// Keep going: the capture may reproduce exactly this error.
+		fmt.Fprintf(os.Stderr, "replay reported: %v\n", err)
+	}
+	return c, nil
+}
+
+// fixtureCase is one row of the generated test table.
+type fixtureCase struct {
+	File       string
+	Text       string
+	ToolCalls  []string
+	StopReason llm.StopReason
+}
+
+// GoLiteral renders c as an element of the generated table.
+func (c fixtureCase) GoLiteral() string {
+	tools := "nil"
+	if len(c.ToolCalls) > 0 {
+		quoted := make([]string, len(c.ToolCalls))
+		for i, name := range c.ToolCalls {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		tools = "[]string{" + strings.Join(quoted, ", ") + "}"
+	}
+	return fmt.Sprintf("{fixture: %q, wantText: %q, wantToolCalls: %s, wantStop: %q},", c.File, c.Text, tools, c.StopReason)
+}
+
+var fixtureTestTemplate = template.Must(template.New("fixture").Parse(`package {{.Package}}
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+// Fixtures are generated by "llmcli debug fixture" from captured responses.
+func TestCreateStream_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantText      string
+		wantToolCalls []string
+		wantStop      llm.StopReason
+	}{
+{{- range .Cases}}
+		{{.GoLiteral}}
+{{- end}}
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			require.NoError(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write(body)
+			}))
+			defer server.Close()
+
+			p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+			stream, err := p.CreateStream(t.Context(), llm.Request{Model: {{printf "%q" .Model}}, Messages: llm.Messages{llm.User("hi")}})
+			require.NoError(t, err)
+			c, _ := llm.Collect(stream)
+
+			assert.Equal(t, tt.wantText, c.Text)
+			var names []string
+			for _, call := range c.ToolCalls {
+				names = append(names, call.ToolName())
+			}
+			assert.Equal(t, tt.wantToolCalls, names)
+			assert.Equal(t, tt.wantStop, c.StopReason)
+		})
+	}
+}
+`))
+
+// renderFixtureTest returns the gofmt-ed source of a fixture test for pkg.
+func renderFixtureTest(pkg, model string, cases []fixtureCase) ([]byte, error) {
+	var buf bytes.Buffer
+	err := fixtureTestTemplate.Execute(&buf, struct {
+		Package string
+		Model   string
+		Cases   []fixtureCase
+	}{pkg, model, cases})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,20 @@
+package cmds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+func TestRenderFixtureTest(t *testing.T) {
+	src, err := renderFixtureTest("openai", "gpt-5.4", []fixtureCase{
+		{File: "tool_call.sse", ToolCalls: []string{"get_weather"}, StopReason: llm.StopReasonToolUse},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "package openai\n")
+	assert.Contains(t, string(src), `{fixture: "tool_call.sse", wantText: "", wantToolCalls: []string{"get_weather"}, wantStop: "tool_use"},`)
+	assert.Contains(t, string(src), `Model: "gpt-5.4"`)
+}
@@ -0,0 +1,469 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+// streamView accumulates the state of one stream for the debug TUI. Apply
+// and AddFrame may be called from different goroutines than Render.
+type streamView struct {
+	mu sync.Mutex
+
+	start     time.Time
+	firstByte time.Duration
+	done      time.Duration
+
+	provider  string
+	model     string
+	requestID string
+	status    string
+	stop      llm.StopReason
+	err       error
+
+	counts    map[llm.EventType]int
+	text      strings.Builder
+	reasoning strings.Builder
+	tools     []*toolState
+	usage     []usage.Record
+	estimate  *usage.Record
+	warnings  []string
+
+	events []string
+	frames []string
+}
+
+// toolState tracks the accumulation of one streamed tool call.
+type toolState struct {
+	key       string
+	id        string
+	name      string
+	args      strings.Builder
+	fragments int
+	complete  bool
+}
+
+// viewOpts selects the panels Render shows. Toggled by hotkeys.
+type viewOpts struct {
+	Raw    bool
+	Events bool
+	Width  int
+	Height int
+}
+
+const maxViewLog = 200
+
+func newStreamView(start time.Time) *streamView {
+	return &streamView{start: start, status: "waiting", counts: map[llm.EventType]int{}}
+}
+
+// Apply folds env into the view.
+func (v *streamView) Apply(env llm.Envelope) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.counts[env.Type]++
+	v.events = appendRing(v.events, v.describe(env))
+
+	switch ev := env.Data.(type) {
+	case *llm.StreamStartedEvent:
+		v.status = "streaming"
+		v.requestID = ev.RequestID
+		v.provider = ev.Provider
+		if ev.Model != "" {
+			v.model = ev.Model
+		}
+	case *llm.ModelResolvedEvent:
+		if ev.Resolved != "" {
+			v.model = ev.Resolved
+		}
+	case *llm.DeltaEvent:
+		if v.firstByte == 0 {
+			v.firstByte = time.Since(v.start)
+		}
+		switch ev.Kind {
+		case llm.DeltaKindText:
+			v.text.WriteString(ev.Text)
+		case llm.DeltaKindThinking:
+			v.reasoning.WriteString(ev.Thinking)
+		case llm.DeltaKindTool:
+			t := v.toolFor(ev)
+			if ev.ToolID != "" {
+				t.id = ev.ToolID
+			}
+			if ev.ToolName != "" {
+				t.name = ev.ToolName
+			}
+			t.args.WriteString(ev.ToolArgs)
+			t.fragments++
+		}
+	case *llm.ToolCallEvent:
+		v.completeTool(ev.ToolCall)
+	case *llm.UsageUpdatedEvent:
+		v.usage = append(v.usage, ev.Record)
+	case *llm.TokenEstimateEvent:
+		if ev.Estimate.Dims.Labels == nil {
+			rec := ev.Estimate
+			v.estimate = &rec
+		}
+	case *llm.WarningEvent:
+		v.warnings = append(v.warnings, fmt.Sprintf("%s: %s", ev.Code, ev.Message))
+	case *llm.ProviderFailoverEvent:
+		v.warnings = append(v.warnings, fmt.Sprintf("failover %s → %s: %v", ev.Provider, ev.FailoverProvider, ev.Error))
+	case *llm.CompletedEvent:
+		v.stop = ev.StopReason
+		v.status = "completed"
+		v.done = time.Since(v.start)
+	case *llm.ErrorEvent:
+		v.err = ev.Error
+		v.status = "error"
+		v.done = time.Since(v.start)
+	}
+}
+
+// Fail records an error that ended the stream before or outside the event
+// stream, such as a failed CreateStream.
+func (v *streamView) Fail(err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.err = err
+	v.status = "error"
+	v.done = time.Since(v.start)
+}
+
+// AddFrame records one raw line read from the provider response.
+func (v *streamView) AddFrame(line string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.frames = appendRing(v.frames, line)
+}
+
+// toolFor returns the tool call a delta belongs to. Providers identify
+// fragments by block index, by call ID, or only on the first fragment.
+func (v *streamView) toolFor(ev *llm.DeltaEvent) *toolState {
+	key := ev.ToolID
+	if ev.Index != nil {
+		key = fmt.Sprintf("#%d", *ev.Index)
+	}
+	for _, t := range v.tools {
+		if key != "" && (t.key == key || t.id == key) {
+			return t
+		}
+	}
+	if key == "" && len(v.tools) > 0 {
+		return v.tools[len(v.tools)-1]
+	}
+	t := &toolState{key: key}
+	v.tools = append(v.tools, t)
+	return t
+}
+
+func (v *streamView) completeTool(tc tool.Call) {
+	var t *toolState
+	for _, s := range v.tools {
+		if s.id == tc.ToolCallID() {
+			t = s
+		}
+	}
+	if t == nil {
+		t = &toolState{key: tc.ToolCallID(), id: tc.ToolCallID(), name: tc.ToolName()}
+		v.tools = append(v.tools, t)
+	}
+	if t.fragments == 0 {
+		if b, err := tool.CanonicalJSON(tc.ToolArgs()); err == nil {
+			t.args.Write(b)
+		}
+	}
+	t.complete = true
+}
+
+// describe returns a one-line summary of env for the event log.
+func (v *streamView) describe(env llm.Envelope) string {
+	at := env.Meta.After
+	if at == 0 {
+		at = time.Since(v.start)
+	}
+	detail := ""
+	switch ev := env.Data.(type) {
+	case *llm.DeltaEvent:
+		switch ev.Kind {
+		case llm.DeltaKindText:
+			detail = fmt.Sprintf("text %q", ev.Text)
+		case llm.DeltaKindThinking:
+			detail = fmt.Sprintf("thinking %q", ev.Thinking)
+		case llm.DeltaKindTool:
+			detail = fmt.Sprintf("tool id=%s name=%s args=%q", ev.ToolID, ev.ToolName, ev.ToolArgs)
+		}
+		if ev.Index != nil {
+			detail = fmt.Sprintf("[%d] %s", *ev.Index, detail)
+		}
+	case *llm.ToolCallEvent:
+		detail = fmt.Sprintf("%s id=%s", ev.ToolCall.ToolName(), ev.ToolCall.ToolCallID())
+	case *llm.CompletedEvent:
+		detail = string(ev.StopReason)
+	case *llm.ErrorEvent:
+		detail = fmt.Sprint(ev.Error)
+	case *llm.UsageUpdatedEvent:
+		detail = formatTokens(ev.Record)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%8s %-15s %s", at.Round(time.Millisecond), env.Type, detail))
+}
+
+// Render draws the view into a string sized for opts.Width × opts.Height.
+func (v *streamView) Render(opts viewOpts) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	width := opts.Width
+	if width <= 0 {
+		width = 100
+	}
+	var b strings.Builder
+
+	elapsed := v.done
+	if elapsed == 0 {
+		elapsed = time.Since(v.start)
+	}
+	status := v.status
+	if v.stop != "" {
+		status += " (" + string(v.stop) + ")"
+	}
+	section(&b, "stream", width)
+	fields := []kvField{
+		{"status", status},
+		{"model", v.model},
+		{"provider", v.provider},
+		{"request_id", v.requestID},
+		{"elapsed", elapsed.Round(time.Millisecond).String()},
+	}
+	if v.firstByte > 0 {
+		fields = append(fields, kvField{"first_token", v.firstByte.Round(time.Millisecond).String()})
+	}
+	fields = append(fields, kvField{"events", formatCounts(v.counts)})
+	if v.err != nil {
+		fields = append(fields, kvField{"error", v.err.Error()})
+	}
+	writeFields(&b, fields, width)
+
+	if v.reasoning.Len() > 0 {
+		section(&b, fmt.Sprintf("reasoning (%d chars)", v.reasoning.Len()), width)
+		writeTail(&b, v.reasoning.String(), 4, width, ansiDim)
+	}
+	section(&b, fmt.Sprintf("text (%d chars)", v.text.Len()), width)
+	writeTail(&b, v.text.String(), textLines(opts), width, "")
+
+	if len(v.tools) > 0 {
+		section(&b, "tool calls", width)
+		for i, t := range v.tools {
+			state := "streaming"
+			if t.complete {
+				state = "complete"
+			}
+			args := t.args.String()
+			fmt.Fprintf(&b, "%s\n", truncate(fmt.Sprintf("[%d] %-9s %s id=%s fragments=%d %s", i, state, t.name, t.id, t.fragments, args), width))
+		}
+	}
+
+	if len(v.usage) > 0 || v.estimate != nil {
+		section(&b, "usage", width)
+		var uf []kvField
+		if v.estimate != nil {
+			uf = append(uf, kvField{"estimate", formatTokens(*v.estimate)})
+		}
+		for i, rec := range v.usage {
+			uf = append(uf, kvField{fmt.Sprintf("record[%d]", i), formatTokens(rec)})
+		}
+		writeFields(&b, uf, width)
+	}
+
+	if len(v.warnings) > 0 {
+		section(&b, "warnings", width)
+		for _, w := range v.warnings {
+			fmt.Fprintln(&b, truncate(w, width))
+		}
+	}
+
+	if opts.Events {
+		section(&b, "events", width)
+		writeLast(&b, v.events, 10, width)
+	}
+	if opts.Raw {
+		section(&b, "raw frames", width)
+		if len(v.frames) == 0 {
+			fmt.Fprintln(&b, ansiDim+"(no HTTP frames captured)"+ansiReset)
+		}
+		writeLast(&b, v.frames, 10, width)
+	}
+	return b.String()
+}
+
+func textLines(opts viewOpts) int {
+	if opts.Height <= 0 {
+		return 12
+	}
+	n := opts.Height / 3
+	if opts.Raw || opts.Events {
+		n = opts.Height / 5
+	}
+	return max(n, 3)
+}
+
+func appendRing(s []string, line string) []string {
+	if len(s) >= maxViewLog {
+		s = s[1:]
+	}
+	return append(s, line)
+}
+
+func section(b *strings.Builder, title string, width int) {
+	line := "── " + title + " "
+	if pad := width - len([]rune(line)); pad > 0 {
+		line += strings.Repeat("─", min(pad, 40))
+	}
+	fmt.Fprintf(b, "%s%s%s\n", ansiDim, line, ansiReset)
+}
+
+func writeFields(b *strings.Builder, fields []kvField, width int) {
+	maxWidth := 0
+	for _, f := range fields {
+		maxWidth = max(maxWidth, len(f.label))
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		fmt.Fprintln(b, truncate(fmt.Sprintf("%*s: %s", maxWidth, f.label, f.value), width))
+	}
+}
+
+// writeTail writes the last n lines of text, truncated to width, each
+// wrapped in style when set.
+func writeTail(b *strings.Builder, text string, n, width int, style string) {
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for _, l := range lines {
+		if style != "" {
+			l = style + truncate(l, width) + ansiReset
+		} else {
+			l = truncate(l, width)
+		}
+		fmt.Fprintln(b, l)
+	}
+}
+
+func writeLast(b *strings.Builder, lines []string, n, width int) {
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for _, l := range lines {
+		fmt.Fprintln(b, truncate(l, width))
+	}
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
+func formatCounts(counts map[llm.EventType]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%d", k, counts[llm.EventType(k)])
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTokens(rec usage.Record) string {
+	var parts []string
+	for _, item := range rec.Tokens.NonZero() {
+		parts = append(parts, fmt.Sprintf("%s=%d", item.Kind, item.Count))
+	}
+	if !rec.Cost.IsZero() {
+		parts = append(parts, "cost="+formatCost(rec.Cost.Total))
+	}
+	return strings.Join(parts, " ")
+}
+
+// frameTapClient returns a copy of c (llm.DefaultHttpClient when nil) that
+// passes every line of each response body to onLine.
+func frameTapClient(c *http.Client, onLine func(string)) *http.Client {
+	if c == nil {
+		c = llm.DefaultHttpClient()
+	}
+	out := *c
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	out.Transport = &frameTapTransport{next: next, onLine: onLine}
+	return &out
+}
+
+type frameTapTransport struct {
+	next   http.RoundTripper
+	onLine func(string)
+}
+
+func (t *frameTapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	t.onLine(fmt.Sprintf("%s %s → %s", req.Method, req.URL.Path, resp.Status))
+	resp.Body = &lineTap{ReadCloser: resp.Body, onLine: t.onLine}
+	return resp, nil
+}
+
+// lineTap passes the body through unchanged while reporting each complete,
+// non-empty line.
+type lineTap struct {
+	io.ReadCloser
+	onLine func(string)
+	line   []byte
+}
+
+func (l *lineTap) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	chunk := p[:n]
+	for len(chunk) > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			l.line = append(l.line, chunk...)
+			break
+		}
+		l.line = append(l.line, chunk[:i]...)
+		l.flush()
+		chunk = chunk[i+1:]
+	}
+	if err == io.EOF {
+		l.flush()
+	}
+	return n, err
+}
+
+func (l *lineTap) flush() {
+	if s := strings.TrimRight(string(l.line), "\r"); s != "" {
+		l.onLine(s)
+	}
+	l.line = l.line[:0]
+}
@@ -0,0 +1,92 @@
+package cmds
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+func env(ev llm.Event) llm.Envelope {
+	return llm.Envelope{Type: ev.Type(), Data: ev}
+}
+
+func TestStreamView_AccumulatesToolCalls(t *testing.T) {
+	v := newStreamView(time.Now())
+	v.Apply(env(&llm.StreamStartedEvent{RequestID: "req-1", Model: "m-1", Provider: "fake"}))
+	v.Apply(env(llm.TextDelta("Hello ")))
+	v.Apply(env(llm.TextDelta("world")))
+	v.Apply(env(llm.ToolDelta("call_1", "add_fact", `{"fa`).WithIndex(1)))
+	v.Apply(env(llm.ToolDelta("", "", `ct":"x"}`).WithIndex(1)))
+
+	require.Len(t, v.tools, 1)
+	assert.Equal(t, "call_1", v.tools[0].id)
+	assert.Equal(t, "add_fact", v.tools[0].name)
+	assert.Equal(t, `{"fact":"x"}`, v.tools[0].args.String())
+	assert.Equal(t, 2, v.tools[0].fragments)
+	assert.False(t, v.tools[0].complete)
+
+	v.Apply(env(&llm.ToolCallEvent{ToolCall: tool.NewToolCall("call_1", "add_fact", tool.Args{"fact": "x"})}))
+	v.Apply(env(&llm.UsageUpdatedEvent{Record: usage.Record{Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 12}}}}))
+	v.Apply(env(&llm.CompletedEvent{StopReason: llm.StopReasonToolUse}))
+
+	require.Len(t, v.tools, 1)
+	assert.True(t, v.tools[0].complete)
+	assert.Equal(t, "completed", v.status)
+	assert.Equal(t, 4, v.counts[llm.StreamEventDelta])
+
+	out := v.Render(viewOpts{Width: 120})
+	assert.Contains(t, out, "req-1")
+	assert.Contains(t, out, "Hello world")
+	assert.Contains(t, out, "complete  add_fact id=call_1 fragments=2")
+	assert.Contains(t, out, "input=12")
+	assert.Contains(t, out, "completed (tool_use)")
+	assert.NotContains(t, out, "raw frames")
+}
+
+func TestStreamView_CompleteToolWithoutDeltas(t *testing.T) {
+	v := newStreamView(time.Now())
+	v.Apply(env(&llm.ToolCallEvent{ToolCall: tool.NewToolCall("call_1", "add_fact", tool.Args{"fact": "x"})}))
+
+	require.Len(t, v.tools, 1)
+	assert.Equal(t, `{"fact":"x"}`, v.tools[0].args.String())
+	assert.True(t, v.tools[0].complete)
+}
+
+func TestStreamView_RenderPanels(t *testing.T) {
+	v := newStreamView(time.Now())
+	v.AddFrame(`data: {"x":1}`)
+	v.Apply(env(llm.TextDelta("hi")))
+
+	out := v.Render(viewOpts{Raw: true, Events: true, Width: 80})
+	assert.Contains(t, out, "raw frames")
+	assert.Contains(t, out, `data: {"x":1}`)
+	assert.Contains(t, out, "events")
+	assert.Contains(t, out, `text "hi"`)
+}
+
+func TestFrameTapClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "event: a\ndata: 1\n\ndata: 2")
+	}))
+	defer server.Close()
+
+	var lines []string
+	client := frameTapClient(server.Client(), func(l string) { lines = append(lines, l) })
+	resp, err := client.Get(server.URL + "/stream")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "event: a\ndata: 1\n\ndata: 2", string(body))
+	assert.Equal(t, []string{"GET /stream → 200 OK", "event: a", "data: 1", "data: 2"}, lines)
+}
@@ -3,7 +3,9 @@ package cmds
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 
@@ -18,8 +20,9 @@ func NewInferCmd(root *RootFlags) *cobra.Command {
 	var opts inferOpts
 
 	cmd := &cobra.Command{
-		Use:   "infer <message>",
-		Short: "Send a message to an LLM and stream the response",
+		Use:     "infer [message]",
+		Aliases: []string{"run"},
+		Short:   "Send a message to an LLM and stream the response",
 		Long: `Send a message using stored OAuth credentials.
 
 Uses all stored credential accounts, trying each in alphabetical order
@@ -27,6 +30,7 @@ until one succeeds (useful for rate limit fallback).
 
 Examples:
   llmcli infer "Hello, how are you?"					# auto thinking, provider-default effort
+  llmcli run -m openai/gpt-4o-mini "Hello"				# same command, explicit provider/model
   llmcli infer --effort high "Explain channels"			# high effort, auto thinking
   llmcli infer --effort max --thinking on "Explain this"	# max effort, force thinking on
   llmcli infer --thinking off "Quick answer"				# disable thinking
@@ -35,10 +39,25 @@ Examples:
   llmcli infer --max-tokens 512 "Short answer please"		# Limit output length
   llmcli infer --temperature 0.2 "Precise answer"			# Low randomness
   llmcli infer --tool-choice none --demo-tools "List facts"	# Tools available but not forced
-  llmcli infer --output-format json "Return a JSON object"	# Constrain to JSON output`,
-		Args: cobra.ExactArgs(1),
+  llmcli infer --output-format json "Return a JSON object"	# Constrain to JSON output
+  cat report.txt | llmcli run "Summarize"					# Attach piped input
+  llmcli run -f main.go -f go.mod "Review this"				# Attach files
+  llmcli run --json "Hello" | jq -r .text					# Final result as JSON
+  llmcli run --stream-jsonl "Hello"						# One JSON line per stream event
+
+Piped input and -f files are sent as text before the message; images and
+other binary files are rejected. The prompt is checked against the model's
+context window before sending.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.UserMsg = args[0]
+			if len(args) > 0 {
+				opts.UserMsg = args[0]
+			}
+			stdin, err := readStdin(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("read stdin: %w", err)
+			}
+			opts.Stdin = stdin
 			return runInfer(cmd.Context(), opts, root)
 		},
 	}
@@ -54,10 +73,15 @@ Examples:
 	f.IntVar(&opts.TopK, "top-k", 0, "Top-K limit (0 = provider default)")
 	f.TextVar(&opts.Thinking, "thinking", llm.ThinkingMode(""), "Thinking mode: auto, on, off")
 	f.TextVar(&opts.Effort, "effort", llm.Effort(""), "Effort: low, medium, high, max")
+	f.IntVar(&opts.ThinkingBudget, "thinking-budget", 0, "Thinking token budget (0 = derived from effort)")
 	f.TextVar(&opts.ApiTypeHint, "api", llm.ApiType(""),
 		"API backend hint: auto, openai-chat (or 'chat'), openai-responses (or 'responses'), anthropic-messages (or 'messages')")
 	f.TextVar(&opts.ToolChoice, "tool-choice", llm.ToolChoiceFlag{}, "Tool selection: auto, none, required, tool:<name>")
 	f.TextVar(&opts.OutputFormat, "output-format", llm.OutputFormat(""), "Output format: text, json")
+	f.StringArrayVarP(&opts.Files, "file", "f", nil, "Attach a text file (repeatable)")
+	f.BoolVar(&opts.JSON, "json", false, "Print the final message, tool calls, usage and cost as one JSON object")
+	f.BoolVar(&opts.StreamJSONL, "stream-jsonl", false, "Print every stream event as a JSON line")
+	cmd.MarkFlagsMutuallyExclusive("json", "stream-jsonl")
 
 	return cmd
 }
@@ -65,21 +89,27 @@ Examples:
 type inferOpts struct {
 	// Populated from the positional argument, not a flag.
 	UserMsg string
+	// Populated from piped stdin, not a flag.
+	Stdin []byte
 
 	// Flags — cobra writes directly via the appropriate Var methods.
-	Model        string
-	System       string
-	Verbose      bool
-	DemoTools    bool
-	MaxTokens    int
-	Temperature  float64
-	TopP         float64
-	TopK         int
-	Thinking     llm.ThinkingMode   // f.TextVar
-	Effort       llm.Effort         // f.TextVar
-	ApiTypeHint  llm.ApiType        // f.TextVar
-	ToolChoice   llm.ToolChoiceFlag // f.TextVar; nil Value = "not specified"
-	OutputFormat llm.OutputFormat   // f.TextVar
+	Model          string
+	System         string
+	Verbose        bool
+	DemoTools      bool
+	MaxTokens      int
+	Temperature    float64
+	TopP           float64
+	TopK           int
+	Thinking       llm.ThinkingMode // f.TextVar
+	Effort         llm.Effort       // f.TextVar
+	ThinkingBudget int
+	ApiTypeHint    llm.ApiType        // f.TextVar
+	ToolChoice     llm.ToolChoiceFlag // f.TextVar; nil Value = "not specified"
+	OutputFormat   llm.OutputFormat   // f.TextVar
+	Files          []string
+	JSON           bool
+	StreamJSONL    bool
 
 	// Populated by runInfer when DemoTools is true, not from flags.
 	demoToolHandlers []tool.NamedHandler
@@ -98,12 +128,33 @@ func (o inferOpts) resolveToolChoice() llm.ToolChoice {
 	return nil
 }
 
+// prompt returns the user message with piped input and attached files
+// placed before it.
+func (o inferOpts) prompt() (string, error) {
+	atts, err := readAttachments(o.Files)
+	if err != nil {
+		return "", err
+	}
+	if len(o.Stdin) > 0 {
+		att, err := newAttachment("stdin", o.Stdin)
+		if err != nil {
+			return "", err
+		}
+		atts = append([]attachment{att}, atts...)
+	}
+	if o.UserMsg == "" && len(atts) == 0 {
+		return "", errors.New("no message: pass one as an argument or pipe it into stdin")
+	}
+	return buildPrompt(o.UserMsg, atts), nil
+}
+
 func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 	httpClient, logHandler := root.BuildHTTPClient()
-	concreteProvider, err := createProvider(ctx, httpClient, root.BuildLLMOptions(logHandler)...)
+	concreteProvider, closeUsageLog, err := createProvider(ctx, root, httpClient, root.BuildLLMOptions(logHandler)...)
 	if err != nil {
 		return err
 	}
+	defer closeUsageLog()
 	service := concreteProvider
 
 	// Messages
@@ -118,6 +169,7 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 		Model(opts.Model).
 		Effort(opts.Effort).
 		Thinking(opts.Thinking).
+		ThinkingBudget(opts.ThinkingBudget).
 		ApiTypeHint(opts.ApiTypeHint).
 		MaxTokens(opts.MaxTokens).
 		Temperature(opts.Temperature).
@@ -127,7 +179,14 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 	if system != "" {
 		b = b.System(system, llm.CacheTTL1h)
 	}
-	b = b.User(opts.UserMsg, llm.CacheTTL1h)
+	prompt, err := opts.prompt()
+	if err != nil {
+		return err
+	}
+	if err := checkTokenBudget(service, opts.Model, prompt, opts.MaxTokens); err != nil {
+		return err
+	}
+	b = b.User(prompt, llm.CacheTTL1h)
 
 	toolChoice := opts.resolveToolChoice()
 	if opts.DemoTools {
@@ -150,6 +209,20 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 		return fmt.Errorf("create stream: %w", err)
 	}
 
+	// In the JSON modes stdout carries only JSON: text and verbose output
+	// are not printed.
+	machine := opts.JSON || opts.StreamJSONL
+	acc := llm.NewAccumulator()
+	if machine {
+		enc := json.NewEncoder(os.Stdout)
+		stream = llm.ObserveStream(ctx, stream, func(env llm.Envelope) {
+			acc.Add(env)
+			if opts.StreamJSONL {
+				_ = enc.Encode(env)
+			}
+		}, acc.Close)
+	}
+
 	var inReasoning bool
 	var hadTokenOutput bool
 	var verboseOutputPrinted bool
@@ -215,6 +288,9 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 
 	proc = proc.
 		OnTextDelta(func(chunk string) {
+			if machine {
+				return
+			}
 			printVerboseSeparator()
 			if inReasoning {
 				fmt.Print(ansiReset)
@@ -227,6 +303,9 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 			}
 		}).
 		OnReasoningDelta(func(chunk string) {
+			if machine {
+				return
+			}
 			printVerboseSeparator()
 			if !inReasoning {
 				fmt.Print(ansiDim)
@@ -247,17 +326,43 @@ func runInfer(ctx context.Context, opts inferOpts, root *RootFlags) error {
 		fmt.Println()
 	}
 
+	if opts.JSON {
+		if err := writeInferJSON(os.Stdout, acc.Completion(), result.Error()); err != nil {
+			return err
+		}
+	}
+
 	if result.Error() != nil {
 		return result.Error()
 	}
 
-	if verbose {
+	if verbose && !machine {
 		printVerboseInfo(result)
 	}
 
 	return nil
 }
 
+// inferJSON is the --json output: the completion plus its aggregated usage
+// and cost.
+type inferJSON struct {
+	*llm.Completion
+	TotalUsage usage.Record `json:"total_usage"`
+	CostUSD    float64      `json:"cost_usd"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// writeInferJSON writes c as one JSON object. A stream error is included
+// so that scripts see the partial result as well.
+func writeInferJSON(w io.Writer, c *llm.Completion, streamErr error) error {
+	total := c.TotalUsage()
+	out := inferJSON{Completion: c, TotalUsage: total, CostUSD: total.Cost.Total}
+	if streamErr != nil {
+		out.Error = streamErr.Error()
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
 // printTokenEstimate prints the pre-request token estimate section when running
 // in verbose mode. Called when a TokenEstimateEvent (unlabeled) arrives.
 func printTokenEstimate(est usage.Record) {
@@ -324,7 +429,7 @@ func printVerboseInfo(result llm.Result) {
 	// Tool calls
 	if len(result.ToolCalls()) > 0 {
 		for i, tc := range result.ToolCalls() {
-			argsJSON, _ := json.Marshal(tc.ToolArgs())
+			argsJSON, _ := tool.CanonicalJSON(tc.ToolArgs())
 			label := fmt.Sprintf("tool[%d]", i)
 			fields = append(fields, field{label, fmt.Sprintf("%s(%s) id:%s", tc.ToolName(), argsJSON, tc.ToolCallID())})
 		}
@@ -1,12 +1,17 @@
 package cmds
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
 )
 
 func TestBuildDemoTools(t *testing.T) {
@@ -65,3 +70,31 @@ func TestInferOpts_ResolveToolChoice(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteInferJSON(t *testing.T) {
+	acc := llm.NewAccumulator()
+	for env := range llmtest.SendEvents(
+		llmtest.TextEvent("hello"),
+		llmtest.ToolEvent("call_1", "lookup", map[string]any{"q": "go"}),
+		llmtest.UsageEvent(usage.Record{
+			Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 10}, {Kind: usage.KindOutput, Count: 5}},
+			Cost:   usage.Cost{Total: 0.25},
+		}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	) {
+		acc.Add(env)
+	}
+	acc.Close()
+
+	var buf bytes.Buffer
+	require.NoError(t, writeInferJSON(&buf, acc.Completion(), errors.New("boom")))
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "hello", out["text"])
+	assert.Equal(t, "tool_use", out["stop_reason"])
+	assert.Len(t, out["tool_calls"], 1)
+	assert.Equal(t, 0.25, out["cost_usd"])
+	assert.Equal(t, "boom", out["error"])
+	assert.NotNil(t, out["total_usage"])
+}
@@ -29,6 +29,17 @@ type RootFlags struct {
 	// from the channel, before any other handling. Useful for debugging the
 	// provider event stream at the application layer.
 	LogEvents bool
+	// NoUsageLog stops recording usage in the usage log. Setting
+	// LLMCLI_USAGE_LOG=off has the same effect.
+	NoUsageLog bool
+}
+
+// usageLogEnvVar disables the usage log when set to "off".
+const usageLogEnvVar = "LLMCLI_USAGE_LOG"
+
+// usageLogEnabled reports whether requests are recorded in the usage log.
+func (f *RootFlags) usageLogEnabled() bool {
+	return !f.NoUsageLog && os.Getenv(usageLogEnvVar) != "off"
 }
 
 // BuildHTTPClient constructs an *http.Client from the root flags.
@@ -73,14 +84,16 @@ func (f *RootFlags) BuildLLMOptions(handler *httpLogHandler) []llm.Option {
 	return []llm.Option{llm.WithLogger(logger)}
 }
 
-// createProvider builds the service from available credentials.
+// createProvider builds the service from available credentials and, unless
+// disabled in root, records its usage in the usage log. The returned func
+// closes the usage log; call it once the service is no longer used.
 // httpClient overrides the default transport (e.g. for logging); pass nil to
 // use llm.DefaultHttpClient(). llmOpts are passed to providers that log
 // outside the HTTP transport layer (e.g. Bedrock).
-func createProvider(ctx context.Context, httpClient *http.Client, llmOpts ...llm.Option) (*llm.Service, error) {
+func createProvider(ctx context.Context, root *RootFlags, httpClient *http.Client, llmOpts ...llm.Option) (*llm.Service, func(), error) {
 	tokenStore, err := getTokenStore()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	autoOpts := []auto.Option{
@@ -95,8 +108,22 @@ func createProvider(ctx context.Context, httpClient *http.Client, llmOpts ...llm
 	if len(llmOpts) > 0 {
 		autoOpts = append(autoOpts, auto.WithLLMOptions(llmOpts...))
 	}
+	closeLog := func() {}
+	// Record usage for `llmcli usage`; the CLI works without the log.
+	if root.usageLogEnabled() {
+		if log, err := openUsageLog(); err == nil {
+			closeLog = func() { _ = log.Close() }
+			tracker := llm.NewCostTracker(llm.WithUsageLog(log))
+			autoOpts = append(autoOpts, auto.WithMiddleware(tracker.Middleware()))
+		}
+	}
 
-	return auto.New(ctx, autoOpts...)
+	service, err := auto.New(ctx, autoOpts...)
+	if err != nil {
+		closeLog()
+		return nil, nil, err
+	}
+	return service, closeLog, nil
 }
 
 func getTokenStore() (*store.FileTokenStore, error) {
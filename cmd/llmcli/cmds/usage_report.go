@@ -0,0 +1,207 @@
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/spf13/cobra"
+)
+
+// NewUsageCmd returns the usage command.
+func NewUsageCmd() *cobra.Command {
+	var opts usageOpts
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report token usage and spend from the local usage log",
+		Long: `Report token usage and spend recorded by llmcli.
+
+Every request llmcli sends is recorded in ~/.llmcli/usage.jsonl by a
+CostTracker (see llm.WithUsageLog), unless --no-usage-log is passed or
+LLMCLI_USAGE_LOG=off is set. This command groups the log by day, model,
+provider or conversation.
+
+Examples:
+  llmcli usage                       # spend per day
+  llmcli usage --by model --days 7   # spend per model over the last week
+  llmcli usage --by provider --json  # machine-readable report`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUsage(opts, cmd.OutOrStdout())
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.File, "file", "", "Usage log to read (default ~/.llmcli/usage.jsonl)")
+	f.StringVar(&opts.By, "by", "day", "Group by: day, model, provider, conversation")
+	f.StringVar(&opts.Since, "since", "", "Only include usage on or after this date (YYYY-MM-DD)")
+	f.IntVar(&opts.Days, "days", 0, "Only include the last N days (0 = all)")
+	f.BoolVar(&opts.JSON, "json", false, "Print the report as JSON")
+
+	return cmd
+}
+
+type usageOpts struct {
+	File  string
+	By    string
+	Since string
+	Days  int
+	JSON  bool
+}
+
+// usageRow is one group of a usage report.
+type usageRow struct {
+	Key string `json:"key"`
+	llm.CostTotals
+}
+
+// usageReport is the usage of a log grouped by one dimension.
+type usageReport struct {
+	By    string         `json:"by"`
+	Rows  []usageRow     `json:"rows"`
+	Total llm.CostTotals `json:"total"`
+}
+
+// defaultUsageLogPath returns where llmcli records its usage.
+func defaultUsageLogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".llmcli", "usage.jsonl"), nil
+}
+
+// openUsageLog opens the usage log for appending, creating it if needed.
+func openUsageLog() (*os.File, error) {
+	path, err := defaultUsageLogPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+}
+
+func runUsage(opts usageOpts, out io.Writer) error {
+	path := opts.File
+	if path == "" {
+		var err error
+		if path, err = defaultUsageLogPath(); err != nil {
+			return err
+		}
+	}
+	since, err := opts.since(time.Now())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no usage recorded yet (%s does not exist)", path)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := llm.ReadUsageLog(f)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildUsageReport(entries, opts.By, since)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printUsageReport(out, report)
+	return nil
+}
+
+// since returns the start of the reported period; zero for all usage.
+func (o usageOpts) since(now time.Time) (time.Time, error) {
+	switch {
+	case o.Since != "" && o.Days > 0:
+		return time.Time{}, errors.New("--since and --days are mutually exclusive")
+	case o.Since != "":
+		t, err := time.ParseInLocation(time.DateOnly, o.Since, now.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --since %q: want YYYY-MM-DD", o.Since)
+		}
+		return t, nil
+	case o.Days > 0:
+		y, m, d := now.Date()
+		return time.Date(y, m, d-o.Days+1, 0, 0, 0, 0, now.Location()), nil
+	}
+	return time.Time{}, nil
+}
+
+// buildUsageReport groups the entries recorded at or after since by the
+// given dimension. Days sort chronologically, everything else by cost.
+func buildUsageReport(entries []llm.UsageLogEntry, by string, since time.Time) (usageReport, error) {
+	var key func(llm.UsageLogEntry) string
+	switch by {
+	case "day":
+		key = func(e llm.UsageLogEntry) string { return e.Time.Local().Format(time.DateOnly) }
+	case "model":
+		key = func(e llm.UsageLogEntry) string { return e.Record.Dims.Model }
+	case "provider":
+		key = func(e llm.UsageLogEntry) string { return e.Record.Dims.Provider }
+	case "conversation":
+		key = func(e llm.UsageLogEntry) string { return e.Conversation }
+	default:
+		return usageReport{}, fmt.Errorf("invalid --by %q: want day, model, provider or conversation", by)
+	}
+
+	report := usageReport{By: by, Rows: []usageRow{}}
+	groups := map[string]*llm.CostTotals{}
+	for _, e := range entries {
+		if e.Time.Before(since) {
+			continue
+		}
+		k := key(e)
+		if k == "" {
+			k = "(unknown)"
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &llm.CostTotals{}
+			groups[k] = g
+		}
+		g.Add(e.Record, e.NewRequest)
+		report.Total.Add(e.Record, e.NewRequest)
+	}
+	for k, g := range groups {
+		report.Rows = append(report.Rows, usageRow{Key: k, CostTotals: *g})
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if by != "day" && a.CostUSD != b.CostUSD {
+			return a.CostUSD > b.CostUSD
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
+
+func printUsageReport(out io.Writer, r usageReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\trequests\tinput\toutput\tcost\t\n", r.By)
+	for _, row := range r.Rows {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t\n", row.Key, row.Requests, row.InputTokens, row.OutputTokens, formatCost(row.CostUSD))
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%d\t%s\t\n", r.Total.Requests, r.Total.InputTokens, r.Total.OutputTokens, formatCost(r.Total.CostUSD))
+	_ = w.Flush()
+}
@@ -0,0 +1,97 @@
+package cmds
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+func usageEntry(at time.Time, provider, model string, newRequest bool, cost float64) llm.UsageLogEntry {
+	return llm.UsageLogEntry{
+		Time:       at,
+		NewRequest: newRequest,
+		Record: usage.Record{
+			Dims:   usage.Dims{Provider: provider, Model: model},
+			Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 100}, {Kind: usage.KindOutput, Count: 10}},
+			Cost:   usage.Cost{Total: cost},
+		},
+	}
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	entries := []llm.UsageLogEntry{
+		usageEntry(day1, "openai", "gpt-4o", true, 0.5),
+		usageEntry(day1, "openai", "gpt-4o", false, 0.1),
+		usageEntry(day2, "anthropic", "claude-sonnet-4-6", true, 2),
+	}
+
+	byDay, err := buildUsageReport(entries, "day", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, byDay.Rows, 2)
+	assert.Equal(t, "2026-10-01", byDay.Rows[0].Key)
+	assert.Equal(t, 1, byDay.Rows[0].Requests)
+	assert.Equal(t, 200, byDay.Rows[0].InputTokens)
+	assert.InDelta(t, 0.6, byDay.Rows[0].CostUSD, 1e-9)
+	assert.Equal(t, llm.CostTotals{Requests: 2, InputTokens: 300, OutputTokens: 30, CostUSD: 2.6}, byDay.Total)
+
+	byModel, err := buildUsageReport(entries, "model", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-6", byModel.Rows[0].Key, "most expensive first")
+
+	since, err := buildUsageReport(entries, "provider", day2)
+	require.NoError(t, err)
+	require.Len(t, since.Rows, 1)
+	assert.Equal(t, "anthropic", since.Rows[0].Key)
+
+	_, err = buildUsageReport(entries, "week", time.Time{})
+	assert.Error(t, err)
+}
+
+func TestUsageOpts_Since(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC)
+	since, err := usageOpts{Days: 7}.since(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), since)
+
+	since, err = usageOpts{Since: "2026-10-01"}.since(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), since)
+
+	_, err = usageOpts{Since: "yesterday"}.since(now)
+	assert.Error(t, err)
+}
+
+func TestRunUsage(t *testing.T) {
+	var log bytes.Buffer
+	tracker := llm.NewCostTracker(llm.WithUsageLog(&log))
+	tracker.Record("", usageEntry(time.Now(), "openai", "gpt-4o", true, 0.5).Record)
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	require.NoError(t, os.WriteFile(path, log.Bytes(), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, runUsage(usageOpts{File: path, By: "model"}, &out))
+	assert.Contains(t, out.String(), "gpt-4o")
+	assert.Contains(t, out.String(), "total")
+
+	err := runUsage(usageOpts{File: filepath.Join(t.TempDir(), "missing.jsonl"), By: "day"}, &out)
+	assert.ErrorContains(t, err, "no usage recorded yet")
+}
+
+func TestRootFlags_UsageLogEnabled(t *testing.T) {
+	t.Setenv(usageLogEnvVar, "")
+	assert.True(t, (&RootFlags{}).usageLogEnabled())
+	assert.False(t, (&RootFlags{NoUsageLog: true}).usageLogEnabled())
+
+	t.Setenv(usageLogEnvVar, "off")
+	assert.False(t, (&RootFlags{}).usageLogEnabled())
+}
@@ -42,10 +42,14 @@ Currently supports Claude OAuth authentication.`,
 	rootCmd.PersistentFlags().BoolVar(&rootFlags.LogHTTPDebug, "log-http-debug", false, "Log HTTP headers and bodies (implies --log-http)")
 	rootCmd.PersistentFlags().BoolVar(&rootFlags.LogHTTPAllHeaders, "log-http-all-headers", false, "Show all response headers instead of curated list (implies --log-http-debug)")
 	rootCmd.PersistentFlags().BoolVar(&rootFlags.LogEvents, "log-events", false, "Log each StreamEvent as JSON to stderr as it is received")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.NoUsageLog, "no-usage-log", false, "Do not record usage in ~/.llmcli/usage.jsonl (or set LLMCLI_USAGE_LOG=off)")
 
 	rootCmd.AddCommand(cmds.NewAuthCmd())
 	rootCmd.AddCommand(cmds.NewClaudeCmd())
 	rootCmd.AddCommand(cmds.NewInferCmd(rootFlags))
+	rootCmd.AddCommand(cmds.NewChatCmd(rootFlags))
+	rootCmd.AddCommand(cmds.NewUsageCmd())
+	rootCmd.AddCommand(cmds.NewDebugCmd(rootFlags))
 	rootCmd.AddCommand(modeldbcli.NewModelsCommand(modeldbcli.ModelsCommandOptions{LoadBaseCatalog: func(ctx context.Context) (modeldb.Catalog, error) { return modelcatalog.LoadMergedBuiltIn() }}))
 
 	return rootCmd.ExecuteContext(ctx)
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+// Completion is the fully-drained outcome of a single model response.
+// It is returned by Complete for callers that do not need token-by-token
+// streaming.
+type Completion struct {
+	// Message is the assistant message assembled from the response, ready to
+	// be appended to the conversation history.
+	Message msg.Message `json:"message"`
+
+	// Text is the concatenated text output.
+	Text string `json:"text,omitempty"`
+
+	// Thinking is the concatenated reasoning/thinking output.
+	Thinking string `json:"thinking,omitempty"`
+
+	// ToolCalls are the completed tool calls emitted by the model, in order.
+	// Complete does not execute them.
+	ToolCalls []tool.Call `json:"tool_calls,omitempty"`
+
+	// StopReason is why the model stopped generating.
+	StopReason StopReason `json:"stop_reason"`
+
+	// FinishReason is the provider's raw finish reason behind StopReason.
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Usage holds the provider-reported usage records, in arrival order.
+	Usage []usage.Record `json:"usage,omitempty"`
+
+	// Warnings holds non-fatal provider warnings, in arrival order.
+	Warnings []WarningEvent `json:"warnings,omitempty"`
+
+	// Safety describes the block when StopReason is StopReasonContentFilter.
+	Safety *SafetyBlock `json:"safety,omitempty"`
+
+	// Response identifies the upstream response, taken from the
+	// CompletedEvent. Nil when the provider reported nothing.
+	Response *ResponseMeta `json:"response,omitempty"`
+
+	// Timing is the request timing from the CompletedEvent. Nil when the
+	// provider does not track it.
+	Timing *Timing `json:"timing,omitempty"`
+
+	// Model, Provider, and RequestID are taken from the StreamStartedEvent.
+	// Empty when the provider did not emit one.
+	Model     string `json:"model,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// TotalUsage sums all provider-reported usage records into one record.
+func (c *Completion) TotalUsage() usage.Record {
+	t := usage.NewTracker()
+	for _, rec := range c.Usage {
+		t.Record(rec)
+	}
+	return t.Aggregate()
+}
+
+// Complete sends src through s and drains the resulting stream, returning the
+// whole response at once. Any Streamer works, including providers and
+// *Service.
+//
+// Errors from CreateStream are returned with a nil Completion. Errors that
+// occur mid-stream are returned alongside the partial Completion so callers
+// can inspect what was received before the failure.
+func Complete(ctx context.Context, s Streamer, src Buildable) (*Completion, error) {
+	stream, err := s.CreateStream(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := NewAccumulator()
+	acc.res = NewEventProcessor(ctx, stream).
+		OnStart(acc.applyStart).
+		Result().(*result)
+	return acc.Completion(), acc.Err()
+}
+
+// Collect drains stream and returns the assembled response. Like Complete,
+// it returns the partial Completion alongside any error reported by the
+// stream. Collect returns once the stream is closed; cancel the context the
+// stream was created with to stop early.
+func Collect(stream Stream) (*Completion, error) {
+	acc := NewAccumulator()
+	for env := range stream {
+		acc.Add(env)
+	}
+	acc.Close()
+	return acc.Completion(), acc.Err()
+}
+
+// Accumulator assembles a Completion from stream events one at a time, for
+// consumers that handle events themselves but still need the final text,
+// reasoning, tool calls and usage. The zero value is not usable; create one
+// with NewAccumulator. An Accumulator is not safe for concurrent use.
+type Accumulator struct {
+	res       *result
+	model     string
+	provider  string
+	requestID string
+}
+
+// NewAccumulator returns an empty Accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{res: newResult()}
+}
+
+// Add folds env into the accumulated response. Error events are recorded
+// and set StopReasonError; accumulation continues so that anything the
+// provider sends afterwards is kept.
+func (a *Accumulator) Add(env Envelope) {
+	if ev, ok := env.Data.(*StreamStartedEvent); ok {
+		a.applyStart(ev)
+	}
+	a.res.apply(env.Data)
+}
+
+// Close marks the end of the stream. A stream that ended without a
+// CompletedEvent or ErrorEvent is reported as StopReasonEndTurn.
+func (a *Accumulator) Close() {
+	if a.res.stopReason == StopReasonUnknown {
+		a.res.stopReason = StopReasonEndTurn
+	}
+}
+
+// Err returns the errors reported by the stream so far, joined.
+func (a *Accumulator) Err() error { return a.res.Error() }
+
+// Completion returns a snapshot of the response accumulated so far.
+func (a *Accumulator) Completion() *Completion {
+	res := a.res
+	return &Completion{
+		Message:      res.Message(),
+		Text:         res.Text(),
+		Thinking:     res.Thought(),
+		ToolCalls:    res.ToolCalls(),
+		StopReason:   res.StopReason(),
+		FinishReason: res.finishReason,
+		Usage:        res.UsageRecords(),
+		Warnings:     res.Warnings(),
+		Safety:       res.Safety(),
+		Response:     res.response,
+		Timing:       res.timing,
+		Model:        a.model,
+		Provider:     a.provider,
+		RequestID:    a.requestID,
+	}
+}
+
+func (a *Accumulator) applyStart(ev *StreamStartedEvent) {
+	a.model = ev.Model
+	a.provider = ev.Provider
+	a.requestID = ev.RequestID
+}
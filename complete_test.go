@@ -0,0 +1,125 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
+)
+
+func streamOf(evs ...llm.Event) llm.StreamFunc {
+	return func(context.Context, llm.Buildable) (llm.Stream, error) {
+		return llmtest.SendEvents(evs...), nil
+	}
+}
+
+func TestComplete_DrainsStream(t *testing.T) {
+	s := streamOf(
+		&llm.StreamStartedEvent{Model: "m-1", Provider: "fake", RequestID: "req-1"},
+		llmtest.ReasoningEvent("think"),
+		llmtest.TextEvent("hello "),
+		llmtest.TextEvent("world"),
+		llmtest.ToolEvent("call-1", "search", map[string]any{"q": "go"}),
+		llmtest.UsageTokenEvent("fake", "m-1", 10, 5),
+		llmtest.UsageTokenEvent("fake", "m-1", 2, 1),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	)
+
+	c, err := llm.Complete(context.Background(), s, llm.Request{Model: "m-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", c.Text)
+	assert.Equal(t, "think", c.Thinking)
+	assert.Equal(t, llm.StopReasonToolUse, c.StopReason)
+	assert.Equal(t, "m-1", c.Model)
+	assert.Equal(t, "fake", c.Provider)
+	assert.Equal(t, "req-1", c.RequestID)
+	require.Len(t, c.ToolCalls, 1)
+	assert.Equal(t, "search", c.ToolCalls[0].ToolName())
+	require.Len(t, c.Message.ToolCalls(), 1)
+	assert.Len(t, c.Usage, 2)
+
+	total := c.TotalUsage()
+	assert.Equal(t, 12, total.Tokens.Count(usage.KindInput))
+	assert.Equal(t, 6, total.Tokens.Count(usage.KindOutput))
+}
+
+func TestComplete_CreateStreamError(t *testing.T) {
+	wantErr := llm.NewErrMissingAPIKey("fake")
+	s := llm.StreamFunc(func(context.Context, llm.Buildable) (llm.Stream, error) {
+		return nil, wantErr
+	})
+
+	c, err := llm.Complete(context.Background(), s, llm.Request{Model: "m-1"})
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, llm.ErrMissingAPIKey)
+}
+
+func TestComplete_StreamErrorReturnsPartial(t *testing.T) {
+	s := streamOf(
+		llmtest.TextEvent("partial"),
+		llmtest.ErrorEvent(llm.NewErrProviderMsg("fake", "overloaded")),
+	)
+
+	c, err := llm.Complete(context.Background(), s, llm.Request{Model: "m-1"})
+	require.Error(t, err)
+	var pe *llm.ProviderError
+	assert.True(t, errors.As(err, &pe))
+	require.NotNil(t, c)
+	assert.Equal(t, "partial", c.Text)
+	assert.Equal(t, llm.StopReasonError, c.StopReason)
+}
+
+func TestCollect_AssemblesResponse(t *testing.T) {
+	c, err := llm.Collect(llmtest.SendEvents(
+		&llm.StreamStartedEvent{Model: "m-1", Provider: "fake"},
+		llmtest.ReasoningEvent("plan"),
+		llmtest.TextEvent("a"),
+		llmtest.ToolEvent("call-1", "first", map[string]any{}),
+		llmtest.TextEvent("b"),
+		llmtest.ToolEvent("call-2", "second", map[string]any{}),
+		llmtest.UsageTokenEvent("fake", "m-1", 3, 4),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, "ab", c.Text)
+	assert.Equal(t, "plan", c.Thinking)
+	assert.Equal(t, "m-1", c.Model)
+	assert.Equal(t, llm.StopReasonToolUse, c.StopReason)
+	require.Len(t, c.ToolCalls, 2)
+	assert.Equal(t, "first", c.ToolCalls[0].ToolName())
+	assert.Equal(t, "second", c.ToolCalls[1].ToolName())
+	assert.Equal(t, 7, c.TotalUsage().Tokens.Total())
+}
+
+func TestCollect_ErrorEventReturnsPartial(t *testing.T) {
+	c, err := llm.Collect(llmtest.SendEvents(
+		llmtest.TextEvent("partial"),
+		llmtest.ErrorEvent(llm.NewErrProviderMsg("fake", "overloaded")),
+	))
+	require.Error(t, err)
+	var pe *llm.ProviderError
+	assert.True(t, errors.As(err, &pe))
+	require.NotNil(t, c)
+	assert.Equal(t, "partial", c.Text)
+	assert.Equal(t, llm.StopReasonError, c.StopReason)
+}
+
+func TestAccumulator_Incremental(t *testing.T) {
+	acc := llm.NewAccumulator()
+	acc.Add(llm.Envelope{Data: llmtest.TextEvent("hel")})
+	assert.Equal(t, "hel", acc.Completion().Text)
+	assert.Equal(t, llm.StopReasonUnknown, acc.Completion().StopReason)
+
+	acc.Add(llm.Envelope{Data: llmtest.TextEvent("lo")})
+	acc.Close()
+	c := acc.Completion()
+	assert.Equal(t, "hello", c.Text)
+	assert.Equal(t, llm.StopReasonEndTurn, c.StopReason)
+	require.NoError(t, acc.Err())
+}
@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config declares a multi-provider setup in a file, see LoadConfig. Load it
+// into a Service with ServiceOptions, or with auto.WithConfig to build the
+// providers with the default registry:
+//
+//	providers:
+//	  - type: openai
+//	    api_key_env: OPENAI_API_KEY
+//	    default_model: gpt-4o
+//	  - name: local
+//	    type: ollama
+//	    base_url: http://gpu-box:11434
+//	    aliases:
+//	      coder: qwen2.5-coder
+//	aliases:
+//	  fast: openai/gpt-4o-mini
+type Config struct {
+	Providers []ProviderConfig `json:"providers" yaml:"providers"`
+
+	// Aliases maps intent aliases to model references, e.g.
+	// "fast": "openai/gpt-4o-mini". They take precedence over the aliases
+	// derived from the providers.
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// ProviderConfig declares one provider instance of a Config.
+type ProviderConfig struct {
+	// Name identifies the instance in "name/type/model" references.
+	// Defaults to Type.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Type is the provider type known to the registry, e.g. "openai",
+	// "anthropic", "bedrock", "ollama" or "openrouter".
+	Type string `json:"type" yaml:"type"`
+
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+
+	// APIKey is the API key. Environment variables in it are expanded, so
+	// "${OPENAI_API_KEY}" works as well as a literal key. APIKeyEnv names a
+	// variable to read the key from on each request instead.
+	APIKey    string `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+	APIKeyEnv string `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+
+	// DefaultModel of the first provider that sets one becomes the
+	// ModelDefault intent alias.
+	DefaultModel string `json:"default_model,omitempty" yaml:"default_model,omitempty"`
+
+	// Aliases maps intent aliases to models of this provider.
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// LoadConfig reads a Config from a YAML (.yaml, .yml) or JSON (.json) file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	default:
+		return nil, fmt.Errorf("load config %s: unsupported format; use .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("load config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate reports missing provider types and duplicate instance names.
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.Providers))
+	var errs []error
+	for i, p := range c.Providers {
+		if strings.TrimSpace(p.Type) == "" {
+			errs = append(errs, fmt.Errorf("providers[%d]: type is required", i))
+			continue
+		}
+		name := p.instanceName()
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("providers[%d]: duplicate name %q", i, name))
+		}
+		seen[name] = true
+	}
+	return errors.Join(errs...)
+}
+
+// DetectedProviders returns the provider requests a ProviderRegistry builds.
+// Base URL and API key are passed as the "baseURL", "apiKey" and
+// "apiKeyEnv" params.
+func (c *Config) DetectedProviders() []DetectedProvider {
+	out := make([]DetectedProvider, 0, len(c.Providers))
+	for i, p := range c.Providers {
+		params := map[string]any{}
+		if p.BaseURL != "" {
+			params["baseURL"] = p.BaseURL
+		}
+		if p.APIKey != "" {
+			params["apiKey"] = os.ExpandEnv(p.APIKey)
+		}
+		if p.APIKeyEnv != "" {
+			params["apiKeyEnv"] = p.APIKeyEnv
+		}
+		out = append(out, DetectedProvider{Name: p.instanceName(), Type: p.Type, Params: params, Order: i})
+	}
+	return out
+}
+
+// IntentAliases returns the aliases declared by the Config: provider
+// default models and aliases, overridden by the top-level Aliases.
+func (c *Config) IntentAliases() map[string]IntentSelector {
+	out := map[string]IntentSelector{}
+	for _, p := range c.Providers {
+		if _, ok := out[ModelDefault]; !ok && p.DefaultModel != "" {
+			out[ModelDefault] = IntentSelector{Model: p.modelRef(p.DefaultModel)}
+		}
+		for alias, model := range p.Aliases {
+			out[alias] = IntentSelector{Model: p.modelRef(model)}
+		}
+	}
+	for alias, ref := range c.Aliases {
+		out[alias] = IntentSelector{Model: ref}
+	}
+	return out
+}
+
+// ServiceOptions returns the options that add the Config's providers and
+// aliases to a Service. Building the providers requires a ProviderRegistry
+// in the ServiceConfig.
+func (c *Config) ServiceOptions() []ServiceOption {
+	var opts []ServiceOption
+	for _, d := range c.DetectedProviders() {
+		opts = append(opts, WithDetectedProvider(d))
+	}
+	for alias, sel := range c.IntentAliases() {
+		opts = append(opts, WithIntentAlias(alias, sel))
+	}
+	return opts
+}
+
+func (p ProviderConfig) instanceName() string {
+	if name := strings.TrimSpace(p.Name); name != "" {
+		return name
+	}
+	return p.Type
+}
+
+// modelRef returns the reference that targets model on this instance.
+func (p ProviderConfig) modelRef(model string) string {
+	if name := p.instanceName(); name != p.Type {
+		return name + "/" + p.Type + "/" + model
+	}
+	return p.Type + "/" + model
+}
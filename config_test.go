@@ -0,0 +1,63 @@
+package llm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-test")
+	cfg, err := llm.LoadConfig(writeConfig(t, "llm.yaml", `
+providers:
+  - type: openai
+    api_key: ${TEST_OPENAI_KEY}
+    default_model: gpt-4o
+  - name: local
+    type: ollama
+    base_url: http://gpu-box:11434
+    aliases:
+      coder: qwen2.5-coder
+aliases:
+  fast: openai/gpt-4o-mini
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []llm.DetectedProvider{
+		{Name: "openai", Type: "openai", Params: map[string]any{"apiKey": "sk-test"}, Order: 0},
+		{Name: "local", Type: "ollama", Params: map[string]any{"baseURL": "http://gpu-box:11434"}, Order: 1},
+	}, cfg.DetectedProviders())
+	assert.Equal(t, map[string]llm.IntentSelector{
+		llm.ModelDefault: {Model: "openai/gpt-4o"},
+		"coder":          {Model: "local/ollama/qwen2.5-coder"},
+		"fast":           {Model: "openai/gpt-4o-mini"},
+	}, cfg.IntentAliases())
+}
+
+func TestLoadConfig_JSONAndErrors(t *testing.T) {
+	cfg, err := llm.LoadConfig(writeConfig(t, "llm.json", `{"providers":[{"type":"openrouter","api_key_env":"OR_KEY"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"apiKeyEnv": "OR_KEY"}, cfg.DetectedProviders()[0].Params)
+
+	_, err = llm.LoadConfig(writeConfig(t, "llm.yaml", "providers:\n  - type: openai\n  - type: openai\n  - name: x\n"))
+	assert.ErrorContains(t, err, `providers[1]: duplicate name "openai"`)
+	assert.ErrorContains(t, err, "providers[2]: type is required")
+
+	_, err = llm.LoadConfig(writeConfig(t, "llm.yaml", "providers:\n  - type: openai\n    apikey: x\n"))
+	assert.ErrorContains(t, err, "apikey")
+
+	_, err = llm.LoadConfig(writeConfig(t, "llm.toml", ""))
+	assert.ErrorContains(t, err, "unsupported format")
+}
@@ -0,0 +1,340 @@
+// Package conformance is a provider certification suite. It runs an
+// llm.Provider against a mock HTTP upstream that replays canned SSE fixtures
+// and asserts the behaviour every provider must share: text and unicode
+// streaming, parallel tool calls, usage placement, HTTP status mapping,
+// mid-stream errors and cancellation.
+//
+// Third-party providers that speak one of the supported wire protocols can
+// certify compatibility from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//	    conformance.Run(t, conformance.Target{
+//	        API:   llm.ApiTypeOpenAIChatCompletion,
+//	        Model: "my-model",
+//	        New: func(baseURL string) llm.Provider {
+//	            return myprovider.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test"))
+//	        },
+//	    })
+//	}
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+// DefaultTimeout bounds every scenario. A provider that does not close its
+// stream within this window fails the suite.
+const DefaultTimeout = 5 * time.Second
+
+// Target describes the provider under test.
+type Target struct {
+	// API is the wire protocol the provider speaks for Model. It selects
+	// which fixtures are replayed.
+	API llm.ApiType
+
+	// Model is sent as Request.Model. It must route to API.
+	Model string
+
+	// New builds a provider pointed at the mock upstream.
+	New func(baseURL string) llm.Provider
+
+	// Skip lists scenarios the provider does not support. Skipped scenarios
+	// are reported via t.Skip rather than silently omitted.
+	Skip []Scenario
+}
+
+func (tg Target) skips(s Scenario) bool {
+	for _, sk := range tg.Skip {
+		if sk == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes the full suite against target as subtests of t.
+func Run(t *testing.T, target Target) {
+	t.Helper()
+	if target.New == nil {
+		t.Fatal("conformance: Target.New is required")
+	}
+	fixtures := Fixtures(target.API)
+	if fixtures == nil {
+		t.Fatalf("conformance: no fixtures for API type %q", target.API)
+	}
+
+	scenario := func(s Scenario, fn func(t *testing.T, f Fixture)) {
+		t.Run(string(s), func(t *testing.T) {
+			if target.skips(s) {
+				t.Skipf("scenario %s skipped by target", s)
+			}
+			fn(t, fixtures[s])
+		})
+	}
+
+	scenario(ScenarioText, func(t *testing.T, f Fixture) {
+		res := runFixture(t, target, f)
+		expectNoError(t, res)
+		if got := res.Text(); got != ExpectedText {
+			t.Errorf("text = %q, want %q", got, ExpectedText)
+		}
+		if got := res.StopReason(); got != llm.StopReasonEndTurn {
+			t.Errorf("stop reason = %q, want %q", got, llm.StopReasonEndTurn)
+		}
+	})
+
+	scenario(ScenarioUnicode, func(t *testing.T, f Fixture) {
+		res := runFixture(t, target, f)
+		expectNoError(t, res)
+		if got := res.Text(); got != ExpectedUnicodeText {
+			t.Errorf("text = %q, want %q", got, ExpectedUnicodeText)
+		}
+	})
+
+	scenario(ScenarioParallelTools, func(t *testing.T, f Fixture) {
+		res := runFixture(t, target, f)
+		expectNoError(t, res)
+		if got := res.StopReason(); got != llm.StopReasonToolUse {
+			t.Errorf("stop reason = %q, want %q", got, llm.StopReasonToolUse)
+		}
+		expectToolCalls(t, res.ToolCalls())
+	})
+
+	scenario(ScenarioUsage, func(t *testing.T, f Fixture) {
+		events := collectFixture(t, target, f)
+		expectUsagePlacement(t, events)
+	})
+
+	scenario(ScenarioStreamError, func(t *testing.T, f Fixture) {
+		res := runFixture(t, target, f)
+		if res.StopReason() != llm.StopReasonError {
+			t.Errorf("stop reason = %q, want %q", res.StopReason(), llm.StopReasonError)
+		}
+		var pe *llm.ProviderError
+		if !errors.As(res.Error(), &pe) {
+			t.Fatalf("error = %v, want *llm.ProviderError", res.Error())
+		}
+	})
+
+	t.Run("http_status", func(t *testing.T) {
+		for _, status := range ErrorStatuses {
+			t.Run(fmt.Sprintf("%d", status), func(t *testing.T) {
+				expectStatusMapping(t, target, status)
+			})
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		expectCancellation(t, target, fixtures[ScenarioText])
+	})
+}
+
+func request(target Target) llm.Request {
+	return llm.Request{
+		Model:    target.Model,
+		Messages: llm.Messages{llm.User("conformance")},
+		Tools: []tool.Definition{
+			{Name: "get_weather", Description: "Get the weather", Parameters: map[string]any{"type": "object"}},
+			{Name: "get_time", Description: "Get the time", Parameters: map[string]any{"type": "object"}},
+		},
+	}
+}
+
+func runFixture(t *testing.T, target Target, f Fixture) llm.Result {
+	t.Helper()
+	srv := NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	t.Cleanup(cancel)
+
+	stream, err := target.New(srv.URL).CreateStream(ctx, request(target))
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	return llm.ProcessEvents(ctx, stream)
+}
+
+func collectFixture(t *testing.T, target Target, f Fixture) []llm.Envelope {
+	t.Helper()
+	srv := NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	t.Cleanup(cancel)
+
+	stream, err := target.New(srv.URL).CreateStream(ctx, request(target))
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	events, ok := drain(stream, DefaultTimeout)
+	if !ok {
+		t.Fatalf("stream not closed within %s", DefaultTimeout)
+	}
+	return events
+}
+
+func drain(stream llm.Stream, timeout time.Duration) ([]llm.Envelope, bool) {
+	var events []llm.Envelope
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev, ok := <-stream:
+			if !ok {
+				return events, true
+			}
+			events = append(events, ev)
+		case <-deadline:
+			return events, false
+		}
+	}
+}
+
+func expectNoError(t *testing.T, res llm.Result) {
+	t.Helper()
+	if err := res.Error(); err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+}
+
+func expectToolCalls(t *testing.T, got []tool.Call) {
+	t.Helper()
+	if len(got) != len(ExpectedToolCalls) {
+		t.Fatalf("tool calls = %d, want %d", len(got), len(ExpectedToolCalls))
+	}
+	for i, want := range ExpectedToolCalls {
+		tc := got[i]
+		if tc.ToolCallID() != want.ID || tc.ToolName() != want.Name {
+			t.Errorf("tool call[%d] = %s/%s, want %s/%s", i, tc.ToolCallID(), tc.ToolName(), want.ID, want.Name)
+		}
+		for k, v := range want.Args {
+			if tc.ToolArgs()[k] != v {
+				t.Errorf("tool call[%d] arg %q = %v, want %v", i, k, tc.ToolArgs()[k], v)
+			}
+		}
+	}
+}
+
+// expectUsagePlacement asserts that exactly one provider-reported usage
+// record is emitted, that it carries the fixture's token counts, and that it
+// arrives before the completed event so consumers that stop reading at
+// completion still observe it.
+func expectUsagePlacement(t *testing.T, events []llm.Envelope) {
+	t.Helper()
+	var (
+		records      []usage.Record
+		usageIdx     = -1
+		completedIdx = -1
+	)
+	for i, ev := range events {
+		switch data := ev.Data.(type) {
+		case *llm.UsageUpdatedEvent:
+			records = append(records, data.Record)
+			usageIdx = i
+		case *llm.CompletedEvent:
+			completedIdx = i
+		}
+	}
+	if len(records) != 1 {
+		t.Fatalf("usage records = %d, want 1", len(records))
+	}
+	if completedIdx < 0 {
+		t.Fatal("no completed event")
+	}
+	if usageIdx > completedIdx {
+		t.Errorf("usage event at %d arrived after completed event at %d", usageIdx, completedIdx)
+	}
+	rec := records[0]
+	if rec.IsEstimate {
+		t.Error("provider usage record marked as estimate")
+	}
+	if got := rec.Tokens.TotalInput(); got != ExpectedInputTokens {
+		t.Errorf("input tokens = %d, want %d", got, ExpectedInputTokens)
+	}
+	if got := rec.Tokens.TotalOutput(); got != ExpectedOutputTokens {
+		t.Errorf("output tokens = %d, want %d", got, ExpectedOutputTokens)
+	}
+}
+
+// expectStatusMapping asserts that a non-2xx upstream response surfaces as a
+// *llm.ProviderError wrapping llm.ErrAPIError with the status preserved.
+// Retriable statuses (see llm.IsRetriableHTTPStatus) must be returned from
+// CreateStream so that llm.Service can fail over; other statuses may be
+// returned or delivered as a stream error event.
+func expectStatusMapping(t *testing.T, target Target, status int) {
+	t.Helper()
+	srv := NewServer(HTTPError(status, fmt.Sprintf(`{"error":{"type":"conformance_error","message":"status %d"}}`, status)))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	t.Cleanup(cancel)
+
+	stream, err := target.New(srv.URL).CreateStream(ctx, request(target))
+	if err == nil {
+		if llm.IsRetriableHTTPStatus(status) {
+			t.Errorf("HTTP %d is retriable and must be returned from CreateStream", status)
+		}
+		err = llm.ProcessEvents(ctx, stream).Error()
+	} else if stream != nil {
+		drain(stream, DefaultTimeout)
+	}
+	if err == nil {
+		t.Fatalf("HTTP %d produced no error", status)
+	}
+	var pe *llm.ProviderError
+	if !errors.As(err, &pe) {
+		t.Fatalf("HTTP %d error = %T (%v), want *llm.ProviderError", status, err, err)
+	}
+	if !errors.Is(pe, llm.ErrAPIError) {
+		t.Errorf("HTTP %d sentinel = %v, want %v", status, pe.Sentinel, llm.ErrAPIError)
+	}
+	if pe.StatusCode != status {
+		t.Errorf("HTTP %d status code = %d", status, pe.StatusCode)
+	}
+	if pe.Provider == "" {
+		t.Errorf("HTTP %d error has no provider name", status)
+	}
+}
+
+// expectCancellation asserts that cancelling the caller's context while the
+// upstream connection is still open closes the stream promptly.
+func expectCancellation(t *testing.T, target Target, f Fixture) {
+	t.Helper()
+	// Drop the terminal frames so the upstream looks mid-response.
+	f.Body = firstFrames(f.Body, 2)
+	srv := NewHangingServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := target.New(srv.URL).CreateStream(ctx, request(target))
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	cancel()
+	if _, ok := drain(stream, DefaultTimeout); !ok {
+		t.Fatalf("stream not closed within %s after cancellation", DefaultTimeout)
+	}
+}
+
+func firstFrames(body string, n int) string {
+	count := 0
+	for i := 0; i+1 < len(body); i++ {
+		if body[i] == '\n' && body[i+1] == '\n' {
+			count++
+			if count == n {
+				return body[:i+2]
+			}
+		}
+	}
+	return body
+}
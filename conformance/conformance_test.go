@@ -0,0 +1,162 @@
+package conformance_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/conformance"
+	"github.com/codewandler/llm/provider/anthropic"
+	"github.com/codewandler/llm/provider/codex"
+	"github.com/codewandler/llm/provider/dockermr"
+	"github.com/codewandler/llm/provider/groq"
+	"github.com/codewandler/llm/provider/minimax"
+	"github.com/codewandler/llm/provider/ollama"
+	"github.com/codewandler/llm/provider/openai"
+	"github.com/codewandler/llm/provider/openrouter"
+	"github.com/codewandler/llm/provider/vertex"
+)
+
+// Bedrock is not covered: it streams AWS event-stream frames rather than
+// SSE, which the mock upstream does not speak.
+
+func TestConformance_OpenAIChat(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIChatCompletion,
+		Model: "gpt-4o-mini",
+		New: func(baseURL string) llm.Provider {
+			return openai.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_OpenAIResponses(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIResponses,
+		Model: "gpt-5.4",
+		New: func(baseURL string) llm.Provider {
+			return openai.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_Anthropic(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeAnthropicMessages,
+		Model: "claude-sonnet-4-5",
+		New: func(baseURL string) llm.Provider {
+			return anthropic.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_Ollama(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIResponses,
+		Model: ollama.ModelLlama32,
+		New: func(baseURL string) llm.Provider {
+			return ollama.New(llm.WithBaseURL(baseURL))
+		},
+	})
+}
+
+func TestConformance_Groq(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIChatCompletion,
+		Model: groq.DefaultModel,
+		New: func(baseURL string) llm.Provider {
+			return groq.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_OpenRouterResponses(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIResponses,
+		Model: "openai/gpt-4o-mini",
+		New: func(baseURL string) llm.Provider {
+			return openrouter.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_OpenRouterMessages(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeAnthropicMessages,
+		Model: "anthropic/claude-sonnet-4.5",
+		New: func(baseURL string) llm.Provider {
+			return openrouter.New(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key"))
+		},
+	})
+}
+
+func TestConformance_MiniMax(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeAnthropicMessages,
+		Model: minimax.ModelM27,
+		New: func(baseURL string) llm.Provider {
+			return minimax.New(minimax.WithLLMOpts(llm.WithBaseURL(baseURL), llm.WithAPIKey("test-key")))
+		},
+	})
+}
+
+func TestConformance_VertexGemini(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIChatCompletion,
+		Model: vertex.ModelGemini25Flash,
+		New:   newVertex,
+	})
+}
+
+func TestConformance_VertexClaude(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeAnthropicMessages,
+		Model: vertex.ModelSonnet45,
+		New:   newVertex,
+	})
+}
+
+func newVertex(baseURL string) llm.Provider {
+	return vertex.New(
+		vertex.WithProject("conformance"),
+		vertex.WithTokenSource(vertex.StaticToken("test-token")),
+		vertex.WithLLMOptions(llm.WithBaseURL(baseURL)),
+	)
+}
+
+func TestConformance_Codex(t *testing.T) {
+	// Codex reads its credentials from ~/.codex/auth.json only.
+	home := t.TempDir()
+	require(t, os.MkdirAll(filepath.Join(home, ".codex"), 0o755))
+	require(t, os.WriteFile(filepath.Join(home, ".codex", "auth.json"),
+		[]byte(`{"tokens":{"access_token":"test-token","account_id":"acct_1"}}`), 0o600))
+	t.Setenv("HOME", home)
+	auth, err := codex.LoadAuth()
+	require(t, err)
+
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIResponses,
+		Model: "gpt-5.4",
+		New: func(baseURL string) llm.Provider {
+			return codex.New(auth, llm.WithBaseURL(baseURL))
+		},
+	})
+}
+
+func TestConformance_DockerMR(t *testing.T) {
+	conformance.Run(t, conformance.Target{
+		API:   llm.ApiTypeOpenAIChatCompletion,
+		Model: dockermr.ModelSmoLLM2,
+		New: func(baseURL string) llm.Provider {
+			return dockermr.New(llm.WithBaseURL(baseURL))
+		},
+	})
+}
+
+func require(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
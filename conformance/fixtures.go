@@ -0,0 +1,251 @@
+package conformance
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/codewandler/llm"
+)
+
+// Scenario identifies one canned upstream behaviour exercised by the suite.
+type Scenario string
+
+const (
+	ScenarioText          Scenario = "text"
+	ScenarioUnicode       Scenario = "unicode"
+	ScenarioParallelTools Scenario = "parallel_tools"
+	ScenarioUsage         Scenario = "usage"
+	ScenarioStreamError   Scenario = "stream_error"
+)
+
+// Expected values shared by every wire format's fixtures. The suite asserts
+// against these so fixtures for a new wire format only need to encode them.
+const (
+	ExpectedText         = "Hello world"
+	ExpectedUnicodeText  = "Grüße, 世界 👋🏽 — ok"
+	ExpectedInputTokens  = 11
+	ExpectedOutputTokens = 7
+)
+
+// ExpectedToolCall is one tool call the parallel-tools fixtures emit.
+type ExpectedToolCall struct {
+	ID   string
+	Name string
+	Args map[string]any
+}
+
+// ExpectedToolCalls are the calls emitted by ScenarioParallelTools, in order.
+var ExpectedToolCalls = []ExpectedToolCall{
+	{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "Berlin"}},
+	{ID: "call_2", Name: "get_time", Args: map[string]any{"zone": "Europe/Berlin"}},
+}
+
+// Fixture is a canned HTTP response served by Server.
+type Fixture struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// SSE returns a 200 text/event-stream fixture built from the given frames.
+// Frames are joined with blank lines, so each frame should hold the
+// "event:" and "data:" lines of exactly one server-sent event.
+func SSE(frames ...string) Fixture {
+	return Fixture{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": {"text/event-stream"}},
+		Body:   strings.Join(frames, "\n\n") + "\n\n",
+	}
+}
+
+// HTTPError returns a JSON error fixture with the given status code.
+func HTTPError(status int, body string) Fixture {
+	return Fixture{
+		Status: status,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   body,
+	}
+}
+
+// Fixtures returns the canned SSE responses for the given wire protocol.
+// Returns nil for ApiTypeAuto or an unknown API type.
+func Fixtures(api llm.ApiType) map[Scenario]Fixture {
+	switch api {
+	case llm.ApiTypeOpenAIChatCompletion:
+		return completionsFixtures()
+	case llm.ApiTypeOpenAIResponses:
+		return responsesFixtures()
+	case llm.ApiTypeAnthropicMessages:
+		return messagesFixtures()
+	default:
+		return nil
+	}
+}
+
+func completionsFixtures() map[Scenario]Fixture {
+	usage := `data: {"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":11,"completion_tokens":7}}`
+	done := "data: [DONE]"
+	return map[Scenario]Fixture{
+		ScenarioText: SSE(
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"content":" world"}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			usage,
+			done,
+		),
+		ScenarioUnicode: SSE(
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Grüße, "}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"content":"世界 👋🏽"}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"content":" — ok"}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			usage,
+			done,
+		),
+		ScenarioParallelTools: SSE(
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":""}}]}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{\"zone\":\"Europe/Berlin\"}"}}]}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Berlin\"}"}}]}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			usage,
+			done,
+		),
+		ScenarioUsage: SSE(
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello world"}}]}`,
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":11,"completion_tokens":7}}`,
+			done,
+		),
+		ScenarioStreamError: SSE(
+			`data: {"id":"chatcmpl-1","model":"conformance-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`data: {"error":{"message":"upstream overloaded","type":"server_error"}}`,
+		),
+	}
+}
+
+func responsesFixtures() map[Scenario]Fixture {
+	created := "event: response.created\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"conformance-model\"}}"
+	completed := func(status string) string {
+		return "event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"conformance-model\",\"status\":\"" + status + "\",\"usage\":{\"input_tokens\":11,\"output_tokens\":7}}}"
+	}
+	textDelta := func(s string) string {
+		return "event: response.output_text.delta\ndata: {\"output_index\":0,\"delta\":" + jsonString(s) + "}"
+	}
+	return map[Scenario]Fixture{
+		ScenarioText: SSE(
+			created,
+			textDelta("Hello"),
+			textDelta(" world"),
+			completed("completed"),
+		),
+		ScenarioUnicode: SSE(
+			created,
+			textDelta("Grüße, "),
+			textDelta("世界 👋🏽"),
+			textDelta(" — ok"),
+			completed("completed"),
+		),
+		ScenarioParallelTools: SSE(
+			created,
+			"event: response.function_call_arguments.delta\ndata: {\"item_id\":\"call_1\",\"output_index\":0,\"delta\":\"{\\\"city\\\":\"}",
+			"event: response.function_call_arguments.delta\ndata: {\"item_id\":\"call_2\",\"output_index\":1,\"delta\":\"{\\\"zone\\\":\\\"Europe/Berlin\\\"}\"}",
+			"event: response.function_call_arguments.delta\ndata: {\"item_id\":\"call_1\",\"output_index\":0,\"delta\":\"\\\"Berlin\\\"}\"}",
+			"event: response.function_call_arguments.done\ndata: {\"item_id\":\"call_1\",\"output_index\":0,\"name\":\"get_weather\",\"arguments\":\"{\\\"city\\\":\\\"Berlin\\\"}\"}",
+			"event: response.function_call_arguments.done\ndata: {\"item_id\":\"call_2\",\"output_index\":1,\"name\":\"get_time\",\"arguments\":\"{\\\"zone\\\":\\\"Europe/Berlin\\\"}\"}",
+			completed("completed"),
+		),
+		ScenarioUsage: SSE(
+			created,
+			textDelta("Hello world"),
+			completed("completed"),
+		),
+		ScenarioStreamError: SSE(
+			created,
+			textDelta("Hel"),
+			"event: error\ndata: {\"type\":\"error\",\"code\":\"server_error\",\"message\":\"upstream overloaded\"}",
+		),
+	}
+}
+
+func messagesFixtures() map[Scenario]Fixture {
+	start := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"conformance-model\",\"usage\":{\"input_tokens\":11}}}"
+	stop := func(reason string) []string {
+		return []string{
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"" + reason + "\"},\"usage\":{\"output_tokens\":7}}",
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}",
+		}
+	}
+	textBlock := func(index string, chunks ...string) []string {
+		frames := []string{"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":" + index + ",\"content_block\":{\"type\":\"text\",\"text\":\"\"}}"}
+		for _, c := range chunks {
+			frames = append(frames, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":"+index+",\"delta\":{\"type\":\"text_delta\",\"text\":"+jsonString(c)+"}}")
+		}
+		return append(frames, "event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":"+index+"}")
+	}
+	frames := func(parts ...[]string) []string {
+		var out []string
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+	return map[Scenario]Fixture{
+		ScenarioText: SSE(frames(
+			[]string{start},
+			textBlock("0", "Hello", " world"),
+			stop("end_turn"),
+		)...),
+		ScenarioUnicode: SSE(frames(
+			[]string{start},
+			textBlock("0", "Grüße, ", "世界 👋🏽", " — ok"),
+			stop("end_turn"),
+		)...),
+		ScenarioParallelTools: SSE(frames(
+			[]string{
+				start,
+				"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"call_1\",\"name\":\"get_weather\",\"input\":{}}}",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\"}}",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"Berlin\\\"}\"}}",
+				"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}",
+				"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"call_2\",\"name\":\"get_time\",\"input\":{}}}",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"zone\\\":\\\"Europe/Berlin\\\"}\"}}",
+				"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}",
+			},
+			stop("tool_use"),
+		)...),
+		ScenarioUsage: SSE(frames(
+			[]string{start},
+			textBlock("0", "Hello world"),
+			stop("end_turn"),
+		)...),
+		ScenarioStreamError: SSE(frames(
+			[]string{start},
+			[]string{
+				"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}",
+				"event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"upstream overloaded\"}}",
+			},
+		)...),
+	}
+}
+
+// ErrorStatuses are the HTTP status codes the suite maps through every
+// provider. Each must surface as a *llm.ProviderError wrapping
+// llm.ErrAPIError with the status code preserved.
+var ErrorStatuses = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusPaymentRequired,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
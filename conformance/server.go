@@ -0,0 +1,71 @@
+package conformance
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Server is a mock upstream that replays a Fixture for every request it
+// receives, regardless of path. It records the raw request bodies so tests
+// can inspect what the provider sent.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixture  Fixture
+	hang     bool
+	requests [][]byte
+}
+
+// NewServer starts a Server that replays f. Call Close when done.
+func NewServer(f Fixture) *Server {
+	s := &Server{fixture: f}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewHangingServer starts a Server that writes the headers and body of f and
+// then blocks until the client goes away. Use it to exercise cancellation:
+// the provider must close its stream once the caller's context is done.
+func NewHangingServer(f Fixture) *Server {
+	s := &Server{fixture: f, hang: true}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Requests returns the raw bodies of all requests received so far.
+func (s *Server) Requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, body)
+	f := s.fixture
+	s.mu.Unlock()
+
+	for k, vs := range f.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, f.Body)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	if s.hang {
+		<-r.Context().Done()
+	}
+}
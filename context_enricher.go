@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SystemContext is the data a ContextEnricher renders into its system
+// message.
+type SystemContext struct {
+	// Now is the current time in Location.
+	Now time.Time
+	// Location is the user's time zone. Defaults to time.Local.
+	Location *time.Location
+	// Locale is a BCP 47 language tag such as "de-DE". Empty omits it.
+	Locale string
+	// Facts are user profile facts (name, role, preferences) rendered as
+	// key/value pairs in key order.
+	Facts map[string]string
+}
+
+// Timezone returns the IANA name of Location.
+func (c SystemContext) Timezone() string {
+	if c.Location == nil {
+		return ""
+	}
+	return c.Location.String()
+}
+
+// DefaultContextTemplate renders a SystemContext as a compact key/value block.
+var DefaultContextTemplate = template.Must(template.New("context").Parse(`<context>
+current_time: {{.Now.Format "2006-01-02T15:04:05Z07:00"}} ({{.Now.Weekday}})
+timezone: {{.Timezone}}
+{{- with .Locale}}
+locale: {{.}}
+{{- end}}
+{{- range $k, $v := .Facts}}
+user.{{$k}}: {{$v}}
+{{- end}}
+</context>`))
+
+// ContextEnricher adds a system message with the current date and time,
+// time zone, locale and user profile facts to every request sent through it,
+// so assistants do not have to hand-roll this into their prompts.
+//
+// The message is inserted after the request's leading system messages, so a
+// stable system prompt stays first and remains cacheable while the context
+// block changes from request to request.
+type ContextEnricher struct {
+	s       Streamer
+	tmpl    *template.Template
+	now     func() time.Time
+	base    SystemContext
+	resolve func(ctx context.Context, sc *SystemContext) error
+}
+
+// NewContextEnricher returns a ContextEnricher that sends enriched requests
+// through s, using DefaultContextTemplate and the local time zone.
+func NewContextEnricher(s Streamer) *ContextEnricher {
+	return &ContextEnricher{s: s, tmpl: DefaultContextTemplate, now: time.Now, base: SystemContext{Location: time.Local}}
+}
+
+// WithLocation sets the time zone used for the current time.
+func (e *ContextEnricher) WithLocation(loc *time.Location) *ContextEnricher {
+	e.base.Location = loc
+	return e
+}
+
+// WithLocale sets the locale tag.
+func (e *ContextEnricher) WithLocale(locale string) *ContextEnricher {
+	e.base.Locale = locale
+	return e
+}
+
+// WithFacts sets user profile facts included in every request.
+func (e *ContextEnricher) WithFacts(facts map[string]string) *ContextEnricher {
+	e.base.Facts = facts
+	return e
+}
+
+// WithResolver registers fn to adjust the SystemContext per request, for
+// example to load the time zone and profile of the user in ctx. fn receives
+// a copy of the configured context with Now set and Facts non-nil; changing
+// Location converts Now accordingly. An error from fn fails the request.
+func (e *ContextEnricher) WithResolver(fn func(ctx context.Context, sc *SystemContext) error) *ContextEnricher {
+	e.resolve = fn
+	return e
+}
+
+// WithTemplate replaces DefaultContextTemplate. The template is executed
+// with a SystemContext; whitespace-only output adds no message.
+func (e *ContextEnricher) WithTemplate(tmpl *template.Template) *ContextEnricher {
+	e.tmpl = tmpl
+	return e
+}
+
+// WithClock replaces time.Now, mainly for tests.
+func (e *ContextEnricher) WithClock(now func() time.Time) *ContextEnricher {
+	e.now = now
+	return e
+}
+
+// Enrich returns req with the rendered context message inserted.
+func (e *ContextEnricher) Enrich(ctx context.Context, req Request) (Request, error) {
+	sc := e.base
+	sc.Facts = make(map[string]string, len(e.base.Facts))
+	for k, v := range e.base.Facts {
+		sc.Facts[k] = v
+	}
+	sc.Now = e.now()
+	if e.resolve != nil {
+		if err := e.resolve(ctx, &sc); err != nil {
+			return req, err
+		}
+	}
+	if sc.Location == nil {
+		sc.Location = time.Local
+	}
+	sc.Now = sc.Now.In(sc.Location)
+
+	var sb strings.Builder
+	if err := e.tmpl.Execute(&sb, sc); err != nil {
+		return req, fmt.Errorf("render system context: %w", err)
+	}
+	text := strings.TrimSpace(sb.String())
+	if text == "" {
+		return req, nil
+	}
+
+	i := 0
+	for i < len(req.Messages) && (req.Messages[i].IsSystem() || req.Messages[i].IsDeveloper()) {
+		i++
+	}
+	msgs := make(Messages, 0, len(req.Messages)+1)
+	msgs = append(msgs, req.Messages[:i]...)
+	msgs = append(msgs, System(text))
+	req.Messages = append(msgs, req.Messages[i:]...)
+	return req, nil
+}
+
+// CreateStream implements Streamer.
+func (e *ContextEnricher) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req, err = e.Enrich(ctx, req); err != nil {
+		return nil, err
+	}
+	return e.s.CreateStream(ctx, req)
+}
@@ -0,0 +1,95 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+func fixedClock() time.Time { return time.Date(2026, 3, 5, 12, 30, 0, 0, time.UTC) }
+
+func TestContextEnricher_InsertsAfterLeadingSystemMessages(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	up := &recordingStreamer{}
+	e := llm.NewContextEnricher(up).
+		WithClock(fixedClock).
+		WithLocation(berlin).
+		WithLocale("de-DE").
+		WithFacts(map[string]string{"name": "Ada", "role": "engineer"})
+
+	stream, err := e.CreateStream(context.Background(), llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.System("You are helpful."), llm.User("What day is it?")},
+	})
+	require.NoError(t, err)
+	llm.NewEventProcessor(context.Background(), stream).Result()
+
+	require.Equal(t, 1, up.calls())
+	msgs := up.reqs[0].Messages
+	require.Len(t, msgs, 3)
+	assert.Equal(t, "You are helpful.", msgs[0].Text())
+	assert.True(t, msgs[1].IsSystem())
+	assert.Equal(t, `<context>
+current_time: 2026-03-05T13:30:00+01:00 (Thursday)
+timezone: Europe/Berlin
+locale: de-DE
+user.name: Ada
+user.role: engineer
+</context>`, msgs[1].Text())
+	assert.Equal(t, "What day is it?", msgs[2].Text())
+}
+
+func TestContextEnricher_Resolver(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	e := llm.NewContextEnricher(nil).
+		WithClock(fixedClock).
+		WithLocation(time.UTC).
+		WithFacts(map[string]string{"plan": "free"}).
+		WithResolver(func(_ context.Context, sc *llm.SystemContext) error {
+			sc.Location = tokyo
+			sc.Facts["name"] = "Kenji"
+			return nil
+		})
+
+	req, err := e.Enrich(context.Background(), llm.Request{Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 2)
+	assert.Contains(t, req.Messages[0].Text(), "current_time: 2026-03-05T21:30:00+09:00")
+	assert.Contains(t, req.Messages[0].Text(), "user.name: Kenji")
+
+	// per-request changes do not leak into the configured facts
+	req, err = e.WithResolver(nil).Enrich(context.Background(), llm.Request{Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	assert.NotContains(t, req.Messages[0].Text(), "Kenji")
+}
+
+func TestContextEnricher_CustomTemplateAndErrors(t *testing.T) {
+	e := llm.NewContextEnricher(nil).
+		WithClock(fixedClock).
+		WithTemplate(template.Must(template.New("t").Parse(`Today is {{.Now.Format "Monday, 2 January 2006"}}.`)))
+
+	req, err := e.Enrich(context.Background(), llm.Request{Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	assert.Contains(t, req.Messages[0].Text(), "March 2026")
+
+	e.WithTemplate(template.Must(template.New("empty").Parse(" ")))
+	req, err = e.Enrich(context.Background(), llm.Request{Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	assert.Len(t, req.Messages, 1, "empty output adds no message")
+
+	boom := errors.New("no profile")
+	_, err = e.WithResolver(func(context.Context, *llm.SystemContext) error { return boom }).
+		Enrich(context.Background(), llm.Request{})
+	assert.ErrorIs(t, err, boom)
+}
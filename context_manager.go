@@ -0,0 +1,358 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	modelcatalog "github.com/codewandler/llm/internal/modelcatalog"
+	"github.com/codewandler/llm/msg"
+)
+
+// DefaultOutputReserve is the number of context tokens a ContextManager
+// keeps free for the response when Request.MaxTokens is unset.
+const DefaultOutputReserve = 4096
+
+// ContextStrategy shortens msgs until fits reports true. Strategies must
+// keep an assistant message and the tool results answering it together, so
+// the result stays valid for ValidateHistory.
+type ContextStrategy func(ctx context.Context, msgs Messages, fits func(Messages) bool) (Messages, error)
+
+// ContextManagerOption configures a ContextManager.
+type ContextManagerOption func(*ContextManager)
+
+// WithContextStrategy sets the strategy. The default is KeepSystem.
+func WithContextStrategy(s ContextStrategy) ContextManagerOption {
+	return func(m *ContextManager) { m.strategy = s }
+}
+
+// WithMessageTokenCounter replaces the built-in estimate of four bytes per
+// token, e.g. with a tokencount-based counter.
+func WithMessageTokenCounter(fn func(Messages) int) ContextManagerOption {
+	return func(m *ContextManager) { m.count = fn }
+}
+
+// WithContextWindow fixes the context window instead of looking the model
+// up in the model catalog.
+func WithContextWindow(tokens int) ContextManagerOption {
+	return func(m *ContextManager) {
+		m.window = func(string, string) (int, bool) { return tokens, true }
+	}
+}
+
+// WithOutputReserve sets the tokens kept free for the response when
+// Request.MaxTokens is unset.
+func WithOutputReserve(tokens int) ContextManagerOption {
+	return func(m *ContextManager) { m.reserve = tokens }
+}
+
+// ContextManager keeps requests within the model's context window. Before a
+// request is sent it estimates the prompt size and, when the prompt would
+// not leave room for the response, shortens the history with its strategy.
+// This prevents context_length_exceeded errors in long agent runs.
+type ContextManager struct {
+	strategy ContextStrategy
+	count    func(Messages) int
+	window   func(provider, model string) (int, bool)
+	reserve  int
+}
+
+// NewContextManager returns a ContextManager that looks up context windows
+// in the model catalog and trims with KeepSystem.
+func NewContextManager(opts ...ContextManagerOption) *ContextManager {
+	m := &ContextManager{
+		strategy: KeepSystem(),
+		count:    estimateMessageTokens,
+		window:   ContextWindow,
+		reserve:  DefaultOutputReserve,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Fit returns req with its messages shortened to fit the context window of
+// req.Model on provider. Requests for models with an unknown window are
+// returned unchanged. When even the shortest history the strategy can
+// produce does not fit, the error matches ErrContextLengthExceeded.
+func (m *ContextManager) Fit(ctx context.Context, provider string, req Request) (Request, error) {
+	window, ok := m.window(provider, req.Model)
+	if !ok || window <= 0 {
+		return req, nil
+	}
+	reserve := req.MaxTokens
+	if reserve <= 0 {
+		reserve = m.reserve
+	}
+	budget := window - reserve - estimateToolTokens(req)
+	fits := func(msgs Messages) bool { return m.count(msgs) <= budget }
+	if fits(req.Messages) {
+		return req, nil
+	}
+
+	msgs, err := m.strategy(ctx, req.Messages, fits)
+	if err != nil {
+		return req, err
+	}
+	if !fits(msgs) {
+		return req, fmt.Errorf("%w: %d estimated prompt tokens exceed the %d available in the %d token window of %s",
+			ErrContextLengthExceeded, m.count(msgs), budget, window, req.Model)
+	}
+	req.Messages = msgs
+	return req, nil
+}
+
+// Middleware returns a Middleware that fits every request before it reaches
+// the provider and reports a trimmed history as a WarningContextTrimmed
+// warning at the start of the stream.
+func (m *ContextManager) Middleware() Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fitted, err := m.Fit(ctx, next.Name(), req)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := next.CreateStream(ctx, fitted)
+		if err != nil || len(fitted.Messages) == len(req.Messages) {
+			return stream, err
+		}
+		return withLeadingWarning(ctx, stream, &WarningEvent{
+			Code:     WarningContextTrimmed,
+			Message:  fmt.Sprintf("history shortened from %d to %d messages to fit the context window", len(req.Messages), len(fitted.Messages)),
+			Provider: next.Name(),
+		}), nil
+	})
+}
+
+// withLeadingWarning emits warning right after the first event of stream,
+// stamped with its meta. When ctx is cancelled the rest of stream is
+// drained.
+func withLeadingWarning(ctx context.Context, stream Stream, warning *WarningEvent) Stream {
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		defer drain(stream)
+		send := func(env Envelope) bool {
+			select {
+			case out <- env:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		first := true
+		for env := range stream {
+			if !send(env) {
+				return
+			}
+			if first {
+				first = false
+				meta := env.Meta
+				meta.CreatedAt = time.Now()
+				if !send(Envelope{Type: warning.Type(), Meta: meta, Data: warning}) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// ContextWindow returns the context window of model on provider from the
+// model catalog (see RefreshModelCatalog).
+func ContextWindow(provider, model string) (int, bool) {
+	cat, err := modelcatalog.Load()
+	if err != nil {
+		return 0, false
+	}
+	for _, serviceID := range modelcatalog.LookupServices(provider) {
+		for _, offering := range cat.OfferingsByService(serviceID) {
+			if offering.WireModelID != model {
+				continue
+			}
+			if o := offering.LimitsOverride; o != nil && o.ContextWindow > 0 {
+				return o.ContextWindow, true
+			}
+			if rec, ok := cat.ModelByKey(offering.ModelKey); ok && rec.Limits.ContextWindow > 0 {
+				return rec.Limits.ContextWindow, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// DropOldest returns a ContextStrategy that drops the oldest messages, system
+// prompts included, until the history fits. The last message is never
+// dropped.
+func DropOldest() ContextStrategy {
+	return dropOldest(false)
+}
+
+// KeepSystem returns a ContextStrategy like DropOldest that never drops
+// system and developer messages.
+func KeepSystem() ContextStrategy {
+	return dropOldest(true)
+}
+
+func dropOldest(keepSystem bool) ContextStrategy {
+	return func(_ context.Context, msgs Messages, fits func(Messages) bool) (Messages, error) {
+		units := historyUnits(msgs)
+		for len(units) > 1 && !fits(flattenUnits(units)) {
+			i := 0
+			for keepSystem && i < len(units)-1 && isInstruction(units[i][0]) {
+				i++
+			}
+			if i == len(units)-1 {
+				break
+			}
+			units = append(units[:i], units[i+1:]...)
+			units = dropLeadingReplies(units, keepSystem)
+		}
+		return flattenUnits(units), nil
+	}
+}
+
+// SummarizeMiddle returns a ContextStrategy that replaces the messages
+// between the system prompts and the last keepLast messages with a summary
+// written by model through s. The summary is sent as a user message. When
+// the result still does not fit, KeepSystem trims it further.
+func SummarizeMiddle(s Streamer, model string, keepLast int) ContextStrategy {
+	return func(ctx context.Context, msgs Messages, fits func(Messages) bool) (Messages, error) {
+		units := historyUnits(msgs)
+		head := 0
+		for head < len(units) && isInstruction(units[head][0]) {
+			head++
+		}
+		tail := len(units)
+		for kept := 0; tail > head && kept < keepLast; {
+			tail--
+			kept += len(units[tail])
+		}
+		if tail-head < 2 {
+			return KeepSystem()(ctx, msgs, fits)
+		}
+
+		var transcript strings.Builder
+		for _, m := range flattenUnits(units[head:tail]) {
+			fmt.Fprintf(&transcript, "%s: %s\n", m.Role, messageDigest(m))
+		}
+		c, err := Complete(ctx, s, Request{
+			Model: model,
+			Messages: Messages{
+				System("Summarize the following conversation excerpt. Keep facts, decisions, open questions and tool results the conversation depends on. Reply with the summary only."),
+				User(transcript.String()),
+			},
+		})
+		if err != nil {
+			return msgs, fmt.Errorf("summarize history: %w", err)
+		}
+
+		out := flattenUnits(units[:head])
+		out = append(out, User("Summary of the earlier conversation:\n"+c.Text))
+		out = append(out, flattenUnits(units[tail:])...)
+		if fits(out) {
+			return out, nil
+		}
+		return KeepSystem()(ctx, out, fits)
+	}
+}
+
+// historyUnits groups msgs so that an assistant message with tool calls and
+// the tool messages answering it form one unit.
+func historyUnits(msgs Messages) []Messages {
+	var units []Messages
+	for _, m := range msgs {
+		if m.Role == RoleTool && len(units) > 0 {
+			last := units[len(units)-1]
+			if last[0].Role == RoleAssistant && len(last[0].ToolCalls()) > 0 {
+				units[len(units)-1] = append(last, m)
+				continue
+			}
+		}
+		units = append(units, Messages{m})
+	}
+	return units
+}
+
+func flattenUnits(units []Messages) Messages {
+	var out Messages
+	for _, u := range units {
+		out = append(out, u...)
+	}
+	return out
+}
+
+// dropLeadingReplies drops assistant and tool units that would open the
+// conversation after trimming, since most APIs expect a user turn first.
+// The last unit is always kept.
+func dropLeadingReplies(units []Messages, keepSystem bool) []Messages {
+	i := 0
+	for keepSystem && i < len(units) && isInstruction(units[i][0]) {
+		i++
+	}
+	for i < len(units)-1 && (units[i][0].Role == RoleAssistant || units[i][0].Role == RoleTool) {
+		units = append(units[:i], units[i+1:]...)
+	}
+	return units
+}
+
+func isInstruction(m Message) bool {
+	return m.Role == RoleSystem || m.Role == RoleDeveloper
+}
+
+// messageDigest renders m as plain text for a summary prompt.
+func messageDigest(m Message) string {
+	var parts []string
+	if text := m.Text(); text != "" {
+		parts = append(parts, text)
+	}
+	for _, tc := range m.ToolCalls() {
+		args, _ := json.Marshal(tc.Args)
+		parts = append(parts, fmt.Sprintf("[tool call %s %s]", tc.Name, args))
+	}
+	for _, tr := range m.ToolResults() {
+		parts = append(parts, "[tool result] "+tr.ToolOutput)
+	}
+	return strings.Join(parts, " ")
+}
+
+// estimateMessageTokens estimates the prompt size of msgs at four bytes per
+// token plus a small per-message overhead.
+func estimateMessageTokens(msgs Messages) int {
+	const perMessage = 4
+	n := 0
+	for _, m := range msgs {
+		n += perMessage
+		for _, p := range m.Parts {
+			switch p.Type {
+			case msg.PartTypeText:
+				n += estimateTokens(p.Text)
+			case msg.PartTypeThinking:
+				if p.Thinking != nil {
+					n += estimateTokens(p.Thinking.Text)
+				}
+			case msg.PartTypeToolCall:
+				args, _ := json.Marshal(p.ToolCall.Args)
+				n += estimateTokens(p.ToolCall.Name) + estimateTokens(string(args))
+			case msg.PartTypeToolResult:
+				n += estimateTokens(p.ToolResult.ToolOutput)
+			}
+		}
+	}
+	return n
+}
+
+// estimateToolTokens estimates the size of the tool definitions of req.
+func estimateToolTokens(req Request) int {
+	if len(req.Tools) == 0 {
+		return 0
+	}
+	data, _ := json.Marshal(req.Tools)
+	return estimateTokens(string(data))
+}
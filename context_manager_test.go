@@ -0,0 +1,109 @@
+package llm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+)
+
+// longHistory returns a system prompt followed by n user/assistant turns of
+// about 100 tokens each.
+func longHistory(n int) llm.Messages {
+	msgs := llm.Messages{llm.System("be brief")}
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, llm.User(strings.Repeat("q", 200)), llm.Assistant(strings.Repeat("a", 200)))
+	}
+	return append(msgs, llm.User("last question"))
+}
+
+func TestContextManager_KeepSystemTrimsOldest(t *testing.T) {
+	m := llm.NewContextManager(llm.WithContextWindow(600), llm.WithOutputReserve(100))
+	req, err := m.Fit(context.Background(), "anthropic", llm.Request{Model: "claude-sonnet-4-6", Messages: longHistory(10)})
+	require.NoError(t, err)
+
+	require.Greater(t, len(req.Messages), 2)
+	assert.Less(t, len(req.Messages), 22)
+	assert.Equal(t, llm.RoleSystem, req.Messages[0].Role)
+	assert.Equal(t, llm.RoleUser, req.Messages[1].Role)
+	assert.Equal(t, "last question", req.Messages[len(req.Messages)-1].Text())
+}
+
+func TestContextManager_DropOldestKeepsToolPairs(t *testing.T) {
+	call := msg.Assistant(msg.NewToolCall("c1", "search", map[string]any{"q": "x"})).Build()
+	result := msg.ToolResult{ToolCallID: "c1", ToolOutput: strings.Repeat("r", 400)}.IntoMessage()
+	history := llm.Messages{
+		llm.System(strings.Repeat("s", 400)),
+		llm.User("first"), call, result,
+		llm.User(strings.Repeat("u", 400)),
+	}
+
+	m := llm.NewContextManager(llm.WithContextWindow(300), llm.WithOutputReserve(100), llm.WithContextStrategy(llm.DropOldest()))
+	req, err := m.Fit(context.Background(), "anthropic", llm.Request{Model: "m", Messages: history})
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 1)
+	assert.Equal(t, history[4], req.Messages[0])
+	require.NoError(t, llm.ValidateHistory(req.Messages))
+}
+
+func TestContextManager_TooLargeAndUnknownWindow(t *testing.T) {
+	req := llm.Request{Model: "unknown-model", Messages: llm.Messages{llm.User(strings.Repeat("x", 4000))}}
+
+	_, err := llm.NewContextManager(llm.WithContextWindow(500)).Fit(context.Background(), "anthropic", llm.Request{
+		Model: req.Model, Messages: req.Messages, MaxTokens: 100,
+	})
+	assert.ErrorIs(t, err, llm.ErrContextLengthExceeded)
+
+	got, err := llm.NewContextManager().Fit(context.Background(), "nowhere", req)
+	require.NoError(t, err)
+	assert.Equal(t, req, got)
+}
+
+func TestContextManager_SummarizeMiddle(t *testing.T) {
+	summarizer := &middlewareTestProvider{events: []llm.Event{
+		llmtest.TextEvent("they talked"),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}
+	m := llm.NewContextManager(
+		llm.WithContextWindow(600), llm.WithOutputReserve(100),
+		llm.WithContextStrategy(llm.SummarizeMiddle(summarizer, "small-model", 2)),
+	)
+
+	req, err := m.Fit(context.Background(), "anthropic", llm.Request{Model: "m", Messages: longHistory(10)})
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 4)
+	assert.Equal(t, llm.RoleSystem, req.Messages[0].Role)
+	assert.Equal(t, "Summary of the earlier conversation:\nthey talked", req.Messages[1].Text())
+	assert.Equal(t, "last question", req.Messages[3].Text())
+}
+
+func TestContextManager_MiddlewareWarns(t *testing.T) {
+	rec := &recordingProvider{name: "anthropic"}
+	p := llm.Wrap(rec, llm.NewContextManager(llm.WithContextWindow(600), llm.WithOutputReserve(100)).Middleware())
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "claude-sonnet-4-6", Messages: longHistory(10)})
+	require.NoError(t, err)
+	var warnings []*llm.WarningEvent
+	for env := range stream {
+		if w, ok := env.Data.(*llm.WarningEvent); ok {
+			warnings = append(warnings, w)
+		}
+	}
+
+	require.Len(t, rec.reqs, 1)
+	assert.Less(t, len(rec.reqs[0].Messages), 22)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, llm.WarningContextTrimmed, warnings[0].Code)
+}
+
+func TestContextWindow_FromCatalog(t *testing.T) {
+	window, ok := llm.ContextWindow("anthropic", "claude-sonnet-4-6")
+	require.True(t, ok)
+	assert.Greater(t, window, 100_000)
+}
@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/codewandler/llm/msg"
+)
+
+// Conversation owns the message history of a multi-turn exchange. It keeps
+// the history in a valid order as messages are added, appends the assistant
+// reply of every stream it sends, and can be snapshotted and restored, so
+// callers do not have to build Messages slices by hand. A Conversation is
+// safe for concurrent use.
+//
+//	conv := llm.NewConversation(llm.System("You are terse."))
+//	stream, err := conv.Send(ctx, svc, llm.Request{
+//	    Model:    "default",
+//	    Messages: llm.Messages{llm.User("Hello")},
+//	})
+//	// drain stream; the reply is now in conv.Messages()
+type Conversation struct {
+	mu       sync.Mutex
+	messages Messages
+	err      error // reply that could not be appended, reported by Send
+}
+
+// ConversationSnapshot is the persistable state of a Conversation.
+type ConversationSnapshot struct {
+	Messages Messages `json:"messages"`
+}
+
+// NewConversation returns a Conversation starting with msgs. Invalid seed
+// messages are reported by the first Append or Send; use Restore to check
+// them up front.
+func NewConversation(msgs ...Message) *Conversation {
+	return &Conversation{messages: append(Messages(nil), msgs...)}
+}
+
+// Messages returns a copy of the history.
+func (c *Conversation) Messages() Messages {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(Messages(nil), c.messages...)
+}
+
+// Len returns the number of messages in the history.
+func (c *Conversation) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.messages)
+}
+
+// Append adds msgs to the history. It fails without changing the history
+// when a message is invalid or the result breaks the tool call ordering
+// checked by ValidateHistory.
+func (c *Conversation) Append(msgs ...Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.appendLocked(msgs...)
+}
+
+func (c *Conversation) appendLocked(msgs ...Message) error {
+	next := append(append(Messages(nil), c.messages...), msgs...)
+	if err := ValidateHistory(next); err != nil {
+		return err
+	}
+	c.messages = next
+	return nil
+}
+
+// AddUser appends a user message with text.
+func (c *Conversation) AddUser(text string) error { return c.Append(User(text)) }
+
+// AddToolResults appends a tool message answering pending tool calls of the
+// last assistant message.
+func (c *Conversation) AddToolResults(results ...msg.ToolResult) error {
+	return c.Append(msg.Tool().Results(msg.ToolResults(results)).Build())
+}
+
+// PendingToolCalls returns the tool calls of the last assistant message that
+// have no result yet.
+func (c *Conversation) PendingToolCalls() msg.ToolCalls {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return pendingToolCalls(c.messages)
+}
+
+// Send appends req.Messages to the history and streams a request carrying
+// the whole history through s. The assistant reply is appended once the
+// stream ends without error, so drain the stream before the next Send. A
+// request that fails to start leaves the appended messages in place.
+//
+// If the previous reply could not be appended, e.g. because it carries a
+// tool call without an ID, Send returns that error once without changing
+// the history or sending anything.
+func (c *Conversation) Send(ctx context.Context, s Streamer, req Request) (Stream, error) {
+	c.mu.Lock()
+	if err := c.err; err != nil {
+		c.err = nil
+		c.mu.Unlock()
+		return nil, fmt.Errorf("conversation: append reply: %w", err)
+	}
+	if err := c.appendLocked(req.Messages...); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	req.Messages = append(Messages(nil), c.messages...)
+	c.mu.Unlock()
+
+	stream, err := s.CreateStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	acc := NewAccumulator()
+	return ObserveStream(ctx, stream, acc.Add, func() {
+		acc.Close()
+		if acc.Err() != nil {
+			return
+		}
+		if m := acc.Completion().Message; len(m.Parts) > 0 {
+			c.mu.Lock()
+			c.err = c.appendLocked(m)
+			c.mu.Unlock()
+		}
+	}), nil
+}
+
+// Snapshot returns the current state for persistence.
+func (c *Conversation) Snapshot() ConversationSnapshot {
+	return ConversationSnapshot{Messages: c.Messages()}
+}
+
+// Restore replaces the history with snap after validating it.
+func (c *Conversation) Restore(snap ConversationSnapshot) error {
+	if err := ValidateHistory(snap.Messages); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(Messages(nil), snap.Messages...)
+	return nil
+}
+
+// ValidateHistory checks every message and the tool call ordering of msgs:
+// each tool result must answer a call of the nearest preceding assistant
+// message, no call may be answered twice, and every call must be answered
+// before the next non-tool message. Calls of the last assistant message may
+// still be unanswered, as while its tools run.
+func ValidateHistory(msgs Messages) error {
+	var (
+		calls    msg.ToolCalls
+		answered map[string]bool
+		from     int
+	)
+	for i, m := range msgs {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		if m.Role == RoleTool {
+			if calls == nil {
+				return fmt.Errorf("messages[%d]: tool result without a preceding tool call", i)
+			}
+			for _, r := range m.ToolResults() {
+				if !slices.ContainsFunc(calls, func(tc msg.ToolCall) bool { return tc.ID == r.ToolCallID }) {
+					return fmt.Errorf("messages[%d]: tool result for unknown tool call %q", i, r.ToolCallID)
+				}
+				if answered[r.ToolCallID] {
+					return fmt.Errorf("messages[%d]: tool call %q answered twice", i, r.ToolCallID)
+				}
+				answered[r.ToolCallID] = true
+			}
+			continue
+		}
+		for _, tc := range calls {
+			if !answered[tc.ID] {
+				return fmt.Errorf("messages[%d]: tool call %q of messages[%d] has no result", i, tc.ID, from)
+			}
+		}
+		calls, answered, from = nil, nil, i
+		if m.Role == RoleAssistant && len(m.ToolCalls()) > 0 {
+			calls, answered = m.ToolCalls(), map[string]bool{}
+		}
+	}
+	return nil
+}
+
+// pendingToolCalls returns the unanswered calls of the last assistant
+// message of msgs.
+func pendingToolCalls(msgs Messages) msg.ToolCalls {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == RoleTool {
+			continue
+		}
+		if msgs[i].Role != RoleAssistant {
+			return nil
+		}
+		answered := map[string]bool{}
+		for _, m := range msgs[i+1:] {
+			for _, r := range m.ToolResults() {
+				answered[r.ToolCallID] = true
+			}
+		}
+		var pending msg.ToolCalls
+		for _, tc := range msgs[i].ToolCalls() {
+			if !answered[tc.ID] {
+				pending = append(pending, tc)
+			}
+		}
+		return pending
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+)
+
+func drainConversation(t *testing.T, conv *llm.Conversation, p llm.Provider, next ...llm.Message) {
+	t.Helper()
+	stream, err := conv.Send(context.Background(), p, llm.Request{Model: "fake-model", Messages: next})
+	require.NoError(t, err)
+	require.NoError(t, llm.ProcessEvents(context.Background(), stream).Error())
+}
+
+func TestConversation_SendAppendsReplyAndToolResults(t *testing.T) {
+	conv := llm.NewConversation(llm.System("be brief"))
+	p := &middlewareTestProvider{events: []llm.Event{
+		llmtest.TextEvent("checking"),
+		llmtest.ToolEvent("call_1", "weather", map[string]any{"city": "Berlin"}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	}}
+
+	drainConversation(t, conv, p, llm.User("weather?"))
+
+	msgs := conv.Messages()
+	require.Len(t, msgs, 3)
+	assert.Equal(t, llm.RoleAssistant, msgs[2].Role)
+	assert.Equal(t, "checking", msgs[2].Text())
+	require.Len(t, conv.PendingToolCalls(), 1)
+
+	// A user turn cannot skip the pending tool call.
+	assert.ErrorContains(t, conv.AddUser("never mind"), `tool call "call_1"`)
+	assert.Equal(t, 3, conv.Len())
+
+	require.NoError(t, conv.AddToolResults(msg.ToolResult{ToolCallID: "call_1", ToolOutput: "sunny"}))
+	assert.Empty(t, conv.PendingToolCalls())
+
+	p.events = []llm.Event{llmtest.TextEvent("It is sunny."), llmtest.CompletedEvent(llm.StopReasonEndTurn)}
+	drainConversation(t, conv, p)
+	assert.Equal(t, "It is sunny.", conv.Messages()[4].Text())
+}
+
+func TestConversation_FailedStreamNotAppended(t *testing.T) {
+	conv := llm.NewConversation()
+	p := &middlewareTestProvider{events: []llm.Event{
+		llmtest.TextEvent("partial"),
+		&llm.ErrorEvent{Error: errors.New("boom")},
+	}}
+
+	stream, err := conv.Send(context.Background(), p, llm.Request{Model: "fake-model", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	require.Error(t, llm.ProcessEvents(context.Background(), stream).Error())
+	assert.Equal(t, 1, conv.Len())
+}
+
+func TestConversation_ReplyAppendErrorReportedBySend(t *testing.T) {
+	conv := llm.NewConversation()
+	p := &middlewareTestProvider{events: []llm.Event{
+		llmtest.ToolEvent("", "weather", map[string]any{"city": "Berlin"}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	}}
+	drainConversation(t, conv, p, llm.User("weather?"))
+	assert.Equal(t, 1, conv.Len(), "invalid reply is not appended")
+
+	_, err := conv.Send(context.Background(), p, llm.Request{Model: "fake-model", Messages: llm.Messages{llm.User("again")}})
+	require.ErrorContains(t, err, "append reply")
+	assert.Equal(t, 1, conv.Len())
+
+	p.events = []llm.Event{llmtest.TextEvent("ok"), llmtest.CompletedEvent(llm.StopReasonEndTurn)}
+	drainConversation(t, conv, p, llm.User("again"))
+	assert.Equal(t, 3, conv.Len())
+}
+
+func TestConversation_SnapshotRestore(t *testing.T) {
+	conv := llm.NewConversation(llm.System("sys"))
+	require.NoError(t, conv.AddUser("hi"))
+
+	data, err := json.Marshal(conv.Snapshot())
+	require.NoError(t, err)
+
+	var snap llm.ConversationSnapshot
+	require.NoError(t, json.Unmarshal(data, &snap))
+	restored := llm.NewConversation()
+	require.NoError(t, restored.Restore(snap))
+	assert.Equal(t, conv.Messages(), restored.Messages())
+
+	bad := llm.ConversationSnapshot{Messages: llm.Messages{msg.ToolResult{ToolCallID: "x", ToolOutput: "y"}.IntoMessage()}}
+	assert.ErrorContains(t, restored.Restore(bad), "without a preceding tool call")
+	assert.Equal(t, 2, restored.Len())
+}
+
+func TestValidateHistory(t *testing.T) {
+	call := msg.Assistant(msg.NewToolCall("a", "t", nil), msg.NewToolCall("b", "t", nil)).Build()
+	result := func(id string) llm.Message { return msg.ToolResult{ToolCallID: id, ToolOutput: "ok"}.IntoMessage() }
+
+	assert.NoError(t, llm.ValidateHistory(llm.Messages{llm.User("q"), call}))
+	assert.NoError(t, llm.ValidateHistory(llm.Messages{llm.User("q"), call, result("a"), result("b"), llm.User("next")}))
+	assert.ErrorContains(t, llm.ValidateHistory(llm.Messages{call, result("c")}), "unknown tool call")
+	assert.ErrorContains(t, llm.ValidateHistory(llm.Messages{call, result("a"), result("a")}), "answered twice")
+	assert.ErrorContains(t, llm.ValidateHistory(llm.Messages{call, result("a"), llm.User("next")}), `tool call "b"`)
+}
@@ -0,0 +1,308 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm/usage"
+)
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a context that attributes requests to tenant in
+// CostMetrics.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with ContextWithTenant, or "".
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// TenantFunc extracts the tenant label of a request from its context and
+// usage record.
+type TenantFunc func(ctx context.Context, r usage.Record) string
+
+// CostMetricsOption configures a CostMetrics.
+type CostMetricsOption func(*CostMetrics)
+
+// WithTenantFunc replaces TenantFromContext as the source of the tenant
+// label.
+func WithTenantFunc(fn TenantFunc) CostMetricsOption {
+	return func(m *CostMetrics) { m.tenant = fn }
+}
+
+// WithMetricsNamespace replaces "llm" as the metric name prefix.
+func WithMetricsNamespace(ns string) CostMetricsOption {
+	return func(m *CostMetrics) { m.namespace = ns }
+}
+
+// CostMetrics exports cumulative cost, token and request counts by tenant,
+// provider and model in the Prometheus text exposition format, so cost
+// anomalies can be alerted on without an extra exporter. Install it with
+// Middleware, then serve it over HTTP (it is an http.Handler) or write it
+// periodically with WriteSnapshots.
+//
+// Exported series, with labels tenant, provider and model:
+//
+//	llm_requests_total               counter
+//	llm_tokens_total{kind="..."}     counter
+//	llm_cost_usd_total               counter
+//	llm_cost_last_request_usd        gauge
+type CostMetrics struct {
+	tenant    TenantFunc
+	namespace string
+
+	mu     sync.Mutex
+	series map[costSeriesKey]*costSeries
+}
+
+type costSeriesKey struct {
+	tenant, provider, model string
+}
+
+type costSeries struct {
+	requests    int
+	tokens      map[usage.TokenKind]int
+	costUSD     float64
+	lastCostUSD float64
+}
+
+// NewCostMetrics returns an empty CostMetrics.
+func NewCostMetrics(opts ...CostMetricsOption) *CostMetrics {
+	m := &CostMetrics{
+		tenant: func(ctx context.Context, _ usage.Record) string {
+			return TenantFromContext(ctx)
+		},
+		namespace: "llm",
+		series:    map[costSeriesKey]*costSeries{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Middleware returns a Middleware that records the usage of every stream
+// as one request once the stream ends.
+func (m *CostMetrics) Middleware() Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		stream, err := next.CreateStream(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			total usage.Record
+			seen  bool
+		)
+		return ObserveStream(ctx, stream, func(env Envelope) {
+			if ev, ok := env.Data.(*UsageUpdatedEvent); ok && !ev.Record.IsEstimate {
+				total = mergeRecord(total, ev.Record, !seen)
+				seen = true
+			}
+		}, func() {
+			if seen {
+				m.Record(m.tenant(ctx, total), total)
+			}
+		}), nil
+	})
+}
+
+// mergeRecord adds r to total. The first record provides the dims.
+func mergeRecord(total, r usage.Record, first bool) usage.Record {
+	if first {
+		total.Dims = r.Dims
+	}
+	for _, item := range r.Tokens {
+		found := false
+		for i := range total.Tokens {
+			if total.Tokens[i].Kind == item.Kind {
+				total.Tokens[i].Count += item.Count
+				found = true
+				break
+			}
+		}
+		if !found {
+			total.Tokens = append(total.Tokens, item)
+		}
+	}
+	total.Cost.Total += r.Cost.Total
+	return total
+}
+
+// Record adds r to the series of tenant as one request. Estimate records are
+// ignored.
+func (m *CostMetrics) Record(tenant string, r usage.Record) {
+	if r.IsEstimate {
+		return
+	}
+	key := costSeriesKey{tenant: tenant, provider: r.Dims.Provider, model: r.Dims.Model}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[key]
+	if !ok {
+		s = &costSeries{tokens: map[usage.TokenKind]int{}}
+		m.series[key] = s
+	}
+	s.requests++
+	for _, item := range r.Tokens {
+		s.tokens[item.Kind] += item.Count
+	}
+	s.costUSD += r.Cost.Total
+	s.lastCostUSD = r.Cost.Total
+}
+
+// WriteTo writes all series in the Prometheus text exposition format.
+func (m *CostMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]costSeriesKey, 0, len(m.series))
+	series := make(map[costSeriesKey]costSeries, len(m.series))
+	for k, s := range m.series {
+		keys = append(keys, k)
+		c := *s
+		c.tokens = make(map[usage.TokenKind]int, len(s.tokens))
+		for kind, n := range s.tokens {
+			c.tokens[kind] = n
+		}
+		series[k] = c
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.tenant != b.tenant {
+			return a.tenant < b.tenant
+		}
+		if a.provider != b.provider {
+			return a.provider < b.provider
+		}
+		return a.model < b.model
+	})
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	m.writeFamily(cw, "requests_total", "counter", "Requests with reported usage.", keys, func(k costSeriesKey, labels string) {
+		fmt.Fprintf(cw, "%s{%s} %d\n", m.name("requests_total"), labels, series[k].requests)
+	})
+	m.writeFamily(cw, "tokens_total", "counter", "Tokens used, by token kind.", keys, func(k costSeriesKey, labels string) {
+		s := series[k]
+		kinds := make([]string, 0, len(s.tokens))
+		for kind := range s.tokens {
+			kinds = append(kinds, string(kind))
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(cw, "%s{%s,kind=%s} %d\n", m.name("tokens_total"), labels, quoteLabel(kind), s.tokens[usage.TokenKind(kind)])
+		}
+	})
+	m.writeFamily(cw, "cost_usd_total", "counter", "Estimated cost in USD.", keys, func(k costSeriesKey, labels string) {
+		fmt.Fprintf(cw, "%s{%s} %s\n", m.name("cost_usd_total"), labels, formatFloat(series[k].costUSD))
+	})
+	m.writeFamily(cw, "cost_last_request_usd", "gauge", "Estimated cost in USD of the most recent request.", keys, func(k costSeriesKey, labels string) {
+		fmt.Fprintf(cw, "%s{%s} %s\n", m.name("cost_last_request_usd"), labels, formatFloat(series[k].lastCostUSD))
+	})
+	if cw.err == nil {
+		cw.err = bw.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (m *CostMetrics) writeFamily(w io.Writer, name, typ, help string, keys []costSeriesKey, write func(costSeriesKey, string)) {
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name(name), help, m.name(name), typ)
+	for _, k := range keys {
+		write(k, fmt.Sprintf("tenant=%s,provider=%s,model=%s", quoteLabel(k.tenant), quoteLabel(k.provider), quoteLabel(k.model)))
+	}
+}
+
+func (m *CostMetrics) name(metric string) string {
+	if m.namespace == "" {
+		return metric
+	}
+	return m.namespace + "_" + metric
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape.
+func (m *CostMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// WriteSnapshots writes the metrics to the file at path now and then every
+// interval until ctx is done, e.g. for the node_exporter textfile collector.
+// Each snapshot replaces the file atomically. It returns the first write
+// error, or nil once ctx is done.
+func (m *CostMetrics) WriteSnapshots(ctx context.Context, path string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.writeFile(path); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *CostMetrics) writeFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("cost metrics snapshot: %w", err)
+	}
+	_, err = m.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("cost metrics snapshot: %w", err)
+	}
+	return nil
+}
+
+// quoteLabel quotes a label value with the escapes of the Prometheus text
+// format.
+func quoteLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
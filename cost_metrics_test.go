@@ -0,0 +1,68 @@
+package llm_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
+)
+
+func TestCostMetrics_MiddlewareByTenant(t *testing.T) {
+	metrics := llm.NewCostMetrics()
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 10, 2, 0.25),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, metrics.Middleware())
+
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		require.NoError(t, drainCosted(llm.ContextWithTenant(context.Background(), tenant), t, p))
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE llm_cost_usd_total counter\n")
+	assert.Contains(t, body, `llm_requests_total{tenant="acme",provider="fake",model="fake-model"} 2`)
+	assert.Contains(t, body, `llm_cost_usd_total{tenant="acme",provider="fake",model="fake-model"} 0.5`)
+	assert.Contains(t, body, `llm_tokens_total{tenant="globex",provider="fake",model="fake-model",kind="input"} 10`)
+	assert.Contains(t, body, "# TYPE llm_cost_last_request_usd gauge\n")
+	assert.Less(t, strings.Index(body, `tenant="acme"`), strings.Index(body, `tenant="globex"`))
+}
+
+func TestCostMetrics_NamespaceAndEscaping(t *testing.T) {
+	metrics := llm.NewCostMetrics(llm.WithMetricsNamespace("app"))
+	metrics.Record("a\"b", usage.Record{Dims: usage.Dims{Provider: "p", Model: "m"}, Cost: usage.Cost{Total: 1}})
+	metrics.Record("ignored", usage.Record{IsEstimate: true, Cost: usage.Cost{Total: 9}})
+
+	var sb strings.Builder
+	_, err := metrics.WriteTo(&sb)
+	require.NoError(t, err)
+	assert.Contains(t, sb.String(), `app_cost_usd_total{tenant="a\"b",provider="p",model="m"} 1`)
+	assert.NotContains(t, sb.String(), "ignored")
+}
+
+func TestCostMetrics_WriteSnapshots(t *testing.T) {
+	metrics := llm.NewCostMetrics()
+	metrics.Record("acme", usage.Record{Dims: usage.Dims{Provider: "p", Model: "m"}, Cost: usage.Cost{Total: 0.1}})
+
+	path := filepath.Join(t.TempDir(), "llm.prom")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, metrics.WriteSnapshots(ctx, path, time.Hour))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `llm_cost_usd_total{tenant="acme",provider="p",model="m"} 0.1`)
+}
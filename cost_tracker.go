@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm/usage"
+)
+
+// ErrBudgetExceeded is matched by a *BudgetExceededError with errors.Is.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetScope says which budget of a CostTracker was exceeded.
+type BudgetScope string
+
+const (
+	BudgetScopeTotal        BudgetScope = "total"
+	BudgetScopeConversation BudgetScope = "conversation"
+)
+
+// BudgetExceededError is returned by CreateStream through a CostTracker when
+// the tracked spend has reached a budget.
+type BudgetExceededError struct {
+	Scope BudgetScope
+	// Conversation is the conversation ID for BudgetScopeConversation.
+	Conversation string
+	Budget       usage.Budget
+	Spent        CostTotals
+}
+
+func (e *BudgetExceededError) Error() string {
+	if e.Scope == BudgetScopeConversation {
+		return fmt.Sprintf("%s: conversation %q spent $%.4f, %d tokens", ErrBudgetExceeded, e.Conversation, e.Spent.CostUSD, e.Spent.InputTokens+e.Spent.OutputTokens)
+	}
+	return fmt.Sprintf("%s: spent $%.4f, %d tokens", ErrBudgetExceeded, e.Spent.CostUSD, e.Spent.InputTokens+e.Spent.OutputTokens)
+}
+
+// Is reports whether target is ErrBudgetExceeded.
+func (e *BudgetExceededError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// CostTotals sums the usage of a group of requests.
+type CostTotals struct {
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Add counts the tokens and cost of r, and a request when newRequest is set
+// (see UsageLogEntry.NewRequest).
+func (c *CostTotals) Add(r usage.Record, newRequest bool) {
+	if newRequest {
+		c.Requests++
+	}
+	c.InputTokens += r.Tokens.TotalInput()
+	c.OutputTokens += r.Tokens.TotalOutput()
+	c.CostUSD += r.Cost.Total
+}
+
+func (c CostTotals) record() usage.Record {
+	return usage.Record{
+		Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: c.InputTokens}, {Kind: usage.KindOutput, Count: c.OutputTokens}},
+		Cost:   usage.Cost{Total: c.CostUSD},
+	}
+}
+
+// CostSnapshot is a point-in-time report of a CostTracker.
+type CostSnapshot struct {
+	TakenAt        time.Time             `json:"taken_at"`
+	Total          CostTotals            `json:"total"`
+	ByConversation map[string]CostTotals `json:"by_conversation,omitempty"`
+	ByProvider     map[string]CostTotals `json:"by_provider,omitempty"`
+	ByModel        map[string]CostTotals `json:"by_model,omitempty"`
+}
+
+type conversationKey struct{}
+
+// ContextWithConversation returns a context that attributes requests to the
+// conversation id in a CostTracker.
+func ContextWithConversation(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, conversationKey{}, id)
+}
+
+// ConversationFromContext returns the conversation ID set with
+// ContextWithConversation, or "".
+func ConversationFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(conversationKey{}).(string)
+	return id
+}
+
+// CostTrackerOption configures a CostTracker.
+type CostTrackerOption func(*CostTracker)
+
+// WithTotalBudget limits the spend across all requests of the tracker.
+func WithTotalBudget(b usage.Budget) CostTrackerOption {
+	return func(t *CostTracker) { t.total = b }
+}
+
+// WithConversationBudget limits the spend of each conversation.
+func WithConversationBudget(b usage.Budget) CostTrackerOption {
+	return func(t *CostTracker) { t.conversation = b }
+}
+
+// WithUsageLog appends every usage record the tracker counts to w as one
+// JSON line (a UsageLogEntry), e.g. to a file opened with O_APPEND. Read it
+// back with ReadUsageLog. Write errors are ignored.
+func WithUsageLog(w io.Writer) CostTrackerOption {
+	return func(t *CostTracker) { t.log = w }
+}
+
+// UsageLogEntry is one line of a usage log written by a CostTracker.
+type UsageLogEntry struct {
+	Time         time.Time `json:"time"`
+	Conversation string    `json:"conversation,omitempty"`
+	// NewRequest marks the first record of a request; later records of the
+	// same stream add tokens and cost but not a request.
+	NewRequest bool         `json:"new_request,omitempty"`
+	Record     usage.Record `json:"record"`
+}
+
+// ReadUsageLog reads the entries of a usage log written with WithUsageLog.
+func ReadUsageLog(r io.Reader) ([]UsageLogEntry, error) {
+	var entries []UsageLogEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e UsageLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("usage log line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// CostTracker aggregates usage and cost across calls per conversation,
+// provider and model, and enforces budgets. Install it with
+// Middleware (or WithMiddleware on a Service); requests are attributed to
+// the conversation set with ContextWithConversation.
+//
+// Budgets are checked before each request, so the request that crosses a
+// limit completes and the next one fails with a *BudgetExceededError.
+type CostTracker struct {
+	total        usage.Budget
+	conversation usage.Budget
+	log          io.Writer
+
+	mu             sync.Mutex
+	sum            CostTotals
+	byConversation map[string]*CostTotals
+	byProvider     map[string]*CostTotals
+	byModel        map[string]*CostTotals
+}
+
+// NewCostTracker returns an empty CostTracker.
+func NewCostTracker(opts ...CostTrackerOption) *CostTracker {
+	t := &CostTracker{
+		byConversation: map[string]*CostTotals{},
+		byProvider:     map[string]*CostTotals{},
+		byModel:        map[string]*CostTotals{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Middleware returns a Middleware that checks the budgets before every
+// CreateStream and records the usage of every stream.
+func (t *CostTracker) Middleware() Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		conversation := ConversationFromContext(ctx)
+		if err := t.Check(conversation); err != nil {
+			return nil, err
+		}
+		stream, err := next.CreateStream(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		var counted bool
+		return ObserveStream(ctx, stream, func(env Envelope) {
+			if ev, ok := env.Data.(*UsageUpdatedEvent); ok {
+				t.record(conversation, ev.Record, !counted)
+				counted = true
+			}
+		}, nil), nil
+	})
+}
+
+// Record adds r to the totals of conversation as one request. Estimate
+// records are ignored.
+func (t *CostTracker) Record(conversation string, r usage.Record) {
+	t.record(conversation, r, true)
+}
+
+func (t *CostTracker) record(conversation string, r usage.Record, newRequest bool) {
+	if r.IsEstimate {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	groups := []*CostTotals{&t.sum, group(t.byProvider, r.Dims.Provider), group(t.byModel, r.Dims.Model)}
+	if conversation != "" {
+		groups = append(groups, group(t.byConversation, conversation))
+	}
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		g.Add(r, newRequest)
+	}
+	if t.log != nil {
+		line, err := json.Marshal(UsageLogEntry{Time: time.Now(), Conversation: conversation, NewRequest: newRequest, Record: r})
+		if err == nil {
+			_, _ = t.log.Write(append(line, '\n'))
+		}
+	}
+}
+
+func group(m map[string]*CostTotals, key string) *CostTotals {
+	if key == "" {
+		return nil
+	}
+	g, ok := m[key]
+	if !ok {
+		g = &CostTotals{}
+		m[key] = g
+	}
+	return g
+}
+
+// Check returns a *BudgetExceededError when the total budget or the budget
+// of conversation has been reached.
+func (t *CostTracker) Check(conversation string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.total.Exceeded(t.sum.record()) {
+		return &BudgetExceededError{Scope: BudgetScopeTotal, Budget: t.total, Spent: t.sum}
+	}
+	if conversation == "" {
+		return nil
+	}
+	if g, ok := t.byConversation[conversation]; ok && t.conversation.Exceeded(g.record()) {
+		return &BudgetExceededError{Scope: BudgetScopeConversation, Conversation: conversation, Budget: t.conversation, Spent: *g}
+	}
+	return nil
+}
+
+// Snapshot returns the current totals.
+func (t *CostTracker) Snapshot() CostSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return CostSnapshot{
+		TakenAt:        time.Now(),
+		Total:          t.sum,
+		ByConversation: copyTotals(t.byConversation),
+		ByProvider:     copyTotals(t.byProvider),
+		ByModel:        copyTotals(t.byModel),
+	}
+}
+
+func copyTotals(m map[string]*CostTotals) map[string]CostTotals {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]CostTotals, len(m))
+	for k, v := range m {
+		out[k] = *v
+	}
+	return out
+}
+
+// Reset clears all totals.
+func (t *CostTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sum = CostTotals{}
+	clear(t.byConversation)
+	clear(t.byProvider)
+	clear(t.byModel)
+}
@@ -0,0 +1,116 @@
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
+)
+
+func costEvent(provider, model string, in, out int, cost float64) llm.Event {
+	return llmtest.UsageEvent(usage.Record{
+		Dims:   usage.Dims{Provider: provider, Model: model},
+		Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: in}, {Kind: usage.KindOutput, Count: out}},
+		Cost:   usage.Cost{Total: cost, Source: "calculated"},
+	})
+}
+
+func drainCosted(ctx context.Context, t *testing.T, p llm.Provider) error {
+	t.Helper()
+	stream, err := p.CreateStream(ctx, llm.Request{Model: "fake-model"})
+	if err != nil {
+		return err
+	}
+	return llm.NewEventProcessor(ctx, stream).Result().Error()
+}
+
+func TestCostTracker_AggregatesAndSnapshots(t *testing.T) {
+	tracker := llm.NewCostTracker()
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 100, 10, 0.25),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, tracker.Middleware())
+
+	require.NoError(t, drainCosted(llm.ContextWithConversation(context.Background(), "c1"), t, p))
+	require.NoError(t, drainCosted(llm.ContextWithConversation(context.Background(), "c2"), t, p))
+	require.NoError(t, drainCosted(context.Background(), t, p))
+
+	snap := tracker.Snapshot()
+	assert.Equal(t, llm.CostTotals{Requests: 3, InputTokens: 300, OutputTokens: 30, CostUSD: 0.75}, snap.Total)
+	assert.Equal(t, 1, snap.ByConversation["c1"].Requests)
+	assert.Len(t, snap.ByConversation, 2)
+	assert.Equal(t, 3, snap.ByProvider["fake"].Requests)
+	assert.InDelta(t, 0.75, snap.ByModel["fake-model"].CostUSD, 1e-9)
+
+	tracker.Reset()
+	assert.Equal(t, llm.CostTotals{}, tracker.Snapshot().Total)
+}
+
+func TestCostTracker_ConversationBudget(t *testing.T) {
+	tracker := llm.NewCostTracker(llm.WithConversationBudget(usage.Budget{MaxCostUSD: 0.5}))
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 100, 10, 0.3),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, tracker.Middleware())
+
+	ctx := llm.ContextWithConversation(context.Background(), "c1")
+	require.NoError(t, drainCosted(ctx, t, p))
+	require.NoError(t, drainCosted(ctx, t, p))
+
+	err := drainCosted(ctx, t, p)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrBudgetExceeded)
+	var be *llm.BudgetExceededError
+	require.True(t, errors.As(err, &be))
+	assert.Equal(t, llm.BudgetScopeConversation, be.Scope)
+	assert.Equal(t, "c1", be.Conversation)
+	assert.InDelta(t, 0.6, be.Spent.CostUSD, 1e-9)
+
+	// Other conversations keep their own budget.
+	require.NoError(t, drainCosted(llm.ContextWithConversation(context.Background(), "c2"), t, p))
+}
+
+func TestCostTracker_TotalBudget(t *testing.T) {
+	tracker := llm.NewCostTracker(llm.WithTotalBudget(usage.Budget{MaxTotalTokens: 100}))
+	tracker.Record("", usage.Record{Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 100}}})
+	tracker.Record("", usage.Record{Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 1000}}, IsEstimate: true})
+
+	err := tracker.Check("any")
+	var be *llm.BudgetExceededError
+	require.True(t, errors.As(err, &be))
+	assert.Equal(t, llm.BudgetScopeTotal, be.Scope)
+	assert.Equal(t, 100, be.Spent.InputTokens)
+}
+
+func TestCostTracker_UsageLog(t *testing.T) {
+	var log bytes.Buffer
+	tracker := llm.NewCostTracker(llm.WithUsageLog(&log))
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 100, 10, 0.25),
+		costEvent("fake", "fake-model", 0, 5, 0.05),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, tracker.Middleware())
+	require.NoError(t, drainCosted(llm.ContextWithConversation(context.Background(), "c1"), t, p))
+	tracker.Record("", usage.Record{IsEstimate: true})
+
+	entries, err := llm.ReadUsageLog(&log)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "estimates are not logged")
+	assert.True(t, entries[0].NewRequest)
+	assert.False(t, entries[1].NewRequest)
+	assert.Equal(t, "c1", entries[0].Conversation)
+	assert.Equal(t, "fake-model", entries[0].Record.Dims.Model)
+	assert.InDelta(t, 0.25, entries[0].Record.Cost.Total, 1e-9)
+	assert.False(t, entries[0].Time.IsZero())
+
+	_, err = llm.ReadUsageLog(strings.NewReader("{}\nnot json\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
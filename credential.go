@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RotatingAPIKey holds an API key that can be replaced while providers are
+// in use. Providers configured with WithRotatingAPIKey resolve the key on
+// every request, so Rotate takes effect from the next request on without
+// recreating the provider. Safe for concurrent use.
+type RotatingAPIKey struct {
+	key atomic.Pointer[string]
+}
+
+// NewRotatingAPIKey returns a RotatingAPIKey holding key.
+func NewRotatingAPIKey(key string) *RotatingAPIKey {
+	k := &RotatingAPIKey{}
+	k.Rotate(key)
+	return k
+}
+
+// Rotate replaces the key. Requests already sent keep the old key.
+func (k *RotatingAPIKey) Rotate(key string) {
+	k.key.Store(&key)
+}
+
+// Get returns the current key. It satisfies the WithAPIKeyFunc signature.
+func (k *RotatingAPIKey) Get(context.Context) (string, error) {
+	if p := k.key.Load(); p != nil {
+		return *p, nil
+	}
+	return "", nil
+}
+
+// WithRotatingAPIKey configures a provider to read its API key from k on
+// every request. Share one RotatingAPIKey across providers to rotate them
+// together.
+func WithRotatingAPIKey(k *RotatingAPIKey) Option {
+	return WithAPIKeyFunc(k.Get)
+}
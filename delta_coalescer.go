@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Default DeltaCoalescer limits.
+const (
+	DefaultCoalesceInterval = 25 * time.Millisecond
+	DefaultCoalesceMaxBytes = 512
+)
+
+// DeltaCoalescer is a Streamer that merges runs of small deltas into fewer,
+// larger ones. Some providers stream single characters or tokens; gateways
+// fanning many streams out to clients pay a per-event cost for each of them.
+//
+// Consecutive deltas of the same kind and block index are concatenated and
+// emitted when the oldest buffered delta is older than the interval, when the
+// batch reaches the byte limit, or when any other event arrives. Event order
+// is preserved, and added latency is bounded by the interval. A merged delta
+// keeps the metadata of its first fragment.
+type DeltaCoalescer struct {
+	s        Streamer
+	interval time.Duration
+	maxBytes int
+}
+
+// NewDeltaCoalescer returns a DeltaCoalescer for streams from s using
+// DefaultCoalesceInterval and DefaultCoalesceMaxBytes.
+func NewDeltaCoalescer(s Streamer) *DeltaCoalescer {
+	return &DeltaCoalescer{s: s, interval: DefaultCoalesceInterval, maxBytes: DefaultCoalesceMaxBytes}
+}
+
+// WithInterval sets how long a delta may be held back. Zero or less disables
+// time-based flushing, so batches end only at the byte limit or at the next
+// non-mergeable event.
+func (c *DeltaCoalescer) WithInterval(d time.Duration) *DeltaCoalescer {
+	c.interval = d
+	return c
+}
+
+// WithMaxBytes sets the batch size at which a merged delta is emitted
+// immediately. Zero or less disables the limit.
+func (c *DeltaCoalescer) WithMaxBytes(n int) *DeltaCoalescer {
+	c.maxBytes = n
+	return c
+}
+
+// CreateStream implements Streamer.
+func (c *DeltaCoalescer) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	stream, err := c.s.CreateStream(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return CoalesceDeltas(ctx, stream, c.interval, c.maxBytes), nil
+}
+
+// CoalesceDeltas applies DeltaCoalescer batching to an existing stream.
+func CoalesceDeltas(ctx context.Context, in Stream, interval time.Duration, maxBytes int) Stream {
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		b := &deltaBatch{ctx: ctx, out: out, maxBytes: maxBytes}
+		if !b.run(in, interval) {
+			// drain so the producer is not blocked
+			for range in {
+			}
+		}
+	}()
+	return out
+}
+
+// deltaBatch holds the per-stream state of CoalesceDeltas.
+type deltaBatch struct {
+	ctx      context.Context
+	out      chan<- Envelope
+	maxBytes int
+
+	pending *Envelope // first fragment of the batch, nil when empty
+	delta   *DeltaEvent
+	buf     strings.Builder
+}
+
+func (b *deltaBatch) run(in Stream, interval time.Duration) bool {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case env, ok := <-in:
+			if !ok {
+				return b.flush()
+			}
+			d, isDelta := env.Data.(*DeltaEvent)
+			if isDelta && b.pending != nil && b.mergeable(d) {
+				b.buf.WriteString(deltaPayload(d))
+			} else {
+				if !b.flush() {
+					return false
+				}
+				if !isDelta {
+					if !b.send(env) {
+						return false
+					}
+					continue
+				}
+				b.start(env, d)
+				if interval > 0 {
+					timer.Reset(interval)
+				}
+			}
+			if b.maxBytes > 0 && b.buf.Len() >= b.maxBytes {
+				timer.Stop()
+				if !b.flush() {
+					return false
+				}
+			}
+		case <-timer.C:
+			if !b.flush() {
+				return false
+			}
+		case <-b.ctx.Done():
+			return false
+		}
+	}
+}
+
+func (b *deltaBatch) start(env Envelope, d *DeltaEvent) {
+	b.pending = &env
+	b.delta = d
+	b.buf.Reset()
+	b.buf.WriteString(deltaPayload(d))
+}
+
+// mergeable reports whether d continues the pending delta. Tool fragments
+// merge while they belong to the same call; later fragments often omit the
+// call ID and name.
+func (b *deltaBatch) mergeable(d *DeltaEvent) bool {
+	p := b.delta
+	if d.Kind != p.Kind || !sameIndex(d.Index, p.Index) {
+		return false
+	}
+	if d.Kind == DeltaKindTool {
+		return (d.ToolID == "" || d.ToolID == p.ToolID) && (d.ToolName == "" || d.ToolName == p.ToolName)
+	}
+	return true
+}
+
+func (b *deltaBatch) flush() bool {
+	if b.pending == nil {
+		return true
+	}
+	merged := *b.delta
+	text := b.buf.String()
+	switch merged.Kind {
+	case DeltaKindText:
+		merged.Text = text
+	case DeltaKindThinking:
+		merged.Thinking = text
+	case DeltaKindTool:
+		merged.ToolArgs = text
+	}
+	env := *b.pending
+	env.Data = &merged
+	b.pending, b.delta = nil, nil
+	b.buf.Reset()
+	return b.send(env)
+}
+
+func (b *deltaBatch) send(env Envelope) bool {
+	select {
+	case b.out <- env:
+		return true
+	case <-b.ctx.Done():
+		return false
+	}
+}
+
+func deltaPayload(d *DeltaEvent) string {
+	switch d.Kind {
+	case DeltaKindText:
+		return d.Text
+	case DeltaKindThinking:
+		return d.Thinking
+	case DeltaKindTool:
+		return d.ToolArgs
+	}
+	return ""
+}
+
+func sameIndex(a, b *uint32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
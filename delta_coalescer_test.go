@@ -0,0 +1,104 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func collectEnvelopes(stream llm.Stream) []llm.Envelope {
+	var out []llm.Envelope
+	for env := range stream {
+		out = append(out, env)
+	}
+	return out
+}
+
+func TestCoalesceDeltas_MergesRunsAndKeepsOrder(t *testing.T) {
+	stream := llm.CoalesceDeltas(context.Background(), llmtest.SendEvents(
+		llmtest.ReasoningEvent("thin"),
+		llmtest.ReasoningEvent("king"),
+		llmtest.TextEvent("H"),
+		llmtest.TextEvent("e"),
+		llmtest.TextEvent("llo"),
+		llm.ToolDelta("call_1", "search", `{"q":`),
+		llm.ToolDelta("", "", `"go"}`),
+		llm.ToolDelta("call_2", "search", `{}`),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	), time.Hour, 0)
+
+	envs := collectEnvelopes(stream)
+	require.Len(t, envs, 5)
+	assert.Equal(t, "thinking", envs[0].Data.(*llm.DeltaEvent).Thinking)
+	assert.Equal(t, "Hello", envs[1].Data.(*llm.DeltaEvent).Text)
+	tool1 := envs[2].Data.(*llm.DeltaEvent)
+	assert.Equal(t, "call_1", tool1.ToolID)
+	assert.Equal(t, `{"q":"go"}`, tool1.ToolArgs)
+	assert.Equal(t, "call_2", envs[3].Data.(*llm.DeltaEvent).ToolID)
+	assert.Equal(t, llm.StreamEventCompleted, envs[4].Type)
+}
+
+func TestCoalesceDeltas_DoesNotMergeAcrossIndexes(t *testing.T) {
+	stream := llm.CoalesceDeltas(context.Background(), llmtest.SendEvents(
+		llm.TextDelta("a").WithIndex(0),
+		llm.TextDelta("b").WithIndex(1),
+	), time.Hour, 0)
+
+	envs := collectEnvelopes(stream)
+	require.Len(t, envs, 2)
+	assert.Equal(t, "a", envs[0].Data.(*llm.DeltaEvent).Text)
+	assert.Equal(t, "b", envs[1].Data.(*llm.DeltaEvent).Text)
+}
+
+func TestCoalesceDeltas_FlushesAtMaxBytes(t *testing.T) {
+	stream := llm.CoalesceDeltas(context.Background(), llmtest.SendEvents(
+		llmtest.TextEvent("ab"),
+		llmtest.TextEvent("cd"),
+		llmtest.TextEvent("e"),
+	), time.Hour, 4)
+
+	envs := collectEnvelopes(stream)
+	require.Len(t, envs, 2)
+	assert.Equal(t, "abcd", envs[0].Data.(*llm.DeltaEvent).Text)
+	assert.Equal(t, "e", envs[1].Data.(*llm.DeltaEvent).Text)
+}
+
+func TestCoalesceDeltas_FlushesAfterInterval(t *testing.T) {
+	in := make(chan llm.Envelope)
+	stream := llm.CoalesceDeltas(context.Background(), in, 10*time.Millisecond, 0)
+
+	in <- llm.Envelope{Type: llm.StreamEventDelta, Data: llm.TextDelta("a")}
+	in <- llm.Envelope{Type: llm.StreamEventDelta, Data: llm.TextDelta("b")}
+
+	select {
+	case env := <-stream:
+		assert.Equal(t, "ab", env.Data.(*llm.DeltaEvent).Text)
+	case <-time.After(time.Second):
+		t.Fatal("batch was not flushed after the interval")
+	}
+	close(in)
+	_, ok := <-stream
+	assert.False(t, ok)
+}
+
+func TestDeltaCoalescer_CreateStream(t *testing.T) {
+	inner := llm.StreamFunc(func(context.Context, llm.Buildable) (llm.Stream, error) {
+		return llmtest.SendEvents(
+			llmtest.TextEvent("Hel"),
+			llmtest.TextEvent("lo"),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		), nil
+	})
+
+	stream, err := llm.NewDeltaCoalescer(inner).WithInterval(time.Hour).CreateStream(context.Background(), llm.Request{})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+	assert.Equal(t, "Hello", res.Text())
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrDryRun is the error a provider fails with when it built a request for
+// DryRun instead of sending it.
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// DryRun builds the provider request that s would send for src and returns
+// it without sending it. The returned event carries the exact wire body,
+// URL and headers (credentials redacted), so callers can check prompt
+// caching markers, tool schemas and message mapping.
+//
+// Providers built on the shared HTTP client stack and Bedrock support dry
+// runs. For other providers DryRun fails with an error wrapping ErrDryRun.
+// The default HTTP client, clients set with WithHTTPClient and the retry
+// transport all refuse to send dry-run requests, so those providers make no
+// network call either; a provider that builds its own *http.Client around
+// them is not covered. Authentication headers are still resolved, so a
+// missing API key fails the dry run.
+func DryRun(ctx context.Context, s Streamer, src Buildable) (*RequestEvent, error) {
+	sink := &dryRunSink{}
+	ctx = context.WithValue(ctx, dryRunKey{}, sink)
+
+	stream, err := s.CreateStream(ctx, src)
+	if err == nil {
+		for env := range stream {
+			if ev, ok := env.Data.(*ErrorEvent); ok && err == nil {
+				err = ev.Error
+			}
+		}
+	}
+	if req := sink.get(); req != nil {
+		return req, nil
+	}
+	if err != nil && !errors.Is(err, ErrDryRun) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: provider does not support dry runs", ErrDryRun)
+}
+
+// CaptureDryRun records req for DryRun when ctx belongs to a dry run. It
+// reports whether it did; a provider must then not send the request and
+// should fail with ErrDryRun. Providers call it right before sending.
+func CaptureDryRun(ctx context.Context, req *RequestEvent) bool {
+	sink := dryRunFrom(ctx)
+	if sink == nil {
+		return false
+	}
+	sink.set(req)
+	return true
+}
+
+// IsDryRun reports whether ctx belongs to a DryRun. Providers that cannot
+// build the wire request at the point they call CaptureDryRun use it to take
+// a separate capture path.
+func IsDryRun(ctx context.Context) bool {
+	return dryRunFrom(ctx) != nil
+}
+
+type dryRunKey struct{}
+
+func dryRunFrom(ctx context.Context) *dryRunSink {
+	sink, _ := ctx.Value(dryRunKey{}).(*dryRunSink)
+	return sink
+}
+
+type dryRunSink struct {
+	mu  sync.Mutex
+	req *RequestEvent
+}
+
+// set keeps the first request, e.g. the primary of a fallback chain.
+func (s *dryRunSink) set(req *RequestEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.req == nil {
+		s.req = req
+	}
+}
+
+func (s *dryRunSink) get() *RequestEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.req
+}
+
+// dryRunGuard refuses to send requests that belong to a dry run.
+type dryRunGuard struct {
+	next http.RoundTripper
+}
+
+func (t dryRunGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if IsDryRun(req.Context()) {
+		return nil, ErrDryRun
+	}
+	return t.next.RoundTrip(req)
+}
+
+// guardDryRun returns a shallow copy of c whose transport refuses dry-run
+// requests. A nil c is returned as is.
+func guardDryRun(c *http.Client) *http.Client {
+	if c == nil {
+		return nil
+	}
+	if _, ok := c.Transport.(dryRunGuard); ok {
+		return c
+	}
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	out := *c
+	out.Transport = dryRunGuard{next: next}
+	return &out
+}
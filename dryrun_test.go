@@ -0,0 +1,141 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/chattemplate"
+	"github.com/codewandler/llm/provider/anthropic"
+	"github.com/codewandler/llm/provider/completion"
+	"github.com/codewandler/llm/provider/fake"
+	"github.com/codewandler/llm/provider/openai"
+	"github.com/codewandler/llm/tool"
+)
+
+// unreachable fails the test if a dry run reaches the network.
+func unreachable(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDryRun_OpenAI(t *testing.T) {
+	srv := unreachable(t)
+	p := openai.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("sk-secret"))
+
+	ev, err := llm.DryRun(t.Context(), p, llm.Request{
+		Model:    "gpt-4o",
+		Messages: llm.Messages{llm.User("weather in Paris?")},
+		Tools: []tool.Definition{{
+			Name:       "get_weather",
+			Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, llm.ApiTypeOpenAIChatCompletion, ev.ResolvedApiType)
+	assert.Equal(t, http.MethodPost, ev.ProviderRequest.Method)
+	assert.Equal(t, srv.URL+"/v1/chat/completions", ev.ProviderRequest.URL)
+	assert.Equal(t, "[REDACTED]", ev.ProviderRequest.Headers["Authorization"])
+	assert.Equal(t, "gpt-4o", ev.OriginalRequest.Model)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(ev.ProviderRequest.Body, &body))
+	assert.Equal(t, "gpt-4o", body["model"])
+	require.Len(t, body["tools"], 1)
+}
+
+func TestDryRun_AnthropicCacheMarkers(t *testing.T) {
+	srv := unreachable(t)
+	p := anthropic.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("key"), anthropic.WithAnthropicAutoSystemCacheControl("1h"))
+
+	ev, err := llm.DryRun(t.Context(), p, llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: llm.Messages{llm.System("be brief"), llm.User("hi")},
+	})
+	require.NoError(t, err)
+
+	var body struct {
+		System []struct {
+			CacheControl map[string]any `json:"cache_control"`
+		} `json:"system"`
+	}
+	require.NoError(t, json.Unmarshal(ev.ProviderRequest.Body, &body))
+	require.NotEmpty(t, body.System)
+	assert.Equal(t, "ephemeral", body.System[0].CacheControl["type"])
+}
+
+func TestDryRun_Unsupported(t *testing.T) {
+	t.Run("no wire request", func(t *testing.T) {
+		_, err := llm.DryRun(t.Context(), fake.NewProvider(), llm.Request{Model: fake.Model1ID, Messages: llm.Messages{llm.User("hi")}})
+		assert.ErrorIs(t, err, llm.ErrDryRun)
+	})
+
+	t.Run("transport refuses to send", func(t *testing.T) {
+		srv := unreachable(t)
+		p := completion.New(chattemplate.ChatML, llm.WithBaseURL(srv.URL))
+		_, err := llm.DryRun(t.Context(), p, llm.Request{Model: "local", Messages: llm.Messages{llm.User("hi")}})
+		assert.ErrorIs(t, err, llm.ErrDryRun)
+	})
+}
+
+func TestDryRun_PropagatesBuildErrors(t *testing.T) {
+	p := openai.New(llm.WithBaseURL(unreachable(t).URL), llm.WithAPIKey("key"))
+	_, err := llm.DryRun(t.Context(), p, llm.Request{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, llm.ErrDryRun)
+	assert.ErrorIs(t, err, llm.ErrBuildRequest)
+}
+
+type countingTransport struct{ calls int }
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, http.ErrHandlerTimeout
+}
+
+func TestDryRun_CustomHTTPClient(t *testing.T) {
+	t.Run("WithHTTPClient", func(t *testing.T) {
+		tr := &countingTransport{}
+		p := completion.New(chattemplate.ChatML, llm.WithBaseURL("http://localhost:1"), llm.WithHTTPClient(&http.Client{Transport: tr}))
+		_, err := llm.DryRun(t.Context(), p, llm.Request{Model: "local", Messages: llm.Messages{llm.User("hi")}})
+		assert.ErrorIs(t, err, llm.ErrDryRun)
+		assert.Zero(t, tr.calls)
+	})
+
+	t.Run("SetDefaultHttpClient", func(t *testing.T) {
+		tr := &countingTransport{}
+		llm.SetDefaultHttpClient(&http.Client{Transport: tr})
+		t.Cleanup(func() { llm.SetDefaultHttpClient(nil) })
+		p := completion.New(chattemplate.ChatML, llm.WithBaseURL("http://localhost:1"))
+		_, err := llm.DryRun(t.Context(), p, llm.Request{Model: "local", Messages: llm.Messages{llm.User("hi")}})
+		assert.ErrorIs(t, err, llm.ErrDryRun)
+		assert.Zero(t, tr.calls)
+	})
+
+	t.Run("retry transport", func(t *testing.T) {
+		tr := &countingTransport{}
+		client := &http.Client{Transport: llm.NewRetryTransport(tr, llm.RetryOptions{})}
+		p := llm.StreamFunc(func(ctx context.Context, _ llm.Buildable) (llm.Stream, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:1", nil)
+			if err != nil {
+				return nil, err
+			}
+			_, err = client.Do(req)
+			return nil, err
+		})
+		_, err := llm.DryRun(t.Context(), p, llm.Request{})
+		assert.ErrorIs(t, err, llm.ErrDryRun)
+		assert.Zero(t, tr.calls)
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Provider name constants used in ProviderError.Provider.
@@ -20,6 +22,8 @@ const (
 	ProviderNameDockerMR   = "dockermr"
 	ProviderNameOpenAI     = "openai"
 	ProviderNameOpenRouter = "openrouter"
+	ProviderNameGroq       = "groq"
+	ProviderNameVertex     = "vertex"
 )
 
 // Sentinel errors for use with errors.Is. Each ProviderError wraps one of
@@ -65,6 +69,35 @@ var (
 	// failover targets have been exhausted.
 	ErrNoProviders = errors.New("no providers configured")
 
+	// ErrFirstTokenTimeout is returned when a request sets
+	// FirstTokenDeadline and the provider produced no output in time.
+	ErrFirstTokenTimeout = errors.New("first token deadline exceeded")
+
+	// ErrRateLimited classifies API errors caused by rate limits or quota
+	// throttling. ProviderError.RetryAfter holds the requested delay when
+	// the provider sent one.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrAuth classifies API errors caused by missing, invalid or
+	// insufficient credentials.
+	ErrAuth = errors.New("authentication failed")
+
+	// ErrContextLengthExceeded classifies API errors for requests that do
+	// not fit the model's context window.
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+
+	// ErrContentFiltered classifies API errors for prompts rejected by a
+	// provider safety system. ProviderError.Safety describes the block.
+	ErrContentFiltered = errors.New("content filtered")
+
+	// ErrModelNotFound classifies API errors for models the provider does
+	// not serve or the caller cannot access.
+	ErrModelNotFound = errors.New("model not found")
+
+	// ErrUnsupportedFeature is returned when a request uses a feature, such
+	// as tools or structured output, that the model does not support.
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+
 	// ErrUnknown is used to wrap any error that is not already a ProviderError.
 	// Callers can test for it with errors.Is(err, llm.ErrUnknown).
 	ErrUnknown = errors.New("unknown error")
@@ -72,7 +105,9 @@ var (
 
 // ProviderError is a structured error emitted by any provider. It wraps a
 // sentinel so errors.Is works, carries the provider name for identification,
-// and optionally holds an HTTP status code and body for API errors.
+// and optionally holds an HTTP status code and body for API errors. API
+// errors are additionally classified by Kind, so callers can branch on
+// errors.Is(err, ErrRateLimited) and friends.
 type ProviderError struct {
 	// Sentinel is one of the Err* vars above. errors.Is matches against it.
 	Sentinel error `json:"-"`
@@ -95,6 +130,23 @@ type ProviderError struct {
 
 	// Body is the raw HTTP response body. Only set for ErrAPIError.
 	ResponseBody string `json:"response_body,omitempty"`
+
+	// Kind classifies an ErrAPIError: one of ErrRateLimited, ErrAuth,
+	// ErrContextLengthExceeded, ErrContentFiltered or ErrModelNotFound, or
+	// nil when the failure fits none of them. errors.Is matches it too.
+	Kind error `json:"-"`
+
+	// Code is the provider's error code from the response body, such as
+	// "rate_limit_exceeded" or "authentication_error".
+	Code string `json:"code,omitempty"`
+
+	// RetryAfter is the delay requested by the provider's Retry-After
+	// header. Zero when the provider sent none.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+
+	// Safety is set when the request was rejected by a provider safety
+	// system, such as a prompt blocked by a content filter.
+	Safety *SafetyBlock `json:"safety,omitempty"`
 }
 
 func (e *ProviderError) WithRequestBody(body string) *ProviderError {
@@ -102,6 +154,14 @@ func (e *ProviderError) WithRequestBody(body string) *ProviderError {
 	return e
 }
 
+// WithRetryAfter sets RetryAfter from the Retry-After headers in h.
+func (e *ProviderError) WithRetryAfter(h http.Header) *ProviderError {
+	if d, ok := ParseRetryAfter(h, time.Now()); ok {
+		e.RetryAfter = d
+	}
+	return e
+}
+
 // Error returns a human-readable error string in the form:
 // "<provider>: <sentinel>" or "<provider>: <sentinel>: <message>" (with optional ": <cause>" suffix).
 func (e *ProviderError) Error() string {
@@ -128,31 +188,42 @@ func (e *ProviderError) Unwrap() error {
 }
 
 // Is reports whether this error matches target. It matches if target is the
-// same sentinel, enabling errors.Is(err, ErrAPIError) etc.
+// same sentinel or Kind, enabling errors.Is(err, ErrAPIError) and
+// errors.Is(err, ErrRateLimited) on the same error.
 func (e *ProviderError) Is(target error) bool {
-	return target == e.Sentinel
+	return target == e.Sentinel || (e.Kind != nil && target == e.Kind)
 }
 
 // MarshalJSON serialises ProviderError to JSON. Sentinel and Cause are
 // rendered as strings so the full error is machine-readable.
 func (e *ProviderError) MarshalJSON() ([]byte, error) {
 	type wire struct {
-		Sentinel   string `json:"sentinel"`
-		Provider   string `json:"provider"`
-		Message    string `json:"message"`
-		Cause      string `json:"cause,omitempty"`
-		StatusCode int    `json:"status_code,omitempty"`
-		Body       string `json:"body,omitempty"`
+		Sentinel   string        `json:"sentinel"`
+		Kind       string        `json:"kind,omitempty"`
+		Provider   string        `json:"provider"`
+		Message    string        `json:"message"`
+		Cause      string        `json:"cause,omitempty"`
+		StatusCode int           `json:"status_code,omitempty"`
+		Code       string        `json:"code,omitempty"`
+		RetryAfter time.Duration `json:"retry_after,omitempty"`
+		Body       string        `json:"body,omitempty"`
+		Safety     *SafetyBlock  `json:"safety,omitempty"`
 	}
 	w := wire{
 		Provider:   e.Provider,
 		Message:    e.Message,
 		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		RetryAfter: e.RetryAfter,
 		Body:       e.ResponseBody,
+		Safety:     e.Safety,
 	}
 	if e.Sentinel != nil {
 		w.Sentinel = e.Sentinel.Error()
 	}
+	if e.Kind != nil {
+		w.Kind = e.Kind.Error()
+	}
 	if e.Cause != nil {
 		w.Cause = e.Cause.Error()
 	}
@@ -171,6 +242,16 @@ func NewErrContextCancelled(provider string, cause error) *ProviderError {
 	}
 }
 
+// NewErrFirstTokenTimeout returns an error for a stream that produced no
+// delta within deadline.
+func NewErrFirstTokenTimeout(provider string, deadline time.Duration) *ProviderError {
+	return &ProviderError{
+		Sentinel: ErrFirstTokenTimeout,
+		Provider: provider,
+		Message:  fmt.Sprintf("no output within %s", deadline),
+	}
+}
+
 // NewErrRequestFailed wraps an HTTP transport-level failure.
 func NewErrRequestFailed(provider string, cause error) *ProviderError {
 	return &ProviderError{
@@ -183,6 +264,7 @@ func NewErrRequestFailed(provider string, cause error) *ProviderError {
 
 // NewErrAPIErrorWithRequest wraps a non-2xx HTTP response from a provider API.
 func NewErrAPIErrorWithRequest(provider string, requestBody string, statusCode int, responseBody string) *ProviderError {
+	code := apiErrorCode(responseBody)
 	return &ProviderError{
 		Sentinel:     ErrAPIError,
 		Provider:     provider,
@@ -190,18 +272,91 @@ func NewErrAPIErrorWithRequest(provider string, requestBody string, statusCode i
 		StatusCode:   statusCode,
 		RequestBody:  requestBody,
 		ResponseBody: responseBody,
+		Kind:         classifyAPIError(statusCode, code, responseBody),
+		Code:         code,
 	}
 }
 
 // NewErrAPIError wraps a non-2xx HTTP response from a provider API.
 func NewErrAPIError(provider string, statusCode int, responseBody string) *ProviderError {
+	code := apiErrorCode(responseBody)
 	return &ProviderError{
 		Sentinel:     ErrAPIError,
 		Provider:     provider,
 		Message:      fmt.Sprintf("HTTP %d\nRESPONSE: %s", statusCode, responseBody),
 		StatusCode:   statusCode,
 		ResponseBody: responseBody,
+		Kind:         classifyAPIError(statusCode, code, responseBody),
+		Code:         code,
+	}
+}
+
+// apiErrorCode extracts the provider error code from an error response
+// body. It understands the OpenAI ({"error":{"code","type"}}), Anthropic
+// ({"error":{"type"}}), Google ({"error":{"status"}}) and flat
+// ({"code"} / {"__type"}) shapes.
+func apiErrorCode(body string) string {
+	var wire struct {
+		Code    any             `json:"code"`
+		Type    string          `json:"type"`
+		AWSType string          `json:"__type"`
+		Error   json.RawMessage `json:"error"`
 	}
+	if json.Unmarshal([]byte(body), &wire) != nil {
+		return ""
+	}
+	var inner struct {
+		Code   any    `json:"code"`
+		Status string `json:"status"`
+		Type   string `json:"type"`
+	}
+	_ = json.Unmarshal(wire.Error, &inner)
+
+	for _, c := range []any{inner.Code, inner.Status, inner.Type, wire.Code, wire.AWSType} {
+		if s, ok := c.(string); ok && s != "" {
+			// AWS qualifies exception names: "aws#ThrottlingException".
+			if i := strings.LastIndexByte(s, '#'); i >= 0 {
+				s = s[i+1:]
+			}
+			return s
+		}
+	}
+	if wire.Type != "error" {
+		return wire.Type
+	}
+	return ""
+}
+
+// classifyAPIError maps an API error response to one of the Kind sentinels,
+// or nil.
+func classifyAPIError(status int, code, body string) error {
+	c := strings.ToLower(code)
+	b := strings.ToLower(body)
+	containsAny := func(s string, needles ...string) bool {
+		for _, n := range needles {
+			if strings.Contains(s, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests || containsAny(c, "rate_limit", "throttl", "resource_exhausted"):
+		return ErrRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		containsAny(c, "authentication", "permission", "invalid_api_key", "unauthorized", "accessdenied", "unauthenticated"):
+		return ErrAuth
+	case containsAny(c, "content_filter", "content_policy"):
+		return ErrContentFiltered
+	case containsAny(c, "context_length") ||
+		containsAny(b, "context length", "context window", "maximum context", "prompt is too long", "input is too long"):
+		return ErrContextLengthExceeded
+	case containsAny(c, "model_not_found") ||
+		(status == http.StatusNotFound && strings.Contains(b, "model")):
+		return ErrModelNotFound
+	}
+	return nil
 }
 
 // NewErrStreamRead wraps an I/O or scanner error that occurred while reading
@@ -266,6 +421,16 @@ func NewErrUnknownModel(provider string, modelID string) *ProviderError {
 	}
 }
 
+// NewErrUnsupportedFeature returns an error for a request that uses feature
+// on a model that does not support it.
+func NewErrUnsupportedFeature(provider, modelID, feature string) *ProviderError {
+	return &ProviderError{
+		Sentinel: ErrUnsupportedFeature,
+		Provider: provider,
+		Message:  fmt.Sprintf("model %q does not support %s", modelID, feature),
+	}
+}
+
 // NewErrAllProvidersFailed returns an error when every failover target has been
 // tried and all returned retriable errors. The original per-provider errors are
 // preserved as the Cause via errors.Join so callers can inspect them with
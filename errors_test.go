@@ -2,8 +2,12 @@ package llm_test
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,3 +91,74 @@ func TestProviderError_APIErrorFields(t *testing.T) {
 	assert.True(t, strings.Contains(pe.ResponseBody, "service unavailable"))
 	assert.Equal(t, llm.ProviderNameOpenAI, pe.Provider)
 }
+
+func TestNewErrAPIError_Classification(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+		code   string
+	}{
+		{"openai rate limit", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, llm.ErrRateLimited, "rate_limit_exceeded"},
+		{"anthropic overloaded is unclassified", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, nil, "overloaded_error"},
+		{"anthropic auth", 401, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, llm.ErrAuth, "authentication_error"},
+		{"openai context length", 400, `{"error":{"message":"This model's maximum context length is 128000 tokens.","type":"invalid_request_error","code":"context_length_exceeded"}}`, llm.ErrContextLengthExceeded, "context_length_exceeded"},
+		{"anthropic prompt too long", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`, llm.ErrContextLengthExceeded, "invalid_request_error"},
+		{"azure content filter", 400, `{"error":{"code":"content_filter","message":"The response was filtered"}}`, llm.ErrContentFiltered, "content_filter"},
+		{"openai model not found", 404, `{"error":{"message":"The model 'gpt-9' does not exist","type":"invalid_request_error","code":"model_not_found"}}`, llm.ErrModelNotFound, "model_not_found"},
+		{"ollama model not found", 404, `{"error":"model 'llama9' not found"}`, llm.ErrModelNotFound, ""},
+		{"google quota", 429, `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`, llm.ErrRateLimited, "RESOURCE_EXHAUSTED"},
+		{"bedrock throttling", 400, `{"__type":"ThrottlingException","message":"Too many requests"}`, llm.ErrRateLimited, "ThrottlingException"},
+		{"plain server error", 500, `internal error`, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := llm.NewErrAPIError("test", tt.status, tt.body)
+			assert.ErrorIs(t, err, llm.ErrAPIError)
+			assert.Equal(t, tt.code, err.Code)
+			assert.Equal(t, tt.kind, err.Kind)
+			if tt.kind != nil {
+				assert.ErrorIs(t, err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestProviderError_WithRetryAfter(t *testing.T) {
+	err := llm.NewErrAPIError("test", 429, "").WithRetryAfter(http.Header{"Retry-After": {"7"}})
+	assert.Equal(t, 7*time.Second, err.RetryAfter)
+
+	var pe *llm.ProviderError
+	require.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &pe))
+	assert.ErrorIs(t, pe, llm.ErrRateLimited)
+	assert.Equal(t, 7*time.Second, pe.RetryAfter)
+}
+
+func TestIsRetriableHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{200, false},
+		{400, false},
+		{401, false},
+		{402, true},
+		{403, false},
+		{404, false},
+		{408, false},
+		{413, false},
+		{422, false},
+		{429, true},
+		{500, false},
+		{502, false},
+		{503, true},
+		{504, false},
+		{529, false},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.want, llm.IsRetriableHTTPStatus(tt.status))
+		})
+	}
+}
@@ -22,10 +22,14 @@ const (
 	StreamEventTokenEstimate    EventType = "token_estimate"
 	StreamEventDelta            EventType = "delta"
 	StreamEventToolCall         EventType = "tool_call"
+	StreamEventToolProgress     EventType = "tool_progress"
+	StreamEventToolResult       EventType = "tool_result"
+	StreamEventAnnotation       EventType = "annotation"
 	StreamEventContentPart      EventType = "content_part"
 	StreamEventCompleted        EventType = "completed"
 	StreamEventError            EventType = "error"
 	StreamEventDebug            EventType = "debug"
+	StreamEventWarning          EventType = "warning"
 	StreamEventRequest          EventType = "request"
 )
 
@@ -55,6 +59,13 @@ type (
 
 		Close()
 	}
+
+	// WarningPublisher is an optional interface of Publishers with a
+	// dedicated method for warnings. PublishWarning falls back to Publish
+	// for Publishers that do not implement it.
+	WarningPublisher interface {
+		Warning(w WarningEvent)
+	}
 )
 
 type EventMeta struct {
@@ -73,6 +84,20 @@ type Envelope struct {
 	Data any       `json:"data,omitempty"`
 }
 
+// WarningCode classifies a WarningEvent. Upstream codes that have no
+// dedicated constant are passed through verbatim.
+type WarningCode string
+
+const (
+	WarningDeprecated       WarningCode = "deprecated"
+	WarningParameterIgnored WarningCode = "parameter_ignored"
+	WarningFallbackApplied  WarningCode = "fallback_applied"
+	WarningUpstream         WarningCode = "upstream"
+	// WarningContextTrimmed reports that a ContextManager shortened the
+	// history to fit the context window.
+	WarningContextTrimmed WarningCode = "context_trimmed"
+)
+
 type (
 	StreamCreatedEvent struct{}
 
@@ -124,6 +149,72 @@ type (
 		ToolCall tool.Call `json:"tool_call"`
 	}
 
+	// ToolProgressEvent reports the progress of a tool the provider runs
+	// itself, such as the OpenAI Responses built-ins web_search, file_search
+	// and code_interpreter. No local handler is involved; the provider
+	// continues the response once the tool is done.
+	ToolProgressEvent struct {
+		// ToolID is the upstream item ID of the tool call.
+		ToolID string `json:"tool_id"`
+
+		// ToolType names the built-in tool: "web_search", "file_search",
+		// "code_interpreter", "image_generation" or "mcp".
+		ToolType string `json:"tool_type"`
+
+		// Status is the upstream stage, e.g. "in_progress", "searching",
+		// "interpreting" or "completed". Streamed input is reported with
+		// status "code" (code_interpreter) or "arguments" (mcp) and the
+		// fragment in Delta.
+		Status string  `json:"status"`
+		Delta  string  `json:"delta,omitempty"`
+		Index  *uint32 `json:"index,omitempty"`
+	}
+
+	// ToolResultEvent carries the final state of a provider-run tool call
+	// (see ToolProgressEvent). The typed fields are filled where the tool
+	// reports them; Item holds the complete upstream output item.
+	ToolResultEvent struct {
+		ToolID   string  `json:"tool_id"`
+		ToolType string  `json:"tool_type"`
+		Status   string  `json:"status,omitempty"`
+		Index    *uint32 `json:"index,omitempty"`
+
+		// Queries lists the search queries of web_search and file_search.
+		Queries []string `json:"queries,omitempty"`
+		// Results lists file_search results.
+		Results []map[string]any `json:"results,omitempty"`
+		// Code is the code run by code_interpreter.
+		Code string `json:"code,omitempty"`
+		// Output is the code_interpreter log output or the mcp tool output.
+		Output string `json:"output,omitempty"`
+
+		Item json.RawMessage `json:"item,omitempty"`
+	}
+
+	// AnnotationEvent attaches a citation to the output text, such as a web
+	// page cited after web_search or a file cited after file_search.
+	AnnotationEvent struct {
+		// AnnotationType is the upstream type, e.g. "url_citation",
+		// "file_citation", "container_file_citation" or "file_path".
+		AnnotationType string `json:"annotation_type"`
+
+		URL         string `json:"url,omitempty"`
+		Title       string `json:"title,omitempty"`
+		FileID      string `json:"file_id,omitempty"`
+		Filename    string `json:"filename,omitempty"`
+		ContainerID string `json:"container_id,omitempty"`
+
+		// StartIndex and EndIndex delimit the cited span of the output text
+		// for URL citations; file citations report their position as
+		// TextIndex.
+		StartIndex int `json:"start_index,omitempty"`
+		EndIndex   int `json:"end_index,omitempty"`
+		TextIndex  int `json:"text_index,omitempty"`
+
+		// Index is the output item the annotated text belongs to.
+		Index *uint32 `json:"index,omitempty"`
+	}
+
 	UsageUpdatedEvent struct {
 		Record usage.Record `json:"record"`
 	}
@@ -139,13 +230,59 @@ type (
 	}
 
 	CompletedEvent struct {
+		// StopReason is the normalized reason generation ended.
 		StopReason StopReason `json:"stop_reason"`
+
+		// FinishReason is the provider's raw value behind StopReason, such
+		// as "length", "max_output_tokens" or "guardrail_intervened". Empty
+		// if the provider reported none.
+		FinishReason string `json:"finish_reason,omitempty"`
+
+		// Safety describes the block when StopReason is
+		// StopReasonContentFilter. It may be nil if the provider gave no
+		// details.
+		Safety *SafetyBlock `json:"safety,omitempty"`
+
+		// Response identifies the upstream response, e.g. for reconciling
+		// billing. Nil if the provider reported nothing.
+		Response *ResponseMeta `json:"response,omitempty"`
+
+		// Timing records request start, first byte, first delta and
+		// completion times. Nil if the provider does not track them.
+		Timing *Timing `json:"timing,omitempty"`
+	}
+
+	// ResponseMeta identifies the response that served a request.
+	ResponseMeta struct {
+		// ID is the response or generation ID assigned by the API, e.g.
+		// OpenRouter's "gen-..." ID accepted by its /generation endpoint.
+		ID string `json:"id,omitempty"`
+
+		// Provider is the upstream provider that served the request. For
+		// routing providers such as OpenRouter it is the actual backend
+		// (e.g. "Anthropic", "Together"); otherwise the provider name.
+		Provider string `json:"provider,omitempty"`
+
+		// Model is the model reported in the response.
+		Model string `json:"model,omitempty"`
 	}
 
 	ErrorEvent struct {
 		Error error `json:"error"`
 	}
 
+	// WarningEvent carries a non-fatal notice from the provider or upstream
+	// API, such as a deprecated model or an ignored parameter. The stream
+	// continues normally after a warning.
+	WarningEvent struct {
+		Code     WarningCode `json:"code"`
+		Message  string      `json:"message"`
+		Provider string      `json:"provider,omitempty"`
+
+		// Param names the request parameter the warning refers to, if any.
+		Param string `json:"param,omitempty"`
+	}
+
 	// ContentPartEvent is emitted once per content block when the provider signals
 	// block completion (content_block_stop). Index is the position of this block in
 	// the model's original output array — required to preserve the exact interleaving
@@ -184,9 +321,13 @@ func (e ProviderFailoverEvent) Type() EventType { return StreamEventProviderFail
 func (e StreamCreatedEvent) Type() EventType    { return StreamEventCreated }
 func (e StreamClosedEvent) Type() EventType     { return StreamEventClosed }
 func (e ToolCallEvent) Type() EventType         { return StreamEventToolCall }
+func (e ToolProgressEvent) Type() EventType     { return StreamEventToolProgress }
+func (e ToolResultEvent) Type() EventType       { return StreamEventToolResult }
+func (e AnnotationEvent) Type() EventType       { return StreamEventAnnotation }
 func (e StreamStartedEvent) Type() EventType    { return StreamEventStarted }
 func (e CompletedEvent) Type() EventType        { return StreamEventCompleted }
 func (e UsageUpdatedEvent) Type() EventType     { return StreamEventUsageUpdated }
 func (e TokenEstimateEvent) Type() EventType    { return StreamEventTokenEstimate }
 func (e ErrorEvent) Type() EventType            { return StreamEventError }
+func (e WarningEvent) Type() EventType          { return StreamEventWarning }
 func (e ContentPartEvent) Type() EventType      { return StreamEventContentPart }
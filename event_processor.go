@@ -19,6 +19,8 @@ type Result interface {
 	UsageRecords() []usage.Record   // provider-reported, in arrival order
 	TokenEstimates() []usage.Record // pre-request estimates, in order
 	Drift() *usage.Drift            // nil if no estimate received
+	Warnings() []WarningEvent       // non-fatal provider warnings, in order
+	Safety() *SafetyBlock           // nil unless a safety system blocked the request
 }
 
 type result struct {
@@ -28,6 +30,11 @@ type result struct {
 	stopReason            StopReason
 	usageRecords          []usage.Record
 	estimateRecs          []usage.Record
+	warnings              []WarningEvent
+	safety                *SafetyBlock
+	response              *ResponseMeta
+	timing                *Timing
+	finishReason          string
 	toolCalls             []tool.Call
 	toolResults           []tool.Result
 	errors                []error
@@ -97,8 +104,14 @@ func (r *result) ToolMessage() (msg.Message, bool) {
 	if len(r.toolResults) == 0 {
 		return msg.Message{}, false
 	}
-	results := make(msg.ToolResults, 0)
-	for _, tr := range r.toolResults {
+	return toolResultsMessage(r.toolResults), true
+}
+
+// toolResultsMessage converts dispatched tool results into a tool message,
+// JSON-encoding each output.
+func toolResultsMessage(toolResults []tool.Result) msg.Message {
+	results := make(msg.ToolResults, 0, len(toolResults))
+	for _, tr := range toolResults {
 		data, _ := json.Marshal(tr.ToolOutput())
 		results = append(results, msg.ToolResult{
 			ToolCallID: tr.ToolCallID(),
@@ -106,7 +119,7 @@ func (r *result) ToolMessage() (msg.Message, bool) {
 			ToolOutput: string(data),
 		})
 	}
-	return msg.Tool().Results(results).Build(), true
+	return msg.Tool().Results(results).Build()
 }
 
 func (r *result) ToolResults() []tool.Result { return r.toolResults }
@@ -124,6 +137,24 @@ func (r *result) TokenEstimates() []usage.Record {
 	return r.estimateRecs
 }
 
+func (r *result) Warnings() []WarningEvent {
+	return r.warnings
+}
+
+// Safety returns the SafetyBlock from the completed event or from a
+// ProviderError, if any.
+func (r *result) Safety() *SafetyBlock {
+	if r.safety != nil {
+		return r.safety
+	}
+	for _, err := range r.errors {
+		if sb := SafetyBlockOf(err); sb != nil {
+			return sb
+		}
+	}
+	return nil
+}
+
 func (r *result) Drift() *usage.Drift {
 	if len(r.estimateRecs) == 0 || len(r.usageRecords) == 0 {
 		return nil
@@ -206,6 +237,33 @@ func (r *result) applyToolCall(tc tool.Call) {
 	r.toolCalls = append(r.toolCalls, tc)
 }
 
+// apply folds one stream event into the result.
+func (r *result) apply(ev any) {
+	switch actual := ev.(type) {
+	case *DeltaEvent:
+		r.applyDelta(actual)
+	case *ToolCallEvent:
+		r.applyToolCall(actual.ToolCall)
+	case *CompletedEvent:
+		r.stopReason = actual.StopReason
+		r.safety = actual.Safety
+		r.response = actual.Response
+		r.timing = actual.Timing
+		r.finishReason = actual.FinishReason
+	case *UsageUpdatedEvent:
+		r.applyUsage(actual.Record)
+	case *TokenEstimateEvent:
+		r.applyEstimate(actual.Estimate)
+	case *ErrorEvent:
+		r.addError(actual.Error)
+		r.stopReason = StopReasonError
+	case *WarningEvent:
+		r.warnings = append(r.warnings, *actual)
+	case *ContentPartEvent:
+		r.applyContentPart(actual)
+	}
+}
+
 var _ Result = (*result)(nil)
 
 func (r *result) Next() msg.Messages {
@@ -288,6 +346,12 @@ func (r *StreamProcessor) OnReasoningDelta(fn func(delta string)) *StreamProcess
 	})
 }
 
+// OnWarning registers a callback that is called for each non-fatal provider
+// warning.
+func (r *StreamProcessor) OnWarning(fn TypedEventHandler[*WarningEvent]) *StreamProcessor {
+	return r.OnEvent(fn)
+}
+
 // OnToolDelta registers a callback that is called for each partial tool-call
 // argument fragment (DeltaKindTool deltas).
 func (r *StreamProcessor) OnToolDelta(fn func(d ToolDeltaPart)) *StreamProcessor {
@@ -427,26 +491,6 @@ func (r *StreamProcessor) dispatchToolCalls() {
 }
 
 func (r *StreamProcessor) processEvent(e Envelope) {
-	ev := e.Data
-
-	switch actual := ev.(type) {
-	case *DeltaEvent:
-		r.result.applyDelta(actual)
-	case *ToolCallEvent:
-		r.result.applyToolCall(actual.ToolCall)
-	case *CompletedEvent:
-		r.result.stopReason = actual.StopReason
-	case *UsageUpdatedEvent:
-		r.result.applyUsage(actual.Record)
-	case *TokenEstimateEvent:
-		r.result.applyEstimate(actual.Estimate)
-	case *ErrorEvent:
-		r.result.addError(actual.Error)
-		r.result.stopReason = StopReasonError
-	case *ContentPartEvent:
-		r.result.applyContentPart(actual)
-
-	}
-
+	r.result.apply(e.Data)
 	r.dispatchEvent(e)
 }
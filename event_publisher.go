@@ -79,5 +79,16 @@ func (s *eventPub) UsageRecord(r usage.Record)         { s.Publish(&UsageUpdated
 func (s *eventPub) TokenEstimate(r usage.Record)       { s.Publish(&TokenEstimateEvent{Estimate: r}) }
 func (s *eventPub) Completed(completed CompletedEvent) { s.Publish(&completed) }
 func (s *eventPub) Error(err error)                    { s.Publish(&ErrorEvent{Error: err}) }
+func (s *eventPub) Warning(w WarningEvent)             { s.Publish(&w) }
 func (s *eventPub) ToolCall(tc tool.Call)              { s.Publish(&ToolCallEvent{ToolCall: tc}) }
 func (s *eventPub) ContentBlock(evt ContentPartEvent)  { s.Publish(&evt) }
+
+// PublishWarning reports w on pub, through its Warning method when pub is a
+// WarningPublisher.
+func PublishWarning(pub Publisher, w WarningEvent) {
+	if wp, ok := pub.(WarningPublisher); ok {
+		wp.Warning(w)
+		return
+	}
+	pub.Publish(&w)
+}
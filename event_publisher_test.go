@@ -0,0 +1,44 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+// minimalPublisher implements only Publisher, as external publishers
+// written before WarningPublisher existed do.
+type minimalPublisher struct {
+	llm.Publisher
+	published []llm.Event
+}
+
+func (p *minimalPublisher) Publish(ev llm.Event) { p.published = append(p.published, ev) }
+
+func TestPublishWarning(t *testing.T) {
+	w := llm.WarningEvent{Code: llm.WarningDeprecated, Message: "model is deprecated"}
+
+	t.Run("Publisher without Warning", func(t *testing.T) {
+		p := &minimalPublisher{}
+		llm.PublishWarning(p, w)
+		require.Len(t, p.published, 1)
+		assert.Equal(t, &w, p.published[0])
+	})
+
+	t.Run("WarningPublisher", func(t *testing.T) {
+		pub, ch := llm.NewEventPublisher()
+		llm.PublishWarning(pub, w)
+		pub.Close()
+		var got []any
+		for env := range ch {
+			if env.Type == llm.StreamEventWarning {
+				got = append(got, env.Data)
+			}
+		}
+		require.Len(t, got, 1)
+		assert.Equal(t, &w, got[0])
+	})
+}
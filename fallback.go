@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fallback is a Provider that chains backends: a request goes to the primary
+// and, when CreateStream fails with a retryable error, to each secondary in
+// turn. Failures after a stream has started are not retried, since output
+// may already have reached the caller.
+//
+// Use ModelMapMiddleware to give a backend its own model names:
+//
+//	p := llm.NewFallback(anthropicProvider,
+//		llm.Wrap(bedrockProvider, llm.ModelMapMiddleware(map[string]string{
+//			"claude-sonnet-4-6": "anthropic.claude-sonnet-4-6",
+//		})),
+//	)
+type Fallback struct {
+	providers []Provider
+	retryable func(error) bool
+}
+
+// NewFallback returns a Fallback that tries primary first, then secondaries
+// in order.
+func NewFallback(primary Provider, secondaries ...Provider) *Fallback {
+	return &Fallback{
+		providers: append([]Provider{primary}, secondaries...),
+		retryable: IsFallbackError,
+	}
+}
+
+// WithRetryable replaces IsFallbackError as the test for whether an error
+// moves the request on to the next backend.
+func (f *Fallback) WithRetryable(fn func(error) bool) *Fallback {
+	f.retryable = fn
+	return f
+}
+
+// Name returns the primary's name.
+func (f *Fallback) Name() string { return f.providers[0].Name() }
+
+// Models returns the models of all backends, the primary's first. A model
+// ID offered by several backends is listed once.
+func (f *Fallback) Models() Models { return unionModels(f.providers) }
+
+// unionModels returns the models of providers in order, listing each model
+// ID once.
+func unionModels(providers []Provider) Models {
+	var out Models
+	seen := map[string]bool{}
+	for _, p := range providers {
+		for _, m := range p.Models() {
+			if !seen[m.ID] {
+				seen[m.ID] = true
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// CreateStream sends src to the first backend that accepts it. When a
+// secondary serves the request, the stream starts with a
+// ProviderFailoverEvent per failed backend and a WarningFallbackApplied
+// warning. When every backend fails, the error wraps all of their errors.
+func (f *Fallback) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		errs   []error
+		failed []string
+	)
+	for _, p := range f.providers {
+		stream, err := p.CreateStream(ctx, req)
+		if err == nil {
+			if len(failed) == 0 {
+				return stream, nil
+			}
+			return withFailoverEvents(ctx, stream, failed, errs, p.Name()), nil
+		}
+		if ctx.Err() != nil || !f.retryable(err) {
+			return nil, err
+		}
+		errs = append(errs, err)
+		failed = append(failed, p.Name())
+	}
+	return nil, NewErrAllProvidersFailed(f.Name(), errs)
+}
+
+// IsFallbackError reports whether err is worth retrying on another
+// provider: rate limits, exhausted quotas, 5xx and other transient HTTP
+// statuses, overload messages, transport failures and timeouts. Service
+// (with RetryPolicy.EnableFallback), Fallback and Router all use it.
+func IsFallbackError(err error) bool {
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrRequestFailed) || errors.Is(err, ErrFirstTokenTimeout) {
+		return true
+	}
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		if pe.StatusCode >= 500 || pe.StatusCode == http.StatusPaymentRequired ||
+			pe.StatusCode == http.StatusRequestTimeout || IsRetriableHTTPStatus(pe.StatusCode) {
+			return true
+		}
+		msg := strings.ToLower(pe.Error())
+		for _, needle := range []string{"rate limit", "too many requests", "quota", "service unavailable", "overloaded", "temporarily unavailable", "try again"} {
+			if strings.Contains(msg, needle) {
+				return true
+			}
+		}
+		if pe.StatusCode != 0 {
+			return false
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// withFailoverEvents reports the failed backends on stream, stamped with
+// the meta of its first event. When ctx is cancelled the rest of stream is
+// drained.
+func withFailoverEvents(ctx context.Context, stream Stream, failed []string, errs []error, served string) Stream {
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		send := func(env Envelope) bool {
+			select {
+			case out <- env:
+				return true
+			case <-ctx.Done():
+				drain(stream)
+				return false
+			}
+		}
+		first := true
+		for env := range stream {
+			if first {
+				first = false
+				meta := env.Meta
+				meta.Seq = 0
+				meta.CreatedAt = time.Now()
+				for i, from := range failed {
+					to := served
+					if i+1 < len(failed) {
+						to = failed[i+1]
+					}
+					ev := &ProviderFailoverEvent{Provider: from, FailoverProvider: to, Error: errs[i]}
+					if !send(Envelope{Type: ev.Type(), Meta: meta, Data: ev}) {
+						return
+					}
+				}
+				warning := &WarningEvent{
+					Code:     WarningFallbackApplied,
+					Message:  fmt.Sprintf("%s failed; served by %s", strings.Join(failed, ", "), served),
+					Provider: served,
+				}
+				if !send(Envelope{Type: warning.Type(), Meta: meta, Data: warning}) {
+					return
+				}
+			}
+			if !send(env) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// ModelMapMiddleware rewrites Request.Model through models before the
+// request reaches the provider, for backends that name the same model
+// differently. Models without an entry pass through unchanged.
+func ModelMapMiddleware(models map[string]string) Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := models[req.Model]; ok {
+			req.Model = m
+		}
+		return next.CreateStream(ctx, req)
+	})
+}
@@ -0,0 +1,124 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func TestFallback_FailsOverOnRetryableError(t *testing.T) {
+	primary := &recordingProvider{name: "primary", err: llm.NewErrAPIError("primary", 429, `{"error":{"code":"rate_limit_exceeded"}}`)}
+	flaky := &recordingProvider{name: "flaky", err: llm.NewErrAPIError("flaky", 502, "bad gateway")}
+	secondary := &recordingProvider{name: "secondary"}
+	p := llm.NewFallback(primary, flaky, llm.Wrap(secondary, llm.ModelMapMiddleware(map[string]string{
+		"claude-sonnet-4-6": "anthropic.claude-sonnet-4-6",
+	})))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "claude-sonnet-4-6", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+
+	var failovers []*llm.ProviderFailoverEvent
+	res := llm.NewEventProcessor(context.Background(), stream).
+		OnEvent(llm.TypedEventHandler[*llm.ProviderFailoverEvent](func(ev *llm.ProviderFailoverEvent) {
+			failovers = append(failovers, ev)
+		})).
+		Result()
+	require.NoError(t, res.Error())
+
+	require.Len(t, failovers, 2)
+	assert.Equal(t, "primary", failovers[0].Provider)
+	assert.Equal(t, "flaky", failovers[0].FailoverProvider)
+	assert.Equal(t, "secondary", failovers[1].FailoverProvider)
+	require.Len(t, res.Warnings(), 1)
+	assert.Equal(t, llm.WarningFallbackApplied, res.Warnings()[0].Code)
+
+	assert.Equal(t, "claude-sonnet-4-6", primary.reqs[0].Model)
+	require.Len(t, secondary.reqs, 1)
+	assert.Equal(t, "anthropic.claude-sonnet-4-6", secondary.reqs[0].Model)
+}
+
+func TestFallback_StopsOnNonRetryableError(t *testing.T) {
+	primary := &recordingProvider{name: "primary", err: llm.NewErrAPIError("primary", 401, `{"error":{"type":"authentication_error"}}`)}
+	secondary := &recordingProvider{name: "secondary"}
+
+	_, err := llm.NewFallback(primary, secondary).CreateStream(context.Background(), llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	assert.ErrorIs(t, err, llm.ErrAuth)
+	assert.Empty(t, secondary.reqs)
+}
+
+func TestFallback_AllFailed(t *testing.T) {
+	primary := &recordingProvider{name: "primary", err: llm.NewErrAPIError("primary", 503, "unavailable")}
+	secondary := &recordingProvider{name: "secondary", err: llm.NewErrAPIError("secondary", 429, "")}
+
+	_, err := llm.NewFallback(primary, secondary).CreateStream(context.Background(), llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrNoProviders)
+	assert.ErrorIs(t, err, llm.ErrRateLimited)
+	assert.Len(t, primary.reqs, 1)
+	assert.Len(t, secondary.reqs, 1)
+}
+
+func TestFallback_PrimaryStreamsUnchanged(t *testing.T) {
+	p := llm.NewFallback(&middlewareTestProvider{events: []llm.Event{llmtest.TextEvent("hi"), llmtest.CompletedEvent(llm.StopReasonEndTurn)}})
+	assert.Equal(t, "fake", p.Name())
+	assert.Len(t, p.Models(), 1)
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	c, err := llm.Collect(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", c.Text)
+	assert.Empty(t, c.Warnings)
+}
+
+func TestIsFallbackError(t *testing.T) {
+	assert.True(t, llm.IsFallbackError(llm.NewErrAPIError("p", 500, "")))
+	assert.True(t, llm.IsFallbackError(llm.NewErrRequestFailed("p", errors.New("connection reset"))))
+	assert.True(t, llm.IsFallbackError(context.DeadlineExceeded))
+	assert.True(t, llm.IsFallbackError(llm.NewErrAPIError("p", 402, "payment required")))
+	assert.True(t, llm.IsFallbackError(llm.NewErrAPIError("p", 400, "monthly quota exceeded")))
+	assert.False(t, llm.IsFallbackError(llm.NewErrAPIError("p", 400, "bad request")))
+	assert.False(t, llm.IsFallbackError(llm.NewErrBuildRequest("p", errors.New("bad"))))
+}
+
+// chanProvider streams whatever is sent on in.
+type chanProvider struct{ in chan llm.Envelope }
+
+func (p chanProvider) Name() string       { return "chan" }
+func (p chanProvider) Models() llm.Models { return nil }
+func (p chanProvider) CreateStream(context.Context, llm.Buildable) (llm.Stream, error) {
+	return p.in, nil
+}
+
+func TestFallback_CancelledConsumer(t *testing.T) {
+	primary := &recordingProvider{name: "primary", err: llm.NewErrAPIError("primary", 503, "unavailable")}
+	secondary := chanProvider{in: make(chan llm.Envelope)}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := llm.NewFallback(primary, secondary).CreateStream(ctx, llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+
+	// Nobody reads stream after cancel; the producer must still finish.
+	cancel()
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < 200; i++ {
+			secondary.in <- llm.Envelope{Type: llm.StreamEventDelta}
+		}
+		close(secondary.in)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("fallback stream blocked after cancellation")
+	}
+	for range stream {
+	}
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// firstTokenMiss records a candidate that missed Request.FirstTokenDeadline.
+type firstTokenMiss struct {
+	provider string
+	err      error
+}
+
+// awaitFirstToken reads stream until its first delta, error or completion
+// and returns a stream replaying everything read so far followed by the
+// rest. Earlier misses are reported on the returned stream, before the first
+// delta, as ProviderFailoverEvent and WarningFallbackApplied events.
+//
+// If timer fires first, cancel stops the attempt, the stream is drained in
+// the background and an ErrFirstTokenTimeout error is returned. cancel is
+// also called once the returned stream ends.
+func awaitFirstToken(ctx context.Context, cancel context.CancelFunc, stream Stream, timer <-chan time.Time, deadline time.Duration, provider string, misses []firstTokenMiss) (Stream, error) {
+	var buf []Envelope
+	for {
+		select {
+		case env, ok := <-stream:
+			if !ok {
+				return replayStream(ctx, cancel, buf, nil, stream), nil
+			}
+			switch env.Type {
+			case StreamEventDelta, StreamEventToolCall, StreamEventToolProgress, StreamEventCompleted, StreamEventError:
+				return replayStream(ctx, cancel, append(buf, failoverEnvelopes(env.Meta, provider, deadline, misses)...), &env, stream), nil
+			}
+			buf = append(buf, env)
+		case <-timer:
+			cancel()
+			go drain(stream)
+			return nil, NewErrFirstTokenTimeout(provider, deadline)
+		case <-ctx.Done():
+			cancel()
+			go drain(stream)
+			return nil, NewErrContextCancelled(provider, ctx.Err())
+		}
+	}
+}
+
+// failoverEnvelopes reports misses as events stamped with meta's request ID.
+func failoverEnvelopes(meta EventMeta, provider string, deadline time.Duration, misses []firstTokenMiss) []Envelope {
+	if len(misses) == 0 {
+		return nil
+	}
+	meta.Seq = 0
+	meta.CreatedAt = time.Now()
+	out := make([]Envelope, 0, len(misses)+1)
+	from := make([]string, 0, len(misses))
+	for i, m := range misses {
+		to := provider
+		if i+1 < len(misses) {
+			to = misses[i+1].provider
+		}
+		ev := &ProviderFailoverEvent{Provider: m.provider, FailoverProvider: to, Error: m.err}
+		out = append(out, Envelope{Type: ev.Type(), Meta: meta, Data: ev})
+		from = append(from, m.provider)
+	}
+	warning := &WarningEvent{
+		Code:     WarningFallbackApplied,
+		Message:  fmt.Sprintf("first token deadline of %s missed by %s; served by %s", deadline, strings.Join(from, ", "), provider),
+		Provider: provider,
+		Param:    "first_token_deadline",
+	}
+	return append(out, Envelope{Type: warning.Type(), Meta: meta, Data: warning})
+}
+
+// replayStream emits buf, then first (when non-nil), then the remainder of
+// rest, and calls cancel when done. When ctx is cancelled the remainder of
+// rest is drained.
+func replayStream(ctx context.Context, cancel context.CancelFunc, buf []Envelope, first *Envelope, rest Stream) Stream {
+	out := make(chan Envelope, len(buf)+1)
+	for _, env := range buf {
+		out <- env
+	}
+	if first != nil {
+		out <- *first
+	}
+	go func() {
+		defer cancel()
+		defer close(out)
+		for env := range rest {
+			select {
+			case out <- env:
+			case <-ctx.Done():
+				drain(rest)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func drain(stream Stream) {
+	for range stream {
+	}
+}
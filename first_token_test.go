@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplayStream_CancelledConsumer(t *testing.T) {
+	rest := make(chan Envelope)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	out := replayStream(ctx, func() { close(stopped) }, []Envelope{{Type: StreamEventStarted}}, nil, rest)
+
+	// Nobody reads out after cancel; the producer must still finish.
+	cancel()
+	go func() {
+		for i := 0; i < 200; i++ {
+			rest <- Envelope{Type: StreamEventDelta}
+		}
+		close(rest)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("replay stream blocked after cancellation")
+	}
+	for range out {
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/andybalholm/brotli"
@@ -34,6 +38,14 @@ type HttpClientOpts struct {
 	// queueing, cold starts), so this defaults to 120 seconds rather than the
 	// typical HTTP client default.
 	ResponseHeaderTimeout time.Duration
+
+	// DialTimeout is the maximum time to establish a TCP connection.
+	// Defaults to 30 seconds if not set.
+	DialTimeout time.Duration
+
+	// Proxy selects the proxy for a request. Defaults to
+	// http.ProxyFromEnvironment (HTTPS_PROXY, HTTP_PROXY, NO_PROXY).
+	Proxy func(*http.Request) (*url.URL, error)
 }
 
 // loggingTransport is an http.RoundTripper that logs every request and response.
@@ -171,9 +183,25 @@ func NewHttpClient(opts HttpClientOpts) *http.Client {
 		headTimeout = opts.ResponseHeaderTimeout
 	}
 
+	dialTimeout := 30 * time.Second
+	if opts.DialTimeout > 0 {
+		dialTimeout = opts.DialTimeout
+	}
+	proxy := opts.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
 	var transport http.RoundTripper = &http.Transport{
+		Proxy:       proxy,
+		DialContext: (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		// Resume TLS sessions so reconnects after idle periods skip the full
+		// handshake.
+		TLSClientConfig:       &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(0)},
+		ForceAttemptHTTP2:     true,
 		TLSHandshakeTimeout:   tlsTimeout,
 		ResponseHeaderTimeout: headTimeout,
+		ExpectContinueTimeout: time.Second,
 		MaxIdleConns:          100,
 		MaxIdleConnsPerHost:   10,
 		MaxConnsPerHost:       0,
@@ -204,6 +232,10 @@ type decompressingTransport struct {
 }
 
 func (t *decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Last line of defence for providers that do not support DryRun.
+	if IsDryRun(req.Context()) {
+		return nil, ErrDryRun
+	}
 	resp, err := t.wrapped.RoundTrip(req)
 	if err != nil || resp == nil {
 		return resp, err
@@ -303,13 +335,27 @@ func (f *flateReadCloser) Close() error {
 	return f.underlying.Close()
 }
 
-// defaultHttpClient is the package-level singleton used when no custom client
+// defaultHttpClient is the package-level client used when no custom client
 // is provided via WithHTTPClient.
-var defaultHttpClient = NewHttpClient(HttpClientOpts{})
+var defaultHttpClient atomic.Pointer[http.Client]
+
+func init() { defaultHttpClient.Store(NewHttpClient(HttpClientOpts{})) }
 
 // DefaultHttpClient returns the shared default HTTP client. It is safe for
 // concurrent use and is reused across all providers that do not supply their
 // own client.
 func DefaultHttpClient() *http.Client {
-	return defaultHttpClient
+	return defaultHttpClient.Load()
+}
+
+// SetDefaultHttpClient replaces the shared default HTTP client, e.g. to route
+// every provider through a corporate proxy or an instrumented transport.
+// Providers resolve their client when they are created, so call it before
+// constructing them. Like WithHTTPClient, c is wrapped so that it refuses
+// DryRun requests. A nil c restores the built-in default.
+func SetDefaultHttpClient(c *http.Client) {
+	if c == nil {
+		c = NewHttpClient(HttpClientOpts{})
+	}
+	defaultHttpClient.Store(guardDryRun(c))
 }
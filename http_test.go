@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHttpClient_Transport(t *testing.T) {
+	c := NewHttpClient(HttpClientOpts{})
+	tr := c.Transport.(*decompressingTransport).wrapped.(*http.Transport)
+
+	assert.NotNil(t, tr.Proxy, "proxy from environment by default")
+	assert.NotNil(t, tr.DialContext)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	require.NotNil(t, tr.TLSClientConfig)
+	assert.NotNil(t, tr.TLSClientConfig.ClientSessionCache, "TLS sessions are resumed")
+	assert.Zero(t, c.Timeout, "streams are bounded by their context, not a client timeout")
+}
+
+func TestNewHttpClient_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	c := NewHttpClient(HttpClientOpts{Proxy: http.ProxyURL(proxyURL)})
+	resp, err := c.Get("http://api.example.invalid/v1/models")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, "via proxy", string(body))
+	assert.Equal(t, "http://api.example.invalid/v1/models", proxied)
+}
+
+func TestSetDefaultHttpClient(t *testing.T) {
+	orig := DefaultHttpClient()
+	t.Cleanup(func() { SetDefaultHttpClient(orig) })
+
+	custom := &http.Client{Timeout: time.Minute}
+	SetDefaultHttpClient(custom)
+	assert.Equal(t, time.Minute, DefaultHttpClient().Timeout)
+	assert.Equal(t, dryRunGuard{next: http.DefaultTransport}, DefaultHttpClient().Transport)
+	assert.Nil(t, custom.Transport, "the passed client is not modified")
+
+	SetDefaultHttpClient(nil)
+	assert.NotNil(t, DefaultHttpClient())
+	assert.NotSame(t, custom, DefaultHttpClient())
+}
@@ -0,0 +1,54 @@
+package modelcatalog
+
+import modeldb "github.com/codewandler/modeldb"
+
+// LongContext is a premium rate card billed for a whole request once its
+// prompt (input plus cache reads and writes) exceeds Threshold tokens.
+type LongContext struct {
+	Threshold int
+	Pricing   modeldb.Pricing
+}
+
+// longContextTier derives a model line's long-context rates from the rates
+// of an offering.
+type longContextTier struct {
+	threshold    int
+	inputFactor  float64 // input, cache read and cache write
+	outputFactor float64 // output and reasoning
+}
+
+// longContextOverrides lists the model lines billed at a premium above 200k
+// prompt tokens, for offerings the refreshed models.dev data carries no
+// long-context rates for (including the whole embedded snapshot, whose
+// pricing has no field for them). The tier is the same on the Anthropic API,
+// Bedrock and Vertex. Keys leave ReleaseDate empty.
+var longContextOverrides = map[modeldb.ModelKey]longContextTier{
+	{Creator: "anthropic", Family: "claude", Series: "sonnet", Version: "4.0"}: {threshold: 200_000, inputFactor: 2, outputFactor: 1.5},
+	{Creator: "anthropic", Family: "claude", Series: "sonnet", Version: "4.5"}: {threshold: 200_000, inputFactor: 2, outputFactor: 1.5},
+}
+
+// LongContextPricing returns the long-context rates of the offering ref of
+// model line key, whose base rates are base. Rates from the refreshed
+// models.dev data take precedence over longContextOverrides.
+func LongContextPricing(ref modeldb.OfferingRef, key modeldb.ModelKey, base modeldb.Pricing) (LongContext, bool) {
+	if r := loadRefreshed(); r != nil {
+		if lc, ok := r.longContext[ref]; ok {
+			return lc, true
+		}
+	}
+	key.ReleaseDate = ""
+	tier, ok := longContextOverrides[key]
+	if !ok {
+		return LongContext{}, false
+	}
+	return LongContext{
+		Threshold: tier.threshold,
+		Pricing: modeldb.Pricing{
+			Input:       base.Input * tier.inputFactor,
+			Output:      base.Output * tier.outputFactor,
+			Reasoning:   base.Reasoning * tier.outputFactor,
+			CachedInput: base.CachedInput * tier.inputFactor,
+			CacheWrite:  base.CacheWrite * tier.inputFactor,
+		},
+	}, true
+}
@@ -0,0 +1,315 @@
+package modelcatalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	modeldb "github.com/codewandler/modeldb"
+)
+
+const (
+	// ModelsDevURL is the models.dev database fetched by Refresh.
+	ModelsDevURL = "https://models.dev/api.json"
+
+	// DefaultRefreshMaxAge is how old the cached database may be before
+	// Refresh fetches it again.
+	DefaultRefreshMaxAge = 24 * time.Hour
+
+	modelsDevCacheFile = "api.json"
+)
+
+var (
+	activeMu   sync.RWMutex
+	active     *refreshedCatalog // nil serves the embedded catalog
+	pinned     bool
+	generation atomic.Uint64
+)
+
+// refreshedCatalog is a catalog built by Refresh with the long-context rates
+// models.dev lists, which modeldb.Pricing has no field for.
+type refreshedCatalog struct {
+	catalog     modeldb.Catalog
+	longContext map[modeldb.OfferingRef]LongContext
+}
+
+// Load returns the catalog consumers should use: the one built by the last
+// successful Refresh, or the embedded snapshot when nothing was refreshed or
+// the catalog is pinned to it.
+func Load() (modeldb.Catalog, error) {
+	if r := loadRefreshed(); r != nil {
+		return r.catalog, nil
+	}
+	return LoadBuiltIn()
+}
+
+// loadRefreshed returns the refreshed catalog Load serves, or nil.
+func loadRefreshed() *refreshedCatalog {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	if pinned {
+		return nil
+	}
+	return active
+}
+
+// Generation changes whenever the catalog returned by Load changes, so
+// consumers that derive data from it know when to rebuild.
+func Generation() uint64 { return generation.Load() }
+
+// Pin makes Load serve the embedded snapshot only (true) or the refreshed
+// catalog again (false). Refresh still updates the on-disk cache while pinned.
+func Pin(pin bool) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if pinned != pin {
+		pinned = pin
+		generation.Add(1)
+	}
+}
+
+func setActive(c *refreshedCatalog) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	active = c
+	generation.Add(1)
+}
+
+// RefreshOptions configures Refresh. The zero value fetches ModelsDevURL with
+// http.DefaultClient into DefaultCacheDir, at most once per
+// DefaultRefreshMaxAge.
+type RefreshOptions struct {
+	URL      string
+	Client   *http.Client
+	CacheDir string
+	MaxAge   time.Duration
+}
+
+// DefaultCacheDir returns <user cache dir>/llm/models.dev.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get cache directory: %w", err)
+	}
+	return filepath.Join(dir, "llm", "models.dev"), nil
+}
+
+// Refresh updates the catalog served by Load from the models.dev database.
+//
+// A cached copy younger than MaxAge is used without a network request.
+// Otherwise the database is fetched, validated and written to the cache. When
+// the fetch or validation fails, a cached copy of any age is still applied and
+// the error is returned.
+func Refresh(ctx context.Context, opts RefreshOptions) error {
+	if opts.URL == "" {
+		opts.URL = ModelsDevURL
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultRefreshMaxAge
+	}
+	if opts.CacheDir == "" {
+		dir, err := DefaultCacheDir()
+		if err != nil {
+			return err
+		}
+		opts.CacheDir = dir
+	}
+	path := filepath.Join(opts.CacheDir, modelsDevCacheFile)
+
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < opts.MaxAge {
+		if err := applyFile(path); err == nil {
+			return nil
+		}
+	}
+
+	data, fetchErr := fetchModelsDev(ctx, opts.Client, opts.URL)
+	if fetchErr == nil {
+		var cat *refreshedCatalog
+		if cat, fetchErr = buildCatalog(data); fetchErr == nil {
+			if err := os.MkdirAll(opts.CacheDir, 0o755); err != nil {
+				return fmt.Errorf("create catalog cache directory: %w", err)
+			}
+			if err := writeFileAtomic(path, data); err != nil {
+				return fmt.Errorf("write catalog cache: %w", err)
+			}
+			setActive(cat)
+			return nil
+		}
+	}
+	if err := applyFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Join(fetchErr, err)
+	}
+	return fetchErr
+}
+
+func applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cat, err := buildCatalog(data)
+	if err != nil {
+		return fmt.Errorf("cached %s: %w", path, err)
+	}
+	setActive(cat)
+	return nil
+}
+
+func fetchModelsDev(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch models.dev: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch models.dev: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch models.dev: %w", err)
+	}
+	return data, nil
+}
+
+// modelsDevDatabase is the part of the models.dev api.json schema Refresh
+// uses: per provider, per wire model ID, the pricing (USD per 1M tokens),
+// including the rates above 200k prompt tokens, and token limits.
+type modelsDevDatabase map[string]struct {
+	Models map[string]struct {
+		Cost *struct {
+			modelsDevCost
+			ContextOver200k *modelsDevCost `json:"context_over_200k"`
+		} `json:"cost"`
+		Limit struct {
+			Context int `json:"context"`
+			Output  int `json:"output"`
+		} `json:"limit"`
+	} `json:"models"`
+}
+
+type modelsDevCost struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+	Reasoning  float64 `json:"reasoning"`
+}
+
+func (c modelsDevCost) negative() bool {
+	return c.Input < 0 || c.Output < 0 || c.CacheRead < 0 || c.CacheWrite < 0 || c.Reasoning < 0
+}
+
+func (c modelsDevCost) pricing() *modeldb.Pricing {
+	return &modeldb.Pricing{
+		Input:       c.Input,
+		Output:      c.Output,
+		CachedInput: c.CacheRead,
+		CacheWrite:  c.CacheWrite,
+		Reasoning:   c.Reasoning,
+	}
+}
+
+// modelsDevServices maps models.dev provider IDs to catalog service IDs where
+// they differ.
+var modelsDevServices = map[string]string{
+	"amazon-bedrock": "bedrock",
+}
+
+// buildCatalog validates a models.dev database and overlays its pricing and
+// limits on the offerings of the embedded snapshot it also lists. Models the
+// snapshot does not know are skipped: their identity and capabilities are not
+// in models.dev in a form the catalog can use.
+func buildCatalog(data []byte) (*refreshedCatalog, error) {
+	var db modelsDevDatabase
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("decode models.dev database: %w", err)
+	}
+	base, err := LoadBuiltIn()
+	if err != nil {
+		return nil, err
+	}
+
+	out := base
+	out.Offerings = make(map[modeldb.OfferingRef]modeldb.Offering, len(base.Offerings))
+	for ref, o := range base.Offerings {
+		out.Offerings[ref] = o
+	}
+
+	longContext := make(map[modeldb.OfferingRef]LongContext)
+	matched := 0
+	for providerID, provider := range db {
+		serviceID := providerID
+		if id, ok := modelsDevServices[providerID]; ok {
+			serviceID = id
+		}
+		for wireID, m := range provider.Models {
+			ref := modeldb.OfferingRef{ServiceID: serviceID, WireModelID: wireID}
+			o, ok := out.Offerings[ref]
+			if !ok {
+				continue
+			}
+			matched++
+			if c := m.Cost; c != nil {
+				if c.negative() || (c.ContextOver200k != nil && c.ContextOver200k.negative()) {
+					return nil, fmt.Errorf("models.dev %s/%s: negative price", providerID, wireID)
+				}
+				if c.Input > 0 || c.Output > 0 {
+					o.Pricing = c.pricing()
+					o.PricingStatus = "known"
+				}
+				if lc := c.ContextOver200k; lc != nil && (lc.Input > 0 || lc.Output > 0) {
+					longContext[ref] = LongContext{Threshold: 200_000, Pricing: *lc.pricing()}
+				}
+			}
+			if m.Limit.Context > 0 || m.Limit.Output > 0 {
+				o.LimitsOverride = &modeldb.Limits{ContextWindow: m.Limit.Context, MaxOutput: m.Limit.Output}
+			}
+			out.Offerings[ref] = o
+		}
+	}
+	if matched == 0 {
+		return nil, errors.New("models.dev database lists no known model")
+	}
+	if err := modeldb.ValidateCatalog(out); err != nil {
+		return nil, fmt.Errorf("validate catalog: %w", err)
+	}
+	return &refreshedCatalog{catalog: out, longContext: longContext}, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
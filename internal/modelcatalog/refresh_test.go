@@ -0,0 +1,117 @@
+package modelcatalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	modeldb "github.com/codewandler/modeldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModelsDev = `{
+  "anthropic": {"models": {
+    "claude-sonnet-4-6": {"cost": {"input": 4, "output": 20, "cache_read": 0.4, "cache_write": 5, "context_over_200k": {"input": 8, "output": 30}}, "limit": {"context": 1000000, "output": 128000}},
+    "claude-unreleased": {"cost": {"input": 1, "output": 2}}
+  }},
+  "amazon-bedrock": {"models": {}}
+}`
+
+func resetActive(t *testing.T) {
+	t.Cleanup(func() {
+		setActive(nil)
+		Pin(false)
+	})
+}
+
+func sonnetOffering(t *testing.T) modeldb.Offering {
+	t.Helper()
+	c, err := Load()
+	require.NoError(t, err)
+	o, ok := c.Offerings[modeldb.OfferingRef{ServiceID: "anthropic", WireModelID: "claude-sonnet-4-6"}]
+	require.True(t, ok)
+	return o
+}
+
+func TestRefresh(t *testing.T) {
+	resetActive(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(testModelsDev))
+	}))
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	opts := RefreshOptions{URL: srv.URL, CacheDir: dir}
+
+	gen := Generation()
+	require.NoError(t, Refresh(context.Background(), opts))
+	assert.NotEqual(t, gen, Generation())
+	o := sonnetOffering(t)
+	assert.Equal(t, &modeldb.Pricing{Input: 4, Output: 20, CachedInput: 0.4, CacheWrite: 5}, o.Pricing)
+	assert.Equal(t, &modeldb.Limits{ContextWindow: 1000000, MaxOutput: 128000}, o.LimitsOverride)
+	assert.FileExists(t, filepath.Join(dir, modelsDevCacheFile))
+	lc, ok := LongContextPricing(modeldb.OfferingRef{ServiceID: "anthropic", WireModelID: "claude-sonnet-4-6"}, o.ModelKey, *o.Pricing)
+	require.True(t, ok)
+	assert.Equal(t, LongContext{Threshold: 200_000, Pricing: modeldb.Pricing{Input: 8, Output: 30}}, lc)
+
+	t.Run("fresh cache skips fetch", func(t *testing.T) {
+		require.NoError(t, Refresh(context.Background(), opts))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("failed fetch falls back to stale cache", func(t *testing.T) {
+		setActive(nil)
+		old := time.Now().Add(-48 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, modelsDevCacheFile), old, old))
+		err := Refresh(context.Background(), RefreshOptions{URL: srv.URL + "/missing", CacheDir: dir, Client: &http.Client{
+			Transport: roundTripFunc(func(*http.Request) (*http.Response, error) { return nil, assert.AnError }),
+		}})
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 4.0, sonnetOffering(t).Pricing.Input)
+	})
+
+	t.Run("pin serves embedded snapshot", func(t *testing.T) {
+		Pin(true)
+		builtIn, err := LoadBuiltIn()
+		require.NoError(t, err)
+		ref := modeldb.OfferingRef{ServiceID: "anthropic", WireModelID: "claude-sonnet-4-6"}
+		assert.Equal(t, builtIn.Offerings[ref].Pricing, sonnetOffering(t).Pricing)
+		_, ok := LongContextPricing(ref, sonnetOffering(t).ModelKey, *sonnetOffering(t).Pricing)
+		assert.False(t, ok, "the embedded snapshot has no long-context rates for this line")
+		Pin(false)
+		assert.Equal(t, 4.0, sonnetOffering(t).Pricing.Input)
+	})
+}
+
+func TestRefresh_RejectsInvalidDatabase(t *testing.T) {
+	resetActive(t)
+	for name, body := range map[string]string{
+		"not json":                    `<html>`,
+		"no known model":              `{"anthropic": {"models": {"claude-unreleased": {}}}}`,
+		"negative price":              `{"anthropic": {"models": {"claude-sonnet-4-6": {"cost": {"input": -1}}}}}`,
+		"negative long-context price": `{"anthropic": {"models": {"claude-sonnet-4-6": {"cost": {"input": 1, "context_over_200k": {"output": -1}}}}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			t.Cleanup(srv.Close)
+			dir := t.TempDir()
+			gen := Generation()
+			require.Error(t, Refresh(context.Background(), RefreshOptions{URL: srv.URL, CacheDir: dir}))
+			assert.Equal(t, gen, Generation())
+			assert.NoFileExists(t, filepath.Join(dir, modelsDevCacheFile))
+		})
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -3,6 +3,8 @@ package providercore
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sort"
 	"strings"
@@ -22,6 +24,13 @@ type llmBridgeBuilder struct {
 	resolvedReq    llm.Request
 	requestedModel string
 	resolvedAPI    llm.ApiType
+	warnings       *warningSink
+	usageDetails   *usageDetailsSink
+	safety         *safetySink
+	builtinItems   *builtinItemSink
+	upstream       *upstreamProviderSink
+	finish         *finishReasonSink
+	streamErr      *streamErrorSink
 }
 
 func (b llmBridgeBuilder) NewBridge() agentclient.StreamBridge[llm.Request, llm.Event] {
@@ -36,6 +45,13 @@ func (b llmBridgeBuilder) NewBridge() agentclient.StreamBridge[llm.Request, llm.
 		resolvedReq:    b.resolvedReq,
 		requestedModel: b.requestedModel,
 		resolvedAPI:    b.resolvedAPI,
+		warnings:       b.warnings,
+		usageDetails:   b.usageDetails,
+		safety:         b.safety,
+		builtinItems:   b.builtinItems,
+		upstream:       b.upstream,
+		finish:         b.finish,
+		streamErr:      b.streamErr,
 		collector:      collector,
 		publisher:      publisher,
 	}
@@ -47,6 +63,13 @@ type llmBridge struct {
 	resolvedReq    llm.Request
 	requestedModel string
 	resolvedAPI    llm.ApiType
+	warnings       *warningSink
+	usageDetails   *usageDetailsSink
+	safety         *safetySink
+	builtinItems   *builtinItemSink
+	upstream       *upstreamProviderSink
+	finish         *finishReasonSink
+	streamErr      *streamErrorSink
 
 	collector *collectingPublisher
 	publisher llm.Publisher
@@ -57,10 +80,12 @@ type llmBridge struct {
 	inputTokens    usage.TokenItems
 	outputTokens   usage.TokenItems
 	stopReason     llm.StopReason
+	failed         bool
 	startedOnce    bool
 	sawToolUseLike bool
 	rateLimits     *llm.RateLimits
 	usageExtras    map[string]any
+	timing         llm.Timing
 }
 
 func (b *llmBridge) BuildRequest(_ context.Context, _ llm.Request) (agentunified.Request, agentclient.UpstreamHints, error) {
@@ -68,11 +93,20 @@ func (b *llmBridge) BuildRequest(_ context.Context, _ llm.Request) (agentunified
 	if err != nil {
 		return agentunified.Request{}, agentclient.UpstreamHints{}, err
 	}
+	if uReq.Output != nil && uReq.Output.Mode == agentunified.OutputModeJSONSchema && b.resolvedAPI != llm.ApiTypeAnthropicMessages {
+		uReq.Output = &agentunified.OutputSpec{Mode: agentunified.OutputModeJSONObject}
+		b.warnings.add(llm.WarningEvent{
+			Code:    llm.WarningParameterIgnored,
+			Message: fmt.Sprintf("output schema is not supported by %s; using JSON mode", b.resolvedAPI),
+			Param:   "output_schema",
+		})
+	}
 	target := apiTypeToTarget(b.resolvedAPI)
 	return uReq, agentclient.UpstreamHints{PreferredTarget: &target}, nil
 }
 
-func (b *llmBridge) OnRequest(_ context.Context, meta agentclient.RequestMeta) ([]llm.Event, error) {
+func (b *llmBridge) OnRequest(ctx context.Context, meta agentclient.RequestMeta) ([]llm.Event, error) {
+	b.timing.RequestStart = time.Now()
 	var out []llm.Event
 	if b.requestedModel != "" && b.requestedModel != b.resolvedReq.Model {
 		out = append(out, &llm.ModelResolvedEvent{
@@ -81,15 +115,20 @@ func (b *llmBridge) OnRequest(_ context.Context, meta agentclient.RequestMeta) (
 			Resolved: b.resolvedReq.Model,
 		})
 	}
-	out = append(out, &llm.RequestEvent{
+	reqEvent := &llm.RequestEvent{
 		OriginalRequest: b.originalReq,
 		ProviderRequest: llm.ProviderRequestFromHTTP(meta.HTTP, meta.Body),
 		ResolvedApiType: b.resolvedAPI,
-	})
-	return out, nil
+	}
+	if llm.CaptureDryRun(ctx, reqEvent) {
+		return nil, llm.ErrDryRun
+	}
+	out = append(out, reqEvent)
+	return append(out, b.warnings.take()...), nil
 }
 
 func (b *llmBridge) OnResponse(_ context.Context, meta agentclient.ResponseMeta) ([]llm.Event, error) {
+	b.timing.FirstByte = time.Now()
 	resp := &http.Response{StatusCode: meta.StatusCode, Header: meta.Headers}
 	if b.cfg.RateLimitParser != nil {
 		b.rateLimits = b.cfg.RateLimitParser(resp)
@@ -97,10 +136,29 @@ func (b *llmBridge) OnResponse(_ context.Context, meta agentclient.ResponseMeta)
 	if b.cfg.UsageExtras != nil {
 		b.usageExtras = b.cfg.UsageExtras(resp)
 	}
+	b.warnings.add(headerWarnings(meta.Headers)...)
 	return nil, nil
 }
 
 func (b *llmBridge) OnEvent(_ context.Context, ev agentunified.StreamEvent) ([]llm.Event, error) {
+	out, err := b.onEvent(ev)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, b.warnings.take()...), nil
+}
+
+func (b *llmBridge) onEvent(ev agentunified.StreamEvent) ([]llm.Event, error) {
+	if ev.Delta != nil || ev.ToolCall != nil {
+		b.timing.MarkFirstDelta()
+	}
+	if ev.Error != nil && ev.Error.Err != nil {
+		// In-band upstream errors (Anthropic "error" events, Responses "error"
+		// events) terminate the response: surface them as ProviderErrors and
+		// suppress the trailing completed event so the error stop reason sticks.
+		b.failed = true
+		ev.Error = &agentunified.StreamError{Err: streamErrorToProviderError(b.cfg.ProviderName, ev.Error.Err)}
+	}
 	switch b.resolvedAPI {
 	case llm.ApiTypeAnthropicMessages:
 		return b.onMessagesEvent(ev)
@@ -112,24 +170,53 @@ func (b *llmBridge) OnEvent(_ context.Context, ev agentunified.StreamEvent) ([]l
 }
 
 func (b *llmBridge) OnClose(_ context.Context) ([]llm.Event, error) {
+	for _, w := range b.warnings.take() {
+		b.collector.Publish(w)
+	}
+	if b.failed {
+		return b.collector.Take(), nil
+	}
 	switch b.resolvedAPI {
 	case llm.ApiTypeAnthropicMessages:
-		emitUsageRecord(b.publisher, b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, append(b.inputTokens, b.outputTokens...).NonZero(), b.rateLimits, b.usageExtras)
+		emitUsageRecord(b.publisher, b.cfg.costCalculator(), b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, append(b.inputTokens, b.outputTokens...).NonZero(), b.rateLimits, b.usageExtras, b.usageDetails.take())
 	case llm.ApiTypeOpenAIResponses:
 		stop := b.stopReason
 		if stop == llm.StopReasonEndTurn && b.sawToolUseLike {
 			stop = llm.StopReasonToolUse
 		}
-		emitUsageRecord(b.publisher, b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, b.allTokens.NonZero(), b.rateLimits, b.usageExtras)
-		b.publisher.Completed(llm.CompletedEvent{StopReason: stop})
+		emitUsageRecord(b.publisher, b.cfg.costCalculator(), b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, b.allTokens.NonZero(), b.rateLimits, b.usageExtras, b.usageDetails.take())
+		b.publisher.Completed(b.completed(stop))
 		return b.collector.Take(), nil
 	default:
-		emitUsageRecord(b.publisher, b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, b.allTokens.NonZero(), b.rateLimits, b.usageExtras)
+		if err := b.streamErr.take(b.cfg.ProviderName); err != nil {
+			// The completions decoder drops in-band error chunks; fail the
+			// response here so it does not end as a successful completion.
+			b.collector.Error(err)
+			return b.collector.Take(), nil
+		}
+		emitUsageRecord(b.publisher, b.cfg.costCalculator(), b.cfg.ProviderName, b.resolvedReq.Model, b.requestID, b.responseModel, b.allTokens.NonZero(), b.rateLimits, b.usageExtras, b.usageDetails.take())
 	}
-	b.publisher.Completed(llm.CompletedEvent{StopReason: b.stopReason})
+	b.publisher.Completed(b.completed(b.stopReason))
 	return b.collector.Take(), nil
 }
 
+// completed returns the completed event for stop with the safety block,
+// the raw finish reason, the timing and the response metadata attached.
+func (b *llmBridge) completed(stop llm.StopReason) llm.CompletedEvent {
+	ev := b.safety.completed(b.cfg.ProviderName, stop)
+	ev.Timing = b.timing.Complete()
+	ev.FinishReason = b.finish.get()
+	if b.requestID == "" && b.responseModel == "" {
+		return ev
+	}
+	provider := b.upstream.get()
+	if provider == "" {
+		provider = b.cfg.ProviderName
+	}
+	ev.Response = &llm.ResponseMeta{ID: b.requestID, Provider: provider, Model: b.responseModel}
+	return ev
+}
+
 func (b *llmBridge) onMessagesEvent(ev agentunified.StreamEvent) ([]llm.Event, error) {
 	var out []llm.Event
 	if ev.Started != nil {
@@ -151,6 +238,11 @@ func (b *llmBridge) onMessagesEvent(ev agentunified.StreamEvent) ([]llm.Event, e
 	}
 	if ev.Completed != nil {
 		if ev.Usage != nil {
+			// message_delta usage is cumulative; when it repeats the input
+			// and cache counts they supersede those from message_start.
+			if in := agentInputTokensToUsage(ev.Usage.Input); len(in) > 0 {
+				b.inputTokens = in
+			}
 			b.outputTokens = agentOutputTokensToUsage(ev.Usage.Output)
 			ev.Usage = nil
 		}
@@ -211,6 +303,23 @@ func (b *llmBridge) onResponsesEvent(ev agentunified.StreamEvent) ([]llm.Event,
 			ev.Started.Extra = map[string]any{"rate_limits": b.rateLimits}
 		}
 	}
+	if events, ok := responsesToolEvents(ev, b.builtinItems); ok {
+		return append(out, events...), nil
+	}
+	if a := ev.Annotation; a != nil {
+		return append(out, &llm.AnnotationEvent{
+			AnnotationType: a.Type,
+			URL:            a.URL,
+			Title:          a.Title,
+			FileID:         a.FileID,
+			Filename:       a.Filename,
+			ContainerID:    a.ContainerID,
+			StartIndex:     a.StartIndex,
+			EndIndex:       a.EndIndex,
+			TextIndex:      a.Index,
+			Index:          a.Ref.ItemIndex,
+		}), nil
+	}
 	if ev.ToolDelta != nil || ev.StreamToolCall != nil || ev.ToolCall != nil {
 		b.sawToolUseLike = true
 	}
@@ -250,6 +359,7 @@ func (p *collectingPublisher) TokenEstimate(r usage.Record) {
 }
 func (p *collectingPublisher) Completed(completed llm.CompletedEvent) { p.Publish(&completed) }
 func (p *collectingPublisher) Error(err error)                        { p.Publish(&llm.ErrorEvent{Error: err}) }
+func (p *collectingPublisher) Warning(w llm.WarningEvent)             { p.Publish(&w) }
 func (p *collectingPublisher) Debug(msg string, data any) {
 	p.Publish(&llm.DebugEvent{Message: msg, Data: data})
 }
@@ -373,16 +483,29 @@ func publishAgentUnifiedToLLM(pub llm.Publisher, ev agentunified.StreamEvent) er
 	return nil
 }
 
+func streamErrorToProviderError(provider string, err error) error {
+	var provErr *llm.ProviderError
+	if errors.As(err, &provErr) {
+		return provErr
+	}
+	return &llm.ProviderError{
+		Sentinel: llm.ErrProviderError,
+		Provider: provider,
+		Message:  err.Error(),
+		Cause:    err,
+	}
+}
+
 func hasUnprojectedSemanticPayload(ev agentunified.StreamEvent) bool {
 	return ev.Lifecycle != nil || ev.ContentDelta != nil || ev.StreamContent != nil || ev.ToolDelta != nil || ev.StreamToolCall != nil || ev.Annotation != nil || ev.Type == agentunified.StreamEventUnknown
 }
 
-func emitUsageRecord(pub llm.Publisher, provider, model, requestID, responseModel string, tokens usage.TokenItems, rateLimits *llm.RateLimits, extras map[string]any) {
+func emitUsageRecord(pub llm.Publisher, calc usage.CostCalculator, provider, model, requestID, responseModel string, tokens usage.TokenItems, rateLimits *llm.RateLimits, extras, details map[string]any) {
 	if len(tokens) == 0 {
 		return
 	}
-	rec := usage.Record{Dims: usage.Dims{Provider: provider, Model: model, RequestID: requestID}, Tokens: tokens, RecordedAt: time.Now(), Extras: cloneAnyMap(extras)}
-	if cost, ok := usage.Default().Calculate(provider, chooseModel(responseModel, model), tokens); ok {
+	rec := usage.Record{Dims: usage.Dims{Provider: provider, Model: model, RequestID: requestID}, Tokens: tokens, RecordedAt: time.Now(), Extras: cloneAnyMap(extras), Details: details}
+	if cost, ok := calc.Calculate(provider, chooseModel(responseModel, model), tokens); ok {
 		rec.Cost = cost
 	}
 	if rateLimits != nil {
@@ -424,6 +547,20 @@ func requestToAgentUnified(req llm.Request) (agentunified.Request, error) {
 			out.Output = &agentunified.OutputSpec{Mode: agentunified.OutputModeJSONObject}
 		}
 	}
+	if req.OutputSchema != nil {
+		out.Output = &agentunified.OutputSpec{Mode: agentunified.OutputModeJSONSchema, Schema: req.OutputSchema}
+	}
+	if len(req.Stop) > 0 {
+		out.Extras.Messages = &agentunified.MessagesExtras{StopSequences: append([]string(nil), req.Stop...)}
+		out.Extras.Completions = &agentunified.CompletionsExtras{Stop: append([]string(nil), req.Stop...)}
+	}
+	if req.ThinkingBudget > 0 && !req.Thinking.IsOff() {
+		if out.Extras.Messages == nil {
+			out.Extras.Messages = &agentunified.MessagesExtras{}
+		}
+		out.Extras.Messages.ThinkingType = "enabled"
+		out.Extras.Messages.ThinkingBudgetTokens = req.ThinkingBudget
+	}
 	if req.RequestMeta != nil {
 		out.Metadata = &agentunified.RequestMetadata{User: req.RequestMeta.User, Metadata: cloneAnyMap(req.RequestMeta.Metadata)}
 	}
@@ -2,6 +2,7 @@ package providercore
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	completionsapi "github.com/codewandler/agentapis/api/completions"
@@ -11,13 +12,30 @@ import (
 	"github.com/codewandler/llm"
 )
 
-func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint llm.ApiType, requestedModel string) *agentclient.TypedClient[llm.Request, llm.Event] {
+func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint llm.ApiType, requestedModel string) (*agentclient.TypedClient[llm.Request, llm.Event], *retryAfterSink) {
 	baseURL := resolveBaseURL(c.cfg, c.opts)
 	path := c.cfg.BasePath
+	warnings := newWarningSink(c.cfg.ProviderName)
+	details := &usageDetailsSink{}
+	safety := &safetySink{}
+	builtinItems := &builtinItemSink{}
+	retryAfter := &retryAfterSink{}
+	upstream := &upstreamProviderSink{}
+	finish := &finishReasonSink{}
+	streamErr := &streamErrorSink{}
+	httpClient := tapHTTPClient(c.client, func(data []byte) {
+		warnings.scan(data)
+		details.scan(data)
+		safety.scan(data)
+		builtinItems.scan(data)
+		upstream.scan(data)
+		finish.scan(data)
+		streamErr.scan(data)
+	}, retryAfter.record)
 
 	messageOpts := []messagesapi.Option{
 		messagesapi.WithBaseURL(baseURL),
-		messagesapi.WithHTTPClient(c.client),
+		messagesapi.WithHTTPClient(httpClient),
 		messagesapi.WithErrorParser(c.cfg.ErrorParser),
 	}
 	if path != "" {
@@ -34,6 +52,11 @@ func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint
 		}),
 		messagesapi.WithRequestTransform(func(ctx context.Context, wire *messagesapi.Request) error {
 			if wire != nil && wire.Thinking != nil && wire.Thinking.Type == "adaptive" && wire.Temperature != 0 && wire.Temperature != 1 {
+				warnings.add(llm.WarningEvent{
+					Code:    llm.WarningParameterIgnored,
+					Message: fmt.Sprintf("temperature %g is not supported with adaptive thinking; using 1", wire.Temperature),
+					Param:   "temperature",
+				})
 				wire.Temperature = 1
 			}
 			if c.cfg.MessagesRequestTransform != nil {
@@ -51,7 +74,7 @@ func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint
 
 	completionsOpts := []completionsapi.Option{
 		completionsapi.WithBaseURL(baseURL),
-		completionsapi.WithHTTPClient(c.client),
+		completionsapi.WithHTTPClient(httpClient),
 		completionsapi.WithErrorParser(c.cfg.ErrorParser),
 	}
 	if path != "" {
@@ -82,7 +105,7 @@ func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint
 
 	responsesOpts := []responsesapi.Option{
 		responsesapi.WithBaseURL(baseURL),
-		responsesapi.WithHTTPClient(c.client),
+		responsesapi.WithHTTPClient(httpClient),
 		responsesapi.WithErrorParser(c.cfg.ErrorParser),
 	}
 	if path != "" {
@@ -98,6 +121,13 @@ func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint
 			return c.resolveHeaders(ctx, resolvedReq, apiHint)
 		}),
 		responsesapi.WithRequestTransform(func(ctx context.Context, wire *responsesapi.Request) error {
+			if len(resolvedReq.Stop) > 0 {
+				warnings.add(llm.WarningEvent{
+					Code:    llm.WarningParameterIgnored,
+					Message: "stop sequences are not supported by the Responses API",
+					Param:   "stop",
+				})
+			}
 			if c.cfg.ResponsesRequestTransform != nil {
 				return c.cfg.ResponsesRequestTransform(wire)
 			}
@@ -111,22 +141,45 @@ func (c *Client) buildAgentClient(originalReq, resolvedReq llm.Request, apiHint
 		}),
 	)
 
-	upstream := agentclient.NewMuxClient(
+	mux := agentclient.NewMuxClient(
 		agentclient.WithMessagesClient(agentclient.NewMessagesClient(messagesapi.NewClient(messageOpts...))),
 		agentclient.WithCompletionsClient(agentclient.NewCompletionsClient(completionsapi.NewClient(completionsOpts...))),
 		agentclient.WithResponsesClient(agentclient.NewResponsesClient(responsesapi.NewClient(responsesOpts...))),
 	)
 
-	return agentclient.NewTypedClient[llm.Request, llm.Event](upstream, llmBridgeBuilder{
+	typed := agentclient.NewTypedClient[llm.Request, llm.Event](mux, llmBridgeBuilder{
 		cfg:            c.cfg,
 		originalReq:    originalReq,
 		resolvedReq:    resolvedReq,
 		requestedModel: requestedModel,
 		resolvedAPI:    apiHint,
+		warnings:       warnings,
+		usageDetails:   details,
+		builtinItems:   builtinItems,
+		safety:         safety,
+		upstream:       upstream,
+		finish:         finish,
+		streamErr:      streamErr,
 	})
+	return typed, retryAfter
 }
 
+// resolveHeaders returns the per-request headers: the headers from
+// llm.WithHeader overlaid with the provider's authentication headers.
 func (c *Client) resolveHeaders(ctx context.Context, req llm.Request, apiHint llm.ApiType) (http.Header, error) {
+	auth, err := c.authHeaders(ctx, req, apiHint)
+	if err != nil || c.opts == nil || len(c.opts.Header) == 0 {
+		return auth, err
+	}
+	h := c.opts.Header.Clone()
+	for key, values := range auth {
+		h.Del(key)
+		h[key] = values
+	}
+	return h, nil
+}
+
+func (c *Client) authHeaders(ctx context.Context, req llm.Request, apiHint llm.ApiType) (http.Header, error) {
 	if c.cfg.HeaderFunc != nil {
 		return c.cfg.HeaderFunc(ctx, &req)
 	}
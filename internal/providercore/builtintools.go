@@ -0,0 +1,205 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	agentunified "github.com/codewandler/agentapis/api/unified"
+
+	"github.com/codewandler/llm"
+)
+
+// responsesBuiltinTools maps Responses output item types of provider-run
+// tools to the tool type reported on ToolProgressEvent and ToolResultEvent.
+var responsesBuiltinTools = map[string]string{
+	"web_search_call":       "web_search",
+	"file_search_call":      "file_search",
+	"code_interpreter_call": "code_interpreter",
+	"image_generation_call": "image_generation",
+	"mcp_call":              "mcp",
+}
+
+// responsesBuiltinInputs maps the streamed-input event prefixes of built-in
+// tools to their item type and the status reported for their fragments.
+var responsesBuiltinInputs = map[string][2]string{
+	"code_interpreter_call_code": {"code_interpreter_call", "code"},
+	"mcp_call_arguments":         {"mcp_call", "arguments"},
+}
+
+type responsesToolFrame struct {
+	OutputIndex *uint32         `json:"output_index"`
+	ItemID      string          `json:"item_id"`
+	Delta       string          `json:"delta"`
+	Item        json.RawMessage `json:"item"`
+}
+
+type responsesToolItem struct {
+	ID      string           `json:"id"`
+	Type    string           `json:"type"`
+	Status  string           `json:"status"`
+	Queries []string         `json:"queries"`
+	Results []map[string]any `json:"results"`
+	Code    string           `json:"code"`
+	Output  string           `json:"output"`
+	Action  *struct {
+		Query string `json:"query"`
+	} `json:"action"`
+	Outputs []struct {
+		Type string `json:"type"`
+		Logs string `json:"logs"`
+	} `json:"outputs"`
+}
+
+// builtinItemSink keeps the raw output items of provider-run tools from the
+// SSE payloads. The typed agentapis decoder drops item fields it does not
+// model, such as web_search actions and code_interpreter outputs.
+type builtinItemSink struct {
+	mu    sync.Mutex
+	items map[string]json.RawMessage
+}
+
+func (s *builtinItemSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"response.output_item.done"`)) {
+		return
+	}
+	var payload struct {
+		Item json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	var item struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload.Item, &item); err != nil || item.ID == "" {
+		return
+	}
+	if _, ok := responsesBuiltinTools[item.Type]; !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.items == nil {
+		s.items = map[string]json.RawMessage{}
+	}
+	s.items[item.ID] = payload.Item
+}
+
+// take returns and forgets the raw item with the given ID.
+func (s *builtinItemSink) take(id string) (json.RawMessage, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, ok := s.items[id]
+	delete(s.items, id)
+	return raw, ok
+}
+
+// responsesToolEvents projects the Responses events of provider-run tools
+// (web_search, file_search, code_interpreter, ...) to ToolProgressEvent and
+// ToolResultEvent. ok reports whether ev belonged to a built-in tool. Raw
+// items from items, when present, take precedence over the decoded event.
+func responsesToolEvents(ev agentunified.StreamEvent, items *builtinItemSink) (out []llm.Event, ok bool) {
+	name, found := strings.CutPrefix(ev.Extras.RawEventName, "response.")
+	if !found || len(ev.Extras.RawJSON) == 0 {
+		return nil, false
+	}
+	itemType, stage, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+
+	// Classify the event by name first so that the frames of text deltas
+	// and other unrelated events are never decoded.
+	if itemType == "output_item" {
+		if stage != "done" {
+			return nil, false
+		}
+		var frame responsesToolFrame
+		if err := json.Unmarshal(ev.Extras.RawJSON, &frame); err != nil {
+			return nil, false
+		}
+		var item responsesToolItem
+		if err := json.Unmarshal(frame.Item, &item); err != nil {
+			return nil, false
+		}
+		toolType, builtin := responsesBuiltinTools[item.Type]
+		if !builtin {
+			return nil, false
+		}
+		if raw, found := items.take(item.ID); found {
+			if err := json.Unmarshal(raw, &item); err == nil {
+				frame.Item = raw
+			}
+		}
+		return []llm.Event{toolResultEvent(toolType, frame, item)}, true
+	}
+
+	input, streamed := responsesBuiltinInputs[itemType]
+	toolType, builtin := responsesBuiltinTools[itemType]
+	switch {
+	case streamed && stage != "delta":
+		// The final input is part of the tool result.
+		return nil, true
+	case streamed:
+		toolType = responsesBuiltinTools[input[0]]
+	case !builtin:
+		return nil, false
+	case stage == "partial_image":
+		// Partial images are large base64 payloads; the final item carries
+		// the image.
+		return nil, true
+	}
+
+	var frame responsesToolFrame
+	if err := json.Unmarshal(ev.Extras.RawJSON, &frame); err != nil {
+		return nil, false
+	}
+	if streamed {
+		return []llm.Event{&llm.ToolProgressEvent{
+			ToolID:   frame.ItemID,
+			ToolType: toolType,
+			Status:   input[1],
+			Delta:    frame.Delta,
+			Index:    frame.OutputIndex,
+		}}, true
+	}
+	return []llm.Event{&llm.ToolProgressEvent{
+		ToolID:   frame.ItemID,
+		ToolType: toolType,
+		Status:   stage,
+		Index:    frame.OutputIndex,
+	}}, true
+}
+
+func toolResultEvent(toolType string, frame responsesToolFrame, item responsesToolItem) *llm.ToolResultEvent {
+	out := &llm.ToolResultEvent{
+		ToolID:   item.ID,
+		ToolType: toolType,
+		Status:   item.Status,
+		Index:    frame.OutputIndex,
+		Queries:  item.Queries,
+		Results:  item.Results,
+		Code:     item.Code,
+		Output:   item.Output,
+		Item:     frame.Item,
+	}
+	if item.Action != nil && item.Action.Query != "" && len(out.Queries) == 0 {
+		out.Queries = []string{item.Action.Query}
+	}
+	var logs []string
+	for _, o := range item.Outputs {
+		if o.Type == "logs" && o.Logs != "" {
+			logs = append(logs, o.Logs)
+		}
+	}
+	if len(logs) > 0 && out.Output == "" {
+		out.Output = strings.Join(logs, "\n")
+	}
+	return out
+}
@@ -65,7 +65,10 @@ func (c *Client) Stream(ctx context.Context, src llm.Buildable) (llm.Stream, err
 			requestedModel = resolvedReq.Model
 		}
 	}
-	if err := resolvedReq.Validate(); err != nil {
+	if err := resolvedReq.ValidateFor(c.cfg.ProviderName); err != nil {
+		if errors.Is(err, llm.ErrUnsupportedFeature) {
+			return nil, err
+		}
 		return nil, llm.NewErrBuildRequest(c.cfg.ProviderName, err)
 	}
 	apiHint := c.cfg.APIHint
@@ -76,39 +79,63 @@ func (c *Client) Stream(ctx context.Context, src llm.Buildable) (llm.Stream, err
 	}
 	pub, ch := llm.NewEventPublisher()
 	c.emitTokenEstimates(ctx, pub, resolvedReq, apiHint)
-	typed := c.buildAgentClient(originalReq, resolvedReq, apiHint, requestedModel)
+	typed, retryAfter := c.buildAgentClient(originalReq, resolvedReq, apiHint, requestedModel)
 	stream, streamErr := typed.Stream(ctx, originalReq)
 	if streamErr != nil {
+		mapErr := func() error {
+			return retryAfter.apply(mapAgentStreamError(c.cfg.ProviderName, c.cfg.ErrorParser != nil, streamErr))
+		}
 		if stream == nil {
 			pub.Close()
-			return nil, c.finalizeImmediateError(resolvedReq, streamErr)
+			return nil, retryAfter.apply(c.finalizeImmediateError(resolvedReq, streamErr))
 		}
 		status, hasStatus := agentclient.StatusCodeOf(streamErr)
 		action := HTTPErrorActionReturn
 		if hasStatus && c.cfg.ResolveHTTPErrorAction != nil {
-			action = c.cfg.ResolveHTTPErrorAction(resolvedReq, status, mapAgentStreamError(c.cfg.ProviderName, c.cfg.ErrorParser != nil, streamErr))
+			action = c.cfg.ResolveHTTPErrorAction(resolvedReq, status, mapErr())
 		}
 		go func() {
 			defer pub.Close()
-			forwardTypedStream(c.cfg.ProviderName, pub, stream)
+			forwardTypedStream(ctx, c.cfg.ProviderName, pub, stream)
 			if action == HTTPErrorActionStream {
-				pub.Error(llm.AsProviderError(c.cfg.ProviderName, mapAgentStreamError(c.cfg.ProviderName, c.cfg.ErrorParser != nil, streamErr)))
+				pub.Error(llm.AsProviderError(c.cfg.ProviderName, mapErr()))
 			}
 		}()
 		if action == HTTPErrorActionStream {
 			return ch, nil
 		}
-		return ch, mapAgentStreamError(c.cfg.ProviderName, c.cfg.ErrorParser != nil, streamErr)
+		return ch, mapErr()
 	}
 	go func() {
 		defer pub.Close()
-		forwardTypedStream(c.cfg.ProviderName, pub, stream)
+		forwardTypedStream(ctx, c.cfg.ProviderName, pub, stream)
 	}()
 	return ch, nil
 }
 
-func forwardTypedStream(provider string, pub llm.Publisher, stream <-chan agentclient.Result[llm.Event]) {
-	for item := range stream {
+// forwardTypedStream publishes events from stream until it ends or ctx is
+// cancelled. Cancellation is observed while waiting for the next event, so
+// an idle upstream cannot hold the stream open; the remaining items are
+// drained in the background.
+func forwardTypedStream(ctx context.Context, provider string, pub llm.Publisher, stream <-chan agentclient.Result[llm.Event]) {
+	for {
+		var (
+			item agentclient.Result[llm.Event]
+			ok   bool
+		)
+		select {
+		case item, ok = <-stream:
+		case <-ctx.Done():
+			pub.Error(llm.NewErrContextCancelled(provider, ctx.Err()))
+			go func() {
+				for range stream {
+				}
+			}()
+			return
+		}
+		if !ok {
+			return
+		}
 		if item.Err != nil {
 			pub.Error(llm.AsProviderError(provider, mapAgentStreamError(provider, false, item.Err)))
 			return
@@ -129,7 +156,12 @@ func mapAgentStreamError(provider string, customParser bool, err error) error {
 		if customParser && statusErr.Err != nil {
 			return statusErr.Err
 		}
-		return llm.NewErrAPIError(provider, statusErr.StatusCode, string(statusErr.Body))
+		apiErr := llm.NewErrAPIError(provider, statusErr.StatusCode, string(statusErr.Body))
+		apiErr.Safety = safetyFromErrorBody(provider, statusErr.Body)
+		if apiErr.Safety != nil {
+			apiErr.Kind = llm.ErrContentFiltered
+		}
+		return apiErr
 	}
 	var provErr *llm.ProviderError
 	if errors.As(err, &provErr) {
@@ -172,7 +204,7 @@ func (c *Client) emitTokenEstimates(ctx context.Context, pub llm.Publisher, req
 		if err != nil || count == nil {
 			return
 		}
-		for _, rec := range tokencount.EstimateRecords(count, c.cfg.ProviderName, req.Model, "api", usage.Default()) {
+		for _, rec := range tokencount.EstimateRecords(count, c.cfg.ProviderName, req.Model, "api", c.cfg.costCalculator()) {
 			pub.TokenEstimate(rec)
 		}
 		return
@@ -187,10 +219,14 @@ func applyDefaults(cfg *clientConfig) {
 }
 
 func resolveHTTPClient(opts *llm.Options) *http.Client {
+	client := llm.DefaultHttpClient()
 	if opts != nil && opts.HTTPClient != nil {
-		return opts.HTTPClient
+		client = opts.HTTPClient
+	}
+	if opts != nil && opts.Retry != nil {
+		client = llm.RetryHTTPClient(client, *opts.Retry)
 	}
-	return llm.DefaultHttpClient()
+	return client
 }
 
 func resolveBaseURL(cfg clientConfig, opts *llm.Options) string {
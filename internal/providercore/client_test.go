@@ -10,7 +10,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +20,7 @@ import (
 	"github.com/codewandler/llm"
 	"github.com/codewandler/llm/msg"
 	"github.com/codewandler/llm/tokencount"
+	"github.com/codewandler/llm/tool"
 	"github.com/codewandler/llm/usage"
 )
 
@@ -207,6 +210,39 @@ func TestClientStream_HeaderFuncMissingKey(t *testing.T) {
 	assert.ErrorIs(t, err, llm.ErrMissingAPIKey)
 }
 
+func TestClientStream_RejectsUnsupportedFeatures(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := New(clientConfig{
+		ProviderName: "openai",
+		BaseURL:      srv.URL,
+		APIHint:      llm.ApiTypeOpenAIChatCompletion,
+	})
+	for name, req := range map[string]llm.Request{
+		"tools": {
+			Model:    "gpt-4o-search-preview",
+			Messages: llm.Messages{llm.User("hi")},
+			Tools:    []tool.Definition{{Name: "lookup", Parameters: map[string]any{"type": "object"}}},
+		},
+		"thinking": {
+			Model:    "gpt-4o-mini-2024-07-18",
+			Messages: llm.Messages{llm.User("hi")},
+			Thinking: llm.ThinkingOn,
+		},
+	} {
+		_, err := client.Stream(context.Background(), req)
+		require.ErrorIs(t, err, llm.ErrUnsupportedFeature, name)
+	}
+	assert.Zero(t, hits.Load(), "nothing is sent")
+}
+
 func TestClientStream_ErrorParser(t *testing.T) {
 	t.Parallel()
 
@@ -289,6 +325,29 @@ func TestClientStream_HTTPErrorActionStream(t *testing.T) {
 	assert.True(t, sawError)
 }
 
+func TestClientStream_RateLimitedErrorCarriesRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":{"message":"slow down","code":"rate_limit_exceeded"}}`)
+	}))
+	defer server.Close()
+
+	client := New(clientConfig{ProviderName: "test", BaseURL: server.URL, APIHint: llm.ApiTypeOpenAIChatCompletion}, llm.WithBaseURL(server.URL))
+	_, err := client.Stream(context.Background(), llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrRateLimited)
+
+	var pe *llm.ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "rate_limit_exceeded", pe.Code)
+	assert.Equal(t, 30*time.Second, pe.RetryAfter)
+}
+
 func TestClientStream_MessagesAPITokenCounterEmitsAdditionalEstimate(t *testing.T) {
 	t.Parallel()
 
@@ -696,3 +755,530 @@ func TestClientStream_AdaptiveThinkingCoercesTemperature(t *testing.T) {
 	require.True(t, ok, "expected temperature in request body")
 	assert.Equal(t, 1.0, temp, "adaptive thinking should coerce temperature to 1")
 }
+
+func TestClientStream_RetriesTransientHTTPErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	cfg := clientConfig{
+		ProviderName: "test",
+		BaseURL:      server.URL,
+		APIHint:      llm.ApiTypeOpenAIChatCompletion,
+	}
+	client := New(cfg, llm.WithRetry(llm.RetryOptions{MaxAttempts: 2}))
+	stream, err := client.Stream(context.Background(), llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	res := llm.ProcessEvents(context.Background(), stream)
+	require.NoError(t, res.Error())
+	assert.Equal(t, "ok", res.Text())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClientStream_SurfacesUpstreamWarnings(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Sunset", "Wed, 01 Jul 2026 00:00:00 GMT")
+		_, _ = io.WriteString(w,
+			"data: {\"id\":\"c1\",\"warnings\":[{\"code\":\"unsupported_parameter\",\"message\":\"top_k is not supported\",\"param\":\"top_k\"}],\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n"+
+				"data: {\"id\":\"c1\",\"warning\":\"routed to fallback model\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"+
+				"data: [DONE]\n\n",
+		)
+	}))
+	defer server.Close()
+
+	client := New(clientConfig{
+		ProviderName: "test",
+		BaseURL:      server.URL,
+		APIHint:      llm.ApiTypeOpenAIChatCompletion,
+	})
+	stream, err := client.Stream(context.Background(), llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+
+	res := llm.ProcessEvents(context.Background(), stream)
+	require.NoError(t, res.Error())
+	assert.Equal(t, "ok", res.Text())
+
+	warnings := res.Warnings()
+	require.Len(t, warnings, 3)
+	assert.Equal(t, llm.WarningDeprecated, warnings[0].Code)
+	assert.Contains(t, warnings[0].Message, "sunset")
+	assert.Equal(t, llm.WarningEvent{Code: llm.WarningParameterIgnored, Message: "top_k is not supported", Param: "top_k", Provider: "test"}, warnings[1])
+	assert.Equal(t, llm.WarningEvent{Code: llm.WarningUpstream, Message: "routed to fallback model", Provider: "test"}, warnings[2])
+}
+
+func TestClientStream_AdaptiveThinkingTemperatureWarning(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w,
+			"event: message_start\ndata: {\"message\":{\"id\":\"m1\",\"model\":\"claude-sonnet-4-6\",\"usage\":{\"input_tokens\":1}}}\n\n"+
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+		)
+	}))
+	defer server.Close()
+
+	client := New(clientConfig{
+		ProviderName: "test",
+		BaseURL:      server.URL,
+		APIHint:      llm.ApiTypeAnthropicMessages,
+	})
+	stream, err := client.Stream(context.Background(), llm.Request{
+		Model:       "claude-sonnet-4-6",
+		Messages:    msg.BuildTranscript(msg.User("hi")),
+		Temperature: 0.7,
+	})
+	require.NoError(t, err)
+
+	res := llm.ProcessEvents(context.Background(), stream)
+	require.Len(t, res.Warnings(), 1)
+	assert.Equal(t, llm.WarningParameterIgnored, res.Warnings()[0].Code)
+	assert.Equal(t, "temperature", res.Warnings()[0].Param)
+}
+
+func TestParseWarningFields(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, parseWarningFields([]byte(`{"id":"x"}`)))
+	assert.Nil(t, parseWarningFields([]byte(`not json`)))
+	assert.Equal(t, []llm.WarningEvent{{Code: llm.WarningDeprecated, Message: "model gpt-old is deprecated"}},
+		parseWarningFields([]byte(`{"warning":{"type":"model_deprecated","message":"model gpt-old is deprecated"}}`)))
+	assert.Equal(t, []llm.WarningEvent{{Code: "custom_notice", Message: "hi"}},
+		parseWarningFields([]byte(`{"warnings":[{"code":"custom_notice","message":"hi"}]}`)))
+	assert.Equal(t, "model is deprecated", warningHeaderText(`299 - "model is deprecated"`))
+}
+
+func TestClientStream_UsageDetails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w,
+			"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n"+
+				"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],"+
+				"\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":3,\"total_tokens\":13,\"cost\":0.0012,\"is_byok\":false,"+
+				"\"prompt_tokens_details\":{\"cached_tokens\":0}},"+
+				"\"x_groq\":{\"usage\":{\"queue_time\":0.02,\"total_time\":0.1}}}\n\n"+
+				"data: [DONE]\n\n",
+		)
+	}))
+	defer server.Close()
+
+	client := New(clientConfig{
+		ProviderName: "test",
+		BaseURL:      server.URL,
+		APIHint:      llm.ApiTypeOpenAIChatCompletion,
+	})
+	stream, err := client.Stream(context.Background(), llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+
+	res := llm.ProcessEvents(context.Background(), stream)
+	require.NoError(t, res.Error())
+	require.Len(t, res.UsageRecords(), 1)
+
+	details := res.UsageRecords()[0].Details
+	assert.Equal(t, 0.0012, details["cost"])
+	assert.Equal(t, false, details["is_byok"])
+	assert.Equal(t, map[string]any{"cached_tokens": float64(0)}, details["prompt_tokens_details"])
+	assert.Equal(t, map[string]any{"queue_time": 0.02, "total_time": 0.1}, details["x_groq"])
+	assert.NotContains(t, details, "prompt_tokens")
+	assert.NotContains(t, details, "total_tokens")
+}
+
+func TestUsageDetailsSink_MergesAnthropicEvents(t *testing.T) {
+	t.Parallel()
+
+	var s usageDetailsSink
+	s.scan([]byte(`{"type":"message_start","message":{"usage":{"input_tokens":5,"cache_creation":{"ephemeral_5m_input_tokens":0,"ephemeral_1h_input_tokens":0},"service_tier":"standard"}}}`))
+	s.scan([]byte(`{"type":"message_delta","usage":{"output_tokens":7,"cache_creation":{"ephemeral_5m_input_tokens":0,"ephemeral_1h_input_tokens":120},"server_tool_use":null}}`))
+	s.scan([]byte(`{"type":"content_block_delta","delta":{"text":"no usage here"}}`))
+
+	details := s.take()
+	assert.Equal(t, "standard", details["service_tier"])
+	assert.Equal(t, map[string]any{"ephemeral_5m_input_tokens": float64(0), "ephemeral_1h_input_tokens": float64(120)}, details["cache_creation"])
+	assert.NotContains(t, details, "server_tool_use")
+	assert.NotContains(t, details, "input_tokens")
+	assert.Nil(t, s.take())
+}
+
+func TestFinishReasonSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		events []string
+		want   string
+	}{
+		{"completions", []string{
+			`{"choices":[{"index":0,"delta":{"content":"hi"},"finish_reason":null}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":1}}`,
+		}, "length"},
+		{"messages", []string{
+			`{"type":"message_start","message":{"stop_reason":null}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"refusal"}}`,
+		}, "refusal"},
+		{"responses incomplete", []string{
+			`{"type":"response.created","response":{"status":"in_progress"}}`,
+			`{"type":"response.incomplete","response":{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}`,
+		}, "max_output_tokens"},
+		{"responses completed", []string{
+			`{"type":"response.completed","response":{"status":"completed"}}`,
+		}, "completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s finishReasonSink
+			for _, ev := range tt.events {
+				s.scan([]byte(ev))
+			}
+			assert.Equal(t, tt.want, s.get())
+		})
+	}
+}
+
+func TestStreamErrorSink(t *testing.T) {
+	t.Parallel()
+
+	var s streamErrorSink
+	s.scan([]byte(`{"choices":[{"index":0,"delta":{"content":"hi"}}]}`))
+	assert.Nil(t, s.take("openai"))
+
+	s.scan([]byte(`{"error":{"message":"upstream overloaded","type":"server_error","code":502}}`))
+	err := s.take("openrouter")
+	var pe *llm.ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.ErrorIs(t, err, llm.ErrProviderError)
+	assert.Equal(t, "openrouter", pe.Provider)
+	assert.Equal(t, "server_error: upstream overloaded", pe.Message)
+	assert.Equal(t, "502", pe.Code)
+	assert.Nil(t, s.take("openrouter"))
+}
+
+func TestSSETapReader_SkipsOversizedLines(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	body := strings.Repeat("x", maxTapLine+10) + "\ndata: {\"a\":1}\n"
+	r := &sseTapReader{ReadCloser: io.NopCloser(strings.NewReader(body)), onData: func(d []byte) { got = append(got, string(d)) }}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(out))
+	assert.Equal(t, []string{`{"a":1}`}, got)
+}
+
+func TestClientStream_StopSequencesWireMapping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		apiHint llm.ApiType
+		body    string
+		field   string
+		warn    bool
+	}{
+		{
+			name:    "messages",
+			apiHint: llm.ApiTypeAnthropicMessages,
+			body: "event: message_start\ndata: {\"message\":{\"id\":\"m1\",\"model\":\"m\",\"usage\":{\"input_tokens\":1}}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			field: "stop_sequences",
+		},
+		{
+			name:    "completions",
+			apiHint: llm.ApiTypeOpenAIChatCompletion,
+			body:    "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n",
+			field:   "stop",
+		},
+		{
+			name:    "responses",
+			apiHint: llm.ApiTypeOpenAIResponses,
+			body: "event: response.created\ndata: {\"response\":{\"id\":\"r1\",\"model\":\"m\"}}\n\n" +
+				"event: response.completed\ndata: {\"response\":{\"id\":\"r1\",\"model\":\"m\",\"status\":\"completed\"}}\n\n",
+			warn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotBody map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				bodyBytes, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal(bodyBytes, &gotBody))
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := New(clientConfig{ProviderName: "test", BaseURL: server.URL, APIHint: tt.apiHint})
+			stream, err := client.Stream(context.Background(), llm.Request{
+				Model:    "m",
+				Messages: msg.BuildTranscript(msg.User("hi")),
+				Stop:     []string{"END", "\n\n"},
+			})
+			require.NoError(t, err)
+			res := llm.ProcessEvents(context.Background(), stream)
+
+			if tt.warn {
+				require.Len(t, res.Warnings(), 1)
+				assert.Equal(t, "stop", res.Warnings()[0].Param)
+				assert.NotContains(t, gotBody, "stop")
+				return
+			}
+			assert.Empty(t, res.Warnings())
+			assert.Equal(t, []any{"END", "\n\n"}, gotBody[tt.field])
+		})
+	}
+}
+
+func TestClientStream_CancelWhileIdle(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		hint  llm.ApiType
+		first string
+	}{
+		{
+			name:  "completions",
+			hint:  llm.ApiTypeOpenAIChatCompletion,
+			first: `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"hello"}}]}` + "\n\n",
+		},
+		{
+			name: "messages",
+			hint: llm.ApiTypeAnthropicMessages,
+			first: "event: message_start\n" +
+				`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}` + "\n\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = io.WriteString(w, tc.first)
+				w.(http.Flusher).Flush()
+				// Stay idle, ignoring the client going away.
+				<-release
+			}))
+			defer server.Close()
+			defer close(release)
+
+			var cfg clientConfig
+			cfg.ApplyOptions(
+				WithProviderName("test-provider"),
+				WithBaseURL(server.URL),
+				WithAPIHint(tc.hint),
+			)
+			client := New(cfg, llm.WithBaseURL(server.URL))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, err := client.Stream(ctx, llm.Request{
+				Model:    "m",
+				Messages: llm.Messages{llm.User("hi")},
+			})
+			require.NoError(t, err)
+
+			var gotErr *llm.ErrorEvent
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for env := range stream {
+					if ev, ok := env.Data.(*llm.StreamStartedEvent); ok && ev != nil {
+						cancel()
+					}
+					if ev, ok := env.Data.(*llm.ErrorEvent); ok {
+						gotErr = ev
+					}
+				}
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("stream did not terminate after cancellation")
+			}
+			require.NotNil(t, gotErr)
+			assert.ErrorIs(t, gotErr.Error, llm.ErrContextCancelled)
+		})
+	}
+}
+
+func streamFromBody(t *testing.T, hint llm.ApiType, status int, body string) (llm.Result, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	client := New(clientConfig{ProviderName: "test", BaseURL: server.URL, APIHint: hint}, llm.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	if err != nil {
+		return nil, err
+	}
+	return llm.NewEventProcessor(context.Background(), stream).Result(), nil
+}
+
+func TestClientStream_ContentFilterSafetyBlock(t *testing.T) {
+	t.Parallel()
+
+	res, err := streamFromBody(t, llm.ApiTypeOpenAIChatCompletion, http.StatusOK, strings.Join([]string{
+		`data: {"id":"c1","choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}]}`,
+		"",
+		`data: {"id":"c1","choices":[{"index":0,"delta":{"content":"I"},"content_filter_results":{"hate":{"filtered":false,"severity":"safe"},"violence":{"filtered":true,"severity":"high"}}}]}`,
+		"",
+		`data: {"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"content_filter","content_filter_results":{"violence":{"filtered":true,"severity":"high"}}}]}`,
+		"",
+		"data: [DONE]",
+		"",
+	}, "\n"))
+	require.NoError(t, err)
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, llm.StopReasonContentFilter, res.StopReason())
+	require.NotNil(t, res.Safety())
+	assert.Equal(t, &llm.SafetyBlock{
+		Provider:   "test",
+		Stage:      llm.SafetyStageOutput,
+		Reason:     "content_filter",
+		Categories: []llm.SafetyCategory{{Name: "violence", Severity: "high", Blocked: true}},
+	}, res.Safety())
+}
+
+func TestClientStream_AnthropicRefusalIsContentFilter(t *testing.T) {
+	t.Parallel()
+
+	res, err := streamFromBody(t, llm.ApiTypeAnthropicMessages, http.StatusOK,
+		"event: message_start\n"+
+			`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}`+"\n\n"+
+			"event: message_delta\n"+
+			`data: {"type":"message_delta","delta":{"stop_reason":"refusal"},"usage":{"output_tokens":1}}`+"\n\n"+
+			"event: message_stop\n"+
+			`data: {"type":"message_stop"}`+"\n\n")
+	require.NoError(t, err)
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, llm.StopReasonContentFilter, res.StopReason())
+	require.NotNil(t, res.Safety())
+	assert.Equal(t, "refusal", res.Safety().Reason)
+	assert.Equal(t, llm.SafetyStageOutput, res.Safety().Stage)
+}
+
+func TestClientStream_PromptRejectedByContentFilter(t *testing.T) {
+	t.Parallel()
+
+	_, err := streamFromBody(t, llm.ApiTypeOpenAIChatCompletion, http.StatusBadRequest,
+		`{"error":{"code":"content_filter","message":"The prompt was filtered.","innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{"self_harm":{"filtered":true,"severity":"medium"},"hate":{"filtered":false,"severity":"safe"}}}}}`)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrAPIError)
+
+	sb := llm.SafetyBlockOf(err)
+	require.NotNil(t, sb)
+	assert.Equal(t, llm.SafetyStageInput, sb.Stage)
+	assert.Equal(t, "The prompt was filtered.", sb.Message)
+	assert.Equal(t, []llm.SafetyCategory{{Name: "self_harm", Severity: "medium", Blocked: true}}, sb.Categories)
+}
+
+func TestClientStream_ResponsesBuiltinToolEvents(t *testing.T) {
+	t.Parallel()
+
+	sse := func(event, data string) string { return "event: " + event + "\ndata: " + data + "\n\n" }
+	body := sse("response.created", `{"type":"response.created","response":{"id":"r1","model":"m"}}`) +
+		sse("response.output_item.added", `{"type":"response.output_item.added","output_index":0,"item":{"id":"ws_1","type":"web_search_call","status":"in_progress"}}`) +
+		sse("response.web_search_call.in_progress", `{"type":"response.web_search_call.in_progress","output_index":0,"item_id":"ws_1"}`) +
+		sse("response.web_search_call.searching", `{"type":"response.web_search_call.searching","output_index":0,"item_id":"ws_1"}`) +
+		sse("response.web_search_call.completed", `{"type":"response.web_search_call.completed","output_index":0,"item_id":"ws_1"}`) +
+		sse("response.output_item.done", `{"type":"response.output_item.done","output_index":0,"item":{"id":"ws_1","type":"web_search_call","status":"completed","action":{"type":"search","query":"go release"}}}`) +
+		sse("response.code_interpreter_call_code.delta", `{"type":"response.code_interpreter_call_code.delta","output_index":1,"item_id":"ci_1","delta":"print(1)"}`) +
+		sse("response.code_interpreter_call_code.done", `{"type":"response.code_interpreter_call_code.done","output_index":1,"item_id":"ci_1","code":"print(1)"}`) +
+		sse("response.output_item.done", `{"type":"response.output_item.done","output_index":1,"item":{"id":"ci_1","type":"code_interpreter_call","status":"completed","code":"print(1)","container_id":"c1","outputs":[{"type":"logs","logs":"1"}]}}`) +
+		sse("response.output_text.delta", `{"type":"response.output_text.delta","output_index":2,"content_index":0,"item_id":"msg_1","delta":"Done"}`) +
+		sse("response.completed", `{"type":"response.completed","response":{"id":"r1","model":"m","status":"completed","usage":{"input_tokens":5,"output_tokens":2}}}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	client := New(clientConfig{ProviderName: "test", BaseURL: server.URL, APIHint: llm.ApiTypeOpenAIResponses}, llm.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), llm.Request{Model: "m", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+
+	var progress []*llm.ToolProgressEvent
+	var results []*llm.ToolResultEvent
+	var text strings.Builder
+	for env := range stream {
+		switch ev := env.Data.(type) {
+		case *llm.ToolProgressEvent:
+			progress = append(progress, ev)
+		case *llm.ToolResultEvent:
+			results = append(results, ev)
+		case *llm.DeltaEvent:
+			text.WriteString(ev.Text)
+		case *llm.ErrorEvent:
+			t.Fatalf("unexpected error: %v", ev.Error)
+		}
+	}
+
+	require.Len(t, progress, 4)
+	assert.Equal(t, []string{"in_progress", "searching", "completed", "code"},
+		[]string{progress[0].Status, progress[1].Status, progress[2].Status, progress[3].Status})
+	assert.Equal(t, "web_search", progress[0].ToolType)
+	assert.Equal(t, "ws_1", progress[0].ToolID)
+	assert.Equal(t, "code_interpreter", progress[3].ToolType)
+	assert.Equal(t, "print(1)", progress[3].Delta)
+	require.NotNil(t, progress[3].Index)
+	assert.Equal(t, uint32(1), *progress[3].Index)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "ws_1", results[0].ToolID)
+	assert.Equal(t, "web_search", results[0].ToolType)
+	assert.Equal(t, "completed", results[0].Status)
+	assert.Equal(t, []string{"go release"}, results[0].Queries)
+	assert.Contains(t, string(results[0].Item), `"action"`)
+	assert.Equal(t, "code_interpreter", results[1].ToolType)
+	assert.Equal(t, "print(1)", results[1].Code)
+	assert.Equal(t, "1", results[1].Output)
+
+	assert.Equal(t, "Done", text.String())
+}
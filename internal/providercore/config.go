@@ -10,6 +10,7 @@ import (
 	responsesapi "github.com/codewandler/agentapis/api/responses"
 	"github.com/codewandler/llm"
 	"github.com/codewandler/llm/tokencount"
+	"github.com/codewandler/llm/usage"
 )
 
 type MessagesRequest = messagesapi.Request
@@ -202,6 +203,15 @@ func WithUsageExtras(fn func(*http.Response) map[string]any) Option {
 	}
 }
 
+// WithCostCalculator sets the provider-local overrides consulted before the
+// model catalog when pricing usage records (see usage.ForProvider).
+func WithCostCalculator(c usage.CostCalculator) Option {
+	return Option{
+		applyCC: func(cfg *clientConfig) { cfg.CostCalculator = c },
+		applyO:  func(o *Options) { o.costCalculator = c },
+	}
+}
+
 func WithBasePath(path string) Option {
 	return Option{
 		applyCC: func(cfg *clientConfig) { cfg.BasePath = path },
@@ -226,6 +236,7 @@ type clientConfig struct {
 	ResolveHTTPErrorAction func(req llm.Request, statusCode int, apiErr error) HTTPErrorAction
 	RateLimitParser        func(*http.Response) *llm.RateLimits
 	UsageExtras            func(*http.Response) map[string]any
+	CostCalculator         usage.CostCalculator
 
 	HeaderFunc     func(ctx context.Context, req *llm.Request) (http.Header, error)
 	MutateRequest  func(r *http.Request)
@@ -253,6 +264,15 @@ func (cfg clientConfig) Validate() error {
 	return nil
 }
 
+// costCalculator returns the configured calculator composed with
+// usage.Default.
+func (cfg clientConfig) costCalculator() usage.CostCalculator {
+	if cfg.CostCalculator == nil {
+		return usage.ForProvider()
+	}
+	return usage.ForProvider(cfg.CostCalculator)
+}
+
 func (cfg *clientConfig) ApplyOptions(opts ...Option) {
 	for _, opt := range opts {
 		opt.applyToClientConfig(cfg)
@@ -286,6 +306,7 @@ type Options struct {
 
 	messagesAPITokenCounter func(ctx context.Context, req llm.Request, wire *MessagesRequest) (*tokencount.TokenCount, error)
 	usageExtras             func(*http.Response) map[string]any
+	costCalculator          usage.CostCalculator
 }
 
 func NewOptions(opts ...Option) Options {
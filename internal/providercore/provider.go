@@ -56,7 +56,19 @@ func (p *Provider) Models() llm.Models {
 }
 
 func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
-	return p.client.Stream(ctx, src)
+	if p.llmOpts.Telemetry == nil && p.llmOpts.RateLimiter == nil {
+		return p.client.Stream(ctx, src)
+	}
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(p.Name(), err)
+	}
+	info := llm.StreamInfo{Provider: p.Name(), Model: req.Model}
+	return p.llmOpts.RateLimiter.Stream(ctx, p.Name(), req, func(ctx context.Context) (llm.Stream, error) {
+		return llm.InstrumentStream(ctx, p.llmOpts.Telemetry, info, func(ctx context.Context) (llm.Stream, error) {
+			return p.client.Stream(ctx, req)
+		})
+	})
 }
 
 func (p *Provider) Options() *llm.Options { return p.llmOpts }
@@ -89,6 +101,7 @@ func optsToClientConfig(o Options, llmOpts *llm.Options) clientConfig {
 		ResolveHTTPErrorAction:      o.resolveHTTPErrorAction,
 		RateLimitParser:             o.rateLimitParser,
 		UsageExtras:                 o.usageExtras,
+		CostCalculator:              o.costCalculator,
 		MutateRequest:               o.mutateRequest,
 		ResolveAPIHint:              o.resolveAPIHint,
 		PreprocessRequest:           o.preprocessRequest,
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -158,6 +160,52 @@ func TestProvider_CreateStream(t *testing.T) {
 	assert.True(t, sawCompleted)
 }
 
+func TestProvider_CreateStream_Telemetry(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"chatcmpl-1","model":"gpt-test-0613","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`+"\n\n"+
+			`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`+"\n\n"+
+			"data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	type key struct{}
+	stats := make(chan llm.StreamStats, 1)
+	var sawCtx bool
+	telemetry := llm.TelemetryFunc(func(ctx context.Context, info llm.StreamInfo) (context.Context, func(llm.StreamStats)) {
+		assert.Equal(t, llm.StreamInfo{Provider: "test", Model: "gpt-test"}, info)
+		return context.WithValue(ctx, key{}, true), func(s llm.StreamStats) { stats <- s }
+	})
+
+	p := NewProvider(NewOptions(
+		WithProviderName("test"),
+		WithBaseURL(server.URL),
+		WithAPIHint(llm.ApiTypeOpenAIChatCompletion),
+		WithModels(llm.Models{{ID: "gpt-test"}}),
+		WithMutateRequest(func(r *http.Request) { sawCtx = r.Context().Value(key{}) == true }),
+	), llm.WithBaseURL(server.URL), llm.WithTelemetry(telemetry))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "gpt-test",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	s := <-stats
+	assert.True(t, sawCtx, "request should use the telemetry context")
+	assert.Equal(t, llm.StopReasonEndTurn, s.StopReason)
+	assert.Equal(t, "gpt-test-0613", s.ResolvedModel)
+	assert.Equal(t, 5, s.Tokens.TotalInput())
+	assert.Equal(t, 1, s.Tokens.TotalOutput())
+	assert.Positive(t, s.TimeToFirstToken)
+	assert.GreaterOrEqual(t, s.Duration, s.TimeToFirstToken)
+	assert.NoError(t, s.Err)
+}
+
 func TestProvider_Options(t *testing.T) {
 	t.Parallel()
 
@@ -186,3 +234,35 @@ func TestOption_AppliesToBothTargets(t *testing.T) {
 	o := NewOptions(opt)
 	assert.Equal(t, "dual-test", o.providerName)
 }
+
+func TestProvider_CreateStream_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`+"\n\n"+
+			"data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider(NewOptions(
+		WithProviderName("test"),
+		WithBaseURL(server.URL),
+		WithAPIHint(llm.ApiTypeOpenAIChatCompletion),
+		WithModels(llm.Models{{ID: "gpt-test"}}),
+	), llm.WithBaseURL(server.URL), llm.WithRateLimit(llm.NewRateLimiter(llm.RateLimit{RequestsPerMinute: 1})))
+
+	req := llm.Request{Model: "gpt-test", Messages: llm.Messages{llm.User("hi")}}
+	stream, err := p.CreateStream(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.CreateStream(ctx, req)
+	require.ErrorIs(t, err, llm.ErrContextCancelled)
+	assert.Equal(t, int32(1), hits.Load(), "the limited request must not reach the server")
+}
@@ -0,0 +1,112 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// upstreamProviderSink records the upstream provider that routing APIs
+// such as OpenRouter name in their SSE payloads: top-level "provider" on
+// Chat Completions chunks, or inside "response" and "message" objects.
+type upstreamProviderSink struct {
+	mu       sync.Mutex
+	provider string
+}
+
+func (s *upstreamProviderSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"provider"`)) {
+		return
+	}
+	var payload struct {
+		Provider string `json:"provider"`
+		Response struct {
+			Provider string `json:"provider"`
+		} `json:"response"`
+		Message struct {
+			Provider string `json:"provider"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	for _, p := range []string{payload.Provider, payload.Response.Provider, payload.Message.Provider} {
+		if p != "" {
+			s.mu.Lock()
+			s.provider = p
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// get returns the recorded provider, or "" if none was seen.
+func (s *upstreamProviderSink) get() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.provider
+}
+
+// finishReasonSink records the provider's raw finish reason, before it is
+// normalized to an llm.StopReason: "finish_reason" on Chat Completions
+// choices, "stop_reason" on Messages deltas, and the incomplete reason or
+// final status of a Responses response.
+type finishReasonSink struct {
+	mu     sync.Mutex
+	reason string
+}
+
+func (s *finishReasonSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"finish_reason"`)) &&
+		!bytes.Contains(data, []byte(`"stop_reason"`)) &&
+		!bytes.Contains(data, []byte(`"response"`)) {
+		return
+	}
+	var payload struct {
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Delta struct {
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"`
+		Response struct {
+			Status            string `json:"status"`
+			IncompleteDetails struct {
+				Reason string `json:"reason"`
+			} `json:"incomplete_details"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	reason := payload.Delta.StopReason
+	for _, c := range payload.Choices {
+		if c.FinishReason != "" {
+			reason = c.FinishReason
+		}
+	}
+	if r := payload.Response; r.IncompleteDetails.Reason != "" {
+		reason = r.IncompleteDetails.Reason
+	} else if r.Status == "completed" || r.Status == "incomplete" || r.Status == "failed" {
+		reason = r.Status
+	}
+	if reason == "" {
+		return
+	}
+	s.mu.Lock()
+	s.reason = reason
+	s.mu.Unlock()
+}
+
+// get returns the recorded finish reason, or "" if none was seen.
+func (s *finishReasonSink) get() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
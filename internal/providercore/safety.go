@@ -0,0 +1,124 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/codewandler/llm"
+)
+
+// stopReasonRefusal is Anthropic's stop reason for declined requests. The
+// Messages adapter passes it through unmapped.
+const stopReasonRefusal llm.StopReason = "refusal"
+
+// safetySink collects content filter annotations from raw SSE payloads so a
+// content_filter stop can carry the categories that triggered it. Azure
+// OpenAI and compatible servers report them per choice
+// ("content_filter_results") and per prompt ("prompt_filter_results").
+type safetySink struct {
+	mu         sync.Mutex
+	categories []llm.SafetyCategory
+	seen       map[string]struct{}
+	output     bool
+}
+
+type contentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity"`
+}
+
+func (s *safetySink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`filter_results"`)) {
+		return
+	}
+	var payload struct {
+		PromptFilterResults []struct {
+			ContentFilterResults map[string]json.RawMessage `json:"content_filter_results"`
+		} `json:"prompt_filter_results"`
+		Choices []struct {
+			ContentFilterResults map[string]json.RawMessage `json:"content_filter_results"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range payload.PromptFilterResults {
+		s.add(p.ContentFilterResults, false)
+	}
+	for _, c := range payload.Choices {
+		s.add(c.ContentFilterResults, true)
+	}
+}
+
+// add records the filtered categories of one result set. Categories that
+// passed are not kept: providers repeat them on every chunk.
+func (s *safetySink) add(results map[string]json.RawMessage, output bool) {
+	for name, raw := range results {
+		var r contentFilterResult
+		if json.Unmarshal(raw, &r) != nil || !r.Filtered {
+			continue
+		}
+		if _, dup := s.seen[name]; dup {
+			continue
+		}
+		if s.seen == nil {
+			s.seen = make(map[string]struct{})
+		}
+		s.seen[name] = struct{}{}
+		s.categories = append(s.categories, llm.SafetyCategory{Name: name, Severity: r.Severity, Blocked: true})
+		s.output = s.output || output
+	}
+}
+
+// completed returns the completed event for stop, normalising Anthropic
+// refusals to StopReasonContentFilter and attaching a SafetyBlock to
+// content filter stops.
+func (s *safetySink) completed(provider string, stop llm.StopReason) llm.CompletedEvent {
+	if stop != llm.StopReasonContentFilter && stop != stopReasonRefusal {
+		return llm.CompletedEvent{StopReason: stop}
+	}
+	block := &llm.SafetyBlock{Provider: provider, Stage: llm.SafetyStageOutput, Reason: string(stop)}
+	if s != nil {
+		s.mu.Lock()
+		block.Categories = append([]llm.SafetyCategory(nil), s.categories...)
+		if len(s.categories) > 0 && !s.output {
+			block.Stage = llm.SafetyStageInput
+		}
+		s.mu.Unlock()
+	}
+	return llm.CompletedEvent{StopReason: llm.StopReasonContentFilter, Safety: block}
+}
+
+// safetyFromErrorBody extracts a SafetyBlock from an OpenAI-style error
+// body rejecting the prompt, e.g. {"error":{"code":"content_filter",...}}
+// with Azure's "innererror.content_filter_result" categories.
+func safetyFromErrorBody(provider string, body []byte) *llm.SafetyBlock {
+	if !bytes.Contains(body, []byte("content_filter")) {
+		return nil
+	}
+	var payload struct {
+		Error struct {
+			Code       string `json:"code"`
+			Message    string `json:"message"`
+			InnerError struct {
+				ContentFilterResult map[string]json.RawMessage `json:"content_filter_result"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) != nil || payload.Error.Code != "content_filter" {
+		return nil
+	}
+	var s safetySink
+	s.add(payload.Error.InnerError.ContentFilterResult, false)
+	return &llm.SafetyBlock{
+		Provider:   provider,
+		Stage:      llm.SafetyStageInput,
+		Reason:     payload.Error.Code,
+		Categories: s.categories,
+		Message:    payload.Error.Message,
+	}
+}
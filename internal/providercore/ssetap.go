@@ -0,0 +1,119 @@
+package providercore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm"
+)
+
+// tapHTTPClient returns a shallow copy of c whose successful response bodies
+// are passed through unchanged while every SSE "data:" payload is handed to
+// onData. It lets the bridge recover upstream fields (warnings, provider
+// usage details) that the typed agentapis decoders drop. The headers of
+// error responses, which agentapis does not surface, go to onError.
+func tapHTTPClient(c *http.Client, onData func(data []byte), onError func(h http.Header)) *http.Client {
+	out := *c
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	out.Transport = &sseTapTransport{next: next, onData: onData, onError: onError}
+	return &out
+}
+
+type sseTapTransport struct {
+	next    http.RoundTripper
+	onData  func(data []byte)
+	onError func(h http.Header)
+}
+
+func (t *sseTapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		resp.Body = &sseTapReader{ReadCloser: resp.Body, onData: t.onData}
+	} else if t.onError != nil {
+		t.onError(resp.Header)
+	}
+	return resp, nil
+}
+
+// retryAfterSink remembers the Retry-After delay of the last error response.
+type retryAfterSink struct {
+	mu sync.Mutex
+	d  time.Duration
+}
+
+func (s *retryAfterSink) record(h http.Header) {
+	d, _ := llm.ParseRetryAfter(h, time.Now())
+	s.mu.Lock()
+	s.d = d
+	s.mu.Unlock()
+}
+
+// apply sets RetryAfter on the API error in err, if any.
+func (s *retryAfterSink) apply(err error) error {
+	var pe *llm.ProviderError
+	if s == nil || !errors.As(err, &pe) || pe.StatusCode == 0 {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pe.RetryAfter == 0 {
+		pe.RetryAfter = s.d
+	}
+	return err
+}
+
+// sseTapReader splits the body into lines as it is read and reports the
+// payload of each "data:" line.
+type sseTapReader struct {
+	io.ReadCloser
+	onData func(data []byte)
+	line   []byte
+	skip   bool // current line exceeded maxTapLine and is being discarded
+}
+
+// maxTapLine bounds the buffered partial line so oversized frames do not
+// grow memory without limit. Such frames are skipped.
+const maxTapLine = 1 << 20
+
+func (r *sseTapReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	chunk := p[:n]
+	for len(chunk) > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			if !r.skip && len(r.line)+len(chunk) <= maxTapLine {
+				r.line = append(r.line, chunk...)
+			} else {
+				r.line, r.skip = r.line[:0], true
+			}
+			break
+		}
+		if !r.skip {
+			r.line = append(r.line, chunk[:i]...)
+			r.emit(r.line)
+		}
+		r.line, r.skip = r.line[:0], false
+		chunk = chunk[i+1:]
+	}
+	return n, err
+}
+
+func (r *sseTapReader) emit(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:"))
+	if !ok {
+		return
+	}
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		r.onData(data)
+	}
+}
@@ -0,0 +1,65 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/codewandler/llm"
+)
+
+// streamErrorSink records an in-band error chunk of a Chat Completions
+// stream. OpenAI and OpenRouter report failures after the response has
+// started as a data payload with a top-level "error" object, which the
+// completions decoder parses as an empty chunk.
+type streamErrorSink struct {
+	mu  sync.Mutex
+	err *llm.ProviderError
+}
+
+func (s *streamErrorSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"error"`)) {
+		return
+	}
+	var payload struct {
+		Error *struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Error == nil {
+		return
+	}
+	e := payload.Error
+	message := e.Message
+	if e.Type != "" {
+		message = e.Type + ": " + message
+	}
+	// OpenRouter sends numeric codes, OpenAI strings.
+	code := strings.Trim(string(e.Code), `"`)
+	if code == "null" {
+		code = ""
+	}
+	s.mu.Lock()
+	s.err = &llm.ProviderError{Sentinel: llm.ErrProviderError, Message: message, Code: code}
+	s.mu.Unlock()
+}
+
+// take returns the recorded error attributed to provider and resets the
+// sink, or nil if no error chunk was seen.
+func (s *streamErrorSink) take(provider string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		return nil
+	}
+	err := s.err
+	s.err = nil
+	err.Provider = provider
+	return err
+}
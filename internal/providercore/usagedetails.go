@@ -0,0 +1,100 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// usageDetailsSink collects provider-specific usage fields from raw SSE
+// payloads so they can be attached to the usage record as Details. Later
+// payloads override earlier ones key by key, matching how providers report
+// cumulative usage (Anthropic message_start then message_delta).
+type usageDetailsSink struct {
+	mu      sync.Mutex
+	details map[string]any
+}
+
+// coreUsageFields are usage keys already represented in usage.Record.Tokens.
+var coreUsageFields = map[string]struct{}{
+	"prompt_tokens":               {},
+	"completion_tokens":           {},
+	"total_tokens":                {},
+	"input_tokens":                {},
+	"output_tokens":               {},
+	"cache_read_input_tokens":     {},
+	"cache_creation_input_tokens": {},
+}
+
+// scan inspects one SSE data payload for usage objects. Recognised shapes:
+// top-level "usage" (Chat Completions, OpenRouter, Anthropic message_delta),
+// "message.usage" (Anthropic message_start), "response.usage" (Responses
+// response.completed) and "x_groq.usage" (Groq timings, kept under "x_groq").
+func (s *usageDetailsSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	var payload struct {
+		Usage   map[string]json.RawMessage `json:"usage"`
+		Message struct {
+			Usage map[string]json.RawMessage `json:"usage"`
+		} `json:"message"`
+		Response struct {
+			Usage map[string]json.RawMessage `json:"usage"`
+		} `json:"response"`
+		XGroq struct {
+			Usage json.RawMessage `json:"usage"`
+		} `json:"x_groq"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+
+	found := make(map[string]any)
+	for _, u := range []map[string]json.RawMessage{payload.Usage, payload.Message.Usage, payload.Response.Usage} {
+		for k, raw := range u {
+			if _, core := coreUsageFields[k]; core {
+				continue
+			}
+			if v, ok := decodeDetail(raw); ok {
+				found[k] = v
+			}
+		}
+	}
+	if v, ok := decodeDetail(payload.XGroq.Usage); ok {
+		found["x_groq"] = v
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.details == nil {
+		s.details = make(map[string]any, len(found))
+	}
+	for k, v := range found {
+		s.details[k] = v
+	}
+}
+
+// take returns the collected details and resets the sink.
+func (s *usageDetailsSink) take() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.details
+	s.details = nil
+	return out
+}
+
+// decodeDetail decodes raw into a generic JSON value, dropping nulls.
+func decodeDetail(raw json.RawMessage) (any, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,155 @@
+package providercore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/codewandler/llm"
+)
+
+// warningSink collects warnings discovered outside the unified event flow
+// (response headers, raw SSE payloads, request transforms) until the bridge
+// flushes them into the stream. It is written from the HTTP body reader and
+// drained from the bridge, so access is synchronised.
+type warningSink struct {
+	provider string
+
+	mu      sync.Mutex
+	pending []llm.WarningEvent
+}
+
+func newWarningSink(provider string) *warningSink {
+	return &warningSink{provider: provider}
+}
+
+func (s *warningSink) add(ws ...llm.WarningEvent) {
+	if len(ws) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range ws {
+		if w.Provider == "" {
+			w.Provider = s.provider
+		}
+		s.pending = append(s.pending, w)
+	}
+}
+
+func (s *warningSink) take() []llm.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	out := make([]llm.Event, len(s.pending))
+	for i := range s.pending {
+		w := s.pending[i]
+		out[i] = &w
+	}
+	s.pending = nil
+	return out
+}
+
+// scan inspects one SSE data payload for warning fields.
+func (s *warningSink) scan(data []byte) {
+	if !bytes.Contains(data, []byte(`"warning`)) {
+		return
+	}
+	s.add(parseWarningFields(data)...)
+}
+
+// parseWarningFields extracts warnings from an upstream JSON payload. OpenAI
+// and OpenRouter report them as a top-level "warning" or "warnings" field,
+// either as plain strings or as objects with code/type, message and param.
+func parseWarningFields(data []byte) []llm.WarningEvent {
+	var payload struct {
+		Warning  json.RawMessage   `json:"warning"`
+		Warnings []json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	var out []llm.WarningEvent
+	for _, raw := range append([]json.RawMessage{payload.Warning}, payload.Warnings...) {
+		if w, ok := parseWarningValue(raw); ok {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func parseWarningValue(raw json.RawMessage) (llm.WarningEvent, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return llm.WarningEvent{}, false
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if text == "" {
+			return llm.WarningEvent{}, false
+		}
+		return llm.WarningEvent{Code: llm.WarningUpstream, Message: text}, true
+	}
+	var obj struct {
+		Code    string `json:"code"`
+		Type    string `json:"type"`
+		Message string `json:"message"`
+		Param   string `json:"param"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil || (obj.Message == "" && obj.Code == "" && obj.Type == "") {
+		return llm.WarningEvent{}, false
+	}
+	code := obj.Code
+	if code == "" {
+		code = obj.Type
+	}
+	return llm.WarningEvent{Code: normalizeWarningCode(code), Message: obj.Message, Param: obj.Param}, true
+}
+
+func normalizeWarningCode(code string) llm.WarningCode {
+	switch c := strings.ToLower(code); {
+	case c == "":
+		return llm.WarningUpstream
+	case strings.Contains(c, "deprecat"):
+		return llm.WarningDeprecated
+	case strings.Contains(c, "unsupported") || strings.Contains(c, "ignored"):
+		return llm.WarningParameterIgnored
+	default:
+		return llm.WarningCode(code)
+	}
+}
+
+// headerWarnings maps standard warning-bearing response headers: RFC 7234
+// Warning, and RFC 8594 Deprecation/Sunset.
+func headerWarnings(h http.Header) []llm.WarningEvent {
+	var out []llm.WarningEvent
+	for _, v := range h.Values("Warning") {
+		out = append(out, llm.WarningEvent{Code: llm.WarningUpstream, Message: warningHeaderText(v)})
+	}
+	if dep := h.Get("Deprecation"); dep != "" {
+		message := "upstream marked this endpoint or model as deprecated"
+		if sunset := h.Get("Sunset"); sunset != "" {
+			message = fmt.Sprintf("%s; sunset %s", message, sunset)
+		}
+		out = append(out, llm.WarningEvent{Code: llm.WarningDeprecated, Message: message})
+	}
+	return out
+}
+
+// warningHeaderText extracts the quoted warn-text from a Warning header value
+// such as `299 - "model is deprecated"`, falling back to the raw value.
+func warningHeaderText(v string) string {
+	start := strings.IndexByte(v, '"')
+	if start < 0 {
+		return strings.TrimSpace(v)
+	}
+	end := strings.IndexByte(v[start+1:], '"')
+	if end < 0 {
+		return strings.TrimSpace(v)
+	}
+	return v[start+1 : start+1+end]
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/codewandler/llm"
@@ -13,10 +14,12 @@ import (
 	"github.com/codewandler/llm/provider/bedrock"
 	"github.com/codewandler/llm/provider/codex"
 	"github.com/codewandler/llm/provider/dockermr"
+	"github.com/codewandler/llm/provider/groq"
 	"github.com/codewandler/llm/provider/minimax"
 	"github.com/codewandler/llm/provider/ollama"
 	"github.com/codewandler/llm/provider/openai"
 	"github.com/codewandler/llm/provider/openrouter"
+	"github.com/codewandler/llm/provider/vertex"
 )
 
 type Registry struct{ defs map[string]Definition }
@@ -77,11 +80,27 @@ func (r *Registry) Build(ctx context.Context, req llm.DetectedProvider, client *
 	if !ok {
 		return nil, fmt.Errorf("unknown provider type: %s", req.Type)
 	}
+	opts = append(append([]llm.Option{}, opts...), paramOptions(req.Params)...)
 	return def.Build(ctx, BuildConfig{Name: req.Name, Type: req.Type, Params: req.Params, HTTPClient: client, LLMOptions: opts})
 }
 
+// paramOptions turns the "baseURL", "apiKey" and "apiKeyEnv" params of a
+// request, as set by llm.Config, into options that override the defaults.
+func paramOptions(params map[string]any) []llm.Option {
+	var opts []llm.Option
+	if baseURL, _ := params["baseURL"].(string); baseURL != "" {
+		opts = append(opts, llm.WithBaseURL(baseURL))
+	}
+	if key, _ := params["apiKey"].(string); key != "" {
+		opts = append(opts, llm.WithAPIKey(key))
+	} else if env, _ := params["apiKeyEnv"].(string); env != "" {
+		opts = append(opts, llm.APIKeyFromEnv(env))
+	}
+	return opts
+}
+
 func orderedTypes() []string {
-	return []string{"claude", "anthropic", "bedrock", "openai", "openrouter", "minimax", "ollama", "codex", "dockermr"}
+	return []string{"claude", "anthropic", "bedrock", "vertex", "openai", "openrouter", "groq", "minimax", "ollama", "codex", "dockermr"}
 }
 
 func registerDefaults(r *Registry) {
@@ -119,7 +138,7 @@ func registerDefaults(r *Registry) {
 		return anthropic.New(opts...), nil
 	}})
 	r.Register(Definition{Type: "bedrock", Detect: func(context.Context, DetectEnv) ([]llm.DetectedProvider, error) {
-		if os.Getenv("AWS_ACCESS_KEY_ID") == "" && os.Getenv("AWS_PROFILE") == "" && os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") == "" {
+		if !awsCredentialsAvailable() {
 			return nil, nil
 		}
 		return []llm.DetectedProvider{{Name: "bedrock", Type: "bedrock", Order: 30}}, nil
@@ -133,6 +152,18 @@ func registerDefaults(r *Registry) {
 		}
 		return bedrock.New(opts...), nil
 	}})
+	r.Register(Definition{Type: "vertex", Detect: func(context.Context, DetectEnv) ([]llm.DetectedProvider, error) {
+		if vertex.ProjectFromEnv() == "" || !vertex.CredentialsAvailable() {
+			return nil, nil
+		}
+		return []llm.DetectedProvider{{Name: "vertex", Type: "vertex", Order: 35}}, nil
+	}, Build: func(ctx context.Context, cfg BuildConfig) (llm.Provider, error) {
+		opts := append([]llm.Option{}, cfg.LLMOptions...)
+		if cfg.HTTPClient != nil {
+			opts = append(opts, llm.WithHTTPClient(cfg.HTTPClient))
+		}
+		return vertex.New(vertex.WithLLMOptions(opts...)), nil
+	}})
 	r.Register(Definition{Type: "openai", Detect: func(context.Context, DetectEnv) ([]llm.DetectedProvider, error) {
 		if os.Getenv("OPENAI_API_KEY") == "" && os.Getenv("OPENAI_KEY") == "" {
 			return nil, nil
@@ -158,6 +189,18 @@ func registerDefaults(r *Registry) {
 		}
 		return openrouter.New(opts...), nil
 	}})
+	r.Register(Definition{Type: "groq", Detect: func(context.Context, DetectEnv) ([]llm.DetectedProvider, error) {
+		if os.Getenv("GROQ_API_KEY") == "" {
+			return nil, nil
+		}
+		return []llm.DetectedProvider{{Name: "groq", Type: "groq", Order: 55}}, nil
+	}, Build: func(ctx context.Context, cfg BuildConfig) (llm.Provider, error) {
+		opts := append([]llm.Option{}, cfg.LLMOptions...)
+		if cfg.HTTPClient != nil {
+			opts = append(opts, llm.WithHTTPClient(cfg.HTTPClient))
+		}
+		return groq.New(opts...), nil
+	}})
 	r.Register(Definition{Type: "minimax", Detect: func(context.Context, DetectEnv) ([]llm.DetectedProvider, error) {
 		if os.Getenv("MINIMAX_API_KEY") == "" {
 			return nil, nil
@@ -216,6 +259,33 @@ func registerDefaults(r *Registry) {
 	}})
 }
 
+// awsCredentialsAvailable reports whether the default AWS credential chain
+// has a source to read from: static keys, a named profile, web identity
+// (EKS IRSA), container credentials (ECS) or a shared credentials file.
+func awsCredentialsAvailable() bool {
+	for _, key := range []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_PROFILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+	} {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func detectDockerMR(sharedTransport http.RoundTripper) []llm.DetectedProvider {
 	if dockermr.Available(sharedTransport) {
 		return []llm.DetectedProvider{{Name: "dockermr", Type: "dockermr", Order: 90}}
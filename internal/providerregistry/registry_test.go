@@ -2,6 +2,8 @@ package providerregistry
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/codewandler/llm"
@@ -12,7 +14,7 @@ import (
 
 func TestRegistryHasDefaultDefinitions(t *testing.T) {
 	r := New()
-	for _, name := range []string{"claude", "anthropic", "bedrock", "openai", "openrouter", "minimax", "ollama", "codex", "dockermr"} {
+	for _, name := range []string{"claude", "anthropic", "bedrock", "vertex", "openai", "openrouter", "groq", "minimax", "ollama", "codex", "dockermr"} {
 		_, ok := r.Definition(name)
 		assert.True(t, ok, name)
 	}
@@ -37,3 +39,39 @@ func (s testStore) List(context.Context) ([]string, error)              { return
 
 var _ claude.TokenStore = testStore{}
 var _ llm.ProviderRegistry = (*Registry)(nil)
+
+func TestAWSCredentialsAvailable(t *testing.T) {
+	for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_PROFILE", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI"} {
+		t.Setenv(key, "")
+	}
+	dir := t.TempDir()
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	assert.False(t, awsCredentialsAvailable())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "credentials"), []byte("[default]\n"), 0o600))
+	assert.True(t, awsCredentialsAvailable())
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "missing"))
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "/var/run/secrets/token")
+	assert.True(t, awsCredentialsAvailable())
+}
+
+func TestDetectVertex_ProjectFromEitherVariable(t *testing.T) {
+	creds := filepath.Join(t.TempDir(), "adc.json")
+	require.NoError(t, os.WriteFile(creds, []byte("{}"), 0o600))
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", creds)
+	def, ok := New().Definition("vertex")
+	require.True(t, ok)
+
+	for _, env := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+			t.Setenv("GCLOUD_PROJECT", "")
+			t.Setenv(env, "my-project")
+			found, err := def.Detect(context.Background(), DetectEnv{})
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, "vertex", found[0].Type)
+		})
+	}
+}
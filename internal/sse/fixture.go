@@ -0,0 +1,124 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// MinimizeOptions controls Minimize.
+type MinimizeOptions struct {
+	// MaxRun caps runs of consecutive events of the same kind, such as text
+	// deltas: the first MaxRun-1 and the last event of a run are kept. Zero
+	// keeps every event.
+	MaxRun int
+}
+
+// Minimize converts a captured provider response into a compact SSE fixture.
+// A leading HTTP status line and headers (as written by curl -i) are
+// skipped, comments, id: and retry: lines and ping events are dropped, JSON
+// payloads are compacted, and every event is written as an optional event:
+// line, one data: line and a blank line.
+func Minimize(r io.Reader, opts MinimizeOptions) ([]byte, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw = skipHTTPHeaders(raw)
+
+	var events []Event
+	err = ForEachDataLine(context.Background(), bytes.NewReader(raw), func(ev Event) bool {
+		if ev.Name == "ping" || strings.TrimSpace(ev.Data) == "" {
+			return true
+		}
+		var buf bytes.Buffer
+		if json.Compact(&buf, []byte(ev.Data)) == nil {
+			ev.Data = buf.String()
+		}
+		events = append(events, ev)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxRun > 0 {
+		events = capRuns(events, opts.MaxRun)
+	}
+
+	var out bytes.Buffer
+	for _, ev := range events {
+		if ev.Name != "" {
+			out.WriteString("event: " + ev.Name + "\n")
+		}
+		out.WriteString("data: " + ev.Data + "\n\n")
+	}
+	return out.Bytes(), nil
+}
+
+// skipHTTPHeaders drops a leading HTTP response head.
+func skipHTTPHeaders(raw []byte) []byte {
+	if !bytes.HasPrefix(raw, []byte("HTTP/")) {
+		return raw
+	}
+	for _, sep := range []string{"\r\n\r\n", "\n\n"} {
+		if i := bytes.Index(raw, []byte(sep)); i >= 0 {
+			return raw[i+len(sep):]
+		}
+	}
+	return raw
+}
+
+// capRuns shortens runs of events with the same kind to max events, keeping
+// the last event of each run since it often carries the final state.
+func capRuns(events []Event, max int) []Event {
+	var out []Event
+	for start := 0; start < len(events); {
+		end := start + 1
+		for end < len(events) && eventKind(events[end]) == eventKind(events[start]) {
+			end++
+		}
+		run := events[start:end]
+		if len(run) > max {
+			run = append(append([]Event(nil), run[:max-1]...), run[len(run)-1])
+		}
+		out = append(out, run...)
+		start = end
+	}
+	return out
+}
+
+// eventKind identifies an event by its name and, for JSON payloads, its
+// "type" field, the type of its delta and, for Chat Completions chunks,
+// whether the chunk carries content, tool calls or a finish reason.
+// Non-JSON payloads such as [DONE] are their own kind.
+func eventKind(ev Event) string {
+	var probe struct {
+		Type    string          `json:"type"`
+		Delta   json.RawMessage `json:"delta"`
+		Choices []struct {
+			Delta        map[string]json.RawMessage `json:"delta"`
+			FinishReason *string                    `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal([]byte(ev.Data), &probe) != nil {
+		return ev.Name + "|" + ev.Data
+	}
+	var delta struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(probe.Delta, &delta) // string deltas have no type
+	kind := ev.Name + "|" + probe.Type + "|" + delta.Type
+	for _, c := range probe.Choices {
+		if c.FinishReason != nil {
+			kind += "|finish"
+		}
+		for _, key := range []string{"content", "reasoning_content", "reasoning", "tool_calls"} {
+			if _, ok := c.Delta[key]; ok {
+				kind += "|" + key
+			}
+		}
+	}
+	return kind
+}
@@ -0,0 +1,80 @@
+package sse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimize(t *testing.T) {
+	capture := strings.Join([]string{
+		"HTTP/2 200",
+		"content-type: text/event-stream",
+		"",
+		": keep-alive",
+		"event: message_start",
+		`data: {"type": "message_start",  "message": {"id": "msg_1"}}`,
+		"id: 7",
+		"",
+		"event: ping",
+		`data: {"type": "ping"}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"a"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"b"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"c"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+		"",
+		"event: message_stop",
+		`data: {"type":"message_stop"}`,
+		"",
+	}, "\r\n")
+
+	out, err := Minimize(strings.NewReader(capture), MinimizeOptions{MaxRun: 2})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"event: message_start",
+		`data: {"type":"message_start","message":{"id":"msg_1"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"a"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"c"}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+		"",
+		"event: message_stop",
+		`data: {"type":"message_stop"}`,
+		"",
+		"",
+	}, "\n"), string(out))
+}
+
+func TestMinimize_ChatChunksKeepFinishAndDone(t *testing.T) {
+	capture := strings.Join([]string{
+		`data: {"choices":[{"delta":{"content":"a"}}]}`,
+		`data: {"choices":[{"delta":{"content":"b"}}]}`,
+		`data: {"choices":[{"delta":{"content":"c"}}]}`,
+		`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	}, "\n\n")
+
+	out, err := Minimize(strings.NewReader(capture), MinimizeOptions{MaxRun: 1})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		`data: {"choices":[{"delta":{"content":"c"}}]}`,
+		`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+		"",
+	}, "\n\n"), string(out))
+}
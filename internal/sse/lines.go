@@ -2,9 +2,10 @@ package sse
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"io"
-	"strings"
 	"sync"
 )
 
@@ -14,24 +15,26 @@ type Event struct {
 	Data string
 }
 
-// scanResult carries one line from the background scanner goroutine.
-type scanResult struct {
-	line string
-	err  error // non-nil on scanner error; io.EOF signals clean end
-}
+// readerSize is the initial line buffer. Longer lines, such as tool calls
+// with large arguments, are assembled without an upper bound.
+const readerSize = 64 * 1024
+
+var (
+	prefixEvent = []byte("event:")
+	prefixData  = []byte("data:")
+)
 
 // ForEachDataLine scans an SSE stream and invokes fn for each data line.
 //
 // It supports both plain `data: ...` streams and named events using
-// `event: ...` followed by `data: ...`.
+// `event: ...` followed by `data: ...`. Lines have no length limit.
 //
-// For closable readers, cancellation and early termination close the reader to
-// unblock any in-flight Read and wait for the scanner goroutine to exit.
+// Lines are read on the calling goroutine. For closable readers,
+// cancellation closes the reader to unblock an in-flight Read, and early
+// termination closes it as well; other readers observe cancellation between
+// lines only.
 func ForEachDataLine(ctx context.Context, r io.Reader, fn func(Event) bool) error {
-	lines := make(chan scanResult, 16)
-	scannerDone := make(chan struct{})
-
-	var closeReader func()
+	closeReader := func() {}
 	if closer, ok := r.(io.Closer); ok {
 		var once sync.Once
 		closeReader = func() {
@@ -39,60 +42,68 @@ func ForEachDataLine(ctx context.Context, r io.Reader, fn func(Event) bool) erro
 				_ = closer.Close()
 			})
 		}
-	} else {
-		closeReader = func() {}
-	}
-
-	go func() {
-		defer close(scannerDone)
-		scanner := bufio.NewScanner(r)
-		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-		for scanner.Scan() {
-			lines <- scanResult{line: scanner.Text()}
-		}
-		if err := scanner.Err(); err != nil {
-			lines <- scanResult{err: err}
-		}
-		close(lines)
-	}()
-
-	var pendingEvent string
-	stop := func(err error) error {
-		closeReader()
-		<-scannerDone
-		return err
 	}
+	stopWatch := context.AfterFunc(ctx, closeReader)
+	defer stopWatch()
 
+	br := bufio.NewReaderSize(r, readerSize)
+	var (
+		long         []byte
+		pendingEvent string
+		lastEvent    string
+	)
 	for {
-		select {
-		case <-ctx.Done():
-			return stop(ctx.Err())
-		case res, ok := <-lines:
-			if !ok {
-				if ctx.Err() != nil {
-					return ctx.Err()
-				}
-				// channel closed — scanner finished cleanly
+		if err := ctx.Err(); err != nil {
+			closeReader()
+			return err
+		}
+		line, err := readLine(br, &long)
+		switch {
+		case bytes.HasPrefix(line, prefixEvent):
+			name := bytes.TrimSpace(line[len(prefixEvent):])
+			if string(name) != lastEvent {
+				// Streams repeat a handful of event names; reuse the string.
+				lastEvent = string(name)
+			}
+			pendingEvent = lastEvent
+		case bytes.HasPrefix(line, prefixData):
+			data := line[len(prefixData):]
+			data = bytes.TrimPrefix(data, []byte(" "))
+			if !fn(Event{Name: pendingEvent, Data: string(data)}) {
+				closeReader()
 				return nil
 			}
-			if res.err != nil {
-				if ctx.Err() != nil {
-					return ctx.Err()
-				}
-				return res.err
+			pendingEvent = ""
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
 			}
-			line := res.line
-			switch {
-			case strings.HasPrefix(line, "event:"):
-				pendingEvent = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-			case strings.HasPrefix(line, "data:"):
-				data := strings.TrimPrefix(line, "data:")
-				data = strings.TrimPrefix(data, " ")
-				if !fn(Event{Name: pendingEvent, Data: data}) {
-					return stop(nil)
-				}
-				pendingEvent = ""
+			if errors.Is(err, io.EOF) {
+				return nil
 			}
+			return err
+		}
+	}
+}
+
+// readLine returns the next line without its line ending. The result aliases
+// br's buffer or *long and is only valid until the next call.
+func readLine(br *bufio.Reader, long *[]byte) ([]byte, error) {
+	*long = (*long)[:0]
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			*long = append(*long, chunk...)
+			continue
+		}
+		line := chunk
+		if len(*long) > 0 {
+			*long = append(*long, chunk...)
+			line = *long
 		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		return line, err
 	}
 }
@@ -1,6 +1,7 @@
 package sse
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -260,3 +261,64 @@ func (s *streamingReadCloser) Close() error {
 	})
 	return nil
 }
+
+func TestForEachDataLine_LineLongerThanOneMiB(t *testing.T) {
+	big := strings.Repeat("x", 3<<20)
+	body := strings.NewReader("data: {\"args\":\"" + big + "\"}\n\ndata: [DONE]\n\n")
+
+	var got []string
+	err := ForEachDataLine(context.Background(), body, func(ev Event) bool {
+		got = append(got, ev.Data)
+		return true
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, len(big)+len(`{"args":""}`), len(got[0]))
+	assert.Equal(t, "[DONE]", got[1])
+}
+
+func TestForEachDataLine_EarlyStopWithBufferedLines(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "data: %d\n\n", i)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ForEachDataLine(context.Background(), strings.NewReader(sb.String()), func(Event) bool { return false })
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("ForEachDataLine did not return after fn stopped")
+	}
+}
+
+func benchmarkStream(events, size int) []byte {
+	var sb strings.Builder
+	payload := strings.Repeat("a", size)
+	for i := 0; i < events; i++ {
+		sb.WriteString("event: content_block_delta\n")
+		sb.WriteString(`data: {"type":"content_block_delta","delta":{"text":"` + payload + `"}}` + "\n\n")
+	}
+	return []byte(sb.String())
+}
+
+func BenchmarkForEachDataLine_SmallDeltas(b *testing.B) {
+	data := benchmarkStream(1000, 4)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = ForEachDataLine(context.Background(), bytes.NewReader(data), func(Event) bool { return true })
+	}
+}
+
+func BenchmarkForEachDataLine_LargeArguments(b *testing.B) {
+	data := benchmarkStream(4, 256<<10)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = ForEachDataLine(context.Background(), bytes.NewReader(data), func(Event) bool { return true })
+	}
+}
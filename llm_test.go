@@ -177,6 +177,63 @@ func TestStreamOptions_Validate(t *testing.T) {
 			},
 			wantErr: "messages[0]:",
 		},
+		{
+			name: "valid - stop sequences",
+			opts: Request{
+				Model:    "gpt-4",
+				Messages: Messages{User("Hello")},
+				Stop:     []string{"END", "\n\n"},
+			},
+			wantErr: "",
+		},
+		{
+			name: "invalid - empty stop sequence",
+			opts: Request{
+				Model:    "gpt-4",
+				Messages: Messages{User("Hello")},
+				Stop:     []string{"END", ""},
+			},
+			wantErr: "stop[1]: must not be empty",
+		},
+		{
+			name: "invalid - too many stop sequences",
+			opts: Request{
+				Model:    "gpt-4",
+				Messages: Messages{User("Hello")},
+				Stop:     []string{"a", "b", "c", "d", "e"},
+			},
+			wantErr: "at most 4 stop sequences",
+		},
+		{
+			name: "valid - thinking budget below max tokens",
+			opts: Request{
+				Model:          "claude-sonnet-4-5",
+				Messages:       Messages{User("Hello")},
+				MaxTokens:      8000,
+				ThinkingBudget: 2048,
+			},
+			wantErr: "",
+		},
+		{
+			name: "invalid - thinking budget not below max tokens",
+			opts: Request{
+				Model:          "claude-sonnet-4-5",
+				Messages:       Messages{User("Hello")},
+				MaxTokens:      2048,
+				ThinkingBudget: 2048,
+			},
+			wantErr: "must be less than MaxTokens",
+		},
+		{
+			name: "invalid - output schema with text format",
+			opts: Request{
+				Model:        "gpt-4",
+				Messages:     Messages{User("Hello")},
+				OutputFormat: OutputFormatText,
+				OutputSchema: map[string]any{"type": "object"},
+			},
+			wantErr: "OutputSchema requires OutputFormat json",
+		},
 	}
 
 	for _, tt := range tests {
@@ -332,3 +389,20 @@ func TestToolDefinition_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestRequest_ValidateFor(t *testing.T) {
+	req := Request{
+		Model:    "gpt-4o-search-preview",
+		Messages: Messages{User("Hello")},
+		Tools:    []tool.Definition{{Name: "lookup", Parameters: map[string]any{"type": "object"}}},
+	}
+	assert.ErrorIs(t, req.ValidateFor("openai"), ErrUnsupportedFeature)
+	assert.NoError(t, req.ValidateFor("unknown-provider"), "models unknown to the catalog pass")
+
+	req.Model = "gpt-4o-mini-2024-07-18"
+	assert.NoError(t, req.ValidateFor("openai"))
+	req.Thinking = ThinkingOn
+	assert.ErrorIs(t, req.ValidateFor("openai"), ErrUnsupportedFeature)
+
+	assert.EqualError(t, Request{}.ValidateFor("openai"), "model is required")
+}
@@ -0,0 +1,266 @@
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is one scripted reply of a Server.
+type Response struct {
+	// Status is the HTTP status code. Zero means 200. For non-2xx codes the
+	// server writes Body and no stream.
+	Status int
+	// Header is added to the response headers, e.g. Retry-After.
+	Header http.Header
+	// Body is the raw body of an error response.
+	Body string
+
+	// Chunks are streamed as content deltas, one SSE event each.
+	Chunks []string
+	// ToolCalls are streamed after the content, one delta per call.
+	ToolCalls []ToolCall
+	// FinishReason is sent on the last choice chunk. Defaults to "stop",
+	// or "tool_calls" when ToolCalls is set.
+	FinishReason string
+	// Usage is sent as a final usage chunk when set.
+	Usage *Usage
+
+	// Delay is waited before every chunk.
+	Delay time.Duration
+	// Hang keeps the stream open after the last chunk until the client
+	// disconnects, for testing cancellation.
+	Hang bool
+}
+
+// ToolCall is a tool call streamed by a Server.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Usage is the token usage reported by a Server.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TextResponse streams chunks as content deltas.
+func TextResponse(chunks ...string) Response { return Response{Chunks: chunks} }
+
+// ToolCallResponse streams a single tool call with JSON-encoded args.
+func ToolCallResponse(id, name string, args map[string]any) Response {
+	b, _ := json.Marshal(args)
+	return Response{ToolCalls: []ToolCall{{ID: id, Name: name, Arguments: string(b)}}}
+}
+
+// ErrorResponse answers with status and an OpenAI-style error body.
+func ErrorResponse(status int, message string) Response {
+	b, _ := json.Marshal(map[string]any{"error": map[string]any{
+		"message": message,
+		"type":    http.StatusText(status),
+	}})
+	return Response{Status: status, Body: string(b)}
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body into a map.
+func (r Request) JSON() map[string]any {
+	var m map[string]any
+	_ = json.Unmarshal(r.Body, &m)
+	return m
+}
+
+// Server emulates an OpenAI-compatible /v1/chat/completions endpoint.
+// It answers each request with the next scripted Response, streaming it
+// as SSE, and records every request it receives. Point a provider at it
+// with llm.WithBaseURL(server.URL):
+//
+//	srv := llmtest.NewServer(llmtest.TextResponse("Hel", "lo"))
+//	defer srv.Close()
+//	p := openai.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test"))
+//
+// Once the script is exhausted, requests get a 500 error response.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses []Response
+	requests  []Request
+}
+
+// NewServer starts a Server that answers with responses in order.
+func NewServer(responses ...Response) *Server {
+	s := &Server{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Enqueue appends responses to the script.
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, responses...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	var (
+		resp Response
+		ok   = len(s.responses) > 0
+	)
+	if ok {
+		resp = s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	s.mu.Unlock()
+
+	if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		writeResponse(w, ErrorResponse(http.StatusNotFound, "llmtest: unsupported path "+r.URL.Path))
+		return
+	}
+	if !ok {
+		writeResponse(w, ErrorResponse(http.StatusInternalServerError, "llmtest: no scripted response left"))
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	_ = json.Unmarshal(body, &req)
+	resp.stream(w, r, req.Model)
+}
+
+func writeResponse(w http.ResponseWriter, resp Response) {
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.Status)
+	_, _ = io.WriteString(w, resp.Body)
+}
+
+func (resp Response) stream(w http.ResponseWriter, r *http.Request, model string) {
+	if resp.Status != 0 && resp.Status/100 != 2 {
+		writeResponse(w, resp)
+		return
+	}
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if resp.Status != 0 {
+		w.WriteHeader(resp.Status)
+	}
+	flusher, _ := w.(http.Flusher)
+
+	send := func(v any) bool {
+		if resp.Delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return false
+			case <-time.After(resp.Delay):
+			}
+		}
+		b, _ := json.Marshal(v)
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+	chunk := func(delta map[string]any, finish any) map[string]any {
+		return map[string]any{
+			"id":      "chatcmpl-llmtest",
+			"object":  "chat.completion.chunk",
+			"created": 0,
+			"model":   model,
+			"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+
+	if !send(chunk(map[string]any{"role": "assistant", "content": ""}, nil)) {
+		return
+	}
+	for _, c := range resp.Chunks {
+		if !send(chunk(map[string]any{"content": c}, nil)) {
+			return
+		}
+	}
+	for i, tc := range resp.ToolCalls {
+		call := map[string]any{
+			"index":    i,
+			"id":       tc.ID,
+			"type":     "function",
+			"function": map[string]any{"name": tc.Name, "arguments": tc.Arguments},
+		}
+		if !send(chunk(map[string]any{"tool_calls": []any{call}}, nil)) {
+			return
+		}
+	}
+	if resp.Hang {
+		<-r.Context().Done()
+		return
+	}
+
+	finish := resp.FinishReason
+	if finish == "" {
+		finish = "stop"
+		if len(resp.ToolCalls) > 0 {
+			finish = "tool_calls"
+		}
+	}
+	if !send(chunk(map[string]any{}, finish)) {
+		return
+	}
+	if resp.Usage != nil {
+		if !send(map[string]any{
+			"id":      "chatcmpl-llmtest",
+			"object":  "chat.completion.chunk",
+			"created": 0,
+			"model":   model,
+			"choices": []any{},
+			"usage": map[string]any{
+				"prompt_tokens":     resp.Usage.PromptTokens,
+				"completion_tokens": resp.Usage.CompletionTokens,
+				"total_tokens":      resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+			},
+		}) {
+			return
+		}
+	}
+	_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,105 @@
+package llmtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/provider/groq"
+	"github.com/codewandler/llm/provider/openai"
+)
+
+func chatRequest(model string) llm.Request {
+	return llm.Request{
+		Model:       model,
+		ApiTypeHint: llm.ApiTypeOpenAIChatCompletion,
+		Messages:    msg.BuildTranscript(msg.User("Hello")),
+	}
+}
+
+func TestServer_StreamsTextAndRecordsRequests(t *testing.T) {
+	resp := llmtest.TextResponse("Hel", "lo")
+	resp.Usage = &llmtest.Usage{PromptTokens: 7, CompletionTokens: 2}
+	srv := llmtest.NewServer(resp)
+	defer srv.Close()
+
+	p := openai.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, chatRequest("gpt-4o"))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", c.Text)
+	assert.Equal(t, llm.StopReasonEndTurn, c.StopReason)
+	assert.Equal(t, 7, c.TotalUsage().Tokens.TotalInput())
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodPost, reqs[0].Method)
+	assert.Equal(t, "/v1/chat/completions", reqs[0].Path)
+	assert.Equal(t, "Bearer test-key", reqs[0].Header.Get("Authorization"))
+	assert.Equal(t, "gpt-4o", reqs[0].JSON()["model"])
+}
+
+func TestServer_ToolCalls(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.ToolCallResponse("call_1", "get_weather", map[string]any{"location": "Berlin"}))
+	defer srv.Close()
+
+	p := groq.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, chatRequest("llama-3.3-70b-versatile"))
+	require.NoError(t, err)
+	require.Len(t, c.ToolCalls, 1)
+	assert.Equal(t, "call_1", c.ToolCalls[0].ToolCallID())
+	assert.Equal(t, "get_weather", c.ToolCalls[0].ToolName())
+	assert.Equal(t, "Berlin", c.ToolCalls[0].ToolArgs()["location"])
+	assert.Equal(t, llm.StopReasonToolUse, c.StopReason)
+}
+
+func TestServer_ErrorResponses(t *testing.T) {
+	rateLimited := llmtest.ErrorResponse(http.StatusTooManyRequests, "slow down")
+	rateLimited.Header = http.Header{"Retry-After": {"2"}}
+	srv := llmtest.NewServer(rateLimited, llmtest.TextResponse("ok"))
+	defer srv.Close()
+
+	p := openai.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	_, err := llm.Complete(t.Context(), p, chatRequest("gpt-4o"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrRateLimited)
+
+	c, err := llm.Complete(t.Context(), p, chatRequest("gpt-4o"))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", c.Text)
+
+	_, err = llm.Complete(t.Context(), p, chatRequest("gpt-4o"))
+	assert.ErrorIs(t, err, llm.ErrAPIError, "an exhausted script answers with 500")
+}
+
+func TestServer_Cancellation(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Response{Chunks: []string{"partial"}, Hang: true})
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p := openai.New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	stream, err := p.CreateStream(ctx, chatRequest("gpt-4o"))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for env := range stream {
+			if env.Type == llm.StreamEventDelta {
+				cancel()
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not close after cancellation")
+	}
+}
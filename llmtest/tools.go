@@ -0,0 +1,277 @@
+package llmtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/codewandler/llm/tool"
+)
+
+// TestingT is the subset of *testing.T used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// FakeTools answers tool calls from canned expectations instead of running
+// real handlers, and records every call for later verification. It lets
+// agent-loop tests exercise tool use without side effects:
+//
+//	tools := llmtest.NewFakeTools(set)
+//	tools.Expect("get_weather", tool.Args{"location": "Paris"}).Return(`{"temp":21}`)
+//
+//	res, err := llm.Run(ctx, svc, req, llm.RunOptions{}, tools.Handlers()...)
+//	tools.AssertExpectations(t)
+//
+// When built from a *tool.Set, calls are validated against the set's schemas
+// like Set.Execute would, and Handlers carries the set's definitions so
+// llm.Run sends them to the model.
+type FakeTools struct {
+	set *tool.Set
+
+	mu           sync.Mutex
+	expectations []*ToolExpectation
+	calls        []tool.Call
+	unexpected   []tool.Call
+}
+
+// NewFakeTools returns a FakeTools. set may be nil, in which case calls are
+// not validated and Handlers only covers tools with expectations.
+func NewFakeTools(set *tool.Set) *FakeTools {
+	return &FakeTools{set: set}
+}
+
+// ToolExpectation is a canned answer for calls matching a tool name and
+// argument matcher. Configure it with Return, ReturnError and Times.
+type ToolExpectation struct {
+	name  string
+	desc  string
+	match func(tool.Args) bool
+
+	out   any
+	err   error
+	times int // 0 means any number of times, at least once
+	calls int
+}
+
+// Expect registers an expectation for calls to name whose arguments contain
+// every key in args with an equal value. Values are compared after
+// tool.CanonicalArgs, so 3 and 3.0 match. Nil args match any call to name.
+func (f *FakeTools) Expect(name string, args tool.Args) *ToolExpectation {
+	want := tool.CanonicalArgs(args)
+	return f.add(&ToolExpectation{
+		name:  name,
+		desc:  fmt.Sprintf("%s%v", name, want),
+		match: func(got tool.Args) bool { return containsArgs(tool.CanonicalArgs(got), want) },
+	})
+}
+
+// ExpectMatch registers an expectation for calls to name for which match
+// returns true.
+func (f *FakeTools) ExpectMatch(name string, match func(tool.Args) bool) *ToolExpectation {
+	return f.add(&ToolExpectation{name: name, desc: name + "(matcher)", match: match})
+}
+
+func (f *FakeTools) add(e *ToolExpectation) *ToolExpectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expectations = append(f.expectations, e)
+	return e
+}
+
+// Return sets the output returned for matching calls.
+func (e *ToolExpectation) Return(out any) *ToolExpectation {
+	e.out = out
+	return e
+}
+
+// ReturnError makes matching calls fail with err.
+func (e *ToolExpectation) ReturnError(err error) *ToolExpectation {
+	e.err = err
+	return e
+}
+
+// Times limits the expectation to exactly n calls. Further matching calls
+// fall through to later expectations, or are reported as unexpected.
+func (e *ToolExpectation) Times(n int) *ToolExpectation {
+	e.times = n
+	return e
+}
+
+// Handle implements tool.Handler. Calls without a matching expectation, to
+// tools missing from the set, or with arguments that fail the set's schema
+// are recorded as unexpected and answered with an error.
+func (f *FakeTools) Handle(_ context.Context, call tool.Call) (any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+
+	if f.set != nil {
+		if _, err := f.set.Parse([]tool.Call{call}); err != nil {
+			f.unexpected = append(f.unexpected, call)
+			return nil, err
+		}
+	}
+	for _, e := range f.expectations {
+		if e.name != call.ToolName() || (e.times > 0 && e.calls >= e.times) || !e.match(call.ToolArgs()) {
+			continue
+		}
+		e.calls++
+		if e.err != nil {
+			return nil, e.err
+		}
+		return e.out, nil
+	}
+	f.unexpected = append(f.unexpected, call)
+	return nil, fmt.Errorf("llmtest: unexpected tool call %s%v", call.ToolName(), call.ToolArgs())
+}
+
+// Execute answers calls like Set.Execute, returning one Result per call in
+// the same order.
+func (f *FakeTools) Execute(ctx context.Context, calls []tool.Call) []tool.Result {
+	results := make([]tool.Result, len(calls))
+	for i, call := range calls {
+		out, err := f.Handle(ctx, call)
+		if err != nil {
+			results[i] = tool.NewResult(call.ToolCallID(), err.Error(), true)
+			continue
+		}
+		results[i] = tool.NewResult(call.ToolCallID(), out, false)
+	}
+	return results
+}
+
+// Handlers returns one tool.NamedHandler per known tool name, for use with
+// llm.Run or tool.NewHandlers. Handlers for tools in the set also carry the
+// tool's definition.
+func (f *FakeTools) Handlers() []tool.NamedHandler {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	var out []tool.NamedHandler
+	if f.set != nil {
+		for _, def := range f.set.Definitions() {
+			seen[def.Name] = struct{}{}
+			out = append(out, &fakeSpecHandler{fakeHandler{f, def.Name}, def})
+		}
+	}
+	for _, e := range f.expectations {
+		if _, ok := seen[e.name]; ok {
+			continue
+		}
+		seen[e.name] = struct{}{}
+		out = append(out, &fakeHandler{f, e.name})
+	}
+	return out
+}
+
+// Calls returns every call received so far, in order.
+func (f *FakeTools) Calls() []tool.Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]tool.Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls received for name, in order.
+func (f *FakeTools) CallsTo(name string) []tool.Call {
+	var out []tool.Call
+	for _, c := range f.Calls() {
+		if c.ToolName() == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// AssertExpectations reports every expectation that was not met (never
+// called, or called a different number of times than set with Times) and
+// every unexpected call. It returns true if there were none.
+func (f *FakeTools) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ok := true
+	for _, e := range f.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			t.Errorf("llmtest: expected %s to be called %d times, got %d", e.desc, e.times, e.calls)
+			ok = false
+		case e.times == 0 && e.calls == 0:
+			t.Errorf("llmtest: expected %s to be called", e.desc)
+			ok = false
+		}
+	}
+	for _, c := range f.unexpected {
+		t.Errorf("llmtest: unexpected tool call %s%v", c.ToolName(), c.ToolArgs())
+		ok = false
+	}
+	return ok
+}
+
+// AssertCalled reports an error unless name was called with arguments
+// containing args, compared as in Expect.
+func (f *FakeTools) AssertCalled(t TestingT, name string, args tool.Args) bool {
+	t.Helper()
+	want := tool.CanonicalArgs(args)
+	for _, c := range f.CallsTo(name) {
+		if containsArgs(tool.CanonicalArgs(c.ToolArgs()), want) {
+			return true
+		}
+	}
+	t.Errorf("llmtest: expected call %s%v, got %s", name, want, describeCalls(f.Calls()))
+	return false
+}
+
+// AssertNotCalled reports an error if name was called.
+func (f *FakeTools) AssertNotCalled(t TestingT, name string) bool {
+	t.Helper()
+	if calls := f.CallsTo(name); len(calls) > 0 {
+		t.Errorf("llmtest: expected no call to %s, got %d", name, len(calls))
+		return false
+	}
+	return true
+}
+
+type fakeHandler struct {
+	f    *FakeTools
+	name string
+}
+
+func (h *fakeHandler) ToolName() string { return h.name }
+
+func (h *fakeHandler) Handle(ctx context.Context, call tool.Call) (any, error) {
+	return h.f.Handle(ctx, call)
+}
+
+type fakeSpecHandler struct {
+	fakeHandler
+	def tool.Definition
+}
+
+func (h *fakeSpecHandler) Definition() tool.Definition { return h.def }
+
+// containsArgs reports whether got has every key of want with an equal value.
+func containsArgs(got, want tool.Args) bool {
+	for k, v := range want {
+		gv, ok := got[k]
+		if !ok || !reflect.DeepEqual(gv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeCalls(calls []tool.Call) string {
+	if len(calls) == 0 {
+		return "no calls"
+	}
+	out := make([]string, len(calls))
+	for i, c := range calls {
+		out[i] = fmt.Sprintf("%s%v", c.ToolName(), c.ToolArgs())
+	}
+	return fmt.Sprint(out)
+}
@@ -0,0 +1,106 @@
+package llmtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/tool"
+)
+
+type weatherParams struct {
+	Location string `json:"location" jsonschema:"required"`
+}
+
+// recorder is a TestingT that collects reported errors.
+type recorder struct{ errs []string }
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestFakeTools_RunAgentLoop(t *testing.T) {
+	set := tool.NewToolSet(tool.NewSpec[weatherParams]("get_weather", "Get weather"))
+	tools := llmtest.NewFakeTools(set)
+	tools.Expect("get_weather", tool.Args{"location": "Paris"}).Return("sunny").Times(1)
+
+	var reqs []llm.Request
+	s := llm.StreamFunc(func(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+		if len(reqs) == 1 {
+			return llmtest.SendEvents(
+				llmtest.ToolEvent("c1", "get_weather", map[string]any{"location": "Paris"}),
+				llmtest.CompletedEvent(llm.StopReasonToolUse),
+			), nil
+		}
+		return llmtest.SendEvents(
+			llmtest.TextEvent("It is sunny."),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		), nil
+	})
+
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("Weather in Paris?")},
+	}, llm.RunOptions{}, tools.Handlers()...)
+	require.NoError(t, err)
+
+	assert.Equal(t, "It is sunny.", res.Text)
+	require.Len(t, reqs[0].Tools, 1, "set definitions should be sent")
+	assert.Equal(t, "get_weather", reqs[0].Tools[0].Name)
+	assert.True(t, tools.AssertExpectations(t))
+	assert.True(t, tools.AssertCalled(t, "get_weather", tool.Args{"location": "Paris"}))
+	assert.True(t, tools.AssertNotCalled(t, "search"))
+}
+
+func TestFakeTools_Execute(t *testing.T) {
+	tools := llmtest.NewFakeTools(nil)
+	tools.Expect("add", tool.Args{"a": 1}).Return(3)
+	tools.ExpectMatch("fail", func(tool.Args) bool { return true }).ReturnError(errors.New("boom"))
+
+	results := tools.Execute(context.Background(), []tool.Call{
+		tool.NewToolCall("c1", "add", tool.Args{"a": 1.0, "b": 2}),
+		tool.NewToolCall("c2", "fail", nil),
+		tool.NewToolCall("c3", "add", tool.Args{"a": 5}),
+	})
+	require.Len(t, results, 3)
+	assert.Equal(t, 3, results[0].ToolOutput())
+	assert.False(t, results[0].IsError())
+	assert.True(t, results[1].IsError())
+	assert.Equal(t, "boom", results[1].ToolOutput())
+	assert.True(t, results[2].IsError())
+	assert.Len(t, tools.CallsTo("add"), 2)
+
+	var r recorder
+	assert.False(t, tools.AssertExpectations(&r))
+	require.Len(t, r.errs, 1)
+	assert.Contains(t, r.errs[0], "unexpected tool call add")
+}
+
+func TestFakeTools_ReportsUnmetExpectations(t *testing.T) {
+	set := tool.NewToolSet(tool.NewSpec[weatherParams]("get_weather", "Get weather"))
+	tools := llmtest.NewFakeTools(set)
+	tools.Expect("get_weather", tool.Args{"location": "Paris"})
+	tools.Expect("get_weather", tool.Args{"location": "Rome"}).Times(2)
+
+	_, err := tools.Handle(context.Background(), tool.NewToolCall("c1", "get_weather", tool.Args{"location": "Rome"}))
+	require.NoError(t, err)
+	_, err = tools.Handle(context.Background(), tool.NewToolCall("c2", "get_weather", tool.Args{}))
+	require.Error(t, err, "schema validation applies")
+
+	var r recorder
+	assert.False(t, tools.AssertExpectations(&r))
+	assert.Len(t, r.errs, 3)
+	assert.False(t, tools.AssertCalled(&r, "get_weather", tool.Args{"location": "Paris"}))
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/codewandler/llm/tool"
+)
+
+// ProtocolVersion is the MCP revision the server implements. Clients that
+// request a different revision are answered with this one, as the
+// specification requires.
+const ProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Tool is the MCP description of a tool, as returned by tools/list.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// Tools converts the definitions of ts into MCP tool descriptions. Use it to
+// export the schemas of a Set without running a server.
+func Tools(ts *tool.Set) []Tool {
+	defs := ts.Definitions()
+	out := make([]Tool, len(defs))
+	for i, def := range defs {
+		schema := def.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		out[i] = Tool{Name: def.Name, Description: def.Description, InputSchema: schema}
+	}
+	return out
+}
+
+// Content is a single content block of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallToolResult is the result of tools/call.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// isNotification reports whether the request expects no response.
+func (r *request) isNotification() bool { return len(r.ID) == 0 }
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      implementation `json:"serverInfo"`
+}
+
+type listToolsResult struct {
+	Tools []Tool `json:"tools"`
+}
+
+type callToolParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+}
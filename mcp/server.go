@@ -0,0 +1,222 @@
+// Package mcp serves a tool.Set over the Model Context Protocol, so tools
+// written against this library can be used by MCP clients such as Claude
+// Desktop.
+//
+// The server implements the tools capability of MCP: initialize, ping,
+// tools/list and tools/call, plus cancellation of running calls. Messages are
+// newline-delimited JSON-RPC 2.0, the framing of the stdio transport.
+//
+// Example:
+//
+//	tools := tool.NewToolSet(
+//	    tool.NewSpec[GetWeatherParams]("get_weather", "Get current weather").
+//	        WithHandler(getWeather),
+//	)
+//	if err := mcp.NewServer("weather", "1.0.0", tools).ServeStdio(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/codewandler/llm/tool"
+)
+
+// Server exposes the tools of a tool.Set to MCP clients. Tool calls are
+// validated and run with tool.Set.ExecuteCall, so the set's argument policy
+// applies and handler failures are reported as error results.
+type Server struct {
+	name    string
+	version string
+	tools   *tool.Set
+	known   map[string]bool
+
+	writeMu sync.Mutex
+	w       io.Writer
+
+	callsMu sync.Mutex
+	calls   map[string]context.CancelFunc // running tools/call by request ID
+}
+
+// NewServer creates a Server that announces itself as name and version and
+// serves the tools of ts.
+func NewServer(name, version string, ts *tool.Set) *Server {
+	known := make(map[string]bool)
+	for _, def := range ts.Definitions() {
+		known[def.Name] = true
+	}
+	return &Server{
+		name:    name,
+		version: version,
+		tools:   ts,
+		known:   known,
+		calls:   make(map[string]context.CancelFunc),
+	}
+}
+
+// ServeStdio serves the stdio transport on os.Stdin and os.Stdout. Stdout is
+// reserved for protocol messages; log to os.Stderr instead.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes responses
+// to w until r reaches EOF. Tool calls run concurrently; Serve waits for
+// running calls before it returns. Cancelling ctx cancels running calls but
+// does not interrupt a blocked read.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.w = w
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			s.handle(ctx, &wg, line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("mcp: read: %w", err)
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, wg *sync.WaitGroup, line []byte) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.writeError(nil, codeParseError, "parse error: "+err.Error())
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if !req.isNotification() {
+			s.writeError(req.ID, codeInvalidRequest, "invalid request")
+		}
+		return
+	}
+	if req.isNotification() && !strings.HasPrefix(req.Method, "notifications/") {
+		return // requests sent without an ID cannot be answered
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.writeResult(req.ID, initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{"listChanged": false}},
+			ServerInfo:      implementation{Name: s.name, Version: s.version},
+		})
+	case "ping":
+		s.writeResult(req.ID, struct{}{})
+	case "tools/list":
+		s.writeResult(req.ID, listToolsResult{Tools: Tools(s.tools)})
+	case "tools/call":
+		var p callToolParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			s.writeError(req.ID, codeInvalidParams, "invalid params: "+err.Error())
+			return
+		}
+		if !s.known[p.Name] {
+			s.writeError(req.ID, codeInvalidParams, "unknown tool: "+p.Name)
+			return
+		}
+		callCtx, cancel := context.WithCancel(ctx)
+		id := string(req.ID)
+		s.callsMu.Lock()
+		s.calls[id] = cancel
+		s.callsMu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				s.callsMu.Lock()
+				delete(s.calls, id)
+				s.callsMu.Unlock()
+				cancel()
+			}()
+			call := tool.NewToolCall(strings.Trim(id, `"`), p.Name, p.Arguments)
+			res := s.tools.ExecuteCall(callCtx, call)
+			if callCtx.Err() != nil && ctx.Err() == nil {
+				// Cancelled by the client, which expects no response.
+				return
+			}
+			s.writeResult(req.ID, toCallToolResult(res))
+		}()
+	case "notifications/cancelled":
+		var p cancelledParams
+		if json.Unmarshal(req.Params, &p) == nil {
+			s.callsMu.Lock()
+			if cancel, ok := s.calls[string(p.RequestID)]; ok {
+				cancel()
+			}
+			s.callsMu.Unlock()
+		}
+	default:
+		// Notifications the server does not act on (notifications/initialized
+		// among them) are ignored.
+		if !req.isNotification() {
+			s.writeError(req.ID, codeMethodNotFound, "method not found: "+req.Method)
+		}
+	}
+}
+
+// toCallToolResult renders a tool result as a single text block. String
+// outputs are sent as-is, anything else as JSON.
+func toCallToolResult(res tool.Result) CallToolResult {
+	var text string
+	switch out := res.ToolOutput().(type) {
+	case nil:
+	case string:
+		text = out
+	default:
+		data, err := json.Marshal(out)
+		if err != nil {
+			return CallToolResult{
+				Content: []Content{{Type: "text", Text: "marshal tool output: " + err.Error()}},
+				IsError: true,
+			}
+		}
+		text = string(data)
+	}
+	return CallToolResult{Content: []Content{{Type: "text", Text: text}}, IsError: res.IsError()}
+}
+
+func (s *Server) writeResult(id json.RawMessage, result any) {
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) writeError(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.write(response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}})
+}
+
+func (s *Server) write(resp response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{
+			JSONRPC: "2.0",
+			ID:      resp.ID,
+			Error:   &rpcError{Code: codeInternalError, Message: "marshal response: " + err.Error()},
+		})
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.w.Write(append(data, '\n'))
+}
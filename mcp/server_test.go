@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/tool"
+)
+
+type weatherParams struct {
+	Location string `json:"location" jsonschema:"description=City name,required"`
+}
+
+func testTools(block chan struct{}) *tool.Set {
+	return tool.NewToolSet(
+		tool.NewSpec[weatherParams]("get_weather", "Get current weather").
+			WithHandler(func(_ context.Context, in weatherParams) (string, error) {
+				if in.Location == "Atlantis" {
+					return "", errors.New("no such place")
+				}
+				return "sunny in " + in.Location, nil
+			}),
+		tool.NewSpec[weatherParams]("slow", "Blocks until cancelled").
+			WithHandler(func(ctx context.Context, _ weatherParams) (string, error) {
+				close(block)
+				<-ctx.Done()
+				return "", ctx.Err()
+			}),
+	)
+}
+
+// client drives a Server over in-memory pipes.
+type client struct {
+	in   *io.PipeWriter
+	out  *bufio.Scanner
+	done chan error
+}
+
+func startServer(t *testing.T, ts *tool.Set) *client {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{in: inW, out: bufio.NewScanner(outR), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer("test", "0.1.0", ts).Serve(context.Background(), inR, outW)
+		_ = outW.Close()
+	}()
+	t.Cleanup(func() { _ = inW.Close() })
+	return c
+}
+
+func (c *client) send(t *testing.T, line string) {
+	t.Helper()
+	_, err := io.WriteString(c.in, line+"\n")
+	require.NoError(t, err)
+}
+
+func (c *client) recv(t *testing.T) map[string]any {
+	t.Helper()
+	require.True(t, c.out.Scan(), "expected a response")
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(c.out.Bytes(), &m))
+	return m
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	c := startServer(t, testTools(make(chan struct{})))
+
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`)
+	resp := c.recv(t)
+	assert.Equal(t, float64(1), resp["id"])
+	result := resp["result"].(map[string]any)
+	assert.Equal(t, ProtocolVersion, result["protocolVersion"])
+	assert.Equal(t, map[string]any{"name": "test", "version": "0.1.0"}, result["serverInfo"])
+	assert.Contains(t, result["capabilities"], "tools")
+
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(t, `{"jsonrpc":"2.0","id":"p","method":"ping"}`)
+	resp = c.recv(t)
+	assert.Equal(t, "p", resp["id"])
+	assert.Equal(t, map[string]any{}, resp["result"])
+
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := c.recv(t)["result"].(map[string]any)["tools"].([]any)
+	require.Len(t, tools, 2)
+	first := tools[0].(map[string]any)
+	assert.Equal(t, "get_weather", first["name"])
+	assert.Equal(t, "Get current weather", first["description"])
+	schema := first["inputSchema"].(map[string]any)
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []any{"location"}, schema["required"])
+
+	require.NoError(t, c.in.Close())
+	require.NoError(t, <-c.done)
+}
+
+func TestServer_ToolsCall(t *testing.T) {
+	c := startServer(t, testTools(make(chan struct{})))
+
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_weather","arguments":{"location":"Berlin"}}}`)
+	assert.Equal(t, map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "sunny in Berlin"}},
+	}, c.recv(t)["result"])
+
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_weather","arguments":{"location":"Atlantis"}}}`)
+	result := c.recv(t)["result"].(map[string]any)
+	assert.Equal(t, true, result["isError"])
+	assert.Contains(t, result["content"].([]any)[0].(map[string]any)["text"], "no such place")
+
+	c.send(t, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_weather","arguments":{}}}`)
+	result = c.recv(t)["result"].(map[string]any)
+	assert.Equal(t, true, result["isError"], "schema validation failures are tool errors")
+
+	c.send(t, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope","arguments":{}}}`)
+	rpcErr := c.recv(t)["error"].(map[string]any)
+	assert.Equal(t, float64(codeInvalidParams), rpcErr["code"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	c := startServer(t, testTools(make(chan struct{})))
+
+	c.send(t, `{not json`)
+	resp := c.recv(t)
+	assert.Nil(t, resp["id"])
+	assert.Equal(t, float64(codeParseError), resp["error"].(map[string]any)["code"])
+
+	c.send(t, `{"jsonrpc":"2.0","method":"tools/list"}`) // no ID: not answered
+	c.send(t, `{"jsonrpc":"2.0","id":7,"method":"resources/list"}`)
+	resp = c.recv(t)
+	assert.Equal(t, float64(7), resp["id"])
+	assert.Equal(t, float64(codeMethodNotFound), resp["error"].(map[string]any)["code"])
+}
+
+func TestServer_Cancel(t *testing.T) {
+	started := make(chan struct{})
+	c := startServer(t, testTools(started))
+
+	c.send(t, `{"jsonrpc":"2.0","id":"slow-1","method":"tools/call","params":{"name":"slow","arguments":{"location":"x"}}}`)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("handler did not start")
+	}
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow-1"}}`)
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	assert.Equal(t, float64(2), c.recv(t)["id"], "cancelled call is not answered")
+
+	require.NoError(t, c.in.Close())
+	require.NoError(t, <-c.done)
+	assert.False(t, c.out.Scan())
+}
+
+func TestTools(t *testing.T) {
+	got := Tools(testTools(make(chan struct{})))
+	require.Len(t, got, 2)
+	assert.Equal(t, "slow", got[1].Name)
+
+	data, err := json.Marshal(got[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `{"name":"get_weather","description":"Get current weather","inputSchema":{`))
+}
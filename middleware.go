@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/codewandler/llm/usage"
+)
+
+// Middleware wraps a Provider to layer cross-cutting behaviour such as
+// logging, metrics, rate limiting or redaction around it without changing
+// provider code.
+type Middleware func(Provider) Provider
+
+// Wrap applies mw to p. The first middleware is the outermost: it sees the
+// request first and every stream event last.
+func Wrap(p Provider, mw ...Middleware) Provider {
+	for i := len(mw) - 1; i >= 0; i-- {
+		p = mw[i](p)
+	}
+	return p
+}
+
+// StreamMiddleware returns a Middleware that intercepts CreateStream with fn.
+// fn receives the wrapped provider as next; Name and Models pass through
+// unchanged.
+func StreamMiddleware(fn func(ctx context.Context, src Buildable, next Provider) (Stream, error)) Middleware {
+	return func(next Provider) Provider {
+		return &middlewareProvider{Provider: next, fn: fn}
+	}
+}
+
+type middlewareProvider struct {
+	Provider
+	fn func(ctx context.Context, src Buildable, next Provider) (Stream, error)
+}
+
+func (p *middlewareProvider) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	return p.fn(ctx, src, p.Provider)
+}
+
+// ObserveStream returns a stream that forwards every envelope of in after
+// passing it to fn, and calls done (when non-nil) once in is exhausted.
+// When ctx is cancelled the consumer may be gone: forwarding stops, but in
+// is still drained through fn so the producer is not blocked and done sees
+// the whole stream.
+func ObserveStream(ctx context.Context, in Stream, fn func(Envelope), done func()) Stream {
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		if done != nil {
+			defer done()
+		}
+		for env := range in {
+			fn(env)
+			select {
+			case out <- env:
+			case <-ctx.Done():
+				for env := range in {
+					fn(env)
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// LoggingMiddleware logs every stream at debug level when it starts and at
+// info level when it ends, with model, stop reason, token counts, cost,
+// time to first token and duration. Failures are logged at error level.
+// A nil logger uses slog.Default.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return TelemetryMiddleware(TelemetryFunc(func(ctx context.Context, info StreamInfo) (context.Context, func(StreamStats)) {
+		log := logger.With("provider", info.Provider, "model", info.Model)
+		log.DebugContext(ctx, "llm stream start")
+		return ctx, func(s StreamStats) {
+			attrs := []any{
+				"resolved_model", s.ResolvedModel,
+				"stop_reason", s.StopReason,
+				"input_tokens", s.Tokens.TotalInput(),
+				"output_tokens", s.Tokens.TotalOutput(),
+				"cost", s.Cost,
+				"ttft", s.TimeToFirstToken,
+				"duration", s.Duration,
+			}
+			if s.Err != nil {
+				log.ErrorContext(ctx, "llm stream failed", append(attrs, "error", s.Err)...)
+				return
+			}
+			log.InfoContext(ctx, "llm stream done", attrs...)
+		}
+	}))
+}
+
+// UsageMiddleware records the usage records and token estimates of every
+// stream in tracker, so spend and estimate drift can be aggregated across
+// calls and providers.
+func UsageMiddleware(tracker *usage.Tracker) Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		stream, err := next.CreateStream(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		return ObserveStream(ctx, stream, func(env Envelope) {
+			switch ev := env.Data.(type) {
+			case *UsageUpdatedEvent:
+				tracker.Record(ev.Record)
+			case *TokenEstimateEvent:
+				tracker.Record(ev.Estimate)
+			}
+		}, nil), nil
+	})
+}
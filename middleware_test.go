@@ -0,0 +1,157 @@
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
+)
+
+type middlewareTestProvider struct {
+	events []llm.Event
+}
+
+func (p *middlewareTestProvider) Name() string { return "fake" }
+func (p *middlewareTestProvider) Models() llm.Models {
+	return llm.Models{{ID: "fake-model", Provider: "fake"}}
+}
+func (p *middlewareTestProvider) CreateStream(context.Context, llm.Buildable) (llm.Stream, error) {
+	return llmtest.SendEvents(p.events...), nil
+}
+
+func TestWrap_OrderAndPassThrough(t *testing.T) {
+	var calls []string
+	tag := func(name string) llm.Middleware {
+		return llm.StreamMiddleware(func(ctx context.Context, src llm.Buildable, next llm.Provider) (llm.Stream, error) {
+			calls = append(calls, name)
+			return next.CreateStream(ctx, src)
+		})
+	}
+
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{llmtest.TextEvent("hi"), llmtest.CompletedEvent(llm.StopReasonEndTurn)}}, tag("outer"), tag("inner"))
+	assert.Equal(t, "fake", p.Name())
+	assert.Len(t, p.Models(), 1)
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model"})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", llm.NewEventProcessor(context.Background(), stream).Result().Text())
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		llmtest.TextEvent("hi"),
+		llmtest.UsageTokenEvent("fake", "fake-model", 10, 3),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, llm.LoggingMiddleware(logger))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var start, done map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &start))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &done))
+
+	assert.Equal(t, "llm stream start", start["msg"])
+	assert.Equal(t, "fake", start["provider"])
+	assert.Equal(t, "fake-model", start["model"])
+
+	assert.Equal(t, "llm stream done", done["msg"])
+	assert.Equal(t, "INFO", done["level"])
+	assert.Equal(t, string(llm.StopReasonEndTurn), done["stop_reason"])
+	assert.Equal(t, float64(10), done["input_tokens"])
+	assert.Equal(t, float64(3), done["output_tokens"])
+}
+
+func TestLoggingMiddleware_StreamError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		llmtest.ErrorEvent(llm.NewErrProviderMsg("fake", "overloaded")),
+	}}, llm.LoggingMiddleware(logger))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model"})
+	require.NoError(t, err)
+	require.Error(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	assert.Contains(t, buf.String(), `"level":"ERROR"`)
+	assert.Contains(t, buf.String(), "overloaded")
+}
+
+func TestUsageMiddleware_RecordsAcrossCalls(t *testing.T) {
+	tracker := usage.NewTracker()
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		llmtest.UsageTokenEvent("fake", "fake-model", 10, 3),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, llm.UsageMiddleware(tracker))
+
+	for range 2 {
+		stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model"})
+		require.NoError(t, err)
+		require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+	}
+
+	agg := tracker.Aggregate()
+	assert.Equal(t, 20, agg.Tokens.Count(usage.KindInput))
+	assert.Equal(t, 6, agg.Tokens.Count(usage.KindOutput))
+	assert.Len(t, tracker.Filter(usage.ByProvider("fake")), 2)
+}
+
+func TestService_WithMiddleware(t *testing.T) {
+	tracker := usage.NewTracker()
+	svc, err := llm.New(
+		llm.WithProvider(&middlewareTestProvider{events: []llm.Event{
+			llmtest.UsageTokenEvent("fake", "fake-model", 5, 1),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		}}),
+		llm.WithMiddleware(llm.UsageMiddleware(tracker)),
+	)
+	require.NoError(t, err)
+
+	stream, err := svc.CreateStream(context.Background(), llm.Request{Model: "fake-model", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+	assert.Len(t, tracker.Records(), 1)
+}
+
+func TestObserveStream_CancelledConsumer(t *testing.T) {
+	in := make(chan llm.Envelope)
+	ctx, cancel := context.WithCancel(context.Background())
+	var seen int
+	done := make(chan struct{})
+	out := llm.ObserveStream(ctx, in, func(llm.Envelope) { seen++ }, func() { close(done) })
+
+	// Fill the output buffer without reading it, then cancel: the producer
+	// must not block and done must still see every event.
+	cancel()
+	for i := 0; i < 100; i++ {
+		in <- llm.Envelope{Type: llm.StreamEventDelta}
+	}
+	close(in)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("done not called after cancellation")
+	}
+	assert.Equal(t, 100, seen)
+	for range out {
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/codewandler/llm/internal/modelcatalog"
+)
+
+// DefaultModelCatalogMaxAge is how old the cached models.dev database may be
+// before RefreshModelCatalog fetches it again.
+const DefaultModelCatalogMaxAge = modelcatalog.DefaultRefreshMaxAge
+
+// CatalogRefreshOption configures RefreshModelCatalog.
+type CatalogRefreshOption func(*catalogRefreshConfig)
+
+type catalogRefreshConfig struct {
+	opts    modelcatalog.RefreshOptions
+	onError func(error)
+}
+
+// WithCatalogURL fetches the models.dev database from url instead of
+// https://models.dev/api.json, e.g. from an internal mirror.
+func WithCatalogURL(url string) CatalogRefreshOption {
+	return func(c *catalogRefreshConfig) { c.opts.URL = url }
+}
+
+// WithCatalogHTTPClient sets the HTTP client used to fetch the database.
+func WithCatalogHTTPClient(client *http.Client) CatalogRefreshOption {
+	return func(c *catalogRefreshConfig) { c.opts.Client = client }
+}
+
+// WithCatalogCacheDir sets the directory the database is cached in. The
+// default is <user cache dir>/llm/models.dev.
+func WithCatalogCacheDir(dir string) CatalogRefreshOption {
+	return func(c *catalogRefreshConfig) { c.opts.CacheDir = dir }
+}
+
+// WithCatalogMaxAge sets how old the cached database may be before it is
+// fetched again. Negative values fetch on every refresh.
+func WithCatalogMaxAge(d time.Duration) CatalogRefreshOption {
+	return func(c *catalogRefreshConfig) { c.opts.MaxAge = d }
+}
+
+// WithCatalogErrorHandler receives the errors of background refreshes started
+// by AutoRefreshModelCatalog, which are otherwise dropped.
+func WithCatalogErrorHandler(fn func(error)) CatalogRefreshOption {
+	return func(c *catalogRefreshConfig) { c.onError = fn }
+}
+
+func applyCatalogRefreshOptions(opts []CatalogRefreshOption) catalogRefreshConfig {
+	var cfg catalogRefreshConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// RefreshModelCatalog updates model pricing and limits from the models.dev
+// database, so they stay current between library releases. The database is
+// validated, cached on disk and, from then on, served in preference to the
+// snapshot embedded in the library: cost calculation (usage.Default), context
+// windows and model routing all see the refreshed values.
+//
+// A cached copy younger than the max age is used without a network request,
+// so calling RefreshModelCatalog at startup is cheap. When fetching fails, a
+// cached copy of any age is still applied and the error is returned; with no
+// cache the embedded snapshot stays in use.
+//
+// Only models the embedded snapshot knows are updated. Use
+// PinEmbeddedModelCatalog for reproducible pricing.
+func RefreshModelCatalog(ctx context.Context, opts ...CatalogRefreshOption) error {
+	return modelcatalog.Refresh(ctx, applyCatalogRefreshOptions(opts).opts)
+}
+
+// AutoRefreshModelCatalog runs RefreshModelCatalog now and then every
+// interval in the background until ctx is done. An interval of zero or less
+// refreshes every DefaultModelCatalogMaxAge.
+func AutoRefreshModelCatalog(ctx context.Context, interval time.Duration, opts ...CatalogRefreshOption) {
+	if interval <= 0 {
+		interval = DefaultModelCatalogMaxAge
+	}
+	cfg := applyCatalogRefreshOptions(opts)
+	refresh := func() {
+		if err := modelcatalog.Refresh(ctx, cfg.opts); err != nil && cfg.onError != nil && ctx.Err() == nil {
+			cfg.onError(err)
+		}
+	}
+	go func() {
+		refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// PinEmbeddedModelCatalog makes the library use only the model catalog
+// embedded at build time (pin true), ignoring refreshed data, or switches
+// back to the refreshed catalog (pin false).
+func PinEmbeddedModelCatalog(pin bool) {
+	modelcatalog.Pin(pin)
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/usage"
+)
+
+func TestRefreshModelCatalog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"anthropic": {"models": {"claude-sonnet-4-6": {
+			"cost": {"input": 4, "output": 20}, "limit": {"context": 500000, "output": 64000}}}}}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { PinEmbeddedModelCatalog(true) })
+
+	calc := usage.Default()
+	tokens := usage.TokenItems{{Kind: usage.KindInput, Count: 1_000_000}}
+	before, ok := calc.Calculate("anthropic", "claude-sonnet-4-6", tokens)
+	require.True(t, ok)
+
+	require.NoError(t, RefreshModelCatalog(context.Background(),
+		WithCatalogURL(srv.URL), WithCatalogCacheDir(t.TempDir())))
+
+	cost, ok := calc.Calculate("anthropic", "claude-sonnet-4-6", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 4.0, cost.Input, 1e-9, "calculators from usage.Default follow refreshes")
+	window, ok := ContextWindow("anthropic", "claude-sonnet-4-6")
+	require.True(t, ok)
+	assert.Equal(t, 500000, window)
+
+	PinEmbeddedModelCatalog(true)
+	cost, _ = calc.Calculate("anthropic", "claude-sonnet-4-6", tokens)
+	assert.Equal(t, before, cost)
+}
+
+func TestAutoRefreshModelCatalog_DefaultInterval(t *testing.T) {
+	fetched := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"anthropic": {"models": {"claude-sonnet-4-6": {"cost": {"input": 4, "output": 20}}}}}`))
+		select {
+		case fetched <- struct{}{}:
+		default:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { PinEmbeddedModelCatalog(true) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	// A zero interval must not panic the background goroutine.
+	AutoRefreshModelCatalog(ctx, 0, WithCatalogURL(srv.URL), WithCatalogCacheDir(t.TempDir()))
+	select {
+	case <-fetched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("initial refresh did not run")
+	}
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultModelRefreshTTL is how long a Service keeps models fetched by
+// Refresh before fetching them again.
+const DefaultModelRefreshTTL = 10 * time.Minute
+
+// WithModelRefreshTTL sets how long models fetched by Service.Refresh stay
+// cached. Zero or negative values make every Refresh fetch again.
+func WithModelRefreshTTL(ttl time.Duration) ServiceOption {
+	return func(c *ServiceConfig) { c.ModelRefreshTTL = &ttl }
+}
+
+// modelCache holds the models fetched per provider, indexed like
+// Service.providers.
+type modelCache struct {
+	ttl      time.Duration
+	fetchers []ModelFetcher // nil for providers without FetchModels
+
+	mu      sync.RWMutex
+	fetched []Models
+	at      []time.Time
+}
+
+func newModelCache(fetchers []ModelFetcher, ttl time.Duration) *modelCache {
+	return &modelCache{
+		ttl:      ttl,
+		fetchers: fetchers,
+		fetched:  make([]Models, len(fetchers)),
+		at:       make([]time.Time, len(fetchers)),
+	}
+}
+
+// Refresh fetches the model lists of all providers implementing
+// ModelFetcher whose cached list is older than the refresh TTL, and merges
+// them into the models the Service resolves requests against. Providers
+// that fail keep their previous list; their errors are returned joined.
+func (s *Service) Refresh(ctx context.Context) error {
+	c := s.models
+	if c == nil {
+		return nil
+	}
+	var errs []error
+	for i, f := range c.fetchers {
+		if f == nil {
+			continue
+		}
+		c.mu.RLock()
+		fresh := !c.at[i].IsZero() && time.Since(c.at[i]) < c.ttl
+		c.mu.RUnlock()
+		if fresh {
+			continue
+		}
+		models, err := f.FetchModels(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("refresh models of %s: %w", s.providers[i].Provider.Name(), err))
+			continue
+		}
+		c.mu.Lock()
+		c.fetched[i] = models
+		c.at[i] = time.Now()
+		c.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Models returns the models of all providers: their static lists merged
+// with the lists fetched by Refresh.
+func (s *Service) Models() Models {
+	var out Models
+	for i := range s.providers {
+		out = append(out, s.providerModels(i)...)
+	}
+	return out
+}
+
+// providerModels returns the static models of provider i followed by the
+// fetched models not already listed, de-duplicated by ID. A fetched model
+// adds its pricing to a static one that has none.
+func (s *Service) providerModels(i int) Models {
+	static := s.providers[i].Provider.Models()
+	if s.models == nil {
+		return static
+	}
+	s.models.mu.RLock()
+	fetched := s.models.fetched[i]
+	s.models.mu.RUnlock()
+	if len(fetched) == 0 {
+		return static
+	}
+
+	out := make(Models, 0, len(static)+len(fetched))
+	index := make(map[string]int, len(static)+len(fetched))
+	for _, m := range static {
+		if _, dup := index[m.ID]; dup {
+			continue
+		}
+		index[m.ID] = len(out)
+		out = append(out, m)
+	}
+	for _, m := range fetched {
+		if j, dup := index[m.ID]; dup {
+			if out[j].Pricing == nil {
+				out[j].Pricing = m.Pricing
+			}
+			continue
+		}
+		index[m.ID] = len(out)
+		out = append(out, m)
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/usage"
+)
+
+type fetchingTestProvider struct {
+	serviceTestProvider
+	fetch func() ([]Model, error)
+	calls int
+}
+
+func (p *fetchingTestProvider) FetchModels(context.Context) ([]Model, error) {
+	p.calls++
+	return p.fetch()
+}
+
+func TestServiceRefresh_MergesFetchedModels(t *testing.T) {
+	p := &fetchingTestProvider{
+		serviceTestProvider: serviceTestProvider{name: "fake", models: Models{{ID: "static", Provider: "fake"}}, stream: completedStream},
+		fetch: func() ([]Model, error) {
+			return []Model{
+				{ID: "static", Provider: "fake", Pricing: &usage.Pricing{Input: 1}},
+				{ID: "fetched", Provider: "fake"},
+				{ID: "fetched", Provider: "fake"},
+			}, nil
+		},
+	}
+	service, err := New(WithProvider(p))
+	require.NoError(t, err)
+
+	_, err = service.resolveModelSpec("fetched")
+	require.ErrorIs(t, err, ErrUnknownModel)
+
+	require.NoError(t, service.Refresh(context.Background()))
+	models := service.Models()
+	require.Len(t, models, 2)
+	assert.Equal(t, "static", models[0].ID)
+	require.NotNil(t, models[0].Pricing)
+	assert.Equal(t, "fetched", models[1].ID)
+
+	resolved, err := service.resolveModelSpec("fetched")
+	require.NoError(t, err)
+	assert.Equal(t, "provider-models", resolved.Offerings[0].Source)
+}
+
+func TestServiceRefresh_TTLAndErrors(t *testing.T) {
+	fail := false
+	p := &fetchingTestProvider{
+		serviceTestProvider: serviceTestProvider{name: "fake", stream: completedStream},
+		fetch: func() ([]Model, error) {
+			if fail {
+				return nil, errors.New("boom")
+			}
+			return []Model{{ID: "fetched", Provider: "fake"}}, nil
+		},
+	}
+	service, err := New(WithProvider(p))
+	require.NoError(t, err)
+	require.NoError(t, service.Refresh(context.Background()))
+	require.NoError(t, service.Refresh(context.Background()))
+	assert.Equal(t, 1, p.calls, "second refresh within the TTL must use the cache")
+
+	service, err = New(WithProvider(p), WithModelRefreshTTL(0))
+	require.NoError(t, err)
+	require.NoError(t, service.Refresh(context.Background()))
+	fail = true
+	err = service.Refresh(context.Background())
+	assert.ErrorContains(t, err, "refresh models of fake: boom")
+	assert.Equal(t, Models{{ID: "fetched", Provider: "fake"}}, service.Models(), "failed refresh keeps the previous list")
+}
@@ -31,6 +31,19 @@ type Options struct {
 	// at Debug level using the same message format as the HTTP transport logger
 	// so the same renderer handles both.
 	Logger *slog.Logger
+
+	// Retry enables automatic retries of transient HTTP failures when set.
+	// See WithRetry.
+	Retry *RetryOptions
+
+	// Telemetry instruments CreateStream when set. See WithTelemetry.
+	Telemetry Telemetry
+
+	// RateLimiter throttles CreateStream when set. See WithRateLimit.
+	RateLimiter *RateLimiter
+
+	// Header holds extra headers sent with every API request. See WithHeader.
+	Header http.Header
 }
 
 // Apply applies all options to a new Options struct and returns it.
@@ -50,10 +63,25 @@ func WithBaseURL(url string) Option {
 }
 
 // WithHTTPClient sets a custom HTTP client for the provider.
-// When not set, providers use DefaultHttpClient().
+// When not set, providers use DefaultHttpClient(). The client is used
+// through a shallow copy whose transport refuses to send DryRun requests.
 func WithHTTPClient(c *http.Client) Option {
 	return func(o *Options) {
-		o.HTTPClient = c
+		o.HTTPClient = guardDryRun(c)
+	}
+}
+
+// WithHeader sets a header that is sent with every API request, e.g. for a
+// proxy or gateway. Authentication headers set by the provider take
+// precedence.
+func WithHeader(key, value string) Option {
+	return func(o *Options) {
+		// Clone so options derived from a shared base do not alias its map.
+		o.Header = o.Header.Clone()
+		if o.Header == nil {
+			o.Header = make(http.Header)
+		}
+		o.Header.Set(key, value)
 	}
 }
 
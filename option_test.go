@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -102,6 +103,30 @@ func TestWithAPIKeyFunc(t *testing.T) {
 	})
 }
 
+func TestWithRotatingAPIKey(t *testing.T) {
+	key := NewRotatingAPIKey("first")
+	opts := Apply(WithRotatingAPIKey(key))
+
+	got, err := opts.ResolveAPIKey(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "first", got)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key.Rotate("second")
+			_, _ = opts.ResolveAPIKey(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	got, err = opts.ResolveAPIKey(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second", got)
+}
+
 func TestAPIKeyFromEnv(t *testing.T) {
 	// Clean up env vars after tests
 	defer func() {
@@ -0,0 +1,163 @@
+// Package otel reports llm streams to OpenTelemetry. It is a separate module
+// so the core library does not depend on the OpenTelemetry SDK.
+//
+// Every stream becomes a client span named "chat <model>" carrying the GenAI
+// semantic convention attributes of llm.StreamStats, and is recorded in
+// these instruments:
+//
+//	gen_ai.client.operation.duration  histogram, seconds
+//	gen_ai.client.token.usage         histogram, tokens by gen_ai.token.type
+//	llm.client.time_to_first_token    histogram, seconds
+//	llm.client.cost                   counter, USD
+//
+// Example:
+//
+//	p := anthropic.New(otel.WithOpenTelemetry(tracerProvider, meterProvider))
+//
+// or, for a whole Service:
+//
+//	svc, err := llm.New(..., llm.WithMiddleware(llm.TelemetryMiddleware(otel.New(tp, mp))))
+package otel
+
+import (
+	"context"
+	"sort"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/codewandler/llm"
+)
+
+const scope = "github.com/codewandler/llm/otel"
+
+// Telemetry implements llm.Telemetry with an OpenTelemetry tracer and meter.
+type Telemetry struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	tokens   metric.Int64Histogram
+	ttft     metric.Float64Histogram
+	cost     metric.Float64Counter
+}
+
+// New creates a Telemetry that records spans with tp and metrics with mp.
+// Nil providers fall back to the global ones. Instrument creation errors
+// are reported to the OpenTelemetry error handler; the affected instrument
+// then records nothing.
+func New(tp trace.TracerProvider, mp metric.MeterProvider) *Telemetry {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(scope)
+	t := &Telemetry{tracer: tp.Tracer(scope)}
+
+	var err error
+	if t.duration, err = meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of LLM streams")); err != nil {
+		otel.Handle(err)
+	}
+	if t.tokens, err = meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithUnit("{token}"), metric.WithDescription("Tokens used per LLM stream")); err != nil {
+		otel.Handle(err)
+	}
+	if t.ttft, err = meter.Float64Histogram("llm.client.time_to_first_token",
+		metric.WithUnit("s"), metric.WithDescription("Time until the first delta of LLM streams")); err != nil {
+		otel.Handle(err)
+	}
+	if t.cost, err = meter.Float64Counter("llm.client.cost",
+		metric.WithUnit("USD"), metric.WithDescription("Cost of LLM streams")); err != nil {
+		otel.Handle(err)
+	}
+	return t
+}
+
+// WithOpenTelemetry instruments every CreateStream of a provider with
+// New(tp, mp). See llm.WithTelemetry.
+func WithOpenTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) llm.Option {
+	return llm.WithTelemetry(New(tp, mp))
+}
+
+// StartStream implements llm.Telemetry.
+func (t *Telemetry) StartStream(ctx context.Context, info llm.StreamInfo) (context.Context, func(llm.StreamStats)) {
+	ctx, span := t.tracer.Start(ctx, "chat "+info.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.system", info.Provider),
+			attribute.String("gen_ai.request.model", info.Model),
+		))
+	return ctx, func(s llm.StreamStats) {
+		span.SetAttributes(attributes(s.Attributes())...)
+		if s.Err != nil {
+			span.RecordError(s.Err)
+			span.SetStatus(codes.Error, s.Err.Error())
+		}
+		span.End()
+		t.record(ctx, s)
+	}
+}
+
+func (t *Telemetry) record(ctx context.Context, s llm.StreamStats) {
+	common := []attribute.KeyValue{
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.system", s.Provider),
+		attribute.String("gen_ai.request.model", s.Model),
+	}
+	if s.ResolvedModel != "" {
+		common = append(common, attribute.String("gen_ai.response.model", s.ResolvedModel))
+	}
+	if s.Err != nil {
+		common = append(common, attribute.String("error.type", s.Attributes()["error.type"].(string)))
+	}
+	with := func(extra ...attribute.KeyValue) metric.MeasurementOption {
+		return metric.WithAttributes(append(append([]attribute.KeyValue(nil), common...), extra...)...)
+	}
+
+	if t.duration != nil {
+		t.duration.Record(ctx, s.Duration.Seconds(), with())
+	}
+	if t.tokens != nil && len(s.Tokens) > 0 {
+		t.tokens.Record(ctx, int64(s.Tokens.TotalInput()), with(attribute.String("gen_ai.token.type", "input")))
+		t.tokens.Record(ctx, int64(s.Tokens.TotalOutput()), with(attribute.String("gen_ai.token.type", "output")))
+	}
+	if t.ttft != nil && s.TimeToFirstToken > 0 {
+		t.ttft.Record(ctx, s.TimeToFirstToken.Seconds(), with())
+	}
+	if t.cost != nil && s.Cost > 0 {
+		t.cost.Add(ctx, s.Cost, with())
+	}
+}
+
+// attributes converts llm.StreamStats.Attributes to OpenTelemetry
+// attributes, sorted by key.
+func attributes(m map[string]any) []attribute.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		switch v := m[k].(type) {
+		case string:
+			out = append(out, attribute.String(k, v))
+		case int:
+			out = append(out, attribute.Int(k, v))
+		case int64:
+			out = append(out, attribute.Int64(k, v))
+		case float64:
+			out = append(out, attribute.Float64(k, v))
+		case bool:
+			out = append(out, attribute.Bool(k, v))
+		case []string:
+			out = append(out, attribute.StringSlice(k, v))
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/otel"
+	"github.com/codewandler/llm/usage"
+)
+
+func setup(t *testing.T) (*otel.Telemetry, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	return otel.New(tp, mp), spans, reader
+}
+
+func run(t *testing.T, tel llm.Telemetry, evs ...llm.Event) {
+	t.Helper()
+	stream, err := llm.InstrumentStream(context.Background(), tel, llm.StreamInfo{Provider: "anthropic", Model: "claude-sonnet-4-6"},
+		func(context.Context) (llm.Stream, error) { return llmtest.SendEvents(evs...), nil })
+	require.NoError(t, err)
+	for range stream {
+	}
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	out := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+	return out
+}
+
+func TestTelemetry_SpanAndMetrics(t *testing.T) {
+	tel, spans, reader := setup(t)
+	run(t, tel,
+		&llm.StreamStartedEvent{Model: "claude-sonnet-4-6-20260101", RequestID: "req-1"},
+		llmtest.TextEvent("hi"),
+		llmtest.UsageEvent(usage.Record{
+			Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 10}, {Kind: usage.KindOutput, Count: 4}},
+			Cost:   usage.Cost{Total: 0.25},
+		}),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "chat claude-sonnet-4-6", span.Name())
+	attrs := attribute.NewSet(span.Attributes()...)
+	v, ok := attrs.Value("gen_ai.usage.input_tokens")
+	require.True(t, ok)
+	assert.Equal(t, int64(10), v.AsInt64())
+	v, _ = attrs.Value("gen_ai.response.model")
+	assert.Equal(t, "claude-sonnet-4-6-20260101", v.AsString())
+	v, _ = attrs.Value("gen_ai.response.finish_reasons")
+	assert.Equal(t, []string{"end_turn"}, v.AsStringSlice())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	metrics := collect(t, reader)
+	tokens := metrics["gen_ai.client.token.usage"].(metricdata.Histogram[int64])
+	var input, output int64
+	for _, dp := range tokens.DataPoints {
+		typ, _ := dp.Attributes.Value("gen_ai.token.type")
+		switch typ.AsString() {
+		case "input":
+			input = dp.Sum
+		case "output":
+			output = dp.Sum
+		}
+	}
+	assert.Equal(t, int64(10), input)
+	assert.Equal(t, int64(4), output)
+
+	duration := metrics["gen_ai.client.operation.duration"].(metricdata.Histogram[float64])
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+
+	cost := metrics["llm.client.cost"].(metricdata.Sum[float64])
+	require.Len(t, cost.DataPoints, 1)
+	assert.InDelta(t, 0.25, cost.DataPoints[0].Value, 1e-9)
+
+	require.Contains(t, metrics, "llm.client.time_to_first_token")
+}
+
+func TestTelemetry_Error(t *testing.T) {
+	tel, spans, reader := setup(t)
+	run(t, tel, llmtest.ErrorEvent(llm.NewErrAPIError("anthropic", 429, "slow down")))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+
+	duration := collect(t, reader)["gen_ai.client.operation.duration"].(metricdata.Histogram[float64])
+	require.Len(t, duration.DataPoints, 1)
+	errType, ok := duration.DataPoints[0].Attributes.Value("error.type")
+	require.True(t, ok)
+	assert.NotEmpty(t, errType.AsString())
+}
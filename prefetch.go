@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Prefetcher speculatively starts a request for a predicted next input, for
+// example while the user is still typing, to cut perceived latency in
+// interactive apps.
+//
+// A prefetched stream is buffered until the real request arrives through
+// CreateStream. If the real request matches the prediction, the prefetched
+// stream is promoted: its buffered events are replayed and the rest is
+// forwarded live. Otherwise the prefetch is cancelled and the real request is
+// sent normally. Starting a new prefetch cancels the previous one.
+//
+// Prefetcher is experimental. Every cancelled prefetch still costs the input
+// tokens the provider processed before cancellation.
+type Prefetcher struct {
+	s     Streamer
+	match func(predicted, actual Request) bool
+
+	mu      sync.Mutex
+	pending *prefetch
+}
+
+// NewPrefetcher returns a Prefetcher that sends requests through s.
+// Predictions match real requests when they are identical.
+func NewPrefetcher(s Streamer) *Prefetcher {
+	return &Prefetcher{s: s, match: sameRequest}
+}
+
+// WithMatch replaces the function deciding whether a prefetched request can
+// serve the real one.
+func (p *Prefetcher) WithMatch(fn func(predicted, actual Request) bool) *Prefetcher {
+	p.match = fn
+	return p
+}
+
+// Prefetch starts src in the background, replacing any pending prefetch.
+// ctx bounds the speculative request until it is promoted; after promotion
+// the context of the CreateStream call also cancels it.
+func (p *Prefetcher) Prefetch(ctx context.Context, src Buildable) error {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return err
+	}
+	pctx, cancel := context.WithCancel(ctx)
+	pf := &prefetch{req: req, cancel: cancel, ready: make(chan struct{}), notify: make(chan struct{})}
+
+	p.mu.Lock()
+	prev := p.pending
+	p.pending = pf
+	p.mu.Unlock()
+	if prev != nil {
+		prev.cancel()
+	}
+
+	go pf.run(pctx, p.s)
+	return nil
+}
+
+// Cancel discards the pending prefetch, if any.
+func (p *Prefetcher) Cancel() {
+	if pf := p.take(); pf != nil {
+		pf.cancel()
+	}
+}
+
+// CreateStream implements Streamer. It promotes the pending prefetch when it
+// matches src and otherwise cancels it and sends src through the wrapped
+// Streamer.
+func (p *Prefetcher) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pf := p.take()
+	if pf == nil {
+		return p.s.CreateStream(ctx, req)
+	}
+	if !p.match(pf.req, req) {
+		pf.cancel()
+		return p.s.CreateStream(ctx, req)
+	}
+
+	select {
+	case <-pf.ready:
+	case <-ctx.Done():
+		pf.cancel()
+		return nil, ctx.Err()
+	}
+	if pf.err != nil {
+		// The speculative call failed (or was cancelled); retry for real.
+		return p.s.CreateStream(ctx, req)
+	}
+	stop := context.AfterFunc(ctx, pf.cancel)
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		defer stop()
+		defer pf.cancel()
+		pf.replay(ctx, out)
+	}()
+	return out, nil
+}
+
+func (p *Prefetcher) take() *prefetch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pf := p.pending
+	p.pending = nil
+	return pf
+}
+
+// prefetch buffers the events of one speculative stream.
+type prefetch struct {
+	req    Request
+	cancel context.CancelFunc
+
+	ready chan struct{} // closed once CreateStream returned
+	err   error         // CreateStream error, valid after ready
+
+	mu     sync.Mutex
+	events []Envelope
+	done   bool
+	notify chan struct{} // closed and replaced whenever events or done change
+}
+
+func (pf *prefetch) run(ctx context.Context, s Streamer) {
+	stream, err := s.CreateStream(ctx, pf.req)
+	pf.err = err
+	close(pf.ready)
+	if err != nil {
+		pf.finish()
+		return
+	}
+	for ev := range stream {
+		pf.mu.Lock()
+		pf.events = append(pf.events, ev)
+		pf.signal()
+		pf.mu.Unlock()
+	}
+	pf.finish()
+}
+
+func (pf *prefetch) finish() {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	pf.done = true
+	pf.signal()
+}
+
+// signal wakes replay. Callers must hold pf.mu.
+func (pf *prefetch) signal() {
+	close(pf.notify)
+	pf.notify = make(chan struct{})
+}
+
+// replay sends the buffered events to out and then follows the live stream
+// until it ends or ctx is done.
+func (pf *prefetch) replay(ctx context.Context, out chan<- Envelope) {
+	for i := 0; ; {
+		pf.mu.Lock()
+		pending := pf.events[i:]
+		done := pf.done
+		notify := pf.notify
+		pf.mu.Unlock()
+
+		for _, ev := range pending {
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+		i += len(pending)
+		if done && len(pending) == 0 {
+			return
+		}
+		if len(pending) > 0 {
+			continue
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sameRequest reports whether a and b encode to the same JSON. ToolChoice
+// variants marshal identically, so they are compared separately.
+func sameRequest(a, b Request) bool {
+	if (a.ToolChoice == nil) != (b.ToolChoice == nil) {
+		return false
+	}
+	if a.ToolChoice != nil && a.ToolChoice.String() != b.ToolChoice.String() {
+		return false
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
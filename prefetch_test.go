@@ -0,0 +1,150 @@
+package llm_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+// recordingStreamer answers every request with its user text and records the
+// request contexts so tests can observe cancellation.
+type recordingStreamer struct {
+	mu   sync.Mutex
+	ctxs []context.Context
+	reqs []llm.Request
+}
+
+func (s *recordingStreamer) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.ctxs = append(s.ctxs, ctx)
+	s.reqs = append(s.reqs, req)
+	s.mu.Unlock()
+	return llmtest.SendEvents(
+		llmtest.TextEvent("re: "+req.Messages[len(req.Messages)-1].Text()),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	), nil
+}
+
+func (s *recordingStreamer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.reqs)
+}
+
+func (s *recordingStreamer) ctx(i int) context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctxs[i]
+}
+
+func prefetchRequest(text string) llm.Request {
+	return llm.Request{Model: "m", Messages: llm.Messages{llm.User(text)}}
+}
+
+func TestPrefetcher_PromotesMatchingRequest(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up)
+
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("hello")))
+	require.Eventually(t, func() bool { return up.calls() == 1 }, time.Second, time.Millisecond)
+
+	stream, err := p.CreateStream(ctx, prefetchRequest("hello"))
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(ctx, stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "re: hello", res.Text())
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason())
+	assert.Equal(t, 1, up.calls())
+}
+
+func TestPrefetcher_PromotedStreamReleasesPrefetchContext(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up)
+
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("hello")))
+	stream, err := p.CreateStream(ctx, prefetchRequest("hello"))
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	assert.ErrorIs(t, up.ctx(0).Err(), context.Canceled)
+}
+
+func TestPrefetcher_CancelsMismatchedPrediction(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up)
+
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("hel")))
+	require.Eventually(t, func() bool { return up.calls() == 1 }, time.Second, time.Millisecond)
+
+	stream, err := p.CreateStream(ctx, prefetchRequest("hello"))
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(ctx, stream).Result()
+
+	assert.Equal(t, "re: hello", res.Text())
+	assert.Equal(t, 2, up.calls())
+	assert.ErrorIs(t, up.ctx(0).Err(), context.Canceled)
+}
+
+func TestPrefetcher_NewPredictionCancelsPrevious(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up)
+
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("a")))
+	require.Eventually(t, func() bool { return up.calls() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("ab")))
+	require.Eventually(t, func() bool { return up.calls() == 2 }, time.Second, time.Millisecond)
+
+	assert.ErrorIs(t, up.ctx(0).Err(), context.Canceled)
+	assert.NoError(t, up.ctx(1).Err())
+
+	p.Cancel()
+	assert.ErrorIs(t, up.ctx(1).Err(), context.Canceled)
+}
+
+func TestPrefetcher_WithMatch(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up).WithMatch(func(predicted, actual llm.Request) bool {
+		return predicted.Messages[0].Text() == actual.Messages[0].Text()
+	})
+
+	require.NoError(t, p.Prefetch(ctx, prefetchRequest("hi")))
+	req := prefetchRequest("hi")
+	req.MaxTokens = 10
+	stream, err := p.CreateStream(ctx, req)
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(ctx, stream).Result()
+
+	assert.Equal(t, "re: hi", res.Text())
+	assert.Equal(t, 1, up.calls())
+}
+
+func TestPrefetcher_WithoutPrefetchPassesThrough(t *testing.T) {
+	ctx := context.Background()
+	up := &recordingStreamer{}
+	p := llm.NewPrefetcher(up)
+
+	stream, err := p.CreateStream(ctx, prefetchRequest("x"))
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(ctx, stream).Result()
+
+	assert.Equal(t, "re: x", res.Text())
+	assert.Equal(t, 1, up.calls())
+}
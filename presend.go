@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+)
+
+// PreSendHook rewrites a request just before a Service sends it to a
+// provider. It works on a copy: changes apply to this provider attempt only
+// and never leak into the caller's request or a fallback candidate. An error
+// fails the attempt.
+type PreSendHook func(ctx context.Context, req Request) (Request, error)
+
+// PreSendRule scopes a PreSendHook to providers and models, so deployment
+// quirks live in one place instead of at every call site.
+type PreSendRule struct {
+	// Provider matches the candidate's Name, ServiceID or Provider.Name().
+	// Empty matches every provider.
+	Provider string
+
+	// Model is a path.Match pattern applied to the requested model, e.g.
+	// "gpt-4*". Empty matches every model.
+	Model string
+
+	Hook PreSendHook
+}
+
+func (r PreSendRule) matches(candidate RegisteredProvider, model string) bool {
+	if r.Provider != "" && r.Provider != candidate.Name && r.Provider != candidate.ServiceID &&
+		r.Provider != candidate.Provider.Name() {
+		return false
+	}
+	if r.Model != "" {
+		if ok, _ := path.Match(r.Model, model); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// WithPreSendHook registers rule on the Service. Matching rules run in
+// registration order, each receiving the previous rule's output.
+func WithPreSendHook(rule PreSendRule) ServiceOption {
+	return func(c *ServiceConfig) { c.PreSend = append(c.PreSend, rule) }
+}
+
+// preSend runs the hooks matching candidate on a copy of req.
+func (s *Service) preSend(ctx context.Context, candidate RegisteredProvider, req Request) (Request, error) {
+	copied := false
+	for _, rule := range s.preSendRules {
+		if rule.Hook == nil || !rule.matches(candidate, req.Model) {
+			continue
+		}
+		if !copied {
+			req = cloneRequest(req)
+			copied = true
+		}
+		var err error
+		if req, err = rule.Hook(ctx, req); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// cloneRequest copies the messages, their parts and the tool definitions of
+// req so that hooks may edit them in place.
+func cloneRequest(req Request) Request {
+	req.Messages = cloneMessages(req.Messages)
+	if req.Tools != nil {
+		tools := make([]tool.Definition, len(req.Tools))
+		for i, t := range req.Tools {
+			tools[i] = t.Clone()
+		}
+		req.Tools = tools
+	}
+	return req
+}
+
+// cloneMessages copies msgs and their parts so that hooks may edit them in
+// place.
+func cloneMessages(msgs Messages) Messages {
+	if msgs == nil {
+		return nil
+	}
+	out := make(Messages, len(msgs))
+	for i, m := range msgs {
+		m.Parts = append(msg.Parts(nil), m.Parts...)
+		out[i] = m
+	}
+	return out
+}
+
+// MapText returns a PreSendHook that replaces the text parts of every
+// message with fn(role, text).
+func MapText(fn func(role msg.Role, text string) string) PreSendHook {
+	return func(_ context.Context, req Request) (Request, error) {
+		for i := range req.Messages {
+			m := &req.Messages[i]
+			for j := range m.Parts {
+				if m.Parts[j].Type == msg.PartTypeText {
+					m.Parts[j].Text = fn(m.Role, m.Parts[j].Text)
+				}
+			}
+		}
+		return req, nil
+	}
+}
+
+// RewriteSystemPrompt returns a PreSendHook that replaces the text of system
+// and developer messages with fn(text).
+func RewriteSystemPrompt(fn func(text string) string) PreSendHook {
+	return MapText(func(role msg.Role, text string) string {
+		if role != msg.RoleSystem && role != msg.RoleDeveloper {
+			return text
+		}
+		return fn(text)
+	})
+}
+
+var (
+	markdownFence   = regexp.MustCompile("(?m)^\\s*```[^\\n]*\\n?")
+	markdownHeading = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownLink    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownBold    = regexp.MustCompile(`\*\*(\S(?:[^\n]*?\S)?)\*\*`)
+	markdownUnder   = regexp.MustCompile(`__(\S(?:[^\n]*?\S)?)__`)
+	markdownCode    = regexp.MustCompile("`([^`]+)`")
+)
+
+// StripMarkdown returns a PreSendHook that reduces Markdown in the text of
+// system, developer and user messages to plain text: fences, headings,
+// bold markers and inline code markers are removed and links are replaced
+// by their label. Bold markers are only removed where they stand apart from
+// the surrounding words, so identifiers like __init__ and code like 2**3
+// survive. Assistant and tool messages are left alone.
+func StripMarkdown() PreSendHook {
+	return MapText(func(role msg.Role, text string) string {
+		if role == msg.RoleAssistant || role == msg.RoleTool {
+			return text
+		}
+		return stripMarkdown(text)
+	})
+}
+
+func stripMarkdown(text string) string {
+	text = markdownFence.ReplaceAllString(text, "")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = stripBold(markdownBold, text)
+	text = stripBold(markdownUnder, text)
+	text = markdownCode.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(text)
+}
+
+// stripBold removes the **bold** or __bold__ markers matched by re where
+// they stand apart from the surrounding words, so identifiers such as
+// __init__(self) and code such as 2**3 or f(**kwargs) keep their
+// delimiters. A rejected opening delimiter, or one whose match spans
+// another delimiter, is kept as plain text, so a later pair on the same
+// line can still match.
+func stripBold(re *regexp.Regexp, text string) string {
+	var b strings.Builder
+	last, pos := 0, 0
+	for pos < len(text) {
+		m := re.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+		for i := range m {
+			m[i] += pos
+		}
+		delim := text[m[0] : m[0]+2]
+		if !emphasisOpens(text[:m[0]]) || !emphasisCloses(text[m[1]:]) || strings.Contains(text[m[2]:m[3]], delim) {
+			pos = m[0] + 2
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(text[m[2]:m[3]])
+		last, pos = m[1], m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// emphasisOpens reports whether an opening delimiter may follow before:
+// at the start of the text, after whitespace or after an opening bracket
+// or quote.
+func emphasisOpens(before string) bool {
+	r, _ := utf8.DecodeLastRuneInString(before)
+	return before == "" || unicode.IsSpace(r) || strings.ContainsRune(`([{"'`, r)
+}
+
+// emphasisCloses reports whether a closing delimiter may precede after: at
+// the end of the text, before whitespace, or before closing punctuation
+// that itself ends the word.
+func emphasisCloses(after string) bool {
+	r, n := utf8.DecodeRuneInString(after)
+	if after == "" || unicode.IsSpace(r) {
+		return true
+	}
+	if !strings.ContainsRune(`.,;:!?)]}"'`, r) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(after[n:])
+	return len(after) == n || unicode.IsSpace(next)
+}
@@ -0,0 +1,158 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+)
+
+type recordingProvider struct {
+	name string
+	err  error
+	reqs []llm.Request
+}
+
+func (p *recordingProvider) Name() string { return p.name }
+func (p *recordingProvider) Models() llm.Models {
+	return llm.Models{{ID: "claude-sonnet-4-6", Provider: "anthropic"}}
+}
+func (p *recordingProvider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.reqs = append(p.reqs, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return llmtest.SendEvents(llmtest.CompletedEvent(llm.StopReasonEndTurn)), nil
+}
+
+func TestService_PreSendHookScopedToProvider(t *testing.T) {
+	first := &recordingProvider{name: "anthropic", err: llm.NewErrAPIError("anthropic", 429, "rate limit")}
+	second := &recordingProvider{name: "anthropic"}
+	svc, err := llm.New(
+		llm.WithRegisteredProvider(llm.RegisteredProvider{Name: "primary", ServiceID: "anthropic", Provider: first}),
+		llm.WithRegisteredProvider(llm.RegisteredProvider{Name: "secondary", ServiceID: "anthropic", Provider: second}),
+		llm.WithPreSendHook(llm.PreSendRule{
+			Provider: "primary",
+			Hook:     llm.RewriteSystemPrompt(func(string) string { return "rewritten" }),
+		}),
+		llm.WithPreSendHook(llm.PreSendRule{
+			Model: "claude-*",
+			Hook: func(_ context.Context, req llm.Request) (llm.Request, error) {
+				req.Messages = append(req.Messages, llm.User("tail"))
+				return req, nil
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	req := llm.Request{Model: "claude-sonnet-4-6", Messages: llm.Messages{llm.System("original"), llm.User("hi")}}
+	stream, err := svc.CreateStream(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, llm.ProcessEvents(context.Background(), stream).Error())
+
+	require.Len(t, first.reqs, 1)
+	assert.Equal(t, "rewritten", first.reqs[0].Messages[0].Text())
+	assert.Len(t, first.reqs[0].Messages, 3)
+
+	// The fallback candidate gets its own copy, untouched by the first
+	// provider's rule.
+	require.Len(t, second.reqs, 1)
+	assert.Equal(t, "original", second.reqs[0].Messages[0].Text())
+	assert.Len(t, second.reqs[0].Messages, 3)
+
+	assert.Equal(t, "original", req.Messages[0].Text())
+	assert.Len(t, req.Messages, 2)
+}
+
+func TestService_PreSendHookEditsToolsOnCopy(t *testing.T) {
+	p := &recordingProvider{name: "anthropic"}
+	svc, err := llm.New(
+		llm.WithProvider(p),
+		llm.WithPreSendHook(llm.PreSendRule{Hook: func(_ context.Context, req llm.Request) (llm.Request, error) {
+			req.Tools[0].Description = "rewritten"
+			req.Tools[0].Parameters["properties"].(map[string]any)["city"] = map[string]any{"type": "integer"}
+			return req, nil
+		}}),
+	)
+	require.NoError(t, err)
+
+	req := llm.Request{
+		Model:    "claude-sonnet-4-6",
+		Messages: llm.Messages{llm.User("hi")},
+		Tools: []tool.Definition{{Name: "weather", Description: "original", Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		}}},
+	}
+	stream, err := svc.CreateStream(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, llm.ProcessEvents(context.Background(), stream).Error())
+
+	require.Len(t, p.reqs, 1)
+	assert.Equal(t, "rewritten", p.reqs[0].Tools[0].Description)
+	assert.Equal(t, "original", req.Tools[0].Description)
+	assert.Equal(t, map[string]any{"type": "string"}, req.Tools[0].Parameters["properties"].(map[string]any)["city"])
+}
+
+func TestService_PreSendHookError(t *testing.T) {
+	p := &recordingProvider{name: "anthropic"}
+	wantErr := errors.New("unsupported content")
+	svc, err := llm.New(
+		llm.WithProvider(p),
+		llm.WithPreSendHook(llm.PreSendRule{Hook: func(_ context.Context, req llm.Request) (llm.Request, error) {
+			return req, wantErr
+		}}),
+	)
+	require.NoError(t, err)
+
+	_, err = svc.CreateStream(context.Background(), llm.Request{Model: "claude-sonnet-4-6", Messages: llm.Messages{llm.User("hi")}})
+	assert.ErrorIs(t, err, wantErr)
+	assert.Empty(t, p.reqs)
+}
+
+func TestStripMarkdown(t *testing.T) {
+	req := llm.Request{Messages: llm.Messages{
+		llm.System("# Role\nYou are **terse**. See [docs](https://example.com) and `go test`."),
+		llm.Assistant("**kept**"),
+	}}
+	out, err := llm.StripMarkdown()(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Role\nYou are terse. See docs and go test.", out.Messages[0].Text())
+	assert.Equal(t, "**kept**", out.Messages[1].Text())
+}
+
+func TestStripMarkdown_KeepsIdentifiers(t *testing.T) {
+	for in, want := range map[string]string{
+		"Call __init__(self) in __main__.py":   "Call __init__(self) in __main__.py",
+		"Rename snake__case__name":             "Rename snake__case__name",
+		"Use snake_case_name, not __x":         "Use snake_case_name, not __x",
+		"This is __important__.":               "This is important.",
+		"(__bold__) and __more bold__":         "(bold) and more bold",
+		"2**3 + 4**2 = 24":                     "2**3 + 4**2 = 24",
+		"Call f(*args, **kwargs) or g(**opts)": "Call f(*args, **kwargs) or g(**opts)",
+		"Pass **kwargs, then **bold** text":    "Pass **kwargs, then bold text",
+	} {
+		out, err := llm.StripMarkdown()(context.Background(), llm.Request{Messages: llm.Messages{llm.User(in)}})
+		require.NoError(t, err)
+		assert.Equal(t, want, out.Messages[0].Text(), in)
+	}
+}
+
+func TestMapText_SkipsNonTextParts(t *testing.T) {
+	m := llm.Messages{msg.Assistant(msg.Thinking("thought", "sig"), msg.Text("a")).Build()}
+	out, err := llm.MapText(func(_ msg.Role, s string) string { return s + "!" })(context.Background(), llm.Request{Messages: m})
+	require.NoError(t, err)
+	assert.Equal(t, "a!", out.Messages[0].Text())
+	assert.Equal(t, "thought", out.Messages[0].Parts[0].Thinking.Text)
+}
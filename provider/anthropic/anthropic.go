@@ -66,6 +66,9 @@ func New(opts ...llm.Option) *Provider {
 		}),
 		providercore2.WithPreprocessRequest(func(req llm.Request) (llm.Request, string, error) {
 			original := req.Model
+			if err := llm.ValidateHistory(req.Messages); err != nil {
+				return req, original, err
+			}
 			if original != "" {
 				if resolved, err := allModelsWithAliases.Resolve(original); err == nil {
 					req.Model = resolved.ID
@@ -0,0 +1,123 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockPollInterval is how often a waiting process retries the lock.
+	lockPollInterval = 50 * time.Millisecond
+
+	// lockStaleAfter is the age after which a lock file is considered
+	// abandoned by a crashed process and removed. The holder refreshes the
+	// lock's mtime every lockRefreshInterval, so a live lock never gets
+	// this old.
+	lockStaleAfter = 30 * time.Second
+)
+
+// lockRefreshInterval is how often the holder touches its lock file.
+var lockRefreshInterval = lockStaleAfter / 3
+
+// lockFile acquires an exclusive, cross-process lock by creating path with
+// O_EXCL. It works on every platform and filesystem that supports exclusive
+// create, without platform-specific syscalls. The returned function releases
+// the lock.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return holdLock(path), nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+		if removeStaleLock(path) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for lock %s: %w", path, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// holdLock keeps the lock at path fresh until the returned release
+// function removes it.
+func holdLock(path string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(lockRefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-t.C:
+				_ = os.Chtimes(path, now, now)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		_ = os.Remove(path)
+	}
+}
+
+// removeStaleLock removes the lock at path if it is older than
+// lockStaleAfter and reports whether it did. The lock is first renamed to a
+// unique name, so of several waiters only one takes it away. If the renamed
+// file turns out to be fresh, another process replaced the stale lock in
+// the meantime, and it is linked back unless path has been taken again.
+func removeStaleLock(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) <= lockStaleAfter {
+		return false
+	}
+	stale := fmt.Sprintf("%s.stale.%d.%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, stale); err != nil {
+		return false
+	}
+	defer func() { _ = os.Remove(stale) }()
+	if info, err := os.Stat(stale); err == nil && time.Since(info.ModTime()) <= lockStaleAfter {
+		_ = os.Link(stale, path)
+		return false
+	}
+	return true
+}
+
+// writeFileAtomic writes data to a uniquely named temporary file next to
+// path and renames it into place, so readers never observe a partially
+// written file and concurrent writers never share a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath) // cleanup on failure; ignore error
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) // cleanup on failure; ignore error
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
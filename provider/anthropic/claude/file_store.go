@@ -0,0 +1,127 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileTokenStore persists tokens as JSON files in a directory, one file per
+// key. Writes are atomic, and it implements TokenLocker so that processes
+// sharing the directory refresh each token only once.
+type FileTokenStore struct {
+	dir string
+}
+
+// fileToken is the JSON format for stored tokens.
+type fileToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// DefaultFileTokenDir returns the default directory for FileTokenStore:
+// <user config dir>/llm/claude, e.g. ~/.config/llm/claude on Linux.
+func DefaultFileTokenDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("get config directory: %w", err)
+	}
+	return filepath.Join(dir, "llm", "claude"), nil
+}
+
+// NewFileTokenStore creates a store that saves tokens to dir.
+// Creates the directory if it doesn't exist.
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create token store directory: %w", err)
+	}
+	return &FileTokenStore{dir: dir}, nil
+}
+
+// Load retrieves a stored token by key. Returns nil, nil if not found.
+func (s *FileTokenStore) Load(ctx context.Context, key string) (*Token, error) {
+	data, err := os.ReadFile(s.pathFor(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+
+	var ft fileToken
+	if err := json.Unmarshal(data, &ft); err != nil {
+		return nil, fmt.Errorf("unmarshal token: %w", err)
+	}
+
+	return &Token{
+		AccessToken:  ft.AccessToken,
+		RefreshToken: ft.RefreshToken,
+		ExpiresAt:    ft.ExpiresAt,
+	}, nil
+}
+
+// Save persists a token with the given key.
+func (s *FileTokenStore) Save(ctx context.Context, key string, token *Token) error {
+	data, err := json.MarshalIndent(fileToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.ExpiresAt,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal token: %w", err)
+	}
+	if err := writeFileAtomic(s.pathFor(key), data); err != nil {
+		return fmt.Errorf("write token file: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a stored token.
+func (s *FileTokenStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.pathFor(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete token file: %w", err)
+	}
+	return nil
+}
+
+// List returns all stored token keys.
+func (s *FileTokenStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list token directory: %w", err)
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys, nil
+}
+
+// Lock implements TokenLocker with a lock file next to the token file.
+func (s *FileTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	return lockFile(ctx, s.pathFor(key)+".lock")
+}
+
+func (s *FileTokenStore) pathFor(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Verify FileTokenStore implements TokenStore and TokenLocker.
+var (
+	_ TokenStore  = (*FileTokenStore)(nil)
+	_ TokenLocker = (*FileTokenStore)(nil)
+)
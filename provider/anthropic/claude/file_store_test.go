@@ -0,0 +1,133 @@
+package claude
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	store, err := NewFileTokenStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	token := &Token{AccessToken: "a", RefreshToken: "r", ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+	require.NoError(t, store.Save(ctx, "work", token))
+
+	info, err := os.Stat(filepath.Join(dir, "work.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := store.Load(ctx, "work")
+	require.NoError(t, err)
+	assert.Equal(t, token.AccessToken, loaded.AccessToken)
+	assert.Equal(t, token.RefreshToken, loaded.RefreshToken)
+	assert.True(t, token.ExpiresAt.Equal(loaded.ExpiresAt))
+
+	missing, err := store.Load(ctx, "other")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	unlock, err := store.Lock(ctx, "work")
+	require.NoError(t, err)
+	keys, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, keys, "lock files are not keys")
+	unlock()
+
+	require.NoError(t, store.Delete(ctx, "work"))
+	keys, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k.lock")
+
+	unlock, err := lockFile(context.Background(), path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	_, err = lockFile(ctx, path)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "lock is exclusive")
+
+	unlock()
+	unlock2, err := lockFile(context.Background(), path)
+	require.NoError(t, err, "released lock can be taken again")
+	unlock2()
+}
+
+func TestLockFile_RemovesStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k.lock")
+	require.NoError(t, os.WriteFile(path, []byte("123"), 0600))
+	old := time.Now().Add(-2 * lockStaleAfter)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := lockFile(ctx, path)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestLockFile_RefreshesHeldLock(t *testing.T) {
+	defer func(d time.Duration) { lockRefreshInterval = d }(lockRefreshInterval)
+	lockRefreshInterval = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "k.lock")
+	unlock, err := lockFile(context.Background(), path)
+	require.NoError(t, err)
+	defer unlock()
+
+	old := time.Now().Add(-2 * lockStaleAfter)
+	require.NoError(t, os.Chtimes(path, old, old))
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && time.Since(info.ModTime()) < lockStaleAfter
+	}, time.Second, lockRefreshInterval)
+	assert.False(t, removeStaleLock(path), "a held lock is not stale")
+}
+
+func TestRemoveStaleLock_OnlyOneWaiterWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k.lock")
+	require.NoError(t, os.WriteFile(path, []byte("123"), 0600))
+	old := time.Now().Add(-2 * lockStaleAfter)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	assert.True(t, removeStaleLock(path))
+	assert.False(t, removeStaleLock(path), "the stale lock is already gone")
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestWriteFileAtomic_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "k.json")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, writeFileAtomic(path, []byte(strconv.Itoa(i))))
+		}()
+	}
+	wg.Wait()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temp files are left behind")
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -213,16 +213,9 @@ func (s *LocalTokenStore) Save(ctx context.Context, key string, token *Token) er
 		return fmt.Errorf("marshal credentials: %w", err)
 	}
 
-	// Atomic write: temp file + rename
-	tmpPath := s.path + ".tmp"
-	if err := os.WriteFile(tmpPath, newData, 0600); err != nil {
-		return fmt.Errorf("write temp credentials file: %w", err)
+	if err := writeFileAtomic(s.path, newData); err != nil {
+		return fmt.Errorf("write credentials file: %w", err)
 	}
-	if err := os.Rename(tmpPath, s.path); err != nil {
-		_ = os.Remove(tmpPath) // cleanup on failure; ignore error
-		return fmt.Errorf("rename credentials file: %w", err)
-	}
-
 	return nil
 }
 
@@ -231,6 +224,13 @@ func (s *LocalTokenStore) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// Lock implements TokenLocker with a lock file next to the credentials file.
+// It serialises refreshes between processes using this package; Claude Code
+// itself does not take the lock.
+func (s *LocalTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	return lockFile(ctx, s.path+".lock")
+}
+
 // List returns the single key used for local storage.
 func (s *LocalTokenStore) List(ctx context.Context) ([]string, error) {
 	// Check if token exists
@@ -244,5 +244,8 @@ func (s *LocalTokenStore) List(ctx context.Context) ([]string, error) {
 	return []string{localTokenKey}, nil
 }
 
-// Ensure LocalTokenStore implements TokenStore.
-var _ TokenStore = (*LocalTokenStore)(nil)
+// Ensure LocalTokenStore implements TokenStore and TokenLocker.
+var (
+	_ TokenStore  = (*LocalTokenStore)(nil)
+	_ TokenLocker = (*LocalTokenStore)(nil)
+)
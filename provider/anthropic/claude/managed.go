@@ -47,23 +47,9 @@ func (p *ManagedTokenProvider) Token(ctx context.Context) (*Token, error) {
 
 	// Refresh if expired
 	if token.IsExpired() {
-		newToken, err := RefreshToken(ctx, token.RefreshToken)
-		if err != nil {
+		if token, err = p.refreshLocked(ctx, token, false); err != nil {
 			return nil, fmt.Errorf("refresh token: %w", err)
 		}
-
-		// Persist refreshed token
-		if err := p.store.Save(ctx, p.key, newToken); err != nil {
-			return nil, fmt.Errorf("save refreshed token: %w", err)
-		}
-
-		// Notify callback — best-effort; notification failure does not abort the refresh.
-		if p.onRefreshed != nil {
-			//nolint:errcheck // intentional: callback failure is non-fatal
-			_ = p.onRefreshed(ctx, p.key, newToken)
-		}
-
-		token = newToken
 	}
 
 	p.cached = token
@@ -80,21 +66,56 @@ func (p *ManagedTokenProvider) Refresh(ctx context.Context) (*Token, error) {
 		return nil, fmt.Errorf("load token for refresh: %w", err)
 	}
 
+	newToken, err := p.refreshLocked(ctx, token, true)
+	if err != nil {
+		return nil, err
+	}
+	p.cached = newToken
+	return newToken, nil
+}
+
+// refreshLocked exchanges the refresh token of token for a new token, saves
+// it and notifies onRefreshed. If the store is a TokenLocker the exchange
+// runs under its lock, and the token is re-loaded first: when another
+// process refreshed it in the meantime, its token is returned instead of
+// spending the now-revoked refresh token. Unless force is set, a re-loaded
+// token that is still valid is returned as is. Callers hold p.mu.
+func (p *ManagedTokenProvider) refreshLocked(ctx context.Context, token *Token, force bool) (*Token, error) {
+	if locker, ok := p.store.(TokenLocker); ok {
+		unlock, err := locker.Lock(ctx, p.key)
+		if err != nil {
+			return nil, fmt.Errorf("lock token: %w", err)
+		}
+		defer unlock()
+
+		current, err := p.store.Load(ctx, p.key)
+		if err != nil {
+			return nil, fmt.Errorf("reload token: %w", err)
+		}
+		if current != nil {
+			rotated := current.RefreshToken != token.RefreshToken
+			if (rotated || !force) && !current.IsExpired() {
+				return current, nil
+			}
+			token = current
+		}
+	}
+
 	newToken, err := RefreshToken(ctx, token.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
 
+	// Persist refreshed token
 	if err := p.store.Save(ctx, p.key, newToken); err != nil {
 		return nil, fmt.Errorf("save refreshed token: %w", err)
 	}
 
-	//nolint:errcheck // intentional: callback failure is non-fatal
+	// Notify callback — best-effort; notification failure does not abort the refresh.
 	if p.onRefreshed != nil {
+		//nolint:errcheck // intentional: callback failure is non-fatal
 		_ = p.onRefreshed(ctx, p.key, newToken)
 	}
-
-	p.cached = newToken
 	return newToken, nil
 }
 
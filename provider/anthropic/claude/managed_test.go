@@ -424,3 +424,74 @@ func TestManagedTokenProvider_ConcurrentAccess(t *testing.T) {
 var _ TokenStore = (*mockTokenStore)(nil)
 var _ TokenProvider = (*ManagedTokenProvider)(nil)
 var _ TokenRefresher = (*ManagedTokenProvider)(nil)
+
+func TestManagedTokenProvider_SharedFileStoreRefreshesOnce(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		if body["refresh_token"] != "r0" {
+			// A rotated refresh token can only be used once.
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+		refreshes++
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "a1",
+			"refresh_token": "r1",
+			"expires_in":    3600,
+		})
+	}))
+	defer server.Close()
+
+	oldEndpoint := tokenEndpoint
+	oldClient := httpClient
+	tokenEndpoint = server.URL
+	httpClient = server.Client()
+	defer func() {
+		tokenEndpoint = oldEndpoint
+		httpClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	seed, err := NewFileTokenStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, seed.Save(context.Background(), "k", &Token{
+		AccessToken:  "a0",
+		RefreshToken: "r0",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	}))
+
+	// Separate stores and providers stand in for separate processes.
+	const n = 4
+	var wg sync.WaitGroup
+	tokens := make([]*Token, n)
+	errs := make([]error, n)
+	for i := range n {
+		store, err := NewFileTokenStore(dir)
+		require.NoError(t, err)
+		provider := NewManagedTokenProvider("k", store, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], errs[i] = provider.Token(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i := range n {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "a1", tokens[i].AccessToken)
+	}
+	assert.Equal(t, 1, refreshes)
+
+	stored, err := seed.Load(context.Background(), "k")
+	require.NoError(t, err)
+	assert.Equal(t, "r1", stored.RefreshToken)
+}
@@ -11,6 +11,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/codewandler/llm"
 )
 
 const (
@@ -32,9 +34,10 @@ var (
 	// This is a var to allow overriding in tests.
 	tokenEndpoint = "https://console.anthropic.com/v1/oauth/token"
 
-	// httpClient is the HTTP client used for token requests.
+	// httpClient is the HTTP client used for token requests. When nil,
+	// llm.DefaultHttpClient() is used.
 	// This is a var to allow overriding in tests.
-	httpClient = http.DefaultClient
+	httpClient *http.Client
 )
 
 // PKCECodes holds the PKCE verifier and challenge pair.
@@ -175,7 +178,11 @@ func exchangeToken(ctx context.Context, body map[string]string) (*Token, error)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(req)
+	client := httpClient
+	if client == nil {
+		client = llm.DefaultHttpClient()
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("token exchange request: %w", err)
 	}
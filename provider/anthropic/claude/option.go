@@ -26,6 +26,12 @@ func WithLLMOptions(opts ...llm.Option) Option {
 		if cfg.Logger != nil {
 			p.log = cfg.Logger
 		}
+		if cfg.Retry != nil {
+			p.retry = cfg.Retry
+		}
+		if cfg.RateLimiter != nil {
+			p.rateLimiter = cfg.RateLimiter
+		}
 		p.autoSystemCacheControl = anthropic.AutoSystemCacheControlFromOptions(opts)
 	}
 }
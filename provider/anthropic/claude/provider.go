@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	agentmessages "github.com/codewandler/agentapis/api/messages"
 	"github.com/codewandler/llm"
@@ -59,6 +60,9 @@ type Provider struct {
 	baseURL       string
 	client        *http.Client
 	log           *slog.Logger
+	retry         *llm.RetryOptions
+	rateLimiter   *llm.RateLimiter
+	tokenMu       sync.RWMutex // guards tokenProvider against SetTokenProvider
 	tokenProvider TokenProvider
 	userID        string
 	sessionID     string
@@ -101,10 +105,11 @@ func New(opts ...Option) *Provider {
 			"Accept": {"application/json"},
 		}),
 		providercore2.WithHeaderFunc(func(ctx context.Context, _ *llm.Request) (http.Header, error) {
-			if p.tokenProvider == nil {
+			tp := p.currentTokenProvider()
+			if tp == nil {
 				return nil, llm.NewErrMissingAPIKey(llm.ProviderNameClaude)
 			}
-			token, err := p.tokenProvider.Token(ctx)
+			token, err := tp.Token(ctx)
 			if err != nil {
 				return nil, llm.NewErrRequestFailed(llm.ProviderNameClaude, err)
 			}
@@ -119,6 +124,9 @@ func New(opts ...Option) *Provider {
 		providercore2.WithPreprocessRequest(func(req llm.Request) (llm.Request, string, error) {
 			normalizeRequest(&req)
 			original := req.Model
+			if err := llm.ValidateHistory(req.Messages); err != nil {
+				return req, original, err
+			}
 			resolvedModel, err := p.claudeModels.Resolve(req.Model)
 			if err != nil {
 				return req, original, err
@@ -158,11 +166,22 @@ func New(opts ...Option) *Provider {
 			}
 			return providercore2.HTTPErrorActionStream
 		}),
-	), llm.WithHTTPClient(p.client), llm.WithLogger(p.log))
+	), p.llmOptions()...)
 
 	return p
 }
 
+func (p *Provider) llmOptions() []llm.Option {
+	opts := []llm.Option{llm.WithHTTPClient(p.client), llm.WithLogger(p.log)}
+	if p.retry != nil {
+		opts = append(opts, llm.WithRetry(*p.retry))
+	}
+	if p.rateLimiter != nil {
+		opts = append(opts, llm.WithRateLimit(p.rateLimiter))
+	}
+	return opts
+}
+
 func (p *Provider) Name() string       { return p.inner.Name() }
 func (p *Provider) Models() llm.Models { return p.inner.Models() }
 func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
@@ -172,11 +191,27 @@ func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Str
 	return p.inner.CreateStream(ctx, src)
 }
 
+// SetTokenProvider swaps the token provider used by subsequent requests,
+// e.g. after the user logs in to a different account. Requests already in
+// flight keep the token they were sent with. Safe for concurrent use.
+func (p *Provider) SetTokenProvider(tp TokenProvider) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+	p.tokenProvider = tp
+}
+
+func (p *Provider) currentTokenProvider() TokenProvider {
+	p.tokenMu.RLock()
+	defer p.tokenMu.RUnlock()
+	return p.tokenProvider
+}
+
 func (p *Provider) countTokensAPI(ctx context.Context, apiReq *providercore2.MessagesRequest) (int, error) {
-	if p.tokenProvider == nil {
+	tp := p.currentTokenProvider()
+	if tp == nil {
 		return 0, fmt.Errorf("claude: count_tokens: missing token provider")
 	}
-	token, err := p.tokenProvider.Token(ctx)
+	token, err := tp.Token(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("claude: count_tokens: %w", err)
 	}
@@ -220,7 +255,8 @@ func (p *Provider) countTokensAPI(ctx context.Context, apiReq *providercore2.Mes
 
 	if resp.StatusCode != http.StatusOK {
 		errBody, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("claude: count_tokens: HTTP %d: %s", resp.StatusCode, string(errBody))
+		return 0, fmt.Errorf("claude: count_tokens: %w",
+			llm.NewErrAPIError(llm.ProviderNameClaude, resp.StatusCode, string(errBody)).WithRetryAfter(resp.Header))
 	}
 
 	var result struct {
@@ -261,10 +297,22 @@ func (p *Provider) augmentMessagesRequest(msgReq *providercore2.MessagesRequest)
 	if msgReq == nil {
 		return fmt.Errorf("nil messages request")
 	}
-	msgReq.System = append(providercore2.MessagesSystemBlocks{
+	// Claude Code only accepts requests whose system prompt starts with the
+	// billing header and the SDK core prompt. The caller's system and
+	// developer messages follow as separate blocks. Copies of the prefix are
+	// dropped first so retries and callers that already include it don't
+	// duplicate it.
+	system := providercore2.MessagesSystemBlocks{
 		&agentmessages.TextBlock{Type: agentmessages.BlockTypeText, Text: billingHeader},
 		&agentmessages.TextBlock{Type: agentmessages.BlockTypeText, Text: systemCore},
-	}, msgReq.System...)
+	}
+	for _, b := range msgReq.System {
+		if b != nil && (b.Text == billingHeader || b.Text == systemCore) {
+			continue
+		}
+		system = append(system, b)
+	}
+	msgReq.System = system
 	if p.autoSystemCacheControl != nil && len(msgReq.System) > 1 && msgReq.System[1] != nil && msgReq.System[1].CacheControl == nil {
 		msgReq.System[1].CacheControl = &agentmessages.CacheControl{Type: p.autoSystemCacheControl.Type, TTL: p.autoSystemCacheControl.TTL}
 	}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/codewandler/agentapis/adapt"
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
 )
 
 func TestNormalizeModel_Aliases(t *testing.T) {
@@ -114,6 +115,52 @@ func TestBuildRequest_UserSystemBlockAppended(t *testing.T) {
 	assert.Equal(t, "be helpful", last["text"])
 }
 
+func TestBuildRequest_SystemAndDeveloperMessagesKeptInOrder(t *testing.T) {
+	p := &Provider{baseURL: defaultBaseURL, sessionID: "s"}
+
+	msgReq, err := buildRequestForTest(p, llm.Request{
+		Model: "claude-sonnet-4-6",
+		Messages: llm.Messages{
+			llm.System("be helpful"),
+			llm.User("hello"),
+			llm.Assistant("hi"),
+			msg.Developer("answer in German").Build(),
+			llm.User("how are you?"),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{billingHeader, systemCore, "be helpful", "answer in German"}, systemTexts(msgReq))
+	for _, m := range msgReq.Messages {
+		assert.NotEqual(t, "system", string(m.Role))
+	}
+}
+
+func TestBuildRequest_PrefixNotDuplicated(t *testing.T) {
+	p := &Provider{baseURL: defaultBaseURL, sessionID: "s"}
+
+	msgReq, err := buildRequestForTest(p, llm.Request{
+		Model: "claude-sonnet-4-6",
+		Messages: llm.Messages{
+			llm.System(systemCore),
+			llm.System("be helpful"),
+			llm.User("hello"),
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, p.augmentMessagesRequest(msgReq), "a retry re-runs the transform")
+
+	assert.Equal(t, []string{billingHeader, systemCore, "be helpful"}, systemTexts(msgReq))
+}
+
+func systemTexts(msgReq *providercore2.MessagesRequest) []string {
+	var out []string
+	for _, b := range msgReq.System {
+		out = append(out, b.Text)
+	}
+	return out
+}
+
 func buildRequestForTest(p *Provider, llmRequest llm.Request) (*providercore2.MessagesRequest, error) {
 	uReq, err := providercore2.RequestToUnified(llmRequest)
 	if err != nil {
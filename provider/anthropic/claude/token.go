@@ -59,6 +59,18 @@ type TokenStore interface {
 	List(ctx context.Context) ([]string, error)
 }
 
+// TokenLocker is implemented by TokenStores that can serialise token
+// refreshes across processes. Refresh tokens are single-use, so two processes
+// refreshing the same token at once would leave one of them with a revoked
+// token. ManagedTokenProvider holds the lock while it re-loads, refreshes and
+// saves a token; a process that waited for the lock picks up the token the
+// holder saved instead of refreshing again.
+type TokenLocker interface {
+	// Lock blocks until the lock for key is held or ctx is done.
+	// The returned function releases the lock.
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
 // OnTokenRefreshed is called when a token is successfully refreshed.
 // Use this to trigger side effects like logging or notifications.
 type OnTokenRefreshed func(ctx context.Context, key string, newToken *Token) error
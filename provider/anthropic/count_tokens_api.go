@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/codewandler/llm"
 	"github.com/codewandler/llm/internal/providercore"
 )
 
@@ -97,8 +98,8 @@ func DoCountTokensAPI(
 
 	if resp.StatusCode != http.StatusOK {
 		errBody, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("count_tokens: HTTP %d: %s",
-			resp.StatusCode, string(errBody))
+		return 0, fmt.Errorf("count_tokens: %w",
+			llm.NewErrAPIError(llm.ProviderNameAnthropic, resp.StatusCode, string(errBody)).WithRetryAfter(resp.Header))
 	}
 
 	var result countTokensResponse
@@ -15,6 +15,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
 )
 
 func TestCreateStream_ValidateError(t *testing.T) {
@@ -124,6 +126,49 @@ func TestCreateStream_HappyPath(t *testing.T) {
 	assert.Equal(t, []string{"Hello!"}, texts)
 }
 
+// TestCreateStream_APIKeyMode guards the plain API-key mode against the Claude
+// Code emulation of the claude subpackage: no OAuth bearer token, no
+// claude-code or oauth betas, no system prefix and no renamed tools.
+func TestCreateStream_APIKeyMode(t *testing.T) {
+	var (
+		header http.Header
+		body   map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	p := New(llm.WithAPIKey("test-key"), llm.WithBaseURL(srv.URL))
+	ch, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: llm.Messages{llm.System("Be brief."), llm.User("weather in Paris?")},
+		Tools: []tool.Definition{{
+			Name:       "get_weather",
+			Parameters: map[string]any{"type": "object"},
+		}},
+	})
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	assert.Equal(t, "test-key", header.Get("x-api-key"))
+	assert.Empty(t, header.Get("Authorization"))
+	assert.Equal(t, BetaInterleavedThinking, header.Get("Anthropic-Beta"))
+
+	system, _ := json.Marshal(body["system"])
+	assert.NotContains(t, string(system), "Claude Code")
+	assert.Contains(t, string(system), "Be brief.")
+
+	tools, _ := body["tools"].([]any)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "get_weather", tools[0].(map[string]any)["name"])
+}
+
 // errorTransport always returns a transport-level error.
 type errorTransport struct{}
 
@@ -194,3 +239,211 @@ func TestCreateStream_AutoSystemCacheControl_Optional(t *testing.T) {
 		assert.Equal(t, "1h", cc["ttl"])
 	})
 }
+
+// captureMessagesBody streams req through a provider pointed at a stub server
+// and returns the decoded wire body.
+func captureMessagesBody(t *testing.T, req llm.Request) map[string]any {
+	t.Helper()
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_stop\ndata: {}\n\n")
+	}))
+	defer srv.Close()
+
+	p := New(llm.WithAPIKey("test-key"), llm.WithBaseURL(srv.URL))
+	stream, err := p.CreateStream(context.Background(), req)
+	require.NoError(t, err)
+	for range stream {
+	}
+	require.NotNil(t, gotBody)
+	return gotBody
+}
+
+func TestCreateStream_ToolChoiceMapping(t *testing.T) {
+	weather := tool.Definition{
+		Name:        "get_weather",
+		Description: "Get weather",
+		Parameters:  map[string]any{"type": "object", "properties": map[string]any{"location": map[string]any{"type": "string"}}},
+	}
+
+	tests := []struct {
+		name   string
+		choice llm.ToolChoice
+		want   map[string]any
+	}{
+		{name: "auto", choice: llm.ToolChoiceAuto{}, want: map[string]any{"type": "auto"}},
+		{name: "required", choice: llm.ToolChoiceRequired{}, want: map[string]any{"type": "any"}},
+		{name: "named tool", choice: llm.ToolChoiceTool{Name: "get_weather"}, want: map[string]any{"type": "tool", "name": "get_weather"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := captureMessagesBody(t, llm.Request{
+				Model:      "claude-sonnet-4-5",
+				Messages:   llm.Messages{llm.User("weather in Paris?")},
+				Tools:      []tool.Definition{weather},
+				ToolChoice: tt.choice,
+				Thinking:   llm.ThinkingOff,
+			})
+			assert.Equal(t, tt.want, body["tool_choice"])
+			require.Len(t, body["tools"], 1)
+		})
+	}
+
+	t.Run("forced choice relaxed with thinking", func(t *testing.T) {
+		// Anthropic rejects forced tool use while extended thinking is on.
+		body := captureMessagesBody(t, llm.Request{
+			Model:      "claude-sonnet-4-5",
+			Messages:   llm.Messages{llm.User("weather in Paris?")},
+			Tools:      []tool.Definition{weather},
+			ToolChoice: llm.ToolChoiceRequired{},
+			Thinking:   llm.ThinkingOn,
+		})
+		assert.Equal(t, map[string]any{"type": "auto"}, body["tool_choice"])
+	})
+}
+
+func TestCreateStream_EffortMapping(t *testing.T) {
+	budget := func(effort llm.Effort) float64 {
+		body := captureMessagesBody(t, llm.Request{
+			Model:    "claude-sonnet-4-5",
+			Messages: llm.Messages{llm.User("hi")},
+			Thinking: llm.ThinkingOn,
+			Effort:   effort,
+		})
+		thinking, ok := body["thinking"].(map[string]any)
+		require.True(t, ok, "expected thinking block, got %v", body["thinking"])
+		assert.Equal(t, "enabled", thinking["type"])
+		return thinking["budget_tokens"].(float64)
+	}
+	low, high := budget(llm.EffortLow), budget(llm.EffortHigh)
+	assert.Greater(t, high, low)
+}
+
+func TestCreateStream_ThinkingBudget(t *testing.T) {
+	body := captureMessagesBody(t, llm.Request{
+		Model:          "claude-sonnet-4-6",
+		Messages:       llm.Messages{llm.User("hi")},
+		MaxTokens:      8000,
+		Effort:         llm.EffortHigh,
+		ThinkingBudget: 3000,
+	})
+	assert.Equal(t, map[string]any{"type": "enabled", "budget_tokens": float64(3000)}, body["thinking"])
+
+	body = captureMessagesBody(t, llm.Request{
+		Model:          "claude-sonnet-4-6",
+		Messages:       llm.Messages{llm.User("hi")},
+		Thinking:       llm.ThinkingOff,
+		ThinkingBudget: 3000,
+	})
+	assert.Equal(t, map[string]any{"type": "disabled"}, body["thinking"])
+}
+
+func TestCreateStream_ThinkingDeltas(t *testing.T) {
+	rawSSE, err := io.ReadAll(buildMessagesSSE(
+		agentmessages.EventContentBlockStart,
+		agentmessages.ContentBlockStartEvent{
+			Index:        0,
+			ContentBlock: json.RawMessage(`{"type":"thinking","thinking":""}`),
+		},
+		agentmessages.EventContentBlockDelta,
+		agentmessages.ContentBlockDeltaEvent{
+			Index: 0,
+			Delta: agentmessages.Delta{Type: agentmessages.DeltaTypeThinking, Thinking: "Let me think."},
+		},
+		agentmessages.EventContentBlockStop,
+		agentmessages.ContentBlockStopEvent{Index: 0},
+		agentmessages.EventMessageStop,
+		agentmessages.MessageStopEvent{},
+	))
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(rawSSE)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := New(llm.WithAPIKey("test-key"), llm.WithBaseURL(srv.URL))
+	ch, err := p.CreateStream(context.Background(), llm.Request{
+		Model:          "claude-sonnet-4-6",
+		Messages:       llm.Messages{llm.User("hi")},
+		ThinkingBudget: 2048,
+	})
+	require.NoError(t, err)
+
+	var thinking []string
+	for env := range ch {
+		if d, ok := env.Data.(*llm.DeltaEvent); ok && d.Kind == llm.DeltaKindThinking {
+			thinking = append(thinking, d.Thinking)
+		}
+	}
+	assert.Equal(t, []string{"Let me think."}, thinking)
+}
+
+func TestCreateStream_CacheUsage(t *testing.T) {
+	start := agentmessages.MessageStartEvent{Message: agentmessages.MessageStartPayload{
+		ID:    "msg_01",
+		Model: "claude-sonnet-4-5",
+		Usage: agentmessages.MessageUsage{InputTokens: 10, CacheCreationInputTokens: 2000, CacheReadInputTokens: 5000},
+	}}
+
+	tests := []struct {
+		name           string
+		delta          func(*agentmessages.MessageDeltaEvent)
+		wantCacheWrite int
+		wantCacheRead  int
+	}{
+		{name: "from message_start", delta: func(*agentmessages.MessageDeltaEvent) {}, wantCacheWrite: 2000, wantCacheRead: 5000},
+		{
+			name: "superseded by cumulative message_delta",
+			delta: func(d *agentmessages.MessageDeltaEvent) {
+				d.Usage.InputTokens = 12
+				d.Usage.CacheCreationInputTokens = 2100
+				d.Usage.CacheReadInputTokens = 5000
+			},
+			wantCacheWrite: 2100,
+			wantCacheRead:  5000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delta agentmessages.MessageDeltaEvent
+			delta.Delta.StopReason = agentmessages.StopReasonEndTurn
+			delta.Usage.OutputTokens = 3
+			tt.delta(&delta)
+			rawSSE, err := io.ReadAll(buildMessagesSSE(
+				agentmessages.EventMessageStart, start,
+				agentmessages.EventMessageDelta, delta,
+				agentmessages.EventMessageStop, agentmessages.MessageStopEvent{},
+			))
+			require.NoError(t, err)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write(rawSSE)
+			}))
+			t.Cleanup(srv.Close)
+
+			p := New(llm.WithAPIKey("test-key"), llm.WithBaseURL(srv.URL))
+			stream, err := p.CreateStream(context.Background(), llm.Request{Model: "claude-sonnet-4-5", Messages: llm.Messages{llm.User("hi")}})
+			require.NoError(t, err)
+
+			var records []*llm.UsageUpdatedEvent
+			for env := range stream {
+				if ev, ok := env.Data.(*llm.UsageUpdatedEvent); ok {
+					records = append(records, ev)
+				}
+			}
+			require.Len(t, records, 1)
+			rec := records[0].Record
+			assert.Equal(t, tt.wantCacheWrite, rec.Tokens.Count(usage.KindCacheWrite))
+			assert.Equal(t, tt.wantCacheRead, rec.Tokens.Count(usage.KindCacheRead))
+			assert.Equal(t, 3, rec.Tokens.Count(usage.KindOutput))
+			assert.Greater(t, rec.Cost.CacheWrite, 0.0)
+			assert.Greater(t, rec.Cost.CacheRead, 0.0)
+			assert.Greater(t, rec.Cost.CacheWrite/float64(tt.wantCacheWrite), rec.Cost.CacheRead/float64(tt.wantCacheRead), "cache writes cost more per token than reads")
+		})
+	}
+}
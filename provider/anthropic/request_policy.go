@@ -1,6 +1,8 @@
 package anthropic
 
-import providercore2 "github.com/codewandler/llm/internal/providercore"
+import (
+	providercore2 "github.com/codewandler/llm/internal/providercore"
+)
 
 func CoerceAnthropicThinkingTemperature(msgReq *providercore2.MessagesRequest) {
 	if msgReq == nil || msgReq.Thinking == nil || msgReq.Thinking.Type == "disabled" {
@@ -0,0 +1,121 @@
+package anthropic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+)
+
+func parallelToolCalls() llm.Message {
+	return msg.Assistant(
+		msg.NewToolCall("call_a", "read_file", msg.ToolArgs{"path": "a.go"}),
+		msg.NewToolCall("call_b", "read_file", msg.ToolArgs{"path": "b.go"}),
+	).Build()
+}
+
+func toolResults(ids ...string) llm.Message {
+	results := make(msg.ToolResults, len(ids))
+	for i, id := range ids {
+		results[i] = msg.ToolResult{ToolCallID: id, ToolOutput: "output of " + id}
+	}
+	return msg.Tool().Results(results).Build()
+}
+
+// TestValidateHistory_ToolResults covers the tool result pairing the
+// preprocessors rely on.
+func TestValidateHistory_ToolResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages llm.Messages
+		wantErr  string
+	}{
+		{
+			name:     "results in call order",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_a", "call_b"), llm.User("thanks")},
+		},
+		{
+			name:     "results out of order",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_b", "call_a")},
+		},
+		{
+			name:     "results in separate messages",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_b"), toolResults("call_a")},
+		},
+		{
+			name:     "pending calls at the end",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls()},
+		},
+		{
+			name:     "unknown id",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_a", "call_c")},
+			wantErr:  `unknown tool call "call_c"`,
+		},
+		{
+			name:     "missing result",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_a"), llm.User("and?")},
+			wantErr:  `tool call "call_b" of messages[1] has no result`,
+		},
+		{
+			name:     "duplicate result",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(), toolResults("call_a", "call_a")},
+			wantErr:  `tool call "call_a" answered twice`,
+		},
+		{
+			name:     "result without preceding call",
+			messages: llm.Messages{llm.User("read"), toolResults("call_a")},
+			wantErr:  "messages[1]: tool result without a preceding tool call",
+		},
+		{
+			name: "missing tool_call_id",
+			messages: llm.Messages{llm.User("read"), parallelToolCalls(),
+				msg.Message{Role: msg.RoleTool, Parts: msg.Parts{msg.ToolResult{ToolOutput: "x"}.IntoPart()}}},
+			wantErr: "tool call id is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := llm.ValidateHistory(tt.messages)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCreateStream_ParallelToolResultsByID(t *testing.T) {
+	body := captureMessagesBody(t, llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: llm.Messages{llm.User("read both"), parallelToolCalls(), toolResults("call_b", "call_a")},
+	})
+
+	msgs := body["messages"].([]any)
+	require.Len(t, msgs, 3)
+	var got []string
+	for _, block := range msgs[2].(map[string]any)["content"].([]any) {
+		b := block.(map[string]any)
+		require.Equal(t, "tool_result", b["type"])
+		got = append(got, b["tool_use_id"].(string)+"="+b["content"].(string))
+	}
+	assert.Equal(t, []string{"call_b=output of call_b", "call_a=output of call_a"}, got)
+}
+
+func TestCreateStream_UnmatchedToolResult(t *testing.T) {
+	p := New(llm.WithAPIKey("test-key"))
+	_, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: llm.Messages{llm.User("read both"), parallelToolCalls(), toolResults("call_a", "call_x")},
+	})
+	require.Error(t, err)
+	var pe *llm.ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.ErrorIs(t, pe.Sentinel, llm.ErrBuildRequest)
+	assert.Contains(t, err.Error(), `"call_x"`)
+}
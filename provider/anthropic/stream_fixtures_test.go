@@ -0,0 +1,50 @@
+package anthropic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+// Fixtures are generated by "llmcli debug fixture" from captured responses.
+func TestCreateStream_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantText      string
+		wantToolCalls []string
+		wantStop      llm.StopReason
+	}{
+		{fixture: "text_reply.sse", wantText: "Hello!", wantToolCalls: nil, wantStop: "end_turn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			require.NoError(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write(body)
+			}))
+			defer server.Close()
+
+			p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+			stream, err := p.CreateStream(t.Context(), llm.Request{Model: "claude-sonnet-4-6", Messages: llm.Messages{llm.User("hi")}})
+			require.NoError(t, err)
+			c, _ := llm.Collect(stream)
+
+			assert.Equal(t, tt.wantText, c.Text)
+			var names []string
+			for _, call := range c.ToolCalls {
+				names = append(names, call.ToolName())
+			}
+			assert.Equal(t, tt.wantToolCalls, names)
+			assert.Equal(t, tt.wantStop, c.StopReason)
+		})
+	}
+}
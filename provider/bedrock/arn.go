@@ -0,0 +1,41 @@
+package bedrock
+
+import "strings"
+
+// baseModel returns the foundation model behind model for capability checks
+// and pricing. ARNs registered with WithModelARN map to their base model;
+// foundation-model and system-defined inference-profile ARNs carry the model
+// ID in their resource name. Anything else is returned unchanged.
+func (p *Provider) baseModel(model string) string {
+	if base, ok := p.modelARNs[model]; ok {
+		return base
+	}
+	return baseModelFromARN(model)
+}
+
+// baseModelFromARN extracts the model ID from a Bedrock ARN of the form
+// arn:<partition>:bedrock:<region>:<account>:<type>/<id>. Only
+// foundation-model and inference-profile ARNs name their model; application
+// inference profiles, custom, provisioned and imported models are opaque and
+// returned unchanged.
+// Example: "arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-6"
+// → "us.anthropic.claude-sonnet-4-6"
+func baseModelFromARN(model string) string {
+	if !strings.HasPrefix(model, "arn:") {
+		return model
+	}
+	parts := strings.SplitN(model, ":", 6)
+	if len(parts) != 6 || parts[2] != "bedrock" {
+		return model
+	}
+	kind, id, ok := strings.Cut(parts[5], "/")
+	if !ok {
+		return model
+	}
+	switch kind {
+	case "foundation-model", "inference-profile":
+		return id
+	default:
+		return model
+	}
+}
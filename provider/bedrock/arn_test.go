@@ -0,0 +1,48 @@
+package bedrock
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseModelFromARN(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"anthropic.claude-sonnet-4-6", "anthropic.claude-sonnet-4-6"},
+		{"arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-3-5-haiku-20241022-v1:0", "anthropic.claude-3-5-haiku-20241022-v1:0"},
+		{"arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-6", "us.anthropic.claude-sonnet-4-6"},
+		{"arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc123", "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc123"},
+		{"arn:aws:bedrock:us-east-1:123456789012:custom-model/my-model", "arn:aws:bedrock:us-east-1:123456789012:custom-model/my-model"},
+		{"arn:aws:s3:::bucket/key", "arn:aws:s3:::bucket/key"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, baseModelFromARN(tt.model), tt.model)
+	}
+}
+
+func TestWithModelARN_CapabilitiesAndCost(t *testing.T) {
+	const arn = "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc123"
+	p := New(WithModelARN(arn, "anthropic.claude-sonnet-4-5-20250929-v1:0"), WithCredentialsProvider(aws.AnonymousCredentials{}))
+
+	base := p.baseModel(arn)
+	assert.Equal(t, "anthropic.claude-sonnet-4-5-20250929-v1:0", base)
+	assert.True(t, isClaudeModel(base))
+
+	resolved, err := p.resolveModel(arn)
+	require.NoError(t, err)
+	assert.Equal(t, arn, resolved)
+
+	u := &types.TokenUsage{InputTokens: aws.Int32(1000), OutputTokens: aws.Int32(100)}
+	rec := converseUsageRecord(u, streamMeta{ResolvedModel: arn, BaseModel: base})
+	assert.Equal(t, arn, rec.Dims.Model)
+	assert.Greater(t, rec.Cost.Total, 0.0)
+
+	unpriced := converseUsageRecord(u, streamMeta{ResolvedModel: arn})
+	assert.Zero(t, unpriced.Cost.Total)
+}
@@ -0,0 +1,86 @@
+package bedrock
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+<AssumeRoleResult><Credentials>
+<AccessKeyId>%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>2099-01-01T00:00:00Z</Expiration>
+</Credentials><AssumedRoleUser><Arn>arn:aws:sts::1:assumed-role/r/s</Arn><AssumedRoleId>AROA:s</AssumedRoleId></AssumedRoleUser>
+</AssumeRoleResult></AssumeRoleResponse>`
+
+func TestProvider_AssumeRoleChainAndEndpoint(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "") // a CA bundle cannot be applied to the plain *http.Client
+
+	var mu sync.Mutex
+	var stsCalls []string // "<signing key id> <form>"
+	var runtimeAuth, runtimePath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		keyID := strings.SplitN(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 Credential="), "/", 2)[0]
+		if strings.HasPrefix(r.URL.Path, "/sts") {
+			body, _ := io.ReadAll(r.Body)
+			stsCalls = append(stsCalls, keyID+" "+string(body))
+			w.Header().Set("Content-Type", "text/xml")
+			next := "ASIA" + string(rune('0'+len(stsCalls)))
+			_, _ = io.WriteString(w, strings.Replace(assumeRoleResponse, "%s", next, 1))
+			return
+		}
+		runtimeAuth, runtimePath = auth, r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Amzn-Errortype", "AccessDeniedException")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"message":"denied"}`)
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL+"/sts")
+
+	p := New(
+		WithRegion(RegionEUCentral1),
+		WithCredentialsProvider(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIABASE", SecretAccessKey: "secret"}, nil
+		})),
+		WithAssumeRole("arn:aws:iam::111111111111:role/hop", WithExternalID("ext-1")),
+		WithAssumeRole("arn:aws:iam::222222222222:role/target", WithSessionName("svc")),
+		WithEndpoint(srv.URL),
+	)
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    ModelSonnetLatest,
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	if err == nil {
+		for range stream {
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, stsCalls, 2)
+	assert.True(t, strings.HasPrefix(stsCalls[0], "AKIABASE "), "first hop uses the base credentials")
+	assert.Contains(t, stsCalls[0], "RoleArn=arn%3Aaws%3Aiam%3A%3A111111111111%3Arole%2Fhop")
+	assert.Contains(t, stsCalls[0], "ExternalId=ext-1")
+	assert.Contains(t, stsCalls[0], "RoleSessionName=llm-bedrock")
+	assert.True(t, strings.HasPrefix(stsCalls[1], "ASIA1 "), "second hop uses the first role")
+	assert.Contains(t, stsCalls[1], "RoleSessionName=svc")
+
+	assert.True(t, strings.HasPrefix(runtimePath, "/model/"), runtimePath)
+	assert.Contains(t, runtimeAuth, "Credential=ASIA2/", "runtime calls use the last role")
+	assert.Contains(t, runtimeAuth, "/eu-central-1/bedrock/aws4_request")
+}
@@ -3,19 +3,24 @@ package bedrock
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 
 	"github.com/codewandler/llm"
@@ -49,8 +54,15 @@ type Provider struct {
 	credentialsProvider aws.CredentialsProvider
 	httpClient          *http.Client // HTTP client passed to the AWS SDK
 	logger              *slog.Logger // optional stream event logger
-
-	mu        sync.Mutex // protects client initialization
+	telemetry           llm.Telemetry
+	rateLimiter         *llm.RateLimiter
+	guardrail           *types.GuardrailStreamConfiguration
+	modelARNs           map[string]string // model ARN → base foundation model
+	assumeRoles         []assumeRole      // role chain, assumed in order
+	endpoint            string            // base endpoint override (VPC endpoints)
+	endpointResolver    bedrockruntime.EndpointResolverV2
+
+	mu        sync.Mutex // protects client, clientErr and credentialsProvider after New
 	client    *bedrockruntime.Client
 	clientErr error // deferred client creation error
 }
@@ -73,6 +85,12 @@ func WithLLMOptions(opts ...llm.Option) Option {
 		if cfg.Logger != nil {
 			p.logger = cfg.Logger
 		}
+		if cfg.Telemetry != nil {
+			p.telemetry = cfg.Telemetry
+		}
+		if cfg.RateLimiter != nil {
+			p.rateLimiter = cfg.RateLimiter
+		}
 	}
 }
 
@@ -103,6 +121,66 @@ func WithProfile(profile string) Option {
 	}
 }
 
+// assumeRole is one link of a WithAssumeRole chain.
+type assumeRole struct {
+	arn  string
+	opts []AssumeRoleOption
+}
+
+// AssumeRoleOption configures a role assumed with WithAssumeRole.
+type AssumeRoleOption func(*stscreds.AssumeRoleOptions)
+
+// WithExternalID sets the external ID required by the role's trust policy.
+func WithExternalID(id string) AssumeRoleOption {
+	return func(o *stscreds.AssumeRoleOptions) { o.ExternalID = aws.String(id) }
+}
+
+// WithSessionName sets the role session name recorded in CloudTrail.
+// The default is "llm-bedrock".
+func WithSessionName(name string) AssumeRoleOption {
+	return func(o *stscreds.AssumeRoleOptions) { o.RoleSessionName = name }
+}
+
+// WithAssumeRole assumes the IAM role roleARN through STS, e.g. to call
+// Bedrock in another account. The role is assumed with the credentials the
+// provider would otherwise use (default chain, WithProfile or
+// WithCredentialsProvider). Calling it repeatedly chains roles: each role is
+// assumed with the credentials of the previous one. Credentials are cached
+// and renewed before they expire.
+//
+// Example:
+//
+//	p := bedrock.New(
+//	    bedrock.WithProfile("sso-admin"),
+//	    bedrock.WithAssumeRole("arn:aws:iam::123456789012:role/bedrock-invoke",
+//	        bedrock.WithExternalID("acme")),
+//	)
+func WithAssumeRole(roleARN string, opts ...AssumeRoleOption) Option {
+	return func(p *Provider) {
+		p.assumeRoles = append(p.assumeRoles, assumeRole{arn: roleARN, opts: opts})
+	}
+}
+
+// WithEndpoint sends Bedrock runtime requests to endpoint instead of the
+// regional default, e.g. a VPC interface endpoint such as
+// "https://vpce-0123-abcd.bedrock-runtime.eu-central-1.vpce.amazonaws.com".
+// Requests are still signed for the configured region.
+func WithEndpoint(endpoint string) Option {
+	return func(p *Provider) {
+		p.endpoint = endpoint
+	}
+}
+
+// WithEndpointResolver sets a custom endpoint resolver for full control over
+// endpoint selection (FIPS, dual-stack, per-request routing). It takes
+// precedence over the SDK default; WithEndpoint still sets the base endpoint
+// the resolver receives.
+func WithEndpointResolver(r bedrockruntime.EndpointResolverV2) Option {
+	return func(p *Provider) {
+		p.endpointResolver = r
+	}
+}
+
 // WithCredentialsProvider sets a custom AWS credentials provider.
 // When set, the AWS client is created lazily on first use, allowing
 // credentials to be fetched at request time rather than at construction.
@@ -114,6 +192,35 @@ func WithCredentialsProvider(cp aws.CredentialsProvider) Option {
 	}
 }
 
+// WithGuardrail applies the Bedrock guardrail id (identifier or ARN) at
+// version ("DRAFT" or a version number) to every request. Tracing is
+// enabled, so an intervention stops the stream with StopReasonContentFilter
+// and a CompletedEvent.Safety listing the policies that blocked content.
+func WithGuardrail(id, version string) Option {
+	return func(p *Provider) {
+		p.guardrail = &types.GuardrailStreamConfiguration{
+			GuardrailIdentifier: aws.String(id),
+			GuardrailVersion:    aws.String(version),
+			Trace:               types.GuardrailTraceEnabled,
+		}
+	}
+}
+
+// WithModelARN registers an application inference profile, custom model or
+// provisioned throughput ARN together with the foundation model it serves,
+// e.g. "anthropic.claude-sonnet-4-6". Requests for arn are sent to arn, while
+// capability checks (caching, reasoning) and cost calculation use baseModel.
+// Foundation-model and system-defined inference-profile ARNs need no
+// registration.
+func WithModelARN(arn, baseModel string) Option {
+	return func(p *Provider) {
+		if p.modelARNs == nil {
+			p.modelARNs = make(map[string]string)
+		}
+		p.modelARNs[arn] = baseModel
+	}
+}
+
 // getRegionFromEnv reads the region from AWS_REGION or AWS_DEFAULT_REGION
 // environment variables, falling back to DefaultRegion if neither is set.
 func getRegionFromEnv() string {
@@ -184,20 +291,7 @@ func New(opts ...Option) *Provider {
 
 	// Create AWS SDK client immediately using default credential chain
 	// We defer errors to CreateStream so New() never fails
-	configOpts := []func(*config.LoadOptions) error{
-		config.WithRegion(p.region),
-		config.WithHTTPClient(p.httpClient),
-	}
-	if p.profile != "" {
-		configOpts = append(configOpts, config.WithSharedConfigProfile(p.profile))
-	}
-	cfg, err := config.LoadDefaultConfig(context.Background(), configOpts...)
-	if err != nil {
-		p.clientErr = fmt.Errorf("load AWS config: %w", err)
-		return p
-	}
-
-	p.client = bedrockruntime.NewFromConfig(cfg)
+	p.client, p.clientErr = p.newClient(context.Background())
 	return p
 }
 
@@ -208,15 +302,28 @@ func (p *Provider) Models() llm.Models { return models() }
 // initClient creates the AWS client lazily if not already initialized.
 // Thread-safe: uses mutex to ensure only one goroutine creates the client.
 func (p *Provider) initClient(ctx context.Context) error {
+	_, err := p.loadClient(ctx)
+	return err
+}
+
+// loadClient returns the AWS client, creating it on first use or after
+// SetCredentialsProvider.
+func (p *Provider) loadClient(ctx context.Context) (*bedrockruntime.Client, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	// Already initialized (success or failure)?
 	if p.client != nil || p.clientErr != nil {
-		return p.clientErr
+		return p.client, p.clientErr
 	}
 
-	// Build config options
+	p.client, p.clientErr = p.newClient(ctx)
+	return p.client, p.clientErr
+}
+
+// newClient builds the Bedrock runtime client from the provider's region,
+// profile, credentials, role chain and endpoint settings.
+func (p *Provider) newClient(ctx context.Context) (*bedrockruntime.Client, error) {
 	configOpts := []func(*config.LoadOptions) error{
 		config.WithRegion(p.region),
 		config.WithHTTPClient(p.httpClient),
@@ -230,12 +337,39 @@ func (p *Provider) initClient(ctx context.Context) error {
 
 	cfg, err := config.LoadDefaultConfig(ctx, configOpts...)
 	if err != nil {
-		p.clientErr = fmt.Errorf("load AWS config: %w", err)
-		return p.clientErr
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	for _, role := range p.assumeRoles {
+		stsClient := sts.NewFromConfig(cfg)
+		opts := append([]AssumeRoleOption{WithSessionName("llm-bedrock")}, role.opts...)
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, role.arn, func(o *stscreds.AssumeRoleOptions) {
+			for _, opt := range opts {
+				opt(o)
+			}
+		}))
 	}
 
-	p.client = bedrockruntime.NewFromConfig(cfg)
-	return nil
+	return bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		if p.endpoint != "" {
+			o.BaseEndpoint = aws.String(p.endpoint)
+		}
+		if p.endpointResolver != nil {
+			o.EndpointResolverV2 = p.endpointResolver
+		}
+	}), nil
+}
+
+// SetCredentialsProvider swaps the AWS credentials used by subsequent
+// requests without recreating the provider. The client is rebuilt lazily on
+// the next request; streams already open keep their credentials. Safe for
+// concurrent use.
+func (p *Provider) SetCredentialsProvider(cp aws.CredentialsProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.credentialsProvider = cp
+	p.client = nil
+	p.clientErr = nil
 }
 
 // resolveModel resolves a model ID to include the appropriate inference profile prefix.
@@ -286,9 +420,40 @@ func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Str
 	if err != nil {
 		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, err)
 	}
+	info := llm.StreamInfo{Provider: llm.ProviderNameBedrock, Model: opts.Model}
+	return p.rateLimiter.Stream(ctx, llm.ProviderNameBedrock, opts, func(ctx context.Context) (llm.Stream, error) {
+		return llm.InstrumentStream(ctx, p.telemetry, info, func(ctx context.Context) (llm.Stream, error) {
+			return p.createStream(ctx, opts)
+		})
+	})
+}
+
+// converseError maps a ConverseStream failure. Service error responses
+// become API errors carrying the status, the AWS exception name as Code and
+// Retry-After, so they are classified like other providers' HTTP errors.
+func converseError(err error) *llm.ProviderError {
+	var re *awshttp.ResponseError
+	if !errors.As(err, &re) || re.Response == nil || re.Response.Response == nil {
+		return llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
+	}
+	var apiErr interface {
+		ErrorCode() string
+		ErrorMessage() string
+	}
+	var body []byte
+	if errors.As(err, &apiErr) {
+		body, _ = json.Marshal(map[string]string{"__type": apiErr.ErrorCode(), "message": apiErr.ErrorMessage()})
+	}
+	pe := llm.NewErrAPIError(llm.ProviderNameBedrock, re.HTTPStatusCode(), string(body)).WithRetryAfter(re.Response.Header)
+	pe.Cause = err
+	return pe
+}
+
+func (p *Provider) createStream(ctx context.Context, opts llm.Request) (llm.Stream, error) {
 
 	// Lazy client initialization (thread-safe)
-	if err := p.initClient(ctx); err != nil {
+	client, err := p.loadClient(ctx)
+	if err != nil {
 		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
 	}
 
@@ -302,36 +467,51 @@ func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Str
 		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, err)
 	}
 
-	// Create a copy of opts with resolved model
+	// Create a copy of opts with the base model: for ARNs, the request is
+	// built for the model behind the ARN and then sent to the ARN itself.
 	resolvedOpts := opts
-	resolvedOpts.Model = resolvedModel
+	resolvedOpts.Model = p.baseModel(resolvedModel)
+	resolvedOpts, warnings := applyReasoningConstraints(resolvedOpts)
 
 	input, err := buildRequest(resolvedOpts)
 	if err != nil {
 		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, err)
 	}
+	input.ModelId = aws.String(resolvedModel)
+	if p.guardrail != nil {
+		g := *p.guardrail
+		input.GuardrailConfig = &g
+	}
+	if llm.IsDryRun(ctx) {
+		return nil, dryRun(ctx, client, input, opts)
+	}
 
-	output, err := p.client.ConverseStream(ctx, input)
+	timing := llm.Timing{RequestStart: time.Now()}
+	output, err := client.ConverseStream(ctx, input)
 	if err != nil {
-		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
+		return nil, converseError(err)
 	}
+	timing.FirstByte = time.Now()
 
 	meta := streamMeta{
 		RequestedModel: opts.Model,
 		ResolvedModel:  resolvedModel,
+		BaseModel:      resolvedOpts.Model,
 		Logger:         p.logger,
 		RequestID:      gonanoid.Must(),
+		Warnings:       warnings,
+		Timing:         timing,
 	}
 	pub, ch := llm.NewEventPublisher()
 
 	// Emit token estimates (primary + per-segment breakdown)
 	if est := tokencount.Estimate(ctx, llm.ProviderNameBedrock, opts); est != nil {
-		for _, rec := range tokencount.EstimateRecords(&tokencount.TokenCount{InputTokens: est.Tokens.Count(usage.KindInput), Encoder: est.Encoder}, llm.ProviderNameBedrock, opts.Model, "heuristic", usage.Default()) {
+		for _, rec := range tokencount.EstimateRecords(&tokencount.TokenCount{InputTokens: est.Tokens.Count(usage.KindInput), Encoder: est.Encoder}, llm.ProviderNameBedrock, opts.Model, "heuristic", costCalculator) {
 			pub.TokenEstimate(rec)
 		}
 	}
 
-	go parseStream(ctx, output, pub, meta)
+	go parseStream(ctx, output.GetStream(), pub, meta)
 	return ch, nil
 }
 
@@ -578,23 +758,11 @@ func buildRequest(opts llm.Request) (*bedrockruntime.ConverseStreamInput, error)
 	}
 
 	// Wire reasoning/thinking via additionalModelRequestFields.
-	// Bedrock uses reasoning_config: {type: "enabled", budget_tokens: N}.
-	// ThinkingOff → omit reasoning_config entirely.
-	// ThinkingOn → always enable reasoning.
-	// ThinkingAuto → only enable reasoning when Effort is explicitly set
-	//   (avoid cost increase from always-on reasoning with no user intent).
-	if opts.Thinking.IsOn() || (!opts.Thinking.IsOff() && !opts.Effort.IsEmpty()) {
-		budget := 31999 // default when no effort specified
-		if b, ok := opts.Effort.ToBudget(1024, 31999); ok {
-			budget = b
-		}
+	if key, config, ok := reasoningConfig(opts); ok {
 		if additionalFields == nil {
 			additionalFields = make(map[string]any)
 		}
-		additionalFields["reasoning_config"] = map[string]any{
-			"type":          "enabled",
-			"budget_tokens": budget,
-		}
+		additionalFields[key] = config
 	}
 
 	// Enable interleaved thinking beta for Claude models.
@@ -615,9 +783,9 @@ func buildRequest(opts llm.Request) (*bedrockruntime.ConverseStreamInput, error)
 		input.AdditionalModelRequestFields = fieldsDoc
 	}
 
-	// Set inference configuration (temperature, topP, maxTokens).
+	// Set inference configuration (temperature, topP, maxTokens, stop).
 	// Only set when at least one parameter is configured.
-	if opts.Temperature > 0 || opts.TopP > 0 || opts.MaxTokens > 0 {
+	if opts.Temperature > 0 || opts.TopP > 0 || opts.MaxTokens > 0 || len(opts.Stop) > 0 {
 		inferenceConfig := &types.InferenceConfiguration{}
 		if opts.MaxTokens > 0 {
 			inferenceConfig.MaxTokens = aws.Int32(int32(opts.MaxTokens))
@@ -628,12 +796,120 @@ func buildRequest(opts llm.Request) (*bedrockruntime.ConverseStreamInput, error)
 		if opts.TopP > 0 {
 			inferenceConfig.TopP = aws.Float32(float32(opts.TopP))
 		}
+		if len(opts.Stop) > 0 {
+			inferenceConfig.StopSequences = append([]string(nil), opts.Stop...)
+		}
 		input.InferenceConfig = inferenceConfig
 	}
 
 	return input, nil
 }
 
+// reasoningRequested reports whether opts ask for reasoning.
+// ThinkingOff → never.
+// ThinkingOn → always.
+// ThinkingAuto → only when Effort or ThinkingBudget is explicitly set (avoid
+// cost increase from always-on reasoning with no user intent).
+func reasoningRequested(opts llm.Request) bool {
+	return opts.Thinking.IsOn() || (!opts.Thinking.IsOff() && (!opts.Effort.IsEmpty() || opts.ThinkingBudget > 0))
+}
+
+// reasoningConfig returns the additionalModelRequestFields entry that enables
+// reasoning for opts.Model, or false when reasoning is not requested or the
+// model family has no reasoning support.
+//
+// Claude uses reasoning_config: {type: "enabled", budget_tokens: N}, where
+// ThinkingBudget overrides the budget derived from Effort.
+// Nova 2 uses reasoningConfig: {type: "enabled", maxReasoningEffort: E}.
+func reasoningConfig(opts llm.Request) (string, map[string]any, bool) {
+	if !reasoningRequested(opts) {
+		return "", nil, false
+	}
+	switch {
+	case isClaudeModel(opts.Model):
+		budget := 31999 // default when no effort specified
+		if b, ok := opts.Effort.ToBudget(1024, 31999); ok {
+			budget = b
+		}
+		if opts.ThinkingBudget > 0 {
+			budget = opts.ThinkingBudget
+		} else if opts.MaxTokens > 0 && budget >= opts.MaxTokens {
+			// budget_tokens must stay below maxTokens.
+			budget = max(1024, opts.MaxTokens/2)
+		}
+		return "reasoning_config", map[string]any{
+			"type":          "enabled",
+			"budget_tokens": budget,
+		}, true
+	case isNovaReasoningModel(opts.Model):
+		return "reasoningConfig", map[string]any{
+			"type":               "enabled",
+			"maxReasoningEffort": novaReasoningEffort(opts.Effort),
+		}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// isNovaReasoningModel returns true for Amazon Nova 2 models, the Nova
+// generation with extended reasoning.
+func isNovaReasoningModel(modelID string) bool {
+	return strings.Contains(modelID, "amazon.nova-2")
+}
+
+// novaReasoningEffort maps effort to Nova's maxReasoningEffort, which has no
+// level above high. Unspecified effort maps to medium.
+func novaReasoningEffort(e llm.Effort) string {
+	switch e {
+	case llm.EffortLow:
+		return "low"
+	case llm.EffortHigh, llm.EffortMax:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
+// applyReasoningConstraints adjusts opts to what the model accepts with
+// reasoning and reports every change as a parameter_ignored warning:
+// reasoning requested for a model without reasoning support is dropped, and
+// Claude with extended thinking accepts neither a temperature other than 1,
+// nor top_k, nor a top_p below 0.95.
+func applyReasoningConstraints(opts llm.Request) (llm.Request, []llm.WarningEvent) {
+	if !reasoningRequested(opts) {
+		return opts, nil
+	}
+	var warnings []llm.WarningEvent
+	warn := func(param, message string) {
+		warnings = append(warnings, llm.WarningEvent{
+			Code:     llm.WarningParameterIgnored,
+			Message:  message,
+			Provider: llm.ProviderNameBedrock,
+			Param:    param,
+		})
+	}
+	if _, _, ok := reasoningConfig(opts); !ok {
+		warn("thinking", fmt.Sprintf("reasoning is not supported by %s on Bedrock; thinking, effort and thinking budget are ignored", opts.Model))
+		return opts, warnings
+	}
+	if !isClaudeModel(opts.Model) {
+		return opts, nil
+	}
+	if opts.Temperature != 0 && opts.Temperature != 1 {
+		warn("temperature", fmt.Sprintf("temperature %g is not supported with extended thinking; using 1", opts.Temperature))
+		opts.Temperature = 1
+	}
+	if opts.TopK > 0 {
+		warn("top_k", "top_k is not supported with extended thinking")
+		opts.TopK = 0
+	}
+	if opts.TopP > 0 && opts.TopP < 0.95 {
+		warn("top_p", fmt.Sprintf("top_p %g is not supported with extended thinking; using 0.95", opts.TopP))
+		opts.TopP = 0.95
+	}
+	return opts, warnings
+}
+
 // toDocument converts a Go value to a Smithy document.Interface.
 func toDocument(v any) (document.Interface, error) {
 	// Marshal to JSON and unmarshal to interface{} to normalize the type
@@ -650,18 +926,87 @@ func toDocument(v any) (document.Interface, error) {
 
 // --- Publisher parsing ---
 
+// oneHourCacheWriteFactor is the price of a 1h cache write relative to the
+// default 5m write: 2x versus 1.25x the input rate.
+const oneHourCacheWriteFactor = 2 / 1.25
+
+// converseUsageRecord converts Converse metadata usage into a usage record with
+// cache read/write tokens and calculated cost. Bedrock reports InputTokens as
+// the non-cache portion, so the kinds do not overlap. When cache writes are
+// broken down by TTL, the per-TTL counts are kept in Details under
+// "cache_write_by_ttl" (TTL -> tokens), and 1h writes are priced at their
+// higher rate. Prompts above 200k tokens are priced at the long-context tier
+// for models that have one (see usage.LongContextPricing).
+func converseUsageRecord(u *types.TokenUsage, meta streamMeta) usage.Record {
+	rec := usage.Record{
+		Dims:       usage.Dims{Provider: llm.ProviderNameBedrock, Model: meta.ResolvedModel, RequestID: meta.RequestID},
+		RecordedAt: time.Now(),
+	}
+	if u == nil {
+		return rec
+	}
+	rec.Tokens = usage.TokenItems{
+		{Kind: usage.KindInput, Count: int(aws.ToInt32(u.InputTokens))},
+		{Kind: usage.KindCacheRead, Count: int(aws.ToInt32(u.CacheReadInputTokens))},
+		{Kind: usage.KindCacheWrite, Count: int(aws.ToInt32(u.CacheWriteInputTokens))},
+		{Kind: usage.KindOutput, Count: int(aws.ToInt32(u.OutputTokens))},
+	}.NonZero()
+
+	if len(u.CacheDetails) > 0 {
+		byTTL := make(map[string]int, len(u.CacheDetails))
+		for _, d := range u.CacheDetails {
+			byTTL[string(d.Ttl)] += int(aws.ToInt32(d.InputTokens))
+		}
+		rec.Details = map[string]any{"cache_write_by_ttl": byTTL}
+	}
+
+	// Strip regional inference profile prefix (us., eu., global., etc.)
+	// before cost lookup — the catalog uses bare model IDs. ARNs are priced
+	// as their base model.
+	costModel := meta.ResolvedModel
+	if meta.BaseModel != "" {
+		costModel = meta.BaseModel
+	}
+	costModel = stripRegionPrefix(costModel)
+	if cost, ok := costCalculator.Calculate(llm.ProviderNameBedrock, costModel, rec.Tokens); ok {
+		// CacheDetails can report 1h writes while CacheWriteInputTokens is
+		// zero; there is then no cache write cost to split.
+		writes := rec.Tokens.Count(usage.KindCacheWrite)
+		if byTTL, _ := rec.Details["cache_write_by_ttl"].(map[string]int); byTTL[string(types.CacheTTLOneHour)] > 0 && writes > 0 {
+			extra := cost.CacheWrite * float64(byTTL[string(types.CacheTTLOneHour)]) / float64(writes) * (oneHourCacheWriteFactor - 1)
+			cost.CacheWrite += extra
+			cost.Total += extra
+		}
+		rec.Cost = cost
+	}
+	return rec
+}
+
 // streamMeta passes context into the stream parser for StreamEventStart.
 type streamMeta struct {
 	RequestedModel string
 	ResolvedModel  string
 	Logger         *slog.Logger
 	RequestID      string // synthesized; Bedrock API does not provide one
+	BaseModel      string // foundation model behind ResolvedModel; empty means ResolvedModel
+	Warnings       []llm.WarningEvent
+	Timing         llm.Timing
+}
+
+// converseEventStream is the part of the SDK's ConverseStreamEventStream the
+// parser uses.
+type converseEventStream interface {
+	Events() <-chan types.ConverseStreamOutput
+	Close() error
+	Err() error
 }
 
-func parseStream(ctx context.Context, output *bedrockruntime.ConverseStreamOutput, pub llm.Publisher, meta streamMeta) {
+// parseStream publishes events from stream until it ends or ctx is
+// cancelled. Cancellation is observed while waiting for the next event and
+// closes the stream, so an idle connection cannot hold the publisher open.
+func parseStream(ctx context.Context, stream converseEventStream, pub llm.Publisher, meta streamMeta) {
 	defer pub.Close()
 
-	stream := output.GetStream()
 	//nolint:errcheck // intentional: defer Close is only for cleanup, failure is non-fatal
 	defer stream.Close()
 
@@ -692,21 +1037,35 @@ func parseStream(ctx context.Context, output *bedrockruntime.ConverseStreamOutpu
 		argsBuf strings.Builder
 	}
 	activeTools := make(map[int]*toolAccum)
-	var inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int
-	var stopReason llm.StopReason
+	var (
+		stopReason   llm.StopReason
+		finishReason string
+	)
+	var safety *llm.SafetyBlock
 	startEmitted := false
 
-	for event := range stream.Events() {
+	events := stream.Events()
+	for {
+		var (
+			event types.ConverseStreamOutput
+			ok    bool
+		)
 		select {
+		case event, ok = <-events:
 		case <-ctx.Done():
 			pub.Error(llm.NewErrContextCancelled(llm.ProviderNameBedrock, ctx.Err()))
 			return
-		default:
+		}
+		if !ok {
+			break
 		}
 
 		if !startEmitted {
 			startEmitted = true
 			pub.Started(llm.StreamStartedEvent{Model: meta.ResolvedModel, Provider: "bedrock"})
+			for _, w := range meta.Warnings {
+				llm.PublishWarning(pub, w)
+			}
 		}
 
 		switch e := event.(type) {
@@ -724,6 +1083,7 @@ func parseStream(ctx context.Context, output *bedrockruntime.ConverseStreamOutpu
 			}
 
 		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			meta.Timing.MarkFirstDelta()
 			idx := int(aws.ToInt32(e.Value.ContentBlockIndex))
 			logEvent("content_block_delta", e.Value)
 			if e.Value.Delta != nil {
@@ -757,47 +1117,20 @@ func parseStream(ctx context.Context, output *bedrockruntime.ConverseStreamOutpu
 
 		case *types.ConverseStreamOutputMemberMetadata:
 			logEvent("metadata", e.Value)
-			if e.Value.Usage != nil {
-				if e.Value.Usage.InputTokens != nil {
-					inputTokens = int(*e.Value.Usage.InputTokens)
-				}
-				if e.Value.Usage.OutputTokens != nil {
-					outputTokens = int(*e.Value.Usage.OutputTokens)
-				}
-				if e.Value.Usage.CacheReadInputTokens != nil {
-					cacheReadTokens = int(*e.Value.Usage.CacheReadInputTokens)
-				}
-				if e.Value.Usage.CacheWriteInputTokens != nil {
-					cacheWriteTokens = int(*e.Value.Usage.CacheWriteInputTokens)
-				}
-				// inputTokens from Bedrock is the non-cache portion.
+			pub.UsageRecord(converseUsageRecord(e.Value.Usage, meta))
+			if safety != nil {
+				addGuardrailTrace(safety, e.Value.Trace)
 			}
-			tokens := usage.TokenItems{
-				{Kind: usage.KindInput, Count: inputTokens},
-				{Kind: usage.KindCacheRead, Count: cacheReadTokens},
-				{Kind: usage.KindCacheWrite, Count: cacheWriteTokens},
-				{Kind: usage.KindOutput, Count: outputTokens},
-			}.NonZero()
-
-			// Strip regional inference profile prefix (us., eu., global., etc.)
-			// before cost lookup — the pricing table uses bare model IDs.
-			costModel := stripRegionPrefix(meta.ResolvedModel)
-
-			rec := usage.Record{
-				Dims:       usage.Dims{Provider: llm.ProviderNameBedrock, Model: meta.ResolvedModel, RequestID: meta.RequestID},
-				Tokens:     tokens,
-				RecordedAt: time.Now(),
-			}
-			if cost, ok := usage.Default().Calculate(llm.ProviderNameBedrock, costModel, tokens); ok {
-				rec.Cost = cost
-			}
-			pub.UsageRecord(rec)
-			pub.Completed(llm.CompletedEvent{StopReason: stopReason})
+			pub.Completed(llm.CompletedEvent{StopReason: stopReason, FinishReason: finishReason, Safety: safety, Timing: meta.Timing.Complete()})
 			return
 
 		case *types.ConverseStreamOutputMemberMessageStop:
 			logEvent("message_stop", e.Value)
 			stopReason = mapBedrockStopReason(e.Value.StopReason)
+			finishReason = string(e.Value.StopReason)
+			if stopReason == llm.StopReasonContentFilter {
+				safety = &llm.SafetyBlock{Provider: llm.ProviderNameBedrock, Reason: string(e.Value.StopReason)}
+			}
 		}
 	}
 
@@ -815,9 +1148,83 @@ func mapBedrockStopReason(r types.StopReason) llm.StopReason {
 		return llm.StopReasonToolUse
 	case types.StopReasonMaxTokens:
 		return llm.StopReasonMaxTokens
-	case types.StopReasonContentFiltered:
+	case types.StopReasonContentFiltered, types.StopReasonGuardrailIntervened:
 		return llm.StopReasonContentFilter
 	default:
 		return llm.StopReason(r)
 	}
 }
+
+// addGuardrailTrace fills block from the guardrail trace Bedrock sends with
+// the stream metadata when a guardrail is configured with tracing. Only
+// content filters, denied topics, word filters and sensitive information
+// filters whose action blocked content are listed.
+func addGuardrailTrace(block *llm.SafetyBlock, trace *types.ConverseStreamTrace) {
+	if trace == nil || trace.Guardrail == nil {
+		return
+	}
+	g := trace.Guardrail
+	block.Message = aws.ToString(g.ActionReason)
+
+	var input, output []llm.SafetyCategory
+	for _, a := range g.InputAssessment {
+		input = append(input, guardrailCategories(a)...)
+	}
+	for _, as := range g.OutputAssessments {
+		for _, a := range as {
+			output = append(output, guardrailCategories(a)...)
+		}
+	}
+	switch {
+	case len(input) > 0:
+		block.Stage = llm.SafetyStageInput
+	case len(output) > 0:
+		block.Stage = llm.SafetyStageOutput
+	}
+	block.Categories = append(input, output...)
+	sort.SliceStable(block.Categories, func(i, j int) bool { return block.Categories[i].Name < block.Categories[j].Name })
+}
+
+func guardrailCategories(a types.GuardrailAssessment) []llm.SafetyCategory {
+	var out []llm.SafetyCategory
+	if a.ContentPolicy != nil {
+		for _, f := range a.ContentPolicy.Filters {
+			if f.Action == types.GuardrailContentPolicyActionBlocked {
+				out = append(out, llm.SafetyCategory{Name: string(f.Type), Severity: string(f.Confidence), Blocked: true})
+			}
+		}
+	}
+	if a.TopicPolicy != nil {
+		for _, t := range a.TopicPolicy.Topics {
+			if t.Action == types.GuardrailTopicPolicyActionBlocked {
+				out = append(out, llm.SafetyCategory{Name: aws.ToString(t.Name), Blocked: true})
+			}
+		}
+	}
+	if a.WordPolicy != nil {
+		for _, w := range a.WordPolicy.ManagedWordLists {
+			if w.Action == types.GuardrailWordPolicyActionBlocked {
+				out = append(out, llm.SafetyCategory{Name: string(w.Type), Blocked: true})
+			}
+		}
+		for _, w := range a.WordPolicy.CustomWords {
+			if w.Action == types.GuardrailWordPolicyActionBlocked {
+				// The matched word is not reported: it may be what was blocked.
+				out = append(out, llm.SafetyCategory{Name: "CUSTOM_WORD", Blocked: true})
+			}
+		}
+	}
+	if a.SensitiveInformationPolicy != nil {
+		for _, e := range a.SensitiveInformationPolicy.PiiEntities {
+			if e.Action == types.GuardrailSensitiveInformationPolicyActionBlocked {
+				out = append(out, llm.SafetyCategory{Name: string(e.Type), Blocked: true})
+			}
+		}
+		for _, r := range a.SensitiveInformationPolicy.Regexes {
+			if r.Action == types.GuardrailSensitiveInformationPolicyActionBlocked {
+				out = append(out, llm.SafetyCategory{Name: aws.ToString(r.Name), Blocked: true})
+			}
+		}
+	}
+	return out
+}
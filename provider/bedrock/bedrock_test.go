@@ -2,9 +2,12 @@ package bedrock
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
@@ -171,6 +174,51 @@ func TestInitClient_OnlyOnce(t *testing.T) {
 	}
 }
 
+func TestSetCredentialsProvider_ResetsClient(t *testing.T) {
+	first := &mockCredentialsProvider{creds: aws.Credentials{AccessKeyID: "first"}}
+	second := &mockCredentialsProvider{creds: aws.Credentials{AccessKeyID: "second"}}
+
+	p := New(WithRegion(RegionUSEast1), WithCredentialsProvider(first))
+	p.clientErr = errors.New("expired")
+
+	p.SetCredentialsProvider(second)
+
+	// The next request rebuilds the client with the new credentials.
+	assert.Same(t, second, p.credentialsProvider)
+	assert.Nil(t, p.client)
+	assert.Nil(t, p.clientErr)
+}
+
+func TestWithGuardrail(t *testing.T) {
+	p := New(WithGuardrail("gr-1", "DRAFT"), WithCredentialsProvider(aws.AnonymousCredentials{}))
+	require.NotNil(t, p.guardrail)
+	assert.Equal(t, "gr-1", aws.ToString(p.guardrail.GuardrailIdentifier))
+	assert.Equal(t, "DRAFT", aws.ToString(p.guardrail.GuardrailVersion))
+	assert.Equal(t, types.GuardrailTraceEnabled, p.guardrail.Trace)
+}
+
+func TestGuardrailCategories_WordAndSensitiveInformation(t *testing.T) {
+	got := guardrailCategories(types.GuardrailAssessment{
+		WordPolicy: &types.GuardrailWordPolicyAssessment{
+			ManagedWordLists: []types.GuardrailManagedWord{{Type: types.GuardrailManagedWordTypeProfanity, Action: types.GuardrailWordPolicyActionBlocked}},
+			CustomWords:      []types.GuardrailCustomWord{{Match: aws.String("secret"), Action: types.GuardrailWordPolicyActionBlocked}},
+		},
+		SensitiveInformationPolicy: &types.GuardrailSensitiveInformationPolicyAssessment{
+			PiiEntities: []types.GuardrailPiiEntityFilter{
+				{Type: types.GuardrailPiiEntityTypeEmail, Action: types.GuardrailSensitiveInformationPolicyActionBlocked},
+				{Type: types.GuardrailPiiEntityTypeName, Action: types.GuardrailSensitiveInformationPolicyActionAnonymized},
+			},
+			Regexes: []types.GuardrailRegexFilter{{Name: aws.String("account-id"), Action: types.GuardrailSensitiveInformationPolicyActionBlocked}},
+		},
+	})
+	assert.Equal(t, []llm.SafetyCategory{
+		{Name: "PROFANITY", Blocked: true},
+		{Name: "CUSTOM_WORD", Blocked: true},
+		{Name: "EMAIL", Blocked: true},
+		{Name: "account-id", Blocked: true},
+	}, got)
+}
+
 func TestProvider_Name(t *testing.T) {
 	p := New()
 	assert.Equal(t, "bedrock", p.Name())
@@ -376,3 +424,265 @@ func TestBuildRequest_AnthropicBetaHeader(t *testing.T) {
 	require.True(t, ok, "anthropic_beta must be an array")
 	require.Contains(t, betaList, anthropic.BetaInterleavedThinking)
 }
+
+func TestBuildRequest_ThinkingBudget(t *testing.T) {
+	t.Parallel()
+
+	input, err := buildRequest(llm.Request{
+		Model:          "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		Messages:       llm.Messages{llm.User("hello")},
+		Effort:         llm.EffortLow,
+		ThinkingBudget: 5000,
+	})
+	require.NoError(t, err)
+
+	fields := make(map[string]any)
+	_ = input.AdditionalModelRequestFields.UnmarshalSmithyDocument(&fields)
+	assert.Equal(t, map[string]any{"type": "enabled", "budget_tokens": json.Number("5000")}, fields["reasoning_config"])
+}
+
+func TestBuildRequest_ReasoningConfigByModelFamily(t *testing.T) {
+	t.Parallel()
+
+	fields := func(model string, effort llm.Effort) map[string]any {
+		input, err := buildRequest(llm.Request{
+			Model:    model,
+			Messages: llm.Messages{llm.User("hello")},
+			Effort:   effort,
+		})
+		require.NoError(t, err)
+		out := make(map[string]any)
+		if input.AdditionalModelRequestFields != nil {
+			_ = input.AdditionalModelRequestFields.UnmarshalSmithyDocument(&out)
+		}
+		return out
+	}
+
+	nova := fields("us.amazon.nova-2-lite-v1:0", llm.EffortMax)
+	assert.Equal(t, map[string]any{"type": "enabled", "maxReasoningEffort": "high"}, nova["reasoningConfig"])
+	assert.NotContains(t, nova, "reasoning_config")
+
+	claude := fields("us.anthropic.claude-sonnet-4-20250514-v1:0", llm.EffortLow)
+	assert.Equal(t, map[string]any{"type": "enabled", "budget_tokens": json.Number("1024")}, claude["reasoning_config"])
+
+	for _, model := range []string{"us.amazon.nova-pro-v1:0", "meta.llama3-3-70b-instruct-v1:0"} {
+		got := fields(model, llm.EffortHigh)
+		assert.NotContains(t, got, "reasoning_config", model)
+		assert.NotContains(t, got, "reasoningConfig", model)
+	}
+}
+
+func TestApplyReasoningConstraints(t *testing.T) {
+	req := llm.Request{Model: "meta.llama3-3-70b-instruct-v1:0", Thinking: llm.ThinkingOn}
+	_, warnings := applyReasoningConstraints(req)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, llm.WarningParameterIgnored, warnings[0].Code)
+	assert.Equal(t, "thinking", warnings[0].Param)
+
+	req.Thinking = llm.ThinkingAuto
+	_, warnings = applyReasoningConstraints(req)
+	assert.Empty(t, warnings)
+	req.Model = "us.amazon.nova-2-lite-v1:0"
+	req.Thinking = llm.ThinkingOn
+	req.Temperature = 0.3
+	got, warnings := applyReasoningConstraints(req)
+	assert.Empty(t, warnings)
+	assert.Equal(t, 0.3, got.Temperature)
+
+	got, warnings = applyReasoningConstraints(llm.Request{
+		Model:       "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		Effort:      llm.EffortHigh,
+		Temperature: 0.3,
+		TopP:        0.5,
+		TopK:        40,
+	})
+	var params []string
+	for _, w := range warnings {
+		params = append(params, w.Param)
+	}
+	assert.Equal(t, []string{"temperature", "top_k", "top_p"}, params)
+	assert.Equal(t, 1.0, got.Temperature)
+	assert.Equal(t, 0.95, got.TopP)
+	assert.Zero(t, got.TopK)
+}
+
+func TestBuildRequest_InferenceConfig(t *testing.T) {
+	input, err := buildRequest(llm.Request{
+		Model:       "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Messages:    msg.BuildTranscript(msg.User("Hello")),
+		MaxTokens:   2048,
+		Temperature: 0.7,
+		TopP:        0.9,
+		TopK:        40,
+		Stop:        []string{"END"},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, input.InferenceConfig)
+	assert.Equal(t, int32(2048), aws.ToInt32(input.InferenceConfig.MaxTokens))
+	assert.InDelta(t, 0.7, aws.ToFloat32(input.InferenceConfig.Temperature), 1e-6)
+	assert.InDelta(t, 0.9, aws.ToFloat32(input.InferenceConfig.TopP), 1e-6)
+	assert.Equal(t, []string{"END"}, input.InferenceConfig.StopSequences)
+
+	fields := make(map[string]any)
+	_ = input.AdditionalModelRequestFields.UnmarshalSmithyDocument(&fields)
+	assert.Equal(t, json.Number("40"), fields["top_k"])
+}
+
+func TestBuildRequest_ReasoningBudgetBelowMaxTokens(t *testing.T) {
+	input, err := buildRequest(llm.Request{
+		Model:     "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Messages:  msg.BuildTranscript(msg.User("Hello")),
+		MaxTokens: 8000,
+		Effort:    llm.EffortHigh,
+	})
+	require.NoError(t, err)
+
+	fields := make(map[string]any)
+	_ = input.AdditionalModelRequestFields.UnmarshalSmithyDocument(&fields)
+	assert.Equal(t, map[string]any{"type": "enabled", "budget_tokens": json.Number("4000")}, fields["reasoning_config"])
+}
+
+func TestBuildRequest_StopSequences(t *testing.T) {
+	input, err := buildRequest(llm.Request{
+		Model:    "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+		Stop:     []string{"END"},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, input.InferenceConfig)
+	assert.Equal(t, []string{"END"}, input.InferenceConfig.StopSequences)
+	assert.Nil(t, input.InferenceConfig.MaxTokens)
+}
+
+// fakeEventStream is a converseEventStream fed by the test.
+type fakeEventStream struct {
+	events    chan types.ConverseStreamOutput
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newFakeEventStream() *fakeEventStream {
+	return &fakeEventStream{events: make(chan types.ConverseStreamOutput), closed: make(chan struct{})}
+}
+
+func (s *fakeEventStream) Events() <-chan types.ConverseStreamOutput { return s.events }
+func (s *fakeEventStream) Err() error                                { return nil }
+func (s *fakeEventStream) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestParseStream_CancelWhileIdle(t *testing.T) {
+	stream := newFakeEventStream()
+	pub, ch := llm.NewEventPublisher()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go parseStream(ctx, stream, pub, streamMeta{ResolvedModel: "m"})
+	stream.events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		ContentBlockIndex: aws.Int32(0),
+		Delta:             &types.ContentBlockDeltaMemberText{Value: "hi"},
+	}}
+	// No further events: the parser is blocked waiting on the stream.
+	cancel()
+
+	var gotErr *llm.ErrorEvent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for env := range ch {
+			if ev, ok := env.Data.(*llm.ErrorEvent); ok {
+				gotErr = ev
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not terminate after cancellation")
+	}
+	require.NotNil(t, gotErr)
+	assert.ErrorIs(t, gotErr.Error, llm.ErrContextCancelled)
+
+	select {
+	case <-stream.closed:
+	default:
+		t.Fatal("event stream was not closed")
+	}
+}
+
+func TestParseStream_ReasoningDeltasAndWarnings(t *testing.T) {
+	stream := newFakeEventStream()
+	pub, ch := llm.NewEventPublisher()
+	warning := llm.WarningEvent{Code: llm.WarningParameterIgnored, Param: "thinking"}
+	go parseStream(context.Background(), stream, pub, streamMeta{ResolvedModel: "m", Warnings: []llm.WarningEvent{warning}})
+	go func() {
+		stream.events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta: &types.ContentBlockDeltaMemberReasoningContent{
+				Value: &types.ReasoningContentBlockDeltaMemberText{Value: "hmm"},
+			},
+		}}
+		stream.events <- &types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonEndTurn}}
+		close(stream.events)
+	}()
+
+	var thinking []string
+	var warnings []*llm.WarningEvent
+	for env := range ch {
+		switch ev := env.Data.(type) {
+		case *llm.DeltaEvent:
+			if ev.Kind == llm.DeltaKindThinking {
+				thinking = append(thinking, ev.Thinking)
+			}
+		case *llm.WarningEvent:
+			warnings = append(warnings, ev)
+		}
+	}
+	assert.Equal(t, []string{"hmm"}, thinking)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "thinking", warnings[0].Param)
+}
+
+func TestParseStream_GuardrailInterventionSafetyBlock(t *testing.T) {
+	stream := newFakeEventStream()
+	pub, ch := llm.NewEventPublisher()
+	go parseStream(context.Background(), stream, pub, streamMeta{ResolvedModel: "m"})
+	go func() {
+		stream.events <- &types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{
+			StopReason: types.StopReasonGuardrailIntervened,
+		}}
+		stream.events <- &types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(0)},
+			Trace: &types.ConverseStreamTrace{Guardrail: &types.GuardrailTraceAssessment{
+				ActionReason: aws.String("Guardrail blocked."),
+				InputAssessment: map[string]types.GuardrailAssessment{"gr-1": {
+					ContentPolicy: &types.GuardrailContentPolicyAssessment{Filters: []types.GuardrailContentFilter{
+						{Type: types.GuardrailContentFilterTypeViolence, Confidence: types.GuardrailContentFilterConfidenceHigh, Action: types.GuardrailContentPolicyActionBlocked},
+						{Type: types.GuardrailContentFilterTypeHate, Confidence: types.GuardrailContentFilterConfidenceLow, Action: types.GuardrailContentPolicyActionNone},
+					}},
+					TopicPolicy: &types.GuardrailTopicPolicyAssessment{Topics: []types.GuardrailTopic{
+						{Name: aws.String("investment-advice"), Action: types.GuardrailTopicPolicyActionBlocked},
+					}},
+				}},
+			}},
+		}}
+		close(stream.events)
+	}()
+
+	res := llm.NewEventProcessor(context.Background(), ch).Result()
+	require.NoError(t, res.Error())
+	assert.Equal(t, llm.StopReasonContentFilter, res.StopReason())
+	assert.Equal(t, &llm.SafetyBlock{
+		Provider: llm.ProviderNameBedrock,
+		Stage:    llm.SafetyStageInput,
+		Reason:   "guardrail_intervened",
+		Message:  "Guardrail blocked.",
+		Categories: []llm.SafetyCategory{
+			{Name: "VIOLENCE", Severity: "HIGH", Blocked: true},
+			{Name: "investment-advice", Blocked: true},
+		},
+	}, res.Safety())
+}
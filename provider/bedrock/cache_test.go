@@ -3,12 +3,14 @@ package bedrock
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/codewandler/llm"
 	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/usage"
 )
 
 func TestBuildBedrockCachePoint(t *testing.T) {
@@ -125,3 +127,99 @@ func TestBuildRequest_NoCacheHint_NoExtraBlocks(t *testing.T) {
 	// Only the text block, no cachePoint
 	require.Len(t, content, 1)
 }
+
+func TestConverseUsageRecord_CacheTokens(t *testing.T) {
+	u := &types.TokenUsage{
+		InputTokens:           aws.Int32(100),
+		OutputTokens:          aws.Int32(50),
+		CacheReadInputTokens:  aws.Int32(2000),
+		CacheWriteInputTokens: aws.Int32(300),
+		CacheDetails: []types.CacheDetail{
+			{Ttl: types.CacheTTLOneHour, InputTokens: aws.Int32(200)},
+			{Ttl: types.CacheTTLFiveMinutes, InputTokens: aws.Int32(100)},
+		},
+	}
+	rec := converseUsageRecord(u, streamMeta{ResolvedModel: "us.anthropic.claude-sonnet-4-5-20250929-v1:0", RequestID: "req-1"})
+
+	assert.Equal(t, llm.ProviderNameBedrock, rec.Dims.Provider)
+	assert.Equal(t, "req-1", rec.Dims.RequestID)
+	assert.Equal(t, 100, rec.Tokens.Count(usage.KindInput))
+	assert.Equal(t, 2000, rec.Tokens.Count(usage.KindCacheRead))
+	assert.Equal(t, 300, rec.Tokens.Count(usage.KindCacheWrite))
+	assert.Equal(t, 50, rec.Tokens.Count(usage.KindOutput))
+	assert.Equal(t, map[string]int{"1h": 200, "5m": 100}, rec.Details["cache_write_by_ttl"])
+
+	require.Greater(t, rec.Cost.CacheRead, 0.0)
+	require.Greater(t, rec.Cost.CacheWrite, 0.0)
+	assert.Less(t, rec.Cost.CacheRead/2000, rec.Cost.Input/100, "cache reads are cheaper than fresh input")
+	assert.InDelta(t, rec.Cost.Input+rec.Cost.CacheRead+rec.Cost.CacheWrite+rec.Cost.Output, rec.Cost.Total, 1e-12)
+}
+
+func TestConverseUsageRecord_NoCache(t *testing.T) {
+	rec := converseUsageRecord(&types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5)}, streamMeta{ResolvedModel: "anthropic.claude-sonnet-4-5-20250929-v1:0"})
+	assert.Len(t, rec.Tokens, 2)
+	assert.Zero(t, rec.Tokens.Count(usage.KindCacheRead))
+	assert.Nil(t, rec.Details)
+
+	assert.Empty(t, converseUsageRecord(nil, streamMeta{}).Tokens)
+}
+
+func TestConverseUsageRecord_Pricing(t *testing.T) {
+	const model = "eu.anthropic.claude-sonnet-4-5-20250929-v1:0"
+
+	// 1h cache writes cost 2x input instead of 1.25x.
+	rec := converseUsageRecord(&types.TokenUsage{
+		InputTokens:           aws.Int32(0),
+		CacheWriteInputTokens: aws.Int32(1_000_000),
+		CacheDetails: []types.CacheDetail{
+			{Ttl: types.CacheTTLOneHour, InputTokens: aws.Int32(500_000)},
+			{Ttl: types.CacheTTLFiveMinutes, InputTokens: aws.Int32(500_000)},
+		},
+	}, streamMeta{ResolvedModel: model})
+	// Above 200k prompt tokens: long-context rates ($7.50 5m, $12 1h per M).
+	assert.InDelta(t, 0.5*7.5+0.5*12, rec.Cost.CacheWrite, 1e-9)
+	assert.InDelta(t, rec.Cost.CacheWrite, rec.Cost.Total, 1e-9)
+
+	// 1h cache details without a cache write total leave the cost finite.
+	rec = converseUsageRecord(&types.TokenUsage{
+		InputTokens:  aws.Int32(1_000),
+		OutputTokens: aws.Int32(10),
+		CacheDetails: []types.CacheDetail{{Ttl: types.CacheTTLOneHour, InputTokens: aws.Int32(500)}},
+	}, streamMeta{ResolvedModel: model})
+	assert.Zero(t, rec.Cost.CacheWrite)
+	assert.InDelta(t, 0.003+0.00015, rec.Cost.Total, 1e-9)
+
+	// Below the threshold: base rates, cache reads included.
+	rec = converseUsageRecord(&types.TokenUsage{
+		InputTokens:          aws.Int32(10_000),
+		CacheReadInputTokens: aws.Int32(100_000),
+		OutputTokens:         aws.Int32(1_000),
+	}, streamMeta{ResolvedModel: model})
+	assert.InDelta(t, 0.03, rec.Cost.Input, 1e-9)
+	assert.InDelta(t, 0.03, rec.Cost.CacheRead, 1e-9)
+	assert.InDelta(t, 0.015, rec.Cost.Output, 1e-9)
+
+	// Crossing it reprices the whole request.
+	rec = converseUsageRecord(&types.TokenUsage{
+		InputTokens:          aws.Int32(10_000),
+		CacheReadInputTokens: aws.Int32(200_000),
+		OutputTokens:         aws.Int32(1_000),
+	}, streamMeta{ResolvedModel: model})
+	assert.InDelta(t, 0.06, rec.Cost.Input, 1e-9)
+	assert.InDelta(t, 0.12, rec.Cost.CacheRead, 1e-9)
+	assert.InDelta(t, 0.0225, rec.Cost.Output, 1e-9)
+}
+
+func TestCostCalculator_TableRates(t *testing.T) {
+	assert.Empty(t, pricing.Shadowed(llm.ProviderNameBedrock, nil), "drop table entries the catalog prices")
+	for _, m := range models() {
+		assert.NotNil(t, m.Pricing, m.ID)
+	}
+
+	rec := converseUsageRecord(&types.TokenUsage{
+		InputTokens:  aws.Int32(1_000_000),
+		OutputTokens: aws.Int32(1_000_000),
+	}, streamMeta{ResolvedModel: "eu." + ModelNovaPro})
+	assert.InDelta(t, 0.8, rec.Cost.Input, 1e-9)
+	assert.InDelta(t, 3.2, rec.Cost.Output, 1e-9)
+}
@@ -0,0 +1,46 @@
+package bedrock
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/codewandler/llm"
+)
+
+// dryRun serializes and signs input with the SDK's own middleware stack and
+// hands the resulting HTTP request to llm.CaptureDryRun instead of sending
+// it, so the captured body is the Converse wire format including cachePoint
+// blocks. The returned error wraps llm.ErrDryRun once the request was
+// captured; signing errors such as missing credentials are returned as is.
+func dryRun(ctx context.Context, client *bedrockruntime.Client, input *bedrockruntime.ConverseStreamInput, opts llm.Request) error {
+	capture := middleware.FinalizeMiddlewareFunc("llmDryRun", func(ctx context.Context, in middleware.FinalizeInput, _ middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		req, ok := in.Request.(*smithyhttp.Request)
+		if !ok {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, fmt.Errorf("%w: unexpected request type %T", llm.ErrDryRun, in.Request)
+		}
+		var body []byte
+		if s := req.GetStream(); s != nil {
+			b, err := io.ReadAll(s)
+			if err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			body = b
+		}
+		llm.CaptureDryRun(ctx, &llm.RequestEvent{
+			OriginalRequest: opts,
+			ProviderRequest: llm.ProviderRequestFromHTTP(req.Request, body),
+		})
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, llm.ErrDryRun
+	})
+	_, err := client.ConverseStream(ctx, input, func(o *bedrockruntime.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Finalize.Add(capture, middleware.After)
+		})
+	})
+	return err
+}
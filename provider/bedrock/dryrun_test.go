@@ -0,0 +1,50 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+)
+
+type failTransport struct{ t *testing.T }
+
+func (f failTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.t.Errorf("dry run sent %s %s", r.Method, r.URL)
+	return nil, http.ErrHandlerTimeout
+}
+
+func TestDryRun_CachePoints(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "") // a CA bundle needs the SDK's own HTTP client
+	p := New(
+		WithRegion("us-east-1"),
+		WithCredentialsProvider(&mockCredentialsProvider{creds: aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}}),
+		WithLLMOptions(llm.WithHTTPClient(&http.Client{Transport: failTransport{t}})),
+	)
+	sys := msg.System("Big system").Build()
+	sys.CacheHint = &llm.CacheHint{Enabled: true}
+
+	ev, err := llm.DryRun(t.Context(), p, llm.Request{
+		Model:    "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Messages: msg.BuildTranscript(sys, msg.User("Hello")),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, ev.ProviderRequest.Method)
+	assert.Contains(t, ev.ProviderRequest.URL, "/converse-stream")
+	assert.Equal(t, "[REDACTED]", ev.ProviderRequest.Headers["Authorization"])
+
+	var body struct {
+		System []map[string]any `json:"system"`
+	}
+	require.NoError(t, json.Unmarshal(ev.ProviderRequest.Body, &body))
+	require.Len(t, body.System, 2)
+	assert.Equal(t, "Big system", body.System[0]["text"])
+	assert.Contains(t, body.System[1], "cachePoint")
+}
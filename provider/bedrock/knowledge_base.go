@@ -0,0 +1,278 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+)
+
+// defaultKnowledgeBaseResults is the number of chunks retrieved per query
+// when WithNumberOfResults is not set; it matches the service default.
+const defaultKnowledgeBaseResults = 5
+
+// KnowledgeBase queries an Amazon Bedrock Knowledge Base through the Retrieve
+// API of the bedrock-agent-runtime service, using the region, credentials and
+// HTTP client of the Provider it was created from.
+//
+// Retrieved chunks reach the model in one of two ways: as a tool the model
+// calls when it needs them (Tool), or injected as context into every request
+// (Middleware), which pairs the Converse API with a knowledge base the way
+// RetrieveAndGenerate does while keeping control of the model call.
+//
+// Example:
+//
+//	p := bedrock.New(bedrock.WithRegion(bedrock.RegionEUCentral1))
+//	kb := p.KnowledgeBase("KBID12345").WithNumberOfResults(8)
+//
+//	// As a tool:
+//	tools := tool.NewToolSet(kb.Tool("search_docs", "Search the product documentation"))
+//
+//	// As injected context:
+//	grounded := llm.Wrap(p, kb.Middleware())
+type KnowledgeBase struct {
+	p          *Provider
+	id         string
+	numResults int
+	endpoint   string
+}
+
+// KnowledgeBase returns a KnowledgeBase client for the knowledge base id.
+func (p *Provider) KnowledgeBase(id string) *KnowledgeBase {
+	return &KnowledgeBase{p: p, id: id, numResults: defaultKnowledgeBaseResults}
+}
+
+// WithNumberOfResults sets how many chunks a query returns.
+func (kb *KnowledgeBase) WithNumberOfResults(n int) *KnowledgeBase {
+	kb.numResults = n
+	return kb
+}
+
+// WithEndpoint overrides the service endpoint, e.g. for a VPC endpoint. The
+// default is https://bedrock-agent-runtime.<region>.amazonaws.com.
+func (kb *KnowledgeBase) WithEndpoint(endpoint string) *KnowledgeBase {
+	kb.endpoint = strings.TrimRight(endpoint, "/")
+	return kb
+}
+
+// RetrievalResult is a chunk returned by a knowledge base query.
+type RetrievalResult struct {
+	// Text is the chunk content.
+	Text string `json:"text"`
+	// Score is the relevance score assigned by the service.
+	Score float64 `json:"score"`
+	// Source identifies the document the chunk came from (S3 URI, web URL,
+	// ...). Empty when the service reports no location.
+	Source string `json:"source,omitempty"`
+	// Metadata holds the document metadata attributes.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+type retrieveRequest struct {
+	RetrievalQuery struct {
+		Text string `json:"text"`
+	} `json:"retrievalQuery"`
+	RetrievalConfiguration struct {
+		VectorSearchConfiguration struct {
+			NumberOfResults int `json:"numberOfResults"`
+		} `json:"vectorSearchConfiguration"`
+	} `json:"retrievalConfiguration"`
+}
+
+type retrieveResponse struct {
+	RetrievalResults []struct {
+		Content struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		Location map[string]json.RawMessage `json:"location"`
+		Metadata map[string]any             `json:"metadata"`
+		Score    float64                    `json:"score"`
+	} `json:"retrievalResults"`
+}
+
+// Retrieve returns the chunks most relevant to query, best first.
+func (kb *KnowledgeBase) Retrieve(ctx context.Context, query string) ([]RetrievalResult, error) {
+	client, err := kb.p.loadClient(ctx)
+	if err != nil {
+		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
+	}
+	opts := client.Options()
+	if opts.Credentials == nil {
+		return nil, llm.NewErrMissingAPIKey(llm.ProviderNameBedrock)
+	}
+	creds, err := opts.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, fmt.Errorf("retrieve AWS credentials: %w", err))
+	}
+
+	var in retrieveRequest
+	in.RetrievalQuery.Text = query
+	in.RetrievalConfiguration.VectorSearchConfiguration.NumberOfResults = kb.numResults
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, err)
+	}
+
+	endpoint := kb.endpoint
+	if endpoint == "" {
+		endpoint = "https://bedrock-agent-runtime." + opts.Region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		endpoint+"/knowledgebases/"+url.PathEscape(kb.id)+"/retrieve", bytes.NewReader(body))
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	sum := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "bedrock", opts.Region, time.Now()); err != nil {
+		return nil, llm.NewErrBuildRequest(llm.ProviderNameBedrock, fmt.Errorf("sign request: %w", err))
+	}
+
+	resp, err := kb.p.httpClient.Do(req)
+	if err != nil {
+		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, llm.NewErrAPIError(llm.ProviderNameBedrock, resp.StatusCode, string(data)).WithRetryAfter(resp.Header)
+	}
+
+	var out retrieveResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, llm.NewErrRequestFailed(llm.ProviderNameBedrock, fmt.Errorf("decode retrieve response: %w", err))
+	}
+	results := make([]RetrievalResult, len(out.RetrievalResults))
+	for i, r := range out.RetrievalResults {
+		results[i] = RetrievalResult{
+			Text:     r.Content.Text,
+			Score:    r.Score,
+			Source:   retrievalSource(r.Location),
+			Metadata: r.Metadata,
+		}
+	}
+	return results, nil
+}
+
+// retrievalSource picks the document reference out of a Retrieve location,
+// whose shape depends on the data source type (s3Location.uri,
+// webLocation.url, confluenceLocation.url, ...).
+func retrievalSource(location map[string]json.RawMessage) string {
+	for key, raw := range location {
+		if !strings.HasSuffix(key, "Location") {
+			continue
+		}
+		var ref map[string]any
+		if json.Unmarshal(raw, &ref) != nil {
+			continue
+		}
+		for _, field := range []string{"uri", "url"} {
+			if s, ok := ref[field].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// FormatRetrievalResults renders results as numbered <source> blocks for
+// inclusion in a prompt or tool result.
+func FormatRetrievalResults(results []RetrievalResult) string {
+	var sb strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(`<source id="` + strconv.Itoa(i+1) + `"`)
+		if r.Source != "" {
+			sb.WriteString(` ref="` + r.Source + `"`)
+		}
+		sb.WriteString(">\n" + strings.TrimSpace(r.Text) + "\n</source>")
+	}
+	return sb.String()
+}
+
+// KnowledgeBaseQuery is the parameter type of the knowledge base tool.
+type KnowledgeBaseQuery struct {
+	Query string `json:"query" jsonschema:"description=Search query in natural language,required"`
+}
+
+// Tool returns a tool spec, with handler, that lets the model query the
+// knowledge base. Results are returned as FormatRetrievalResults output.
+func (kb *KnowledgeBase) Tool(name, description string) *tool.Spec[KnowledgeBaseQuery] {
+	return tool.NewSpec[KnowledgeBaseQuery](name, description).
+		WithHandler(func(ctx context.Context, in KnowledgeBaseQuery) (string, error) {
+			results, err := kb.Retrieve(ctx, in.Query)
+			if err != nil {
+				return "", err
+			}
+			if len(results) == 0 {
+				return "No results.", nil
+			}
+			return FormatRetrievalResults(results), nil
+		})
+}
+
+// Augment returns req with the chunks retrieved for its last user message
+// inserted as a system message after the leading system messages, so a
+// stable system prompt stays first and cacheable. Requests without user text
+// or without results are returned unchanged.
+func (kb *KnowledgeBase) Augment(ctx context.Context, req llm.Request) (llm.Request, error) {
+	var query string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].IsUser() {
+			query = strings.TrimSpace(req.Messages[i].Text())
+			break
+		}
+	}
+	if query == "" {
+		return req, nil
+	}
+	results, err := kb.Retrieve(ctx, query)
+	if err != nil || len(results) == 0 {
+		return req, err
+	}
+
+	text := "Use the following retrieved documents to answer. Cite sources by id.\n<retrieved_context>\n" +
+		FormatRetrievalResults(results) + "\n</retrieved_context>"
+	i := 0
+	for i < len(req.Messages) && (req.Messages[i].IsSystem() || req.Messages[i].IsDeveloper()) {
+		i++
+	}
+	msgs := make(llm.Messages, 0, len(req.Messages)+1)
+	msgs = append(msgs, req.Messages[:i]...)
+	msgs = append(msgs, llm.System(text))
+	req.Messages = append(msgs, req.Messages[i:]...)
+	return req, nil
+}
+
+// Middleware returns a middleware that runs Augment on every request, for
+// use with llm.Wrap. A retrieval error fails the request.
+func (kb *KnowledgeBase) Middleware() llm.Middleware {
+	return llm.StreamMiddleware(func(ctx context.Context, src llm.Buildable, next llm.Provider) (llm.Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if req, err = kb.Augment(ctx, req); err != nil {
+			return nil, err
+		}
+		return next.CreateStream(ctx, req)
+	})
+}
@@ -0,0 +1,120 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+)
+
+func knowledgeBaseServer(t *testing.T, queries *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/knowledgebases/KB1/retrieve", r.URL.Path)
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/eu-central-1/bedrock/aws4_request")
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		query := body["retrievalQuery"].(map[string]any)["text"].(string)
+		*queries = append(*queries, query)
+		assert.Equal(t, float64(2),
+			body["retrievalConfiguration"].(map[string]any)["vectorSearchConfiguration"].(map[string]any)["numberOfResults"])
+
+		if query == "fail" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"message":"slow down"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"retrievalResults":[
+			{"content":{"text":"Refunds take 5 days.","type":"TEXT"},"location":{"type":"S3","s3Location":{"uri":"s3://docs/refunds.md"}},"metadata":{"lang":"en"},"score":0.9},
+			{"content":{"text":"Contact support."},"location":{"type":"WEB","webLocation":{"url":"https://example.com/help"}},"score":0.5}
+		]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testKnowledgeBase(t *testing.T, srv *httptest.Server) *KnowledgeBase {
+	t.Setenv("AWS_CA_BUNDLE", "") // a CA bundle cannot be applied to the plain *http.Client
+	p := New(
+		WithRegion(RegionEUCentral1),
+		WithCredentialsProvider(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		})),
+	)
+	return p.KnowledgeBase("KB1").WithNumberOfResults(2).WithEndpoint(srv.URL + "/")
+}
+
+func TestKnowledgeBase_Retrieve(t *testing.T) {
+	var queries []string
+	kb := testKnowledgeBase(t, knowledgeBaseServer(t, &queries))
+
+	results, err := kb.Retrieve(context.Background(), "refund time")
+	require.NoError(t, err)
+	assert.Equal(t, []RetrievalResult{
+		{Text: "Refunds take 5 days.", Score: 0.9, Source: "s3://docs/refunds.md", Metadata: map[string]any{"lang": "en"}},
+		{Text: "Contact support.", Score: 0.5, Source: "https://example.com/help"},
+	}, results)
+	assert.Equal(t, []string{"refund time"}, queries)
+
+	_, err = kb.Retrieve(context.Background(), "fail")
+	var pe *llm.ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, http.StatusTooManyRequests, pe.StatusCode)
+}
+
+func TestKnowledgeBase_Tool(t *testing.T) {
+	var queries []string
+	kb := testKnowledgeBase(t, knowledgeBaseServer(t, &queries))
+
+	ts := tool.NewToolSet(kb.Tool("search_docs", "Search the docs"))
+	res := ts.ExecuteCall(context.Background(), tool.NewToolCall("c1", "search_docs", tool.Args{"query": "refunds"}))
+	require.False(t, res.IsError(), "%v", res.ToolOutput())
+	assert.Equal(t, `<source id="1" ref="s3://docs/refunds.md">
+Refunds take 5 days.
+</source>
+<source id="2" ref="https://example.com/help">
+Contact support.
+</source>`, res.ToolOutput())
+}
+
+func TestKnowledgeBase_Augment(t *testing.T) {
+	var queries []string
+	kb := testKnowledgeBase(t, knowledgeBaseServer(t, &queries))
+
+	req := llm.Request{Model: "m", Messages: llm.Messages{
+		llm.System("You are support."),
+		llm.User("hello"),
+		msg.Assistant(msg.Text("hi")).Build(),
+		llm.User("How long do refunds take?"),
+	}}
+	out, err := kb.Augment(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"How long do refunds take?"}, queries, "queries with the last user message")
+	require.Len(t, out.Messages, 5)
+	assert.Equal(t, "You are support.", out.Messages[0].Text())
+	assert.True(t, out.Messages[1].IsSystem())
+	assert.Contains(t, out.Messages[1].Text(), "<retrieved_context>")
+	assert.Contains(t, out.Messages[1].Text(), "Refunds take 5 days.")
+	assert.Len(t, req.Messages, 4, "input request is not modified")
+
+	noUser := llm.Request{Model: "m", Messages: llm.Messages{llm.System("x")}}
+	out, err = kb.Augment(context.Background(), noUser)
+	require.NoError(t, err)
+	assert.Len(t, out.Messages, 1)
+	assert.Len(t, queries, 1)
+}
@@ -2,6 +2,7 @@ package bedrock
 
 import (
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
 )
 
 // -----------------------------------------------------------------------------
@@ -98,17 +99,12 @@ const (
 // Model Registry (Single Source of Truth)
 // -----------------------------------------------------------------------------
 
-// modelDef defines a single model with all its metadata.
-// Pricing fields are the authoritative source for the entries in usage/pricing.go;
-// they are not used for runtime cost calculation (which goes through usage.Default()).
+// modelDef defines a single model with all its metadata. Prices live in the
+// model catalog and, for models it does not price, in pricing.
 type modelDef struct {
-	ID               string   // Bedrock model ID (use constants above)
-	Name             string   // Human-readable name
-	InputPrice       float64  // USD per 1M input tokens (0 if unknown)
-	OutputPrice      float64  // USD per 1M output tokens (0 if unknown)
-	CachedInputPrice float64  // USD per 1M cache read tokens (~0.1× input)
-	CacheWritePrice  float64  // USD per 1M cache write tokens (~1.25× input)
-	Prefixes         []string // Inference profile prefixes (nil = no profile)
+	ID       string   // Bedrock model ID (use constants above)
+	Name     string   // Human-readable name
+	Prefixes []string // Inference profile prefixes (nil = no profile)
 }
 
 // Common prefix combinations for inference profiles.
@@ -121,78 +117,118 @@ var (
 
 // allModels is the single source of truth for all Bedrock models.
 // Order defines display order in Models().
-// Pricing data from AWS Bedrock pricing page (us-east-1 region).
 var allModels = []modelDef{
 	// -------------------------------------------------------------------------
-	// Anthropic Claude (cache pricing: read=0.1×input, write=1.25×input)
+	// Anthropic Claude
 	// -------------------------------------------------------------------------
-	{ModelOpusLatest, "Claude Opus 4.6", 5.00, 25.00, 0.50, 6.25, prefixesEUUSGlobal},
-	{ModelSonnetLatest, "Claude Sonnet 4.6", 3.00, 15.00, 0.30, 3.75, prefixesEUUSGlobal},
-	{ModelHaikuLatest, "Claude Haiku 4.5", 1.00, 5.00, 0.10, 1.25, prefixesEUUSGlobal},
-	{ModelOpus45, "Claude Opus 4.5", 5.00, 25.00, 0.50, 6.25, prefixesEUUSGlobal},
-	{ModelSonnet45, "Claude Sonnet 4.5", 3.00, 15.00, 0.30, 3.75, prefixesEUUSGlobal},
-	{ModelSonnet37, "Claude 3.7 Sonnet", 3.00, 15.00, 0.30, 3.75, prefixesEUUSAPAC},
-	{ModelSonnet35, "Claude 3.5 Sonnet", 3.00, 15.00, 0.30, 3.75, prefixesEUUSAPAC},
-	{ModelHaiku3, "Claude 3 Haiku", 0.25, 1.25, 0.025, 0.3125, prefixesEUUSAPAC},
+	{ModelOpusLatest, "Claude Opus 4.6", prefixesEUUSGlobal},
+	{ModelSonnetLatest, "Claude Sonnet 4.6", prefixesEUUSGlobal},
+	{ModelHaikuLatest, "Claude Haiku 4.5", prefixesEUUSGlobal},
+	{ModelOpus45, "Claude Opus 4.5", prefixesEUUSGlobal},
+	{ModelSonnet45, "Claude Sonnet 4.5", prefixesEUUSGlobal},
+	{ModelSonnet37, "Claude 3.7 Sonnet", prefixesEUUSAPAC},
+	{ModelSonnet35, "Claude 3.5 Sonnet", prefixesEUUSAPAC},
+	{ModelHaiku3, "Claude 3 Haiku", prefixesEUUSAPAC},
 
 	// -------------------------------------------------------------------------
 	// Amazon Nova
 	// -------------------------------------------------------------------------
-	{ModelNovaPremier, "Amazon Nova Premier", 2.50, 10.00, 0, 0, prefixesUSOnly},
-	{ModelNovaPro, "Amazon Nova Pro", 0.80, 3.20, 0, 0, prefixesEUUSAPAC},
-	{ModelNova2Lite, "Amazon Nova 2 Lite", 0.06, 0.24, 0, 0, prefixesEUUSGlobal},
-	{ModelNovaLite, "Amazon Nova Lite", 0.06, 0.24, 0, 0, prefixesEUUSAPAC},
-	{ModelNovaMicro, "Amazon Nova Micro", 0.035, 0.14, 0, 0, prefixesEUUSAPAC},
+	{ModelNovaPremier, "Amazon Nova Premier", prefixesUSOnly},
+	{ModelNovaPro, "Amazon Nova Pro", prefixesEUUSAPAC},
+	{ModelNova2Lite, "Amazon Nova 2 Lite", prefixesEUUSGlobal},
+	{ModelNovaLite, "Amazon Nova Lite", prefixesEUUSAPAC},
+	{ModelNovaMicro, "Amazon Nova Micro", prefixesEUUSAPAC},
 
 	// -------------------------------------------------------------------------
 	// Cohere
 	// -------------------------------------------------------------------------
-	{ModelCommandRPlus, "Command R+", 2.50, 10.00, 0, 0, nil},
-	{ModelCommandR, "Command R", 0.15, 0.60, 0, 0, nil},
-	{ModelCohereEmbedV4, "Cohere Embed v4", 0.10, 0, 0, 0, prefixesEUUSGlobal},
+	{ModelCommandRPlus, "Command R+", nil},
+	{ModelCommandR, "Command R", nil},
+	{ModelCohereEmbedV4, "Cohere Embed v4", prefixesEUUSGlobal},
 
 	// -------------------------------------------------------------------------
 	// DeepSeek
 	// -------------------------------------------------------------------------
-	{ModelDeepSeekR1, "DeepSeek R1", 1.35, 5.40, 0, 0, prefixesUSOnly},
+	{ModelDeepSeekR1, "DeepSeek R1", prefixesUSOnly},
 
 	// -------------------------------------------------------------------------
 	// Meta Llama
 	// -------------------------------------------------------------------------
-	{ModelLlama4Maverick, "Llama 4 Maverick 17B", 0.22, 0.88, 0, 0, prefixesUSOnly},
-	{ModelLlama4Scout, "Llama 4 Scout 17B", 0.22, 0.88, 0, 0, prefixesUSOnly},
-	{ModelLlama33_70B, "Llama 3.3 70B Instruct", 0.72, 0.72, 0, 0, prefixesUSOnly},
-	{ModelLlama32_90B, "Llama 3.2 90B Instruct", 0.72, 0.72, 0, 0, prefixesUSOnly},
-	{ModelLlama32_11B, "Llama 3.2 11B Instruct", 0.16, 0.16, 0, 0, prefixesUSOnly},
-	{ModelLlama32_3B, "Llama 3.2 3B Instruct", 0.15, 0.15, 0, 0, prefixesEUUS},
-	{ModelLlama32_1B, "Llama 3.2 1B Instruct", 0.10, 0.10, 0, 0, prefixesEUUS},
-	{ModelLlama31_70B, "Llama 3.1 70B Instruct", 0.72, 0.72, 0, 0, prefixesUSOnly},
-	{ModelLlama31_8B, "Llama 3.1 8B Instruct", 0.22, 0.22, 0, 0, prefixesUSOnly},
-	{ModelLlama3_70B, "Llama 3 70B Instruct", 2.65, 3.50, 0, 0, nil},
-	{ModelLlama3_8B, "Llama 3 8B Instruct", 0.30, 0.60, 0, 0, nil},
+	{ModelLlama4Maverick, "Llama 4 Maverick 17B", prefixesUSOnly},
+	{ModelLlama4Scout, "Llama 4 Scout 17B", prefixesUSOnly},
+	{ModelLlama33_70B, "Llama 3.3 70B Instruct", prefixesUSOnly},
+	{ModelLlama32_90B, "Llama 3.2 90B Instruct", prefixesUSOnly},
+	{ModelLlama32_11B, "Llama 3.2 11B Instruct", prefixesUSOnly},
+	{ModelLlama32_3B, "Llama 3.2 3B Instruct", prefixesEUUS},
+	{ModelLlama32_1B, "Llama 3.2 1B Instruct", prefixesEUUS},
+	{ModelLlama31_70B, "Llama 3.1 70B Instruct", prefixesUSOnly},
+	{ModelLlama31_8B, "Llama 3.1 8B Instruct", prefixesUSOnly},
+	{ModelLlama3_70B, "Llama 3 70B Instruct", nil},
+	{ModelLlama3_8B, "Llama 3 8B Instruct", nil},
 
 	// -------------------------------------------------------------------------
 	// Mistral
 	// -------------------------------------------------------------------------
-	{ModelMistralLarge3, "Mistral Large 3", 0.50, 1.50, 0, 0, nil},
-	{ModelPixtralLarge, "Pixtral Large", 0.50, 1.50, 0, 0, prefixesEUUS},
-	{ModelDevstral2, "Devstral 2", 0.40, 2.00, 0, 0, nil},
-	{ModelMagistralSmall, "Magistral Small", 0.50, 1.50, 0, 0, nil},
-	{ModelMinistral14B, "Ministral 14B", 0.20, 0.20, 0, 0, nil},
-	{ModelMinistral8B, "Ministral 8B", 0.15, 0.15, 0, 0, nil},
-	{ModelMinistral3B, "Ministral 3B", 0.10, 0.10, 0, 0, nil},
-	{ModelVoxtralSmall, "Voxtral Small", 0.10, 0.30, 0, 0, nil},
-	{ModelVoxtralMini, "Voxtral Mini", 0.04, 0.04, 0, 0, nil},
-	{ModelMistralLarge2402, "Mistral Large (24.02)", 4.00, 12.00, 0, 0, nil},
-	{ModelMistralSmall, "Mistral Small", 0.10, 0.30, 0, 0, nil},
-	{ModelMixtral8x7B, "Mixtral 8x7B Instruct", 0.45, 0.70, 0, 0, nil},
-	{ModelMistral7B, "Mistral 7B Instruct", 0.15, 0.20, 0, 0, nil},
+	{ModelMistralLarge3, "Mistral Large 3", nil},
+	{ModelPixtralLarge, "Pixtral Large", prefixesEUUS},
+	{ModelDevstral2, "Devstral 2", nil},
+	{ModelMagistralSmall, "Magistral Small", nil},
+	{ModelMinistral14B, "Ministral 14B", nil},
+	{ModelMinistral8B, "Ministral 8B", nil},
+	{ModelMinistral3B, "Ministral 3B", nil},
+	{ModelVoxtralSmall, "Voxtral Small", nil},
+	{ModelVoxtralMini, "Voxtral Mini", nil},
+	{ModelMistralLarge2402, "Mistral Large (24.02)", nil},
+	{ModelMistralSmall, "Mistral Small", nil},
+	{ModelMixtral8x7B, "Mixtral 8x7B Instruct", nil},
+	{ModelMistral7B, "Mistral 7B Instruct", nil},
 
 	// -------------------------------------------------------------------------
 	// Writer
 	// -------------------------------------------------------------------------
-	{ModelPalmyraX4, "Palmyra X4", 2.50, 10.00, 0, 0, prefixesUSOnly},
-	{ModelPalmyraX5, "Palmyra X5", 0.60, 6.00, 0, 0, prefixesUSOnly},
+	{ModelPalmyraX4, "Palmyra X4", prefixesUSOnly},
+	{ModelPalmyraX5, "Palmyra X5", prefixesUSOnly},
+}
+
+// pricing lists on-demand prices (us-east-1) in USD per million tokens for
+// the Bedrock models the model catalog does not price. Claude models are
+// priced from the catalog.
+var pricing = usage.PricingTable{
+	ModelNovaPremier:      {Input: 2.50, Output: 10.00},
+	ModelNovaPro:          {Input: 0.80, Output: 3.20},
+	ModelNova2Lite:        {Input: 0.06, Output: 0.24},
+	ModelNovaLite:         {Input: 0.06, Output: 0.24},
+	ModelNovaMicro:        {Input: 0.035, Output: 0.14},
+	ModelCommandRPlus:     {Input: 2.50, Output: 10.00},
+	ModelCommandR:         {Input: 0.15, Output: 0.60},
+	ModelCohereEmbedV4:    {Input: 0.10},
+	ModelDeepSeekR1:       {Input: 1.35, Output: 5.40},
+	ModelLlama4Maverick:   {Input: 0.22, Output: 0.88},
+	ModelLlama4Scout:      {Input: 0.22, Output: 0.88},
+	ModelLlama33_70B:      {Input: 0.72, Output: 0.72},
+	ModelLlama32_90B:      {Input: 0.72, Output: 0.72},
+	ModelLlama32_11B:      {Input: 0.16, Output: 0.16},
+	ModelLlama32_3B:       {Input: 0.15, Output: 0.15},
+	ModelLlama32_1B:       {Input: 0.10, Output: 0.10},
+	ModelLlama31_70B:      {Input: 0.72, Output: 0.72},
+	ModelLlama31_8B:       {Input: 0.22, Output: 0.22},
+	ModelLlama3_70B:       {Input: 2.65, Output: 3.50},
+	ModelLlama3_8B:        {Input: 0.30, Output: 0.60},
+	ModelMistralLarge3:    {Input: 0.50, Output: 1.50},
+	ModelPixtralLarge:     {Input: 0.50, Output: 1.50},
+	ModelDevstral2:        {Input: 0.40, Output: 2.00},
+	ModelMagistralSmall:   {Input: 0.50, Output: 1.50},
+	ModelMinistral14B:     {Input: 0.20, Output: 0.20},
+	ModelMinistral8B:      {Input: 0.15, Output: 0.15},
+	ModelMinistral3B:      {Input: 0.10, Output: 0.10},
+	ModelVoxtralSmall:     {Input: 0.10, Output: 0.30},
+	ModelVoxtralMini:      {Input: 0.04, Output: 0.04},
+	ModelMistralLarge2402: {Input: 4.00, Output: 12.00},
+	ModelMistralSmall:     {Input: 0.10, Output: 0.30},
+	ModelMixtral8x7B:      {Input: 0.45, Output: 0.70},
+	ModelMistral7B:        {Input: 0.15, Output: 0.20},
+	ModelPalmyraX4:        {Input: 2.50, Output: 10.00},
+	ModelPalmyraX5:        {Input: 0.60, Output: 6.00},
 }
 
 // -----------------------------------------------------------------------------
@@ -215,15 +251,38 @@ func init() {
 func models() []llm.Model {
 	result := make([]llm.Model, 0, len(allModels))
 	for _, m := range allModels {
-		result = append(result, llm.Model{
+		model := llm.Model{
 			ID:       m.ID,
 			Name:     m.Name,
 			Provider: providerName,
-		})
+		}
+		if p, ok := modelPricing(m.ID); ok {
+			model.Pricing = &p
+		}
+		result = append(result, model)
 	}
 	return result
 }
 
+// modelPricing returns the rates model is priced at: the local table first,
+// then the model catalog.
+func modelPricing(model string) (usage.Pricing, bool) {
+	model = stripRegionPrefix(model)
+	if p, ok := pricing[model]; ok {
+		return p, true
+	}
+	return usage.CatalogPricing(llm.ProviderNameBedrock, model)
+}
+
+var tableCalculator = pricing.Calculator(llm.ProviderNameBedrock)
+
+// costCalculator prices usage records from the pricing table, then the model
+// catalog. Regional inference profile prefixes (eu., global., ...) are
+// ignored by the table.
+var costCalculator = usage.ForProvider(usage.CostCalculatorFunc(func(provider, model string, tokens usage.TokenItems) (usage.Cost, bool) {
+	return tableCalculator.Calculate(provider, stripRegionPrefix(model), tokens)
+}))
+
 // ModelAliases maps short alias names to full Bedrock model IDs.
 // These are used by the auto package for provider-prefixed resolution
 // (e.g., "bedrock/sonnet", "bedrock/haiku", "bedrock/opus").
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/codewandler/llm"
 )
 
 const (
@@ -80,7 +82,7 @@ func loadAuthFrom(path string) (*Auth, error) {
 	if auth.Tokens.AccessToken == "" && auth.Tokens.RefreshToken == "" {
 		return nil, fmt.Errorf("codex: no tokens in %s", path)
 	}
-	a := &Auth{auth: auth, path: path, httpClient: http.DefaultClient}
+	a := &Auth{auth: auth, path: path, httpClient: llm.DefaultHttpClient()}
 	if exp, err := jwtExpiry(auth.Tokens.AccessToken); err == nil {
 		a.expiry = exp
 	}
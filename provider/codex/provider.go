@@ -167,7 +167,7 @@ func (p *Provider) FetchRawModels(ctx context.Context) ([]byte, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, llm.NewErrAPIError(p.Name(), resp.StatusCode, string(body))
+		return nil, llm.NewErrAPIError(p.Name(), resp.StatusCode, string(body)).WithRetryAfter(resp.Header)
 	}
 	return io.ReadAll(resp.Body)
 }
@@ -0,0 +1,301 @@
+// Package completion implements a provider for servers that only expose the
+// OpenAI-compatible text completion endpoint (/v1/completions), such as
+// llama.cpp, vLLM or Ollama serving base models. Conversations are rendered
+// into a single prompt with a chat template; the raw text the model produces
+// is streamed back as text deltas.
+package completion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/chattemplate"
+	"github.com/codewandler/llm/internal/sse"
+	"github.com/codewandler/llm/usage"
+)
+
+const (
+	ProviderName   = "completion"
+	defaultBaseURL = "http://localhost:8080"
+)
+
+// Provider streams completions for prompts rendered from llm.Request
+// messages.
+type Provider struct {
+	opts     *llm.Options
+	client   *http.Client
+	template *chattemplate.Template
+	models   llm.Models
+}
+
+// New returns a completion provider. When tmpl is nil the template is chosen
+// per request with chattemplate.ForModel; requests for unknown model
+// families then fail.
+func New(tmpl *chattemplate.Template, opts ...llm.Option) *Provider {
+	allOpts := append([]llm.Option{llm.WithBaseURL(defaultBaseURL)}, opts...)
+	o := llm.Apply(allOpts...)
+	client := o.HTTPClient
+	if client == nil {
+		client = llm.DefaultHttpClient()
+	}
+	if o.Retry != nil {
+		client = llm.RetryHTTPClient(client, *o.Retry)
+	}
+	return &Provider{opts: o, client: client, template: tmpl}
+}
+
+// WithModels sets the models reported by Models.
+func (p *Provider) WithModels(models ...llm.Model) *Provider {
+	p.models = models
+	return p
+}
+
+func (p *Provider) Name() string       { return ProviderName }
+func (p *Provider) Models() llm.Models { return p.models }
+
+// PromptRequest is a raw completion request.
+type PromptRequest struct {
+	Model       string
+	Prompt      string
+	MaxTokens   int
+	Temperature float64
+	TopP        float64
+	TopK        int
+	Stop        []string
+}
+
+// CreateStream renders the request messages with the chat template and
+// streams the completion. Tools, tool choice and output format cannot be
+// expressed in completion mode; they are dropped with a warning.
+func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, llm.NewErrBuildRequest(ProviderName, err)
+	}
+	tmpl := p.template
+	if tmpl == nil {
+		var ok bool
+		if tmpl, ok = chattemplate.ForModel(req.Model); !ok {
+			return nil, llm.NewErrBuildRequest(ProviderName, fmt.Errorf("no chat template for model %q", req.Model))
+		}
+	}
+	prompt, err := tmpl.Render(req.Messages, true)
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(ProviderName, err)
+	}
+
+	var warnings []llm.WarningEvent
+	if len(req.Tools) > 0 {
+		warnings = append(warnings, ignored("tools"))
+	}
+	if req.OutputFormat == llm.OutputFormatJSON {
+		warnings = append(warnings, ignored("output_format"))
+	}
+
+	return p.stream(ctx, PromptRequest{
+		Model:       req.Model,
+		Prompt:      prompt,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		TopK:        req.TopK,
+		Stop:        append(append([]string(nil), req.Stop...), tmpl.Stop...),
+	}, warnings)
+}
+
+// StreamPrompt streams the completion of a raw prompt.
+func (p *Provider) StreamPrompt(ctx context.Context, req PromptRequest) (llm.Stream, error) {
+	if req.Model == "" {
+		return nil, llm.NewErrBuildRequest(ProviderName, fmt.Errorf("model is required"))
+	}
+	return p.stream(ctx, req, nil)
+}
+
+func ignored(param string) llm.WarningEvent {
+	return llm.WarningEvent{
+		Code:     llm.WarningParameterIgnored,
+		Message:  param + " is not supported in completion mode",
+		Provider: ProviderName,
+		Param:    param,
+	}
+}
+
+type completionRequest struct {
+	Model         string         `json:"model"`
+	Prompt        string         `json:"prompt"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Temperature   float64        `json:"temperature,omitempty"`
+	TopP          float64        `json:"top_p,omitempty"`
+	TopK          int            `json:"top_k,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	Stream        bool           `json:"stream"`
+	StreamOptions map[string]any `json:"stream_options,omitempty"`
+}
+
+type completionChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Text         string  `json:"text"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *completionUsage `json:"usage"`
+}
+
+type completionUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+func (p *Provider) stream(ctx context.Context, req PromptRequest, warnings []llm.WarningEvent) (llm.Stream, error) {
+	info := llm.StreamInfo{Provider: ProviderName, Model: req.Model}
+	// The rendered prompt stands in for the messages when estimating tokens.
+	limited := llm.Request{Model: req.Model, Messages: llm.Messages{llm.User(req.Prompt)}}
+	return p.opts.RateLimiter.Stream(ctx, ProviderName, limited, func(ctx context.Context) (llm.Stream, error) {
+		return llm.InstrumentStream(ctx, p.opts.Telemetry, info, func(ctx context.Context) (llm.Stream, error) {
+			return p.send(ctx, req, warnings)
+		})
+	})
+}
+
+func (p *Provider) send(ctx context.Context, req PromptRequest, warnings []llm.WarningEvent) (llm.Stream, error) {
+	body, err := json.Marshal(completionRequest{
+		Model:         req.Model,
+		Prompt:        req.Prompt,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
+		Stop:          req.Stop,
+		Stream:        true,
+		StreamOptions: map[string]any{"include_usage": true},
+	})
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(ProviderName, err)
+	}
+	endpoint := strings.TrimRight(p.opts.BaseURL, "/") + "/v1/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(ProviderName, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	key, err := p.opts.ResolveAPIKey(ctx)
+	if err != nil {
+		return nil, llm.NewErrMissingAPIKey(ProviderName)
+	}
+	if key != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	timing := llm.Timing{RequestStart: time.Now()}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, llm.NewErrRequestFailed(ProviderName, err)
+	}
+	timing.FirstByte = time.Now()
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, llm.NewErrAPIErrorWithRequest(ProviderName, string(body), resp.StatusCode, string(respBody)).WithRetryAfter(resp.Header)
+	}
+
+	pub, ch := llm.NewEventPublisher()
+	go func() {
+		defer pub.Close()
+		defer resp.Body.Close()
+
+		pub.Started(llm.StreamStartedEvent{Model: req.Model, Provider: ProviderName})
+		for _, w := range warnings {
+			llm.PublishWarning(pub, w)
+		}
+
+		var (
+			model        = req.Model
+			stopReason   = llm.StopReasonUnknown
+			finishReason string
+			decodeErr    error
+		)
+		err := sse.ForEachDataLine(ctx, resp.Body, func(ev sse.Event) bool {
+			if ev.Data == "[DONE]" {
+				return false
+			}
+			var chunk completionChunk
+			if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+				decodeErr = err
+				return false
+			}
+			if chunk.Model != "" {
+				model = chunk.Model
+			}
+			for _, c := range chunk.Choices {
+				if c.Text != "" {
+					timing.MarkFirstDelta()
+					pub.Delta(llm.TextDelta(c.Text))
+				}
+				if c.FinishReason != nil {
+					stopReason = mapFinishReason(*c.FinishReason)
+					finishReason = *c.FinishReason
+				}
+			}
+			if chunk.Usage != nil {
+				pub.UsageRecord(usageRecord(model, chunk.ID, *chunk.Usage))
+			}
+			return true
+		})
+		switch {
+		case decodeErr != nil:
+			pub.Error(llm.NewErrStreamDecode(ProviderName, decodeErr))
+		case ctx.Err() != nil:
+			pub.Error(llm.NewErrContextCancelled(ProviderName, ctx.Err()))
+		case err != nil:
+			pub.Error(llm.NewErrStreamRead(ProviderName, err))
+		default:
+			pub.Completed(llm.CompletedEvent{StopReason: stopReason, FinishReason: finishReason, Timing: timing.Complete()})
+		}
+	}()
+	return ch, nil
+}
+
+func mapFinishReason(reason string) llm.StopReason {
+	switch reason {
+	case "stop", "eos":
+		return llm.StopReasonEndTurn
+	case "length":
+		return llm.StopReasonMaxTokens
+	case "content_filter":
+		return llm.StopReasonContentFilter
+	default:
+		return llm.StopReasonUnknown
+	}
+}
+
+// usageRecord converts completion usage into a record. Prefix-cache hits
+// reported in prompt_tokens_details (vLLM, llama.cpp) are split out of the
+// input count as KindCacheRead.
+func usageRecord(model, requestID string, u completionUsage) usage.Record {
+	cached := u.PromptTokensDetails.CachedTokens
+	tokens := usage.TokenItems{
+		{Kind: usage.KindInput, Count: u.PromptTokens - cached},
+		{Kind: usage.KindCacheRead, Count: cached},
+		{Kind: usage.KindOutput, Count: u.CompletionTokens},
+	}.NonZero()
+	return usage.Record{
+		Dims:       usage.Dims{Provider: ProviderName, Model: model, RequestID: requestID},
+		Tokens:     tokens,
+		RecordedAt: time.Now(),
+	}
+}
@@ -0,0 +1,194 @@
+package completion
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/chattemplate"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+func completionServer(t *testing.T, gotBody *map[string]any, chunks string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/completions", r.URL.Path)
+		defer r.Body.Close()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, gotBody))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, chunks)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+const textChunks = "data: {\"id\":\"cmpl-1\",\"model\":\"qwen2.5\",\"choices\":[{\"text\":\"Hel\",\"finish_reason\":null}]}\n\n" +
+	"data: {\"id\":\"cmpl-1\",\"model\":\"qwen2.5\",\"choices\":[{\"text\":\"lo\",\"finish_reason\":\"stop\"}]}\n\n" +
+	"data: {\"id\":\"cmpl-1\",\"model\":\"qwen2.5\",\"choices\":[],\"usage\":{\"prompt_tokens\":9,\"completion_tokens\":2}}\n\n" +
+	"data: [DONE]\n\n"
+
+func TestCreateStream_RendersTemplateAndStreamsText(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := completionServer(t, &gotBody, textChunks)
+
+	p := New(nil, llm.WithBaseURL(server.URL))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:       "qwen2.5",
+		MaxTokens:   64,
+		Temperature: 0.5,
+		Messages:    llm.Messages{llm.System("sys"), llm.User("hi")},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "Hello", res.Text())
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason())
+	require.Len(t, res.UsageRecords(), 1)
+	rec := res.UsageRecords()[0]
+	assert.Equal(t, 9, rec.Tokens.Count(usage.KindInput))
+	assert.Equal(t, 2, rec.Tokens.Count(usage.KindOutput))
+	assert.Equal(t, "cmpl-1", rec.Dims.RequestID)
+
+	assert.Equal(t, "qwen2.5", gotBody["model"])
+	assert.Equal(t, "<|im_start|>system\nsys<|im_end|>\n<|im_start|>user\nhi<|im_end|>\n<|im_start|>assistant\n", gotBody["prompt"])
+	assert.Equal(t, float64(64), gotBody["max_tokens"])
+	assert.Equal(t, 0.5, gotBody["temperature"])
+	assert.Equal(t, true, gotBody["stream"])
+	assert.Equal(t, []any{"<|im_end|>", "<|endoftext|>"}, gotBody["stop"])
+}
+
+func TestCreateStream_WarnsOnTools(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := completionServer(t, &gotBody, textChunks)
+
+	p := New(chattemplate.Llama3, llm.WithBaseURL(server.URL))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "my-base-model",
+		Tools:    []tool.Definition{{Name: "search", Parameters: map[string]any{"type": "object"}}},
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	require.Len(t, res.Warnings(), 1)
+	assert.Equal(t, llm.WarningParameterIgnored, res.Warnings()[0].Code)
+	assert.Equal(t, "tools", res.Warnings()[0].Param)
+	_, hasTools := gotBody["tools"]
+	assert.False(t, hasTools)
+}
+
+func TestCreateStream_UnknownModelWithoutTemplate(t *testing.T) {
+	t.Parallel()
+
+	p := New(nil)
+	_, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "gpt2",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no chat template")
+}
+
+func TestStreamPrompt_RawPrompt(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := completionServer(t, &gotBody,
+		"data: {\"id\":\"cmpl-2\",\"choices\":[{\"text\":\" upon a time\",\"finish_reason\":\"length\"}]}\n\ndata: [DONE]\n\n")
+
+	p := New(nil, llm.WithBaseURL(server.URL), llm.WithAPIKey("secret"))
+	stream, err := p.StreamPrompt(context.Background(), PromptRequest{
+		Model:     "base",
+		Prompt:    "Once",
+		MaxTokens: 3,
+		Stop:      []string{"\n"},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, " upon a time", res.Text())
+	assert.Equal(t, llm.StopReasonMaxTokens, res.StopReason())
+	assert.Equal(t, "Once", gotBody["prompt"])
+	assert.Equal(t, []any{"\n"}, gotBody["stop"])
+}
+
+func TestStreamPrompt_APIError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	p := New(nil, llm.WithBaseURL(server.URL))
+	_, err := p.StreamPrompt(context.Background(), PromptRequest{Model: "base", Prompt: "x"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrAPIError)
+}
+
+func TestStreamPrompt_RetriesTransientFailure(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"id\":\"cmpl-4\",\"choices\":[{\"text\":\"ok\",\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := New(nil, llm.WithBaseURL(server.URL), llm.WithRetry(llm.RetryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	stream, err := p.StreamPrompt(context.Background(), PromptRequest{Model: "base", Prompt: "x"})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "ok", res.Text())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestStreamPrompt_SplitsCachedPromptTokens(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := completionServer(t, &gotBody,
+		"data: {\"id\":\"cmpl-3\",\"choices\":[{\"text\":\"ok\",\"finish_reason\":\"stop\"}]}\n\n"+
+			"data: {\"id\":\"cmpl-3\",\"choices\":[],\"usage\":{\"prompt_tokens\":100,\"completion_tokens\":1,\"prompt_tokens_details\":{\"cached_tokens\":80}}}\n\n"+
+			"data: [DONE]\n\n")
+
+	p := New(nil, llm.WithBaseURL(server.URL))
+	stream, err := p.StreamPrompt(context.Background(), PromptRequest{Model: "base", Prompt: "x"})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	require.Len(t, res.UsageRecords(), 1)
+	tokens := res.UsageRecords()[0].Tokens
+	assert.Equal(t, 20, tokens.Count(usage.KindInput))
+	assert.Equal(t, 80, tokens.Count(usage.KindCacheRead))
+	assert.Equal(t, 1, tokens.Count(usage.KindOutput))
+	assert.Equal(t, 100, tokens.TotalInput())
+}
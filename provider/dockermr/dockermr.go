@@ -39,6 +39,9 @@ func New(opts ...llm.Option) *Provider {
 	if client == nil {
 		client = llm.DefaultHttpClient()
 	}
+	if llmOpts.Retry != nil {
+		client = llm.RetryHTTPClient(client, *llmOpts.Retry)
+	}
 
 	inner := providercore2.NewProvider(providercore2.NewOptions(
 		providercore2.WithProviderName(llm.ProviderNameDockerMR),
@@ -83,7 +86,7 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, llm.NewErrAPIError(llm.ProviderNameDockerMR, resp.StatusCode, string(body))
+		return nil, llm.NewErrAPIError(llm.ProviderNameDockerMR, resp.StatusCode, string(body)).WithRetryAfter(resp.Header)
 	}
 	var result struct {
 		Data []struct {
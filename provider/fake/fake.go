@@ -2,9 +2,12 @@ package fake
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
 	"github.com/codewandler/llm/tool"
 	"github.com/codewandler/llm/usage"
 )
@@ -27,14 +30,69 @@ var (
 	}
 )
 
+// Provider is an in-memory provider for tests. Without a script it answers
+// the first call with a bash tool call and every later call with "done".
+// Use the With* methods to script other conversations.
 type Provider struct {
-	called bool
+	mu        sync.Mutex
+	calls     int
+	turn      int
+	scripts   [][]llm.Event
+	latency   time.Duration
+	errors    map[int]error
+	responder ToolCallResponder
 }
 
+// ToolCallResponder produces the events of a response to a request whose
+// last message carries tool results.
+type ToolCallResponder func(results msg.ToolResults) []llm.Event
+
 func NewProvider() *Provider {
 	return &Provider{}
 }
 
+// WithScript adds one scripted turn. Each CreateStream call replays the next
+// turn's events verbatim after the started event, so a turn normally ends
+// with a CompletedEvent. Once a script is set, calls beyond the last turn
+// fail.
+func (p *Provider) WithScript(events ...llm.Event) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scripts = append(p.scripts, events)
+	return p
+}
+
+// WithLatency delays every emitted event by d.
+func (p *Provider) WithLatency(d time.Duration) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+	return p
+}
+
+// WithErrorAt makes the n-th CreateStream call (1-based) return err instead
+// of a stream. The failed call does not consume a scripted turn, so a retry
+// gets the turn the failed call would have played.
+func (p *Provider) WithErrorAt(n int, err error) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.errors == nil {
+		p.errors = map[int]error{}
+	}
+	p.errors[n] = err
+	return p
+}
+
+// WithToolCallResponder answers requests whose last message carries tool
+// results with the events returned by fn, instead of the next scripted
+// turn.
+func (p *Provider) WithToolCallResponder(fn ToolCallResponder) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responder = fn
+	return p
+}
+
 func (p *Provider) Name() string       { return ProviderName }
 func (p *Provider) Models() llm.Models { return fakeModelList }
 
@@ -52,7 +110,23 @@ func fakeUsageRecord() usage.Record {
 		RecordedAt: time.Now(),
 	}
 }
-func (p *Provider) CreateStream(_ context.Context, _ llm.Buildable) (llm.Stream, error) {
+func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	var results msg.ToolResults
+	if p.hasResponder() {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(req.Messages); n > 0 {
+			results = req.Messages[n-1].ToolResults()
+		}
+	}
+
+	events, latency, err := p.next(results)
+	if err != nil {
+		return nil, err
+	}
+
 	pub, ch := llm.NewEventPublisher()
 	go func() {
 		defer pub.Close()
@@ -63,16 +137,60 @@ func (p *Provider) CreateStream(_ context.Context, _ llm.Buildable) (llm.Stream,
 			Provider:  "fake",
 		})
 
-		if !p.called {
-			p.called = true
-			pub.ToolCall(tool.NewToolCall("bash-1", "bash", map[string]any{"command": "echo hello"}))
-			pub.UsageRecord(fakeUsageRecord())
-			pub.Completed(llm.CompletedEvent{StopReason: llm.StopReasonToolUse})
-		} else {
-			pub.Delta(llm.TextDelta("done"))
-			pub.UsageRecord(fakeUsageRecord())
-			pub.Completed(llm.CompletedEvent{StopReason: llm.StopReasonEndTurn})
+		for _, ev := range events {
+			if latency > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(latency):
+				}
+			}
+			if ctx.Err() != nil {
+				pub.Error(llm.NewErrContextCancelled(ProviderName, ctx.Err()))
+				return
+			}
+			pub.Publish(ev)
 		}
 	}()
 	return ch, nil
 }
+
+func (p *Provider) hasResponder() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.responder != nil
+}
+
+// next picks the events for the current call.
+func (p *Provider) next(results msg.ToolResults) ([]llm.Event, time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if err, ok := p.errors[p.calls]; ok {
+		return nil, 0, err
+	}
+	if len(results) > 0 && p.responder != nil {
+		return p.responder(results), p.latency, nil
+	}
+
+	turn := p.turn
+	p.turn++
+	if len(p.scripts) > 0 {
+		if turn >= len(p.scripts) {
+			return nil, 0, llm.NewErrProviderMsg(ProviderName, fmt.Sprintf("script exhausted after %d turns", len(p.scripts)))
+		}
+		return p.scripts[turn], p.latency, nil
+	}
+	if turn == 0 {
+		return []llm.Event{
+			&llm.ToolCallEvent{ToolCall: tool.NewToolCall("bash-1", "bash", map[string]any{"command": "echo hello"})},
+			&llm.UsageUpdatedEvent{Record: fakeUsageRecord()},
+			&llm.CompletedEvent{StopReason: llm.StopReasonToolUse},
+		}, p.latency, nil
+	}
+	return []llm.Event{
+		llm.TextDelta("done"),
+		&llm.UsageUpdatedEvent{Record: fakeUsageRecord()},
+		&llm.CompletedEvent{StopReason: llm.StopReasonEndTurn},
+	}, p.latency, nil
+}
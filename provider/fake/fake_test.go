@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
 	"github.com/codewandler/llm/tool"
 )
 
@@ -307,3 +308,78 @@ func TestCreateStream_AcceptsBuilder(t *testing.T) {
 	_, err := p.CreateStream(context.Background(), b)
 	require.NoError(t, err)
 }
+
+func TestProvider_WithScript(t *testing.T) {
+	p := NewProvider().
+		WithScript(llm.TextDelta("hello"), &llm.CompletedEvent{StopReason: llm.StopReasonEndTurn}).
+		WithScript(&llm.CompletedEvent{StopReason: llm.StopReasonMaxTokens})
+
+	c, err := llm.Complete(t.Context(), p, llm.Request{Model: Model1ID})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", c.Text)
+	assert.Equal(t, llm.StopReasonEndTurn, c.StopReason)
+
+	c, err = llm.Complete(t.Context(), p, llm.Request{Model: Model1ID})
+	require.NoError(t, err)
+	assert.Equal(t, llm.StopReasonMaxTokens, c.StopReason)
+
+	_, err = p.CreateStream(t.Context(), llm.Request{Model: Model1ID})
+	assert.ErrorIs(t, err, llm.ErrProviderError)
+}
+
+func TestProvider_WithErrorAt(t *testing.T) {
+	boom := llm.NewErrAPIError(ProviderName, 503, "unavailable")
+	p := NewProvider().
+		WithScript(llm.TextDelta("ok"), &llm.CompletedEvent{StopReason: llm.StopReasonEndTurn}).
+		WithErrorAt(1, boom)
+
+	_, err := p.CreateStream(t.Context(), llm.Request{Model: Model1ID})
+	assert.ErrorIs(t, err, boom)
+
+	c, err := llm.Complete(t.Context(), p, llm.Request{Model: Model1ID})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", c.Text, "the failed call must not consume a scripted turn")
+}
+
+func TestProvider_WithLatency(t *testing.T) {
+	p := NewProvider().
+		WithScript(llm.TextDelta("a"), llm.TextDelta("b"), &llm.CompletedEvent{StopReason: llm.StopReasonEndTurn}).
+		WithLatency(10 * time.Millisecond)
+
+	start := time.Now()
+	c, err := llm.Complete(t.Context(), p, llm.Request{Model: Model1ID})
+	require.NoError(t, err)
+	assert.Equal(t, "ab", c.Text)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestProvider_WithToolCallResponder(t *testing.T) {
+	var got msg.ToolResults
+	p := NewProvider().WithToolCallResponder(func(results msg.ToolResults) []llm.Event {
+		got = results
+		return []llm.Event{
+			llm.TextDelta("it is " + results[0].ToolOutput),
+			&llm.CompletedEvent{StopReason: llm.StopReasonEndTurn},
+		}
+	})
+
+	first, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:    Model1ID,
+		Messages: llm.Messages{llm.User("weather?")},
+	})
+	require.NoError(t, err)
+	require.Len(t, first.ToolCalls, 1)
+
+	c, err := llm.Complete(t.Context(), p, llm.Request{
+		Model: Model1ID,
+		Messages: llm.Messages{
+			llm.User("weather?"),
+			first.Message,
+			msg.Tool().Results(msg.ToolResult{ToolCallID: first.ToolCalls[0].ToolCallID(), ToolOutput: "sunny"}).Build(),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "it is sunny", c.Text)
+	require.Len(t, got, 1)
+	assert.Equal(t, "bash-1", got[0].ToolCallID)
+}
@@ -0,0 +1,64 @@
+// Package groq provides an llm.Provider for Groq's OpenAI-compatible API.
+package groq
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/codewandler/llm"
+	providercore2 "github.com/codewandler/llm/internal/providercore"
+)
+
+const (
+	defaultBaseURL = "https://api.groq.com/openai"
+	providerName   = llm.ProviderNameGroq
+
+	DefaultModel = ModelLlama33_70B
+)
+
+type Provider struct {
+	inner *providercore2.Provider
+	opts  *llm.Options
+}
+
+func DefaultOptions() []llm.Option {
+	return []llm.Option{
+		llm.WithBaseURL(defaultBaseURL),
+		llm.APIKeyFromEnv("GROQ_API_KEY"),
+	}
+}
+
+func New(opts ...llm.Option) *Provider {
+	allOpts := append(DefaultOptions(), opts...)
+	cfg := llm.Apply(allOpts...)
+
+	inner := providercore2.NewProvider(providercore2.NewOptions(
+		providercore2.WithProviderName(providerName),
+		providercore2.WithBaseURL(defaultBaseURL),
+		providercore2.WithAPIHint(llm.ApiTypeOpenAIChatCompletion),
+		providercore2.WithModels(allModels),
+		providercore2.WithCostCalculator(costCalculator),
+		providercore2.WithHeaderFunc(func(ctx context.Context, _ *llm.Request) (http.Header, error) {
+			key, err := cfg.ResolveAPIKey(ctx)
+			if err != nil || key == "" {
+				return nil, llm.NewErrMissingAPIKey(providerName)
+			}
+			return http.Header{"Authorization": {"Bearer " + key}}, nil
+		}),
+		providercore2.WithPreprocessRequest(func(req llm.Request) (llm.Request, string, error) {
+			original := req.Model
+			if resolved, err := allModels.Resolve(req.Model); err == nil {
+				req.Model = resolved.ID
+			}
+			return req, original, nil
+		}),
+	), allOpts...)
+
+	return &Provider{inner: inner, opts: cfg}
+}
+
+func (p *Provider) Name() string       { return p.inner.Name() }
+func (p *Provider) Models() llm.Models { return p.inner.Models() }
+func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	return p.inner.CreateStream(ctx, src)
+}
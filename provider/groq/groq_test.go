@@ -0,0 +1,107 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+func TestProvider_ResolveAliases(t *testing.T) {
+	p := New()
+	assert.Equal(t, llm.ProviderNameGroq, p.Name())
+
+	m, err := p.Models().Resolve(llm.ModelDefault)
+	require.NoError(t, err)
+	assert.Equal(t, ModelLlama33_70B, m.ID)
+
+	m, err = p.Models().Resolve("kimi")
+	require.NoError(t, err)
+	assert.Equal(t, ModelKimiK2, m.ID)
+
+	for _, m := range p.Models() {
+		require.NotNil(t, m.Pricing, m.ID)
+		assert.Positive(t, m.Pricing.Input, m.ID)
+	}
+}
+
+func TestProvider_CreateStream_ChatCompletionsWithTools(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gotPath   string
+		gotHeader http.Header
+		gotBody   map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Clone()
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w,
+			`data: {"id":"chatcmpl-1","model":"llama-3.3-70b-versatile","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Paris\"}"}}]}}]}`+"\n\n"+
+				`data: {"id":"chatcmpl-1","model":"llama-3.3-70b-versatile","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`+"\n\n"+
+				`data: {"id":"chatcmpl-1","model":"llama-3.3-70b-versatile","choices":[],"usage":{"prompt_tokens":1000000,"completion_tokens":1000000}}`+"\n\n"+
+				"data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("gsk-test"))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "llama",
+		Messages: llm.Messages{llm.User("Weather in Paris?")},
+		Tools:    []tool.Definition{{Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "/v1/chat/completions", gotPath)
+	assert.Equal(t, "Bearer gsk-test", gotHeader.Get("Authorization"))
+	assert.Equal(t, ModelLlama33_70B, gotBody["model"])
+	assert.Len(t, gotBody["tools"], 1)
+
+	assert.Equal(t, llm.StopReasonToolUse, res.StopReason())
+	require.Len(t, res.ToolCalls(), 1)
+	assert.Equal(t, "get_weather", res.ToolCalls()[0].ToolName())
+	assert.Equal(t, "Paris", res.ToolCalls()[0].ToolArgs()["location"])
+
+	require.Len(t, res.UsageRecords(), 1)
+	rec := res.UsageRecords()[0]
+	assert.Equal(t, llm.ProviderNameGroq, rec.Dims.Provider)
+	assert.Equal(t, 1000000, rec.Tokens.Count(usage.KindInput))
+	assert.InDelta(t, 0.59+0.79, rec.Cost.Total, 1e-9)
+}
+
+func TestProvider_CreateStream_MissingAPIKey(t *testing.T) {
+	t.Setenv("GROQ_API_KEY", "")
+
+	p := New(llm.WithBaseURL("http://127.0.0.1:0"))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    ModelLlama31_8B,
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	if err == nil {
+		err = llm.NewEventProcessor(context.Background(), stream).Result().Error()
+	}
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrMissingAPIKey)
+}
+
+func TestCostCalculator_TableRates(t *testing.T) {
+	assert.Empty(t, pricing.Shadowed(llm.ProviderNameGroq, nil), "drop table entries the catalog prices")
+	cost, ok := costCalculator.Calculate(llm.ProviderNameGroq, ModelLlama33_70B, usage.TokenItems{{Kind: usage.KindOutput, Count: 1_000_000}})
+	require.True(t, ok)
+	assert.InDelta(t, 0.79, cost.Output, 1e-9)
+}
@@ -0,0 +1,86 @@
+package groq
+
+import (
+	"sort"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+// Model ID constants for programmatic use.
+const (
+	// Llama models.
+	ModelLlama33_70B    = "llama-3.3-70b-versatile"
+	ModelLlama31_8B     = "llama-3.1-8b-instant"
+	ModelLlama4Scout    = "meta-llama/llama-4-scout-17b-16e-instruct"
+	ModelLlama4Maverick = "meta-llama/llama-4-maverick-17b-128e-instruct"
+
+	// Open-weight models from other labs.
+	ModelGPTOSS120B = "openai/gpt-oss-120b"
+	ModelGPTOSS20B  = "openai/gpt-oss-20b"
+	ModelQwen3_32B  = "qwen/qwen3-32b"
+	ModelKimiK2     = "moonshotai/kimi-k2-instruct"
+)
+
+// ModelAliases maps short alias names to full model IDs.
+var ModelAliases = map[string]string{
+	"llama":    ModelLlama33_70B,
+	"llama-70": ModelLlama33_70B,
+	"llama-8":  ModelLlama31_8B,
+	"scout":    ModelLlama4Scout,
+	"maverick": ModelLlama4Maverick,
+	"gpt-oss":  ModelGPTOSS120B,
+	"qwen":     ModelQwen3_32B,
+	"kimi":     ModelKimiK2,
+}
+
+// pricing lists Groq's on-demand prices in USD per million tokens. Groq's
+// models are not in the model catalog, so usage records are priced from this
+// table.
+var pricing = usage.PricingTable{
+	ModelLlama33_70B:    {Input: 0.59, Output: 0.79},
+	ModelLlama31_8B:     {Input: 0.05, Output: 0.08},
+	ModelLlama4Scout:    {Input: 0.11, Output: 0.34},
+	ModelLlama4Maverick: {Input: 0.20, Output: 0.60},
+	ModelGPTOSS120B:     {Input: 0.15, Output: 0.75},
+	ModelGPTOSS20B:      {Input: 0.10, Output: 0.50},
+	ModelQwen3_32B:      {Input: 0.29, Output: 0.59},
+	ModelKimiK2:         {Input: 1.00, Output: 3.00},
+}
+
+var modelNames = []struct{ id, name string }{
+	{ModelLlama33_70B, "Llama 3.3 70B Versatile"},
+	{ModelLlama31_8B, "Llama 3.1 8B Instant"},
+	{ModelLlama4Scout, "Llama 4 Scout"},
+	{ModelLlama4Maverick, "Llama 4 Maverick"},
+	{ModelGPTOSS120B, "GPT-OSS 120B"},
+	{ModelGPTOSS20B, "GPT-OSS 20B"},
+	{ModelQwen3_32B, "Qwen3 32B"},
+	{ModelKimiK2, "Kimi K2 Instruct"},
+}
+
+// allModels is the static list returned by Provider.Models().
+var allModels = func() llm.Models {
+	models := make(llm.Models, 0, len(modelNames))
+	for _, m := range modelNames {
+		p := pricing[m.id]
+		model := llm.Model{ID: m.id, Name: m.name, Provider: llm.ProviderNameGroq, Pricing: &p}
+		for alias, target := range ModelAliases {
+			if target == m.id {
+				model.Aliases = append(model.Aliases, alias)
+			}
+		}
+		sort.Strings(model.Aliases)
+		switch m.id {
+		case DefaultModel:
+			model.Aliases = append(model.Aliases, llm.ModelDefault)
+		case ModelLlama31_8B:
+			model.Aliases = append(model.Aliases, llm.ModelFast)
+		}
+		models = append(models, model)
+	}
+	return models
+}()
+
+// costCalculator prices usage records from the pricing table.
+var costCalculator = pricing.Calculator(llm.ProviderNameGroq)
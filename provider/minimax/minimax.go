@@ -76,6 +76,9 @@ func New(opts ...Option) *Provider {
 	if p.opts.Logger != nil {
 		allLLMOpts = append(allLLMOpts, llm.WithLogger(p.opts.Logger))
 	}
+	if p.opts.Retry != nil {
+		allLLMOpts = append(allLLMOpts, llm.WithRetry(*p.opts.Retry))
+	}
 
 	p.inner = providercore2.NewProvider(providercore2.NewOptions(
 		providercore2.WithProviderName(providerName),
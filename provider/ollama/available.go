@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/codewandler/llm"
 )
 
 // EnvOllamaHost is the environment variable that overrides the Ollama base URL.
@@ -37,7 +39,7 @@ func probeOllama(url string, timeout time.Duration) bool {
 	if err != nil {
 		return false
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := llm.DefaultHttpClient().Do(req)
 	if err != nil {
 		return false
 	}
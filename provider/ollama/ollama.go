@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/codewandler/llm"
 	modelcatalogview "github.com/codewandler/llm/internal/modelview"
@@ -44,6 +45,9 @@ type Provider struct {
 
 	modelOnce     sync.Once
 	fetchedModels llm.Models
+
+	modelOptions ModelOptions
+	keepAlive    any
 }
 
 func DefaultOptions() []llm.Option {
@@ -57,8 +61,12 @@ func New(opts ...llm.Option) *Provider {
 	if client == nil {
 		client = llm.DefaultHttpClient()
 	}
+	if llmOpts.Retry != nil {
+		client = llm.RetryHTTPClient(client, *llmOpts.Retry)
+	}
 
-	inner := providercore2.NewProvider(providercore2.NewOptions(
+	p := &Provider{client: client}
+	p.inner = providercore2.NewProvider(providercore2.NewOptions(
 		providercore2.WithProviderName(llm.ProviderNameOllama),
 		providercore2.WithBaseURL(defaultBaseURL),
 		providercore2.WithAPIHint(llm.ApiTypeOpenAIResponses),
@@ -69,15 +77,22 @@ func New(opts ...llm.Option) *Provider {
 			}
 			return curatedModelList, nil
 		}),
+		providercore2.WithPreprocessRequest(moveOutputSchema),
+		providercore2.WithMutateRequest(p.rewriteBody),
 	), allOpts...)
 
-	return &Provider{inner: inner, client: client}
+	return p
 }
 
 func (p *Provider) Name() string       { return p.inner.Name() }
 func (p *Provider) Models() llm.Models { return p.inner.Models() }
 func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
-	return p.inner.CreateStream(ctx, src)
+	start := time.Now()
+	stream, err := p.inner.CreateStream(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return withTimings(ctx, stream, start), nil
 }
 
 func (p *Provider) Resolve(modelID string) (llm.Model, error) {
@@ -100,7 +115,7 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, llm.NewErrAPIError(llm.ProviderNameOllama, resp.StatusCode, string(body))
+		return nil, llm.NewErrAPIError(llm.ProviderNameOllama, resp.StatusCode, string(body)).WithRetryAfter(resp.Header)
 	}
 	var result struct {
 		Models []struct {
@@ -156,7 +171,7 @@ func (p *Provider) downloadModel(ctx context.Context, modelID string) error {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		errBody, _ := io.ReadAll(resp.Body)
-		return llm.NewErrAPIError(llm.ProviderNameOllama, resp.StatusCode, string(errBody))
+		return llm.NewErrAPIError(llm.ProviderNameOllama, resp.StatusCode, string(errBody)).WithRetryAfter(resp.Header)
 	}
 	scanner := bufio.NewScanner(resp.Body)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/codewandler/llm"
 	"github.com/stretchr/testify/assert"
@@ -58,6 +59,8 @@ func TestCreateStream_TextResponse(t *testing.T) {
 			ue := ev.Data.(*llm.UsageUpdatedEvent)
 			assert.Equal(t, 12, ue.Record.Tokens.Count(usage.KindInput))
 			assert.Equal(t, 3, ue.Record.Tokens.Count(usage.KindOutput))
+			assert.Contains(t, ue.Record.Details, "client_total_ns")
+			assert.Contains(t, ue.Record.Details, "client_eval_ns")
 		case llm.StreamEventCompleted:
 			completed = ev.Data.(*llm.CompletedEvent)
 		}
@@ -139,3 +142,23 @@ func TestResolve_PassthroughUnknownModel(t *testing.T) {
 	assert.Equal(t, "my-local-model", resolved.ID)
 	assert.Equal(t, llm.ProviderNameOllama, resolved.Provider)
 }
+
+func TestAddTimings(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(100, 0)
+	rec := usage.Record{
+		Tokens:  usage.TokenItems{{Kind: usage.KindOutput, Count: 21}},
+		Details: map[string]any{"x": 1},
+	}
+	got := addTimings(rec, start, start.Add(500*time.Millisecond), start.Add(2500*time.Millisecond), start.Add(2600*time.Millisecond))
+
+	assert.Equal(t, map[string]any{
+		"x":                        1,
+		"client_total_ns":          int64(2600 * time.Millisecond),
+		"client_ttft_ns":           int64(500 * time.Millisecond),
+		"client_eval_ns":           int64(2 * time.Second),
+		"client_tokens_per_second": 10.0,
+	}, got)
+	assert.Equal(t, map[string]any{"x": 1}, rec.Details, "input details must not be modified")
+}
@@ -0,0 +1,147 @@
+package ollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/codewandler/llm"
+)
+
+// Metadata keys carrying per-request settings to the request body. They are
+// removed from the metadata before the request is sent.
+const (
+	internalModelOptionsKey = "__ollama_options"
+	internalKeepAliveKey    = "__ollama_keep_alive"
+	internalOutputSchemaKey = "__ollama_output_schema"
+)
+
+// ModelOptions are Ollama model parameters sent as the "options" object,
+// e.g. {"num_ctx": 32768, "seed": 42, "num_gpu": 99}. See the Ollama
+// Modelfile documentation for the full list.
+type ModelOptions map[string]any
+
+// WithModelOptions sets model options sent with every request. Options set
+// per request with WithRequestModelOptions take precedence key by key.
+func (p *Provider) WithModelOptions(opts ModelOptions) *Provider {
+	p.modelOptions = maps.Clone(opts)
+	return p
+}
+
+// WithKeepAlive sets how long Ollama keeps the model loaded after each
+// request. Zero unloads it immediately, a negative duration keeps it
+// loaded indefinitely.
+func (p *Provider) WithKeepAlive(d time.Duration) *Provider {
+	p.keepAlive = keepAliveValue(d)
+	return p
+}
+
+// WithRequestModelOptions sets model options for a single request.
+func WithRequestModelOptions(opts ModelOptions) llm.RequestOption {
+	return withMetadata(internalModelOptionsKey, map[string]any(maps.Clone(opts)))
+}
+
+// WithRequestKeepAlive sets the keep-alive of a single request. See
+// Provider.WithKeepAlive.
+func WithRequestKeepAlive(d time.Duration) llm.RequestOption {
+	return withMetadata(internalKeepAliveKey, keepAliveValue(d))
+}
+
+func withMetadata(key string, value any) llm.RequestOption {
+	return func(r *llm.Request) {
+		if r.RequestMeta == nil {
+			r.RequestMeta = &llm.RequestMeta{}
+		} else {
+			r.RequestMeta = r.RequestMeta.Clone()
+		}
+		if r.RequestMeta.Metadata == nil {
+			r.RequestMeta.Metadata = map[string]any{}
+		}
+		r.RequestMeta.Metadata[key] = value
+	}
+}
+
+// keepAliveValue encodes d the way Ollama accepts it: a duration string, or
+// -1 to keep the model loaded.
+func keepAliveValue(d time.Duration) any {
+	if d < 0 {
+		return -1
+	}
+	return d.String()
+}
+
+// moveOutputSchema hands Request.OutputSchema to rewriteBody through the
+// metadata, since the Responses wire request cannot carry a schema.
+func moveOutputSchema(req llm.Request) (llm.Request, string, error) {
+	if req.OutputSchema == nil {
+		return req, req.Model, nil
+	}
+	withMetadata(internalOutputSchemaKey, req.OutputSchema)(&req)
+	req.OutputSchema = nil
+	req.OutputFormat = llm.OutputFormatJSON
+	return req, req.Model, nil
+}
+
+// rewriteBody moves the model options, keep-alive and output schema into
+// the JSON body of r, merging per-request options over the provider
+// defaults.
+func (p *Provider) rewriteBody(r *http.Request) {
+	if r.Body == nil || r.Header.Get("Content-Type") != "application/json" {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+
+	options := map[string]any(maps.Clone(p.modelOptions))
+	keepAlive := p.keepAlive
+	if meta, ok := payload["metadata"].(map[string]any); ok {
+		if o, ok := meta[internalModelOptionsKey].(map[string]any); ok {
+			if options == nil {
+				options = map[string]any{}
+			}
+			maps.Copy(options, o)
+		}
+		if k, ok := meta[internalKeepAliveKey]; ok {
+			keepAlive = k
+		}
+		if schema, ok := meta[internalOutputSchemaKey]; ok {
+			delete(payload, "response_format")
+			payload["text"] = map[string]any{"format": map[string]any{
+				"type":   "json_schema",
+				"name":   "response",
+				"schema": schema,
+			}}
+		}
+		delete(meta, internalModelOptionsKey)
+		delete(meta, internalKeepAliveKey)
+		delete(meta, internalOutputSchemaKey)
+		if len(meta) == 0 {
+			delete(payload, "metadata")
+		}
+	}
+	if len(options) > 0 {
+		payload["options"] = options
+	}
+	if keepAlive != nil {
+		payload["keep_alive"] = keepAlive
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+}
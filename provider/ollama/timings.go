@@ -0,0 +1,73 @@
+package ollama
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+// withTimings adds generation timings to the usage records of stream. The
+// OpenAI-compatible endpoint reports token counts but not Ollama's native
+// durations, so they are measured from the event timestamps and stored in
+// Record.Details under client_ keys (in nanoseconds), distinct from the
+// server-side counters of Ollama's native API:
+//
+//	"client_ttft_ns"            request start to first delta
+//	"client_eval_ns"            first to last delta
+//	"client_total_ns"           request start to usage report
+//	"client_tokens_per_second"  output tokens per second of client_eval_ns
+//
+// When ctx is cancelled the rest of stream is drained.
+func withTimings(ctx context.Context, stream llm.Stream, start time.Time) llm.Stream {
+	out := make(chan llm.Envelope, 64)
+	go func() {
+		defer close(out)
+		var firstDelta, lastDelta time.Time
+		for env := range stream {
+			switch ev := env.Data.(type) {
+			case *llm.DeltaEvent:
+				if firstDelta.IsZero() {
+					firstDelta = env.Meta.CreatedAt
+				}
+				lastDelta = env.Meta.CreatedAt
+			case *llm.UsageUpdatedEvent:
+				if !ev.Record.IsEstimate {
+					ev.Record.Details = addTimings(ev.Record, start, firstDelta, lastDelta, env.Meta.CreatedAt)
+				}
+			}
+			select {
+			case out <- env:
+			case <-ctx.Done():
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func addTimings(rec usage.Record, start, firstDelta, lastDelta, end time.Time) map[string]any {
+	details := maps.Clone(rec.Details)
+	if details == nil {
+		details = map[string]any{}
+	}
+	if !end.IsZero() {
+		details["client_total_ns"] = end.Sub(start).Nanoseconds()
+	}
+	if firstDelta.IsZero() {
+		return details
+	}
+	details["client_ttft_ns"] = firstDelta.Sub(start).Nanoseconds()
+	eval := lastDelta.Sub(firstDelta)
+	details["client_eval_ns"] = eval.Nanoseconds()
+	if n := rec.Tokens.Count(usage.KindOutput); n > 1 && eval > 0 {
+		// The first token arrives with the first delta, so n-1 tokens span
+		// the eval window.
+		details["client_tokens_per_second"] = float64(n-1) / eval.Seconds()
+	}
+	return details
+}
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
 	"github.com/codewandler/llm/tool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -100,3 +102,172 @@ func TestCreateStream_ErrorsOnMultipleSystemMessages(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "multiple system messages")
 }
+
+// captureResponsesBody starts a server that records the decoded request body
+// and replies with a minimal completed response.
+func captureResponsesBody(t *testing.T) (*httptest.Server, *map[string]any) {
+	t.Helper()
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(bodyBytes, &got))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w,
+			"event: response.created\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"llama3.2\"}}\n\n"+
+				"event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"llama3.2\",\"status\":\"completed\",\"usage\":{\"input_tokens\":1,\"output_tokens\":1}}}\n\n",
+		)
+	}))
+	t.Cleanup(server.Close)
+	return server, &got
+}
+
+func TestCreateStream_ToolCallResultHistory(t *testing.T) {
+	t.Parallel()
+
+	server, gotBody := captureResponsesBody(t)
+	p := New(llm.WithBaseURL(server.URL))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model: "llama3.2",
+		Tools: []tool.Definition{{
+			Name:       "get_weather",
+			Parameters: map[string]any{"type": "object"},
+		}},
+		Messages: llm.Messages{
+			llm.User("weather in Paris?"),
+			msg.Assistant(msg.ToolCall(msg.NewToolCall("call_1", "get_weather", msg.ToolArgs{"location": "Paris"}))).Build(),
+			msg.Tool().Results(msg.ToolResult{ToolCallID: "call_1", ToolOutput: `{"temp":22}`}).Build(),
+		},
+	})
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	input, ok := (*gotBody)["input"].([]any)
+	require.True(t, ok)
+	require.Len(t, input, 3)
+
+	call := input[1].(map[string]any)
+	assert.Equal(t, "function_call", call["type"])
+	assert.Equal(t, "call_1", call["call_id"])
+	assert.Equal(t, "get_weather", call["name"])
+	assert.JSONEq(t, `{"location":"Paris"}`, call["arguments"].(string))
+
+	result := input[2].(map[string]any)
+	assert.Equal(t, "function_call_output", result["type"])
+	assert.Equal(t, "call_1", result["call_id"])
+	assert.Equal(t, `{"temp":22}`, result["output"])
+}
+
+func TestCreateStream_ToolChoiceMapping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		choice llm.ToolChoice
+		want   any
+	}{
+		{name: "auto", choice: llm.ToolChoiceAuto{}, want: "auto"},
+		{name: "required", choice: llm.ToolChoiceRequired{}, want: "required"},
+		{name: "none", choice: llm.ToolChoiceNone{}, want: "none"},
+		{name: "named tool", choice: llm.ToolChoiceTool{Name: "search"}, want: map[string]any{"type": "function", "name": "search"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, gotBody := captureResponsesBody(t)
+			p := New(llm.WithBaseURL(server.URL))
+			stream, err := p.CreateStream(context.Background(), llm.Request{
+				Model: "llama3.2",
+				Tools: []tool.Definition{{
+					Name:       "search",
+					Parameters: map[string]any{"type": "object"},
+				}},
+				ToolChoice: tt.choice,
+				Messages:   llm.Messages{llm.User("hello")},
+			})
+			require.NoError(t, err)
+			for range stream {
+			}
+
+			assert.Equal(t, tt.want, (*gotBody)["tool_choice"])
+		})
+	}
+}
+
+func TestCreateStream_ModelOptionsAndKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"llama3.2\",\"status\":\"completed\"}}\n\n")
+	}))
+	defer server.Close()
+
+	p := New(llm.WithBaseURL(server.URL)).
+		WithModelOptions(ModelOptions{"num_ctx": 8192, "seed": 7}).
+		WithKeepAlive(-1)
+	req := llm.Request{
+		Model:       "llama3.2",
+		Messages:    llm.Messages{llm.User("hello")},
+		RequestMeta: &llm.RequestMeta{Metadata: map[string]any{"trace_id": "t1"}},
+	}
+	WithRequestModelOptions(ModelOptions{"num_ctx": 32768})(&req)
+	WithRequestKeepAlive(10 * time.Minute)(&req)
+
+	stream, err := p.CreateStream(context.Background(), req)
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	assert.Equal(t, map[string]any{"num_ctx": float64(32768), "seed": float64(7)}, gotBody["options"])
+	assert.Equal(t, "10m0s", gotBody["keep_alive"])
+	assert.Equal(t, map[string]any{"trace_id": "t1"}, gotBody["metadata"])
+}
+
+func TestCreateStream_OutputSchema(t *testing.T) {
+	t.Parallel()
+
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"llama3.2\",\"status\":\"completed\"}}\n\n")
+	}))
+	defer server.Close()
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		"required":   []any{"city"},
+	}
+	p := New(llm.WithBaseURL(server.URL))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:        "llama3.2",
+		Messages:     llm.Messages{llm.User("Where is the Eiffel tower?")},
+		OutputSchema: schema,
+	})
+	require.NoError(t, err)
+	var warnings []*llm.WarningEvent
+	for env := range stream {
+		if w, ok := env.Data.(*llm.WarningEvent); ok {
+			warnings = append(warnings, w)
+		}
+	}
+
+	assert.Empty(t, warnings)
+	assert.NotContains(t, gotBody, "response_format")
+	assert.NotContains(t, gotBody, "metadata")
+	assert.Equal(t, map[string]any{"format": map[string]any{
+		"type":   "json_schema",
+		"name":   "response",
+		"schema": schema,
+	}}, gotBody["text"])
+}
@@ -0,0 +1,72 @@
+package openai
+
+import "github.com/codewandler/llm"
+
+// internalBuiltinToolsKey carries built-in tools from WithBuiltinTools to the
+// request body. It is removed from the metadata before the request is sent.
+const internalBuiltinToolsKey = "__openai_builtin_tools"
+
+// BuiltinTool is a tool OpenAI runs itself on the Responses API, sent as-is
+// next to the function tools of the request. Its progress and result arrive
+// as llm.ToolProgressEvent and llm.ToolResultEvent, and the citations it
+// produces as llm.AnnotationEvent.
+type BuiltinTool map[string]any
+
+// WebSearch returns the web_search tool.
+func WebSearch() BuiltinTool {
+	return BuiltinTool{"type": "web_search"}
+}
+
+// FileSearch returns the file_search tool over the given vector stores.
+func FileSearch(vectorStoreIDs ...string) BuiltinTool {
+	return BuiltinTool{"type": "file_search", "vector_store_ids": vectorStoreIDs}
+}
+
+// CodeInterpreter returns the code_interpreter tool in an automatically
+// created container.
+func CodeInterpreter() BuiltinTool {
+	return BuiltinTool{"type": "code_interpreter", "container": map[string]any{"type": "auto"}}
+}
+
+// WithBuiltinTools enables OpenAI built-in tools for a request. Further
+// settings are plain map entries, e.g.
+// WebSearch() with "search_context_size": "high". Requests with built-in
+// tools are sent to the Responses API.
+func WithBuiltinTools(tools ...BuiltinTool) llm.RequestOption {
+	return func(r *llm.Request) {
+		if r.RequestMeta == nil {
+			r.RequestMeta = &llm.RequestMeta{}
+		} else {
+			r.RequestMeta = r.RequestMeta.Clone()
+		}
+		if r.RequestMeta.Metadata == nil {
+			r.RequestMeta.Metadata = map[string]any{}
+		}
+		r.RequestMeta.Metadata[internalBuiltinToolsKey] = tools
+	}
+}
+
+// hasBuiltinTools reports whether req carries tools from WithBuiltinTools.
+func hasBuiltinTools(req llm.Request) bool {
+	if req.RequestMeta == nil {
+		return false
+	}
+	tools, _ := req.RequestMeta.Metadata[internalBuiltinToolsKey].([]BuiltinTool)
+	return len(tools) > 0
+}
+
+// injectBuiltinTools moves the built-in tools from the metadata of a request
+// payload into its tools list.
+func injectBuiltinTools(payload, meta map[string]any) {
+	raw, ok := meta[internalBuiltinToolsKey]
+	if !ok {
+		return
+	}
+	delete(meta, internalBuiltinToolsKey)
+	builtin, _ := raw.([]any)
+	if len(builtin) == 0 {
+		return
+	}
+	tools, _ := payload["tools"].([]any)
+	payload["tools"] = append(tools, builtin...)
+}
@@ -91,7 +91,7 @@ const (
 )
 
 // modelInfo contains metadata and routing properties for a model.
-// Pricing is managed centrally in usage/pricing.go (KnownPricing).
+// Pricing comes from the model catalog (usage.Default).
 type modelInfo struct {
 	ID                    string        // API model ID
 	Name                  string        // Human-readable name
@@ -319,8 +319,10 @@ func mergeOpenAIAliases(a, b []string) []string {
 
 // useResponsesAPI reports whether the given model ID should be routed to the
 // Responses API (/v1/responses) instead of Chat Completions (/v1/chat/completions).
-// Two model classes require /v1/responses:
+// Three model classes require /v1/responses:
 //   - Codex models (categoryCodex) — always routed via Responses API.
+//   - Pro models (categoryPro, e.g. gpt-5-pro, o1-pro) — only served by the
+//     Responses API.
 //   - Models with UseResponsesAPI: true — newer non-Codex models (e.g. gpt-5.4 series)
 //     that are only available on the Responses API endpoint.
 //
@@ -330,7 +332,7 @@ func useResponsesAPI(model string) bool {
 	if !ok {
 		return false
 	}
-	return info.Category == categoryCodex || info.UseResponsesAPI
+	return info.Category == categoryCodex || info.Category == categoryPro || info.UseResponsesAPI
 }
 
 // UseResponsesAPI reports whether the given model requires the OpenAI
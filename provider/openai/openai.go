@@ -19,6 +19,10 @@ const (
 
 	DefaultModel               = "gpt-4o-mini"
 	internalReasoningEffortKey = "__openai_reasoning_effort"
+
+	// minResponsesOutputTokens is the smallest max_output_tokens the
+	// Responses API accepts; smaller limits are raised to it.
+	minResponsesOutputTokens = 16
 )
 
 type Provider struct {
@@ -75,10 +79,10 @@ func New(opts ...llm.Option) *Provider {
 			return req, original, nil
 		}),
 		providercore2.WithAPIHintResolver(func(req llm.Request) llm.ApiType {
-			if useResponsesAPI(req.Model) {
+			if hasBuiltinTools(req) {
 				return llm.ApiTypeOpenAIResponses
 			}
-			return llm.ApiTypeOpenAIChatCompletion
+			return selectAPI(req.Model, req.ApiTypeHint)
 		}),
 		providercore2.WithResponsesRequestTransform(func(resp *providercore2.ResponsesRequest) error {
 			if resp == nil {
@@ -88,6 +92,9 @@ func New(opts ...llm.Option) *Provider {
 				resp.MaxOutputTokens = resp.MaxTokens
 				resp.MaxTokens = 0
 			}
+			if resp.MaxOutputTokens > 0 && resp.MaxOutputTokens < minResponsesOutputTokens {
+				resp.MaxOutputTokens = minResponsesOutputTokens
+			}
 			if resp.Metadata != nil {
 				if value, ok := resp.Metadata[internalReasoningEffortKey].(string); ok && value != "" {
 					if resp.Reasoning == nil {
@@ -117,6 +124,7 @@ func New(opts ...llm.Option) *Provider {
 			}
 			if meta, ok := payload["metadata"].(map[string]any); ok {
 				delete(meta, internalReasoningEffortKey)
+				injectBuiltinTools(payload, meta)
 				if len(meta) == 0 {
 					delete(payload, "metadata")
 				} else {
@@ -163,6 +171,9 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+	for key, values := range p.opts.Header {
+		req.Header[key] = values
+	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
 	resp, err := client.Do(req)
@@ -173,7 +184,7 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, llm.NewErrAPIError(p.Name(), resp.StatusCode, string(body))
+		return nil, llm.NewErrAPIError(p.Name(), resp.StatusCode, string(body)).WithRetryAfter(resp.Header)
 	}
 
 	var result struct {
@@ -196,3 +207,13 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 	}
 	return models, nil
 }
+
+// selectAPI picks the wire API for model. Models only served by the Responses
+// API always use it; other models honour an explicit Responses hint and
+// default to Chat Completions.
+func selectAPI(model string, hint llm.ApiType) llm.ApiType {
+	if useResponsesAPI(model) || hint == llm.ApiTypeOpenAIResponses {
+		return llm.ApiTypeOpenAIResponses
+	}
+	return llm.ApiTypeOpenAIChatCompletion
+}
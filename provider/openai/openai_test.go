@@ -6,11 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
 	"github.com/codewandler/llm/msg"
 )
 
@@ -88,3 +90,224 @@ func TestProvider_CreateStream_ResponsesBody_UsesOnlyPromptCacheRetention_WhenBo
 	assert.Equal(t, "24h", gotBody["prompt_cache_retention"])
 	assert.Nil(t, gotBody["cache_control"])
 }
+
+func TestProvider_WarmCache_ResponsesRaisesOutputLimitToMinimum(t *testing.T) {
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"gpt-5.4\",\"status\":\"completed\"}}\n\n")
+	}))
+	defer server.Close()
+
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+	_, err := llm.WarmCache(t.Context(), p, llm.Request{
+		Model:    "gpt-5.4",
+		Messages: msg.BuildTranscript(msg.System("You are terse."), msg.User("Hello")),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(minResponsesOutputTokens), gotBody["max_output_tokens"])
+}
+
+func TestProvider_CreateStream_RoutesToAPI(t *testing.T) {
+	tests := []struct {
+		model    string
+		hint     llm.ApiType
+		wantPath string
+	}{
+		{model: "gpt-4o", wantPath: "/v1/chat/completions"},
+		{model: "gpt-4o", hint: llm.ApiTypeOpenAIResponses, wantPath: "/v1/responses"},
+		{model: "gpt-5-pro", wantPath: "/v1/responses"},
+		{model: "o1-pro", hint: llm.ApiTypeOpenAIChatCompletion, wantPath: "/v1/responses"},
+		{model: "gpt-5.4", wantPath: "/v1/responses"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model+"/"+string(tt.hint), func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = io.WriteString(w, "data: [DONE]\n\n")
+			}))
+			defer server.Close()
+
+			p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+			stream, err := p.CreateStream(t.Context(), llm.Request{
+				Model:       tt.model,
+				ApiTypeHint: tt.hint,
+				Messages:    msg.BuildTranscript(msg.User("Hello")),
+			})
+			require.NoError(t, err)
+			for range stream {
+			}
+			assert.Equal(t, tt.wantPath, gotPath)
+		})
+	}
+}
+
+func TestProvider_CreateStream_BuiltinToolsAndAnnotations(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.output_text.annotation.added\n"+
+			`data: {"type":"response.output_text.annotation.added","output_index":1,"item_id":"msg_1","content_index":0,"annotation_index":0,`+
+			`"annotation":{"type":"url_citation","url":"https://example.com","title":"Example","start_index":3,"end_index":9}}`+"\n\n"+
+			"event: response.completed\n"+
+			`data: {"response":{"id":"resp_1","model":"gpt-4o","status":"completed"}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	req := llm.Request{Model: "gpt-4o", Messages: msg.BuildTranscript(msg.User("News?"))}
+	WithBuiltinTools(WebSearch(), FileSearch("vs_1"))(&req)
+
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+	stream, err := p.CreateStream(t.Context(), req)
+	require.NoError(t, err)
+	var annotations []*llm.AnnotationEvent
+	for env := range stream {
+		if a, ok := env.Data.(*llm.AnnotationEvent); ok {
+			annotations = append(annotations, a)
+		}
+	}
+
+	assert.Equal(t, "/v1/responses", gotPath)
+	assert.Equal(t, []any{
+		map[string]any{"type": "web_search"},
+		map[string]any{"type": "file_search", "vector_store_ids": []any{"vs_1"}},
+	}, gotBody["tools"])
+	assert.NotContains(t, gotBody, "metadata")
+
+	require.Len(t, annotations, 1)
+	assert.Equal(t, "url_citation", annotations[0].AnnotationType)
+	assert.Equal(t, "https://example.com", annotations[0].URL)
+	assert.Equal(t, "Example", annotations[0].Title)
+	assert.Equal(t, 3, annotations[0].StartIndex)
+	assert.Equal(t, 9, annotations[0].EndIndex)
+}
+
+func TestProvider_CreateStream_OutputSchemaFallsBackToJSONMode(t *testing.T) {
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+	stream, err := p.CreateStream(t.Context(), llm.Request{
+		Model:        "gpt-4o",
+		Messages:     msg.BuildTranscript(msg.User("Hello")),
+		OutputSchema: map[string]any{"type": "object"},
+	})
+	require.NoError(t, err)
+	var warnings []*llm.WarningEvent
+	for env := range stream {
+		if w, ok := env.Data.(*llm.WarningEvent); ok {
+			warnings = append(warnings, w)
+		}
+	}
+
+	assert.Equal(t, map[string]any{"type": "json_object"}, gotBody["response_format"])
+	require.Len(t, warnings, 1)
+	assert.Equal(t, llm.WarningParameterIgnored, warnings[0].Code)
+	assert.Equal(t, "output_schema", warnings[0].Param)
+}
+
+func TestProvider_CreateStream_CompletedCarriesTiming(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Response{Chunks: []string{"Hi"}, Delay: 20 * time.Millisecond})
+	defer srv.Close()
+
+	p := New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:    "gpt-4o",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, c.Timing)
+
+	tm := *c.Timing
+	assert.False(t, tm.RequestStart.IsZero())
+	assert.False(t, tm.FirstByte.IsZero())
+	assert.False(t, tm.FirstDelta.IsZero())
+	assert.GreaterOrEqual(t, tm.TimeToFirstToken(), 40*time.Millisecond, "role chunk and first content chunk are both delayed")
+	assert.LessOrEqual(t, tm.TimeToFirstByte(), tm.TimeToFirstToken())
+	assert.LessOrEqual(t, tm.TimeToFirstToken(), tm.Duration())
+}
+
+func TestProvider_CreateStream_CompletedCarriesFinishReason(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.Response{Chunks: []string{"Once upon"}, FinishReason: "length"})
+	defer srv.Close()
+
+	p := New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:    "gpt-4o",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, llm.StopReasonMaxTokens, c.StopReason)
+	assert.Equal(t, "length", c.FinishReason)
+	assert.True(t, c.StopReason.Truncated())
+}
+
+func TestProvider_CreateStream_SendsConfiguredHeaders(t *testing.T) {
+	srv := llmtest.NewServer(llmtest.TextResponse("Hi"))
+	defer srv.Close()
+
+	var transported bool
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		transported = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	p := New(
+		llm.WithBaseURL(srv.URL),
+		llm.WithAPIKey("test-key"),
+		llm.WithHTTPClient(client),
+		WithOrganization("org-123"),
+		WithProject("proj-456"),
+		llm.WithHeader("X-Gateway-Route", "eu"),
+		llm.WithHeader("Authorization", "Bearer ignored"),
+	)
+	_, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:    "gpt-4o",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+	})
+	require.NoError(t, err)
+	assert.True(t, transported, "custom http.Client is used")
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	h := reqs[0].Header
+	assert.Equal(t, "org-123", h.Get("OpenAI-Organization"))
+	assert.Equal(t, "proj-456", h.Get("OpenAI-Project"))
+	assert.Equal(t, "eu", h.Get("X-Gateway-Route"))
+	assert.Equal(t, "Bearer test-key", h.Get("Authorization"), "provider auth wins over WithHeader")
+}
+
+func TestProvider_FetchModels_SendsConfiguredHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		_, _ = io.WriteString(w, `{"data":[{"id":"gpt-4o"}]}`)
+	}))
+	defer srv.Close()
+
+	p := New(llm.WithBaseURL(srv.URL), llm.WithAPIKey("test-key"), WithOrganization("org-123"))
+	models, err := p.FetchModels(t.Context())
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, "org-123", got.Get("OpenAI-Organization"))
+	assert.Equal(t, "Bearer test-key", got.Get("Authorization"))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
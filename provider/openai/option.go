@@ -0,0 +1,15 @@
+package openai
+
+import "github.com/codewandler/llm"
+
+// WithOrganization sends the OpenAI-Organization header so usage is billed
+// to org.
+func WithOrganization(org string) llm.Option {
+	return llm.WithHeader("OpenAI-Organization", org)
+}
+
+// WithProject sends the OpenAI-Project header so usage is attributed to
+// project.
+func WithProject(project string) llm.Option {
+	return llm.WithHeader("OpenAI-Project", project)
+}
@@ -29,6 +29,9 @@ type Provider struct {
 	client       *http.Client
 	defaultModel string
 	models       llm.Models
+
+	preferences    *ProviderPreferences
+	fallbackModels []string
 }
 
 func DefaultOptions() []llm.Option {
@@ -103,6 +106,7 @@ func New(opts ...llm.Option) *Provider {
 				r.Header.Set("Anthropic-Version", anthropic.AnthropicVersion)
 				r.Header.Set("Anthropic-Beta", anthropic.BetaInterleavedThinking)
 			}
+			p.applyRouting(r)
 		}),
 	), allOpts...)
 
@@ -134,7 +138,7 @@ func (p *Provider) FetchModels(ctx context.Context) ([]llm.Model, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, llm.NewErrAPIError(llm.ProviderNameOpenRouter, resp.StatusCode, string(body))
+		return nil, llm.NewErrAPIError(llm.ProviderNameOpenRouter, resp.StatusCode, string(body)).WithRetryAfter(resp.Header)
 	}
 	var result struct {
 		Data []struct {
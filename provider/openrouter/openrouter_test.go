@@ -432,3 +432,60 @@ func TestProvider_CreateStream_OpenAIModel_UsesOnlyPromptCacheRetention_WhenBoth
 	assert.Equal(t, "24h", gotBody["prompt_cache_retention"])
 	assert.Nil(t, gotBody["cache_control"])
 }
+
+func TestProvider_CreateStream_RoutingPreferencesAndFallbackModels(t *testing.T) {
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.completed\ndata: {\"response\":{\"id\":\"resp_1\",\"model\":\"meta/llama-4\",\"status\":\"completed\"}}\n\n")
+	}))
+	defer server.Close()
+
+	allowFallbacks := false
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key")).
+		WithProviderPreferences(ProviderPreferences{
+			Order:          []string{"groq", "together"},
+			Ignore:         []string{"deepinfra"},
+			AllowFallbacks: &allowFallbacks,
+			Quantizations:  []string{"fp8"},
+		}).
+		WithFallbackModels("mistral/mistral-large", "openai/gpt-4o")
+	stream, err := p.CreateStream(t.Context(), llm.Request{
+		Model:    "meta/llama-4",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+	})
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	assert.Equal(t, "meta/llama-4", gotBody["model"])
+	assert.Equal(t, []any{"mistral/mistral-large", "openai/gpt-4o"}, gotBody["models"])
+	assert.Equal(t, map[string]any{
+		"order":           []any{"groq", "together"},
+		"ignore":          []any{"deepinfra"},
+		"allow_fallbacks": false,
+		"quantizations":   []any{"fp8"},
+	}, gotBody["provider"])
+}
+
+func TestProvider_CreateStream_CompletedCarriesGenerationAndUpstreamProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w,
+			"event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"gen-123\",\"model\":\"meta-llama/llama-4\",\"status\":\"in_progress\",\"provider\":\"Together\"}}\n\n"+
+				"event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"gen-123\",\"model\":\"meta-llama/llama-4\",\"status\":\"completed\",\"provider\":\"Together\"}}\n\n")
+	}))
+	defer server.Close()
+
+	p := New(llm.WithBaseURL(server.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:    "meta-llama/llama-4",
+		Messages: msg.BuildTranscript(msg.User("Hello")),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, c.Response)
+	assert.Equal(t, llm.ResponseMeta{ID: "gen-123", Provider: "Together", Model: "meta-llama/llama-4"}, *c.Response)
+}
@@ -0,0 +1,82 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// ProviderPreferences controls which upstream vendors OpenRouter routes a
+// request to. It is sent as the "provider" object of every request; empty
+// fields are left to OpenRouter's defaults.
+type ProviderPreferences struct {
+	// Order lists provider slugs to try first, in order.
+	Order []string `json:"order,omitempty"`
+	// Only restricts routing to these providers.
+	Only []string `json:"only,omitempty"`
+	// Ignore excludes these providers.
+	Ignore []string `json:"ignore,omitempty"`
+	// AllowFallbacks, when false, fails the request instead of trying
+	// providers outside Order.
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+	// RequireParameters routes only to providers supporting every
+	// parameter of the request.
+	RequireParameters bool `json:"require_parameters,omitempty"`
+	// DataCollection is "allow" or "deny".
+	DataCollection string `json:"data_collection,omitempty"`
+	// Quantizations restricts routing to these quantization levels,
+	// e.g. "fp8" or "bf16".
+	Quantizations []string `json:"quantizations,omitempty"`
+	// Sort is "price", "throughput" or "latency".
+	Sort string `json:"sort,omitempty"`
+}
+
+// WithProviderPreferences sets the upstream routing preferences sent with
+// every request.
+func (p *Provider) WithProviderPreferences(prefs ProviderPreferences) *Provider {
+	p.preferences = &prefs
+	return p
+}
+
+// WithFallbackModels sets the models OpenRouter tries, in order, when the
+// requested model is unavailable or fails. They are sent as the "models"
+// array after the requested model.
+func (p *Provider) WithFallbackModels(models ...string) *Provider {
+	p.fallbackModels = models
+	return p
+}
+
+// applyRouting adds the routing preferences and fallback models to the JSON
+// body of r.
+func (p *Provider) applyRouting(r *http.Request) {
+	if p.preferences == nil && len(p.fallbackModels) == 0 {
+		return
+	}
+	if r.Body == nil || r.Header.Get("Content-Type") != "application/json" {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+	if p.preferences != nil {
+		payload["provider"] = p.preferences
+	}
+	if len(p.fallbackModels) > 0 {
+		payload["models"] = p.fallbackModels
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+}
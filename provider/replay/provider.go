@@ -0,0 +1,102 @@
+// Package replay records provider streams into golden JSON files and
+// replays them deterministically, so tests can exercise real event
+// sequences without network access or API keys.
+//
+// Record once against a real provider:
+//
+//	rec := replay.NewRecorder(openai.New(...))
+//	// ... run the code under test with rec as its provider ...
+//	err := rec.Save("testdata/chat.json")
+//
+// Then replay in unit tests:
+//
+//	p, err := replay.Open("testdata/chat.json")
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/codewandler/llm"
+)
+
+// ProviderName is the default name of a replay Provider when the recording
+// does not name the provider it was taken from.
+const ProviderName = "replay"
+
+// Provider replays a Recording. Each CreateStream call emits the next
+// recorded stream; the request itself is ignored.
+type Provider struct {
+	rec *Recording
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewProvider returns a Provider that replays rec.
+func NewProvider(rec *Recording) *Provider {
+	return &Provider{rec: rec}
+}
+
+// Open loads the golden file at path and returns a Provider replaying it.
+func Open(path string) (*Provider, error) {
+	rec, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(rec), nil
+}
+
+// Name returns the name of the recorded provider, so replayed events and
+// errors look the same as the original ones.
+func (p *Provider) Name() string {
+	if p.rec.Provider != "" {
+		return p.rec.Provider
+	}
+	return ProviderName
+}
+
+func (p *Provider) Models() llm.Models { return p.rec.Models }
+
+// Remaining reports how many recorded streams have not been replayed yet.
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.rec.Streams) - p.next
+}
+
+// CreateStream replays the next recorded stream. It fails once every
+// recorded stream has been replayed.
+func (p *Provider) CreateStream(ctx context.Context, _ llm.Buildable) (llm.Stream, error) {
+	p.mu.Lock()
+	if p.next >= len(p.rec.Streams) {
+		p.mu.Unlock()
+		return nil, llm.NewErrProviderMsg(p.Name(), fmt.Sprintf("replay: no recorded stream left (%d replayed)", p.next))
+	}
+	stream := p.rec.Streams[p.next]
+	p.next++
+	p.mu.Unlock()
+
+	events := make([]llm.Event, 0, len(stream.Events))
+	for _, ev := range stream.Events {
+		e, err := decodeEvent(ev)
+		if err != nil {
+			return nil, llm.NewErrStreamDecode(p.Name(), err)
+		}
+		events = append(events, e)
+	}
+
+	pub, ch := llm.NewEventPublisher()
+	go func() {
+		defer pub.Close()
+		for _, e := range events {
+			if ctx.Err() != nil {
+				pub.Error(llm.NewErrContextCancelled(p.Name(), ctx.Err()))
+				return
+			}
+			pub.Publish(e)
+		}
+	}()
+	return ch, nil
+}
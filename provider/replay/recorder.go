@@ -0,0 +1,84 @@
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/codewandler/llm"
+)
+
+// Recorder wraps a provider and records every stream it produces. Events
+// are forwarded to the caller unchanged. Call Save once the streams have
+// been consumed to write a golden file that Provider can replay.
+type Recorder struct {
+	inner llm.Provider
+
+	mu      sync.Mutex
+	streams []Stream
+	err     error
+}
+
+// NewRecorder returns a Recorder around inner.
+func NewRecorder(inner llm.Provider) *Recorder {
+	return &Recorder{inner: inner}
+}
+
+func (r *Recorder) Name() string       { return r.inner.Name() }
+func (r *Recorder) Models() llm.Models { return r.inner.Models() }
+
+// CreateStream starts a stream on the wrapped provider and records its
+// events in call order. Streams that fail to start are not recorded. When
+// ctx is cancelled the rest of the stream is still recorded but no longer
+// forwarded.
+func (r *Recorder) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	stream, err := r.inner.CreateStream(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	idx := len(r.streams)
+	r.streams = append(r.streams, Stream{Events: []Event{}})
+	r.mu.Unlock()
+
+	return llm.ObserveStream(ctx, stream, func(env llm.Envelope) { r.record(idx, env) }, nil), nil
+}
+
+func (r *Recorder) record(idx int, env llm.Envelope) {
+	ev, ok, err := encodeEvent(env)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	if ok {
+		r.streams[idx].Events = append(r.streams[idx].Events, ev)
+	}
+}
+
+// Recording returns a snapshot of everything recorded so far.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	streams := make([]Stream, len(r.streams))
+	for i, s := range r.streams {
+		streams[i] = Stream{Events: append([]Event(nil), s.Events...)}
+	}
+	return &Recording{
+		Provider: r.inner.Name(),
+		Models:   r.inner.Models(),
+		Streams:  streams,
+	}
+}
+
+// Save writes the recording to path. It fails if any event could not be
+// encoded.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.Recording().Save(path)
+}
@@ -0,0 +1,265 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+)
+
+// Recording is the content of a golden file: the provider that was
+// recorded and every stream it produced, in call order.
+type Recording struct {
+	Provider string     `json:"provider"`
+	Models   llm.Models `json:"models,omitempty"`
+	Streams  []Stream   `json:"streams"`
+}
+
+// Stream holds the events of one CreateStream call.
+type Stream struct {
+	Events []Event `json:"events"`
+}
+
+// Event is one recorded stream event. Data holds the JSON encoding of the
+// llm event payload that matches Type.
+type Event struct {
+	Type llm.EventType   `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Load reads a recording from a golden JSON file.
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: read %s: %w", path, err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("replay: decode %s: %w", path, err)
+	}
+	return &rec, nil
+}
+
+// Save writes the recording to path as indented JSON, creating parent
+// directories as needed.
+func (r *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("replay: encode: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("replay: write %s: %w", path, err)
+	}
+	return nil
+}
+
+// skipped lists event types that are not recorded. Created and closed are
+// emitted by the publisher itself on replay; request events carry the raw
+// HTTP headers, including credentials.
+var skipped = map[llm.EventType]bool{
+	llm.StreamEventCreated: true,
+	llm.StreamEventClosed:  true,
+	llm.StreamEventRequest: true,
+}
+
+// encodeEvent converts a stream envelope into a recorded Event. ok is false
+// for event types that are not recorded.
+func encodeEvent(env llm.Envelope) (ev Event, ok bool, err error) {
+	if skipped[env.Type] {
+		return Event{}, false, nil
+	}
+	var payload any = env.Data
+	switch d := env.Data.(type) {
+	case *llm.ErrorEvent:
+		payload = errorRecordOf(d.Error)
+	case *llm.ProviderFailoverEvent:
+		payload = failoverRecord{Provider: d.Provider, FailoverProvider: d.FailoverProvider, Error: errorRecordOf(d.Error)}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, false, fmt.Errorf("replay: encode %s event: %w", env.Type, err)
+	}
+	return Event{Type: env.Type, Data: data}, true, nil
+}
+
+// decodeEvent turns a recorded Event back into the llm event payload the
+// publisher emits for its type.
+func decodeEvent(ev Event) (llm.Event, error) {
+	var (
+		out llm.Event
+		err error
+	)
+	switch ev.Type {
+	case llm.StreamEventStarted:
+		out, err = decodeInto(ev.Data, &llm.StreamStartedEvent{})
+	case llm.StreamEventModelResolved:
+		out, err = decodeInto(ev.Data, &llm.ModelResolvedEvent{})
+	case llm.StreamEventUsageUpdated:
+		out, err = decodeInto(ev.Data, &llm.UsageUpdatedEvent{})
+	case llm.StreamEventTokenEstimate:
+		out, err = decodeInto(ev.Data, &llm.TokenEstimateEvent{})
+	case llm.StreamEventDelta:
+		out, err = decodeInto(ev.Data, &llm.DeltaEvent{})
+	case llm.StreamEventToolProgress:
+		out, err = decodeInto(ev.Data, &llm.ToolProgressEvent{})
+	case llm.StreamEventToolResult:
+		out, err = decodeInto(ev.Data, &llm.ToolResultEvent{})
+	case llm.StreamEventAnnotation:
+		out, err = decodeInto(ev.Data, &llm.AnnotationEvent{})
+	case llm.StreamEventContentPart:
+		out, err = decodeInto(ev.Data, &llm.ContentPartEvent{})
+	case llm.StreamEventCompleted:
+		out, err = decodeInto(ev.Data, &llm.CompletedEvent{})
+	case llm.StreamEventWarning:
+		out, err = decodeInto(ev.Data, &llm.WarningEvent{})
+	case llm.StreamEventDebug:
+		out, err = decodeInto(ev.Data, &llm.DebugEvent{})
+	case llm.StreamEventToolCall:
+		var w struct {
+			ToolCall struct {
+				ID   string    `json:"id"`
+				Name string    `json:"name"`
+				Args tool.Args `json:"args"`
+			} `json:"tool_call"`
+		}
+		if err = json.Unmarshal(ev.Data, &w); err == nil {
+			out = &llm.ToolCallEvent{ToolCall: tool.NewToolCall(w.ToolCall.ID, w.ToolCall.Name, w.ToolCall.Args)}
+		}
+	case llm.StreamEventError:
+		var w errorRecord
+		if err = json.Unmarshal(ev.Data, &w); err == nil {
+			out = &llm.ErrorEvent{Error: w.err()}
+		}
+	case llm.StreamEventProviderFailover:
+		var w failoverRecord
+		if err = json.Unmarshal(ev.Data, &w); err == nil {
+			out = &llm.ProviderFailoverEvent{Provider: w.Provider, FailoverProvider: w.FailoverProvider, Error: w.Error.err()}
+		}
+	default:
+		return nil, fmt.Errorf("replay: unsupported event type %q", ev.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replay: decode %s event: %w", ev.Type, err)
+	}
+	return out, nil
+}
+
+func decodeInto[T llm.Event](data json.RawMessage, v T) (llm.Event, error) {
+	if len(data) == 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+type failoverRecord struct {
+	Provider         string       `json:"provider"`
+	FailoverProvider string       `json:"failover_provider"`
+	Error            *errorRecord `json:"error,omitempty"`
+}
+
+// errorRecord is the recorded form of an error. ProviderErrors keep their
+// sentinel and kind by name so errors.Is still works after replay.
+type errorRecord struct {
+	Sentinel   string           `json:"sentinel,omitempty"`
+	Kind       string           `json:"kind,omitempty"`
+	Provider   string           `json:"provider,omitempty"`
+	Message    string           `json:"message"`
+	StatusCode int              `json:"status_code,omitempty"`
+	Code       string           `json:"code,omitempty"`
+	Body       string           `json:"body,omitempty"`
+	Safety     *llm.SafetyBlock `json:"safety,omitempty"`
+}
+
+var sentinels = []error{
+	llm.ErrContextCancelled,
+	llm.ErrRequestFailed,
+	llm.ErrAPIError,
+	llm.ErrStreamRead,
+	llm.ErrStreamDecode,
+	llm.ErrProviderError,
+	llm.ErrMissingAPIKey,
+	llm.ErrBuildRequest,
+	llm.ErrUnknownModel,
+	llm.ErrNoProviders,
+	llm.ErrFirstTokenTimeout,
+	llm.ErrRateLimited,
+	llm.ErrAuth,
+	llm.ErrContextLengthExceeded,
+	llm.ErrContentFiltered,
+	llm.ErrModelNotFound,
+	llm.ErrUnknown,
+}
+
+func sentinelByName(name string) error {
+	for _, s := range sentinels {
+		if s.Error() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func errorRecordOf(err error) *errorRecord {
+	if err == nil {
+		return nil
+	}
+	var pe *llm.ProviderError
+	if !errors.As(err, &pe) {
+		return &errorRecord{Message: err.Error()}
+	}
+	r := &errorRecord{
+		Provider:   pe.Provider,
+		Message:    pe.Message,
+		StatusCode: pe.StatusCode,
+		Code:       pe.Code,
+		Body:       pe.ResponseBody,
+		Safety:     pe.Safety,
+	}
+	if pe.Sentinel != nil {
+		r.Sentinel = pe.Sentinel.Error()
+	}
+	if pe.Kind != nil {
+		r.Kind = pe.Kind.Error()
+	}
+	if pe.Cause != nil {
+		if r.Message == "" {
+			r.Message = pe.Cause.Error()
+		} else {
+			r.Message += ": " + pe.Cause.Error()
+		}
+	}
+	return r
+}
+
+func (r *errorRecord) err() error {
+	if r == nil {
+		return nil
+	}
+	if r.Sentinel == "" {
+		return errors.New(r.Message)
+	}
+	sentinel := sentinelByName(r.Sentinel)
+	if sentinel == nil {
+		sentinel = llm.ErrUnknown
+	}
+	return &llm.ProviderError{
+		Sentinel:     sentinel,
+		Kind:         sentinelByName(r.Kind),
+		Provider:     r.Provider,
+		Message:      r.Message,
+		StatusCode:   r.StatusCode,
+		Code:         r.Code,
+		ResponseBody: r.Body,
+		Safety:       r.Safety,
+	}
+}
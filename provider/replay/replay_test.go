@@ -0,0 +1,148 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/provider/fake"
+)
+
+type scripted struct {
+	events []llm.Event
+}
+
+func (s scripted) Name() string       { return "scripted" }
+func (s scripted) Models() llm.Models { return llm.Models{{ID: "m-1", Provider: "scripted"}} }
+func (s scripted) CreateStream(context.Context, llm.Buildable) (llm.Stream, error) {
+	pub, ch := llm.NewEventPublisher()
+	go func() {
+		defer pub.Close()
+		for _, e := range s.events {
+			pub.Publish(e)
+		}
+	}()
+	return ch, nil
+}
+
+func TestRecordAndReplay_Completions(t *testing.T) {
+	rec := NewRecorder(fake.NewProvider())
+	req := llm.Request{Model: fake.Model1ID}
+
+	first, err := llm.Complete(t.Context(), rec, req)
+	require.NoError(t, err)
+	second, err := llm.Complete(t.Context(), rec, req)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "golden", "fake.json")
+	require.NoError(t, rec.Save(path))
+
+	p, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, fake.ProviderName, p.Name())
+	assert.Equal(t, fake.NewProvider().Models(), p.Models())
+	assert.Equal(t, 2, p.Remaining())
+
+	got1, err := llm.Complete(t.Context(), p, req)
+	require.NoError(t, err)
+	got2, err := llm.Complete(t.Context(), p, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.StopReason, got1.StopReason)
+	require.Len(t, got1.ToolCalls, 1)
+	assert.Equal(t, first.ToolCalls[0].ToolCallID(), got1.ToolCalls[0].ToolCallID())
+	assert.Equal(t, first.ToolCalls[0].ToolName(), got1.ToolCalls[0].ToolName())
+	assert.Equal(t, first.ToolCalls[0].ToolArgs(), got1.ToolCalls[0].ToolArgs())
+	assert.Equal(t, first.Usage[0].Tokens, got1.Usage[0].Tokens)
+	assert.Equal(t, second.Text, got2.Text)
+	assert.Equal(t, second.StopReason, got2.StopReason)
+
+	_, err = p.CreateStream(t.Context(), req)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrProviderError)
+}
+
+func TestRecordAndReplay_PreservesEventTypesAndErrors(t *testing.T) {
+	inner := scripted{events: []llm.Event{
+		&llm.StreamStartedEvent{RequestID: "req-1", Model: "m-1", Provider: "scripted"},
+		&llm.RequestEvent{ProviderRequest: llm.ProviderRequest{Headers: map[string]string{"Authorization": "Bearer secret"}}},
+		&llm.WarningEvent{Code: llm.WarningParameterIgnored, Message: "ignored"},
+		llm.ThinkingDelta("hmm"),
+		llm.TextDelta("hi").WithIndex(1),
+		&llm.ErrorEvent{Error: llm.NewErrAPIError("scripted", 429, `{"error":"slow down"}`)},
+	}}
+	rec := NewRecorder(inner)
+	stream, err := rec.CreateStream(t.Context(), llm.Request{})
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	recording := rec.Recording()
+	require.Len(t, recording.Streams, 1)
+	var types []llm.EventType
+	for _, ev := range recording.Streams[0].Events {
+		types = append(types, ev.Type)
+	}
+	assert.Equal(t, []llm.EventType{
+		llm.StreamEventStarted,
+		llm.StreamEventWarning,
+		llm.StreamEventDelta,
+		llm.StreamEventDelta,
+		llm.StreamEventError,
+	}, types, "request events must not be recorded")
+
+	var replayed []llm.Envelope
+	stream, err = NewProvider(recording).CreateStream(t.Context(), llm.Request{})
+	require.NoError(t, err)
+	for env := range stream {
+		replayed = append(replayed, env)
+	}
+	require.Len(t, replayed, 6, "created + 5 recorded events")
+
+	started, ok := replayed[1].Data.(*llm.StreamStartedEvent)
+	require.True(t, ok)
+	assert.Equal(t, "req-1", started.RequestID)
+
+	delta, ok := replayed[4].Data.(*llm.DeltaEvent)
+	require.True(t, ok)
+	assert.Equal(t, "hi", delta.Text)
+	require.NotNil(t, delta.Index)
+	assert.Equal(t, uint32(1), *delta.Index)
+
+	errEv, ok := replayed[5].Data.(*llm.ErrorEvent)
+	require.True(t, ok)
+	assert.ErrorIs(t, errEv.Error, llm.ErrAPIError)
+	assert.ErrorIs(t, errEv.Error, llm.ErrRateLimited)
+	var pe *llm.ProviderError
+	require.True(t, errors.As(errEv.Error, &pe))
+	assert.Equal(t, 429, pe.StatusCode)
+	assert.Equal(t, "scripted", pe.Provider)
+}
+
+func TestDecodeEvent_UnknownType(t *testing.T) {
+	_, err := decodeEvent(Event{Type: "bogus"})
+	require.Error(t, err)
+}
+
+func TestRecorder_CancelledConsumer(t *testing.T) {
+	events := make([]llm.Event, 200)
+	for i := range events {
+		events[i] = llm.TextDelta("x")
+	}
+	rec := NewRecorder(scripted{events: events})
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := rec.CreateStream(ctx, llm.Request{Model: "m-1", Messages: llm.Messages{llm.User("hi")}})
+	require.NoError(t, err)
+
+	// Nobody reads the stream after cancel; recording must still finish.
+	cancel()
+	require.Eventually(t, func() bool {
+		return len(rec.Recording().Streams[0].Events) == len(events)
+	}, time.Second, 5*time.Millisecond)
+}
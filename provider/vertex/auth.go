@@ -0,0 +1,355 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables consulted for Application Default Credentials.
+const (
+	EnvApplicationCredentials = "GOOGLE_APPLICATION_CREDENTIALS"
+	EnvCloudSDKConfig         = "CLOUDSDK_CONFIG"
+	EnvMetadataHost           = "GCE_METADATA_HOST"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	defaultTokenURL    = "https://oauth2.googleapis.com/token"
+	metadataHost       = "metadata.google.internal"
+
+	// tokenRefreshMargin renews cached tokens this long before they expire.
+	tokenRefreshMargin = time.Minute
+)
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken string
+	// Expiry is when the token stops being valid. Zero means it never
+	// expires.
+	Expiry time.Time
+}
+
+// TokenSource supplies access tokens for Vertex AI requests.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// StaticToken returns a TokenSource that always returns token, for example
+// the output of `gcloud auth print-access-token`.
+func StaticToken(token string) TokenSource {
+	return staticToken{Token{AccessToken: token}}
+}
+
+type staticToken struct{ tok Token }
+
+func (s staticToken) Token(context.Context) (*Token, error) {
+	t := s.tok
+	return &t, nil
+}
+
+// credentialsFile is the JSON layout shared by service account keys and the
+// gcloud application default credentials file.
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// service_account
+	ProjectID    string `json:"project_id"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+
+	// authorized_user
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// project returns the project the credentials belong to, if recorded.
+func (f *credentialsFile) project() string {
+	if f.ProjectID != "" {
+		return f.ProjectID
+	}
+	return f.QuotaProjectID
+}
+
+// credentialsFromJSON builds a caching TokenSource from a service account
+// key or authorized_user credentials file.
+func credentialsFromJSON(data []byte, client *http.Client) (TokenSource, *credentialsFile, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	switch f.Type {
+	case "service_account":
+		key, err := parsePrivateKey(f.PrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if f.ClientEmail == "" {
+			return nil, nil, errors.New("service account credentials: missing client_email")
+		}
+		src := &serviceAccountSource{client: client, email: f.ClientEmail, keyID: f.PrivateKeyID, key: key, tokenURL: orDefault(f.TokenURI, defaultTokenURL)}
+		return newCachedTokenSource(src), &f, nil
+	case "authorized_user":
+		if f.RefreshToken == "" {
+			return nil, nil, errors.New("authorized_user credentials: missing refresh_token")
+		}
+		src := &refreshTokenSource{client: client, clientID: f.ClientID, clientSecret: f.ClientSecret, refreshToken: f.RefreshToken, tokenURL: orDefault(f.TokenURI, defaultTokenURL)}
+		return newCachedTokenSource(src), &f, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported credentials type %q", f.Type)
+	}
+}
+
+// findDefaultCredentials resolves Application Default Credentials: the file
+// named by GOOGLE_APPLICATION_CREDENTIALS, then gcloud's well-known file,
+// then the GCE metadata server. The metadata server is not probed here; it
+// fails on first use when not running on Google Cloud.
+func findDefaultCredentials(client *http.Client) (TokenSource, *credentialsFile, error) {
+	if path := os.Getenv(EnvApplicationCredentials); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %w", EnvApplicationCredentials, err)
+		}
+		return credentialsFromJSON(data, client)
+	}
+	if path := wellKnownCredentialsFile(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			return credentialsFromJSON(data, client)
+		}
+	}
+	return newCachedTokenSource(&metadataSource{client: client}), nil, nil
+}
+
+// CredentialsAvailable reports whether an Application Default Credentials
+// file exists. The metadata server is not probed.
+func CredentialsAvailable() bool {
+	path := os.Getenv(EnvApplicationCredentials)
+	if path == "" {
+		path = wellKnownCredentialsFile()
+	}
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// wellKnownCredentialsFile returns the path `gcloud auth
+// application-default login` writes to.
+func wellKnownCredentialsFile() string {
+	dir := os.Getenv(EnvCloudSDKConfig)
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+// cachedTokenSource reuses a token until shortly before it expires.
+type cachedTokenSource struct {
+	src TokenSource
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+func newCachedTokenSource(src TokenSource) *cachedTokenSource {
+	return &cachedTokenSource{src: src}
+}
+
+func (c *cachedTokenSource) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tok != nil && (c.tok.Expiry.IsZero() || time.Until(c.tok.Expiry) > tokenRefreshMargin) {
+		return c.tok, nil
+	}
+	tok, err := c.src.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.tok = tok
+	return tok, nil
+}
+
+// serviceAccountSource exchanges a self-signed JWT for an access token.
+type serviceAccountSource struct {
+	client   *http.Client
+	email    string
+	keyID    string
+	key      *rsa.PrivateKey
+	tokenURL string
+}
+
+func (s *serviceAccountSource) Token(ctx context.Context) (*Token, error) {
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if s.keyID != "" {
+		header["kid"] = s.keyID
+	}
+	claims := map[string]any{
+		"iss":   s.email,
+		"scope": cloudPlatformScope,
+		"aud":   s.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	assertion, err := signJWT(header, claims, s.key)
+	if err != nil {
+		return nil, err
+	}
+	return exchangeToken(ctx, s.client, s.tokenURL, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+}
+
+// refreshTokenSource exchanges a gcloud user refresh token.
+type refreshTokenSource struct {
+	client       *http.Client
+	clientID     string
+	clientSecret string
+	refreshToken string
+	tokenURL     string
+}
+
+func (s *refreshTokenSource) Token(ctx context.Context) (*Token, error) {
+	return exchangeToken(ctx, s.client, s.tokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+		"refresh_token": {s.refreshToken},
+	})
+}
+
+// metadataSource fetches the default service account token from the GCE
+// metadata server (Compute Engine, Cloud Run, GKE workload identity).
+type metadataSource struct {
+	client *http.Client
+}
+
+func (s *metadataSource) Token(ctx context.Context) (*Token, error) {
+	host := orDefault(os.Getenv(EnvMetadataHost), metadataHost)
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(cloudPlatformScope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no application default credentials found and metadata server unreachable: %w", err)
+	}
+	return decodeTokenResponse(resp)
+}
+
+func exchangeToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	return decodeTokenResponse(resp)
+}
+
+func decodeTokenResponse(resp *http.Response) (*Token, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	tok := &Token{AccessToken: result.AccessToken}
+	if result.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+func signJWT(header map[string]string, claims map[string]any, key *rsa.PrivateKey) (string, error) {
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+	sum := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign JWT: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("service account credentials: private_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("service account credentials: private_key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("service account credentials: parse private_key: %w", err)
+	}
+	return key, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
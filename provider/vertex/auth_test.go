@@ -0,0 +1,109 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountCredentials_SignsJWTAndCachesToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig))
+
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims map[string]any
+		require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+		assert.Equal(t, "sa@my-project.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, cloudPlatformScope, claims["scope"])
+
+		_, _ = w.Write([]byte(`{"access_token":"ya29.sa","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	creds, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "my-project",
+		"client_email":   "sa@my-project.iam.gserviceaccount.com",
+		"private_key_id": "kid-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      server.URL,
+	})
+	ts, file, err := credentialsFromJSON(creds, server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "my-project", file.project())
+
+	for range 2 {
+		tok, err := ts.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "ya29.sa", tok.AccessToken)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAuthorizedUserCredentials_RefreshToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		assert.Equal(t, "1//refresh", r.Form.Get("refresh_token"))
+		_, _ = w.Write([]byte(`{"access_token":"ya29.user","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	ts, file, err := credentialsFromJSON([]byte(`{"type":"authorized_user","client_id":"id","client_secret":"secret","refresh_token":"1//refresh","quota_project_id":"quota-project","token_uri":"`+server.URL+`"}`), server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "quota-project", file.project())
+
+	tok, err := ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.user", tok.AccessToken)
+}
+
+func TestNew_CredentialsFromApplicationDefaultCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adc.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"authorized_user","client_id":"id","client_secret":"s","refresh_token":"r","quota_project_id":"adc-project"}`), 0o600))
+	t.Setenv(EnvApplicationCredentials, path)
+	t.Setenv(EnvProject, "")
+	t.Setenv(EnvProjectAlt, "")
+
+	p := New()
+	require.NoError(t, p.credErr)
+	assert.Equal(t, "adc-project", p.Project())
+}
+
+func TestCredentialsFromJSON_UnsupportedType(t *testing.T) {
+	_, _, err := credentialsFromJSON([]byte(`{"type":"external_account"}`), http.DefaultClient)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "external_account")
+}
@@ -0,0 +1,127 @@
+package vertex
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+// Model ID constants for programmatic use. Claude models use Vertex's
+// versioned "name@date" IDs.
+const (
+	// Anthropic Claude models (publisher "anthropic").
+	ModelOpus41   = "claude-opus-4-1@20250805"
+	ModelSonnet45 = "claude-sonnet-4-5@20250929"
+	ModelSonnet4  = "claude-sonnet-4@20250514"
+	ModelHaiku45  = "claude-haiku-4-5@20251001"
+	ModelHaiku35  = "claude-3-5-haiku@20241022"
+	ModelOpus     = ModelOpus41
+	ModelSonnet   = ModelSonnet45
+	ModelHaiku    = ModelHaiku45
+
+	// Google Gemini models (publisher "google").
+	ModelGemini25Pro       = "gemini-2.5-pro"
+	ModelGemini25Flash     = "gemini-2.5-flash"
+	ModelGemini25FlashLite = "gemini-2.5-flash-lite"
+	ModelGemini20Flash     = "gemini-2.0-flash"
+)
+
+// ModelAliases maps short alias names to full model IDs.
+var ModelAliases = map[string]string{
+	"opus":       ModelOpus,
+	"sonnet":     ModelSonnet,
+	"haiku":      ModelHaiku,
+	"gemini":     ModelGemini25Flash,
+	"pro":        ModelGemini25Pro,
+	"flash":      ModelGemini25Flash,
+	"flash-lite": ModelGemini25FlashLite,
+}
+
+// pricing lists Vertex AI on-demand prices in USD per million tokens for
+// prompts up to 200k tokens, for the models the model catalog does not
+// price. Claude models are priced as their Anthropic catalog entries (see
+// anthropicModelID).
+var pricing = usage.PricingTable{
+	ModelHaiku35:           {Input: 0.8, Output: 4, CachedInput: 0.08, CacheWrite: 1},
+	ModelGemini25Pro:       {Input: 1.25, Output: 10, CachedInput: 0.31},
+	ModelGemini25Flash:     {Input: 0.30, Output: 2.50, CachedInput: 0.075},
+	ModelGemini25FlashLite: {Input: 0.10, Output: 0.40, CachedInput: 0.025},
+	ModelGemini20Flash:     {Input: 0.15, Output: 0.60},
+}
+
+var modelNames = []struct{ id, name string }{
+	{ModelOpus41, "Claude Opus 4.1"},
+	{ModelSonnet45, "Claude Sonnet 4.5"},
+	{ModelSonnet4, "Claude Sonnet 4"},
+	{ModelHaiku45, "Claude Haiku 4.5"},
+	{ModelHaiku35, "Claude 3.5 Haiku"},
+	{ModelGemini25Pro, "Gemini 2.5 Pro"},
+	{ModelGemini25Flash, "Gemini 2.5 Flash"},
+	{ModelGemini25FlashLite, "Gemini 2.5 Flash-Lite"},
+	{ModelGemini20Flash, "Gemini 2.0 Flash"},
+}
+
+// allModels is the static list returned by Provider.Models().
+var allModels = func() llm.Models {
+	models := make(llm.Models, 0, len(modelNames))
+	for _, m := range modelNames {
+		model := llm.Model{ID: m.id, Name: m.name, Provider: llm.ProviderNameVertex}
+		if p, ok := modelPricing(m.id); ok {
+			model.Pricing = &p
+		}
+		for alias, target := range ModelAliases {
+			if target == m.id {
+				model.Aliases = append(model.Aliases, alias)
+			}
+		}
+		sort.Strings(model.Aliases)
+		switch m.id {
+		case DefaultModel:
+			model.Aliases = append(model.Aliases, llm.ModelDefault)
+		case ModelGemini25FlashLite:
+			model.Aliases = append(model.Aliases, llm.ModelFast)
+		}
+		models = append(models, model)
+	}
+	return models
+}()
+
+// isClaude reports whether model is served by Anthropic's publisher
+// endpoint rather than the OpenAI-compatible Gemini endpoint.
+func isClaude(model string) bool {
+	return strings.HasPrefix(model, "claude-")
+}
+
+// anthropicModelID maps a Vertex Claude model ID ("claude-sonnet-4-5@20250929")
+// to the Anthropic API ID it is priced as ("claude-sonnet-4-5-20250929").
+func anthropicModelID(model string) (service, id string, ok bool) {
+	if !isClaude(model) {
+		return "", "", false
+	}
+	return llm.ProviderNameAnthropic, strings.Replace(model, "@", "-", 1), true
+}
+
+// modelPricing returns the rates model is priced at: the local table first,
+// then the Anthropic catalog entry for Claude models.
+func modelPricing(model string) (usage.Pricing, bool) {
+	if p, ok := pricing[model]; ok {
+		return p, true
+	}
+	if service, id, ok := anthropicModelID(model); ok {
+		return usage.CatalogPricing(service, id)
+	}
+	return usage.Pricing{}, false
+}
+
+var tableCalculator = pricing.Calculator(llm.ProviderNameVertex)
+
+// costCalculator prices usage records from the pricing table and, for
+// Claude models, the Anthropic catalog entries.
+var costCalculator = usage.Compose(
+	usage.CostCalculatorFunc(func(provider, model string, tokens usage.TokenItems) (usage.Cost, bool) {
+		return tableCalculator.Calculate(provider, strings.TrimPrefix(model, geminiPublisherPrefix), tokens)
+	}),
+	usage.CatalogAlias(llm.ProviderNameVertex, anthropicModelID),
+)
@@ -0,0 +1,260 @@
+// Package vertex provides an llm.Provider for Google Cloud Vertex AI.
+//
+// Claude models are called through Anthropic's publisher endpoint
+// (streamRawPredict, Messages API format) and Gemini models through Vertex's
+// OpenAI-compatible Chat Completions endpoint. Requests authenticate with
+// Application Default Credentials: a service account key, gcloud user
+// credentials, or the metadata server when running on Google Cloud.
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/codewandler/llm"
+	providercore2 "github.com/codewandler/llm/internal/providercore"
+)
+
+// Environment variables consulted for the project and region.
+const (
+	EnvProject      = "GOOGLE_CLOUD_PROJECT"
+	EnvProjectAlt   = "GCLOUD_PROJECT"
+	EnvLocation     = "GOOGLE_CLOUD_LOCATION"
+	EnvLocationAlt  = "GOOGLE_CLOUD_REGION"
+	EnvClaudeRegion = "CLOUD_ML_REGION"
+)
+
+// Region names.
+const (
+	DefaultRegion = "us-central1"
+	RegionGlobal  = "global"
+)
+
+const (
+	providerName     = llm.ProviderNameVertex
+	anthropicVersion = "vertex-2023-10-16"
+
+	// geminiPublisherPrefix qualifies Gemini model IDs on the
+	// OpenAI-compatible endpoint.
+	geminiPublisherPrefix = "google/"
+)
+
+// DefaultModel is the model used for llm.ModelDefault.
+const DefaultModel = ModelGemini25Flash
+
+// Provider implements the Vertex AI backend.
+type Provider struct {
+	project string
+	region  string
+	tokens  TokenSource
+	credErr error // deferred credentials error, reported by CreateStream
+
+	credentialsJSON []byte
+	llmOpts         []llm.Option
+
+	inner *providercore2.Provider
+}
+
+// Option configures a Vertex AI provider.
+type Option func(*Provider)
+
+// WithProject sets the Google Cloud project. By default New reads
+// GOOGLE_CLOUD_PROJECT or GCLOUD_PROJECT, falling back to the project
+// recorded in the credentials file.
+func WithProject(project string) Option {
+	return func(p *Provider) { p.project = project }
+}
+
+// WithRegion sets the Vertex AI region, such as "us-east5", "europe-west1"
+// or RegionGlobal. By default New reads GOOGLE_CLOUD_LOCATION,
+// GOOGLE_CLOUD_REGION or CLOUD_ML_REGION, falling back to DefaultRegion.
+func WithRegion(region string) Option {
+	return func(p *Provider) { p.region = region }
+}
+
+// WithTokenSource replaces Application Default Credentials.
+func WithTokenSource(ts TokenSource) Option {
+	return func(p *Provider) { p.tokens = ts }
+}
+
+// WithCredentialsJSON authenticates with a service account key or
+// authorized_user credentials file's contents instead of looking up
+// Application Default Credentials.
+func WithCredentialsJSON(data []byte) Option {
+	return func(p *Provider) { p.credentialsJSON = data }
+}
+
+// WithCredentialsFile is WithCredentialsJSON reading from path.
+func WithCredentialsFile(path string) Option {
+	return func(p *Provider) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			p.credErr = fmt.Errorf("read credentials file: %w", err)
+			return
+		}
+		p.credentialsJSON = data
+	}
+}
+
+// WithLLMOptions applies shared llm options (e.g. llm.WithHTTPClient,
+// llm.WithBaseURL) to the provider.
+func WithLLMOptions(opts ...llm.Option) Option {
+	return func(p *Provider) { p.llmOpts = append(p.llmOpts, opts...) }
+}
+
+// New creates a Vertex AI provider. Credential errors are deferred to
+// CreateStream so New never fails.
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		project: ProjectFromEnv(),
+		region:  firstEnv(EnvLocation, EnvLocationAlt, EnvClaudeRegion),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.region == "" {
+		p.region = DefaultRegion
+	}
+
+	cfg := llm.Apply(p.llmOpts...)
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = llm.DefaultHttpClient()
+	}
+	if p.tokens == nil && p.credErr == nil {
+		var (
+			creds *credentialsFile
+			err   error
+		)
+		if p.credentialsJSON != nil {
+			p.tokens, creds, err = credentialsFromJSON(p.credentialsJSON, httpClient)
+		} else {
+			p.tokens, creds, err = findDefaultCredentials(httpClient)
+		}
+		if err != nil {
+			p.credErr = err
+		}
+		if p.project == "" && creds != nil {
+			p.project = creds.project()
+		}
+	}
+
+	p.inner = providercore2.NewProvider(providercore2.NewOptions(
+		providercore2.WithProviderName(providerName),
+		providercore2.WithBaseURL(Endpoint(p.region)),
+		providercore2.WithAPIHint(llm.ApiTypeOpenAIChatCompletion),
+		providercore2.WithAPIHintResolver(func(req llm.Request) llm.ApiType {
+			if isClaude(req.Model) {
+				return llm.ApiTypeAnthropicMessages
+			}
+			return llm.ApiTypeOpenAIChatCompletion
+		}),
+		providercore2.WithModels(allModels),
+		providercore2.WithCostCalculator(costCalculator),
+		providercore2.WithHeaderFunc(p.authHeader),
+		providercore2.WithPreprocessRequest(func(req llm.Request) (llm.Request, string, error) {
+			original := req.Model
+			if resolved, err := allModels.Resolve(req.Model); err == nil {
+				req.Model = resolved.ID
+			}
+			return req, original, nil
+		}),
+		providercore2.WithMutateRequest(p.rewriteRequest),
+	), p.llmOpts...)
+	return p
+}
+
+// Endpoint returns the Vertex AI API host for region.
+func Endpoint(region string) string {
+	if region == "" || region == RegionGlobal {
+		return "https://aiplatform.googleapis.com"
+	}
+	return "https://" + region + "-aiplatform.googleapis.com"
+}
+
+func (p *Provider) Name() string       { return p.inner.Name() }
+func (p *Provider) Models() llm.Models { return p.inner.Models() }
+func (p *Provider) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	return p.inner.CreateStream(ctx, src)
+}
+
+// Project returns the Google Cloud project requests are billed to.
+func (p *Provider) Project() string { return p.project }
+
+// Region returns the Vertex AI region requests are sent to.
+func (p *Provider) Region() string { return p.region }
+
+func (p *Provider) authHeader(ctx context.Context, _ *llm.Request) (http.Header, error) {
+	if p.credErr != nil {
+		return nil, llm.NewErrBuildRequest(providerName, fmt.Errorf("vertex credentials: %w", p.credErr))
+	}
+	if p.project == "" {
+		return nil, llm.NewErrBuildRequest(providerName, errors.New("no Google Cloud project configured; set "+EnvProject+" or "+EnvProjectAlt+", or use vertex.WithProject"))
+	}
+	tok, err := p.tokens.Token(ctx)
+	if err != nil {
+		return nil, llm.NewErrBuildRequest(providerName, fmt.Errorf("vertex credentials: %w", err))
+	}
+	return http.Header{"Authorization": {"Bearer " + tok.AccessToken}}, nil
+}
+
+// rewriteRequest maps the Messages and Chat Completions requests built by
+// providercore onto Vertex's project- and model-scoped endpoints.
+func (p *Provider) rewriteRequest(r *http.Request) {
+	if r.Body == nil {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	var m map[string]any
+	if err != nil || json.Unmarshal(body, &m) != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+	model, _ := m["model"].(string)
+	base := "/v1/projects/" + p.project + "/locations/" + p.region
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/messages"):
+		// Claude: the model moves into the URL and the API version into the
+		// body.
+		delete(m, "model")
+		m["anthropic_version"] = anthropicVersion
+		r.Header.Del("anthropic-version")
+		r.URL.Path = base + "/publishers/anthropic/models/" + model + ":streamRawPredict"
+	case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		if model != "" && !strings.Contains(model, "/") {
+			m["model"] = geminiPublisherPrefix + model
+		}
+		r.URL.Path = base + "/endpoints/openapi/chat/completions"
+	}
+	r.URL.RawPath = ""
+
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		encoded = body
+	}
+	r.Body = io.NopCloser(bytes.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(encoded)), nil }
+}
+
+// ProjectFromEnv returns the project named by GOOGLE_CLOUD_PROJECT or
+// GCLOUD_PROJECT, or "" if neither is set.
+func ProjectFromEnv() string { return firstEnv(EnvProject, EnvProjectAlt) }
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+func newTestServer(t *testing.T, sse string) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	got := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.header = r.Header.Clone()
+		defer r.Body.Close()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got.body))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, sse)
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+func newTestProvider(serverURL string) *Provider {
+	return New(
+		WithProject("my-project"),
+		WithRegion("us-east5"),
+		WithTokenSource(StaticToken("ya29.test")),
+		WithLLMOptions(llm.WithBaseURL(serverURL)),
+	)
+}
+
+func TestProvider_ResolveAliases(t *testing.T) {
+	p := New(WithProject("p"), WithTokenSource(StaticToken("t")))
+	assert.Equal(t, llm.ProviderNameVertex, p.Name())
+
+	m, err := p.Models().Resolve(llm.ModelDefault)
+	require.NoError(t, err)
+	assert.Equal(t, ModelGemini25Flash, m.ID)
+
+	m, err = p.Models().Resolve("sonnet")
+	require.NoError(t, err)
+	assert.Equal(t, ModelSonnet45, m.ID)
+
+	for _, m := range p.Models() {
+		require.NotNil(t, m.Pricing, m.ID)
+		assert.Positive(t, m.Pricing.Input, m.ID)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "https://europe-west1-aiplatform.googleapis.com", Endpoint("europe-west1"))
+	assert.Equal(t, "https://aiplatform.googleapis.com", Endpoint(RegionGlobal))
+}
+
+func TestNew_RegionAndProjectFromEnv(t *testing.T) {
+	t.Setenv(EnvProject, "env-project")
+	t.Setenv(EnvLocation, "")
+	t.Setenv(EnvLocationAlt, "")
+	t.Setenv(EnvClaudeRegion, "europe-west4")
+
+	p := New(WithTokenSource(StaticToken("t")))
+	assert.Equal(t, "env-project", p.Project())
+	assert.Equal(t, "europe-west4", p.Region())
+}
+
+func TestProvider_CreateStream_ClaudeRawPredict(t *testing.T) {
+	server, got := newTestServer(t,
+		"event: message_start\n"+
+			`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":1000000,"output_tokens":0}}}`+"\n\n"+
+			"event: content_block_start\n"+
+			`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`+"\n\n"+
+			"event: content_block_delta\n"+
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`+"\n\n"+
+			"event: content_block_stop\n"+
+			`data: {"type":"content_block_stop","index":0}`+"\n\n"+
+			"event: message_delta\n"+
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1000000}}`+"\n\n"+
+			"event: message_stop\n"+
+			`data: {"type":"message_stop"}`+"\n\n")
+
+	p := newTestProvider(server.URL)
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "sonnet",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:streamRawPredict", got.path)
+	assert.Equal(t, "Bearer ya29.test", got.header.Get("Authorization"))
+	assert.Empty(t, got.header.Get("anthropic-version"))
+	assert.Equal(t, anthropicVersion, got.body["anthropic_version"])
+	assert.NotContains(t, got.body, "model")
+
+	assert.Equal(t, "Hello", res.Text())
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason())
+}
+
+func TestProvider_CreateStream_GeminiOpenAICompatible(t *testing.T) {
+	server, got := newTestServer(t,
+		`data: {"id":"1","model":"google/gemini-2.5-flash","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}`+"\n\n"+
+			`data: {"id":"1","model":"google/gemini-2.5-flash","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`+"\n\n"+
+			`data: {"id":"1","model":"google/gemini-2.5-flash","choices":[],"usage":{"prompt_tokens":1000000,"completion_tokens":1000000}}`+"\n\n"+
+			"data: [DONE]\n\n")
+
+	p := newTestProvider(server.URL)
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "flash",
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+
+	assert.Equal(t, "/v1/projects/my-project/locations/us-east5/endpoints/openapi/chat/completions", got.path)
+	assert.Equal(t, "google/gemini-2.5-flash", got.body["model"])
+	assert.Equal(t, "Hi", res.Text())
+
+	require.Len(t, res.UsageRecords(), 1)
+	rec := res.UsageRecords()[0]
+	assert.Equal(t, llm.ProviderNameVertex, rec.Dims.Provider)
+	assert.Equal(t, 1000000, rec.Tokens.Count(usage.KindInput))
+	assert.InDelta(t, 0.30+2.50, rec.Cost.Total, 1e-9)
+}
+
+func TestProvider_CreateStream_MissingProject(t *testing.T) {
+	t.Setenv(EnvProject, "")
+	t.Setenv(EnvProjectAlt, "")
+
+	p := New(WithTokenSource(StaticToken("t")), WithLLMOptions(llm.WithBaseURL("http://127.0.0.1:0")))
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    ModelGemini25Flash,
+		Messages: llm.Messages{llm.User("hi")},
+	})
+	if err == nil {
+		err = llm.NewEventProcessor(context.Background(), stream).Result().Error()
+	}
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrBuildRequest)
+	assert.Contains(t, err.Error(), EnvProject)
+}
+
+// TestCostCalculator_TableRates checks Gemini models use the table rates and
+// Claude models the Anthropic catalog rates.
+func TestCostCalculator_TableRates(t *testing.T) {
+	catalog := usage.ForProvider(usage.CatalogAlias(llm.ProviderNameVertex, anthropicModelID))
+	assert.Empty(t, pricing.Shadowed(llm.ProviderNameVertex, catalog), "drop table entries the catalog prices")
+
+	tokens := usage.TokenItems{{Kind: usage.KindInput, Count: 1_000_000}, {Kind: usage.KindOutput, Count: 1_000}}
+	cost, ok := costCalculator.Calculate(llm.ProviderNameVertex, ModelSonnet45, tokens)
+	require.True(t, ok)
+	want, ok := usage.Default().Calculate(llm.ProviderNameAnthropic, "claude-sonnet-4-5-20250929", tokens)
+	require.True(t, ok)
+	assert.Equal(t, want, cost)
+
+	cost, ok = costCalculator.Calculate(llm.ProviderNameVertex, geminiPublisherPrefix+ModelGemini25Flash, tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 0.30, cost.Input, 1e-9)
+
+	for _, m := range allModels {
+		assert.NotNil(t, m.Pricing, m.ID)
+	}
+}
@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a RateLimiter. Zero fields are unlimited.
+type RateLimit struct {
+	// RequestsPerMinute caps the number of streams started per minute.
+	RequestsPerMinute int
+
+	// TokensPerMinute caps input plus output tokens per minute. Input tokens
+	// are estimated before the request is sent; the difference to the usage
+	// reported by the provider is charged once the stream ends.
+	TokensPerMinute int
+}
+
+// RateLimiter is a client-side token bucket limiter for requests and tokens
+// per minute. Each bucket holds up to one minute of budget, so a full minute
+// may be spent in a burst. A RateLimiter is safe for concurrent use; share one
+// between providers, Services and goroutines that draw on the same provider
+// quota.
+//
+// Callers that exceed the limit are queued in arrival order and wait until
+// the budget refills or their context is done.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewRateLimiter returns a RateLimiter enforcing l.
+func NewRateLimiter(l RateLimit) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		requests: newBucket(l.RequestsPerMinute, now),
+		tokens:   newBucket(l.TokensPerMinute, now),
+	}
+}
+
+// WithRateLimit limits every CreateStream of providers that share
+// llm.Options (Bedrock included) with l. Use RateLimitMiddleware for other
+// providers or to limit a whole Service.
+func WithRateLimit(l *RateLimiter) Option {
+	return func(o *Options) {
+		o.RateLimiter = l
+	}
+}
+
+// RateLimitMiddleware limits every CreateStream of the wrapped provider
+// with l.
+func RateLimitMiddleware(l *RateLimiter) Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return l.Stream(ctx, next.Name(), req, func(ctx context.Context) (Stream, error) {
+			return next.CreateStream(ctx, req)
+		})
+	})
+}
+
+// Wait blocks until one request and tokens tokens are available, or ctx is
+// done. A request for more tokens than TokensPerMinute waits for a full
+// bucket. On cancellation the reservation is returned and ctx's error is
+// returned.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	_, err := l.wait(ctx, tokens)
+	return err
+}
+
+// wait is Wait that also returns the tokens it reserved: tokens clamped to
+// the bucket capacity.
+func (l *RateLimiter) wait(ctx context.Context, tokens int) (int, error) {
+	if l == nil {
+		return 0, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	now := time.Now()
+	n := l.tokens.clamp(tokens)
+	delay := max(l.requests.reserve(now, 1), l.tokens.reserve(now, n))
+	l.mu.Unlock()
+
+	if err := sleepCtx(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.requests.refund(1)
+		l.tokens.refund(n)
+		l.mu.Unlock()
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// Charge draws tokens from the token budget without waiting; a negative
+// value returns budget. Use it to settle the difference between the
+// estimate passed to Wait and the actual usage.
+func (l *RateLimiter) Charge(tokens int) {
+	if l == nil || tokens == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if tokens < 0 {
+		l.tokens.refund(float64(-tokens))
+		return
+	}
+	l.tokens.reserve(time.Now(), float64(tokens))
+}
+
+// Stream waits for budget for req, calls create and charges the difference
+// between the usage the stream reports and the tokens Wait reserved. A nil l
+// calls create directly. A wait cut short by ctx returns a context cancelled
+// error for provider.
+func (l *RateLimiter) Stream(ctx context.Context, provider string, req Request, create func(ctx context.Context) (Stream, error)) (Stream, error) {
+	if l == nil {
+		return create(ctx)
+	}
+	reserved, err := l.wait(ctx, estimateRequestTokens(req))
+	if err != nil {
+		return nil, NewErrContextCancelled(provider, err)
+	}
+	stream, err := create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var actual int
+	return ObserveStream(ctx, stream, func(env Envelope) {
+		if ev, ok := env.Data.(*UsageUpdatedEvent); ok && !ev.Record.IsEstimate {
+			actual += ev.Record.Tokens.TotalInput() + ev.Record.Tokens.TotalOutput()
+		}
+	}, func() {
+		if actual > 0 {
+			l.Charge(actual - reserved)
+		}
+	}), nil
+}
+
+// estimateRequestTokens approximates the input tokens of req from its
+// message text, tool results and tool definitions.
+func estimateRequestTokens(req Request) int {
+	n := 0
+	for _, m := range req.Messages {
+		n += estimateTokens(m.Text())
+		for _, tr := range m.ToolResults() {
+			n += estimateTokens(tr.ToolOutput)
+		}
+	}
+	for _, t := range req.Tools {
+		n += estimateTokens(t.Name) + estimateTokens(t.Description)
+	}
+	return n
+}
+
+// bucket is a token bucket refilled continuously at perMinute per minute.
+// Its level goes negative while callers are queued on it. A nil bucket is
+// unlimited.
+type bucket struct {
+	capacity float64
+	rate     float64 // per nanosecond
+	level    float64
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	c := float64(perMinute)
+	return &bucket{capacity: c, rate: c / float64(time.Minute), level: c, last: now}
+}
+
+func (b *bucket) clamp(n int) float64 {
+	if b == nil {
+		return 0
+	}
+	return min(float64(n), b.capacity)
+}
+
+// reserve takes n from the bucket and returns how long the caller must wait
+// until the bucket is no longer in debt.
+func (b *bucket) reserve(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.level = min(b.capacity, b.level+float64(elapsed)*b.rate)
+		b.last = now
+	}
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.rate)
+}
+
+func (b *bucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	b.level = min(b.capacity, b.level+n)
+}
@@ -0,0 +1,122 @@
+package llm_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	l := llm.NewRateLimiter(llm.RateLimit{})
+	for range 100 {
+		require.NoError(t, l.Wait(context.Background(), 1_000_000))
+	}
+	var nilLimiter *llm.RateLimiter
+	require.NoError(t, nilLimiter.Wait(context.Background(), 1))
+}
+
+func TestRateLimiter_RequestsPerMinute(t *testing.T) {
+	l := llm.NewRateLimiter(llm.RateLimit{RequestsPerMinute: 2})
+	require.NoError(t, l.Wait(context.Background(), 0))
+	require.NoError(t, l.Wait(context.Background(), 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, 0), context.DeadlineExceeded)
+}
+
+func TestRateLimiter_TokensRefill(t *testing.T) {
+	// 6000 tokens per minute refill at 100 per second.
+	l := llm.NewRateLimiter(llm.RateLimit{TokensPerMinute: 6000})
+	require.NoError(t, l.Wait(context.Background(), 6000))
+
+	start := time.Now()
+	require.NoError(t, l.Wait(context.Background(), 5))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRateLimiter_CancelledWaitReturnsBudget(t *testing.T) {
+	l := llm.NewRateLimiter(llm.RateLimit{TokensPerMinute: 6000})
+	require.NoError(t, l.Wait(context.Background(), 6000))
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	require.ErrorIs(t, l.Wait(short, 6000), context.DeadlineExceeded)
+
+	// Without the refund the next caller would queue behind a full minute.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, l.Wait(ctx, 5))
+}
+
+func TestRateLimiter_ConcurrentWaiters(t *testing.T) {
+	l := llm.NewRateLimiter(llm.RateLimit{RequestsPerMinute: 10})
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- l.Wait(ctx, 0)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var ok int
+	for err := range errs {
+		if err == nil {
+			ok++
+		}
+	}
+	assert.Equal(t, 10, ok)
+}
+
+func TestRateLimitMiddleware_ChargesReportedUsage(t *testing.T) {
+	l := llm.NewRateLimiter(llm.RateLimit{TokensPerMinute: 100})
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 100, 10, 0),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, llm.RateLimitMiddleware(l))
+
+	require.NoError(t, drainCosted(context.Background(), t, p))
+
+	// The reported 110 tokens overdrew the minute's budget.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := drainCosted(ctx, t, p)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrContextCancelled)
+}
+
+func TestRateLimitMiddleware_ChargesAboveCapacity(t *testing.T) {
+	// 6000 tokens per minute refill at 100 per second. The prompt estimate
+	// of 8000 tokens only reserves the 6000 the bucket holds, so the
+	// remaining 2000 must be charged when usage is reported.
+	l := llm.NewRateLimiter(llm.RateLimit{TokensPerMinute: 6000})
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		costEvent("fake", "fake-model", 8000, 0, 0),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, llm.RateLimitMiddleware(l))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{
+		Model:    "fake-model",
+		Messages: llm.Messages{llm.User(strings.Repeat("x", 4*8000))},
+	})
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, 1), context.DeadlineExceeded)
+}
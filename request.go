@@ -3,6 +3,7 @@ package llm
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	llmtool "github.com/codewandler/llm/tool"
 )
@@ -130,6 +131,10 @@ func (t ApiType) Valid() bool {
 
 type StreamRequest = Request
 
+// MaxStopSequences is the number of stop sequences every provider accepts
+// (OpenAI Chat Completions allows four).
+const MaxStopSequences = 4
+
 // RequestMeta carries provider-specific request attribution metadata used by
 // OpenAI-compatible APIs such as OpenAI and OpenRouter.
 type RequestMeta struct {
@@ -196,11 +201,21 @@ type Request struct {
 	// increase diversity. Not supported by Anthropic.
 	TopK int `json:"top_k,omitempty"`
 
+	// Stop lists sequences that end generation when produced. At most
+	// MaxStopSequences entries are accepted. The OpenAI Responses API has no
+	// stop parameter; there it is dropped with a warning.
+	Stop []string `json:"stop,omitempty"`
+
 	// OutputFormat specifies the desired output format.
 	// Supported by OpenAI and Anthropic. When set to JSON, the model will
 	// be constrained to output valid JSON.
 	OutputFormat OutputFormat `json:"output_format,omitempty"`
 
+	// OutputSchema is a JSON schema the response must conform to. It implies
+	// OutputFormatJSON. Anthropic and Ollama enforce the schema; APIs
+	// without schema support fall back to JSON mode with a warning.
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+
 	// Tools is the set of tools the model may call during the response.
 	Tools []llmtool.Definition `json:"tools,omitempty"`
 
@@ -214,6 +229,13 @@ type Request struct {
 	// This is a mode selector (on/off/auto), not a depth control.
 	Thinking ThinkingMode `json:"thinking,omitempty"`
 
+	// ThinkingBudget sets the thinking token budget explicitly on providers
+	// with budget-based thinking (Anthropic budget_tokens, Bedrock
+	// reasoning_config). It enables thinking unless Thinking is ThinkingOff
+	// and takes precedence over the budget derived from Effort. Anthropic
+	// requires at least 1024 tokens. Zero leaves the budget to Effort.
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+
 	// RequestMeta carries OpenAI-compatible request attribution metadata.
 	RequestMeta *RequestMeta `json:"request_meta,omitempty"`
 
@@ -225,6 +247,30 @@ type Request struct {
 	// they support the requested API; otherwise they fall back to their default.
 	// The actual API used is always reported in RequestEvent.ResolvedApiType.
 	ApiTypeHint ApiType `json:"api_type_hint,omitempty"`
+
+	// FirstTokenDeadline bounds the time until the first delta when the
+	// request goes through a Service. A provider that misses it is cancelled
+	// and, when fallback is enabled, the next candidate is tried; the
+	// fallback is reported as a WarningFallbackApplied warning on the
+	// served stream. CreateStream blocks until the first delta, an error or
+	// the deadline. Zero disables the deadline.
+	FirstTokenDeadline time.Duration `json:"first_token_deadline,omitempty"`
+}
+
+// ValidateFor checks the request like Validate and against the catalog
+// capabilities of Model on provider: tools on a model without tool calling
+// and thinking on a model without reasoning fail with ErrUnsupportedFeature
+// before a network round-trip. Models unknown to the catalog are only
+// checked by Validate; see CheckCapabilities for the stricter check.
+func (o Request) ValidateFor(provider string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	c, ok := Capabilities(provider, o.Model)
+	if !ok {
+		return nil
+	}
+	return c.checkRequired(provider, o)
 }
 
 // Validate checks that the options are valid.
@@ -244,6 +290,17 @@ func (o Request) Validate() error {
 		return fmt.Errorf("invalid Thinking %q", o.Thinking)
 	}
 
+	if o.ThinkingBudget < 0 {
+		return errors.New("ThinkingBudget must be non-negative")
+	}
+	if o.ThinkingBudget > 0 && o.MaxTokens > 0 && o.ThinkingBudget >= o.MaxTokens {
+		return fmt.Errorf("ThinkingBudget %d must be less than MaxTokens %d", o.ThinkingBudget, o.MaxTokens)
+	}
+
+	if o.FirstTokenDeadline < 0 {
+		return fmt.Errorf("invalid FirstTokenDeadline %s: must not be negative", o.FirstTokenDeadline)
+	}
+
 	if !o.ApiTypeHint.Valid() {
 		return fmt.Errorf("invalid ApiTypeHint %q; valid values: auto, openai-chat, openai-responses, anthropic-messages", o.ApiTypeHint)
 	}
@@ -282,10 +339,23 @@ func (o Request) Validate() error {
 		return errors.New("TopK must be non-negative")
 	}
 
+	// Validate Stop
+	if len(o.Stop) > MaxStopSequences {
+		return fmt.Errorf("at most %d stop sequences are supported, got %d", MaxStopSequences, len(o.Stop))
+	}
+	for i, s := range o.Stop {
+		if s == "" {
+			return fmt.Errorf("stop[%d]: must not be empty", i)
+		}
+	}
+
 	// Validate OutputFormat
 	if o.OutputFormat != "" && o.OutputFormat != OutputFormatText && o.OutputFormat != OutputFormatJSON {
 		return fmt.Errorf("invalid OutputFormat %q; must be one of: text, json", o.OutputFormat)
 	}
+	if o.OutputSchema != nil && o.OutputFormat == OutputFormatText {
+		return errors.New("OutputSchema requires OutputFormat json")
+	}
 
 	// Validate ToolChoice
 	if o.ToolChoice != nil && len(o.Tools) == 0 {
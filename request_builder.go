@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"time"
 
 	"github.com/codewandler/llm/msg"
 	"github.com/codewandler/llm/tool"
@@ -71,6 +72,11 @@ func (b *RequestBuilder) Thinking(mode ThinkingMode) *RequestBuilder {
 	return b
 }
 
+func (b *RequestBuilder) ThinkingBudget(tokens int) *RequestBuilder {
+	b.req.ThinkingBudget = tokens
+	return b
+}
+
 func (b *RequestBuilder) Effort(level Effort) *RequestBuilder {
 	b.req.Effort = level
 	return b
@@ -112,6 +118,12 @@ func (b *RequestBuilder) OutputFormat(format OutputFormat) *RequestBuilder {
 	return b
 }
 
+// OutputSchema constrains the response to the JSON schema.
+func (b *RequestBuilder) OutputSchema(schema map[string]any) *RequestBuilder {
+	b.req.OutputSchema = schema
+	return b
+}
+
 // ApiTypeHint sets the preferred wire protocol. The provider honours it when
 // supported; falls back to its default otherwise.
 func (b *RequestBuilder) ApiTypeHint(t ApiType) *RequestBuilder {
@@ -124,6 +136,17 @@ func WithApiTypeHint(t ApiType) RequestOption {
 	return func(r *Request) { r.ApiTypeHint = t }
 }
 
+// FirstTokenDeadline sets Request.FirstTokenDeadline.
+func (b *RequestBuilder) FirstTokenDeadline(d time.Duration) *RequestBuilder {
+	b.req.FirstTokenDeadline = d
+	return b
+}
+
+// WithFirstTokenDeadline sets Request.FirstTokenDeadline.
+func WithFirstTokenDeadline(d time.Duration) RequestOption {
+	return func(r *Request) { r.FirstTokenDeadline = d }
+}
+
 // TopK sets the top-k parameter for sampling.
 func (b *RequestBuilder) TopK(k int) *RequestBuilder {
 	b.req.TopK = k
@@ -135,6 +158,12 @@ func (b *RequestBuilder) TopP(p float64) *RequestBuilder {
 	return b
 }
 
+// Stop sets the sequences that end generation.
+func (b *RequestBuilder) Stop(seqs ...string) *RequestBuilder {
+	b.req.Stop = append([]string(nil), seqs...)
+	return b
+}
+
 func (b *RequestBuilder) Coding() *RequestBuilder {
 	return b.Thinking(ThinkingOn).
 		Effort(EffortHigh).
@@ -199,6 +228,10 @@ func WithThinking(mode ThinkingMode) RequestOption {
 	return func(r *Request) { r.Thinking = mode }
 }
 
+func WithThinkingBudget(tokens int) RequestOption {
+	return func(r *Request) { r.ThinkingBudget = tokens }
+}
+
 func WithEffort(level Effort) RequestOption {
 	return func(r *Request) { r.Effort = level }
 }
@@ -231,6 +264,11 @@ func WithOutputFormat(f OutputFormat) RequestOption {
 	return func(r *Request) { r.OutputFormat = f }
 }
 
+// WithOutputSchema constrains the response to the JSON schema.
+func WithOutputSchema(schema map[string]any) RequestOption {
+	return func(r *Request) { r.OutputSchema = schema }
+}
+
 func WithTopK(k int) RequestOption {
 	return func(r *Request) { r.TopK = k }
 }
@@ -239,6 +277,10 @@ func WithTopP(p float64) RequestOption {
 	return func(r *Request) { r.TopP = p }
 }
 
+func WithStop(seqs ...string) RequestOption {
+	return func(r *Request) { r.Stop = append([]string(nil), seqs...) }
+}
+
 // WithSystem appends a system message. Same cache nil-guard semantics as
 // the fluent System method: omitting cache leaves CacheHint nil.
 func WithSystem(text string, cache ...CacheOpt) RequestOption {
@@ -27,6 +27,17 @@ const (
 	StopReasonUnknown StopReason = ""
 )
 
+// Truncated reports whether the output was cut off before the model
+// finished: by the length limit, a content filter or cancellation.
+func (s StopReason) Truncated() bool {
+	switch s {
+	case StopReasonMaxTokens, StopReasonContentFilter, StopReasonCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 type Response interface {
 	Message() msg.Message
 	Text() string
@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryOptions configures automatic retries of transient HTTP failures.
+// Zero fields take the values from DefaultRetryOptions.
+type RetryOptions struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. Each further retry
+	// multiplies it by Multiplier, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Jitter randomises each delay by ±Jitter (a fraction between 0 and 1)
+	// so that concurrent clients do not retry in lockstep.
+	Jitter float64
+
+	// RetryStatus reports whether a response status is worth retrying.
+	// Defaults to 408, 409, 429 and 5xx.
+	RetryStatus func(status int) bool
+}
+
+// DefaultRetryOptions returns the retry settings used for zero fields.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryStatus:    defaultRetryStatus,
+	}
+}
+
+func defaultRetryStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+func (r RetryOptions) withDefaults() RetryOptions {
+	d := DefaultRetryOptions()
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = d.MaxAttempts
+	}
+	if r.InitialBackoff <= 0 {
+		r.InitialBackoff = d.InitialBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = d.MaxBackoff
+	}
+	if r.Multiplier < 1 {
+		r.Multiplier = d.Multiplier
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		r.Jitter = d.Jitter
+	}
+	if r.RetryStatus == nil {
+		r.RetryStatus = d.RetryStatus
+	}
+	return r
+}
+
+// Backoff returns the delay before retry number n (1-based), without jitter.
+func (r RetryOptions) Backoff(n int) time.Duration {
+	r = r.withDefaults()
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(n-1))
+	if d > float64(r.MaxBackoff) {
+		return r.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+func (r RetryOptions) jittered(d time.Duration) time.Duration {
+	if r.Jitter == 0 {
+		return d
+	}
+	f := 1 + r.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * f)
+}
+
+// WithRetry enables automatic retries of transient HTTP failures (429, 5xx
+// and connection errors) for providers that share llm.Options. Requests are
+// retried before any event is streamed, so callers only see the final
+// outcome. A Retry-After delay longer than MaxBackoff is not waited out; the
+// response is returned instead so that llm.Service can fail over.
+//
+// Bedrock is not affected; the AWS SDK applies its own retry policy.
+func WithRetry(r RetryOptions) Option {
+	return func(o *Options) {
+		r := r
+		o.Retry = &r
+	}
+}
+
+// RetryHTTPClient returns a shallow copy of c whose transport retries
+// transient failures according to r. A nil c uses DefaultHttpClient.
+func RetryHTTPClient(c *http.Client, r RetryOptions) *http.Client {
+	if c == nil {
+		c = DefaultHttpClient()
+	}
+	out := *c
+	out.Transport = NewRetryTransport(c.Transport, r)
+	return &out
+}
+
+// NewRetryTransport wraps next (http.DefaultTransport when nil) with retries
+// according to r.
+func NewRetryTransport(next http.RoundTripper, r RetryOptions) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{next: next, opts: r.withDefaults()}
+}
+
+type retryTransport struct {
+	next http.RoundTripper
+	opts RetryOptions
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if IsDryRun(req.Context()) {
+		return nil, ErrDryRun
+	}
+	getBody, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= t.opts.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var delay time.Duration
+		if err != nil {
+			delay = t.opts.jittered(t.opts.Backoff(attempt))
+		} else {
+			retry, wait := t.shouldRetry(resp, attempt)
+			if !retry {
+				return resp, nil
+			}
+			delay = wait
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry decides whether resp warrants another attempt and how long to
+// wait first. Upstream hints (x-should-retry, Retry-After) take precedence
+// over the status-based policy.
+func (t *retryTransport) shouldRetry(resp *http.Response, attempt int) (bool, time.Duration) {
+	switch strings.ToLower(resp.Header.Get("x-should-retry")) {
+	case "false":
+		return false, 0
+	case "true":
+	default:
+		if !t.opts.RetryStatus(resp.StatusCode) {
+			return false, 0
+		}
+	}
+	if wait, ok := ParseRetryAfter(resp.Header, time.Now()); ok {
+		if wait > t.opts.MaxBackoff {
+			return false, 0
+		}
+		return true, wait
+	}
+	return true, t.opts.jittered(t.opts.Backoff(attempt))
+}
+
+// ParseRetryAfter extracts the server-requested delay from response headers.
+// It understands retry-after-ms (milliseconds, sent by OpenAI-compatible
+// APIs) and Retry-After in both delay-seconds and HTTP-date form.
+func ParseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := strings.TrimSpace(h.Get("retry-after-ms")); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// replayableBody returns a function producing a fresh copy of the request
+// body for each retry, buffering the body once if the request has no GetBody.
+func replayableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
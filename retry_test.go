@@ -0,0 +1,152 @@
+package llm_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+)
+
+var fastRetry = llm.RetryOptions{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     50 * time.Millisecond,
+}
+
+// statusSequence serves the given statuses in order, then 200 for every
+// further request. It records the request bodies it received.
+func statusSequence(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32, *[]string) {
+	t.Helper()
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			for k, vs := range header {
+				w.Header()[k] = vs
+			}
+			w.WriteHeader(statuses[n-1])
+			_, _ = io.WriteString(w, "error")
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &bodies
+}
+
+func post(t *testing.T, c *http.Client, url string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, io.NopCloser(strings.NewReader(`{"q":1}`)))
+	require.NoError(t, err)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestRetryHTTPClient_RetriesTransientStatuses(t *testing.T) {
+	srv, calls, bodies := statusSequence(t, nil, 429, 503)
+	resp := post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, []string{`{"q":1}`, `{"q":1}`, `{"q":1}`}, *bodies, "body is replayed on every attempt")
+}
+
+func TestRetryHTTPClient_ReturnsLastResponseWhenExhausted(t *testing.T) {
+	srv, calls, _ := statusSequence(t, nil, 500, 500, 500, 500)
+	resp := post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "error", string(b))
+}
+
+func TestRetryHTTPClient_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls, _ := statusSequence(t, nil, 400)
+	resp := post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryHTTPClient_HonoursShouldRetryHeader(t *testing.T) {
+	srv, calls, _ := statusSequence(t, http.Header{"X-Should-Retry": {"false"}}, 503)
+	resp := post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	srv, calls, _ = statusSequence(t, http.Header{"X-Should-Retry": {"true"}}, 400)
+	resp = post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryHTTPClient_RetryAfterBeyondMaxBackoffIsNotWaited(t *testing.T) {
+	srv, calls, _ := statusSequence(t, http.Header{"Retry-After": {"120"}}, 429)
+	start := time.Now()
+	resp := post(t, llm.RetryHTTPClient(srv.Client(), fastRetry), srv.URL)
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryHTTPClient_StopsOnContextCancel(t *testing.T) {
+	srv, calls, _ := statusSequence(t, http.Header{"Retry-After": {"1"}}, 503, 503)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	c := llm.RetryHTTPClient(srv.Client(), llm.RetryOptions{MaxAttempts: 3, MaxBackoff: 5 * time.Second})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader("x"))
+	require.NoError(t, err)
+	_, err = c.Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"fractional seconds", http.Header{"Retry-After": {"0.5"}}, 500 * time.Millisecond, true},
+		{"http date", http.Header{"Retry-After": {now.Add(10 * time.Second).Format(http.TimeFormat)}}, 10 * time.Second, true},
+		{"past date", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, true},
+		{"milliseconds wins", http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"3"}}, 250 * time.Millisecond, true},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := llm.ParseRetryAfter(tt.header, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryOptions_Backoff(t *testing.T) {
+	r := llm.RetryOptions{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	assert.Equal(t, 100*time.Millisecond, r.Backoff(1))
+	assert.Equal(t, 300*time.Millisecond, r.Backoff(2))
+	assert.Equal(t, 900*time.Millisecond, r.Backoff(3))
+	assert.Equal(t, time.Second, r.Backoff(4))
+}
@@ -0,0 +1,365 @@
+package llm
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codewandler/llm/catalog"
+	modelcatalog "github.com/codewandler/llm/internal/modelcatalog"
+)
+
+// Virtual model names understood by Service without any configuration. A
+// provider that lists one of them as a model ID or alias, such as
+// OpenRouter's "auto", keeps it: the built-in virtual model only applies
+// when no provider claims the name.
+const (
+	ModelAuto          = "auto"
+	ModelCheapest      = "cheapest"
+	ModelCheapestTools = "cheapest-tools"
+)
+
+// ModelSort orders the candidates of a virtual model.
+type ModelSort string
+
+const (
+	// ModelSortDefault keeps preference and registration order and, within a
+	// provider, prefers its default model, then the highest wire model ID.
+	ModelSortDefault ModelSort = ""
+	// ModelSortPrice prefers the lowest input+output price. Models without
+	// known pricing sort last.
+	ModelSortPrice ModelSort = "price"
+	// ModelSortContext prefers the largest context window.
+	ModelSortContext ModelSort = "context"
+)
+
+// ModelConstraints describe which configured models a virtual model may
+// resolve to. Zero values do not constrain.
+type ModelConstraints struct {
+	MinContext int
+	MinOutput  int
+	Tools      bool
+	Vision     bool
+	Reasoning  bool
+	ServiceIDs []string
+	// Match is a path.Match pattern applied to the wire model ID,
+	// e.g. "claude-sonnet-*".
+	Match string
+	Sort  ModelSort
+}
+
+// DefaultVirtualModels returns the virtual models every Service starts with.
+func DefaultVirtualModels() map[string]ModelConstraints {
+	return map[string]ModelConstraints{
+		ModelAuto:          {},
+		ModelCheapest:      {Sort: ModelSortPrice},
+		ModelCheapestTools: {Tools: true, Sort: ModelSortPrice},
+	}
+}
+
+// WithVirtualModel registers (or overrides) a virtual model name that is
+// resolved against the configured providers at request time.
+func WithVirtualModel(name string, c ModelConstraints) ServiceOption {
+	return func(cfg *ServiceConfig) {
+		if cfg.VirtualModels == nil {
+			cfg.VirtualModels = map[string]ModelConstraints{}
+		}
+		cfg.VirtualModels[name] = c
+	}
+}
+
+// ParseModelConstraints parses the query part of a virtual model reference
+// such as "auto?min_context=200k&tools=true" into c. Recognised keys are
+// min_context, min_output, tools, vision, reasoning, provider (comma
+// separated), match and sort.
+func ParseModelConstraints(query string, c ModelConstraints) (ModelConstraints, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return c, fmt.Errorf("invalid model constraints %q: %w", query, err)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := values.Get(k)
+		switch k {
+		case "min_context":
+			c.MinContext, err = parseTokenCount(v)
+		case "min_output":
+			c.MinOutput, err = parseTokenCount(v)
+		case "tools":
+			c.Tools, err = strconv.ParseBool(v)
+		case "vision":
+			c.Vision, err = strconv.ParseBool(v)
+		case "reasoning":
+			c.Reasoning, err = strconv.ParseBool(v)
+		case "provider":
+			c.ServiceIDs = nil
+			for _, id := range strings.Split(v, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					c.ServiceIDs = append(c.ServiceIDs, id)
+				}
+			}
+		case "match":
+			if _, err = path.Match(v, ""); err == nil {
+				c.Match = v
+			}
+		case "sort":
+			switch s := ModelSort(v); s {
+			case ModelSortDefault, ModelSortPrice, ModelSortContext:
+				c.Sort = s
+			default:
+				err = fmt.Errorf("unknown sort %q", v)
+			}
+		default:
+			err = fmt.Errorf("unknown constraint")
+		}
+		if err != nil {
+			return c, fmt.Errorf("invalid model constraint %s=%q: %w", k, v, err)
+		}
+	}
+	return c, nil
+}
+
+// parseTokenCount parses counts like "8192", "200k" or "1m".
+func parseTokenCount(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	mult := 1
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1_000, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		mult, s = 1_000_000, strings.TrimSuffix(s, "m")
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid token count")
+	}
+	return n * mult, nil
+}
+
+// virtualCandidate is one model a configured provider can serve, with the
+// catalog metadata needed to evaluate constraints.
+type virtualCandidate struct {
+	provider  RegisteredProvider
+	order     int
+	wireModel string
+	isDefault bool
+	context   int
+	output    int
+	tools     bool
+	vision    bool
+	reasoning bool
+	price     float64
+	hasPrice  bool
+}
+
+// virtualRef is a model reference that resolves by constraint matching.
+type virtualRef struct {
+	name        string
+	instance    string
+	constraints ModelConstraints
+}
+
+// virtualModelRef reports whether model (after intent expansion) is a virtual
+// model name or wildcard, optionally followed by "?constraints".
+func (s *Service) virtualModelRef(model string) (virtualRef, bool, error) {
+	base, query, hasQuery := strings.Cut(model, "?")
+	instance, serviceID, requested := s.parseModelRef(base)
+	ref := virtualRef{instance: instance}
+	virtual, isVirtual := s.virtual[requested]
+	if isVirtual && !hasQuery && !s.configured[requested] && s.providerOffers(requested) {
+		isVirtual = false
+	}
+	switch {
+	case instance == "" && serviceID == "" && isVirtual:
+		ref.name, ref.constraints = requested, virtual
+	case strings.Contains(requested, "*"):
+		if _, err := path.Match(requested, ""); err != nil {
+			return virtualRef{}, false, fmt.Errorf("invalid model pattern %q: %w", requested, err)
+		}
+		ref.name, ref.constraints = base, ModelConstraints{Match: requested}
+		if serviceID != "" {
+			ref.constraints.ServiceIDs = []string{serviceID}
+		}
+	case hasQuery:
+		return virtualRef{}, false, fmt.Errorf("model constraints require a virtual model or wildcard, got %q", base)
+	default:
+		return virtualRef{}, false, nil
+	}
+	if hasQuery {
+		c, err := ParseModelConstraints(query, ref.constraints)
+		if err != nil {
+			return virtualRef{}, false, err
+		}
+		ref.constraints = c
+	}
+	return ref, true, nil
+}
+
+// providerOffers reports whether a provider lists model as a model ID or
+// alias.
+func (s *Service) providerOffers(model string) bool {
+	for i := range s.providers {
+		models := s.providerModels(i)
+		if _, ok := models.ByID(model); ok {
+			return true
+		}
+		if _, ok := models.ByAlias(model); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) resolveVirtualModel(resolved ResolvedModelSpec, ref virtualRef) (ResolvedModelSpec, error) {
+	instance, c := ref.instance, ref.constraints
+	if instance != "" && !s.hasProviderName(instance) {
+		return ResolvedModelSpec{}, fmt.Errorf("provider instance %q not configured", instance)
+	}
+	resolved.FromVirtual = ref.name
+	weights := s.preferenceWeights(resolved)
+
+	var matches []virtualCandidate
+	for _, cand := range s.virtualCandidates() {
+		if instance != "" && cand.provider.Name != instance {
+			continue
+		}
+		if c.matches(cand) {
+			matches = append(matches, cand)
+		}
+	}
+	if len(matches) == 0 {
+		return ResolvedModelSpec{}, fmt.Errorf("%w: no configured model satisfies %q", ErrUnknownModel, resolved.RawModel)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch c.Sort {
+		case ModelSortPrice:
+			if a.hasPrice != b.hasPrice {
+				return a.hasPrice
+			}
+			if a.price != b.price {
+				return a.price < b.price
+			}
+		case ModelSortContext:
+			if a.context != b.context {
+				return a.context > b.context
+			}
+		}
+		wa, wb := weights[providerCandidateKey(a.provider)], weights[providerCandidateKey(b.provider)]
+		if wa != wb {
+			return wa > wb
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		if a.isDefault != b.isDefault {
+			return a.isDefault
+		}
+		return a.wireModel > b.wireModel
+	})
+
+	best := matches[0]
+	resolved.ExactName = instance
+	resolved.ExactServiceID = best.provider.ServiceID
+	resolved.RequestedModel = best.wireModel
+	resolved.Offerings = []OfferingCandidate{{ServiceID: best.provider.ServiceID, WireModel: best.wireModel, Source: "virtual"}}
+	return resolved, nil
+}
+
+func (c ModelConstraints) matches(cand virtualCandidate) bool {
+	if len(c.ServiceIDs) > 0 && !containsString(c.ServiceIDs, cand.provider.ServiceID) {
+		return false
+	}
+	if c.Match != "" {
+		if ok, _ := path.Match(c.Match, cand.wireModel); !ok {
+			return false
+		}
+	}
+	if c.MinContext > 0 && cand.context < c.MinContext {
+		return false
+	}
+	if c.MinOutput > 0 && cand.output < c.MinOutput {
+		return false
+	}
+	if c.Tools && !cand.tools {
+		return false
+	}
+	if c.Vision && !cand.vision {
+		return false
+	}
+	if c.Reasoning && !cand.reasoning {
+		return false
+	}
+	return true
+}
+
+// virtualCandidates lists every model the configured providers can serve.
+// Catalog offerings carry limits, capabilities and pricing; models only known
+// from Provider.Models() are included without metadata and so only satisfy
+// constraints that do not depend on it.
+func (s *Service) virtualCandidates() []virtualCandidate {
+	cat, err := modelcatalog.Load()
+	catalogOK := err == nil
+
+	var out []virtualCandidate
+	for order, p := range s.providers {
+		models := s.providerModels(order)
+		defaultID := ""
+		if m, ok := models.ByAlias(ModelDefault); ok {
+			defaultID = m.ID
+		}
+		seen := map[string]struct{}{}
+		if catalogOK {
+			for _, serviceID := range modelcatalog.LookupServices(p.ServiceID) {
+				for _, offering := range cat.OfferingsByService(serviceID) {
+					if _, dup := seen[offering.WireModelID]; dup {
+						continue
+					}
+					rec, ok := cat.ModelByKey(offering.ModelKey)
+					if !ok || rec.Deprecated {
+						continue
+					}
+					seen[offering.WireModelID] = struct{}{}
+					out = append(out, catalogCandidate(p, order, catalog.FromOffering(offering, rec), defaultID))
+				}
+			}
+		}
+		for _, m := range models {
+			if _, dup := seen[m.ID]; dup || m.ID == "" {
+				continue
+			}
+			seen[m.ID] = struct{}{}
+			cand := virtualCandidate{provider: p, order: order, wireModel: m.ID, isDefault: m.ID == defaultID}
+			if m.Pricing != nil {
+				cand.price, cand.hasPrice = m.Pricing.Input+m.Pricing.Output, true
+			}
+			out = append(out, cand)
+		}
+	}
+	return out
+}
+
+func catalogCandidate(p RegisteredProvider, order int, m catalog.Model, defaultID string) virtualCandidate {
+	cand := virtualCandidate{
+		provider:  p,
+		order:     order,
+		wireModel: m.ID,
+		isDefault: m.ID == defaultID,
+		context:   m.ContextWindow,
+		output:    m.MaxOutput,
+		tools:     m.ToolCall,
+		vision:    m.Vision,
+		reasoning: m.Reasoning,
+	}
+	if m.Pricing != nil {
+		cand.price, cand.hasPrice = m.Pricing.Input+m.Pricing.Output, true
+	}
+	return cand
+}
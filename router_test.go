@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm/usage"
+)
+
+func TestServiceVirtualModel_AutoPrefersProviderDefault(t *testing.T) {
+	p := serviceTestProvider{name: "fake", models: Models{
+		{ID: "fake-large", Provider: "fake"},
+		{ID: "fake-small", Provider: "fake", Aliases: []string{ModelDefault}},
+	}, stream: completedStream}
+	svc, err := New(WithRegisteredProvider(RegisteredProvider{ServiceID: "fake", Provider: p}))
+	require.NoError(t, err)
+
+	resolved, candidates, err := svc.ExplainModel(ModelAuto)
+	require.NoError(t, err)
+	assert.Equal(t, ModelAuto, resolved.FromVirtual)
+	assert.Equal(t, "fake-small", resolved.RequestedModel)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "fake", candidates[0].ServiceID)
+}
+
+func TestServiceVirtualModel_CheapestUsesPricing(t *testing.T) {
+	a := serviceTestProvider{name: "a", models: Models{
+		{ID: "a-pricey", Pricing: &usage.Pricing{Input: 3, Output: 15}},
+		{ID: "a-unpriced"},
+	}, stream: completedStream}
+	b := serviceTestProvider{name: "b", models: Models{
+		{ID: "b-cheap", Pricing: &usage.Pricing{Input: 0.1, Output: 0.4}},
+	}, stream: completedStream}
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "a", Provider: a}),
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "b", Provider: b}),
+	)
+	require.NoError(t, err)
+
+	resolved, _, err := svc.ExplainModel(ModelCheapest)
+	require.NoError(t, err)
+	assert.Equal(t, "b", resolved.ExactServiceID)
+	assert.Equal(t, "b-cheap", resolved.RequestedModel)
+
+	resolved, _, err = svc.ExplainModel("auto?provider=a&sort=price")
+	require.NoError(t, err)
+	assert.Equal(t, "a-pricey", resolved.RequestedModel)
+}
+
+func TestServiceVirtualModel_CatalogConstraints(t *testing.T) {
+	p := serviceTestProvider{name: "anthropic", models: nil, stream: completedStream}
+	svc, err := New(WithRegisteredProvider(RegisteredProvider{ServiceID: "anthropic", Provider: p}))
+	require.NoError(t, err)
+
+	resolved, _, err := svc.ExplainModel("auto?min_context=200k&tools=true")
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", resolved.ExactServiceID)
+	assert.NotEmpty(t, resolved.RequestedModel)
+
+	_, _, err = svc.ExplainModel("auto?min_context=1000m")
+	assert.ErrorIs(t, err, ErrUnknownModel)
+}
+
+func TestServiceVirtualModel_Wildcard(t *testing.T) {
+	a := serviceTestProvider{name: "a", models: Models{{ID: "chat-1"}, {ID: "chat-2"}, {ID: "embed-1"}}, stream: completedStream}
+	b := serviceTestProvider{name: "b", models: Models{{ID: "chat-9"}}, stream: completedStream}
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "a", Provider: a}),
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "b", Provider: b}),
+	)
+	require.NoError(t, err)
+
+	resolved, _, err := svc.ExplainModel("chat-*")
+	require.NoError(t, err)
+	assert.Equal(t, "a", resolved.ExactServiceID)
+	assert.Equal(t, "chat-2", resolved.RequestedModel)
+
+	resolved, _, err = svc.ExplainModel("b/chat-*")
+	require.NoError(t, err)
+	assert.Equal(t, "b", resolved.ExactServiceID)
+	assert.Equal(t, "chat-9", resolved.RequestedModel)
+}
+
+func TestServiceVirtualModel_ConfiguredAndIntent(t *testing.T) {
+	p := serviceTestProvider{name: "fake", models: Models{
+		{ID: "fake-1", Pricing: &usage.Pricing{Input: 1, Output: 1}},
+		{ID: "fake-2", Pricing: &usage.Pricing{Input: 2, Output: 2}},
+	}, stream: completedStream}
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "fake", Provider: p}),
+		WithVirtualModel("budget", ModelConstraints{Sort: ModelSortPrice}),
+		WithIntentAlias("smart", IntentSelector{Model: "auto?match=fake-2"}),
+	)
+	require.NoError(t, err)
+
+	resolved, _, err := svc.ExplainModel("budget")
+	require.NoError(t, err)
+	assert.Equal(t, "budget", resolved.FromVirtual)
+	assert.Equal(t, "fake-1", resolved.RequestedModel)
+
+	resolved, _, err = svc.ExplainModel("smart")
+	require.NoError(t, err)
+	assert.Equal(t, "smart", resolved.FromIntent)
+	assert.Equal(t, "fake-2", resolved.RequestedModel)
+}
+
+func TestServiceVirtualModel_ExplicitServiceRefPassesThrough(t *testing.T) {
+	p := serviceTestProvider{name: "openrouter", models: Models{{ID: "openrouter/auto", Aliases: []string{ModelAuto}}}, stream: completedStream}
+	svc, err := New(WithRegisteredProvider(RegisteredProvider{ServiceID: "openrouter", Provider: p}))
+	require.NoError(t, err)
+
+	resolved, _, err := svc.ExplainModel("openrouter/auto")
+	require.NoError(t, err)
+	assert.Empty(t, resolved.FromVirtual)
+	assert.Equal(t, "auto", resolved.RequestedModel)
+}
+
+func TestServiceVirtualModel_InvalidReferences(t *testing.T) {
+	p := serviceTestProvider{name: "fake", models: Models{{ID: "fake-1"}}, stream: completedStream}
+	svc, err := New(WithRegisteredProvider(RegisteredProvider{ServiceID: "fake", Provider: p}))
+	require.NoError(t, err)
+
+	for _, model := range []string{
+		"fake-1?tools=true",
+		"auto?bogus=1",
+		"auto?min_context=lots",
+		"auto?sort=random",
+		"fake-[",
+	} {
+		_, _, err := svc.ExplainModel(model)
+		assert.Error(t, err, model)
+	}
+}
+
+func TestParseModelConstraints(t *testing.T) {
+	c, err := ParseModelConstraints("min_context=200k&min_output=8192&tools=true&vision=1&reasoning=true&provider=anthropic,openai&match=claude-*&sort=context", ModelConstraints{})
+	require.NoError(t, err)
+	assert.Equal(t, ModelConstraints{
+		MinContext: 200_000,
+		MinOutput:  8192,
+		Tools:      true,
+		Vision:     true,
+		Reasoning:  true,
+		ServiceIDs: []string{"anthropic", "openai"},
+		Match:      "claude-*",
+		Sort:       ModelSortContext,
+	}, c)
+
+	c, err = ParseModelConstraints("min_context=1m", ModelConstraints{Tools: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1_000_000, c.MinContext)
+	assert.True(t, c.Tools, "base constraints are kept")
+}
+
+func TestServiceVirtualModel_ProviderAliasWins(t *testing.T) {
+	or := serviceTestProvider{name: "openrouter", models: Models{{ID: "openrouter/auto", Aliases: []string{ModelAuto}}}, stream: completedStream}
+	fake := serviceTestProvider{name: "fake", models: Models{{ID: "fake-1", Aliases: []string{ModelDefault}}}, stream: completedStream}
+
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "fake", Provider: fake}),
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "openrouter", Provider: or}),
+	)
+	require.NoError(t, err)
+	resolved, candidates, err := svc.ExplainModel(ModelAuto)
+	require.NoError(t, err)
+	assert.Empty(t, resolved.FromVirtual, "OpenRouter's auto alias keeps working")
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "openrouter", candidates[0].ServiceID)
+
+	// Constraints and explicit configuration still select the virtual model.
+	resolved, _, err = svc.ExplainModel(ModelAuto + "?tools=false")
+	require.NoError(t, err)
+	assert.Equal(t, ModelAuto, resolved.FromVirtual)
+
+	svc, err = New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "fake", Provider: fake}),
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "openrouter", Provider: or}),
+		WithVirtualModel(ModelAuto, ModelConstraints{ServiceIDs: []string{"fake"}}),
+	)
+	require.NoError(t, err)
+	resolved, _, err = svc.ExplainModel(ModelAuto)
+	require.NoError(t, err)
+	assert.Equal(t, ModelAuto, resolved.FromVirtual)
+	assert.Equal(t, "fake-1", resolved.RequestedModel)
+}
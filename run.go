@@ -0,0 +1,308 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+// DefaultMaxTurns is the turn limit Run applies when RunOptions.MaxTurns is 0.
+const DefaultMaxTurns = 10
+
+// ErrMaxTurns is returned by Run when the model is still requesting tools
+// after RunOptions.MaxTurns round-trips.
+var ErrMaxTurns = errors.New("max turns reached")
+
+// RunOptions configures Run.
+type RunOptions struct {
+	// MaxTurns bounds the number of model round-trips. A turn is one request
+	// and its streamed response. Defaults to DefaultMaxTurns.
+	MaxTurns int
+
+	// Dispatcher selects sync (default) or concurrent execution of the tool
+	// calls emitted in a single response.
+	Dispatcher tool.DispatcherType
+
+	// ToolOutput, if set, truncates oversized tool results before they are
+	// appended to the history.
+	ToolOutput *ToolOutputPolicy
+
+	// Executions, if set, records every tool call outcome by
+	// tool.ExecutionKey and replays recorded outcomes instead of running the
+	// handler again. Use a durable store to avoid duplicate side effects when
+	// a crashed run is resumed from its persisted history.
+	Executions tool.ExecutionStore
+
+	// OnEvent, if set, receives every stream event of every turn.
+	OnEvent EventHandler
+
+	// OnTurn, if set, is called after each turn with the 1-based turn number
+	// and that turn's Result, including executed tool results.
+	OnTurn func(turn int, res Result)
+}
+
+// RunResult is the outcome of an agent loop driven by Run.
+type RunResult struct {
+	// Messages is the full conversation: the request messages followed by
+	// every assistant and tool message produced during the run.
+	Messages Messages `json:"messages"`
+
+	// Turns is the number of model round-trips performed.
+	Turns int `json:"turns"`
+
+	// Text is the text output of the last turn.
+	Text string `json:"text,omitempty"`
+
+	// StopReason is the stop reason of the last turn.
+	StopReason StopReason `json:"stop_reason"`
+
+	// Usage holds the provider-reported usage records of all turns, in
+	// arrival order. Records without a Dims.TurnID get the 1-based turn
+	// number.
+	Usage []usage.Record `json:"usage,omitempty"`
+
+	// TurnDetails describes each turn, in order.
+	TurnDetails []RunTurn `json:"turn_details,omitempty"`
+}
+
+// RunTurn records one model round-trip of a Run.
+type RunTurn struct {
+	// Message is the index in RunResult.Messages of the assistant message
+	// the turn produced.
+	Message int `json:"message"`
+
+	// Model and Provider are taken from the StreamStartedEvent.
+	Model    string `json:"model,omitempty"`
+	Provider string `json:"provider,omitempty"`
+
+	StopReason StopReason `json:"stop_reason"`
+
+	// Usage holds the turn's usage records; they are also in RunResult.Usage.
+	Usage []usage.Record `json:"usage,omitempty"`
+
+	StartedAt time.Time `json:"started_at"`
+
+	// TimeToFirstToken is zero when no delta arrived.
+	TimeToFirstToken time.Duration `json:"time_to_first_token"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// TotalUsage sums all provider-reported usage records into one record.
+func (r *RunResult) TotalUsage() usage.Record { return sumUsage(r.Usage) }
+
+// Cost returns the total cost of the run in USD, or 0 when no record carries
+// pricing.
+func (r *RunResult) Cost() float64 { return r.TotalUsage().Cost.Total }
+
+// UsageByModel sums the usage records of all turns per model, keyed by
+// Dims.Model. Each sum keeps the model's Provider and Model dims.
+func (r *RunResult) UsageByModel() map[string]usage.Record {
+	byModel := make(map[string][]usage.Record)
+	for _, rec := range r.Usage {
+		byModel[rec.Dims.Model] = append(byModel[rec.Dims.Model], rec)
+	}
+	out := make(map[string]usage.Record, len(byModel))
+	for model, recs := range byModel {
+		sum := sumUsage(recs)
+		sum.Dims = usage.Dims{Provider: recs[0].Dims.Provider, Model: model}
+		out[model] = sum
+	}
+	return out
+}
+
+// TotalUsage sums the turn's usage records into one record.
+func (t RunTurn) TotalUsage() usage.Record { return sumUsage(t.Usage) }
+
+func sumUsage(recs []usage.Record) usage.Record {
+	t := usage.NewTracker()
+	for _, rec := range recs {
+		t.Record(rec)
+	}
+	return t.Aggregate()
+}
+
+// Run drives a tool-using conversation to completion. It sends src through
+// s, executes the tool calls of each response with the matching handler,
+// appends the assistant message and tool results to the history, and repeats
+// until the model stops for any reason other than StopReasonToolUse.
+//
+// Handlers that also carry a definition (such as *tool.BoundToolSpec from
+// tool.Handle) are added to the request's tools unless a tool with the same
+// name is already present. Calls to tools without a handler are answered with
+// an error result so the model can recover.
+//
+// If the history ends with an assistant message whose tool calls have no
+// results, as after a crash between receiving the calls and persisting their
+// results, those calls are executed before the first model request.
+//
+// Stream errors end the run and are returned alongside the partial
+// RunResult. When the model is still requesting tools after MaxTurns turns,
+// the error wraps ErrMaxTurns.
+//
+// Example:
+//
+//	res, err := llm.Run(ctx, svc, llm.Request{
+//	    Model:    "default",
+//	    Messages: llm.Messages{llm.User("What's the weather in Berlin?")},
+//	}, llm.RunOptions{}, tool.Handle(weatherSpec, getWeather))
+func Run(ctx context.Context, s Streamer, src Buildable, opts RunOptions, handlers ...tool.NamedHandler) (*RunResult, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Executions != nil {
+		handlers = idempotentHandlers(handlers, opts.Executions)
+	}
+	req.Tools = withHandlerDefinitions(req.Tools, handlers)
+
+	maxTurns := opts.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = DefaultMaxTurns
+	}
+
+	out := &RunResult{Messages: append(Messages(nil), req.Messages...)}
+	if pending := unansweredToolCalls(out.Messages); len(pending) > 0 {
+		m, err := runToolCalls(ctx, pending, opts, handlers)
+		if err != nil {
+			return out, err
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	for out.Turns < maxTurns {
+		req.Messages = out.Messages
+		turn := RunTurn{Message: len(out.Messages), StartedAt: time.Now()}
+		stream, err := s.CreateStream(ctx, req)
+		if err != nil {
+			return out, err
+		}
+		out.Turns++
+
+		proc := NewEventProcessor(ctx, stream).
+			HandleTool(handlers...).
+			WithToolDispatcher(opts.Dispatcher).
+			OnStart(func(ev *StreamStartedEvent) {
+				turn.Model = ev.Model
+				turn.Provider = ev.Provider
+			}).
+			OnDelta(func(*DeltaEvent) {
+				if turn.TimeToFirstToken == 0 {
+					turn.TimeToFirstToken = time.Since(turn.StartedAt)
+				}
+			})
+		if opts.OnEvent != nil {
+			proc.OnEvent(opts.OnEvent)
+		}
+		res := proc.Result()
+		turn.Duration = time.Since(turn.StartedAt)
+		turn.StopReason = res.StopReason()
+		turn.Usage = turnUsage(res.UsageRecords(), out.Turns)
+		out.TurnDetails = append(out.TurnDetails, turn)
+
+		next := res.Next()
+		if opts.ToolOutput != nil {
+			for i := range next {
+				if next[i], err = opts.ToolOutput.Apply(ctx, next[i]); err != nil {
+					return out, err
+				}
+			}
+		}
+		out.Messages = out.Messages.Append(next)
+		out.Text = res.Text()
+		out.StopReason = res.StopReason()
+		out.Usage = append(out.Usage, turn.Usage...)
+		if opts.OnTurn != nil {
+			opts.OnTurn(out.Turns, res)
+		}
+
+		if err := res.Error(); err != nil {
+			return out, err
+		}
+		if res.StopReason() != StopReasonToolUse || len(res.ToolCalls()) == 0 {
+			return out, nil
+		}
+	}
+	return out, fmt.Errorf("%w: %d", ErrMaxTurns, maxTurns)
+}
+
+// turnUsage copies recs and stamps records without a turn ID with turn.
+func turnUsage(recs []usage.Record, turn int) []usage.Record {
+	if len(recs) == 0 {
+		return nil
+	}
+	out := make([]usage.Record, len(recs))
+	for i, rec := range recs {
+		if rec.Dims.TurnID == "" {
+			rec.Dims.TurnID = strconv.Itoa(turn)
+		}
+		out[i] = rec
+	}
+	return out
+}
+
+// unansweredToolCalls returns the tool calls of the last message if it is an
+// assistant message, i.e. calls whose results were never appended.
+func unansweredToolCalls(msgs Messages) []tool.Call {
+	if len(msgs) == 0 || !msgs[len(msgs)-1].IsAssistant() {
+		return nil
+	}
+	var calls []tool.Call
+	for _, tc := range msgs[len(msgs)-1].ToolCalls() {
+		calls = append(calls, tool.NewToolCall(tc.ID, tc.Name, tool.Args(tc.Args)))
+	}
+	return calls
+}
+
+// runToolCalls dispatches calls outside a stream and returns the tool
+// message with their results.
+func runToolCalls(ctx context.Context, calls []tool.Call, opts RunOptions, handlers []tool.NamedHandler) (Message, error) {
+	hs := tool.NewHandlers(handlers...)
+	var d tool.Dispatcher = tool.NewSyncDispatcher(hs)
+	if opts.Dispatcher == tool.DispatchTypeAsync {
+		d = &tool.AsyncDispatcher{Handlers: hs}
+	}
+	results, err := d.Dispatch(ctx, calls...)
+	if err != nil {
+		return Message{}, err
+	}
+	m := toolResultsMessage(results)
+	if opts.ToolOutput != nil {
+		return opts.ToolOutput.Apply(ctx, m)
+	}
+	return m, nil
+}
+
+func idempotentHandlers(handlers []tool.NamedHandler, store tool.ExecutionStore) []tool.NamedHandler {
+	out := make([]tool.NamedHandler, len(handlers))
+	for i, h := range handlers {
+		out[i] = tool.Idempotent(h, store)
+	}
+	return out
+}
+
+// withHandlerDefinitions appends the definitions of spec-bound handlers that
+// are not already declared in defs.
+func withHandlerDefinitions(defs []tool.Definition, handlers []tool.NamedHandler) []tool.Definition {
+	seen := make(map[string]struct{}, len(defs))
+	for _, d := range defs {
+		seen[d.Name] = struct{}{}
+	}
+	out := defs[:len(defs):len(defs)]
+	for _, h := range handlers {
+		dh, ok := h.(interface{ Definition() tool.Definition })
+		if !ok {
+			continue
+		}
+		def := dh.Definition()
+		if _, dup := seen[def.Name]; dup {
+			continue
+		}
+		seen[def.Name] = struct{}{}
+		out = append(out, def)
+	}
+	return out
+}
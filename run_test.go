@@ -0,0 +1,261 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+	"github.com/codewandler/llm/usage"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type addResult struct {
+	Sum int `json:"sum"`
+}
+
+// scripted returns a Streamer that replays one event script per call and
+// records the requests it receives.
+func scripted(reqs *[]llm.Request, turns ...[]llm.Event) llm.StreamFunc {
+	return func(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		*reqs = append(*reqs, req)
+		i := len(*reqs) - 1
+		if i >= len(turns) {
+			i = len(turns) - 1
+		}
+		return llmtest.SendEvents(turns[i]...), nil
+	}
+}
+
+func TestRun_ExecutesToolsUntilFinalAnswer(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs,
+		[]llm.Event{
+			llmtest.ToolEvent("call-1", "add", map[string]any{"a": 2, "b": 3}),
+			llmtest.UsageTokenEvent("fake", "m", 10, 2),
+			llmtest.CompletedEvent(llm.StopReasonToolUse),
+		},
+		[]llm.Event{
+			llmtest.TextEvent("the sum is 5"),
+			llmtest.UsageTokenEvent("fake", "m", 20, 4),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		},
+	)
+
+	add := tool.Handle(tool.NewSpec[addParams]("add", "Add two numbers"), func(_ context.Context, in addParams) (*addResult, error) {
+		return &addResult{Sum: in.A + in.B}, nil
+	})
+
+	var turns []int
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("2+3?")},
+	}, llm.RunOptions{OnTurn: func(turn int, _ llm.Result) { turns = append(turns, turn) }}, add)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, res.Turns)
+	assert.Equal(t, []int{1, 2}, turns)
+	assert.Equal(t, "the sum is 5", res.Text)
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason)
+
+	require.Len(t, reqs, 2)
+	require.Len(t, reqs[0].Tools, 1, "bound spec definition should be sent")
+	assert.Equal(t, "add", reqs[0].Tools[0].Name)
+	require.Len(t, reqs[1].Messages, 3)
+	assert.Equal(t, msg.RoleAssistant, reqs[1].Messages[1].Role)
+	assert.Equal(t, msg.RoleTool, reqs[1].Messages[2].Role)
+
+	require.Len(t, res.Messages, 4)
+	results := res.Messages[2].ToolResults()
+	require.Len(t, results, 1)
+	assert.Equal(t, "call-1", results[0].ToolCallID)
+	assert.False(t, results[0].IsError)
+	assert.Contains(t, results[0].ToolOutput, `\"sum\":5`)
+
+	total := res.TotalUsage()
+	assert.Equal(t, 30, total.Tokens.Count(usage.KindInput))
+
+	require.Len(t, res.TurnDetails, 2)
+	assert.Equal(t, 1, res.TurnDetails[0].Message)
+	assert.Equal(t, llm.StopReasonToolUse, res.TurnDetails[0].StopReason)
+	require.Len(t, res.TurnDetails[0].Usage, 1)
+	assert.Equal(t, 3, res.TurnDetails[1].Message)
+	assert.Positive(t, res.TurnDetails[1].TimeToFirstToken)
+}
+
+func TestRun_MaxTurns(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs, []llm.Event{
+		llmtest.ToolEvent("call-1", "loop", map[string]any{}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	})
+
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("go")},
+	}, llm.RunOptions{MaxTurns: 3})
+	require.ErrorIs(t, err, llm.ErrMaxTurns)
+	assert.Equal(t, 3, res.Turns)
+	assert.Len(t, reqs, 3)
+
+	// Unhandled tools are answered with an error result.
+	results := res.Messages[2].ToolResults()
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsError)
+}
+
+func TestRun_StreamErrorStops(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs, []llm.Event{
+		llmtest.TextEvent("partial"),
+		llmtest.ErrorEvent(llm.NewErrProviderMsg("fake", "overloaded")),
+	})
+
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("go")},
+	}, llm.RunOptions{})
+	require.Error(t, err)
+	var pe *llm.ProviderError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, 1, res.Turns)
+	assert.Equal(t, "partial", res.Text)
+}
+
+func TestRun_ResumesUnansweredToolCalls(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs,
+		[]llm.Event{
+			llmtest.TextEvent("done"),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		},
+	)
+
+	var runs int
+	add := tool.Handle(tool.NewSpec[addParams]("add", "Add two numbers"), func(_ context.Context, in addParams) (*addResult, error) {
+		runs++
+		return &addResult{Sum: in.A + in.B}, nil
+	})
+
+	history := llm.Messages{
+		llm.User("2+3?"),
+		msg.Assistant(msg.ToolCall(msg.NewToolCall("call-1", "add", msg.ToolArgs{"a": 2, "b": 3}))).Build(),
+	}
+
+	store := tool.NewMemoryExecutionStore()
+	call := tool.NewToolCall("call-1", "add", tool.Args{"a": 2, "b": 3})
+	require.NoError(t, store.SaveExecution(context.Background(), tool.ExecutionKey(call), tool.Execution{Output: `{"sum":5}`}))
+
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: history,
+	}, llm.RunOptions{Executions: store}, add)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, runs, "recorded execution must be replayed")
+	assert.Equal(t, "done", res.Text)
+	require.Len(t, reqs, 1)
+	require.Len(t, reqs[0].Messages, 3)
+	results := reqs[0].Messages[2].ToolResults()
+	require.Len(t, results, 1)
+	assert.Equal(t, "call-1", results[0].ToolCallID)
+	assert.Contains(t, results[0].ToolOutput, `\"sum\":5`)
+}
+
+func TestRun_ResumeWithoutStoreExecutesTools(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs,
+		[]llm.Event{
+			llmtest.TextEvent("done"),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		},
+	)
+
+	var runs int
+	add := tool.Handle(tool.NewSpec[addParams]("add", "Add two numbers"), func(_ context.Context, in addParams) (*addResult, error) {
+		runs++
+		return &addResult{Sum: in.A + in.B}, nil
+	})
+
+	_, err := llm.Run(context.Background(), s, llm.Request{
+		Model: "m",
+		Messages: llm.Messages{
+			llm.User("2+3?"),
+			msg.Assistant(msg.ToolCall(msg.NewToolCall("call-1", "add", msg.ToolArgs{"a": 2, "b": 3}))).Build(),
+		},
+	}, llm.RunOptions{}, add)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, runs)
+	require.Len(t, reqs, 1)
+	require.Len(t, reqs[0].Messages, 3)
+	assert.Equal(t, msg.RoleTool, reqs[0].Messages[2].Role)
+}
+
+func TestRun_UsageAggregation(t *testing.T) {
+	rec := func(model string, in, out int, cost float64) llm.Event {
+		return llmtest.UsageEvent(usage.Record{
+			Dims:   usage.Dims{Provider: "fake", Model: model},
+			Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: in}, {Kind: usage.KindOutput, Count: out}},
+			Cost:   usage.Cost{Total: cost, Source: "calculated"},
+		})
+	}
+	var reqs []llm.Request
+	s := scripted(&reqs,
+		[]llm.Event{
+			llmtest.ToolEvent("call-1", "add", map[string]any{"a": 1, "b": 1}),
+			rec("big", 100, 10, 0.5),
+			llmtest.CompletedEvent(llm.StopReasonToolUse),
+		},
+		[]llm.Event{
+			llmtest.ToolEvent("call-2", "add", map[string]any{"a": 2, "b": 2}),
+			rec("small", 200, 20, 0.02),
+			llmtest.CompletedEvent(llm.StopReasonToolUse),
+		},
+		[]llm.Event{
+			llmtest.TextEvent("done"),
+			rec("big", 300, 30, 1.5),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		},
+	)
+	add := tool.Handle(tool.NewSpec[addParams]("add", "Add two numbers"), func(_ context.Context, in addParams) (*addResult, error) {
+		return &addResult{Sum: in.A + in.B}, nil
+	})
+
+	res, err := llm.Run(t.Context(), s, llm.Request{Model: "m", Messages: llm.Messages{llm.User("sum")}}, llm.RunOptions{}, add)
+	require.NoError(t, err)
+	require.Equal(t, 3, res.Turns)
+
+	assert.InDelta(t, 2.02, res.Cost(), 1e-9)
+	assert.Equal(t, 600, res.TotalUsage().Tokens.Count(usage.KindInput))
+
+	byModel := res.UsageByModel()
+	require.Len(t, byModel, 2)
+	assert.Equal(t, 400, byModel["big"].Tokens.Count(usage.KindInput))
+	assert.Equal(t, 40, byModel["big"].Tokens.Count(usage.KindOutput))
+	assert.InDelta(t, 2.0, byModel["big"].Cost.Total, 1e-9)
+	assert.Equal(t, usage.Dims{Provider: "fake", Model: "big"}, byModel["big"].Dims)
+	assert.InDelta(t, 0.02, byModel["small"].Cost.Total, 1e-9)
+
+	require.Len(t, res.TurnDetails, 3)
+	assert.Equal(t, 200, res.TurnDetails[1].TotalUsage().Tokens.Count(usage.KindInput))
+	assert.InDelta(t, 0.02, res.TurnDetails[1].TotalUsage().Cost.Total, 1e-9)
+	for i, r := range res.Usage {
+		assert.Equal(t, strconv.Itoa(i+1), r.Dims.TurnID)
+	}
+}
@@ -0,0 +1,60 @@
+package llm
+
+import "errors"
+
+// SafetyStage says whether a safety system blocked the prompt or the output.
+type SafetyStage string
+
+const (
+	SafetyStageInput  SafetyStage = "input"
+	SafetyStageOutput SafetyStage = "output"
+)
+
+// SafetyBlock describes content blocked by a provider's safety system:
+// OpenAI and Azure content filters, Anthropic refusals, Bedrock guardrails,
+// Gemini safety filters. It is attached to the CompletedEvent of a stream
+// that stopped with StopReasonContentFilter, or to the ProviderError of a
+// request rejected before streaming, so applications can show appropriate
+// messaging without parsing provider payloads.
+type SafetyBlock struct {
+	// Provider is the provider that reported the block.
+	Provider string `json:"provider,omitempty"`
+
+	// Stage is where the block happened. Empty when the provider does not
+	// say.
+	Stage SafetyStage `json:"stage,omitempty"`
+
+	// Reason is the provider's own code, e.g. "content_filter", "refusal" or
+	// "guardrail_intervened".
+	Reason string `json:"reason,omitempty"`
+
+	// Categories lists the policy categories the provider reported, if any.
+	Categories []SafetyCategory `json:"categories,omitempty"`
+
+	// Message is a provider-supplied explanation, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// SafetyCategory is one policy category evaluated by a safety system.
+type SafetyCategory struct {
+	// Name is the provider's category name, e.g. "hate", "VIOLENCE" or a
+	// Bedrock denied topic.
+	Name string `json:"name"`
+
+	// Severity is the provider-reported severity or confidence, on the
+	// provider's own scale ("high", "MEDIUM", ...). Empty when not reported.
+	Severity string `json:"severity,omitempty"`
+
+	// Blocked reports whether this category caused the block.
+	Blocked bool `json:"blocked"`
+}
+
+// SafetyBlockOf returns the SafetyBlock carried by a ProviderError in err's
+// chain, or nil.
+func SafetyBlockOf(err error) *SafetyBlock {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Safety
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+)
+
+// chatRequest is the body of POST /v1/chat/completions. Fields without an
+// llm.Request counterpart, such as penalties and logprobs, are ignored.
+type chatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []chatMessage   `json:"messages"`
+	MaxTokens           int             `json:"max_tokens"`
+	MaxCompletionTokens int             `json:"max_completion_tokens"`
+	Temperature         float64         `json:"temperature"`
+	TopP                float64         `json:"top_p"`
+	Stop                json.RawMessage `json:"stop"`
+	N                   int             `json:"n"`
+	Tools               []chatTool      `json:"tools"`
+	ToolChoice          json.RawMessage `json:"tool_choice"`
+	ResponseFormat      *responseFormat `json:"response_format"`
+	ReasoningEffort     string          `json:"reasoning_effort"`
+	User                string          `json:"user"`
+	Metadata            map[string]any  `json:"metadata"`
+	Stream              bool            `json:"stream"`
+	StreamOptions       *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+}
+
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []chatToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+type contentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type chatToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+	} `json:"function"`
+}
+
+type responseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema *struct {
+		Name   string         `json:"name"`
+		Schema map[string]any `json:"schema"`
+	} `json:"json_schema"`
+}
+
+// toRequest converts the request to an llm.Request.
+func (c chatRequest) toRequest() (llm.Request, error) {
+	if c.Model == "" {
+		return llm.Request{}, errors.New("model is required")
+	}
+	if c.N > 1 {
+		return llm.Request{}, errors.New("n > 1 is not supported")
+	}
+	messages, err := convertMessages(c.Messages)
+	if err != nil {
+		return llm.Request{}, err
+	}
+	req := llm.Request{
+		Model:       c.Model,
+		Messages:    messages,
+		MaxTokens:   c.MaxTokens,
+		Temperature: c.Temperature,
+		TopP:        c.TopP,
+	}
+	if c.MaxCompletionTokens > 0 {
+		req.MaxTokens = c.MaxCompletionTokens
+	}
+	if req.Stop, err = decodeStop(c.Stop); err != nil {
+		return llm.Request{}, err
+	}
+	for _, t := range c.Tools {
+		if t.Type != "function" {
+			return llm.Request{}, fmt.Errorf("tool type %q is not supported", t.Type)
+		}
+		req.Tools = append(req.Tools, tool.Definition{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+	if req.ToolChoice, err = decodeToolChoice(c.ToolChoice); err != nil {
+		return llm.Request{}, err
+	}
+	if f := c.ResponseFormat; f != nil {
+		switch f.Type {
+		case "", "text":
+		case "json_object":
+			req.OutputFormat = llm.OutputFormatJSON
+		case "json_schema":
+			if f.JSONSchema == nil || f.JSONSchema.Schema == nil {
+				return llm.Request{}, errors.New("response_format json_schema requires a schema")
+			}
+			req.OutputFormat = llm.OutputFormatJSON
+			req.OutputSchema = f.JSONSchema.Schema
+		default:
+			return llm.Request{}, fmt.Errorf("response_format %q is not supported", f.Type)
+		}
+	}
+	if c.ReasoningEffort != "" {
+		effort := llm.Effort(c.ReasoningEffort)
+		if effort == "minimal" {
+			effort = llm.EffortLow
+		}
+		if !effort.Valid() {
+			return llm.Request{}, fmt.Errorf("invalid reasoning_effort %q", c.ReasoningEffort)
+		}
+		req.Effort = effort
+	}
+	if c.User != "" || len(c.Metadata) > 0 {
+		req.RequestMeta = &llm.RequestMeta{User: c.User, Metadata: c.Metadata}
+	}
+	return req, req.Validate()
+}
+
+// convertMessages converts OpenAI messages. Consecutive tool messages are
+// merged into one message holding all results, the shape providers that
+// answer parallel tool calls in a single turn require.
+func convertMessages(in []chatMessage) (llm.Messages, error) {
+	var out llm.Messages
+	for i, m := range in {
+		text, err := decodeContent(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		switch m.Role {
+		case "system":
+			out = append(out, msg.System(text).Build())
+		case "developer":
+			out = append(out, msg.Developer(text).Build())
+		case "user":
+			out = append(out, msg.User(text).Build())
+		case "assistant":
+			b := msg.Assistant()
+			if text != "" {
+				b.Text(text)
+			}
+			for _, tc := range m.ToolCalls {
+				args := msg.ToolArgs{}
+				if strings.TrimSpace(tc.Function.Arguments) != "" {
+					if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+						return nil, fmt.Errorf("messages[%d]: tool call %s: invalid arguments: %w", i, tc.ID, err)
+					}
+				}
+				b.ToolCalls(msg.NewToolCall(tc.ID, tc.Function.Name, args))
+			}
+			out = append(out, b.Build())
+		case "tool":
+			result := msg.ToolResult{ToolCallID: m.ToolCallID, ToolOutput: text}
+			if n := len(out); n > 0 && out[n-1].IsTool() {
+				out[n-1].Parts = append(out[n-1].Parts, result.IntoPart())
+				continue
+			}
+			out = append(out, msg.Tool().Results(result).Build())
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, m.Role)
+		}
+	}
+	return out, nil
+}
+
+// decodeContent returns the text of a message content: a string, null or an
+// array of text parts.
+func decodeContent(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("content must be a string or an array of parts")
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		if p.Type != "text" {
+			return "", fmt.Errorf("content part %q is not supported", p.Type)
+		}
+		b.WriteString(p.Text)
+	}
+	return b.String(), nil
+}
+
+// decodeStop accepts a single stop sequence or an array of them.
+func decodeStop(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, errors.New("stop must be a string or an array of strings")
+	}
+	return list, nil
+}
+
+// decodeToolChoice accepts "auto", "none", "required" and
+// {"type": "function", "function": {"name": ...}}.
+func decodeToolChoice(raw json.RawMessage) (llm.ToolChoice, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		switch s {
+		case "auto":
+			return llm.ToolChoiceAuto{}, nil
+		case "none":
+			return llm.ToolChoiceNone{}, nil
+		case "required":
+			return llm.ToolChoiceRequired{}, nil
+		}
+		return nil, fmt.Errorf("invalid tool_choice %q", s)
+	}
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &named); err != nil || named.Function.Name == "" {
+		return nil, errors.New("tool_choice must be auto, none, required or name a function")
+	}
+	return llm.ToolChoiceTool{Name: named.Function.Name}, nil
+}
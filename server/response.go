@@ -0,0 +1,233 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/usage"
+)
+
+type (
+	chatCompletion struct {
+		ID      string       `json:"id"`
+		Object  string       `json:"object"`
+		Created int64        `json:"created"`
+		Model   string       `json:"model"`
+		Choices []chatChoice `json:"choices"`
+		Usage   *chatUsage   `json:"usage,omitempty"`
+	}
+
+	chatChoice struct {
+		Index        int        `json:"index"`
+		Message      *chatReply `json:"message,omitempty"`
+		Delta        *chatReply `json:"delta,omitempty"`
+		FinishReason *string    `json:"finish_reason"`
+	}
+
+	// chatReply is the assistant message of a completion, or a fragment of
+	// it in a streamed chunk.
+	chatReply struct {
+		Role             string         `json:"role,omitempty"`
+		Content          *string        `json:"content,omitempty"`
+		ReasoningContent string         `json:"reasoning_content,omitempty"`
+		ToolCalls        []chatToolCall `json:"tool_calls,omitempty"`
+	}
+
+	chatUsage struct {
+		PromptTokens            int           `json:"prompt_tokens"`
+		CompletionTokens        int           `json:"completion_tokens"`
+		TotalTokens             int           `json:"total_tokens"`
+		PromptTokensDetails     *tokenDetails `json:"prompt_tokens_details,omitempty"`
+		CompletionTokensDetails *tokenDetails `json:"completion_tokens_details,omitempty"`
+	}
+
+	tokenDetails struct {
+		CachedTokens    int `json:"cached_tokens,omitempty"`
+		ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+	}
+
+	modelList struct {
+		Object string        `json:"object"`
+		Data   []modelObject `json:"data"`
+	}
+
+	modelObject struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	errorResponse struct {
+		Error errorBody `json:"error"`
+	}
+
+	errorBody struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	}
+)
+
+// complete serves a non-streaming request.
+func (s *Server) complete(w http.ResponseWriter, r *http.Request, req llm.Request) {
+	c, err := llm.Complete(r.Context(), s.streamer, req)
+	if err != nil {
+		status, typ := errorStatus(err)
+		writeError(w, status, typ, err.Error())
+		return
+	}
+
+	reply := &chatReply{Role: "assistant", ReasoningContent: c.Thinking}
+	if c.Text != "" || len(c.ToolCalls) == 0 {
+		reply.Content = &c.Text
+	}
+	for _, call := range c.ToolCalls {
+		args, _ := json.Marshal(call.ToolArgs())
+		tc := chatToolCall{ID: call.ToolCallID(), Type: "function"}
+		tc.Function.Name = call.ToolName()
+		tc.Function.Arguments = string(args)
+		reply.ToolCalls = append(reply.ToolCalls, tc)
+	}
+	finish := finishReason(c.StopReason)
+	writeJSON(w, http.StatusOK, chatCompletion{
+		ID:      newCompletionID(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   responseModel(c.Model, req.Model),
+		Choices: []chatChoice{{Message: reply, FinishReason: &finish}},
+		Usage:   toChatUsage(c.TotalUsage()),
+	})
+}
+
+// stream serves a streaming request as server-sent events. Errors before the
+// first event are reported with an error status; later errors are sent as an
+// error event, after which the stream ends without [DONE].
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, in chatRequest, req llm.Request) {
+	stream, err := s.streamer.CreateStream(r.Context(), req)
+	if err != nil {
+		status, typ := errorStatus(err)
+		writeError(w, status, typ, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(v any) {
+		b, _ := json.Marshal(v)
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", b)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	base := chatCompletion{
+		ID:      newCompletionID(),
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	chunk := func(delta *chatReply, finish *string) chatCompletion {
+		c := base
+		c.Choices = []chatChoice{{Delta: delta, FinishReason: finish}}
+		return c
+	}
+
+	empty := ""
+	send(chunk(&chatReply{Role: "assistant", Content: &empty}, nil))
+
+	// Tool calls are sent whole, each as one delta, once the provider has
+	// completed them: not every provider streams argument fragments.
+	acc := llm.NewAccumulator()
+	toolIndex := 0
+	for env := range stream {
+		acc.Add(env)
+		switch ev := env.Data.(type) {
+		case *llm.StreamStartedEvent:
+			base.Model = responseModel(ev.Model, req.Model)
+		case *llm.DeltaEvent:
+			switch ev.Kind {
+			case llm.DeltaKindText:
+				text := ev.Text
+				send(chunk(&chatReply{Content: &text}, nil))
+			case llm.DeltaKindThinking:
+				send(chunk(&chatReply{ReasoningContent: ev.Thinking}, nil))
+			}
+		case *llm.ToolCallEvent:
+			args, _ := json.Marshal(ev.ToolCall.ToolArgs())
+			index := toolIndex
+			tc := chatToolCall{Index: &index, ID: ev.ToolCall.ToolCallID(), Type: "function"}
+			tc.Function.Name = ev.ToolCall.ToolName()
+			tc.Function.Arguments = string(args)
+			send(chunk(&chatReply{ToolCalls: []chatToolCall{tc}}, nil))
+			toolIndex++
+		}
+	}
+	acc.Close()
+	if err := acc.Err(); err != nil {
+		_, typ := errorStatus(err)
+		send(errorResponse{Error: errorBody{Message: err.Error(), Type: typ}})
+		return
+	}
+
+	c := acc.Completion()
+	finish := finishReason(c.StopReason)
+	send(chunk(&chatReply{}, &finish))
+	if in.StreamOptions != nil && in.StreamOptions.IncludeUsage {
+		final := base
+		final.Choices = []chatChoice{}
+		final.Usage = toChatUsage(c.TotalUsage())
+		send(final)
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// finishReason maps a StopReason to the OpenAI finish_reason.
+func finishReason(r llm.StopReason) string {
+	switch r {
+	case llm.StopReasonToolUse:
+		return "tool_calls"
+	case llm.StopReasonMaxTokens:
+		return "length"
+	case llm.StopReasonContentFilter:
+		return "content_filter"
+	}
+	return "stop"
+}
+
+func toChatUsage(rec usage.Record) *chatUsage {
+	t := rec.Tokens
+	u := &chatUsage{
+		PromptTokens:     t.TotalInput(),
+		CompletionTokens: t.TotalOutput(),
+		TotalTokens:      t.Total(),
+	}
+	if n := t.Count(usage.KindCacheRead); n > 0 {
+		u.PromptTokensDetails = &tokenDetails{CachedTokens: n}
+	}
+	if n := t.Count(usage.KindReasoning); n > 0 {
+		u.CompletionTokensDetails = &tokenDetails{ReasoningTokens: n}
+	}
+	return u
+}
+
+// responseModel returns the model that served the request, falling back to
+// the requested one when the provider did not report it.
+func responseModel(served, requested string) string {
+	if served != "" {
+		return served
+	}
+	return requested
+}
+
+func newCompletionID() string { return "chatcmpl-" + gonanoid.Must() }
@@ -0,0 +1,173 @@
+// Package server serves a Streamer, typically a *llm.Service with every
+// configured provider, over the OpenAI Chat Completions API, so existing
+// OpenAI SDK clients can use Anthropic, Bedrock, Ollama and the other
+// providers of this library without changes.
+//
+// The server implements POST /v1/chat/completions, streamed as server-sent
+// events or as a single response, with tools and usage, and GET /v1/models.
+// Models are addressed exactly as in llm.Request, so aliases such as "fast"
+// and provider-qualified names such as "anthropic/claude-sonnet-4-6" work.
+//
+// Example:
+//
+//	svc, err := auto.New(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	srv := server.New(svc, server.WithAPIKeys(os.Getenv("GATEWAY_KEY")))
+//	log.Fatal(http.ListenAndServe(":8080", srv))
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/codewandler/llm"
+)
+
+// maxRequestBytes bounds the size of a request body.
+const maxRequestBytes = 32 << 20
+
+// Server is an http.Handler exposing a Streamer as an OpenAI-compatible API.
+type Server struct {
+	streamer llm.Streamer
+	models   llm.Models
+	keys     []string
+	mux      *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithModels sets the models listed by GET /v1/models. By default the server
+// lists the models of the Streamer when it implements llm.ModelsProvider,
+// as *llm.Service does.
+func WithModels(models llm.Models) Option {
+	return func(s *Server) { s.models = models }
+}
+
+// WithAPIKeys requires clients to authenticate with one of keys as a bearer
+// token. Without keys the server accepts every request.
+func WithAPIKeys(keys ...string) Option {
+	return func(s *Server) {
+		for _, k := range keys {
+			if k != "" {
+				s.keys = append(s.keys, k)
+			}
+		}
+	}
+}
+
+// New creates a Server that serves requests with s.
+func New(s llm.Streamer, opts ...Option) *Server {
+	srv := &Server{streamer: s}
+	for _, opt := range opts {
+		opt(srv)
+	}
+	srv.mux = http.NewServeMux()
+	srv.mux.HandleFunc("POST /v1/chat/completions", srv.handleChatCompletions)
+	srv.mux.HandleFunc("GET /v1/models", srv.handleModels)
+	srv.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found_error", "unknown endpoint "+r.Method+" "+r.URL.Path)
+	})
+	return srv
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "invalid API key")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.keys) == 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(k)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var in chatRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid request body: "+err.Error())
+		return
+	}
+	req, err := in.toRequest()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if in.Stream {
+		s.stream(w, r, in, req)
+		return
+	}
+	s.complete(w, r, req)
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, _ *http.Request) {
+	models := s.models
+	if models == nil {
+		if mp, ok := s.streamer.(llm.ModelsProvider); ok {
+			models = mp.Models()
+		}
+	}
+	list := modelList{Object: "list", Data: []modelObject{}}
+	seen := make(map[string]bool, len(models))
+	for _, m := range models {
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		list.Data = append(list.Data, modelObject{ID: m.ID, Object: "model", OwnedBy: m.Provider})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// errorStatus maps err to the HTTP status an OpenAI client expects.
+func errorStatus(err error) (int, string) {
+	var pe *llm.ProviderError
+	switch {
+	case errors.Is(err, llm.ErrUnknownModel), errors.Is(err, llm.ErrModelNotFound):
+		return http.StatusNotFound, "not_found_error"
+	case errors.Is(err, llm.ErrUnsupportedFeature), errors.Is(err, llm.ErrBuildRequest),
+		errors.Is(err, llm.ErrContextLengthExceeded):
+		return http.StatusBadRequest, "invalid_request_error"
+	case errors.Is(err, llm.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limit_error"
+	case errors.Is(err, llm.ErrNoProviders):
+		return http.StatusServiceUnavailable, "api_error"
+	case errors.As(err, &pe) && (pe.StatusCode == http.StatusUnauthorized || pe.StatusCode == http.StatusForbidden):
+		// The gateway's upstream credentials were rejected, not the
+		// client's; a 401 would make clients drop their own valid key.
+		return http.StatusBadGateway, "api_error"
+	case errors.As(err, &pe) && pe.StatusCode >= 400 && pe.StatusCode < 600:
+		return pe.StatusCode, "api_error"
+	}
+	return http.StatusBadGateway, "api_error"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ, message string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Message: message, Type: typ}})
+}
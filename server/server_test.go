@@ -0,0 +1,312 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/provider/openai"
+	"github.com/codewandler/llm/server"
+	"github.com/codewandler/llm/tool"
+)
+
+// fakeStreamer records the last request and replies with evs.
+type fakeStreamer struct {
+	req llm.Request
+	evs []llm.Event
+	err error
+}
+
+func (f *fakeStreamer) CreateStream(ctx context.Context, src llm.Buildable) (llm.Stream, error) {
+	req, err := src.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return llmtest.SendEvents(f.evs...), nil
+}
+
+func (f *fakeStreamer) Models() llm.Models {
+	return llm.Models{
+		{ID: "claude-sonnet-4-6", Provider: "anthropic"},
+		{ID: "gpt-4o", Provider: "openai"},
+		{ID: "gpt-4o", Provider: "openrouter"},
+	}
+}
+
+func post(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	return rec
+}
+
+func TestServer_Completion(t *testing.T) {
+	f := &fakeStreamer{evs: []llm.Event{
+		&llm.StreamStartedEvent{Model: "claude-sonnet-4-6"},
+		llmtest.TextEvent("Hello"),
+		llmtest.TextEvent(" world"),
+		llmtest.UsageTokenEvent("anthropic", "claude-sonnet-4-6", 12, 3),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}
+	rec := post(t, server.New(f), `{
+		"model": "fast",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [{"type": "text", "text": "Hi"}]}
+		],
+		"max_completion_tokens": 100,
+		"stop": "END",
+		"response_format": {"type": "json_object"},
+		"reasoning_effort": "high"
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, "fast", f.req.Model)
+	assert.Equal(t, 100, f.req.MaxTokens)
+	assert.Equal(t, []string{"END"}, f.req.Stop)
+	assert.Equal(t, llm.OutputFormatJSON, f.req.OutputFormat)
+	assert.Equal(t, llm.EffortHigh, f.req.Effort)
+	require.Len(t, f.req.Messages, 2)
+	assert.Equal(t, msg.RoleSystem, f.req.Messages[0].Role)
+	assert.Equal(t, "Hi", f.req.Messages[1].Text())
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "chat.completion", out["object"])
+	assert.Equal(t, "claude-sonnet-4-6", out["model"])
+	choice := out["choices"].([]any)[0].(map[string]any)
+	assert.Equal(t, "stop", choice["finish_reason"])
+	assert.Equal(t, "Hello world", choice["message"].(map[string]any)["content"])
+	assert.Equal(t, map[string]any{"prompt_tokens": 12.0, "completion_tokens": 3.0, "total_tokens": 15.0}, out["usage"])
+}
+
+func TestServer_ToolConversation(t *testing.T) {
+	f := &fakeStreamer{evs: []llm.Event{
+		llmtest.ToolEvent("call_2", "get_time", map[string]any{"tz": "UTC"}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	}}
+	rec := post(t, server.New(f), `{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "user", "content": "Weather and time?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_0", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Berlin\"}"}},
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_0", "content": "sunny"},
+			{"role": "tool", "tool_call_id": "call_1", "content": "rain"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_time", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_time"}}
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.Len(t, f.req.Tools, 1)
+	assert.Equal(t, "get_time", f.req.Tools[0].Name)
+	assert.Equal(t, llm.ToolChoiceTool{Name: "get_time"}, f.req.ToolChoice)
+	require.Len(t, f.req.Messages, 3)
+	calls := f.req.Messages[1].ToolCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "Paris", calls[1].Args["city"])
+	results := f.req.Messages[2].ToolResults()
+	require.Len(t, results, 2, "consecutive tool messages are merged")
+	assert.Equal(t, "rain", results[1].ToolOutput)
+
+	var out struct {
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+			Message      struct {
+				Content   *string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "tool_calls", out.Choices[0].FinishReason)
+	assert.Nil(t, out.Choices[0].Message.Content)
+	require.Len(t, out.Choices[0].Message.ToolCalls, 1)
+	assert.Equal(t, "call_2", out.Choices[0].Message.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"tz":"UTC"}`, out.Choices[0].Message.ToolCalls[0].Function.Arguments)
+}
+
+func TestServer_Streaming(t *testing.T) {
+	f := &fakeStreamer{evs: []llm.Event{
+		llmtest.TextEvent("Hel"),
+		llmtest.TextEvent("lo"),
+		llmtest.UsageTokenEvent("", "", 4, 2),
+		llmtest.CompletedEvent(llm.StopReasonMaxTokens),
+	}}
+	rec := post(t, server.New(f), `{"model": "fast", "stream": true, "stream_options": {"include_usage": true},
+		"messages": [{"role": "user", "content": "Hi"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+
+	var (
+		text   string
+		finish string
+		usage  map[string]any
+		done   bool
+	)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage map[string]any `json:"usage"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		assert.Equal(t, "chat.completion.chunk", chunk.Object)
+		for _, c := range chunk.Choices {
+			text += c.Delta.Content
+			if c.FinishReason != nil {
+				finish = *c.FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	assert.Equal(t, "Hello", text)
+	assert.Equal(t, "length", finish)
+	assert.Equal(t, 6.0, usage["total_tokens"])
+	assert.True(t, done)
+}
+
+func TestServer_Errors(t *testing.T) {
+	t.Run("invalid request", func(t *testing.T) {
+		rec := post(t, server.New(&fakeStreamer{}), `{"model": "fast", "messages": [{"role": "robot", "content": "Hi"}]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"invalid_request_error"`)
+	})
+	t.Run("unknown model", func(t *testing.T) {
+		f := &fakeStreamer{err: llm.NewErrUnknownModel("", "nope")}
+		rec := post(t, server.New(f), `{"model": "nope", "messages": [{"role": "user", "content": "Hi"}]}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	for _, tc := range []struct {
+		upstream, want int
+		typ            string
+	}{
+		{http.StatusUnauthorized, http.StatusBadGateway, "api_error"},
+		{http.StatusForbidden, http.StatusBadGateway, "api_error"},
+		{http.StatusTooManyRequests, http.StatusTooManyRequests, "rate_limit_error"},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable, "api_error"},
+	} {
+		t.Run(fmt.Sprintf("upstream %d", tc.upstream), func(t *testing.T) {
+			f := &fakeStreamer{err: llm.NewErrAPIError("openai", tc.upstream, "upstream says no")}
+			rec := post(t, server.New(f), `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}]}`)
+			assert.Equal(t, tc.want, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"`+tc.typ+`"`)
+		})
+	}
+	t.Run("mid-stream error", func(t *testing.T) {
+		f := &fakeStreamer{evs: []llm.Event{
+			llmtest.TextEvent("Hel"),
+			llmtest.ErrorEvent(llm.NewErrStreamRead("fake", assert.AnError)),
+		}}
+		rec := post(t, server.New(f), `{"model": "fast", "stream": true, "messages": [{"role": "user", "content": "Hi"}]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"error":{`)
+		assert.NotContains(t, rec.Body.String(), "[DONE]")
+	})
+}
+
+func TestServer_Models(t *testing.T) {
+	rec := httptest.NewRecorder()
+	server.New(&fakeStreamer{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var out struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "list", out.Object)
+	require.Len(t, out.Data, 2, "duplicate IDs are listed once")
+	assert.Equal(t, "claude-sonnet-4-6", out.Data[0].ID)
+	assert.Equal(t, "anthropic", out.Data[0].OwnedBy)
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	srv := server.New(&fakeStreamer{}, server.WithAPIKeys("secret"))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestServer_OpenAIClient drives the server with the OpenAI provider of this
+// library, which speaks the Chat Completions API like any OpenAI SDK.
+func TestServer_OpenAIClient(t *testing.T) {
+	f := &fakeStreamer{evs: []llm.Event{
+		llmtest.TextEvent("Checking."),
+		llmtest.ToolEvent("call_1", "get_weather", map[string]any{"city": "Berlin"}),
+		llmtest.UsageTokenEvent("", "", 20, 5),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	}}
+	ts := httptest.NewServer(server.New(f, server.WithAPIKeys("test-key")))
+	defer ts.Close()
+
+	p := openai.New(llm.WithBaseURL(ts.URL), llm.WithAPIKey("test-key"))
+	c, err := llm.Complete(t.Context(), p, llm.Request{
+		Model:       "gpt-4o",
+		ApiTypeHint: llm.ApiTypeOpenAIChatCompletion,
+		Messages:    msg.BuildTranscript(msg.User("Weather in Berlin?")),
+		Tools:       []tool.Definition{{Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Checking.", c.Text)
+	require.Len(t, c.ToolCalls, 1)
+	assert.Equal(t, "call_1", c.ToolCalls[0].ToolCallID())
+	assert.Equal(t, "Berlin", c.ToolCalls[0].ToolArgs()["city"])
+	assert.Equal(t, llm.StopReasonToolUse, c.StopReason)
+	assert.Equal(t, 20, c.TotalUsage().Tokens.TotalInput())
+
+	assert.Equal(t, "gpt-4o", f.req.Model)
+	require.Len(t, f.req.Tools, 1)
+	assert.Equal(t, "Weather in Berlin?", f.req.Messages[0].Text())
+}
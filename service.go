@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	modelcatalog "github.com/codewandler/llm/internal/modelcatalog"
 	modeldb "github.com/codewandler/modeldb"
@@ -15,9 +16,15 @@ import (
 type Service struct {
 	providers   []RegisteredProvider
 	intents     map[string]IntentSelector
+	virtual     map[string]ModelConstraints
+	configured  map[string]bool // virtual models set with WithVirtualModel
 	preferences []PreferenceRule
 	retryPolicy RetryPolicy
 	wrappers    []ProviderWrapper
+	models      *modelCache
+
+	preSendRules    []PreSendRule
+	capabilityCheck bool
 }
 
 type RegisteredProvider struct {
@@ -34,6 +41,9 @@ type IntentSelector struct {
 }
 
 type RetryPolicy struct {
+	// EnableFallback moves a request on to the next candidate provider when
+	// CreateStream fails with an error IsFallbackError accepts, unless the
+	// caller's context is done.
 	EnableFallback bool
 }
 
@@ -56,6 +66,7 @@ type ResolvedModelSpec struct {
 	RequestedModel string
 	Offerings      []OfferingCandidate
 	FromIntent     string
+	FromVirtual    string
 	Ambiguous      bool
 }
 
@@ -87,8 +98,10 @@ type ProviderRegistry interface {
 type ServiceConfig struct {
 	Providers        []RegisteredProvider
 	IntentAliases    map[string]IntentSelector
+	VirtualModels    map[string]ModelConstraints
 	Preferences      []PreferenceRule
 	Wrappers         []ProviderWrapper
+	Middleware       []Middleware
 	RetryPolicy      RetryPolicy
 	AutoDetect       bool
 	DisabledTypes    map[string]bool
@@ -96,6 +109,9 @@ type ServiceConfig struct {
 	LLMOptions       []Option
 	Registry         ProviderRegistry
 	DetectedRequests []DetectedProvider
+	PreSend          []PreSendRule
+	ModelRefreshTTL  *time.Duration
+	CapabilityCheck  bool
 }
 
 type ServiceOption func(*ServiceConfig)
@@ -138,6 +154,7 @@ func New(opts ...ServiceOption) (*Service, error) {
 	}
 
 	providers := make([]RegisteredProvider, 0, len(cfg.Providers))
+	fetchers := make([]ModelFetcher, 0, len(cfg.Providers))
 	for i, p := range cfg.Providers {
 		if p.Provider == nil {
 			return nil, fmt.Errorf("providers[%d]: provider is nil", i)
@@ -146,10 +163,12 @@ func New(opts ...ServiceOption) (*Service, error) {
 		if serviceID == "" {
 			serviceID = strings.TrimSpace(p.Provider.Name())
 		}
+		fetcher, _ := p.Provider.(ModelFetcher)
+		fetchers = append(fetchers, fetcher)
 		providers = append(providers, RegisteredProvider{
 			Name:      strings.TrimSpace(p.Name),
 			ServiceID: serviceID,
-			Provider:  p.Provider,
+			Provider:  Wrap(p.Provider, cfg.Middleware...),
 		})
 	}
 	if len(providers) == 0 {
@@ -164,12 +183,33 @@ func New(opts ...ServiceOption) (*Service, error) {
 		intents[k] = v
 	}
 
+	virtual := DefaultVirtualModels()
+	configured := make(map[string]bool, len(cfg.VirtualModels))
+	for k, v := range cfg.VirtualModels {
+		if strings.TrimSpace(k) == "" {
+			continue
+		}
+		virtual[k] = v
+		configured[k] = true
+	}
+
+	refreshTTL := DefaultModelRefreshTTL
+	if cfg.ModelRefreshTTL != nil {
+		refreshTTL = *cfg.ModelRefreshTTL
+	}
+
 	return &Service{
 		providers:   providers,
 		intents:     intents,
+		virtual:     virtual,
+		configured:  configured,
 		preferences: append([]PreferenceRule(nil), cfg.Preferences...),
 		retryPolicy: cfg.RetryPolicy,
 		wrappers:    append([]ProviderWrapper(nil), cfg.Wrappers...),
+		models:      newModelCache(fetchers, refreshTTL),
+
+		preSendRules:    append([]PreSendRule(nil), cfg.PreSend...),
+		capabilityCheck: cfg.CapabilityCheck,
 	}, nil
 }
 
@@ -197,16 +237,28 @@ func (s *Service) CreateStream(ctx context.Context, src Buildable) (Stream, erro
 	}
 
 	var lastErr error
+	var misses []firstTokenMiss
 	for i, candidate := range candidates {
 		exec := s.wrap(candidate)
-		stream, err := exec.CreateStream(ctx, resolvedReq)
+		attemptReq, err := s.preSend(ctx, candidate, resolvedReq)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkCandidate(candidate, attemptReq); err != nil {
+			lastErr = err
+			continue
+		}
+		stream, err := s.createCandidateStream(ctx, exec, candidate, attemptReq, misses)
 		if err == nil {
 			return stream, nil
 		}
 		lastErr = err
-		if i == len(candidates)-1 || !s.shouldFallback(err) {
+		if i == len(candidates)-1 || !s.shouldFallback(ctx, err) {
 			return nil, err
 		}
+		if errors.Is(err, ErrFirstTokenTimeout) {
+			misses = append(misses, firstTokenMiss{provider: candidateName(candidate), err: err})
+		}
 	}
 	if lastErr != nil {
 		return nil, lastErr
@@ -214,6 +266,35 @@ func (s *Service) CreateStream(ctx context.Context, src Buildable) (Stream, erro
 	return nil, ErrNoProviders
 }
 
+// createCandidateStream starts req on one candidate. With a
+// FirstTokenDeadline it waits for the first delta, cancelling the attempt
+// when the deadline passes first.
+func (s *Service) createCandidateStream(ctx context.Context, exec Executor, candidate RegisteredProvider, req Request, misses []firstTokenMiss) (Stream, error) {
+	if req.FirstTokenDeadline <= 0 {
+		return exec.CreateStream(ctx, req)
+	}
+	timer := time.NewTimer(req.FirstTokenDeadline)
+	defer timer.Stop()
+	attemptCtx, cancel := context.WithCancel(ctx)
+	stream, err := exec.CreateStream(attemptCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return awaitFirstToken(ctx, cancel, stream, timer.C, req.FirstTokenDeadline, candidateName(candidate), misses)
+}
+
+// candidateName identifies a candidate in errors and events.
+func candidateName(r RegisteredProvider) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if r.ServiceID != "" {
+		return r.ServiceID
+	}
+	return r.Provider.Name()
+}
+
 func (s *Service) wrap(r RegisteredProvider) Executor {
 	var exec Executor = providerExecutor{provider: r.Provider}
 	for i := len(s.wrappers) - 1; i >= 0; i-- {
@@ -241,6 +322,14 @@ func (s *Service) resolveModelSpec(model string) (ResolvedModelSpec, error) {
 		resolved.RequestedModel = strings.TrimSpace(intent.Model)
 	}
 
+	ref, ok, err := s.virtualModelRef(resolved.RequestedModel)
+	if err != nil {
+		return ResolvedModelSpec{}, err
+	}
+	if ok {
+		return s.resolveVirtualModel(resolved, ref)
+	}
+
 	name, serviceID, requestedModel := s.parseModelRef(resolved.RequestedModel)
 	resolved.ExactName = name
 	resolved.ExactServiceID = serviceID
@@ -275,8 +364,8 @@ func (s *Service) resolveModelSpec(model string) (ResolvedModelSpec, error) {
 
 	resolved.Offerings = s.resolveOfferingCandidates(requestedModel)
 	if len(resolved.Offerings) == 0 {
-		for _, p := range s.providers {
-			if hasProviderModel(p.Provider, requestedModel) {
+		for i, p := range s.providers {
+			if _, err := s.providerModels(i).Resolve(requestedModel); err == nil {
 				resolved.Offerings = append(resolved.Offerings, OfferingCandidate{ServiceID: p.ServiceID, WireModel: requestedModel, Source: "provider-models"})
 			}
 		}
@@ -291,7 +380,7 @@ func (s *Service) resolveModelSpec(model string) (ResolvedModelSpec, error) {
 }
 
 func (s *Service) resolveOfferingCandidates(requestedModel string) []OfferingCandidate {
-	cat, err := modelcatalog.LoadBuiltIn()
+	cat, err := modelcatalog.Load()
 	if err != nil {
 		return nil
 	}
@@ -374,7 +463,7 @@ func (s *Service) preferenceWeights(resolved ResolvedModelSpec) map[string]int {
 		weights[providerCandidateKey(p)] = 0
 	}
 	for _, pref := range s.preferences {
-		if pref.Intent != "" && pref.Intent != resolved.FromIntent {
+		if pref.Intent != "" && pref.Intent != resolved.FromIntent && pref.Intent != resolved.FromVirtual {
 			continue
 		}
 		for _, p := range s.providers {
@@ -423,11 +512,6 @@ func ambiguousModelError(resolved ResolvedModelSpec) error {
 	return fmt.Errorf("model %q is ambiguous; matches: %s; use a provider-prefixed or instance-prefixed reference", resolved.RawModel, strings.Join(matches, ", "))
 }
 
-func hasProviderModel(p Provider, ref string) bool {
-	_, err := p.Models().Resolve(ref)
-	return err == nil
-}
-
 func providerCandidateKey(p RegisteredProvider) string {
 	return p.Name + "|" + p.ServiceID + "|" + p.Provider.Name()
 }
@@ -493,23 +577,11 @@ func (s *Service) hasProviderName(name string) bool {
 	return false
 }
 
-func (s *Service) shouldFallback(err error) bool {
-	if !s.retryPolicy.EnableFallback {
-		return false
-	}
-	var pe *ProviderError
-	if errors.As(err, &pe) {
-		if pe.StatusCode == 402 || pe.StatusCode == 429 || pe.StatusCode == 503 {
-			return true
-		}
-		msg := strings.ToLower(pe.Error())
-		for _, needle := range []string{"rate limit", "too many requests", "quota", "service unavailable", "overloaded", "temporarily unavailable", "try again"} {
-			if strings.Contains(msg, needle) {
-				return true
-			}
-		}
-	}
-	return false
+// shouldFallback reports whether err moves the request on to the next
+// candidate. A timeout or cancellation of the caller's own ctx is not a
+// provider failure and ends the request.
+func (s *Service) shouldFallback(ctx context.Context, err error) bool {
+	return s.retryPolicy.EnableFallback && ctx.Err() == nil && IsFallbackError(err)
 }
 
 func WithProvider(p Provider) ServiceOption {
@@ -545,6 +617,11 @@ func WithWrapper(w ProviderWrapper) ServiceOption {
 	return func(c *ServiceConfig) { c.Wrappers = append(c.Wrappers, w) }
 }
 
+// WithMiddleware wraps every provider of the Service with mw, see Wrap.
+func WithMiddleware(mw ...Middleware) ServiceOption {
+	return func(c *ServiceConfig) { c.Middleware = append(c.Middleware, mw...) }
+}
+
 func WithRetryPolicy(p RetryPolicy) ServiceOption {
 	return func(c *ServiceConfig) { c.RetryPolicy = p }
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,19 +59,48 @@ func TestServiceCreateStream_IntentAlias(t *testing.T) {
 }
 
 func TestServiceCreateStream_FallbackOnRetriableError(t *testing.T) {
-	failProvider := serviceTestProvider{name: "anthropic-primary", models: Models{{ID: "claude-sonnet-4-6", Name: "Claude Sonnet 4.6", Provider: "anthropic"}}, stream: func(context.Context, Buildable) (Stream, error) {
-		return nil, NewErrAPIError("anthropic", 429, "rate limit")
+	// Service falls back on the same errors as Fallback, see IsFallbackError.
+	for _, primaryErr := range []error{
+		NewErrAPIError("anthropic", 429, "rate limit"),
+		NewErrAPIError("anthropic", 502, "bad gateway"),
+		NewErrRequestFailed("anthropic", errors.New("connection reset")),
+	} {
+		t.Run(primaryErr.Error(), func(t *testing.T) {
+			failProvider := serviceTestProvider{name: "anthropic-primary", models: Models{{ID: "claude-sonnet-4-6", Name: "Claude Sonnet 4.6", Provider: "anthropic"}}, stream: func(context.Context, Buildable) (Stream, error) {
+				return nil, primaryErr
+			}}
+			okProvider := serviceTestProvider{name: "anthropic-secondary", models: nil, stream: completedStream}
+			svc, err := New(
+				WithRegisteredProvider(RegisteredProvider{Name: "primary", ServiceID: "anthropic", Provider: failProvider}),
+				WithRegisteredProvider(RegisteredProvider{Name: "secondary", ServiceID: "anthropic", Provider: okProvider}),
+			)
+			require.NoError(t, err)
+			stream, err := svc.CreateStream(context.Background(), Request{Model: "claude-sonnet-4-6", Messages: Messages{User("hi")}})
+			require.NoError(t, err)
+			for range stream {
+			}
+		})
+	}
+}
+
+func TestServiceCreateStream_NoFallbackOnCallerDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	slowProvider := serviceTestProvider{name: "anthropic-primary", models: Models{{ID: "claude-sonnet-4-6", Name: "Claude Sonnet 4.6", Provider: "anthropic"}}, stream: func(ctx context.Context, _ Buildable) (Stream, error) {
+		<-ctx.Done()
+		return nil, NewErrRequestFailed("anthropic", ctx.Err())
+	}}
+	otherProvider := serviceTestProvider{name: "anthropic-secondary", stream: func(context.Context, Buildable) (Stream, error) {
+		t.Fatal("the caller's deadline must not trigger fallback")
+		return nil, nil
 	}}
-	okProvider := serviceTestProvider{name: "anthropic-secondary", models: nil, stream: completedStream}
 	svc, err := New(
-		WithRegisteredProvider(RegisteredProvider{Name: "primary", ServiceID: "anthropic", Provider: failProvider}),
-		WithRegisteredProvider(RegisteredProvider{Name: "secondary", ServiceID: "anthropic", Provider: okProvider}),
+		WithRegisteredProvider(RegisteredProvider{Name: "primary", ServiceID: "anthropic", Provider: slowProvider}),
+		WithRegisteredProvider(RegisteredProvider{Name: "secondary", ServiceID: "anthropic", Provider: otherProvider}),
 	)
 	require.NoError(t, err)
-	stream, err := svc.CreateStream(context.Background(), Request{Model: "claude-sonnet-4-6", Messages: Messages{User("hi")}})
-	require.NoError(t, err)
-	for range stream {
-	}
+	_, err = svc.CreateStream(ctx, Request{Model: "claude-sonnet-4-6", Messages: Messages{User("hi")}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
 }
 
 func TestServiceCreateStream_NoFallbackOnNonRetriableError(t *testing.T) {
@@ -206,6 +236,31 @@ func TestServiceExplicitServiceRef_DoesNotFallThroughToCanonicalSibling(t *testi
 	assert.Equal(t, "openai", resolved.ExactServiceID)
 }
 
+func TestServiceCreateStream_DispatchesByProviderPrefix(t *testing.T) {
+	var got []string
+	recording := func(name string) serviceTestProvider {
+		return serviceTestProvider{name: name, stream: func(ctx context.Context, src Buildable) (Stream, error) {
+			req, err := src.BuildRequest(ctx)
+			require.NoError(t, err)
+			got = append(got, name+":"+req.Model)
+			return completedStream(ctx, src)
+		}}
+	}
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "openai", Provider: recording("openai")}),
+		WithRegisteredProvider(RegisteredProvider{ServiceID: "openrouter", Provider: recording("openrouter")}),
+	)
+	require.NoError(t, err)
+
+	for _, model := range []string{"openai/gpt-4o", "openrouter/openai/gpt-4o"} {
+		stream, err := svc.CreateStream(context.Background(), Request{Model: model, Messages: Messages{User("hi")}})
+		require.NoError(t, err)
+		for range stream {
+		}
+	}
+	assert.Equal(t, []string{"openai:gpt-4o", "openrouter:openai/gpt-4o"}, got)
+}
+
 func TestServiceParseModelRef_ServiceWithNestedModelPath(t *testing.T) {
 	svc, err := New(
 		WithRegisteredProvider(RegisteredProvider{ServiceID: "openrouter", Provider: serviceTestProvider{name: "openrouter", models: nil, stream: completedStream}}),
@@ -229,3 +284,90 @@ func TestServiceParseModelRef_ClaudeServiceRemainsDistinctFromAnthropic(t *testi
 	require.Len(t, candidates, 1)
 	assert.Equal(t, "claude", candidates[0].ServiceID)
 }
+
+func TestServiceCreateStream_FirstTokenDeadlineFallsBack(t *testing.T) {
+	cancelled := make(chan struct{})
+	slow := serviceTestProvider{name: "slow", models: Models{{ID: "m", Provider: "slow"}}, stream: func(ctx context.Context, _ Buildable) (Stream, error) {
+		pub, ch := NewEventPublisher()
+		go func() {
+			defer pub.Close()
+			pub.Started(StreamStartedEvent{Provider: "slow"})
+			<-ctx.Done()
+			close(cancelled)
+		}()
+		return ch, nil
+	}}
+	fast := serviceTestProvider{name: "fast", stream: textStream("hello")}
+	svc, err := New(
+		WithRegisteredProvider(RegisteredProvider{Name: "slow", ServiceID: "fake", Provider: slow}),
+		WithRegisteredProvider(RegisteredProvider{Name: "fast", ServiceID: "fake", Provider: fast}),
+	)
+	require.NoError(t, err)
+
+	stream, err := svc.CreateStream(context.Background(), Request{Model: "m", Messages: Messages{User("hi")}, FirstTokenDeadline: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	var failovers []*ProviderFailoverEvent
+	res := NewEventProcessor(context.Background(), stream).
+		OnEvent(TypedEventHandler[*ProviderFailoverEvent](func(ev *ProviderFailoverEvent) { failovers = append(failovers, ev) })).
+		Result()
+	require.NoError(t, res.Error())
+	assert.Equal(t, "hello", res.Text())
+
+	require.Len(t, failovers, 1)
+	assert.Equal(t, "slow", failovers[0].Provider)
+	assert.Equal(t, "fast", failovers[0].FailoverProvider)
+	assert.ErrorIs(t, failovers[0].Error, ErrFirstTokenTimeout)
+
+	require.Len(t, res.Warnings(), 1)
+	assert.Equal(t, WarningFallbackApplied, res.Warnings()[0].Code)
+	assert.Equal(t, "first_token_deadline", res.Warnings()[0].Param)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow attempt was not cancelled")
+	}
+}
+
+func TestServiceCreateStream_FirstTokenDeadlineExceeded(t *testing.T) {
+	slow := serviceTestProvider{name: "slow", models: Models{{ID: "m", Provider: "slow"}}, stream: func(ctx context.Context, _ Buildable) (Stream, error) {
+		pub, ch := NewEventPublisher()
+		go func() {
+			defer pub.Close()
+			<-ctx.Done()
+		}()
+		return ch, nil
+	}}
+	svc, err := New(WithProvider(slow))
+	require.NoError(t, err)
+
+	_, err = svc.CreateStream(context.Background(), Request{Model: "m", Messages: Messages{User("hi")}, FirstTokenDeadline: 10 * time.Millisecond})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrFirstTokenTimeout)
+}
+
+func TestServiceCreateStream_FirstTokenDeadlineMet(t *testing.T) {
+	p := serviceTestProvider{name: "fake", models: Models{{ID: "m", Provider: "fake"}}, stream: textStream("hello")}
+	svc, err := New(WithProvider(p))
+	require.NoError(t, err)
+
+	stream, err := svc.CreateStream(context.Background(), Request{Model: "m", Messages: Messages{User("hi")}, FirstTokenDeadline: time.Second})
+	require.NoError(t, err)
+	res := NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+	assert.Equal(t, "hello", res.Text())
+	assert.Empty(t, res.Warnings())
+}
+
+func textStream(text string) func(context.Context, Buildable) (Stream, error) {
+	return func(context.Context, Buildable) (Stream, error) {
+		pub, ch := NewEventPublisher()
+		go func() {
+			defer pub.Close()
+			pub.Delta(TextDelta(text))
+			pub.Completed(CompletedEvent{StopReason: StopReasonEndTurn})
+		}()
+		return ch, nil
+	}
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// StreamHandle is a stream that can be stopped explicitly. Open one with
+// OpenStream.
+//
+// Guarantees:
+//   - Events is closed exactly once, after the provider closed its stream or
+//     after Close.
+//   - Close cancels the request context, which aborts the HTTP request and
+//     closes the response body, then drains the provider's stream so its
+//     goroutine never blocks on an abandoned channel. Close returns once the
+//     provider's stream is closed.
+//   - Err reports the first error event. It is final once Events is closed.
+//
+// A plain Stream gives the same guarantees only when its consumer keeps
+// reading until the channel is closed, even after cancelling the context.
+type StreamHandle struct {
+	events chan Envelope
+	cancel context.CancelFunc
+	closed chan struct{}
+	done   chan struct{}
+
+	closeOnce sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// OpenStream starts a stream on s and returns a handle for it. The stream
+// runs under a child of ctx that Close cancels.
+func OpenStream(ctx context.Context, s Streamer, src Buildable) (*StreamHandle, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := s.CreateStream(ctx, src)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	h := &StreamHandle{
+		events: make(chan Envelope),
+		cancel: cancel,
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go h.pump(stream)
+	return h, nil
+}
+
+// Events returns the event channel.
+func (h *StreamHandle) Events() Stream { return h.events }
+
+// Close stops the stream and waits until the provider has closed it. It is
+// safe to call more than once and from any goroutine.
+func (h *StreamHandle) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.closed)
+		h.cancel()
+	})
+	<-h.done
+	return nil
+}
+
+// Err returns the error of the first error event, or nil. Cancellation
+// caused by Close is not reported.
+func (h *StreamHandle) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *StreamHandle) pump(in Stream) {
+	defer close(h.done)
+	defer h.cancel()
+	defer close(h.events)
+
+	for env := range in {
+		if ev, ok := env.Data.(*ErrorEvent); ok {
+			h.setErr(ev.Error)
+		}
+		select {
+		case h.events <- env:
+		case <-h.closed:
+			for range in {
+			}
+			return
+		}
+	}
+}
+
+func (h *StreamHandle) setErr(err error) {
+	if err == nil {
+		return
+	}
+	select {
+	case <-h.closed:
+		if errors.Is(err, context.Canceled) || errors.Is(err, ErrContextCancelled) {
+			return
+		}
+	default:
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		h.err = err
+	}
+}
@@ -0,0 +1,89 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/provider/fake"
+)
+
+func TestOpenStream_ReadsToEnd(t *testing.T) {
+	p := fake.NewProvider().WithScript(llm.TextDelta("hi"), &llm.CompletedEvent{StopReason: llm.StopReasonEndTurn})
+	h, err := llm.OpenStream(t.Context(), p, llm.Request{Model: fake.Model1ID})
+	require.NoError(t, err)
+
+	res := llm.ProcessEvents(t.Context(), h.Events())
+	require.NoError(t, res.Error())
+	assert.Equal(t, "hi", res.Text())
+	require.NoError(t, h.Close())
+	assert.NoError(t, h.Err())
+}
+
+func TestOpenStream_CloseStopsProducer(t *testing.T) {
+	produced := make(chan struct{})
+	s := llm.StreamFunc(func(ctx context.Context, _ llm.Buildable) (llm.Stream, error) {
+		ch := make(chan llm.Envelope)
+		go func() {
+			defer close(produced)
+			defer close(ch)
+			for i := 0; i < 1000; i++ {
+				ch <- llm.Envelope{Type: llm.StreamEventDelta, Data: llm.TextDelta("x")}
+			}
+		}()
+		return ch, nil
+	})
+
+	h, err := llm.OpenStream(t.Context(), s, llm.Request{})
+	require.NoError(t, err)
+	<-h.Events()
+
+	closed := make(chan struct{})
+	go func() {
+		_ = h.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+
+	select {
+	case <-produced:
+	default:
+		t.Fatal("producer still blocked after Close")
+	}
+	for range h.Events() {
+	}
+	assert.NoError(t, h.Err())
+	assert.NoError(t, h.Close(), "Close is idempotent")
+}
+
+func TestOpenStream_CloseCancelsContext(t *testing.T) {
+	p := fake.NewProvider().
+		WithScript(llm.TextDelta("a"), llm.TextDelta("b"), &llm.CompletedEvent{StopReason: llm.StopReasonEndTurn}).
+		WithLatency(time.Hour)
+	h, err := llm.OpenStream(t.Context(), p, llm.Request{Model: fake.Model1ID})
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, h.Close())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.NoError(t, h.Err(), "cancellation by Close is not an error")
+}
+
+func TestOpenStream_ErrReportsErrorEvent(t *testing.T) {
+	boom := llm.NewErrProviderMsg("fake", "boom")
+	p := fake.NewProvider().WithScript(&llm.ErrorEvent{Error: boom})
+	h, err := llm.OpenStream(t.Context(), p, llm.Request{Model: fake.Model1ID})
+	require.NoError(t, err)
+
+	for range h.Events() {
+	}
+	assert.ErrorIs(t, h.Err(), boom)
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"strings"
+	"time"
+
+	"github.com/codewandler/llm/msg"
+)
+
+// SystemPrompt assembles a system prompt from sections and renders it in the
+// shape each wire protocol caches best.
+//
+// Sections are ordered from stable to volatile: Identity, ToolHints and
+// Sections rarely change between requests, Environment and Date do. When
+// Cache is set, the cache breakpoint is placed after the last stable section
+// so that a new date does not invalidate the cached prefix.
+//
+// Example:
+//
+//	sp := llm.SystemPrompt{
+//	    Identity:  "You are a release assistant.",
+//	    ToolHints: "Prefer git_log over reading files.",
+//	    Date:      time.Now(),
+//	    Cache:     []llm.CacheOpt{llm.CacheTTL1h},
+//	}
+//	req, err := llm.NewRequestBuilder().
+//	    ApiTypeHint(llm.ApiTypeAnthropicMessages).
+//	    SystemPrompt(sp).
+//	    User("What changed since v1.2?").
+//	    Build()
+type SystemPrompt struct {
+	// Identity describes who the model is and how it should behave.
+	Identity string
+	// ToolHints explains when and how to use the available tools.
+	ToolHints string
+	// Sections are additional stable sections, rendered after ToolHints.
+	Sections []string
+	// Environment describes the runtime context (working directory, OS, ...).
+	Environment string
+	// Date, when non-zero, is rendered as the current date.
+	Date time.Time
+	// Cache enables prompt caching for the stable sections.
+	Cache []CacheOpt
+}
+
+// stable returns the non-empty stable sections in render order.
+func (p SystemPrompt) stable() []string {
+	var out []string
+	for _, s := range append([]string{p.Identity, p.ToolHints}, p.Sections...) {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// volatile returns the non-empty volatile sections in render order.
+func (p SystemPrompt) volatile() []string {
+	var out []string
+	if s := strings.TrimSpace(p.Environment); s != "" {
+		out = append(out, s)
+	}
+	if !p.Date.IsZero() {
+		out = append(out, "Today's date is "+p.Date.Format("2006-01-02")+".")
+	}
+	return out
+}
+
+// String returns the whole prompt as a single text, sections separated by a
+// blank line.
+func (p SystemPrompt) String() string {
+	return strings.Join(append(p.stable(), p.volatile()...), "\n\n")
+}
+
+// Messages renders the prompt for the given wire protocol:
+//
+//   - ApiTypeAnthropicMessages: one system message per section, which
+//     providers send as separate system text blocks. The cache hint is set on
+//     the last stable section.
+//   - any other type: a single system message holding String(). The cache
+//     hint, if any, is set on that message; OpenAI caches prompts
+//     automatically and ignores it.
+//
+// An empty prompt renders no messages.
+func (p SystemPrompt) Messages(api ApiType) Messages {
+	stable, volatile := p.stable(), p.volatile()
+	if len(stable)+len(volatile) == 0 {
+		return nil
+	}
+	if api != ApiTypeAnthropicMessages {
+		return Messages{buildMsg(msg.System(p.String()), p.Cache)}
+	}
+	out := make(Messages, 0, len(stable)+len(volatile))
+	for i, text := range stable {
+		var cache []CacheOpt
+		if i == len(stable)-1 {
+			cache = p.Cache
+		}
+		out = append(out, buildMsg(msg.System(text), cache))
+	}
+	for _, text := range volatile {
+		out = append(out, buildMsg(msg.System(text), nil))
+	}
+	return out
+}
+
+// SystemPrompt appends the rendered system prompt, using the request's
+// ApiTypeHint to choose the shape. Set ApiTypeHint first when targeting the
+// Anthropic Messages API.
+func (b *RequestBuilder) SystemPrompt(p SystemPrompt) *RequestBuilder {
+	b.req.Messages = append(b.req.Messages, p.Messages(b.req.ApiTypeHint)...)
+	return b
+}
+
+// WithSystemPrompt appends the rendered system prompt, like the fluent
+// SystemPrompt method.
+func WithSystemPrompt(p SystemPrompt) RequestOption {
+	return func(r *Request) {
+		r.Messages = append(r.Messages, p.Messages(r.ApiTypeHint)...)
+	}
+}
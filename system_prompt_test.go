@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSystemPrompt() SystemPrompt {
+	return SystemPrompt{
+		Identity:    "You are a release assistant.",
+		ToolHints:   "Prefer git_log.",
+		Sections:    []string{"Answer briefly.", "  "},
+		Environment: "cwd: /repo",
+		Date:        time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC),
+		Cache:       []CacheOpt{CacheTTL1h},
+	}
+}
+
+func TestSystemPrompt_String(t *testing.T) {
+	assert.Equal(t,
+		"You are a release assistant.\n\nPrefer git_log.\n\nAnswer briefly.\n\ncwd: /repo\n\nToday's date is 2026-03-14.",
+		testSystemPrompt().String())
+	assert.Empty(t, SystemPrompt{}.Messages(ApiTypeAnthropicMessages))
+}
+
+func TestSystemPrompt_Messages_OpenAI(t *testing.T) {
+	for _, api := range []ApiType{ApiTypeAuto, ApiTypeOpenAIChatCompletion, ApiTypeOpenAIResponses} {
+		msgs := testSystemPrompt().Messages(api)
+		require.Len(t, msgs, 1, api)
+		assert.True(t, msgs[0].IsSystem())
+		assert.Equal(t, testSystemPrompt().String(), msgs[0].Text())
+	}
+}
+
+func TestSystemPrompt_Messages_Anthropic(t *testing.T) {
+	msgs := testSystemPrompt().Messages(ApiTypeAnthropicMessages)
+	require.Len(t, msgs, 5)
+
+	texts := make([]string, len(msgs))
+	for i, m := range msgs {
+		assert.True(t, m.IsSystem())
+		texts[i] = m.Text()
+	}
+	assert.Equal(t, []string{
+		"You are a release assistant.", "Prefer git_log.", "Answer briefly.",
+		"cwd: /repo", "Today's date is 2026-03-14.",
+	}, texts)
+
+	// The breakpoint follows the last stable section, before the date.
+	for i, m := range msgs {
+		if i == 2 {
+			require.NotNil(t, m.CacheHint)
+			assert.Equal(t, "1h", m.CacheHint.TTL)
+			continue
+		}
+		assert.Nil(t, m.CacheHint, "message %d", i)
+	}
+}
+
+func TestRequestBuilder_SystemPrompt(t *testing.T) {
+	req, err := NewRequestBuilder().
+		Model("m").
+		ApiTypeHint(ApiTypeAnthropicMessages).
+		SystemPrompt(SystemPrompt{Identity: "id", Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}).
+		User("hi").
+		Build()
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 3)
+	assert.Equal(t, "id", req.Messages[0].Text())
+	assert.Nil(t, req.Messages[0].CacheHint)
+
+	req, err = NewRequestBuilder().Build(
+		WithModel("m"),
+		WithSystemPrompt(SystemPrompt{Identity: "id", ToolHints: "tools"}),
+		WithUser("hi"),
+	)
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 2)
+	assert.Equal(t, "id\n\ntools", req.Messages[0].Text())
+}
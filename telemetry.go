@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/codewandler/llm/usage"
+)
+
+// Telemetry receives one call per CreateStream so streams can be traced and
+// measured, for example with OpenTelemetry spans, counters and histograms.
+// The package has no OpenTelemetry dependency; an adapter implements this
+// interface and maps StreamStats.Attributes onto its spans and instruments.
+type Telemetry interface {
+	// StartStream is called before the request is sent. The returned
+	// context is used for the request, so a span started here becomes the
+	// parent of HTTP client spans. end is called exactly once when the
+	// stream finishes or fails to start.
+	StartStream(ctx context.Context, info StreamInfo) (_ context.Context, end func(StreamStats))
+}
+
+// TelemetryFunc adapts a function to Telemetry.
+type TelemetryFunc func(ctx context.Context, info StreamInfo) (context.Context, func(StreamStats))
+
+// StartStream implements Telemetry.
+func (f TelemetryFunc) StartStream(ctx context.Context, info StreamInfo) (context.Context, func(StreamStats)) {
+	return f(ctx, info)
+}
+
+// StreamInfo identifies an instrumented stream.
+type StreamInfo struct {
+	// Provider is the provider name.
+	Provider string
+	// Model is the requested model.
+	Model string
+}
+
+// StreamStats is the outcome of an instrumented stream.
+type StreamStats struct {
+	StreamInfo
+
+	// RequestID is the upstream request ID, if reported.
+	RequestID string
+	// ResolvedModel is the model the upstream API reported, if any.
+	ResolvedModel string
+	// StopReason is empty when the stream ended without completing.
+	StopReason StopReason
+	// Tokens sums the usage records of the stream.
+	Tokens usage.TokenItems
+	// Cost is the total cost in USD of the usage records.
+	Cost float64
+	// TimeToFirstToken is zero when no delta arrived.
+	TimeToFirstToken time.Duration
+	// Duration runs from CreateStream until the stream closed.
+	Duration time.Duration
+	// Err is the error returned by CreateStream or carried by the stream.
+	Err error
+}
+
+// Attributes returns the stats keyed by OpenTelemetry GenAI semantic
+// convention names where one exists, and "llm.*" names otherwise.
+func (s StreamStats) Attributes() map[string]any {
+	attrs := map[string]any{
+		"gen_ai.system":              s.Provider,
+		"gen_ai.request.model":       s.Model,
+		"gen_ai.usage.input_tokens":  s.Tokens.TotalInput(),
+		"gen_ai.usage.output_tokens": s.Tokens.TotalOutput(),
+		"llm.cost_usd":               s.Cost,
+		"llm.duration_ms":            s.Duration.Milliseconds(),
+	}
+	if s.ResolvedModel != "" {
+		attrs["gen_ai.response.model"] = s.ResolvedModel
+	}
+	if s.RequestID != "" {
+		attrs["gen_ai.response.id"] = s.RequestID
+	}
+	if s.StopReason != "" {
+		attrs["gen_ai.response.finish_reasons"] = []string{string(s.StopReason)}
+	}
+	if s.TimeToFirstToken > 0 {
+		attrs["llm.time_to_first_token_ms"] = s.TimeToFirstToken.Milliseconds()
+	}
+	if s.Err != nil {
+		attrs["error.type"] = errorType(s.Err)
+	}
+	return attrs
+}
+
+// errorType names the sentinel of a ProviderError, or "unknown".
+func errorType(err error) string {
+	if pe := AsProviderError("", err); pe.Sentinel != nil {
+		return pe.Sentinel.Error()
+	}
+	return "unknown"
+}
+
+// WithTelemetry instruments every CreateStream of providers that share
+// llm.Options (Bedrock included) with t. Use TelemetryMiddleware for other
+// providers or to instrument a whole Service.
+func WithTelemetry(t Telemetry) Option {
+	return func(o *Options) {
+		o.Telemetry = t
+	}
+}
+
+// TelemetryMiddleware instruments every CreateStream of the wrapped provider
+// with t.
+func TelemetryMiddleware(t Telemetry) Middleware {
+	return StreamMiddleware(func(ctx context.Context, src Buildable, next Provider) (Stream, error) {
+		req, err := src.BuildRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return InstrumentStream(ctx, t, StreamInfo{Provider: next.Name(), Model: req.Model}, func(ctx context.Context) (Stream, error) {
+			return next.CreateStream(ctx, req)
+		})
+	})
+}
+
+// InstrumentStream reports the stream created by create to t. A nil t calls
+// create directly.
+func InstrumentStream(ctx context.Context, t Telemetry, info StreamInfo, create func(ctx context.Context) (Stream, error)) (Stream, error) {
+	if t == nil {
+		return create(ctx)
+	}
+	ctx, end := t.StartStream(ctx, info)
+	start := time.Now()
+	stream, err := create(ctx)
+	stats := StreamStats{StreamInfo: info}
+	if err != nil {
+		stats.Duration = time.Since(start)
+		stats.Err = err
+		end(stats)
+		return nil, err
+	}
+	return ObserveStream(ctx, stream, func(env Envelope) {
+		switch ev := env.Data.(type) {
+		case *StreamStartedEvent:
+			stats.RequestID = ev.RequestID
+			stats.ResolvedModel = ev.Model
+		case *DeltaEvent:
+			if stats.TimeToFirstToken == 0 {
+				stats.TimeToFirstToken = time.Since(start)
+			}
+		case *UsageUpdatedEvent:
+			stats.Tokens = append(stats.Tokens, ev.Record.Tokens...)
+			stats.Cost += ev.Record.Cost.Total
+		case *CompletedEvent:
+			stats.StopReason = ev.StopReason
+		case *ErrorEvent:
+			stats.Err = ev.Error
+		}
+	}, func() {
+		stats.Duration = time.Since(start)
+		end(stats)
+	}), nil
+}
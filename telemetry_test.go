@@ -0,0 +1,75 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/usage"
+)
+
+func recordingTelemetry(stats *[]llm.StreamStats) llm.Telemetry {
+	return llm.TelemetryFunc(func(ctx context.Context, info llm.StreamInfo) (context.Context, func(llm.StreamStats)) {
+		return ctx, func(s llm.StreamStats) { *stats = append(*stats, s) }
+	})
+}
+
+func TestTelemetryMiddleware_ReportsStreamStats(t *testing.T) {
+	var stats []llm.StreamStats
+	p := llm.Wrap(&middlewareTestProvider{events: []llm.Event{
+		&llm.StreamStartedEvent{RequestID: "req_1", Model: "fake-model-2025"},
+		llmtest.TextEvent("hi"),
+		llmtest.UsageEvent(usage.Record{
+			Tokens: usage.TokenItems{{Kind: usage.KindInput, Count: 10}, {Kind: usage.KindOutput, Count: 2}},
+			Cost:   usage.Cost{Total: 0.5},
+		}),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	}}, llm.TelemetryMiddleware(recordingTelemetry(&stats)))
+
+	stream, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model"})
+	require.NoError(t, err)
+	require.NoError(t, llm.NewEventProcessor(context.Background(), stream).Result().Error())
+
+	require.Len(t, stats, 1)
+	s := stats[0]
+	assert.Equal(t, llm.StreamInfo{Provider: "fake", Model: "fake-model"}, s.StreamInfo)
+	assert.Equal(t, "req_1", s.RequestID)
+	assert.Equal(t, llm.StopReasonEndTurn, s.StopReason)
+	assert.InDelta(t, 0.5, s.Cost, 1e-9)
+
+	attrs := s.Attributes()
+	assert.Equal(t, "fake", attrs["gen_ai.system"])
+	assert.Equal(t, "fake-model-2025", attrs["gen_ai.response.model"])
+	assert.Equal(t, 10, attrs["gen_ai.usage.input_tokens"])
+	assert.Equal(t, 2, attrs["gen_ai.usage.output_tokens"])
+	assert.Equal(t, []string{"end_turn"}, attrs["gen_ai.response.finish_reasons"])
+	assert.NotContains(t, attrs, "error.type")
+}
+
+func TestTelemetryMiddleware_ReportsErrors(t *testing.T) {
+	var stats []llm.StreamStats
+	failing := llm.StreamMiddleware(func(context.Context, llm.Buildable, llm.Provider) (llm.Stream, error) {
+		return nil, llm.NewErrAPIError("fake", 500, "boom")
+	})
+	p := llm.Wrap(&middlewareTestProvider{}, llm.TelemetryMiddleware(recordingTelemetry(&stats)), failing)
+
+	_, err := p.CreateStream(context.Background(), llm.Request{Model: "fake-model"})
+	require.Error(t, err)
+	require.Len(t, stats, 1)
+	assert.True(t, errors.Is(stats[0].Err, llm.ErrAPIError))
+	assert.Equal(t, llm.ErrAPIError.Error(), stats[0].Attributes()["error.type"])
+}
+
+func TestInstrumentStream_NilTelemetry(t *testing.T) {
+	stream, err := llm.InstrumentStream(context.Background(), nil, llm.StreamInfo{}, func(context.Context) (llm.Stream, error) {
+		return llmtest.SendEvents(llmtest.TextEvent("hi")), nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hi", llm.NewEventProcessor(context.Background(), stream).Result().Text())
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "time"
+
+// Timing records when the phases of a request happened, as observed by the
+// provider. It is attached to CompletedEvent. Phases that were not observed
+// are zero.
+type Timing struct {
+	// RequestStart is when the request was sent.
+	RequestStart time.Time `json:"request_start"`
+	// FirstByte is when the response headers arrived.
+	FirstByte time.Time `json:"first_byte,omitempty"`
+	// FirstDelta is when the first delta or tool call arrived.
+	FirstDelta time.Time `json:"first_delta,omitempty"`
+	// Completed is when the response completed.
+	Completed time.Time `json:"completed"`
+}
+
+// TimeToFirstByte is the time from RequestStart to FirstByte, or zero.
+func (t Timing) TimeToFirstByte() time.Duration { return t.since(t.FirstByte) }
+
+// TimeToFirstToken is the time from RequestStart to FirstDelta, or zero.
+func (t Timing) TimeToFirstToken() time.Duration { return t.since(t.FirstDelta) }
+
+// Duration is the time from RequestStart to Completed, or zero.
+func (t Timing) Duration() time.Duration { return t.since(t.Completed) }
+
+func (t Timing) since(at time.Time) time.Duration {
+	if t.RequestStart.IsZero() || at.IsZero() {
+		return 0
+	}
+	return at.Sub(t.RequestStart)
+}
+
+// MarkFirstDelta sets FirstDelta to now unless it is already set.
+func (t *Timing) MarkFirstDelta() {
+	if t.FirstDelta.IsZero() {
+		t.FirstDelta = time.Now()
+	}
+}
+
+// Complete sets Completed to now and returns a copy for a CompletedEvent.
+func (t *Timing) Complete() *Timing {
+	t.Completed = time.Now()
+	c := *t
+	return &c
+}
@@ -0,0 +1,76 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledArgsBuffer caps the buffers kept for reuse so one very large call
+// does not pin its memory.
+const maxPooledArgsBuffer = 1 << 20
+
+var argsBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// decodeArgs converts already-decoded call arguments into T. The JSON
+// round-trip goes through a pooled buffer instead of allocating a fresh
+// encoding per call; arguments destined for Args are deep-copied without a
+// round-trip, so handlers may edit them without touching the call.
+func decodeArgs[T any](args Args, out *T) *argsError {
+	switch p := any(out).(type) {
+	case *Args:
+		*p, _ = cloneValue(args).(Args)
+		return nil
+	}
+
+	buf := argsBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledArgsBuffer {
+			argsBufferPool.Put(buf)
+		}
+	}()
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(args); err != nil {
+		return &argsError{op: "marshal", err: err}
+	}
+	if err := json.Unmarshal(buf.Bytes(), out); err != nil {
+		return &argsError{op: "parse", err: err}
+	}
+	return nil
+}
+
+// argsError records which half of the round-trip failed ("marshal" or
+// "parse") so callers can word their errors.
+type argsError struct {
+	op  string
+	err error
+}
+
+// cloneValue deep-copies the maps and slices of a decoded JSON value; other
+// values are returned as is.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = cloneValue(e)
+		}
+		return out
+	case []any:
+		if v == nil {
+			return v
+		}
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = cloneValue(e)
+		}
+		return out
+	}
+	return v
+}
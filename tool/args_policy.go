@@ -0,0 +1,155 @@
+package tool
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// ArgsPolicy controls how a Set repairs tool call arguments before they are
+// validated against the tool's schema. Models regularly produce slightly
+// malformed arguments — "5" for an integer, a bare string where a list is
+// expected — and the zero value keeps the strict behaviour: such calls fail
+// validation and the error is reported back to the model.
+//
+// Repairs are applied to a canonical copy of the arguments (see
+// CanonicalArgs); the original call is never modified.
+type ArgsPolicy struct {
+	// Coerce converts values to the type the schema declares when the
+	// conversion is lossless: numeric and boolean strings become numbers and
+	// booleans, numbers and booleans become strings, and a single value
+	// becomes a one-element array.
+	Coerce bool
+
+	// DropUnknown removes properties that an object schema does not declare
+	// instead of rejecting the call. Objects that allow additional
+	// properties are left alone.
+	DropUnknown bool
+
+	// Defaults fills in missing properties from the schema's "default"
+	// keyword (set with the jsonschema:"default=..." struct tag).
+	Defaults bool
+}
+
+func (p ArgsPolicy) enabled() bool {
+	return p.Coerce || p.DropUnknown || p.Defaults
+}
+
+// apply returns call with its arguments repaired against schema, or call
+// itself when the policy is disabled.
+func (p ArgsPolicy) apply(schema map[string]any, call Call) Call {
+	if !p.enabled() || schema == nil {
+		return call
+	}
+	args := CanonicalArgs(call.ToolArgs())
+	if args == nil {
+		args = Args{}
+	}
+	p.repairObject(schema, args)
+	return NewToolCall(call.ToolCallID(), call.ToolName(), args)
+}
+
+func (p ArgsPolicy) repair(schema map[string]any, v any) any {
+	switch schemaType(schema) {
+	case "object":
+		if obj, ok := v.(map[string]any); ok {
+			p.repairObject(schema, obj)
+		}
+		return v
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			if !p.Coerce || v == nil {
+				return v
+			}
+			arr = []any{v}
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i := range arr {
+				arr[i] = p.repair(items, arr[i])
+			}
+		}
+		return arr
+	case "integer":
+		return p.coerceNumber(v, true)
+	case "number":
+		return p.coerceNumber(v, false)
+	case "boolean":
+		if s, ok := v.(string); ok && p.Coerce {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+		return v
+	case "string":
+		if !p.Coerce {
+			return v
+		}
+		switch x := v.(type) {
+		case float64:
+			return strconv.FormatFloat(x, 'f', -1, 64)
+		case json.Number:
+			return x.String()
+		case bool:
+			return strconv.FormatBool(x)
+		}
+		return v
+	}
+	return v
+}
+
+func (p ArgsPolicy) repairObject(schema map[string]any, obj map[string]any) {
+	props, _ := schema["properties"].(map[string]any)
+	for name, v := range obj {
+		prop, ok := props[name].(map[string]any)
+		if ok {
+			obj[name] = p.repair(prop, v)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra && p.DropUnknown {
+				delete(obj, name)
+			}
+		case map[string]any:
+			obj[name] = p.repair(extra, v)
+		}
+	}
+	if !p.Defaults {
+		return
+	}
+	for name, raw := range props {
+		prop, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, present := obj[name]; present {
+			continue
+		}
+		if def, ok := prop["default"]; ok {
+			obj[name] = canonicalValue(def)
+		}
+	}
+}
+
+func (p ArgsPolicy) coerceNumber(v any, integer bool) any {
+	s, ok := v.(string)
+	if !ok || !p.Coerce {
+		return v
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return v
+	}
+	if integer && f != math.Trunc(f) {
+		return v
+	}
+	return f
+}
+
+// schemaType returns the single type a schema declares, or "" when it
+// declares none or several.
+func schemaType(schema map[string]any) string {
+	t, _ := schema["type"].(string)
+	return t
+}
@@ -0,0 +1,107 @@
+package tool
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policyParams struct {
+	Count  int      `json:"count" jsonschema:"required"`
+	Ratio  float64  `json:"ratio,omitempty"`
+	Force  bool     `json:"force,omitempty"`
+	Label  string   `json:"label,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Limit  int      `json:"limit,omitempty" jsonschema:"default=10"`
+	Nested struct {
+		Depth int `json:"depth,omitempty"`
+	} `json:"nested,omitempty"`
+}
+
+func parsePolicy(t *testing.T, p ArgsPolicy, args Args) (*TypedToolCall[policyParams], error) {
+	t.Helper()
+	ts := NewToolSet(NewSpec[policyParams]("p", "policy")).WithArgsPolicy(p)
+	calls, err := ts.Parse([]Call{NewToolCall("c1", "p", args)})
+	if err != nil {
+		return nil, err
+	}
+	require.Len(t, calls, 1)
+	return calls[0].(*TypedToolCall[policyParams]), nil
+}
+
+func TestArgsPolicy_StrictByDefault(t *testing.T) {
+	_, err := parsePolicy(t, ArgsPolicy{}, Args{"count": "5"})
+	assert.Error(t, err)
+
+	_, err = parsePolicy(t, ArgsPolicy{}, Args{"count": 5, "extra": true})
+	assert.Error(t, err, "unknown fields are rejected")
+}
+
+func TestArgsPolicy_Coerce(t *testing.T) {
+	call, err := parsePolicy(t, ArgsPolicy{Coerce: true}, Args{
+		"count":  "5",
+		"ratio":  "0.5",
+		"force":  "true",
+		"label":  42,
+		"tags":   "solo",
+		"nested": map[string]any{"depth": "3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "c1", call.ID)
+	assert.Equal(t, 5, call.Params.Count)
+	assert.Equal(t, 0.5, call.Params.Ratio)
+	assert.True(t, call.Params.Force)
+	assert.Equal(t, "42", call.Params.Label)
+	assert.Equal(t, []string{"solo"}, call.Params.Tags)
+	assert.Equal(t, 3, call.Params.Nested.Depth)
+}
+
+func TestArgsPolicy_CoerceIsLossless(t *testing.T) {
+	_, err := parsePolicy(t, ArgsPolicy{Coerce: true}, Args{"count": "5.5"})
+	assert.Error(t, err, "fractional string must not become an integer")
+
+	_, err = parsePolicy(t, ArgsPolicy{Coerce: true}, Args{"count": "five"})
+	assert.Error(t, err)
+}
+
+func TestArgsPolicy_DropUnknown(t *testing.T) {
+	call, err := parsePolicy(t, ArgsPolicy{DropUnknown: true}, Args{
+		"count":  1,
+		"extra":  true,
+		"nested": map[string]any{"depth": 2, "width": 4},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, call.Params.Count)
+	assert.Equal(t, 2, call.Params.Nested.Depth)
+}
+
+func TestArgsPolicy_Defaults(t *testing.T) {
+	call, err := parsePolicy(t, ArgsPolicy{Defaults: true}, Args{"count": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 10, call.Params.Limit)
+
+	call, err = parsePolicy(t, ArgsPolicy{Defaults: true}, Args{"count": 1, "limit": 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, call.Params.Limit, "explicit values win")
+
+	call, err = parsePolicy(t, ArgsPolicy{}, Args{"count": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 0, call.Params.Limit)
+}
+
+func TestArgsPolicy_ExecuteUsesRepairedArgs(t *testing.T) {
+	spec := NewSpec[policyParams]("p", "policy").
+		WithHandler(func(_ context.Context, in policyParams) (string, error) {
+			return strconv.Itoa(in.Count + in.Limit), nil
+		})
+	ts := NewToolSet(spec).WithArgsPolicy(ArgsPolicy{Coerce: true, Defaults: true})
+
+	args := Args{"count": "5"}
+	res := ts.ExecuteCall(context.Background(), NewToolCall("c1", "p", args))
+	require.False(t, res.IsError(), "%v", res.ToolOutput())
+	assert.Equal(t, "15", res.ToolOutput())
+	assert.Equal(t, Args{"count": "5"}, args, "original args are not modified")
+}
@@ -0,0 +1,160 @@
+package tool
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxExactInt is the largest integer magnitude a float64 represents exactly.
+const maxExactInt = 1 << 53
+
+// CanonicalArgs returns a deep copy of args reduced to plain JSON value
+// types, so that arguments that mean the same thing compare and serialise the
+// same regardless of how they were built:
+//
+//   - objects become map[string]any and arrays []any, at every level
+//   - numbers become float64 (-0 becomes 0, float32 values keep their
+//     shortest decimal form); integers beyond ±2^53 stay exact as json.Number
+//   - other values (structs, typed maps and slices) are round-tripped
+//     through JSON
+//
+// Use CanonicalJSON or Fingerprint when a byte-stable form is needed.
+func CanonicalArgs(args Args) Args {
+	if args == nil {
+		return nil
+	}
+	out := make(Args, len(args))
+	for k, v := range args {
+		out[k] = canonicalValue(v)
+	}
+	return out
+}
+
+// CanonicalJSON returns the canonical JSON encoding of args: keys sorted at
+// every level, numbers normalised as in CanonicalArgs, and no HTML escaping.
+// Nil args encode as {}.
+func CanonicalJSON(args Args) ([]byte, error) {
+	if args == nil {
+		args = Args{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(CanonicalArgs(args)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Fingerprint returns a stable hex digest of a call's name and canonical
+// arguments. The call ID is not included, so two calls requesting the same
+// operation share a fingerprint. Use it for deduplication and cache keys.
+func Fingerprint(call Call) string {
+	h := sha256.New()
+	h.Write([]byte(call.ToolName()))
+	h.Write([]byte{0})
+	data, err := CanonicalJSON(call.ToolArgs())
+	if err != nil {
+		// Only reachable for values JSON cannot represent (NaN, channels);
+		// fmt prints maps in sorted key order, which is still stable.
+		data = []byte(fmt.Sprint(CanonicalArgs(call.ToolArgs())))
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EqualArgs reports whether a and b are equal after canonicalisation.
+func EqualArgs(a, b Args) bool {
+	ja, errA := CanonicalJSON(a)
+	jb, errB := CanonicalJSON(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+func canonicalValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v
+	case map[string]any:
+		return CanonicalArgs(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = canonicalValue(e)
+		}
+		return out
+	case float64:
+		return canonicalFloat(v)
+	case float32:
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return canonicalFloat(f)
+	case int:
+		return canonicalInt(int64(v))
+	case int8:
+		return canonicalInt(int64(v))
+	case int16:
+		return canonicalInt(int64(v))
+	case int32:
+		return canonicalInt(int64(v))
+	case int64:
+		return canonicalInt(v)
+	case uint:
+		return canonicalUint(uint64(v))
+	case uint8:
+		return canonicalUint(uint64(v))
+	case uint16:
+		return canonicalUint(uint64(v))
+	case uint32:
+		return canonicalUint(uint64(v))
+	case uint64:
+		return canonicalUint(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return canonicalInt(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return canonicalFloat(f)
+		}
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var decoded any
+		if err := dec.Decode(&decoded); err != nil {
+			return v
+		}
+		return canonicalValue(decoded)
+	}
+}
+
+func canonicalFloat(f float64) any {
+	if f == 0 {
+		return float64(0)
+	}
+	if f == math.Trunc(f) && math.Abs(f) > maxExactInt && math.Abs(f) < math.MaxInt64 {
+		return canonicalInt(int64(f))
+	}
+	return f
+}
+
+func canonicalInt(i int64) any {
+	if i >= -maxExactInt && i <= maxExactInt {
+		return float64(i)
+	}
+	return json.Number(strconv.FormatInt(i, 10))
+}
+
+func canonicalUint(u uint64) any {
+	if u <= maxExactInt {
+		return float64(u)
+	}
+	return json.Number(strconv.FormatUint(u, 10))
+}
@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalJSON_SortsKeysAtEveryLevel(t *testing.T) {
+	b, err := CanonicalJSON(Args{
+		"z": 1,
+		"a": map[string]any{"y": true, "b": []any{map[string]any{"d": 1, "c": 2}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"b":[{"c":2,"d":1}],"y":true},"z":1}`, string(b))
+}
+
+func TestCanonicalJSON_NormalizesNumbers(t *testing.T) {
+	variants := []Args{
+		{"n": 1, "f": 0.5},
+		{"n": int64(1), "f": float32(0.5)},
+		{"n": uint8(1), "f": json.Number("5e-1")},
+		{"n": json.Number("1.0"), "f": 0.5},
+		{"n": float64(1), "f": json.Number("0.50")},
+	}
+	for _, args := range variants {
+		b, err := CanonicalJSON(args)
+		require.NoError(t, err)
+		assert.Equal(t, `{"f":0.5,"n":1}`, string(b), "%#v", args)
+	}
+
+	b, err := CanonicalJSON(Args{"z": math.Copysign(0, -1), "f32": float32(0.1)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"f32":0.1,"z":0}`, string(b))
+}
+
+func TestCanonicalJSON_KeepsLargeIntegersExact(t *testing.T) {
+	b, err := CanonicalJSON(Args{"a": int64(9007199254740993), "b": json.Number("9007199254740993"), "c": uint64(math.MaxUint64)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":9007199254740993,"b":9007199254740993,"c":18446744073709551615}`, string(b))
+}
+
+func TestCanonicalJSON_TypedValuesAndEscaping(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	b, err := CanonicalJSON(Args{
+		"p":    point{X: 1, Y: 2},
+		"tags": []string{"a", "b"},
+		"m":    map[string]int{"b": 2, "a": 1},
+		"html": "<a & b>",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"html":"<a & b>","m":{"a":1,"b":2},"p":{"x":1,"y":2},"tags":["a","b"]}`, string(b))
+
+	b, err = CanonicalJSON(nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(b))
+}
+
+func TestCanonicalArgs_DoesNotMutateInput(t *testing.T) {
+	nested := map[string]any{"n": 1}
+	args := Args{"nested": nested}
+	out := CanonicalArgs(args)
+	out["nested"].(map[string]any)["n"] = 2.0
+	assert.Equal(t, 1, nested["n"])
+}
+
+func TestFingerprint(t *testing.T) {
+	a := NewToolCall("call_1", "search", Args{"q": "go", "limit": 10})
+	b := NewToolCall("call_2", "search", Args{"limit": json.Number("10.0"), "q": "go"})
+	c := NewToolCall("call_3", "search", Args{"q": "go", "limit": 11})
+	d := NewToolCall("call_4", "lookup", Args{"q": "go", "limit": 10})
+
+	assert.Equal(t, Fingerprint(a), Fingerprint(b))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(c))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(d))
+	assert.Len(t, Fingerprint(a), 64)
+
+	assert.True(t, EqualArgs(a.ToolArgs(), b.ToolArgs()))
+	assert.False(t, EqualArgs(a.ToolArgs(), c.ToolArgs()))
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/invopop/jsonschema"
 )
@@ -16,6 +18,13 @@ type Definition struct {
 	Parameters  map[string]any `json:"parameters"`
 }
 
+// Clone returns a copy of t whose Parameters schema shares no maps or
+// slices with t.
+func (t Definition) Clone() Definition {
+	t.Parameters, _ = cloneValue(t.Parameters).(map[string]any)
+	return t
+}
+
 type DefinitionProvider interface {
 	ToolDefinitions() []Definition
 }
@@ -45,6 +54,17 @@ func (t Definition) Validate() error {
 //   - `jsonschema:"required"` - Marks the parameter as required
 //   - `jsonschema:"enum=val1,enum=val2"` - Restricts to specific values
 //
+// Type mappings follow encoding/json, so arguments that match the schema
+// decode into T:
+//   - Embedded structs are flattened into the parent unless they carry a
+//     json name; their required fields stay required.
+//   - Maps with string keys become objects whose additionalProperties
+//     describe the values.
+//   - time.Time is a string with format date-time (RFC 3339).
+//   - time.Duration is an integer number of nanoseconds.
+//   - json.RawMessage and interface fields accept any JSON value; their
+//     schema has no type.
+//
 // Example:
 //
 //	type GetWeatherParams struct {
@@ -53,12 +73,32 @@ func (t Definition) Validate() error {
 //	}
 //
 //	tool := DefinitionFor[GetWeatherParams]("get_weather", "Get current weather")
-func DefinitionFor[T any](name, description string) Definition {
+//
+// opts adjust the generated schema after T's SchemaExtender, if any; see
+// SchemaOption. Options after one that fails are not applied; use
+// NewDefinition to get the error.
+func DefinitionFor[T any](name, description string, opts ...SchemaOption) Definition {
+	def, _ := definitionFor[T](name, description, opts)
+	return def
+}
+
+// NewDefinition is like DefinitionFor but returns the error of the first
+// SchemaOption that fails, e.g. WithProperty with an unknown path.
+func NewDefinition[T any](name, description string, opts ...SchemaOption) (Definition, error) {
+	def, err := definitionFor[T](name, description, opts)
+	if err != nil {
+		return Definition{}, err
+	}
+	return def, nil
+}
+
+func definitionFor[T any](name, description string, opts []SchemaOption) (Definition, error) {
 	r := jsonschema.Reflector{
 		DoNotReference:             true,  // Inline all types, no $defs
 		Anonymous:                  true,  // No $id field
 		AllowAdditionalProperties:  false, // Require strict schema: additionalProperties: false
 		RequiredFromJSONSchemaTags: true,  // Use jsonschema:"required" instead of all fields
+		Mapper:                     mapSchemaType,
 	}
 	schema := r.Reflect(new(T))
 	schema.Version = "" // Strip $schema URI
@@ -71,10 +111,64 @@ func DefinitionFor[T any](name, description string) Definition {
 	// Clean up any remaining metadata fields
 	delete(params, "$schema")
 	delete(params, "$id")
+	finishSchema(params)
+	err := applySchemaOptions[T](params, opts)
 
 	return Definition{
 		Name:        name,
 		Description: description,
 		Parameters:  params,
+	}, err
+}
+
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// goTypeKey marks schemas produced by mapSchemaType until finishSchema
+// replaces the marker with a default description.
+const goTypeKey = "x-go-type"
+
+// goTypeDescriptions are the default descriptions of mapped types, used when
+// the field has none.
+var goTypeDescriptions = map[string]string{
+	"duration": "Duration in nanoseconds",
+	"any":      "Any JSON value",
+}
+
+// mapSchemaType overrides the reflector for types whose default schema
+// surprises models or providers. It returns nil for all other types.
+func mapSchemaType(t reflect.Type) *jsonschema.Schema {
+	switch {
+	case t == durationType:
+		return &jsonschema.Schema{Type: "integer", Extras: map[string]any{goTypeKey: "duration"}}
+	case t == rawMessageType, t.Kind() == reflect.Interface && t.NumMethod() == 0:
+		// The reflector emits the boolean schema true here, which cannot
+		// carry a description and which some providers reject. A schema
+		// without a type accepts the same values.
+		return &jsonschema.Schema{Extras: map[string]any{goTypeKey: "any"}}
+	}
+	return nil
+}
+
+// finishSchema replaces the markers left by mapSchemaType. The reflector
+// overwrites descriptions from field tags, so defaults are applied here.
+func finishSchema(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		if kind, ok := n[goTypeKey].(string); ok {
+			delete(n, goTypeKey)
+			if _, ok := n["description"]; !ok {
+				n["description"] = goTypeDescriptions[kind]
+			}
+		}
+		for _, v := range n {
+			finishSchema(v)
+		}
+	case []any:
+		for _, v := range n {
+			finishSchema(v)
+		}
 	}
 }
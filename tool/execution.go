@@ -0,0 +1,107 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ExecutionKey returns the key under which Idempotent records the outcome of
+// call. It combines the call ID with the call's Fingerprint, so a call that
+// reuses an ID with different arguments is executed again.
+func ExecutionKey(call Call) string {
+	return call.ToolCallID() + ":" + Fingerprint(call)
+}
+
+// Execution is the recorded outcome of one tool call.
+type Execution struct {
+	Output any    `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ExecutionStore persists tool call outcomes by execution key. Implementations
+// backed by durable storage let a restarted process skip tools that already
+// ran before a crash.
+type ExecutionStore interface {
+	LoadExecution(ctx context.Context, key string) (Execution, bool, error)
+	SaveExecution(ctx context.Context, key string, e Execution) error
+}
+
+// MemoryExecutionStore is an in-process ExecutionStore. It is safe for
+// concurrent use.
+type MemoryExecutionStore struct {
+	mu    sync.RWMutex
+	items map[string]Execution
+}
+
+// NewMemoryExecutionStore returns an empty MemoryExecutionStore.
+func NewMemoryExecutionStore() *MemoryExecutionStore {
+	return &MemoryExecutionStore{items: make(map[string]Execution)}
+}
+
+// LoadExecution implements ExecutionStore.
+func (s *MemoryExecutionStore) LoadExecution(_ context.Context, key string) (Execution, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.items[key]
+	return e, ok, nil
+}
+
+// SaveExecution implements ExecutionStore.
+func (s *MemoryExecutionStore) SaveExecution(_ context.Context, key string, e Execution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = e
+	return nil
+}
+
+// Idempotent wraps h so each call runs at most once per ExecutionKey. The
+// outcome of the first run, including a handler error, is saved to store and
+// replayed for later calls with the same key. A failed load aborts the call
+// without running the handler; a failed save is returned as an error.
+//
+// If h carries a definition (such as a *BoundToolSpec), the returned handler
+// does too.
+func Idempotent(h NamedHandler, store ExecutionStore) NamedHandler {
+	ih := &idempotentHandler{h: h, store: store}
+	if dh, ok := h.(interface{ Definition() Definition }); ok {
+		return &idempotentSpecHandler{idempotentHandler: ih, def: dh}
+	}
+	return ih
+}
+
+type idempotentHandler struct {
+	h     NamedHandler
+	store ExecutionStore
+}
+
+func (h *idempotentHandler) ToolName() string { return h.h.ToolName() }
+
+func (h *idempotentHandler) Handle(ctx context.Context, call Call) (any, error) {
+	key := ExecutionKey(call)
+	if e, ok, err := h.store.LoadExecution(ctx, key); err != nil {
+		return nil, err
+	} else if ok {
+		if e.Error != "" {
+			return nil, errors.New(e.Error)
+		}
+		return e.Output, nil
+	}
+
+	out, err := h.h.Handle(ctx, call)
+	e := Execution{Output: out}
+	if err != nil {
+		e = Execution{Error: err.Error()}
+	}
+	if saveErr := h.store.SaveExecution(ctx, key, e); saveErr != nil {
+		return nil, errors.Join(err, saveErr)
+	}
+	return out, err
+}
+
+type idempotentSpecHandler struct {
+	*idempotentHandler
+	def interface{ Definition() Definition }
+}
+
+func (h *idempotentSpecHandler) Definition() Definition { return h.def.Definition() }
@@ -0,0 +1,92 @@
+package tool
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// SchemaOption adjusts the JSON Schema generated for a tool's parameters,
+// for constraints the struct-tag DSL cannot express such as oneOf,
+// patternProperties or format. It receives the parameters schema as sent to
+// providers and may modify it in place, or fail if it does not fit the
+// schema.
+type SchemaOption func(params map[string]any) error
+
+// SchemaExtender is implemented by parameter types that adjust their own
+// generated schema. DefinitionFor calls ExtendSchema before applying any
+// SchemaOption.
+//
+// Nested types can instead implement JSONSchemaExtend(*jsonschema.Schema)
+// from github.com/invopop/jsonschema, which the reflector calls for every
+// occurrence of the type.
+type SchemaExtender interface {
+	ExtendSchema(params map[string]any)
+}
+
+// WithProperty merges patch into the schema of the property at path. Path
+// segments are separated by dots and descend through nested objects and
+// array items, e.g. "filter.labels" or "items.name". A nil value in patch
+// removes that keyword.
+//
+// WithProperty fails when path does not name a property of the generated
+// schema; see Spec.Err.
+//
+// Example:
+//
+//	spec := NewSpec[SearchParams]("search", "Search documents",
+//	    WithProperty("labels", map[string]any{
+//	        "propertyNames": map[string]any{"pattern": "^[a-z_]+$"},
+//	    }),
+//	)
+func WithProperty(path string, patch map[string]any) SchemaOption {
+	return func(params map[string]any) error {
+		node := params
+		for _, seg := range strings.Split(path, ".") {
+			node = property(node, seg)
+			if node == nil {
+				return fmt.Errorf("tool: schema has no property %q", path)
+			}
+		}
+		mergeSchema(node, patch)
+		return nil
+	}
+}
+
+// property returns the schema of the named property of node, looking
+// through array items.
+func property(node map[string]any, name string) map[string]any {
+	if items, ok := node["items"].(map[string]any); ok {
+		node = items
+	}
+	props, _ := node["properties"].(map[string]any)
+	prop, _ := props[name].(map[string]any)
+	return prop
+}
+
+func mergeSchema(dst, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		if m, ok := v.(map[string]any); ok {
+			v = maps.Clone(m)
+		}
+		dst[k] = v
+	}
+}
+
+// applySchemaOptions runs T's SchemaExtender and then opts on params. It
+// stops at the first option that fails.
+func applySchemaOptions[T any](params map[string]any, opts []SchemaOption) error {
+	if ext, ok := any(new(T)).(SchemaExtender); ok {
+		ext.ExtendSchema(params)
+	}
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
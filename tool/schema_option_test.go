@@ -0,0 +1,148 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchFilter struct {
+	Labels map[string]string `json:"labels"`
+}
+
+type searchHit struct {
+	Name string `json:"name"`
+}
+
+type searchParams struct {
+	Query  string       `json:"query" jsonschema:"required"`
+	Filter searchFilter `json:"filter"`
+	Hits   []searchHit  `json:"hits"`
+}
+
+func TestWithProperty(t *testing.T) {
+	def := DefinitionFor[searchParams]("search", "Search",
+		WithProperty("query", map[string]any{"format": "uri"}),
+		WithProperty("filter.labels", map[string]any{
+			"additionalProperties": false,
+			"patternProperties":    map[string]any{"^[a-z_]+$": map[string]any{"type": "string"}},
+		}),
+		WithProperty("hits.name", map[string]any{"type": nil, "oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "null"},
+		}}),
+	)
+
+	props := def.Parameters["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "format": "uri"}, props["query"])
+
+	labels := props["filter"].(map[string]any)["properties"].(map[string]any)["labels"].(map[string]any)
+	assert.Equal(t, false, labels["additionalProperties"])
+	assert.Contains(t, labels, "patternProperties")
+
+	name := props["hits"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)["name"].(map[string]any)
+	assert.NotContains(t, name, "type", "nil removes a keyword")
+	assert.Len(t, name["oneOf"], 2)
+}
+
+func TestWithProperty_UnknownPath(t *testing.T) {
+	spec := NewSpec[searchParams]("search", "Search", WithProperty("filter.tags", map[string]any{"minItems": 1}))
+	require.EqualError(t, spec.Err(), `tool: schema has no property "filter.tags"`)
+
+	_, err := spec.parse(&toolCall{ID: "1", Name: "search", Args: map[string]any{"query": "q"}})
+	assert.ErrorIs(t, err, spec.Err())
+
+	assert.NotPanics(t, func() {
+		DefinitionFor[searchParams]("search", "Search", WithProperty("filter.tags", map[string]any{}))
+	})
+}
+
+func TestNewDefinition_UnknownPath(t *testing.T) {
+	_, err := NewDefinition[searchParams]("search", "Search", WithProperty("filter.tags", map[string]any{}))
+	require.EqualError(t, err, `tool: schema has no property "filter.tags"`)
+
+	def, err := NewDefinition[searchParams]("search", "Search", WithProperty("query", map[string]any{"format": "uri"}))
+	require.NoError(t, err)
+	assert.Equal(t, "search", def.Name)
+}
+
+func TestSet_SpecErr(t *testing.T) {
+	bad := NewSpec[searchParams]("bad", "Search", WithProperty("filter.tags", map[string]any{}))
+	good := NewSpec[searchParams]("good", "Search")
+
+	set := NewToolSet(good, bad)
+	require.ErrorIs(t, set.Err(), bad.Err())
+	require.Len(t, set.Definitions(), 1, "the broken definition is not sent")
+	assert.Equal(t, "good", set.Definitions()[0].Name)
+
+	_, err := NewToolSet(good).Merge(NewToolSet(bad))
+	assert.ErrorIs(t, err, bad.Err())
+	_, err = set.Merge(NewToolSet())
+	assert.ErrorIs(t, err, bad.Err())
+}
+
+func TestNewSpec_ValidatesAgainstOverrides(t *testing.T) {
+	spec := NewSpec[searchParams]("search", "Search",
+		WithProperty("filter.labels", map[string]any{
+			"propertyNames": map[string]any{"pattern": "^[a-z_]+$"},
+		}),
+	)
+	require.NoError(t, spec.Err())
+
+	_, err := spec.parse(&toolCall{ID: "1", Name: "search", Args: map[string]any{
+		"query":  "q",
+		"filter": map[string]any{"labels": map[string]any{"team": "core"}},
+	}})
+	require.NoError(t, err)
+
+	_, err = spec.parse(&toolCall{ID: "2", Name: "search", Args: map[string]any{
+		"query":  "q",
+		"filter": map[string]any{"labels": map[string]any{"Team-Name": "core"}},
+	}})
+	assert.Error(t, err)
+}
+
+type rangeParams struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	Last int `json:"last"`
+}
+
+// ExtendSchema requires either from and to, or last.
+func (rangeParams) ExtendSchema(params map[string]any) {
+	params["oneOf"] = []any{
+		map[string]any{"required": []any{"from", "to"}},
+		map[string]any{"required": []any{"last"}},
+	}
+}
+
+func TestSchemaExtender(t *testing.T) {
+	spec := NewSpec[rangeParams]("range", "Pick a range",
+		WithProperty("last", map[string]any{"minimum": 1}),
+	)
+	params := spec.Definition().Parameters
+	assert.Len(t, params["oneOf"], 2)
+	assert.Equal(t, 1, params["properties"].(map[string]any)["last"].(map[string]any)["minimum"], "options run after the extender")
+
+	_, err := spec.parse(&toolCall{ID: "1", Name: "range", Args: map[string]any{"last": 5}})
+	require.NoError(t, err)
+	_, err = spec.parse(&toolCall{ID: "2", Name: "range", Args: map[string]any{"from": 1}})
+	assert.Error(t, err)
+}
+
+type color string
+
+func (color) JSONSchemaExtend(s *jsonschema.Schema) {
+	s.Pattern = "^#[0-9a-f]{6}$"
+}
+
+func TestDefinitionFor_NestedJSONSchemaExtend(t *testing.T) {
+	type Params struct {
+		Colors []color `json:"colors"`
+	}
+	props := DefinitionFor[Params]("paint", "Paint").Parameters["properties"].(map[string]any)
+	items := props["colors"].(map[string]any)["items"].(map[string]any)
+	assert.Equal(t, "^#[0-9a-f]{6}$", items["pattern"])
+}
@@ -1,6 +1,7 @@
 package tool
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -13,9 +14,14 @@ import (
 type Set struct {
 	tools []toolRegistration          // ordered for Definitions()
 	index map[string]toolRegistration // keyed by name for Parse()
+
+	policy ArgsPolicy
+	err    error // joined Spec.Err of the tools
 }
 
-// NewToolSet creates a Set from one or more tool specs.
+// NewToolSet creates a Set from one or more tool specs. Specs whose schema
+// options failed are kept so their calls report the error, but they are
+// left out of Definitions; Err returns their errors.
 //
 // Example:
 //
@@ -25,21 +31,138 @@ type Set struct {
 //	)
 func NewToolSet(tools ...toolRegistration) *Set {
 	index := make(map[string]toolRegistration, len(tools))
+	var errs []error
 	for _, tool := range tools {
 		def := tool.Definition()
 		index[def.Name] = tool
+		if err := tool.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("tool %s: %w", def.Name, err))
+		}
 	}
 	return &Set{
 		tools: tools,
 		index: index,
+		err:   errors.Join(errs...),
 	}
 }
 
-// Definitions returns all tool definitions for sending to providers.
-func (ts *Set) Definitions() []Definition {
-	defs := make([]Definition, len(ts.tools))
+// Err returns the errors of the specs in ts whose schema options failed
+// (see Spec.Err), or nil.
+func (ts *Set) Err() error { return ts.err }
+
+// WithArgsPolicy sets how arguments are repaired before validation in Parse,
+// Execute and ExecuteCall, and returns the Set for chaining. Handlers receive
+// the repaired arguments.
+//
+// Example:
+//
+//	tools := NewToolSet(specs...).WithArgsPolicy(ArgsPolicy{Coerce: true, Defaults: true})
+func (ts *Set) WithArgsPolicy(p ArgsPolicy) *Set {
+	ts.policy = p
+	return ts
+}
+
+// Merge returns a new Set with the tools of ts followed by those of other.
+// The result keeps the argument policy of ts. Merge fails if a tool of
+// other has the same name as a tool of ts or an earlier tool of other (use
+// Prefix to namespace one of the sets), or if either set has an Err.
+//
+// Example:
+//
+//	tools, err := fsTools.Merge(webTools.Prefix("web_"))
+func (ts *Set) Merge(other *Set) (*Set, error) {
+	if err := errors.Join(ts.err, other.err); err != nil {
+		return nil, err
+	}
+	tools := make([]toolRegistration, 0, len(ts.tools)+len(other.tools))
+	tools = append(tools, ts.tools...)
+	seen := make(map[string]bool, len(ts.index)+len(other.tools))
+	for name := range ts.index {
+		seen[name] = true
+	}
+	for _, tool := range other.tools {
+		name := tool.Definition().Name
+		if seen[name] {
+			return nil, fmt.Errorf("tool: duplicate tool %q", name)
+		}
+		seen[name] = true
+		tools = append(tools, tool)
+	}
+	return NewToolSet(tools...).WithArgsPolicy(ts.policy), nil
+}
+
+// Only returns a new Set containing just the named tools, in their original
+// order. Names that are not in ts are ignored.
+func (ts *Set) Only(names ...string) *Set {
+	keep := nameSet(names)
+	return ts.filter(func(name string) bool { return keep[name] })
+}
+
+// Except returns a new Set without the named tools. Names that are not in ts
+// are ignored.
+func (ts *Set) Except(names ...string) *Set {
+	drop := nameSet(names)
+	return ts.filter(func(name string) bool { return !drop[name] })
+}
+
+// Prefix returns a new Set in which every tool name is prefixed with prefix,
+// so that sets from different modules can be merged without collisions.
+// Calls are matched by the prefixed name; handlers and schemas are unchanged.
+//
+// Example:
+//
+//	gh := githubTools.Prefix("github_") // "search" becomes "github_search"
+func (ts *Set) Prefix(prefix string) *Set {
+	tools := make([]toolRegistration, len(ts.tools))
 	for i, tool := range ts.tools {
-		defs[i] = tool.Definition()
+		if p, ok := tool.(*prefixedTool); ok {
+			tools[i] = &prefixedTool{toolRegistration: p.toolRegistration, name: prefix + p.name}
+			continue
+		}
+		tools[i] = &prefixedTool{toolRegistration: tool, name: prefix + tool.Definition().Name}
+	}
+	return NewToolSet(tools...).WithArgsPolicy(ts.policy)
+}
+
+func (ts *Set) filter(keep func(name string) bool) *Set {
+	var tools []toolRegistration
+	for _, tool := range ts.tools {
+		if keep(tool.Definition().Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return NewToolSet(tools...).WithArgsPolicy(ts.policy)
+}
+
+func nameSet(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// prefixedTool exposes a registration under a different name.
+type prefixedTool struct {
+	toolRegistration
+	name string
+}
+
+func (p *prefixedTool) Definition() Definition {
+	def := p.toolRegistration.Definition()
+	def.Name = p.name
+	return def
+}
+
+// Definitions returns all tool definitions for sending to providers. Tools
+// whose spec has an Err are left out so a half-built schema is never sent.
+func (ts *Set) Definitions() []Definition {
+	defs := make([]Definition, 0, len(ts.tools))
+	for _, tool := range ts.tools {
+		if tool.Err() != nil {
+			continue
+		}
+		defs = append(defs, tool.Definition())
 	}
 	return defs
 }
@@ -73,7 +196,7 @@ func (ts *Set) Parse(calls []Call) ([]ParsedToolCall, error) {
 			errs = append(errs, fmt.Errorf("unknown tool: %s", call.ToolName()))
 			continue
 		}
-		parsed, err := reg.parse(call)
+		parsed, err := reg.parse(ts.policy.apply(reg.Definition().Parameters, call))
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -83,3 +206,43 @@ func (ts *Set) Parse(calls []Call) ([]ParsedToolCall, error) {
 
 	return result, errors.Join(errs...)
 }
+
+// Execute validates and runs each call with the handler registered for its
+// tool, returning one Result per call in the same order.
+//
+// Failures never abort the batch: unknown tools, schema validation errors,
+// handler errors and handler panics are reported as error Results so they can
+// be sent back to the model. Specs without a handler (see Spec.WithHandler)
+// produce an ErrNoHandler error Result.
+//
+// Example:
+//
+//	results := tools.Execute(ctx, resp.ToolCalls())
+//	for _, r := range results {
+//	    fmt.Println(r.ToolCallID(), r.ToolOutput(), r.IsError())
+//	}
+func (ts *Set) Execute(ctx context.Context, calls []Call) []Result {
+	results := make([]Result, len(calls))
+	for i, call := range calls {
+		results[i] = ts.ExecuteCall(ctx, call)
+	}
+	return results
+}
+
+// ExecuteCall validates and runs a single call. Failures are reported as an
+// error Result, exactly as in Execute.
+func (ts *Set) ExecuteCall(ctx context.Context, call Call) Result {
+	reg, ok := ts.index[call.ToolName()]
+	if !ok {
+		return NewResult(call.ToolCallID(), fmt.Sprintf("unknown tool: %s", call.ToolName()), true)
+	}
+	// Handle validates and decodes the repaired arguments.
+	out, err := SafeHandle(ctx, reg, ts.policy.apply(reg.Definition().Parameters, call))
+	if err != nil {
+		return NewResult(call.ToolCallID(), err.Error(), true)
+	}
+	if res, ok := out.(Result); ok {
+		return res
+	}
+	return NewResult(call.ToolCallID(), out, false)
+}
@@ -1,7 +1,7 @@
 package tool
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
 	jsv "github.com/santhosh-tekuri/jsonschema/v6"
@@ -41,12 +41,17 @@ type (
 // toolRegistration is the internal interface that allows heterogeneous Spec[T]
 // types to be stored in a Set.
 type toolRegistration interface {
+	Handler
 	Definition() Definition
+	Err() error
 	parse(raw Call) (ParsedToolCall, error)
 }
 
-// Ensure Spec implements toolRegistration.
-var _ toolRegistration = (*Spec[struct{}])(nil)
+// Ensure Spec implements toolRegistration and NamedHandler.
+var (
+	_ toolRegistration = (*Spec[struct{}])(nil)
+	_ NamedHandler     = (*Spec[struct{}])(nil)
+)
 
 // Spec is a type-safe tool specification that pairs a tool name/description
 // with a Go struct that defines the parameter schema.
@@ -56,11 +61,16 @@ type Spec[T any] struct {
 	description string
 	definition  Definition
 	schema      *jsv.Schema // compiled schema for validation
+	handler     func(ctx context.Context, in T) (string, error)
+	err         error // first failing SchemaOption
 }
 
 // NewSpec creates a typed tool specification from a parameter struct.
 // The struct's fields define the JSON Schema for the tool's parameters.
-// Field tags are the same as DefinitionFor: json, jsonschema.
+// Field tags are the same as DefinitionFor: json, jsonschema. opts adjust the
+// schema as in DefinitionFor, and arguments are validated against the
+// adjusted schema. If an option fails, Err returns the error, parsing calls
+// of the tool fails with it and a Set leaves the tool out of Definitions.
 //
 // Example:
 //
@@ -68,8 +78,8 @@ type Spec[T any] struct {
 //	    Location string `json:"location" jsonschema:"description=City name,required"`
 //	}
 //	spec := NewSpec[GetWeatherParams]("get_weather", "Get current weather")
-func NewSpec[T any](name, description string) *Spec[T] {
-	def := DefinitionFor[T](name, description)
+func NewSpec[T any](name, description string, opts ...SchemaOption) *Spec[T] {
+	def, optErr := definitionFor[T](name, description, opts)
 
 	// Compile schema for validation
 	c := jsv.NewCompiler()
@@ -85,15 +95,58 @@ func NewSpec[T any](name, description string) *Spec[T] {
 		description: description,
 		definition:  def,
 		schema:      schema,
+		err:         optErr,
 	}
 }
 
 // Definition returns the Definition for sending to providers.
 func (s *Spec[T]) Definition() Definition { return s.definition }
 
+// Err returns the error of the first SchemaOption passed to NewSpec that
+// failed, e.g. WithProperty with an unknown path, or nil.
+func (s *Spec[T]) Err() error { return s.err }
+
+// WithHandler attaches fn as the handler for this tool, so Set.Execute and
+// StreamProcessor.HandleTool can run its calls. It returns s for chaining.
+//
+// Example:
+//
+//	spec := NewSpec[GetWeatherParams]("get_weather", "Get current weather").
+//	    WithHandler(func(ctx context.Context, in GetWeatherParams) (string, error) {
+//	        return "22°C in " + in.Location, nil
+//	    })
+func (s *Spec[T]) WithHandler(fn func(ctx context.Context, in T) (string, error)) *Spec[T] {
+	s.handler = fn
+	return s
+}
+
+// ToolName implements NamedHandler — returns the spec's tool name.
+func (s *Spec[T]) ToolName() string { return s.name }
+
+// Handle implements Handler — validates and decodes the call arguments into
+// T exactly as Set.Parse does and calls the handler set with WithHandler.
+// Returns ErrNoHandler if none is set.
+func (s *Spec[T]) Handle(ctx context.Context, call Call) (any, error) {
+	parsed, err := s.parseTyped(call)
+	if err != nil {
+		return nil, err
+	}
+	if s.handler == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoHandler, s.name)
+	}
+	return s.handler(ctx, parsed.Params)
+}
+
 // parse validates and parses a raw Call into a TypedToolCall[T].
 // This is called by Set.Parse().
 func (s *Spec[T]) parse(raw Call) (ParsedToolCall, error) {
+	return s.parseTyped(raw)
+}
+
+func (s *Spec[T]) parseTyped(raw Call) (*TypedToolCall[T], error) {
+	if s.err != nil {
+		return nil, fmt.Errorf("tool %s: %w", s.name, s.err)
+	}
 	// Validate arguments against schema if available
 	if s.schema != nil {
 		if err := s.schema.Validate(raw.ToolArgs()); err != nil {
@@ -101,15 +154,9 @@ func (s *Spec[T]) parse(raw Call) (ParsedToolCall, error) {
 		}
 	}
 
-	// Marshal arguments to JSON, then unmarshal into T
-	data, err := json.Marshal(raw.ToolArgs())
-	if err != nil {
-		return nil, fmt.Errorf("marshal %s arguments: %w", s.name, err)
-	}
-
 	var params T
-	if err := json.Unmarshal(data, &params); err != nil {
-		return nil, fmt.Errorf("parse %s arguments: %w", s.name, err)
+	if ae := decodeArgs(raw.ToolArgs(), &params); ae != nil {
+		return nil, fmt.Errorf("%s %s arguments: %w", ae.op, s.name, ae.err)
 	}
 
 	return &TypedToolCall[T]{
@@ -116,6 +116,9 @@ func (b *BoundToolSpec[In, Out]) Handle(ctx context.Context, call Call) (any, er
 // Definition implements toolRegistration — delegates to the embedded spec.
 func (b *BoundToolSpec[In, Out]) Definition() Definition { return b.spec.Definition() }
 
+// Err implements toolRegistration — delegates to the embedded spec.
+func (b *BoundToolSpec[In, Out]) Err() error { return b.spec.Err() }
+
 // parse implements toolRegistration — delegates to the embedded spec.
 func (b *BoundToolSpec[In, Out]) parse(raw Call) (ParsedToolCall, error) {
 	return b.spec.parse(raw)
@@ -126,13 +129,9 @@ func (b *BoundToolSpec[In, Out]) parse(raw Call) (ParsedToolCall, error) {
 // execTypedHandler is the shared marshal→unmarshal→call→marshal pipeline used
 // by both namedToolHandler and BoundToolSpec.
 func execTypedHandler[In, Out any](ctx context.Context, name string, call Call, fn func(context.Context, In) (*Out, error)) (string, error) {
-	raw, err := json.Marshal(call.ToolArgs())
-	if err != nil {
-		return "", fmt.Errorf("tool %s: marshal args: %w", name, err)
-	}
 	var in In
-	if err := json.Unmarshal(raw, &in); err != nil {
-		return "", fmt.Errorf("tool %s: parse args: %w", name, err)
+	if ae := decodeArgs(call.ToolArgs(), &in); ae != nil {
+		return "", fmt.Errorf("tool %s: %s args: %w", name, ae.op, ae.err)
 	}
 	out, err := fn(ctx, in)
 	if err != nil {
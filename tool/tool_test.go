@@ -1,8 +1,12 @@
 package tool
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -202,6 +206,111 @@ func TestToolDefinitionFor_NestedStruct(t *testing.T) {
 	assert.Contains(t, nestedProps, "city")
 }
 
+func TestToolDefinitionFor_EmbeddedStruct(t *testing.T) {
+	type Paging struct {
+		Cursor string `json:"cursor" jsonschema:"required"`
+		Limit  int    `json:"limit"`
+	}
+	type Meta struct {
+		Trace string `json:"trace"`
+	}
+	type Params struct {
+		Paging
+		*Meta
+		Owner Paging `json:"owner"`
+		Query string `json:"query" jsonschema:"required"`
+	}
+
+	tool := DefinitionFor[Params]("test", "test")
+	props := tool.Parameters["properties"].(map[string]any)
+
+	assert.ElementsMatch(t, []string{"cursor", "limit", "trace", "owner", "query"}, keys(props), "anonymous structs are flattened")
+	assert.Equal(t, "object", props["owner"].(map[string]any)["type"], "named embedded struct stays nested")
+	assert.ElementsMatch(t, []any{"cursor", "query"}, tool.Parameters["required"])
+}
+
+func TestToolDefinitionFor_Maps(t *testing.T) {
+	type Params struct {
+		Labels map[string]string `json:"labels" jsonschema:"description=Resource labels"`
+		Counts map[string]int    `json:"counts"`
+	}
+
+	props := DefinitionFor[Params]("test", "test").Parameters["properties"].(map[string]any)
+
+	labels := props["labels"].(map[string]any)
+	assert.Equal(t, "object", labels["type"])
+	assert.Equal(t, "Resource labels", labels["description"])
+	assert.Equal(t, map[string]any{"type": "string"}, labels["additionalProperties"])
+	assert.Equal(t, map[string]any{"type": "integer"}, props["counts"].(map[string]any)["additionalProperties"])
+}
+
+func TestToolDefinitionFor_TimeTypes(t *testing.T) {
+	type Params struct {
+		Since   time.Time     `json:"since"`
+		Timeout time.Duration `json:"timeout"`
+		Backoff time.Duration `json:"backoff" jsonschema:"description=Retry backoff in nanoseconds"`
+	}
+
+	props := DefinitionFor[Params]("test", "test").Parameters["properties"].(map[string]any)
+
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time"}, props["since"])
+	assert.Equal(t, map[string]any{"type": "integer", "description": "Duration in nanoseconds"}, props["timeout"])
+	assert.Equal(t, "Retry backoff in nanoseconds", props["backoff"].(map[string]any)["description"])
+}
+
+func TestToolDefinitionFor_AnyJSON(t *testing.T) {
+	type Params struct {
+		Payload json.RawMessage `json:"payload" jsonschema:"description=Request body"`
+		Value   any             `json:"value"`
+	}
+
+	props := DefinitionFor[Params]("test", "test").Parameters["properties"].(map[string]any)
+
+	assert.Equal(t, map[string]any{"description": "Request body"}, props["payload"], "no boolean schema, description kept")
+	assert.Equal(t, map[string]any{"description": "Any JSON value"}, props["value"])
+}
+
+func TestToolSpec_ParseMappedTypes(t *testing.T) {
+	type Base struct {
+		ID string `json:"id" jsonschema:"required"`
+	}
+	type Params struct {
+		Base
+		Labels  map[string]string `json:"labels"`
+		Since   time.Time         `json:"since"`
+		Timeout time.Duration     `json:"timeout"`
+		Payload json.RawMessage   `json:"payload"`
+	}
+
+	spec := NewSpec[Params]("test", "test")
+	call, err := spec.parse(&toolCall{ID: "call_1", Name: "test", Args: map[string]any{
+		"id":      "x1",
+		"labels":  map[string]any{"env": "prod"},
+		"since":   "2026-01-02T03:04:05Z",
+		"timeout": 5e9,
+		"payload": map[string]any{"k": []any{1, 2}},
+	}})
+	require.NoError(t, err)
+
+	p := call.(*TypedToolCall[Params]).Params
+	assert.Equal(t, "x1", p.ID)
+	assert.Equal(t, map[string]string{"env": "prod"}, p.Labels)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), p.Since)
+	assert.Equal(t, 5*time.Second, p.Timeout)
+	assert.JSONEq(t, `{"k":[1,2]}`, string(p.Payload))
+
+	_, err = spec.parse(&toolCall{ID: "call_2", Name: "test", Args: map[string]any{"id": "x1", "labels": map[string]any{"env": 1}}})
+	assert.Error(t, err, "map values are validated")
+}
+
+func keys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
 func TestToolDefinitionFor_PointerFields(t *testing.T) {
 	type ParamsWithPointer struct {
 		RequiredField string  `json:"required_field" jsonschema:"required"`
@@ -692,6 +801,136 @@ func TestToolSet_Parse_EmptyCalls(t *testing.T) {
 	assert.Empty(t, parsed)
 }
 
+func TestToolSet_Execute(t *testing.T) {
+	type Params struct {
+		Location string `json:"location" jsonschema:"required"`
+	}
+	type SearchParams struct {
+		Query string `json:"query"`
+	}
+	type Out struct {
+		Hits int `json:"hits"`
+	}
+
+	weather := NewSpec[Params]("get_weather", "Get weather").
+		WithHandler(func(_ context.Context, in Params) (string, error) {
+			if in.Location == "Atlantis" {
+				return "", errors.New("no such place")
+			}
+			return "sunny in " + in.Location, nil
+		})
+	search := Handle(NewSpec[SearchParams]("search", "Search"), func(_ context.Context, in SearchParams) (*Out, error) {
+		return &Out{Hits: len(in.Query)}, nil
+	})
+	noHandler := NewSpec[Params]("bare", "No handler")
+	boom := NewSpec[Params]("boom", "Panics").WithHandler(func(context.Context, Params) (string, error) {
+		panic("kaboom")
+	})
+	toolSet := NewToolSet(weather, search, noHandler, boom)
+
+	results := toolSet.Execute(context.Background(), []Call{
+		&toolCall{ID: "c1", Name: "get_weather", Args: map[string]any{"location": "London"}},
+		&toolCall{ID: "c2", Name: "search", Args: map[string]any{"query": "golang"}},
+		&toolCall{ID: "c3", Name: "get_weather", Args: map[string]any{}},
+		&toolCall{ID: "c4", Name: "get_weather", Args: map[string]any{"location": "Atlantis"}},
+		&toolCall{ID: "c5", Name: "missing", Args: map[string]any{}},
+		&toolCall{ID: "c6", Name: "bare", Args: map[string]any{"location": "x"}},
+		&toolCall{ID: "c7", Name: "boom", Args: map[string]any{"location": "x"}},
+	})
+	require.Len(t, results, 7)
+
+	for i, id := range []string{"c1", "c2", "c3", "c4", "c5", "c6", "c7"} {
+		assert.Equal(t, id, results[i].ToolCallID())
+	}
+
+	assert.False(t, results[0].IsError())
+	assert.Equal(t, "sunny in London", results[0].ToolOutput())
+
+	assert.False(t, results[1].IsError())
+	assert.Equal(t, `{"hits":6}`, results[1].ToolOutput())
+
+	assert.True(t, results[2].IsError())
+	assert.Contains(t, results[2].ToolOutput(), "validate")
+
+	assert.True(t, results[3].IsError())
+	assert.Equal(t, "no such place", results[3].ToolOutput())
+
+	assert.True(t, results[4].IsError())
+	assert.Equal(t, "unknown tool: missing", results[4].ToolOutput())
+
+	assert.True(t, results[5].IsError())
+	assert.Contains(t, results[5].ToolOutput(), ErrNoHandler.Error())
+
+	assert.True(t, results[6].IsError())
+	assert.Contains(t, results[6].ToolOutput(), "kaboom")
+}
+
+func TestToolSet_Compose(t *testing.T) {
+	type Params struct {
+		Q string `json:"q"`
+	}
+	echo := func(name string) *Spec[Params] {
+		return NewSpec[Params](name, name).WithHandler(func(_ context.Context, in Params) (string, error) {
+			return name + ":" + in.Q, nil
+		})
+	}
+	names := func(ts *Set) []string {
+		var out []string
+		for _, d := range ts.Definitions() {
+			out = append(out, d.Name)
+		}
+		return out
+	}
+
+	fs := NewToolSet(echo("read"), echo("write"), echo("list"))
+	web := NewToolSet(echo("search"), echo("read"))
+
+	assert.Equal(t, []string{"read", "list"}, names(fs.Only("list", "read", "nope")))
+	assert.Equal(t, []string{"read", "list"}, names(fs.Except("write")))
+	assert.Len(t, fs.Definitions(), 3, "receiver is unchanged")
+
+	_, err := fs.Merge(web)
+	assert.EqualError(t, err, `tool: duplicate tool "read"`)
+	_, err = NewToolSet().Merge(NewToolSet(echo("x"), echo("x")))
+	assert.EqualError(t, err, `tool: duplicate tool "x"`, "duplicates within other")
+
+	merged, err := fs.Merge(web.Prefix("web_"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "write", "list", "web_search", "web_read"}, names(merged))
+
+	results := merged.Execute(context.Background(), []Call{
+		&toolCall{ID: "c1", Name: "read", Args: map[string]any{"q": "a"}},
+		&toolCall{ID: "c2", Name: "web_read", Args: map[string]any{"q": "b"}},
+	})
+	require.Len(t, results, 2)
+	assert.Equal(t, "read:a", results[0].ToolOutput())
+	assert.Equal(t, "read:b", results[1].ToolOutput())
+
+	parsed, err := merged.Parse([]Call{&toolCall{ID: "c3", Name: "web_search", Args: map[string]any{"q": "x"}}})
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+	assert.Equal(t, "x", parsed[0].(*TypedToolCall[Params]).Params.Q)
+
+	assert.Equal(t, []string{"a_web_search"}, names(web.Prefix("web_").Only("web_search").Prefix("a_")))
+}
+
+func TestSpec_WithHandler_AsNamedHandler(t *testing.T) {
+	type Params struct {
+		N int `json:"n"`
+	}
+	spec := NewSpec[Params]("double", "Double").WithHandler(func(_ context.Context, in Params) (string, error) {
+		return fmt.Sprint(in.N * 2), nil
+	})
+
+	h := NewHandlers(spec)
+	out, err := h.Handle(context.Background(), NewToolCall("c1", "double", Args{"n": 21}))
+	require.NoError(t, err)
+	assert.Equal(t, "42", out)
+
+	_, err = spec.Handle(context.Background(), NewToolCall("c2", "double", Args{"n": "21"}))
+	assert.ErrorContains(t, err, "validate double arguments", "Handle validates like Parse")
+}
+
 // --- TypedToolCall Tests ---
 
 func TestTypedToolCall_Interface(t *testing.T) {
@@ -711,3 +950,90 @@ func TestTypedToolCall_Interface(t *testing.T) {
 	assert.Equal(t, "test_tool", call.ToolName())
 	assert.Equal(t, "call_123", call.ToolCallID())
 }
+
+func TestIdempotent_RunsOncePerKey(t *testing.T) {
+	type Params struct {
+		N int `json:"n"`
+	}
+	var runs int
+	spec := NewSpec[Params]("double", "Double").WithHandler(func(_ context.Context, in Params) (string, error) {
+		runs++
+		if in.N < 0 {
+			return "", errors.New("negative")
+		}
+		return fmt.Sprint(in.N * 2), nil
+	})
+
+	store := NewMemoryExecutionStore()
+	h := Idempotent(spec, store)
+	ctx := context.Background()
+
+	out, err := h.Handle(ctx, NewToolCall("c1", "double", Args{"n": 21}))
+	require.NoError(t, err)
+	assert.Equal(t, "42", out)
+
+	out, err = h.Handle(ctx, NewToolCall("c1", "double", Args{"n": 21}))
+	require.NoError(t, err)
+	assert.Equal(t, "42", out)
+	assert.Equal(t, 1, runs, "replayed call must not run the handler")
+
+	// same ID, different arguments: a different key
+	out, err = h.Handle(ctx, NewToolCall("c1", "double", Args{"n": 2}))
+	require.NoError(t, err)
+	assert.Equal(t, "4", out)
+	assert.Equal(t, 2, runs)
+
+	// recorded errors are replayed too
+	_, err = h.Handle(ctx, NewToolCall("c2", "double", Args{"n": -1}))
+	require.Error(t, err)
+	_, err = h.Handle(ctx, NewToolCall("c2", "double", Args{"n": -1}))
+	require.EqualError(t, err, "negative")
+	assert.Equal(t, 3, runs)
+
+	dh, ok := h.(interface{ Definition() Definition })
+	require.True(t, ok, "definition must be preserved")
+	assert.Equal(t, "double", dh.Definition().Name)
+}
+
+func TestExecutionKey(t *testing.T) {
+	a := ExecutionKey(NewToolCall("c1", "t", Args{"x": 1, "y": 2}))
+	b := ExecutionKey(NewToolCall("c1", "t", Args{"y": 2, "x": 1}))
+	c := ExecutionKey(NewToolCall("c2", "t", Args{"x": 1, "y": 2}))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func BenchmarkSpecParse_LargeArgs(b *testing.B) {
+	type EditParams struct {
+		Path    string `json:"path" jsonschema:"required"`
+		Content string `json:"content" jsonschema:"required"`
+	}
+	spec := NewSpec[EditParams]("edit", "Edit a file")
+	content := make([]byte, 256<<10)
+	for i := range content {
+		content[i] = 'a' + byte(i%26)
+	}
+	call := &toolCall{ID: "call_1", Name: "edit", Args: map[string]any{"path": "main.go", "content": string(content)}}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := spec.parse(call); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSpec_ArgsParamsAreCopied(t *testing.T) {
+	spec := NewSpec[Args]("raw", "Raw arguments")
+	call := &toolCall{ID: "1", Name: "raw", Args: map[string]any{
+		"filter": map[string]any{"labels": []any{"a"}},
+	}}
+	parsed, err := spec.parse(call)
+	require.NoError(t, err)
+
+	params := parsed.(*TypedToolCall[Args]).Params
+	params["filter"].(map[string]any)["labels"].([]any)[0] = "changed"
+	params["extra"] = true
+
+	assert.Equal(t, map[string]any{"filter": map[string]any{"labels": []any{"a"}}}, call.Args)
+}
@@ -0,0 +1,305 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/codewandler/llm/tool"
+)
+
+// toolCallMarkup is a pseudo tool-call syntax some models emit as plain text.
+// An empty close marker means the call runs to the end of the text.
+type toolCallMarkup struct {
+	open, close string
+}
+
+var toolCallMarkups = []toolCallMarkup{
+	{open: "<tool_call>", close: "</tool_call>"}, // Qwen, Hermes, GLM
+	{open: "[TOOL_CALLS]"},                       // Mistral
+}
+
+var glmArgPattern = regexp.MustCompile(`(?s)<arg_key>(.*?)</arg_key>\s*<arg_value>(.*?)</arg_value>`)
+
+// ToolCallGuard is a Streamer that keeps pseudo tool-call markup out of
+// user-visible text. Some models, notably Qwen and GLM served through Ollama
+// and other OpenAI-compatible runtimes, write tool calls into the text as
+// <tool_call>…</tool_call> or [TOOL_CALLS] instead of using the API's tool
+// call fields.
+//
+// The guard removes such markup from text deltas. Calls it can parse are
+// emitted as StreamEventToolCall events, and an end_turn stop reason becomes
+// tool_use. Markup it cannot parse is dropped with a debug event.
+type ToolCallGuard struct {
+	s Streamer
+}
+
+// NewToolCallGuard returns a ToolCallGuard that filters streams from s.
+func NewToolCallGuard(s Streamer) *ToolCallGuard {
+	return &ToolCallGuard{s: s}
+}
+
+// CreateStream implements Streamer.
+func (g *ToolCallGuard) CreateStream(ctx context.Context, src Buildable) (Stream, error) {
+	stream, err := g.s.CreateStream(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return GuardToolCalls(ctx, stream), nil
+}
+
+// GuardToolCalls applies the ToolCallGuard filtering to an existing stream.
+func GuardToolCalls(ctx context.Context, in Stream) Stream {
+	out := make(chan Envelope, 64)
+	go func() {
+		defer close(out)
+		g := &toolCallFilter{out: out, ctx: ctx}
+		for env := range in {
+			if !g.handle(env) {
+				// drain so the producer is not blocked
+				for range in {
+				}
+				return
+			}
+		}
+		g.flush(EventMeta{})
+	}()
+	return out
+}
+
+// toolCallFilter holds the per-stream state of GuardToolCalls.
+type toolCallFilter struct {
+	ctx context.Context
+	out chan<- Envelope
+
+	buf    string          // text not yet emitted or parsed
+	inside *toolCallMarkup // markup being collected, nil outside
+	calls  int             // tool calls extracted so far
+}
+
+func (f *toolCallFilter) handle(env Envelope) bool {
+	switch ev := env.Data.(type) {
+	case *DeltaEvent:
+		if ev.Kind == DeltaKindText {
+			f.buf += ev.Text
+			return f.scan(env.Meta, ev.Index)
+		}
+	case *CompletedEvent:
+		if !f.flush(env.Meta) {
+			return false
+		}
+		if f.calls > 0 && ev.StopReason == StopReasonEndTurn {
+			c := *ev
+			c.StopReason = StopReasonToolUse
+			env.Data = &c
+		}
+	}
+	return f.send(env)
+}
+
+// scan emits all text that can no longer be part of markup and extracts
+// complete tool calls.
+func (f *toolCallFilter) scan(meta EventMeta, idx *uint32) bool {
+	for {
+		if f.inside == nil {
+			pos, m := nextMarkup(f.buf)
+			if m == nil {
+				keep := partialMarkupSuffix(f.buf)
+				if !f.emitText(meta, idx, f.buf[:len(f.buf)-keep]) {
+					return false
+				}
+				f.buf = f.buf[len(f.buf)-keep:]
+				return true
+			}
+			if !f.emitText(meta, idx, f.buf[:pos]) {
+				return false
+			}
+			f.buf = f.buf[pos+len(m.open):]
+			f.inside = m
+			continue
+		}
+		if f.inside.close == "" {
+			return true
+		}
+		end := strings.Index(f.buf, f.inside.close)
+		if end < 0 {
+			return true
+		}
+		body := f.buf[:end]
+		f.buf = f.buf[end+len(f.inside.close):]
+		f.inside = nil
+		if !f.emitCalls(meta, body) {
+			return false
+		}
+	}
+}
+
+// flush emits buffered text, or the calls of an unterminated markup block,
+// at the end of the text.
+func (f *toolCallFilter) flush(meta EventMeta) bool {
+	if f.inside != nil {
+		body := f.buf
+		f.buf, f.inside = "", nil
+		return f.emitCalls(meta, body)
+	}
+	text := f.buf
+	f.buf = ""
+	return f.emitText(meta, nil, text)
+}
+
+func (f *toolCallFilter) emitText(meta EventMeta, idx *uint32, text string) bool {
+	if text == "" {
+		return true
+	}
+	d := TextDelta(text)
+	d.Index = idx
+	return f.send(Envelope{Type: StreamEventDelta, Meta: meta, Data: d})
+}
+
+func (f *toolCallFilter) emitCalls(meta EventMeta, body string) bool {
+	calls, ok := parseToolCallMarkup(body)
+	if !ok {
+		return f.send(Envelope{Type: StreamEventDebug, Meta: meta, Data: &DebugEvent{
+			Message: "dropped unparseable tool call markup from text",
+			Data:    body,
+		}})
+	}
+	for _, c := range calls {
+		f.calls++
+		if !f.send(Envelope{Type: StreamEventToolCall, Meta: meta, Data: &ToolCallEvent{ToolCall: c}}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *toolCallFilter) send(env Envelope) bool {
+	select {
+	case f.out <- env:
+		return true
+	case <-f.ctx.Done():
+		return false
+	}
+}
+
+// nextMarkup returns the position and kind of the first markup opener in s.
+func nextMarkup(s string) (int, *toolCallMarkup) {
+	best, found := -1, (*toolCallMarkup)(nil)
+	for i := range toolCallMarkups {
+		m := &toolCallMarkups[i]
+		if pos := strings.Index(s, m.open); pos >= 0 && (best < 0 || pos < best) {
+			best, found = pos, m
+		}
+	}
+	return best, found
+}
+
+// partialMarkupSuffix returns the length of the longest suffix of s that is
+// a proper prefix of a markup opener, i.e. text that must be held back until
+// the next delta shows whether it starts markup.
+func partialMarkupSuffix(s string) int {
+	keep := 0
+	for _, m := range toolCallMarkups {
+		for n := len(m.open) - 1; n > keep; n-- {
+			if strings.HasSuffix(s, m.open[:n]) {
+				keep = n
+				break
+			}
+		}
+	}
+	return keep
+}
+
+// parseToolCallMarkup parses the body of a markup block. Accepted forms are a
+// JSON object {"name": ..., "arguments": ...}, a JSON array of such objects,
+// and GLM's "name<arg_key>k</arg_key><arg_value>v</arg_value>…" form.
+func parseToolCallMarkup(body string) ([]tool.Call, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, false
+	}
+
+	type jsonCall struct {
+		Name       string          `json:"name"`
+		Arguments  json.RawMessage `json:"arguments"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	var raw []jsonCall
+	if strings.HasPrefix(body, "[") {
+		if err := json.Unmarshal([]byte(body), &raw); err != nil {
+			return nil, false
+		}
+	} else if strings.HasPrefix(body, "{") {
+		var one jsonCall
+		if err := json.Unmarshal([]byte(body), &one); err != nil {
+			return nil, false
+		}
+		raw = []jsonCall{one}
+	} else {
+		return parseGLMToolCall(body)
+	}
+
+	calls := make([]tool.Call, 0, len(raw))
+	for _, rc := range raw {
+		args := rc.Arguments
+		if len(args) == 0 {
+			args = rc.Parameters
+		}
+		parsed, ok := parseMarkupArgs(args)
+		if rc.Name == "" || !ok {
+			return nil, false
+		}
+		calls = append(calls, tool.NewToolCall(markupCallID(), rc.Name, parsed))
+	}
+	return calls, len(calls) > 0
+}
+
+// parseMarkupArgs accepts arguments as a JSON object or as a JSON string
+// containing one.
+func parseMarkupArgs(raw json.RawMessage) (tool.Args, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return tool.Args{}, true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		raw = json.RawMessage(s)
+	}
+	var args tool.Args
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, false
+	}
+	if args == nil {
+		args = tool.Args{}
+	}
+	return args, true
+}
+
+func parseGLMToolCall(body string) ([]tool.Call, bool) {
+	name := body
+	if i := strings.IndexAny(body, "\n<"); i >= 0 {
+		name = body[:i]
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " {}\"") {
+		return nil, false
+	}
+	args := tool.Args{}
+	for _, m := range glmArgPattern.FindAllStringSubmatch(body, -1) {
+		key, value := strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value
+		}
+		args[key] = v
+	}
+	return []tool.Call{tool.NewToolCall(markupCallID(), name, args)}, true
+}
+
+func markupCallID() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return "call_" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,102 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func guardResult(t *testing.T, evs ...llm.Event) llm.Result {
+	t.Helper()
+	stream := llm.GuardToolCalls(context.Background(), llmtest.SendEvents(evs...))
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.NoError(t, res.Error())
+	return res
+}
+
+func TestGuardToolCalls_ExtractsSplitJSONMarkup(t *testing.T) {
+	res := guardResult(t,
+		llmtest.TextEvent("Let me check. <tool"),
+		llmtest.TextEvent(`_call>{"name": "get_weather", "argu`),
+		llmtest.TextEvent(`ments": {"location": "Paris"}}</tool_call>`),
+		llmtest.TextEvent(" Done."),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	assert.Equal(t, "Let me check.  Done.", res.Text())
+	assert.Equal(t, llm.StopReasonToolUse, res.StopReason())
+	require.Len(t, res.ToolCalls(), 1)
+	call := res.ToolCalls()[0]
+	assert.Equal(t, "get_weather", call.ToolName())
+	assert.Equal(t, "Paris", call.ToolArgs()["location"])
+	assert.Regexp(t, `^call_[0-9a-f]{12}$`, call.ToolCallID())
+}
+
+func TestGuardToolCalls_GLMArgumentTags(t *testing.T) {
+	res := guardResult(t,
+		llmtest.TextEvent("<tool_call>search\n<arg_key>query</arg_key>\n<arg_value>go channels</arg_value>\n<arg_key>limit</arg_key>\n<arg_value>5</arg_value>\n</tool_call>"),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	assert.Empty(t, res.Text())
+	require.Len(t, res.ToolCalls(), 1)
+	assert.Equal(t, "search", res.ToolCalls()[0].ToolName())
+	assert.Equal(t, "go channels", res.ToolCalls()[0].ToolArgs()["query"])
+	assert.Equal(t, float64(5), res.ToolCalls()[0].ToolArgs()["limit"])
+}
+
+func TestGuardToolCalls_MistralArrayAtEnd(t *testing.T) {
+	res := guardResult(t,
+		llmtest.TextEvent(`[TOOL_CALLS][{"name": "a", "arguments": "{\"x\": 1}"}, {"name": "b", "arguments": {}}]`),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	assert.Empty(t, res.Text())
+	require.Len(t, res.ToolCalls(), 2)
+	assert.Equal(t, "a", res.ToolCalls()[0].ToolName())
+	assert.Equal(t, float64(1), res.ToolCalls()[0].ToolArgs()["x"])
+	assert.Equal(t, "b", res.ToolCalls()[1].ToolName())
+}
+
+func TestGuardToolCalls_PlainTextUnchanged(t *testing.T) {
+	res := guardResult(t,
+		llmtest.TextEvent("a < b and [TOOL"),
+		llmtest.TextEvent("S] are fine <"),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	assert.Equal(t, "a < b and [TOOLS] are fine <", res.Text())
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason())
+	assert.Empty(t, res.ToolCalls())
+}
+
+func TestGuardToolCalls_DropsUnparseableMarkup(t *testing.T) {
+	res := guardResult(t,
+		llmtest.TextEvent("before <tool_call>{not json</tool_call> after"),
+		llmtest.CompletedEvent(llm.StopReasonEndTurn),
+	)
+
+	assert.Equal(t, "before  after", res.Text())
+	assert.Empty(t, res.ToolCalls())
+	assert.Equal(t, llm.StopReasonEndTurn, res.StopReason())
+}
+
+func TestToolCallGuard_Streamer(t *testing.T) {
+	s := llm.StreamFunc(func(context.Context, llm.Buildable) (llm.Stream, error) {
+		return llmtest.SendEvents(
+			llmtest.TextEvent(`<tool_call>{"name":"ping"}</tool_call>`),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		), nil
+	})
+
+	stream, err := llm.NewToolCallGuard(s).CreateStream(context.Background(), llm.Request{Model: "m"})
+	require.NoError(t, err)
+	res := llm.NewEventProcessor(context.Background(), stream).Result()
+	require.Len(t, res.ToolCalls(), 1)
+	assert.Equal(t, "ping", res.ToolCalls()[0].ToolName())
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PartialToolCall is a tool call that is still being generated. It
+// accumulates the DeltaKindTool fragments of one call so UIs can render the
+// call while the model types it.
+type PartialToolCall struct {
+	// Index is the position of the call's block in the response, or 0 when
+	// the provider does not supply block indices.
+	Index uint32
+	// ID and Name are the tool call ID and tool name, once known.
+	ID   string
+	Name string
+	// Args is the raw argument JSON received so far. It is usually
+	// incomplete.
+	Args string
+}
+
+// ParsedArgs returns the arguments received so far, parsed leniently: the
+// longest prefix of Args that forms valid JSON once open strings, objects
+// and arrays are closed. It returns nil if nothing can be parsed yet.
+func (c PartialToolCall) ParsedArgs() map[string]any {
+	return parsePartialJSONObject(c.Args)
+}
+
+// OnPartialToolCall registers a callback that is called after each tool-call
+// argument fragment with the call assembled so far. It is the opt-in,
+// assembled counterpart of OnToolDelta; the final call still arrives as a
+// StreamEventToolCall.
+func (r *StreamProcessor) OnPartialToolCall(fn func(c PartialToolCall)) *StreamProcessor {
+	calls := make(map[uint32]*PartialToolCall)
+	byID := make(map[string]uint32)
+	return r.onDeltaKind(DeltaKindTool, func(d DeltaEvent) {
+		var idx uint32
+		switch {
+		case d.Index != nil:
+			idx = *d.Index
+		case d.ToolID != "":
+			i, ok := byID[d.ToolID]
+			if !ok {
+				i = uint32(len(byID))
+				byID[d.ToolID] = i
+			}
+			idx = i
+		}
+		c := calls[idx]
+		if c == nil {
+			c = &PartialToolCall{Index: idx}
+			calls[idx] = c
+		}
+		if d.ToolID != "" {
+			c.ID = d.ToolID
+		}
+		if d.ToolName != "" {
+			c.Name = d.ToolName
+		}
+		c.Args += d.ToolArgs
+		fn(*c)
+	})
+}
+
+// parsePartialJSONObject parses the longest prefix of s that is a valid JSON
+// object once its open strings and containers are closed.
+func parsePartialJSONObject(s string) map[string]any {
+	s = strings.TrimSpace(s)
+	for n := len(s); n > 0; n-- {
+		closed, ok := closeJSON(s[:n])
+		if !ok {
+			continue
+		}
+		var out map[string]any
+		if json.Unmarshal([]byte(closed), &out) == nil {
+			return out
+		}
+	}
+	return nil
+}
+
+// closeJSON appends the quotes and brackets needed to close s. ok is false
+// when s ends inside an escape sequence.
+func closeJSON(s string) (string, bool) {
+	var (
+		stack    []byte
+		inString bool
+		escaped  bool
+	)
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if escaped {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteString(s)
+	if inString {
+		b.WriteByte('"')
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+	return b.String(), true
+}
@@ -0,0 +1,54 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+)
+
+func TestPartialToolCall_ParsedArgs(t *testing.T) {
+	tests := []struct {
+		args string
+		want map[string]any
+	}{
+		{``, nil},
+		{`{"pa`, map[string]any{}},
+		{`{"path": "src/ma`, map[string]any{"path": "src/ma"}},
+		{`{"path": "a.go", "con`, map[string]any{"path": "a.go"}},
+		{`{"path": "a.go", "lines": [1, 2`, map[string]any{"path": "a.go", "lines": []any{float64(1), float64(2)}}},
+		{`{"q": "say \"hi\`, map[string]any{"q": `say "hi`}},
+		{`{"path": "a.go"}`, map[string]any{"path": "a.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			assert.Equal(t, tt.want, llm.PartialToolCall{Args: tt.args}.ParsedArgs())
+		})
+	}
+}
+
+func TestStreamProcessor_OnPartialToolCall(t *testing.T) {
+	stream := llmtest.SendEvents(
+		llm.ToolDelta("call_1", "write_file", "").WithIndex(1),
+		llm.ToolDelta("", "", `{"path": "src/`).WithIndex(1),
+		llm.ToolDelta("call_2", "read_file", `{"path"`).WithIndex(2),
+		llm.ToolDelta("", "", `main.go"}`).WithIndex(1),
+		llmtest.ToolEvent("call_1", "write_file", map[string]any{"path": "src/main.go"}),
+		llmtest.CompletedEvent(llm.StopReasonToolUse),
+	)
+
+	var got []llm.PartialToolCall
+	res := llm.NewEventProcessor(t.Context(), stream).
+		OnPartialToolCall(func(c llm.PartialToolCall) { got = append(got, c) }).
+		Result()
+	require.NoError(t, res.Error())
+
+	require.Len(t, got, 4)
+	assert.Equal(t, llm.PartialToolCall{Index: 1, ID: "call_1", Name: "write_file", Args: `{"path": "src/`}, got[1])
+	assert.Equal(t, map[string]any{"path": "src/"}, got[1].ParsedArgs())
+	assert.Equal(t, "read_file", got[2].Name)
+	assert.Equal(t, llm.PartialToolCall{Index: 1, ID: "call_1", Name: "write_file", Args: `{"path": "src/main.go"}`}, got[3])
+}
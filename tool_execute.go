@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codewandler/llm/tool"
+)
+
+// ExecuteOptions configures ExecuteToolCalls.
+type ExecuteOptions struct {
+	// Concurrency bounds how many handlers run at once. Zero or negative
+	// runs every call concurrently.
+	Concurrency int
+
+	// Timeout, if set, bounds each handler. A call that does not finish in
+	// time gets an error result; its handler's context is cancelled.
+	Timeout time.Duration
+}
+
+// ExecuteToolCalls runs the calls emitted in one response concurrently and
+// returns their results in call order, each carrying its call's ID.
+//
+// Failures never abort the batch: unknown tools, invalid arguments, handler
+// errors, panics and timeouts are reported as results with IsError set, so
+// the whole batch can be sent back to the model. Cancelling ctx ends calls
+// still running with error results.
+func ExecuteToolCalls(ctx context.Context, tools *tool.Set, calls []tool.Call, opts ExecuteOptions) []tool.Result {
+	results := make([]tool.Result, len(calls))
+	limit := opts.Concurrency
+	if limit <= 0 || limit > len(calls) {
+		limit = len(calls)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = tool.NewResult(call.ToolCallID(), ctx.Err().Error(), true)
+				return
+			}
+			results[i] = executeToolCall(ctx, tools, call, opts.Timeout)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// executeToolCall runs one call, giving up when the timeout or ctx ends
+// first. The abandoned handler keeps running until it observes its
+// cancelled context.
+func executeToolCall(ctx context.Context, tools *tool.Set, call tool.Call, timeout time.Duration) tool.Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan tool.Result, 1)
+	go func() { done <- tools.ExecuteCall(ctx, call) }()
+
+	select {
+	case res := <-done:
+		return res
+	case <-ctx.Done():
+		text := ctx.Err().Error()
+		if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+			text = fmt.Sprintf("tool %s timed out after %s", call.ToolName(), timeout)
+		}
+		return tool.NewResult(call.ToolCallID(), text, true)
+	}
+}
@@ -0,0 +1,79 @@
+package llm_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+)
+
+type sleepParams struct {
+	Ms int `json:"ms"`
+}
+
+func TestExecuteToolCalls_OrderConcurrencyAndErrors(t *testing.T) {
+	var running, peak atomic.Int32
+	sleep := tool.NewSpec[sleepParams]("sleep", "Sleep").
+		WithHandler(func(ctx context.Context, in sleepParams) (string, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(in.Ms) * time.Millisecond)
+			return "slept", nil
+		})
+	boom := tool.NewSpec[sleepParams]("boom", "Panics").
+		WithHandler(func(ctx context.Context, in sleepParams) (string, error) { panic("kaboom") })
+	tools := tool.NewToolSet(sleep, boom)
+
+	calls := []tool.Call{
+		tool.NewToolCall("c1", "sleep", tool.Args{"ms": 30}),
+		tool.NewToolCall("c2", "boom", tool.Args{}),
+		tool.NewToolCall("c3", "sleep", tool.Args{"ms": 1}),
+		tool.NewToolCall("c4", "missing", tool.Args{}),
+		tool.NewToolCall("c5", "sleep", tool.Args{"ms": 10}),
+	}
+	results := llm.ExecuteToolCalls(t.Context(), tools, calls, llm.ExecuteOptions{Concurrency: 2})
+
+	require.Len(t, results, len(calls))
+	for i, r := range results {
+		assert.Equal(t, calls[i].ToolCallID(), r.ToolCallID())
+	}
+	assert.False(t, results[0].IsError())
+	assert.Equal(t, "slept", results[0].ToolOutput())
+	assert.True(t, results[1].IsError(), "panics become error results")
+	assert.Contains(t, results[1].ToolOutput(), "kaboom")
+	assert.False(t, results[2].IsError())
+	assert.True(t, results[3].IsError(), "unknown tools become error results")
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestExecuteToolCalls_Timeout(t *testing.T) {
+	slow := tool.NewSpec[sleepParams]("slow", "Blocks until cancelled").
+		WithHandler(func(ctx context.Context, in sleepParams) (string, error) {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return "late", nil
+		})
+	tools := tool.NewToolSet(slow)
+
+	start := time.Now()
+	results := llm.ExecuteToolCalls(t.Context(), tools,
+		[]tool.Call{tool.NewToolCall("c1", "slow", tool.Args{})},
+		llm.ExecuteOptions{Timeout: 20 * time.Millisecond})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsError())
+	assert.Contains(t, results[0].ToolOutput(), "timed out")
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
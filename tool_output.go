@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/codewandler/llm/msg"
+)
+
+// ArtifactStore keeps the full text of tool outputs that were truncated
+// before being appended to the conversation history.
+type ArtifactStore interface {
+	// PutArtifact stores content produced by the tool call callID and returns
+	// a reference that is shown to the model in the elision marker.
+	PutArtifact(ctx context.Context, callID, content string) (ref string, err error)
+}
+
+// MemoryArtifactStore is an in-process ArtifactStore. It is safe for
+// concurrent use.
+type MemoryArtifactStore struct {
+	mu    sync.RWMutex
+	items map[string]string
+}
+
+// NewMemoryArtifactStore returns an empty MemoryArtifactStore.
+func NewMemoryArtifactStore() *MemoryArtifactStore {
+	return &MemoryArtifactStore{items: make(map[string]string)}
+}
+
+// PutArtifact implements ArtifactStore. References have the form
+// "artifact://<callID>"; storing the same callID again replaces the content.
+func (s *MemoryArtifactStore) PutArtifact(_ context.Context, callID, content string) (string, error) {
+	ref := "artifact://" + callID
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[ref] = content
+	return ref, nil
+}
+
+// Artifact returns the content stored under ref.
+func (s *MemoryArtifactStore) Artifact(ref string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	content, ok := s.items[ref]
+	return content, ok
+}
+
+// ToolOutputPolicy truncates oversized tool outputs before they enter the
+// conversation history, keeping the head and tail of the output around an
+// elision marker. It protects the context budget in sessions where tools
+// return whole files or long command logs.
+type ToolOutputPolicy struct {
+	// MaxTokens is the largest tool output kept verbatim. Zero disables
+	// truncation.
+	MaxTokens int
+
+	// HeadRatio is the share of MaxTokens kept from the start of the output;
+	// the rest is kept from the end. Defaults to 0.5.
+	HeadRatio float64
+
+	// CountTokens counts the tokens in a tool output. Defaults to an
+	// estimate of four bytes per token.
+	CountTokens func(text string) int
+
+	// Store, if set, receives the full output of every truncated result and
+	// its reference is included in the elision marker.
+	Store ArtifactStore
+}
+
+// Apply returns m with every tool result that exceeds MaxTokens truncated.
+// Messages without tool results are returned unchanged. A Store error is
+// returned with m unmodified.
+func (p ToolOutputPolicy) Apply(ctx context.Context, m msg.Message) (msg.Message, error) {
+	if p.MaxTokens <= 0 {
+		return m, nil
+	}
+	var parts msg.Parts
+	for i, part := range m.Parts {
+		if part.ToolResult == nil {
+			continue
+		}
+		out, ok, err := p.truncate(ctx, *part.ToolResult)
+		if err != nil {
+			return m, err
+		}
+		if !ok {
+			continue
+		}
+		if parts == nil {
+			parts = append(msg.Parts(nil), m.Parts...)
+		}
+		parts[i].ToolResult = &out
+	}
+	if parts != nil {
+		m.Parts = parts
+	}
+	return m, nil
+}
+
+func (p ToolOutputPolicy) truncate(ctx context.Context, tr msg.ToolResult) (msg.ToolResult, bool, error) {
+	count := p.CountTokens
+	if count == nil {
+		count = estimateTokens
+	}
+	total := count(tr.ToolOutput)
+	if total <= p.MaxTokens {
+		return tr, false, nil
+	}
+
+	headRatio := p.HeadRatio
+	if headRatio <= 0 || headRatio > 1 {
+		headRatio = 0.5
+	}
+	// Token counts are converted to byte offsets proportionally; exact token
+	// boundaries are not needed for a context-budget safeguard.
+	size := len(tr.ToolOutput)
+	keep := size * p.MaxTokens / total
+	head := int(float64(keep) * headRatio)
+	tail := keep - head
+
+	headText := trimPartialRuneEnd(tr.ToolOutput[:head])
+	tailText := trimPartialRuneStart(tr.ToolOutput[size-tail:])
+	omitted := total - count(headText) - count(tailText)
+
+	notice := fmt.Sprintf("[... %d tokens omitted ...]", omitted)
+	if p.Store != nil {
+		ref, err := p.Store.PutArtifact(ctx, tr.ToolCallID, tr.ToolOutput)
+		if err != nil {
+			return tr, false, fmt.Errorf("store tool output %s: %w", tr.ToolCallID, err)
+		}
+		notice = fmt.Sprintf("[... %d tokens omitted; full output stored as %s ...]", omitted, ref)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(headText) + len(notice) + len(tailText) + 2)
+	sb.WriteString(headText)
+	sb.WriteString("\n")
+	sb.WriteString(notice)
+	sb.WriteString("\n")
+	sb.WriteString(tailText)
+	tr.ToolOutput = sb.String()
+	return tr, true, nil
+}
+
+// estimateTokens approximates the token count of text at four bytes per token.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// trimPartialRuneEnd drops a trailing partial UTF-8 sequence from s.
+func trimPartialRuneEnd(s string) string {
+	for len(s) > 0 {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// trimPartialRuneStart drops a leading partial UTF-8 sequence from s.
+func trimPartialRuneStart(s string) string {
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		s = s[1:]
+	}
+	return s
+}
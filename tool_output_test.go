@@ -0,0 +1,104 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/llmtest"
+	"github.com/codewandler/llm/msg"
+	"github.com/codewandler/llm/tool"
+)
+
+func toolResultMessage(id, output string) msg.Message {
+	return msg.Tool().Results(msg.ToolResults{{ToolCallID: id, ToolOutput: output}}).Build()
+}
+
+func TestToolOutputPolicy_KeepsShortOutput(t *testing.T) {
+	p := llm.ToolOutputPolicy{MaxTokens: 100}
+	in := toolResultMessage("c1", "short")
+	out, err := p.Apply(context.Background(), in)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestToolOutputPolicy_TruncatesHeadAndTail(t *testing.T) {
+	store := llm.NewMemoryArtifactStore()
+	p := llm.ToolOutputPolicy{MaxTokens: 10, Store: store}
+
+	full := "HEAD" + strings.Repeat("x", 400) + "TAIL"
+	in := toolResultMessage("c1", full)
+	out, err := p.Apply(context.Background(), in)
+	require.NoError(t, err)
+
+	got := out.ToolResults()[0].ToolOutput
+	assert.True(t, strings.HasPrefix(got, "HEAD"))
+	assert.True(t, strings.HasSuffix(got, "TAIL"))
+	assert.Contains(t, got, "tokens omitted; full output stored as artifact://c1")
+	assert.Less(t, len(got), len(full))
+
+	// The input message is not modified.
+	assert.Equal(t, full, in.ToolResults()[0].ToolOutput)
+
+	stored, ok := store.Artifact("artifact://c1")
+	require.True(t, ok)
+	assert.Equal(t, full, stored)
+}
+
+func TestToolOutputPolicy_KeepsValidUTF8(t *testing.T) {
+	p := llm.ToolOutputPolicy{MaxTokens: 5, HeadRatio: 0.7}
+	out, err := p.Apply(context.Background(), toolResultMessage("c1", strings.Repeat("äöü€", 50)))
+	require.NoError(t, err)
+	got := out.ToolResults()[0].ToolOutput
+	assert.Contains(t, got, "tokens omitted ...]")
+	assert.True(t, strings.ToValidUTF8(got, "?") == got, "output must stay valid UTF-8")
+}
+
+type failingStore struct{}
+
+func (failingStore) PutArtifact(context.Context, string, string) (string, error) {
+	return "", errors.New("disk full")
+}
+
+func TestToolOutputPolicy_StoreError(t *testing.T) {
+	p := llm.ToolOutputPolicy{MaxTokens: 1, Store: failingStore{}}
+	in := toolResultMessage("c1", strings.Repeat("x", 100))
+	out, err := p.Apply(context.Background(), in)
+	require.ErrorContains(t, err, "disk full")
+	assert.Equal(t, in, out)
+}
+
+func TestRun_TruncatesToolOutput(t *testing.T) {
+	var reqs []llm.Request
+	s := scripted(&reqs,
+		[]llm.Event{
+			llmtest.ToolEvent("call-1", "cat", map[string]any{}),
+			llmtest.CompletedEvent(llm.StopReasonToolUse),
+		},
+		[]llm.Event{
+			llmtest.TextEvent("done"),
+			llmtest.CompletedEvent(llm.StopReasonEndTurn),
+		},
+	)
+	cat := tool.NewHandler("cat", func(context.Context, struct{}) (*string, error) {
+		out := strings.Repeat("line\n", 1000)
+		return &out, nil
+	})
+
+	res, err := llm.Run(context.Background(), s, llm.Request{
+		Model:    "m",
+		Messages: llm.Messages{llm.User("read it")},
+	}, llm.RunOptions{ToolOutput: &llm.ToolOutputPolicy{MaxTokens: 50}}, cat)
+	require.NoError(t, err)
+
+	require.Len(t, reqs, 2)
+	sent := reqs[1].Messages[2].ToolResults()[0].ToolOutput
+	assert.Contains(t, sent, "tokens omitted")
+	assert.Less(t, len(sent), 400)
+	assert.Equal(t, sent, res.Messages[2].ToolResults()[0].ToolOutput)
+}
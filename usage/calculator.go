@@ -1,5 +1,7 @@
 package usage
 
+import "slices"
+
 // CostCalculator computes a Cost for a given provider, model, and token items.
 type CostCalculator interface {
 	// Calculate returns (Cost, true) when pricing is known,
@@ -11,3 +13,61 @@ type CostCalculator interface {
 type CostCalculatorFunc func(provider, model string, tokens TokenItems) (Cost, bool)
 
 func (f CostCalculatorFunc) Calculate(p, m string, t TokenItems) (Cost, bool) { return f(p, m, t) }
+
+// ForProvider returns the cost engine providers price usage records with:
+// the provider-local overrides in order, then the model catalog (Default).
+// Overrides cover models the catalog does not price, or price differently
+// on that provider.
+func ForProvider(overrides ...CostCalculator) CostCalculator {
+	return Compose(append(overrides, Default())...)
+}
+
+// PricingTable is a provider-local price list keyed by wire model ID, in
+// USD per 1M tokens. Keep it to models the catalog does not price, so that
+// catalog updates are not shadowed.
+type PricingTable map[string]Pricing
+
+// Calculator prices the models of provider from the table.
+func (t PricingTable) Calculator(provider string) CostCalculator {
+	return CostCalculatorFunc(func(p, model string, tokens TokenItems) (Cost, bool) {
+		pricing, ok := t[model]
+		if !ok || p != provider {
+			return Cost{}, false
+		}
+		return CalcCost(tokens, pricing), true
+	})
+}
+
+// Shadowed returns the models of t, sorted, that catalog also prices for
+// provider. Such entries hide catalog updates and should be dropped. A nil
+// catalog means Default.
+func (t PricingTable) Shadowed(provider string, catalog CostCalculator) []string {
+	if catalog == nil {
+		catalog = Default()
+	}
+	var out []string
+	for model := range t {
+		if _, ok := catalog.Calculate(provider, model, nil); ok {
+			out = append(out, model)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// CatalogAlias prices the models of provider as catalog entries of another
+// service, for providers that resell models under their own IDs (e.g.
+// Vertex AI's "claude-sonnet-4-5@20250929"). resolve maps a wire model ID to
+// the catalog service and model ID, or reports false.
+func CatalogAlias(provider string, resolve func(model string) (service, id string, ok bool)) CostCalculator {
+	return CostCalculatorFunc(func(p, model string, tokens TokenItems) (Cost, bool) {
+		if p != provider {
+			return Cost{}, false
+		}
+		service, id, ok := resolve(model)
+		if !ok {
+			return Cost{}, false
+		}
+		return Default().Calculate(service, id, tokens)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	modelcatalog "github.com/codewandler/llm/internal/modelcatalog"
+	modeldb "github.com/codewandler/modeldb"
 )
 
 type pricingByModelKey struct {
@@ -35,29 +36,50 @@ type catalogCalc struct {
 }
 
 var (
-	defaultCalc     CostCalculator
-	defaultCalcOnce sync.Once
+	defaultCalcMu  sync.Mutex
+	defaultCalc    *catalogCalc
+	defaultCalcGen uint64
 )
 
+// Default returns the calculator backed by the model catalog. It follows
+// catalog refreshes: the returned calculator always prices against the
+// current catalog (see llm.RefreshModelCatalog).
 func Default() CostCalculator {
-	defaultCalcOnce.Do(func() {
-		defaultCalc = newCatalogCalculator()
+	return CostCalculatorFunc(func(provider, model string, tokens TokenItems) (Cost, bool) {
+		p, ok := CatalogPricing(provider, model)
+		if !ok {
+			return Cost{}, false
+		}
+		return CalcCost(tokens, p), true
 	})
-	return defaultCalc
 }
 
-func newCatalogCalculator() CostCalculator {
-	c, err := modelcatalog.LoadBuiltIn()
-	if err != nil {
-		return CostCalculatorFunc(func(string, string, TokenItems) (Cost, bool) {
-			return Cost{}, false
-		})
+// CatalogPricing returns the model catalog's pricing for model on provider,
+// the rates Default calculates with.
+func CatalogPricing(provider, model string) (Pricing, bool) {
+	return currentCatalogCalculator().lookup(provider, model)
+}
+
+// currentCatalogCalculator returns the calculator for the current catalog,
+// rebuilding it when the catalog has changed since it was built.
+func currentCatalogCalculator() *catalogCalc {
+	defaultCalcMu.Lock()
+	defer defaultCalcMu.Unlock()
+	if gen := modelcatalog.Generation(); defaultCalc == nil || gen != defaultCalcGen {
+		defaultCalc, defaultCalcGen = newCatalogCalculator(), gen
 	}
+	return defaultCalc
+}
 
+func newCatalogCalculator() *catalogCalc {
 	calc := &catalogCalc{
 		byServiceModel: make(map[string]map[string]Pricing),
 		byModelKey:     make(map[pricingByModelKey]Pricing),
 	}
+	c, err := modelcatalog.Load()
+	if err != nil {
+		return calc
+	}
 
 	for ref, offering := range c.Offerings {
 		pricing := offering.Pricing
@@ -74,14 +96,6 @@ func newCatalogCalculator() CostCalculator {
 		if calc.byServiceModel[ref.ServiceID] == nil {
 			calc.byServiceModel[ref.ServiceID] = make(map[string]Pricing)
 		}
-		calc.byServiceModel[ref.ServiceID][ref.WireModelID] = Pricing{
-			Input:       pricing.Input,
-			Output:      pricing.Output,
-			CachedInput: pricing.CachedInput,
-			CacheWrite:  pricing.CacheWrite,
-			Reasoning:   pricing.Reasoning,
-		}
-
 		lineKey := pricingByModelKey{
 			Creator: offering.ModelKey.Creator,
 			Family:  offering.ModelKey.Family,
@@ -89,43 +103,47 @@ func newCatalogCalculator() CostCalculator {
 			Version: offering.ModelKey.Version,
 			Variant: offering.ModelKey.Variant,
 		}
+		p := Pricing{
+			Input:       pricing.Input,
+			Output:      pricing.Output,
+			CachedInput: pricing.CachedInput,
+			CacheWrite:  pricing.CacheWrite,
+			Reasoning:   pricing.Reasoning,
+		}.withLongContext(ref, offering.ModelKey, *pricing)
+		calc.byServiceModel[ref.ServiceID][ref.WireModelID] = p
+
 		if !lineKey.isZero() {
 			if _, exists := calc.byModelKey[lineKey]; !exists {
-				calc.byModelKey[lineKey] = Pricing{
-					Input:       pricing.Input,
-					Output:      pricing.Output,
-					CachedInput: pricing.CachedInput,
-					CacheWrite:  pricing.CacheWrite,
-					Reasoning:   pricing.Reasoning,
-				}
+				calc.byModelKey[lineKey] = p
 			}
 		}
 	}
 
-	return CostCalculatorFunc(func(provider, model string, tokens TokenItems) (Cost, bool) {
-		provider = modelcatalog.CanonicalProvider(provider)
-		providers := []string{provider}
-		if basis := modelcatalog.BasisProvider(provider); basis != "" && basis != provider {
-			providers = append(providers, basis)
-		}
+	return calc
+}
 
-		for _, serviceID := range providers {
-			if byModel, ok := calc.byServiceModel[serviceID]; ok {
-				if p, ok := byModel[model]; ok {
-					return CalcCost(tokens, p), true
-				}
-			}
+func (calc *catalogCalc) lookup(provider, model string) (Pricing, bool) {
+	provider = modelcatalog.CanonicalProvider(provider)
+	providers := []string{provider}
+	if basis := modelcatalog.BasisProvider(provider); basis != "" && basis != provider {
+		providers = append(providers, basis)
+	}
 
-			lineKey := inferPricingModelKey(serviceID, model)
-			if lineKey != (pricingByModelKey{}) {
-				if p, ok := calc.byModelKey[lineKey]; ok {
-					return CalcCost(tokens, p), true
-				}
+	for _, serviceID := range providers {
+		if byModel, ok := calc.byServiceModel[serviceID]; ok {
+			if p, ok := byModel[model]; ok {
+				return p, true
 			}
 		}
 
-		return Cost{}, false
-	})
+		lineKey := inferPricingModelKey(serviceID, model)
+		if lineKey != (pricingByModelKey{}) {
+			if p, ok := calc.byModelKey[lineKey]; ok {
+				return p, true
+			}
+		}
+	}
+	return Pricing{}, false
 }
 
 func inferPricingModelKey(provider, model string) pricingByModelKey {
@@ -223,9 +241,47 @@ type Pricing struct {
 	Reasoning   float64 `json:"reasoning,omitempty"`
 	CachedInput float64 `json:"cached_input,omitempty"`
 	CacheWrite  float64 `json:"cache_write,omitempty"`
+
+	// LongContext, when set, replaces these rates for requests whose prompt
+	// exceeds LongContext.Threshold tokens.
+	LongContext *LongContextPricing `json:"long_context,omitempty"`
+}
+
+// LongContextPricing is a premium rate card billed for the whole request
+// once its prompt (input plus cache reads and writes) exceeds Threshold
+// tokens. Its own LongContext field is ignored.
+type LongContextPricing struct {
+	Threshold int `json:"threshold"`
+	Pricing
 }
 
+// withLongContext attaches the long-context rates modelcatalog knows for the
+// offering ref of model line key, whose base rates are base.
+func (p Pricing) withLongContext(ref modeldb.OfferingRef, key modeldb.ModelKey, base modeldb.Pricing) Pricing {
+	lc, ok := modelcatalog.LongContextPricing(ref, key, base)
+	if !ok {
+		return p
+	}
+	p.LongContext = &LongContextPricing{
+		Threshold: lc.Threshold,
+		Pricing: Pricing{
+			Input:       lc.Pricing.Input,
+			Output:      lc.Pricing.Output,
+			Reasoning:   lc.Pricing.Reasoning,
+			CachedInput: lc.Pricing.CachedInput,
+			CacheWrite:  lc.Pricing.CacheWrite,
+		},
+	}
+	return p
+}
+
+// CalcCost prices items with p, switching to p.LongContext when the prompt
+// exceeds its threshold.
 func CalcCost(items TokenItems, p Pricing) Cost {
+	if lc := p.LongContext; lc != nil && lc.Threshold > 0 && items.TotalInput() > lc.Threshold {
+		p = lc.Pricing
+		p.LongContext = nil
+	}
 	var c Cost
 	for _, item := range items {
 		switch item.Kind {
@@ -1,6 +1,7 @@
 package usage
 
 import (
+	"strings"
 	"testing"
 
 	modeldb "github.com/codewandler/modeldb"
@@ -202,3 +203,94 @@ func TestCompose(t *testing.T) {
 	_ = c1
 	_ = c2
 }
+
+func TestCalcCost_LongContextTier(t *testing.T) {
+	base := modeldb.Pricing{Input: 3, Output: 15, CachedInput: 0.3, CacheWrite: 3.75}
+	p := Pricing{Input: 3, Output: 15, CachedInput: 0.3, CacheWrite: 3.75}.withLongContext(
+		modeldb.OfferingRef{ServiceID: "anthropic", WireModelID: "claude-sonnet-4-5"},
+		modeldb.ModelKey{Creator: "anthropic", Family: "claude", Series: "sonnet", Version: "4.5", ReleaseDate: "2025-09-29"},
+		base)
+	require.NotNil(t, p.LongContext)
+	assert.Equal(t, 200_000, p.LongContext.Threshold)
+
+	// At the threshold the base rates apply.
+	atThreshold := CalcCost(TokenItems{{Kind: KindInput, Count: 150_000}, {Kind: KindCacheRead, Count: 50_000}}, p)
+	assert.InDelta(t, 0.45+0.015, atThreshold.Total, 1e-9)
+
+	// Above it, every token is billed at the premium rates, cache included.
+	long := CalcCost(TokenItems{
+		{Kind: KindInput, Count: 100_000},
+		{Kind: KindCacheRead, Count: 100_000},
+		{Kind: KindCacheWrite, Count: 10_000},
+		{Kind: KindOutput, Count: 1_000},
+	}, p)
+	assert.InDelta(t, 0.6, long.Input, 1e-9)
+	assert.InDelta(t, 0.06, long.CacheRead, 1e-9)
+	assert.InDelta(t, 0.075, long.CacheWrite, 1e-9)
+	assert.InDelta(t, 0.0225, long.Output, 1e-9)
+
+	plain := Pricing{Input: 3}.withLongContext(
+		modeldb.OfferingRef{ServiceID: "openai", WireModelID: "gpt-5"},
+		modeldb.ModelKey{Creator: "openai", Family: "gpt", Version: "5"},
+		modeldb.Pricing{Input: 3})
+	assert.Nil(t, plain.LongContext)
+}
+
+func TestDefault_BedrockLongContext(t *testing.T) {
+	tokens := TokenItems{{Kind: KindInput, Count: 250_000}}
+	cost, ok := Default().Calculate("bedrock", "anthropic.claude-sonnet-4-5-20250929-v1:0", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 1.5, cost.Input, 1e-9, "250k tokens at $6/M")
+
+	cost, ok = Default().Calculate("anthropic", "claude-sonnet-4-20250514", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 1.5, cost.Input, 1e-9)
+}
+
+func TestForProvider_LocalOverridesThenCatalog(t *testing.T) {
+	tokens := TokenItems{{Kind: KindInput, Count: 1_000_000}}
+	table := PricingTable{"local-model": {Input: 7}, "claude-sonnet-4-6": {Input: 1}}
+	calc := ForProvider(table.Calculator("anthropic"))
+
+	cost, ok := calc.Calculate("anthropic", "local-model", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 7.0, cost.Input, 1e-9)
+
+	cost, ok = calc.Calculate("anthropic", "claude-sonnet-4-6", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, cost.Input, 1e-9, "local entries override the catalog")
+
+	cost, ok = calc.Calculate("anthropic", "claude-haiku-4-5-20251001", tokens)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, cost.Input, 1e-9)
+
+	_, ok = table.Calculator("anthropic").Calculate("openai", "local-model", tokens)
+	assert.False(t, ok, "tables only price their own provider")
+}
+
+func TestPricingTable_Shadowed(t *testing.T) {
+	table := PricingTable{"local-model": {Input: 7}, "claude-sonnet-4-6": {Input: 1}, "claude-haiku-4-5-20251001": {Input: 1}}
+	assert.Equal(t, []string{"claude-haiku-4-5-20251001", "claude-sonnet-4-6"}, table.Shadowed("anthropic", nil))
+	assert.Empty(t, table.Shadowed("no-such-provider", nil))
+
+	alias := CatalogAlias("reseller", func(model string) (string, string, bool) { return "anthropic", model, true })
+	assert.Equal(t, []string{"claude-haiku-4-5-20251001", "claude-sonnet-4-6"}, table.Shadowed("reseller", alias))
+}
+
+func TestCatalogAlias(t *testing.T) {
+	calc := CatalogAlias("reseller", func(model string) (string, string, bool) {
+		id, ok := strings.CutPrefix(model, "resold/")
+		return "anthropic", id, ok
+	})
+	tokens := TokenItems{{Kind: KindInput, Count: 1_000_000}}
+
+	cost, ok := calc.Calculate("reseller", "resold/claude-sonnet-4-6", tokens)
+	require.True(t, ok)
+	want, _ := CatalogPricing("anthropic", "claude-sonnet-4-6")
+	assert.InDelta(t, want.Input, cost.Input, 1e-9)
+
+	_, ok = calc.Calculate("reseller", "other", tokens)
+	assert.False(t, ok)
+	_, ok = calc.Calculate("anthropic", "resold/claude-sonnet-4-6", tokens)
+	assert.False(t, ok)
+}
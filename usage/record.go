@@ -97,7 +97,7 @@ type Cost struct {
 	CacheWrite float64 `json:"cache_write,omitempty"`
 
 	// Source describes how the cost was determined.
-	//   "calculated" — via CalcCost from KnownPricing or the built-in catalog
+	//   "calculated" — via CalcCost from the model catalog or a provider table
 	//   "reported"   — API-provided total (OpenRouter)
 	//   "estimated"  — pre-request estimate from CountTokens
 	//   ""           — no pricing available (Ollama local, unknown model)
@@ -124,6 +124,12 @@ type Dims struct {
 }
 
 // Record is a single, fully-attributed usage record.
+//
+// Stability: Tokens, Cost, Dims, IsEstimate, RecordedAt, Source and Encoder
+// are the core fields. They are normalised across providers and follow the
+// module's compatibility guarantees. Details and Extras carry provider-specific
+// data as reported upstream; their keys and shapes follow the provider's API
+// and may change or disappear when the provider changes it.
 type Record struct {
 	Tokens     TokenItems `json:"tokens"`
 	Cost       Cost       `json:"cost"`
@@ -152,4 +158,22 @@ type Record struct {
 	//
 	// nil for estimate records and for providers that return no extras.
 	Extras map[string]any `json:"extras,omitempty"`
+
+	// Details holds usage fields reported by the provider that have no core
+	// equivalent, keyed by the provider's own field names and decoded as
+	// generic JSON values. Examples:
+	//
+	//   OpenRouter: "cost", "is_byok", "cost_details", "prompt_tokens_details"
+	//   Anthropic:  "cache_creation" (per-TTL cache writes), "service_tier",
+	//               "server_tool_use"
+	//   Groq:       "x_groq" -> {"queue_time", "prompt_time", ...}
+	//   Bedrock:    "cache_write_by_ttl" -> map[string]int (TTL -> tokens)
+	//   Ollama:     "client_ttft_ns", "client_eval_ns", "client_total_ns"
+	//               (measured client-side), "client_tokens_per_second"
+	//
+	// Top-level counts already represented in Tokens (input, output, cache
+	// read/write totals) are not repeated here; nested breakdown objects are
+	// kept verbatim. nil when the provider reports nothing beyond the core
+	// fields.
+	Details map[string]any `json:"details,omitempty"`
 }
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// warmCacheUserText is the user turn appended when the prefix does not end
+// with one; Anthropic requires a user message to follow the system prompt.
+const warmCacheUserText = "."
+
+// WarmCache populates the provider-side prompt cache for a long static
+// prefix, typically the system prompt and tool definitions, so the first
+// real turn is served from the cache. It sends prefix with a one-token
+// output limit (raised by providers to their API's minimum, 16 on the
+// OpenAI Responses API) and thinking disabled, marking the last prefix
+// message as a cache breakpoint unless the prefix already carries one.
+// Anthropic and Bedrock cache up to the breakpoint; OpenAI caches the
+// prefix automatically.
+//
+// Later requests must repeat the same model, tools and prefix messages
+// unchanged to hit the cache. The returned Completion carries the usage
+// records, whose cache write tokens show what was cached.
+func WarmCache(ctx context.Context, s Streamer, prefix Buildable) (*Completion, error) {
+	req, err := prefix.BuildRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Messages) == 0 {
+		return nil, errors.New("warm cache: prefix has no messages")
+	}
+
+	msgs := append(Messages(nil), req.Messages...)
+	if !hasCacheBreakpoint(msgs) {
+		last := &msgs[len(msgs)-1]
+		last.CacheHint = &CacheHint{Enabled: true}
+		if req.CacheHint != nil {
+			last.CacheHint.TTL = req.CacheHint.TTL
+		}
+	}
+	if msgs[len(msgs)-1].Role != RoleUser {
+		msgs = append(msgs, User(warmCacheUserText))
+	}
+
+	req.Messages = msgs
+	req.MaxTokens = 1
+	req.Thinking = ThinkingOff
+	req.ThinkingBudget = 0
+	req.Effort = EffortUnspecified
+	req.OutputFormat = ""
+	req.OutputSchema = nil
+	return Complete(ctx, s, req)
+}
+
+func hasCacheBreakpoint(msgs Messages) bool {
+	for _, m := range msgs {
+		if m.CacheHint != nil && m.CacheHint.Enabled {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codewandler/llm"
+	"github.com/codewandler/llm/tool"
+)
+
+func TestWarmCache_MinimalRequestWithBreakpoint(t *testing.T) {
+	s := &recordingStreamer{}
+	tools := []tool.Definition{{Name: "search", Description: "Search the web"}}
+
+	c, err := llm.WarmCache(context.Background(), s, llm.Request{
+		Model:     "m",
+		Messages:  llm.Messages{llm.System("long static prompt")},
+		Tools:     tools,
+		MaxTokens: 4096,
+		Thinking:  llm.ThinkingOn,
+		CacheHint: &llm.CacheHint{Enabled: true, TTL: "1h"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	require.Equal(t, 1, s.calls())
+	req := s.reqs[0]
+	assert.Equal(t, 1, req.MaxTokens)
+	assert.Equal(t, llm.ThinkingOff, req.Thinking)
+	assert.Equal(t, tools, req.Tools)
+
+	require.Len(t, req.Messages, 2)
+	assert.Equal(t, llm.RoleSystem, req.Messages[0].Role)
+	require.NotNil(t, req.Messages[0].CacheHint)
+	assert.True(t, req.Messages[0].CacheHint.Enabled)
+	assert.Equal(t, "1h", req.Messages[0].CacheHint.TTL)
+	assert.Equal(t, llm.RoleUser, req.Messages[1].Role)
+	assert.Nil(t, req.Messages[1].CacheHint)
+}
+
+func TestWarmCache_KeepsExistingBreakpoint(t *testing.T) {
+	s := &recordingStreamer{}
+	prefix := llm.Messages{llm.System("a"), llm.System("b"), llm.User("c")}
+	prefix[0].CacheHint = &llm.CacheHint{Enabled: true}
+
+	_, err := llm.WarmCache(context.Background(), s, llm.Request{Model: "m", Messages: prefix})
+	require.NoError(t, err)
+
+	req := s.reqs[0]
+	require.Len(t, req.Messages, 3)
+	assert.Nil(t, req.Messages[2].CacheHint)
+	assert.Nil(t, prefix[2].CacheHint, "caller's messages must not be modified")
+}
+
+func TestWarmCache_EmptyPrefix(t *testing.T) {
+	_, err := llm.WarmCache(context.Background(), &recordingStreamer{}, llm.Request{Model: "m"})
+	require.Error(t, err)
+}